@@ -4,31 +4,86 @@ package ai
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
 	"hermes/internal/safety"
 )
 
 // GenerateRequest represents a request for command generation
 type GenerateRequest struct {
-	Query   string // Natural language query from user
-	Verbose bool   // Whether to include detailed explanation
+	Query      string // Natural language query from user
+	Verbose    bool   // Whether to include detailed explanation
+	Context    string // Optional local context (e.g., related shell history) for the prompt
+	Target     string // Target shell syntax: "posix" (default) or "cmd"
+	POSIX      bool   // Strict POSIX sh: no bashisms or GNU-only options (posix target only)
+	Candidates int    // Number of alternative commands to ask for; 0 or 1 asks for one
+	Commented  bool   // Ask for a short comment per pipeline stage
+	Baseline   string // Command to adjust as the query asks instead of starting over (e.g., the edited shell buffer)
+	MultiLine  bool   // Allow commands spanning several lines (here-docs, loops); otherwise line breaks are rejected
+	Correction string // Why the previous answer broke the response schema, for the one retry
+	PackageManager string // System package manager (apt, dnf, pacman, zypper, apk or brew); "" when unknown
+	Preset     string // Domain whose curated guidance is added to the prompt (see Presets); "" for none
 }
 
 // GenerateResponse represents the response from AI command generation
 type GenerateResponse struct {
-	Command     string              // Generated shell command
-	SafetyLevel safety.SafetyLevel  // AI's assessment of command safety
-	Reasoning   string              // Optional explanation of the generated command (for --explain-generation flag)
-	Explanation string              // Detailed explanation when verbose mode is requested
+	Command      string             // Generated shell command
+	SafetyLevel  safety.SafetyLevel // AI's assessment of command safety
+	Reasoning    string             // Optional explanation of the generated command (for --explain-generation flag)
+	Explanation  string             // Detailed explanation when verbose mode is requested
+	TokensUsed   int                // Prompt plus response tokens reported by the provider (0 if unknown)
+	Exfiltration bool               // AI's assessment: the command prints or sends credentials
+	Steps        []PlanStep         // Ordered steps when the task needs several commands; Command is the first
+	Placeholders []Placeholder      // Named {placeholders} in Command the user should fill in
+	Undo         string             // How to reverse or recover from an Attention-level command
+	Candidates   []Candidate        // Alternative commands when several were requested; Command is the first
+	Comments     []string           // One comment per part of Command (split at |, &&, || and ;) when requested
+}
+
+// Candidate is one of several alternative commands for a query
+type Candidate struct {
+	Command     string `json:"command" koanf:"command"`
+	Description string `json:"description" koanf:"description"`
+}
+
+// Placeholder is a named value the user fills into a command template
+type Placeholder struct {
+	Name        string `json:"name" koanf:"name"`
+	Default     string `json:"default" koanf:"default"`
+	Description string `json:"description" koanf:"description"`
+}
+
+// PlanStep is one command of a multi-command plan
+type PlanStep struct {
+	Command     string `json:"command" koanf:"command"`
+	Description string `json:"description" koanf:"description"`
 }
 
 // ExplainRequest represents a request for command explanation
 type ExplainRequest struct {
-	Command string // Shell command to explain
+	Command     string   // Shell command to explain
+	ExitCode    *int     // Exit status the command failed with, to interpret instead of the command alone
+	Environment []string // NAME=value of the variables the command references, secrets redacted
+	CompareWith string   // Second command to compare the first with instead of explaining it alone
+	Level       string   // Reader's experience: "beginner", "intermediate" (default) or "expert"
+	Review      bool     // Quick safety review before running the command (e.g., pasted from a web page)
+	Parts       []string // Numbered parts of the command to describe one by one instead of a prose explanation
 }
 
+// Experience levels that shape explanations
+const (
+	LevelBeginner     = "beginner"
+	LevelIntermediate = "intermediate"
+	LevelExpert       = "expert"
+)
+
 // ExplainResponse represents the response from AI command explanation
 type ExplainResponse struct {
 	Explanation string // Human-readable explanation of the command
+	TokensUsed  int    // Prompt plus response tokens reported by the provider (0 if unknown)
 }
 
 // Client interface defines the contract for AI providers
@@ -43,23 +98,75 @@ type Client interface {
 	Close() error
 }
 
+// Pinger is implemented by clients that can verify their credentials and
+// model with a minimal provider call
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
 // Config holds configuration for AI clients
 type Config struct {
 	APIKey       string // API key for the AI provider
 	Model        string // Model name to use (optional)
+	BaseURL      string // Provider endpoint override (e.g., the Ollama server URL)
 	Debug        bool   // Enable debug logging
 	MockResponse string // Mock response for testing
+	MockScenario string // Path to a mock scenario file (JSON or TOML)
+	MockLatency  string // Artificial latency for every mock call (Go duration)
+	MockFault    string // Failure injected into every mock call (see Fault* kinds)
+
+	// Sampling controls; nil keeps the provider default
+	Temperature *float32
+	TopP        *float32
+	Seed        *int32
+
+	// Gemini thinking budget in tokens (0 = off, -1 = dynamic); nil keeps
+	// the model default
+	ThinkingBudget *int32
+
+	// MaxResponseBytes caps the provider's raw HTTP response; 0 is unlimited
+	MaxResponseBytes int
+
+	// Provider-side caching of the static instructions (Gemini only); a nil
+	// store or zero TTL sends them with every request
+	PromptCache    CacheStore
+	PromptCacheTTL time.Duration
+
+	// Transcript captures every prompt and raw answer (--capture); nil
+	// captures nothing
+	Transcript *Transcript
+
+	// HTTPClient overrides the transport used for provider calls (e.g., the
+	// vcr recorder in tests); nil uses the SDK default
+	HTTPClient *http.Client
 }
 
+// CacheStore remembers provider cache names between runs (see
+// promptcache.Store). An empty name records that the provider refused to
+// cache, so it is not asked again until expires.
+type CacheStore interface {
+	Get(key string) (name string, ok bool)
+	Put(key, name string, expires time.Time) error
+}
+
+// Providers lists the provider names NewClient accepts
+var Providers = []string{"gemini", "ollama", "mock"}
+
 // NewClient creates a new AI client based on the provider type
 func NewClient(provider string, config Config) (Client, error) {
 	switch provider {
 	case "gemini":
 		return NewGeminiClient(config)
+	case "ollama":
+		return NewOllamaClient(config)
 	case "mock":
 		return NewMockClient(config)
 	default:
-		// This should never happen since we control the provider parameter
-		return nil, fmt.Errorf("internal error: unknown provider %s", provider)
+		return nil, fmt.Errorf("unknown provider %s (supported: %s)", provider, strings.Join(Providers, ", "))
 	}
-}
\ No newline at end of file
+}
+// debugf prints a --debug line to stderr, keeping stdout to the command
+// and result that callers capture
+func debugf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "DEBUG: "+format, a...)
+}
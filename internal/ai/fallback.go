@@ -0,0 +1,80 @@
+// Package ai - switching to a fallback provider
+package ai
+
+import (
+	"context"
+	"fmt"
+)
+
+// FallbackClient sends calls to its provider and, when a call fails in a
+// way switchOn accepts (such as an exhausted budget), repeats it with a
+// fallback provider. The fallback is only created when it is first needed.
+type FallbackClient struct {
+	Client
+	fallback    func() (Client, error)
+	switchOn    func(err error) bool
+	fallbackFor Client // Created on first use
+}
+
+// NewFallbackClient wraps client so calls failing with an error switchOn
+// accepts go to the client fallback creates
+func NewFallbackClient(client Client, fallback func() (Client, error), switchOn func(err error) bool) *FallbackClient {
+	return &FallbackClient{Client: client, fallback: fallback, switchOn: switchOn}
+}
+
+// GenerateCommand asks the provider, then the fallback if needed
+func (c *FallbackClient) GenerateCommand(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	resp, err := c.Client.GenerateCommand(ctx, req)
+	if err == nil || !c.switchOn(err) {
+		return resp, err
+	}
+	fallback, fallbackErr := c.fallbackClient()
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w; fallback provider unavailable: %v", err, fallbackErr)
+	}
+	return fallback.GenerateCommand(ctx, req)
+}
+
+// ExplainCommand asks the provider, then the fallback if needed
+func (c *FallbackClient) ExplainCommand(ctx context.Context, req ExplainRequest) (*ExplainResponse, error) {
+	resp, err := c.Client.ExplainCommand(ctx, req)
+	if err == nil || !c.switchOn(err) {
+		return resp, err
+	}
+	fallback, fallbackErr := c.fallbackClient()
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w; fallback provider unavailable: %v", err, fallbackErr)
+	}
+	return fallback.ExplainCommand(ctx, req)
+}
+
+// Ping passes through to the provider
+func (c *FallbackClient) Ping(ctx context.Context) error {
+	if pinger, ok := c.Client.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return fmt.Errorf("provider does not support connection tests")
+}
+
+// Close closes the provider and the fallback, if it was created
+func (c *FallbackClient) Close() error {
+	err := c.Client.Close()
+	if c.fallbackFor != nil {
+		if fallbackErr := c.fallbackFor.Close(); err == nil {
+			err = fallbackErr
+		}
+	}
+	return err
+}
+
+// fallbackClient creates the fallback on first use
+func (c *FallbackClient) fallbackClient() (Client, error) {
+	if c.fallbackFor == nil {
+		client, err := c.fallback()
+		if err != nil {
+			return nil, err
+		}
+		c.fallbackFor = client
+	}
+	return c.fallbackFor, nil
+}
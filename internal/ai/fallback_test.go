@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFallbackClient(t *testing.T) {
+	primary, err := NewMockClient(Config{MockResponse: "ls -la"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := 0
+	newFallback := func() (Client, error) {
+		created++
+		return NewMockClient(Config{MockResponse: "ls -1"})
+	}
+	overBudget := func(err error) bool { return errors.Is(err, errOverBudget) }
+	client := NewFallbackClient(NewLimitedClient(primary, &fakeLimiter{remaining: 1}, nil), newFallback, overBudget)
+	defer client.Close()
+
+	resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+	if err != nil || resp.Command != "ls -la" {
+		t.Fatalf("GenerateCommand() = %v, %v, want the provider's answer", resp, err)
+	}
+	if created != 0 {
+		t.Error("fallback created before it was needed")
+	}
+
+	// Over budget: the fallback answers
+	resp, err = client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+	if err != nil || resp.Command != "ls -1" {
+		t.Fatalf("GenerateCommand() over budget = %v, %v, want the fallback's answer", resp, err)
+	}
+	if _, err := client.ExplainCommand(context.Background(), ExplainRequest{Command: "ls"}); err != nil {
+		t.Fatalf("ExplainCommand() over budget error = %v", err)
+	}
+	if created != 1 {
+		t.Errorf("fallback created %d times, want once", created)
+	}
+}
+
+func TestFallbackClientOtherErrors(t *testing.T) {
+	primary, err := NewMockClient(Config{MockResponse: "ls", MockFault: FaultRateLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewFallbackClient(primary, func() (Client, error) {
+		t.Fatal("fallback used for an error it should not handle")
+		return nil, nil
+	}, func(err error) bool { return errors.Is(err, errOverBudget) })
+
+	if _, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"}); err == nil {
+		t.Error("GenerateCommand() succeeded, want the provider's error")
+	}
+}
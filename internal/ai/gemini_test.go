@@ -0,0 +1,324 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hermes/internal/ai/vcr"
+	"hermes/internal/safety"
+)
+
+// newCassetteClient returns a Gemini client backed by a recorded cassette.
+// Set HERMES_VCR_RECORD=1 and GEMINI_API_KEY to re-record it against the
+// live API; the API key is stripped before the cassette is written.
+func newCassetteClient(t *testing.T, name string) *GeminiClient {
+	t.Helper()
+
+	path := filepath.Join("testdata", "cassettes", name+".json")
+	mode, apiKey := vcr.Replay, "test-key"
+	if os.Getenv("HERMES_VCR_RECORD") == "1" {
+		mode, apiKey = vcr.Record, os.Getenv("GEMINI_API_KEY")
+		if apiKey == "" {
+			t.Fatal("HERMES_VCR_RECORD requires GEMINI_API_KEY")
+		}
+	}
+
+	recorder, err := vcr.New(path, mode)
+	if err != nil {
+		t.Fatalf("vcr.New(%s) error = %v", path, err)
+	}
+	t.Cleanup(func() {
+		if err := recorder.Save(); err != nil {
+			t.Errorf("saving cassette: %v", err)
+		}
+	})
+
+	client, err := NewGeminiClient(Config{APIKey: apiKey, HTTPClient: recorder.Client()})
+	if err != nil {
+		t.Fatalf("NewGeminiClient() error = %v", err)
+	}
+	return client
+}
+
+func TestGeminiGenerateCommand(t *testing.T) {
+	tests := []struct {
+		cassette        string
+		query           string
+		verbose         bool
+		wantCommand     string
+		wantSafety      safety.SafetyLevel
+		wantExplanation string
+	}{
+		{"generate_simple", "list all files", false, "ls -la", safety.Safe, "Lists all files"},
+		{"generate_verbose_markdown", "delete logs older than a week", true, "find . -name '*.log' -mtime +7 -delete", safety.Attention, "  • -mtime +7: modified more than 7 days ago\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cassette, func(t *testing.T) {
+			client := newCassetteClient(t, tt.cassette)
+			resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: tt.query, Verbose: tt.verbose})
+			if err != nil {
+				t.Fatalf("GenerateCommand() error = %v", err)
+			}
+			if resp.Command != tt.wantCommand {
+				t.Errorf("Command = %q, want %q", resp.Command, tt.wantCommand)
+			}
+			if resp.SafetyLevel != tt.wantSafety {
+				t.Errorf("SafetyLevel = %v, want %v", resp.SafetyLevel, tt.wantSafety)
+			}
+			if !strings.Contains(resp.Explanation, tt.wantExplanation) {
+				t.Errorf("Explanation = %q, want it to contain %q", resp.Explanation, tt.wantExplanation)
+			}
+		})
+	}
+}
+
+func TestGeminiGenerateCommandAPIError(t *testing.T) {
+	client := newCassetteClient(t, "generate_invalid_key")
+	_, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list all files"})
+	if err == nil || !strings.Contains(err.Error(), "API key not valid") {
+		t.Fatalf("GenerateCommand() error = %v, want API key error", err)
+	}
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.Provider != "gemini" || apiErr.StatusCode != 400 {
+		t.Errorf("GenerateCommand() error = %#v, want a gemini APIError with status 400", err)
+	}
+}
+
+// failingTransport fails every request without touching the network
+type failingTransport struct{ err error }
+
+func (f failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, f.err
+}
+
+func TestGeminiNetworkError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantTimeout bool
+	}{
+		{"dns", &net.DNSError{Err: "no such host", Name: "generativelanguage.googleapis.com", IsNotFound: true}, false},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, false},
+		{"timeout", context.DeadlineExceeded, true},
+	}
+	for _, tt := range tests {
+		client, err := NewGeminiClient(Config{APIKey: "test-key", HTTPClient: &http.Client{Transport: failingTransport{tt.err}}})
+		if err != nil {
+			t.Fatalf("NewGeminiClient() error = %v", err)
+		}
+		_, err = client.GenerateCommand(context.Background(), GenerateRequest{Query: "list all files"})
+		var netErr NetworkError
+		if !errors.As(err, &netErr) || netErr.Provider != "gemini" {
+			t.Errorf("%s: GenerateCommand() error = %#v, want a gemini NetworkError", tt.name, err)
+		}
+		if errors.Is(err, context.DeadlineExceeded) != tt.wantTimeout {
+			t.Errorf("%s: errors.Is(err, DeadlineExceeded) = %v, want %v", tt.name, !tt.wantTimeout, tt.wantTimeout)
+		}
+	}
+
+	// Cancellation is the user's doing, not a network failure
+	client, _ := NewGeminiClient(Config{APIKey: "test-key", HTTPClient: &http.Client{Transport: failingTransport{context.Canceled}}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.GenerateCommand(ctx, GenerateRequest{Query: "list all files"})
+	var netErr NetworkError
+	if !errors.Is(err, context.Canceled) || errors.As(err, &netErr) {
+		t.Errorf("GenerateCommand() after cancel error = %#v, want context.Canceled", err)
+	}
+}
+
+func TestGeminiExplainCommand(t *testing.T) {
+	client := newCassetteClient(t, "explain")
+	resp, err := client.ExplainCommand(context.Background(), ExplainRequest{Command: "tar -czf backup.tgz ."})
+	if err != nil {
+		t.Fatalf("ExplainCommand() error = %v", err)
+	}
+
+	want := "• 'tar' creates a compressed archive.\n  • -c: create\n  • -z: gzip\n  • -f backup.tgz: output file\n"
+	if resp.Explanation != want {
+		t.Errorf("Explanation = %q, want %q", resp.Explanation, want)
+	}
+}
+
+func TestGeminiGenerateConfig(t *testing.T) {
+	client := &GeminiClient{}
+	if cfg := client.generateConfig(); cfg != nil {
+		t.Errorf("generateConfig() = %+v, want nil without sampling controls", cfg)
+	}
+
+	temperature, seed := float32(0), int32(42)
+	client.config = Config{Temperature: &temperature, Seed: &seed}
+	cfg := client.generateConfig()
+	if cfg == nil || *cfg.Temperature != 0 || *cfg.Seed != 42 || cfg.TopP != nil {
+		t.Errorf("generateConfig() = %+v, want temperature 0 and seed 42", cfg)
+	}
+	if cfg.ThinkingConfig != nil {
+		t.Errorf("ThinkingConfig = %+v, want nil without a thinking budget", cfg.ThinkingConfig)
+	}
+
+	budget := int32(0)
+	client.config = Config{ThinkingBudget: &budget}
+	cfg = client.generateConfig()
+	if cfg == nil || cfg.ThinkingConfig == nil || *cfg.ThinkingConfig.ThinkingBudget != 0 {
+		t.Errorf("generateConfig() = %+v, want thinking turned off", cfg)
+	}
+}
+
+func TestBuildGeneratePromptPOSIX(t *testing.T) {
+	strict := buildGeneratePrompt(GenerateRequest{Query: "count lines", POSIX: true}).String()
+	if !strings.Contains(strict, "strict POSIX sh") || !strings.Contains(strict, "grep -P") {
+		t.Errorf("strict POSIX prompt lacks the portability rules:\n%s", strict)
+	}
+	if relaxed := buildGeneratePrompt(GenerateRequest{Query: "count lines"}).String(); strings.Contains(relaxed, "strict POSIX sh") {
+		t.Error("default prompt asks for strict POSIX")
+	}
+}
+
+func TestBuildGeneratePromptPreset(t *testing.T) {
+	for _, name := range PresetNames() {
+		prompt := buildGeneratePrompt(GenerateRequest{Query: "clean up", Preset: name}).String()
+		if !strings.Contains(prompt, "Domain Guidelines ("+name+"):\n"+Presets[name]) {
+			t.Errorf("%s preset prompt lacks its guidance:\n%s", name, prompt)
+		}
+	}
+	if prompt := buildGeneratePrompt(GenerateRequest{Query: "clean up"}).String(); strings.Contains(prompt, "Domain Guidelines") {
+		t.Error("default prompt includes domain guidance")
+	}
+}
+
+func TestParseGenerateTextUndo(t *testing.T) {
+	resp, err := parseGenerateText(`{"command": "git reset --hard", "safety": "ATTENTION", "explanation": "Discard changes", "undo": "git reset --hard HEAD@{1}"}`, false, false)
+	if err != nil {
+		t.Fatalf("parseGenerateText() error = %v", err)
+	}
+	if resp.Undo != "git reset --hard HEAD@{1}" {
+		t.Errorf("Undo = %q, want the model's recovery command", resp.Undo)
+	}
+}
+
+func TestBuildGeneratePromptComments(t *testing.T) {
+	if prompt := buildGeneratePrompt(GenerateRequest{Query: "count errors"}).String(); strings.Contains(prompt, "Comments requested") {
+		t.Error("default prompt requests comments")
+	}
+	prompt := buildGeneratePrompt(GenerateRequest{Query: "count errors", Commented: true}).String()
+	if !strings.HasSuffix(prompt, "User Query: count errors\nComments requested: yes") {
+		t.Errorf("prompt does not request comments after the query:\n%s", prompt[len(prompt)-80:])
+	}
+
+	resp, err := parseGenerateText(`{"command": "grep ERROR app.log | wc -l", "safety": "SAFE", "explanation": "Count errors", "comments": ["find error lines", "count them"]}`, false, false)
+	if err != nil {
+		t.Fatalf("parseGenerateText() error = %v", err)
+	}
+	if len(resp.Comments) != 2 || resp.Comments[1] != "count them" {
+		t.Errorf("Comments = %q, want the model's per-part comments", resp.Comments)
+	}
+}
+
+// memoryCacheStore is a CacheStore without expiry handling
+type memoryCacheStore map[string]string
+
+func (m memoryCacheStore) Get(key string) (string, bool) {
+	name, ok := m[key]
+	return name, ok
+}
+
+func (m memoryCacheStore) Put(key, name string, expires time.Time) error {
+	if !expires.After(time.Now()) {
+		delete(m, key)
+		return nil
+	}
+	m[key] = name
+	return nil
+}
+
+// fakeGemini answers cache creation and generation requests and records
+// the requests it saw
+type fakeGemini struct {
+	refuseCache bool
+	requests    []string // "cache" or the generateContent body
+}
+
+func (f *fakeGemini) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	status, answer := http.StatusOK, `{"candidates": [{"content": {"parts": [{"text": "{\"command\": \"ls\", \"safety\": \"SAFE\", \"explanation\": \"List\"}"}]}}]}`
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/cachedContents"):
+		f.requests = append(f.requests, "cache")
+		answer = `{"name": "cachedContents/abc", "model": "models/gemini-2.5-flash"}`
+		if f.refuseCache {
+			status, answer = http.StatusBadRequest, `{"error": {"code": 400, "message": "Cached content is too small", "status": "INVALID_ARGUMENT"}}`
+		}
+	case strings.Contains(string(body), "cachedContents/gone"):
+		f.requests = append(f.requests, string(body))
+		status, answer = http.StatusNotFound, `{"error": {"code": 404, "message": "CachedContent not found", "status": "NOT_FOUND"}}`
+	default:
+		f.requests = append(f.requests, string(body))
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(answer)),
+		Request:    req,
+	}, nil
+}
+
+func TestGeminiPromptCache(t *testing.T) {
+	newClient := func(fake *fakeGemini, store memoryCacheStore) *GeminiClient {
+		client, err := NewGeminiClient(Config{APIKey: "test-key", HTTPClient: &http.Client{Transport: fake}, PromptCache: store, PromptCacheTTL: time.Hour})
+		if err != nil {
+			t.Fatalf("NewGeminiClient() error = %v", err)
+		}
+		return client
+	}
+	generate := func(client *GeminiClient) {
+		t.Helper()
+		if _, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"}); err != nil {
+			t.Fatalf("GenerateCommand() error = %v", err)
+		}
+	}
+
+	// The instructions are cached once and referenced from then on
+	fake, store := &fakeGemini{}, memoryCacheStore{}
+	client := newClient(fake, store)
+	generate(client)
+	generate(client)
+	if len(fake.requests) != 3 || fake.requests[0] != "cache" {
+		t.Fatalf("requests = %q, want one cache creation and two generations", fake.requests)
+	}
+	for _, body := range fake.requests[1:] {
+		if !strings.Contains(body, `"cachedContent":"cachedContents/abc"`) || strings.Contains(body, "systemInstruction") {
+			t.Errorf("generation does not use the cache:\n%s", body)
+		}
+	}
+
+	// A cache that vanished is forgotten and the instructions sent inline
+	for key := range store {
+		store[key] = "cachedContents/gone"
+	}
+	fake.requests = nil
+	generate(client)
+	if len(fake.requests) != 2 || !strings.Contains(fake.requests[1], "systemInstruction") {
+		t.Errorf("requests = %q, want a retry with inline instructions", fake.requests)
+	}
+	if len(store) != 0 {
+		t.Errorf("store = %v, want the vanished cache forgotten", store)
+	}
+
+	// A refusal is remembered, so the next call does not ask again
+	fake, store = &fakeGemini{refuseCache: true}, memoryCacheStore{}
+	client = newClient(fake, store)
+	generate(client)
+	generate(client)
+	if len(fake.requests) != 3 || fake.requests[0] != "cache" || !strings.Contains(fake.requests[2], "systemInstruction") {
+		t.Errorf("requests = %q, want one refused cache creation and two inline generations", fake.requests)
+	}
+}
@@ -0,0 +1,96 @@
+// Package ai - prompt-injection hardening for untrusted command input
+package ai
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Limits on a valid explanation; anything larger is not an explanation of
+// a single command line
+const (
+	maxExplainSections = 40
+	maxExplainText     = 2000
+)
+
+// ansiEscape matches terminal escape sequences, which can hide text from
+// the user while the model still reads it
+var ansiEscape = regexp.MustCompile(`\x1b(\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[@-Z\\-_])`)
+
+// newDelimiter returns a random tag for fencing untrusted input. The input
+// cannot close the fence early because it cannot guess the tag.
+func newDelimiter() string {
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		// crypto/rand does not fail on supported platforms; a fixed tag
+		// still delimits, it is just guessable
+		return "UNTRUSTED_COMMAND"
+	}
+	return "UNTRUSTED_COMMAND_" + strings.ToUpper(hex.EncodeToString(nonce[:]))
+}
+
+// sanitizeCommandInput removes characters that render invisibly or
+// misleadingly (terminal escapes, control, zero-width and bidi override
+// characters) so the model sees the same text as the user. The command is
+// otherwise passed through; injected instructions stay visible as data.
+func sanitizeCommandInput(command string) string {
+	command = ansiEscape.ReplaceAllString(command, "")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case unicode.IsControl(r):
+			return -1
+		case unicode.Is(unicode.Cf, r):
+			// Format characters: zero-width spaces and joiners, bidi overrides
+			return -1
+		}
+		return r
+	}, command)
+}
+
+// validateExplanation decodes an explain answer strictly: exactly the
+// expected schema, no unknown fields, no text after the JSON, and sane
+// sizes. Anything else means the model did not follow the instructions,
+// possibly because the input hijacked them.
+func validateExplanation(cleanedJSON string) ([]ExplanationSection, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(cleanedJSON)))
+	decoder.DisallowUnknownFields()
+
+	var explainResp struct {
+		Explanation []ExplanationSection `json:"explanation"`
+	}
+	if err := decoder.Decode(&explainResp); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected response: text after the JSON object")
+	}
+
+	sections := explainResp.Explanation
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("unexpected response: no explanation sections")
+	}
+	if len(sections) > maxExplainSections {
+		return nil, fmt.Errorf("unexpected response: %d explanation sections", len(sections))
+	}
+	for i, section := range sections {
+		if strings.TrimSpace(section.Text) == "" {
+			return nil, fmt.Errorf("unexpected response: section %d has no text", i+1)
+		}
+		size := len(section.Text)
+		for _, detail := range section.Details {
+			size += len(detail)
+		}
+		if size > maxExplainText {
+			return nil, fmt.Errorf("unexpected response: section %d is %d bytes long", i+1, size)
+		}
+	}
+	return sections, nil
+}
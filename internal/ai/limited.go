@@ -0,0 +1,107 @@
+// Package ai - client-side request and token budgets
+package ai
+
+import (
+	"context"
+	"fmt"
+)
+
+// Limiter decides whether a provider call may go ahead and accounts for the
+// tokens it used
+type Limiter interface {
+	Allow() error
+	Record(tokens int) error
+}
+
+// LimitedClient wraps a Client so every call is checked against a Limiter
+// first. Calls the limiter refuses never reach the provider.
+type LimitedClient struct {
+	Client
+	limiter Limiter
+	onError func(err error)      // Called when usage could not be recorded
+	approve func(err error) bool // Lets a refused call go ahead anyway
+}
+
+// NewLimitedClient wraps client with limiter; onError may be nil
+func NewLimitedClient(client Client, limiter Limiter, onError func(err error)) *LimitedClient {
+	return &LimitedClient{Client: client, limiter: limiter, onError: onError}
+}
+
+// WithApproval asks approve whether a call the limiter refuses may go ahead
+// anyway, such as after asking the user, and returns the client
+func (c *LimitedClient) WithApproval(approve func(err error) bool) *LimitedClient {
+	c.approve = approve
+	return c
+}
+
+// allow checks the limiter, giving approve the last word
+func (c *LimitedClient) allow() error {
+	err := c.limiter.Allow()
+	if err != nil && c.approve != nil && c.approve(err) {
+		return nil
+	}
+	return err
+}
+
+// GenerateCommand checks the budget, then records the tokens the call used
+func (c *LimitedClient) GenerateCommand(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.GenerateCommand(ctx, req)
+	if err != nil {
+		// Failed calls still count against the request rate
+		c.record(0)
+		return nil, err
+	}
+	tokens := resp.TokensUsed
+	if tokens == 0 {
+		tokens = estimateTokens(req.Query + req.Context + resp.Command + resp.Explanation + resp.Reasoning)
+	}
+	c.record(tokens)
+	return resp, nil
+}
+
+// ExplainCommand checks the budget, then records the tokens the call used
+func (c *LimitedClient) ExplainCommand(ctx context.Context, req ExplainRequest) (*ExplainResponse, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.ExplainCommand(ctx, req)
+	if err != nil {
+		c.record(0)
+		return nil, err
+	}
+	tokens := resp.TokensUsed
+	if tokens == 0 {
+		tokens = estimateTokens(req.Command + resp.Explanation)
+	}
+	c.record(tokens)
+	return resp, nil
+}
+
+// Ping passes through to the wrapped client without touching the budget
+func (c *LimitedClient) Ping(ctx context.Context) error {
+	if pinger, ok := c.Client.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return fmt.Errorf("provider does not support connection tests")
+}
+
+// record accounts for a call. The response is already paid for, so a
+// failure to save the usage is reported but does not fail the call.
+func (c *LimitedClient) record(tokens int) {
+	if err := c.limiter.Record(tokens); err != nil && c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// estimateTokens approximates the token count of text for providers that
+// do not report usage (about four characters per token, plus the prompt
+// template)
+func estimateTokens(text string) int {
+	return len(text)/4 + promptOverheadTokens
+}
+
+// promptOverheadTokens approximates the fixed instructions in every prompt
+const promptOverheadTokens = 500
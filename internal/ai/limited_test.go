@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeLimiter allows a fixed number of calls and remembers what was recorded
+type fakeLimiter struct {
+	remaining int
+	recorded  []int
+}
+
+var errOverBudget = errors.New("over budget")
+
+func (l *fakeLimiter) Allow() error {
+	if l.remaining == 0 {
+		return errOverBudget
+	}
+	l.remaining--
+	return nil
+}
+
+func (l *fakeLimiter) Record(tokens int) error {
+	l.recorded = append(l.recorded, tokens)
+	return nil
+}
+
+func TestLimitedClient(t *testing.T) {
+	mock, err := NewMockClient(Config{MockResponse: "ls -la"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := &fakeLimiter{remaining: 1}
+	client := NewLimitedClient(mock, limiter, nil)
+
+	if _, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"}); err != nil {
+		t.Fatalf("first GenerateCommand() error = %v", err)
+	}
+	if len(limiter.recorded) != 1 || limiter.recorded[0] <= 0 {
+		t.Errorf("recorded = %v, want one estimated token count", limiter.recorded)
+	}
+
+	if _, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"}); !errors.Is(err, errOverBudget) {
+		t.Errorf("second GenerateCommand() error = %v, want the limiter's error", err)
+	}
+	if len(limiter.recorded) != 1 {
+		t.Errorf("a refused call was recorded: %v", limiter.recorded)
+	}
+}
+
+func TestLimitedClientApproval(t *testing.T) {
+	mock, err := NewMockClient(Config{MockResponse: "ls -la"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := &fakeLimiter{}
+	var asked error
+	client := NewLimitedClient(mock, limiter, nil).WithApproval(func(err error) bool {
+		asked = err
+		return true
+	})
+
+	if _, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"}); err != nil {
+		t.Fatalf("approved GenerateCommand() error = %v", err)
+	}
+	if !errors.Is(asked, errOverBudget) {
+		t.Errorf("approval asked about %v, want the limiter's error", asked)
+	}
+	if len(limiter.recorded) != 1 {
+		t.Errorf("recorded = %v, want the approved call counted", limiter.recorded)
+	}
+}
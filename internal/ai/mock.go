@@ -4,50 +4,59 @@ package ai
 import (
 	"context"
 	"fmt"
+	"strings"
+
 	"hermes/internal/safety"
 )
 
 // MockClient implements the Client interface for testing
 type MockClient struct {
-	config         Config
-	staticCommand  string            // Static command from MockResponse flag
-	responseMap    map[string]string // Query -> Command mapping
-	explanationMap map[string]string // Command -> Explanation mapping
+	config        Config
+	staticCommand string    // Static command from MockResponse flag
+	scenario      *Scenario // Query -> command and command -> explanation mappings
 }
 
 // NewMockClient creates a new mock AI client
 func NewMockClient(config Config) (*MockClient, error) {
+	scenario := defaultScenario
+	if config.MockScenario != "" {
+		loaded, err := LoadScenario(config.MockScenario)
+		if err != nil {
+			return nil, err
+		}
+		scenario = *loaded
+	}
+
+	// Flag and config overrides apply on top of the scenario defaults
+	if config.MockLatency != "" {
+		scenario.Latency = config.MockLatency
+	}
+	if config.MockFault != "" {
+		scenario.Kind, scenario.Error, scenario.Status = config.MockFault, "", 0
+	}
+	if err := scenario.Fault.validate(); err != nil {
+		return nil, err
+	}
+
 	return &MockClient{
 		config:        config,
 		staticCommand: config.MockResponse, // Use MockResponse as the static command
-		responseMap: map[string]string{
-			"list files":        "ls -la",
-			"list all files":    "ls -la",
-			"delete everything": "rm -rf /",
-			"install vim":       "sudo apt install vim",
-			"check disk usage":  "df -h",
-			"show processes":    "ps aux",
-			"find python files": "find . -name '*.py'",
-		},
-		explanationMap: map[string]string{
-			"ls -la":              "List all files and directories in long format, including hidden files",
-			"rm -rf /":            "DANGEROUS: Recursively remove all files starting from root directory",
-			"sudo apt install vim": "Install vim text editor using apt package manager with sudo privileges",
-			"df -h":               "Display filesystem disk usage in human-readable format",
-			"ps aux":              "Show all running processes with detailed information",
-			"find . -name '*.py'": "Find all Python files in current directory and subdirectories",
-		},
+		scenario:      &scenario,
 	}, nil
 }
 
 // GenerateCommand generates a shell command from natural language
 func (m *MockClient) GenerateCommand(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
 	if m.config.Debug {
-		fmt.Printf("DEBUG: Mock AI generating command for: %s\n", req.Query)
+		debugf("Mock AI generating command for: %s\n", req.Query)
 	}
 	
 	// Prioritize static command from --mock-response flag
 	if m.staticCommand != "" {
+		if err := m.scenario.Fault.apply(ctx); err != nil {
+			return nil, err
+		}
+		
 		// Determine safety level based on command content
 		safetyLevel := safety.Safe
 		if containsDangerousPatterns(m.staticCommand) {
@@ -67,29 +76,43 @@ func (m *MockClient) GenerateCommand(ctx context.Context, req GenerateRequest) (
 		}, nil
 	}
 	
-	// Check if we have a predefined response
-	if command, exists := m.responseMap[req.Query]; exists {
-		// Determine safety level based on command content
-		safetyLevel := safety.Safe
-		if containsDangerousPatterns(command) {
-			safetyLevel = safety.Attention
+	// Check if the scenario scripts this query
+	entry, exists, err := m.scenario.findGenerate(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if err := entry.withDefaults(m.scenario.Fault).apply(ctx); err != nil {
+			return nil, err
 		}
 		
-		explanation := fmt.Sprintf("Mock explanation for: %s", command)
-		if req.Verbose {
-			explanation = fmt.Sprintf("• '%s' command explanation\n  • This is a predefined mock response\n  • Generated from query: %s", command, req.Query)
+		explanation := entry.Explanation
+		if explanation == "" {
+			explanation = fmt.Sprintf("Mock explanation for: %s", entry.Command)
+			if req.Verbose {
+				explanation = fmt.Sprintf("• '%s' command explanation\n  • This is a predefined mock response\n  • Generated from query: %s", entry.Command, req.Query)
+			}
 		}
 		
 		return &GenerateResponse{
-			Command:     command,
-			SafetyLevel: safetyLevel,
-			Reasoning:   fmt.Sprintf("Mock reasoning for: %s", req.Query),
-			Explanation: explanation,
+			Command:      entry.Command,
+			SafetyLevel:  entry.safetyLevel(),
+			Reasoning:    fmt.Sprintf("Mock reasoning for: %s", req.Query),
+			Explanation:  explanation,
+			Steps:        entry.Steps,
+			Placeholders: entry.Placeholders,
+			Undo:         entry.Undo,
+			Candidates:   entry.candidates(req.Candidates),
+			Comments:     entry.comments(req.Commented),
 		}, nil
 	}
 	
+	if err := m.scenario.Fault.apply(ctx); err != nil {
+		return nil, err
+	}
+	
 	// Default response for unknown queries
-	defaultCommand := fmt.Sprintf("echo 'Mock command for: %s'", req.Query)
+	defaultCommand := fmt.Sprintf("echo 'Mock command for: %s'", strings.ReplaceAll(req.Query, "'", `'\''`))
 	explanation := fmt.Sprintf("Mock explanation for: %s", defaultCommand)
 	if req.Verbose {
 		explanation = fmt.Sprintf("• '%s' default mock command\n  • Generated for unknown query\n  • Query was: %s", defaultCommand, req.Query)
@@ -106,29 +129,48 @@ func (m *MockClient) GenerateCommand(ctx context.Context, req GenerateRequest) (
 // ExplainCommand explains what a shell command does
 func (m *MockClient) ExplainCommand(ctx context.Context, req ExplainRequest) (*ExplainResponse, error) {
 	if m.config.Debug {
-		fmt.Printf("DEBUG: Mock AI explaining command: %s\n", req.Command)
+		debugf("Mock AI explaining command: %s\n", req.Command)
 	}
 
 	// Prioritize static response from --mock-response flag
 	if m.staticCommand != "" {
+		if err := m.scenario.Fault.apply(ctx); err != nil {
+			return nil, err
+		}
 		return &ExplainResponse{
 			Explanation: m.staticCommand,
 		}, nil
 	}
 
-	// Check if we have a predefined explanation
-	if explanation, exists := m.explanationMap[req.Command]; exists {
+	// Check if the scenario scripts this command
+	entry, exists, err := m.scenario.findExplain(req.Command)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if err := entry.withDefaults(m.scenario.Fault).apply(ctx); err != nil {
+			return nil, err
+		}
 		return &ExplainResponse{
-			Explanation: explanation,
+			Explanation: entry.Explanation,
 		}, nil
 	}
 
+	if err := m.scenario.Fault.apply(ctx); err != nil {
+		return nil, err
+	}
+
 	// Default explanation for unknown commands
 	return &ExplainResponse{
 		Explanation: fmt.Sprintf("Mock explanation for command: %s", req.Command),
 	}, nil
 }
 
+// Ping succeeds unless the scenario injects a failure
+func (m *MockClient) Ping(ctx context.Context) error {
+	return m.scenario.Fault.apply(ctx)
+}
+
 // Close cleans up any resources used by the client
 func (m *MockClient) Close() error {
 	// Mock client has no resources to clean up
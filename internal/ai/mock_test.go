@@ -0,0 +1,206 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hermes/internal/safety"
+)
+
+func TestMockScenario(t *testing.T) {
+	for _, name := range []string{"basic.toml", "basic.json"} {
+		t.Run(name, func(t *testing.T) {
+			client, err := NewMockClient(Config{MockScenario: filepath.Join("testdata", "scenarios", name)})
+			if err != nil {
+				t.Fatalf("NewMockClient() error = %v", err)
+			}
+
+			resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "List Files"})
+			if err != nil || resp.Command != "ls -la" {
+				t.Errorf("GenerateCommand(list files) = %v, %v, want ls -la", resp, err)
+			}
+
+			_, err = client.GenerateCommand(context.Background(), GenerateRequest{Query: "over quota"})
+			var apiErr APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+				t.Errorf("GenerateCommand(over quota) error = %v, want APIError with status 429", err)
+			}
+
+			explain, err := client.ExplainCommand(context.Background(), ExplainRequest{Command: "ls -la"})
+			if err != nil || explain.Explanation != "List all files, including hidden ones" {
+				t.Errorf("ExplainCommand(ls -la) = %v, %v", explain, err)
+			}
+		})
+	}
+}
+
+func TestMockScenarioSafetyAndLatency(t *testing.T) {
+	client, err := NewMockClient(Config{MockScenario: filepath.Join("testdata", "scenarios", "basic.toml")})
+	if err != nil {
+		t.Fatalf("NewMockClient() error = %v", err)
+	}
+
+	resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "clean logs"})
+	if err != nil || resp.SafetyLevel != safety.Attention {
+		t.Errorf("GenerateCommand(clean logs) = %v, %v, want Attention", resp, err)
+	}
+
+	start := time.Now()
+	if _, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "slow"}); err != nil {
+		t.Fatalf("GenerateCommand(slow) error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("GenerateCommand(slow) took %v, want at least 50ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GenerateCommand(ctx, GenerateRequest{Query: "slow"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateCommand(slow) with cancelled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestMockScenarioPlan(t *testing.T) {
+	client, err := NewMockClient(Config{MockScenario: filepath.Join("testdata", "scenarios", "basic.toml")})
+	if err != nil {
+		t.Fatalf("NewMockClient() error = %v", err)
+	}
+
+	resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "set up a venv"})
+	if err != nil {
+		t.Fatalf("GenerateCommand(set up a venv) error = %v", err)
+	}
+	if len(resp.Steps) != 3 || resp.Steps[2].Description != "Install the dependencies" {
+		t.Errorf("Steps = %+v, want the three scripted steps", resp.Steps)
+	}
+	if resp.Command != "python3 -m venv .venv" {
+		t.Errorf("Command = %q, want the first step", resp.Command)
+	}
+}
+
+func TestMockScenarioPatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.toml")
+	scenario := `
+[[generate]]
+query_regex = '^delete (?P<ext>\w+) files older than (?P<days>\d+) days$'
+command = "find . -name '*.{{.ext}}' -mtime +{{.days}} -delete"
+
+[[generate]]
+query_glob = "show * in *"
+command = "grep -r '{{index .Groups 1}}' {{index .Groups 2}}"
+explanation = "Search for {{index .Groups 1}}: {{.Query}}"
+
+[[explain]]
+command_glob = "git *"
+explanation = "Run git {{index .Groups 1}}"
+`
+	if err := os.WriteFile(path, []byte(scenario), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewMockClient(Config{MockScenario: path})
+	if err != nil {
+		t.Fatalf("NewMockClient() error = %v", err)
+	}
+
+	tests := []struct {
+		query       string
+		wantCommand string
+	}{
+		{"delete log files older than 7 days", "find . -name '*.log' -mtime +7 -delete"},
+		{"Show TODO in src/", "grep -r 'TODO' src/"},
+		{"delete log files older than a week", "echo 'Mock command for: delete log files older than a week'"},
+	}
+	for _, tt := range tests {
+		resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: tt.query})
+		if err != nil || resp.Command != tt.wantCommand {
+			t.Errorf("GenerateCommand(%q) = %v, %v, want %q", tt.query, resp, err, tt.wantCommand)
+		}
+	}
+
+	resp, _ := client.GenerateCommand(context.Background(), GenerateRequest{Query: "show TODO in src/"})
+	if resp.Explanation != "Search for TODO: show TODO in src/" {
+		t.Errorf("Explanation = %q, want the expanded template", resp.Explanation)
+	}
+	explain, err := client.ExplainCommand(context.Background(), ExplainRequest{Command: "git status"})
+	if err != nil || explain.Explanation != "Run git status" {
+		t.Errorf("ExplainCommand(git status) = %v, %v, want the expanded template", explain, err)
+	}
+}
+
+func TestMockDefaultScenarioPatterns(t *testing.T) {
+	client, err := NewMockClient(Config{})
+	if err != nil {
+		t.Fatalf("NewMockClient() error = %v", err)
+	}
+	for query, want := range map[string]string{
+		"list files":        "ls -la",
+		"Show all files":    "ls -la",
+		"find python files": "find . -name '*.py'",
+		"find go files":     "find . -name '*.go'",
+	} {
+		resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: query})
+		if err != nil || resp.Command != want {
+			t.Errorf("GenerateCommand(%q) = %v, %v, want %q", query, resp, err, want)
+		}
+	}
+}
+
+func TestLoadScenarioRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"bad_latency.json":  `{"generate": [{"query": "x", "command": "y", "latency": "soon"}]}`,
+		"bad_safety.json":   `{"generate": [{"query": "x", "command": "y", "safety": "maybe"}]}`,
+		"no_query.json":     `{"generate": [{"command": "y"}]}`,
+		"two_matches.json":  `{"generate": [{"query": "x", "query_glob": "x*", "command": "y"}]}`,
+		"bad_regex.json":    `{"generate": [{"query_regex": "(", "command": "y"}]}`,
+		"bad_template.json": `{"explain": [{"command_glob": "x*", "explanation": "{{.Command"}]}`,
+		"bad_fault.json":    `{"fault": "explode"}`,
+		"scenario.yaml":     `generate: []`,
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadScenario(path); err == nil {
+			t.Errorf("LoadScenario(%s) succeeded, want error", name)
+		}
+	}
+}
+
+func TestMockFaults(t *testing.T) {
+	tests := []struct {
+		fault      string
+		wantStatus int // APIError status, 0 for other errors
+	}{
+		{FaultRateLimit, 429},
+		{FaultServerError, 503},
+		{FaultMalformed, 0},
+		{FaultTimeout, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fault, func(t *testing.T) {
+			client, err := NewMockClient(Config{MockResponse: "ls", MockFault: tt.fault, MockLatency: "1ms"})
+			if err != nil {
+				t.Fatalf("NewMockClient() error = %v", err)
+			}
+
+			_, err = client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+			if err == nil {
+				t.Fatal("GenerateCommand() succeeded, want injected failure")
+			}
+			var apiErr APIError
+			if tt.wantStatus != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus) {
+				t.Errorf("GenerateCommand() error = %v, want APIError with status %d", err, tt.wantStatus)
+			}
+			if tt.fault == FaultTimeout && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("GenerateCommand() error = %v, want context.DeadlineExceeded", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Package ai - Ollama client for locally hosted models
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hermes/internal/trace"
+)
+
+// DefaultOllamaURL is where a stock Ollama install listens
+const DefaultOllamaURL = "http://localhost:11434"
+
+// OllamaClient implements the Client interface for an Ollama server
+type OllamaClient struct {
+	config Config
+	http   *http.Client
+}
+
+// ollamaRequest is the body of POST /api/generate
+type ollamaRequest struct {
+	Model   string                 `json:"model"`
+	System  string                 `json:"system,omitempty"`
+	Prompt  string                 `json:"prompt"`
+	Stream  bool                   `json:"stream"`
+	Format  string                 `json:"format"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// ollamaResponse is the non-streaming answer of POST /api/generate
+type ollamaResponse struct {
+	Response        string `json:"response"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// NewOllamaClient creates a client for the Ollama server at config.BaseURL
+func NewOllamaClient(config Config) (*OllamaClient, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultOllamaURL
+	}
+	if config.Model == "" {
+		return nil, fmt.Errorf("ollama requires a model (set ollama.model in the config file)")
+	}
+
+	httpClient := limitResponses(config.HTTPClient, config.MaxResponseBytes)
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &OllamaClient{config: config, http: httpClient}, nil
+}
+
+// GenerateCommand generates a shell command from natural language
+func (o *OllamaClient) GenerateCommand(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	return generateValidated(req, func(req GenerateRequest) (*GenerateResponse, error) {
+		text, tokens, err := o.generate(ctx, buildGeneratePrompt(req))
+		if err != nil {
+			return nil, err
+		}
+		result, err := parseGenerateText(text, req.MultiLine, o.config.Debug)
+		if err != nil {
+			return nil, err
+		}
+		result.TokensUsed = tokens
+		return result, nil
+	})
+}
+
+// ExplainCommand explains what a shell command does
+func (o *OllamaClient) ExplainCommand(ctx context.Context, req ExplainRequest) (*ExplainResponse, error) {
+	text, tokens, err := o.generate(ctx, buildExplainPrompt(req))
+	if err != nil {
+		return nil, err
+	}
+	result, err := parseExplainText(text, o.config.Debug)
+	if err != nil {
+		return nil, err
+	}
+	result.TokensUsed = tokens
+	return result, nil
+}
+
+// generate sends a prompt and returns the model's raw answer and the number
+// of tokens it took, adding the call to the transcript when one is being
+// captured
+func (o *OllamaClient) generate(ctx context.Context, prompt chatPrompt) (string, int, error) {
+	exchange := Exchange{Provider: "ollama", Model: o.config.Model, Time: time.Now(), System: prompt.System, User: prompt.User}
+	text, tokens, err := o.send(ctx, prompt)
+	exchange.Response, exchange.Tokens = text, tokens
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+	o.config.Transcript.record(exchange)
+	return text, tokens, err
+}
+
+// send makes the API call
+func (o *OllamaClient) send(ctx context.Context, prompt chatPrompt) (string, int, error) {
+	ctx, span := trace.Start(ctx, "ollama.generate")
+	defer span.End()
+	span.SetAttr("ollama.model", o.config.Model)
+
+	options := map[string]interface{}{}
+	if o.config.Temperature != nil {
+		options["temperature"] = *o.config.Temperature
+	}
+	if o.config.TopP != nil {
+		options["top_p"] = *o.config.TopP
+	}
+	if o.config.Seed != nil {
+		options["seed"] = *o.config.Seed
+	}
+
+	body, err := json.Marshal(ollamaRequest{
+		Model:   o.config.Model,
+		System:  prompt.System,
+		Prompt:  prompt.User,
+		Format:  "json",
+		Options: options,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(o.config.BaseURL, "/")+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.http.Do(httpReq)
+	if err != nil {
+		span.RecordError(err)
+		return "", 0, NetworkError{Provider: "ollama", Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	var sizeErr SizeError
+	if errors.As(err, &sizeErr) {
+		return "", 0, sizeErr
+	}
+	if err != nil {
+		return "", 0, NetworkError{Provider: "ollama", Err: err}
+	}
+
+	var result ollamaResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", 0, APIError{Provider: "ollama", StatusCode: resp.StatusCode, Message: fmt.Sprintf("unexpected response: %s", strings.TrimSpace(string(data)))}
+	}
+	if resp.StatusCode != http.StatusOK || result.Error != "" {
+		apiErr := APIError{Provider: "ollama", StatusCode: resp.StatusCode, Message: result.Error}
+		span.RecordError(apiErr)
+		return "", 0, apiErr
+	}
+	return result.Response, result.PromptEvalCount + result.EvalCount, nil
+}
+
+// Ping checks that the server is reachable and has the model pulled
+func (o *OllamaClient) Ping(ctx context.Context) error {
+	body, _ := json.Marshal(map[string]string{"model": o.config.Model})
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(o.config.BaseURL, "/")+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := o.http.Do(httpReq)
+	if err != nil {
+		return NetworkError{Provider: "ollama", Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var result ollamaResponse
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &result) != nil || result.Error == "" {
+			result.Error = strings.TrimSpace(string(data))
+		}
+		return APIError{Provider: "ollama", StatusCode: resp.StatusCode, Message: result.Error}
+	}
+	return nil
+}
+
+// Close cleans up any resources used by the client
+func (o *OllamaClient) Close() error {
+	return nil
+}
+
+// IsLocalEndpoint reports whether a provider URL points at this machine
+// (a loopback address or localhost)
+func IsLocalEndpoint(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
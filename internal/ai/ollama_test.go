@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOllamaGenerateCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/api/generate" {
+			http.Error(w, `{"error": "bad request"}`, http.StatusBadRequest)
+			return
+		}
+		if req.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "model 'missing' not found"}`))
+			return
+		}
+		if req.Format != "json" || req.Stream || req.Options["seed"] != float64(7) || req.Prompt != "User Query: list files" || req.System == "" {
+			t.Errorf("unexpected request %+v", req)
+		}
+		json.NewEncoder(w).Encode(ollamaResponse{Response: `{"command": "ls -la", "safety": "SAFE", "explanation": "Lists files"}`})
+	}))
+	defer server.Close()
+
+	seed := int32(7)
+	client, err := NewOllamaClient(Config{BaseURL: server.URL, Model: "qwen", Seed: &seed})
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+	resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+	if err != nil || resp.Command != "ls -la" {
+		t.Fatalf("GenerateCommand() = %+v, %v, want ls -la", resp, err)
+	}
+
+	client.config.Model = "missing"
+	_, err = client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+	if apiErr, ok := err.(APIError); !ok || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("GenerateCommand() error = %v, want 404 APIError", err)
+	}
+}
+
+func TestIsLocalEndpoint(t *testing.T) {
+	tests := map[string]bool{
+		"http://localhost:11434":     true,
+		"http://127.0.0.1:11434":     true,
+		"http://[::1]:11434":         true,
+		"http://10.0.0.5:11434":      false,
+		"https://ollama.example.com": false,
+		"http://localhost.evil.com":  false,
+		"not a url":                  false,
+	}
+	for url, want := range tests {
+		if got := IsLocalEndpoint(url); got != want {
+			t.Errorf("IsLocalEndpoint(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package ai - curated domain guidance for generation presets
+package ai
+
+import "sort"
+
+// Presets maps preset names to guidance added to the generate prompt for
+// that domain: preferred flags, current subcommands and common pitfalls
+var Presets = map[string]string{
+	"docker": `- Use "docker compose" (the v2 plugin), not the old "docker-compose" binary
+- Prefer the explicit object subcommands: docker container ls, docker image prune, docker volume rm
+- Name containers with --name and clean up one-off containers with --rm
+- docker system prune and "prune -a" delete stopped containers, unused images and (with --volumes) data: ATTENTION
+- Bind mounts need absolute paths; use "$(pwd)" rather than a relative path
+- Filter with --filter and format output with --format '{{.Names}}' instead of parsing tables with awk`,
+
+	"git": `- Use git switch and git restore, not the overloaded git checkout
+- Prefer --force-with-lease over --force when pushing rewritten history
+- git reset --hard, git clean -fd and git push --force discard work: ATTENTION
+- Use git log --oneline --graph, and --since/--author for filtering, instead of piping into grep
+- Refer to the default branch by name from the query; do not assume master or main
+- Use git stash push -m "<message>", not the deprecated git stash save`,
+
+	"ffmpeg": `- Put -i before output options and -ss before -i for fast seeking
+- Copy streams with -c copy when no re-encoding is needed; re-encode video with -c:v libx264 -crf 23 -preset medium and audio with -c:a aac
+- Add -movflags +faststart for MP4 files meant for the web
+- Use -map to pick streams explicitly when inputs have several
+- Scale with -vf "scale=1280:-2" so the height stays even
+- ffmpeg asks before overwriting; -y overwrites the output without asking: ATTENTION
+- Use ffprobe -v error -show_entries ... -of csv=p=0 to read media properties`,
+
+	"k8s": `- Always pass -n <namespace> (or --all-namespaces when asked) instead of relying on the current namespace
+- Prefer kubectl get -o wide, -o jsonpath or -o yaml over parsing table output
+- Use kubectl logs -f --tail=100 and -c <container> for pods with several containers
+- kubectl delete, scale to zero, drain, rollout restart and apply against production contexts change the cluster: ATTENTION
+- Use kubectl rollout status/undo for deployments and kubectl diff before kubectl apply
+- Use kubectl debug or kubectl exec -it <pod> -- sh rather than editing running pods`,
+
+	"networking": `- Use ip (ip addr, ip route, ip link) and ss, not the deprecated ifconfig, route and netstat
+- Use ss -tulpn to list listening sockets with their processes
+- Prefer dig +short (or resolvectl query) for DNS lookups and curl -sS -o /dev/null -w '%{http_code}' for HTTP checks
+- Bound scans and probes: ping -c 4, nc -z -w 2, timeout with curl --max-time
+- Changing addresses, routes, firewall rules (iptables, nft, ufw) or bringing links down can cut off the session: ATTENTION
+- Scanning hosts with nmap is only for networks the user controls`,
+}
+
+// PresetNames returns the preset names in order
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
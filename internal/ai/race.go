@@ -0,0 +1,114 @@
+// Package ai - racing several providers
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hermes/internal/trace"
+)
+
+// RaceClient sends every request to all of its providers at once and
+// answers with the first valid response, cancelling the others. It trades
+// extra provider calls for the latency of the fastest provider and the
+// reliability of any of them.
+type RaceClient struct {
+	names   []string
+	clients []Client
+	onWin   func(name string) // Called with the provider that answered first
+}
+
+// NewRaceClient races clients, named by names in the same order; onWin may
+// be nil
+func NewRaceClient(names []string, clients []Client, onWin func(name string)) *RaceClient {
+	return &RaceClient{names: names, clients: clients, onWin: onWin}
+}
+
+// GenerateCommand returns the first response with a command
+func (c *RaceClient) GenerateCommand(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	return race(ctx, c, func(ctx context.Context, client Client) (*GenerateResponse, error) {
+		resp, err := client.GenerateCommand(ctx, req)
+		if err == nil && strings.TrimSpace(resp.Command) == "" {
+			err = fmt.Errorf("empty command")
+		}
+		return resp, err
+	})
+}
+
+// ExplainCommand returns the first response with an explanation
+func (c *RaceClient) ExplainCommand(ctx context.Context, req ExplainRequest) (*ExplainResponse, error) {
+	return race(ctx, c, func(ctx context.Context, client Client) (*ExplainResponse, error) {
+		resp, err := client.ExplainCommand(ctx, req)
+		if err == nil && strings.TrimSpace(resp.Explanation) == "" {
+			err = fmt.Errorf("empty explanation")
+		}
+		return resp, err
+	})
+}
+
+// Ping succeeds when any provider answers
+func (c *RaceClient) Ping(ctx context.Context) error {
+	_, err := race(ctx, c, func(ctx context.Context, client Client) (struct{}, error) {
+		pinger, ok := client.(Pinger)
+		if !ok {
+			return struct{}{}, fmt.Errorf("provider does not support connection tests")
+		}
+		return struct{}{}, pinger.Ping(ctx)
+	})
+	return err
+}
+
+// Close closes every provider
+func (c *RaceClient) Close() error {
+	var errs []error
+	for _, client := range c.clients {
+		errs = append(errs, client.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// race runs call against every client concurrently and returns the first
+// success. When all fail, the errors are joined in provider order, so
+// errors.As finds the first provider's failure first.
+func race[T any](ctx context.Context, c *RaceClient, call func(ctx context.Context, client Client) (T, error)) (T, error) {
+	ctx, span := trace.Start(ctx, "ai.race")
+	defer span.End()
+	span.SetAttr("race.providers", strings.Join(c.names, ","))
+
+	// The losers are cancelled once there is a winner
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		index int
+		value T
+		err   error
+	}
+	results := make(chan result, len(c.clients)) // Buffered, so losers never block
+	for i, client := range c.clients {
+		go func() {
+			value, err := call(ctx, client)
+			results <- result{index: i, value: value, err: err}
+		}()
+	}
+
+	errs := make([]error, len(c.clients))
+	for range c.clients {
+		r := <-results
+		if r.err == nil {
+			span.SetAttr("race.winner", c.names[r.index])
+			if c.onWin != nil {
+				c.onWin(c.names[r.index])
+			}
+			return r.value, nil
+		}
+		errs[r.index] = r.err
+	}
+
+	var zero T
+	err := errors.Join(errs...)
+	span.RecordError(err)
+	return zero, err
+}
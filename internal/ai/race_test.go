@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRaceMock returns a mock client for config
+func newRaceMock(t *testing.T, config Config) Client {
+	t.Helper()
+	client, err := NewMockClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestRaceClientFirstValidAnswerWins(t *testing.T) {
+	slow := newRaceMock(t, Config{MockResponse: "ls -la", MockLatency: "5s"})
+	failing := newRaceMock(t, Config{MockResponse: "ls", MockFault: FaultRateLimit})
+	fast := newRaceMock(t, Config{MockResponse: "ls -1", MockLatency: "20ms"})
+
+	var winner string
+	client := NewRaceClient([]string{"slow", "failing", "fast"}, []Client{slow, failing, fast}, func(name string) { winner = name })
+	started := time.Now()
+	resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+	if err != nil {
+		t.Fatalf("GenerateCommand() error = %v", err)
+	}
+	if resp.Command != "ls -1" || winner != "fast" {
+		t.Errorf("GenerateCommand() = %q from %q, want the fast provider's answer", resp.Command, winner)
+	}
+	// The slow provider was cancelled rather than waited for
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("race took %v, want the fast provider's latency", elapsed)
+	}
+}
+
+func TestRaceClientAllFail(t *testing.T) {
+	rateLimited := newRaceMock(t, Config{MockResponse: "ls", MockFault: FaultRateLimit})
+	overloaded := newRaceMock(t, Config{MockResponse: "ls", MockFault: FaultServerError})
+	client := NewRaceClient([]string{"a", "b"}, []Client{rateLimited, overloaded}, nil)
+
+	_, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Errorf("GenerateCommand() error = %v, want the first provider's error first", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "overloaded") {
+		t.Errorf("GenerateCommand() error = %v, want both providers' errors", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Package ai - secret redaction for outgoing requests
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"hermes/internal/redact"
+)
+
+// RedactingClient wraps a Client so credentials in queries, context and
+// commands never reach the provider. Secrets are replaced by placeholders
+// and restored in the generated command, so the user still gets a working
+// command; explanations keep the placeholders.
+type RedactingClient struct {
+	Client
+	onRedact func(count int) // Called with the number of secrets redacted, if any
+}
+
+// NewRedactingClient wraps client; onRedact may be nil
+func NewRedactingClient(client Client, onRedact func(count int)) *RedactingClient {
+	return &RedactingClient{Client: client, onRedact: onRedact}
+}
+
+// GenerateCommand redacts the query and context, then restores secrets in
+// the generated command
+func (c *RedactingClient) GenerateCommand(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	r := redact.New()
+	req.Query = r.Redact(req.Query)
+	req.Context = r.Redact(req.Context)
+	req.Baseline = r.Redact(req.Baseline)
+	c.report(r)
+
+	resp, err := c.Client.GenerateCommand(ctx, req)
+	if err != nil || r.Count() == 0 {
+		return resp, err
+	}
+	restored := *resp
+	restored.Command = r.Restore(resp.Command)
+	restored.Undo = r.Restore(resp.Undo)
+	restored.Candidates = nil
+	for _, candidate := range resp.Candidates {
+		candidate.Command = r.Restore(candidate.Command)
+		restored.Candidates = append(restored.Candidates, candidate)
+	}
+	restored.Comments = nil
+	for _, comment := range resp.Comments {
+		restored.Comments = append(restored.Comments, r.Restore(comment))
+	}
+	return &restored, nil
+}
+
+// ExplainCommand redacts the command before asking for an explanation
+func (c *RedactingClient) ExplainCommand(ctx context.Context, req ExplainRequest) (*ExplainResponse, error) {
+	r := redact.New()
+	req.Command = r.Redact(req.Command)
+	req.CompareWith = r.Redact(req.CompareWith)
+	parts := make([]string, len(req.Parts))
+	for i, part := range req.Parts {
+		parts[i] = r.Redact(part)
+	}
+	req.Parts = parts
+	environment := make([]string, len(req.Environment))
+	for i, variable := range req.Environment {
+		if name, value, ok := strings.Cut(variable, "="); ok {
+			environment[i] = name + "=" + r.Redact(value)
+		} else {
+			environment[i] = r.Redact(variable)
+		}
+	}
+	req.Environment = environment
+	c.report(r)
+	return c.Client.ExplainCommand(ctx, req)
+}
+
+// Ping passes through to the wrapped client when it supports it
+func (c *RedactingClient) Ping(ctx context.Context) error {
+	if pinger, ok := c.Client.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return fmt.Errorf("provider does not support connection tests")
+}
+
+// report notifies the caller when anything was redacted
+func (c *RedactingClient) report(r *redact.Redactor) {
+	if c.onRedact != nil && r.Count() > 0 {
+		c.onRedact(r.Count())
+	}
+}
@@ -0,0 +1,497 @@
+// Package ai - scripted scenarios for the mock client
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"hermes/internal/safety"
+)
+
+// Scenario scripts the mock client's behavior: which command each query
+// produces, which explanation each command gets, and where to inject
+// errors or latency. Scenarios are JSON or TOML files:
+//
+//	[[generate]]
+//	query = "list files"
+//	command = "ls -la"
+//
+//	[[generate]]
+//	query = "slow query"
+//	latency = "3s"
+//	error = "quota exceeded"
+//	status = 429
+//
+//	[[generate]]
+//	query = "garbled"
+//	fault = "malformed"
+//
+//	[[generate]]
+//	query = "set up a venv"
+//	steps = [
+//	  { command = "python3 -m venv .venv", description = "Create the environment" },
+//	  { command = "pip install -r requirements.txt", description = "Install dependencies" },
+//	]
+//
+//	[[generate]]
+//	query = "archive a folder"
+//	command = "tar -czf {archive_name}.tar.gz {directory}"
+//	placeholders = [{ name = "archive_name", default = "backup" }, { name = "directory" }]
+//
+//	[[generate]]
+//	query = "drop local changes"
+//	command = "git reset --hard"
+//	undo = "git reset --hard HEAD@{1} (see git reflog)"
+//
+//	[[generate]]
+//	query = "delete temp files"
+//	candidates = [
+//	  { command = "find . -name '*.tmp' -delete", description = "Delete in place" },
+//	  { command = "find . -name '*.tmp' -print", description = "List them first" },
+//	]
+//
+//	[[generate]]
+//	query = "count errors"
+//	command = "grep -h ERROR *.log | sort | uniq -c"
+//	comments = ["find error lines", "group identical lines", "count each group"]
+//
+//	[[explain]]
+//	command = "ls -la"
+//	explanation = "List all files in long format"
+//
+// Instead of an exact query (or command), an entry can match a shell-style
+// glob, where * and ? match any text, or a regular expression. The command,
+// explanation and undo hint of such entries are Go templates over the
+// query ({{.Query}}, or {{.Command}} for explain entries) and the matched
+// groups: {{index .Groups 1}} for the first, or {{.name}} for (?P<name>...).
+//
+//	[[generate]]
+//	query_glob = "find * files"
+//	command = "find . -name '*.{{index .Groups 1}}'"
+//
+//	[[generate]]
+//	query_regex = '^delete (?P<ext>\w+) files older than (?P<days>\d+) days$'
+//	command = "find . -name '*.{{.ext}}' -mtime +{{.days}} -delete"
+//
+//	[[explain]]
+//	command_glob = "git *"
+//	explanation = "Run the git subcommand {{index .Groups 1}}"
+//
+// Entries are tried in order and the first match wins. Exact queries and
+// globs ignore case.
+//
+// Top-level latency and fault settings apply to every call whose entry does
+// not set its own, including unscripted queries.
+type Scenario struct {
+	Fault    `koanf:",squash"`
+	Generate []GenerateEntry `json:"generate" koanf:"generate"`
+	Explain  []ExplainEntry  `json:"explain" koanf:"explain"`
+}
+
+// Fault kinds simulating common provider failures
+const (
+	FaultTimeout     = "timeout"      // Hang until the latency (or 30s) elapses, then time out
+	FaultRateLimit   = "rate_limit"   // HTTP 429 quota error
+	FaultServerError = "server_error" // HTTP 503 overloaded model
+	FaultMalformed   = "malformed"    // Truncated JSON in the model response
+)
+
+// defaultTimeout is how long a timeout fault hangs without an explicit latency
+const defaultTimeout = 30 * time.Second
+
+// Fault describes an injected delay or error shared by scenario entries
+type Fault struct {
+	Latency string `json:"latency" koanf:"latency"` // Go duration, e.g. "250ms"
+	Kind    string `json:"fault" koanf:"fault"`     // One of the Fault* kinds
+	Error   string `json:"error" koanf:"error"`     // Return this error instead of a response
+	Status  int    `json:"status" koanf:"status"`   // HTTP status reported with the error
+}
+
+// GenerateEntry maps a query to a generated command
+type GenerateEntry struct {
+	Fault        `koanf:",squash"`
+	Query        string        `json:"query" koanf:"query"`
+	QueryGlob    string        `json:"query_glob" koanf:"query_glob"`   // Shell-style pattern, e.g. "find * files"
+	QueryRegex   string        `json:"query_regex" koanf:"query_regex"` // Regular expression; its groups feed the templates
+	Command      string        `json:"command" koanf:"command"`
+	Safety       string        `json:"safety" koanf:"safety"` // "safe" or "attention"; derived from the command when empty
+	Explanation  string        `json:"explanation" koanf:"explanation"`
+	Steps        []PlanStep    `json:"steps" koanf:"steps"` // Multi-command plan; Command defaults to the first step
+	Placeholders []Placeholder `json:"placeholders" koanf:"placeholders"`
+	Undo         string        `json:"undo" koanf:"undo"`             // Recovery hint for Attention-level commands
+	Candidates   []Candidate   `json:"candidates" koanf:"candidates"` // Alternatives returned when several are requested
+	Comments     []string      `json:"comments" koanf:"comments"`     // Per-part comments returned when requested
+
+	pattern *regexp.Regexp // Compiled QueryGlob or QueryRegex
+}
+
+// ExplainEntry maps a command to its explanation
+type ExplainEntry struct {
+	Fault        `koanf:",squash"`
+	Command      string `json:"command" koanf:"command"`
+	CommandGlob  string `json:"command_glob" koanf:"command_glob"`
+	CommandRegex string `json:"command_regex" koanf:"command_regex"`
+	Explanation  string `json:"explanation" koanf:"explanation"`
+
+	pattern *regexp.Regexp // Compiled CommandGlob or CommandRegex
+}
+
+// defaultScenario is used when no scenario file is given
+var defaultScenario = mustValidate(Scenario{
+	Generate: []GenerateEntry{
+		{QueryRegex: `(?i)^(list|show)( all)? files$`, Command: "ls -la"},
+		{Query: "delete everything", Command: "rm -rf /"},
+		{Query: "install vim", Command: "sudo apt install vim"},
+		{Query: "check disk usage", Command: "df -h"},
+		{Query: "show processes", Command: "ps aux"},
+		{Query: "find python files", Command: "find . -name '*.py'"},
+		{QueryGlob: "find * files", Command: "find . -name '*.{{index .Groups 1}}'"},
+	},
+	Explain: []ExplainEntry{
+		{Command: "ls -la", Explanation: "List all files and directories in long format, including hidden files"},
+		{Command: "rm -rf /", Explanation: "DANGEROUS: Recursively remove all files starting from root directory"},
+		{Command: "sudo apt install vim", Explanation: "Install vim text editor using apt package manager with sudo privileges"},
+		{Command: "df -h", Explanation: "Display filesystem disk usage in human-readable format"},
+		{Command: "ps aux", Explanation: "Show all running processes with detailed information"},
+		{Command: "find . -name '*.py'", Explanation: "Find all Python files in current directory and subdirectories"},
+	},
+})
+
+// mustValidate validates and compiles a built-in scenario
+func mustValidate(s Scenario) Scenario {
+	if err := s.validate(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// LoadScenario reads a JSON or TOML scenario file, chosen by extension
+func LoadScenario(path string) (*Scenario, error) {
+	var scenario Scenario
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenario: %w", err)
+		}
+		if err := json.Unmarshal(data, &scenario); err != nil {
+			return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+		}
+	case ".toml":
+		k := koanf.New(".")
+		if err := k.Load(file.Provider(path), toml.Parser()); err != nil {
+			return nil, fmt.Errorf("failed to read scenario: %w", err)
+		}
+		if err := k.Unmarshal("", &scenario); err != nil {
+			return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scenario format %q (use .json or .toml)", filepath.Ext(path))
+	}
+
+	if err := scenario.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	for i, entry := range scenario.Generate {
+		if entry.Command == "" && len(entry.Steps) > 0 {
+			scenario.Generate[i].Command = entry.Steps[0].Command
+		}
+		if entry.Command == "" && len(entry.Candidates) > 0 {
+			scenario.Generate[i].Command = entry.Candidates[0].Command
+		}
+	}
+	return &scenario, nil
+}
+
+// validate checks durations and safety values up front so a typo fails
+// loudly instead of silently changing test behavior
+func (s *Scenario) validate() error {
+	faults := []Fault{s.Fault}
+	for i, entry := range s.Generate {
+		pattern, err := compilePattern(entry.Query, entry.QueryGlob, entry.QueryRegex)
+		if err != nil {
+			return fmt.Errorf("generate entry %d: %w", i+1, err)
+		}
+		if entry.Query == "" && pattern == nil {
+			return fmt.Errorf("generate entry %d has no query", i+1)
+		}
+		switch strings.ToLower(entry.Safety) {
+		case "", "safe", "attention":
+		default:
+			return fmt.Errorf("generate entry %d has unknown safety %q", i+1, entry.Safety)
+		}
+		if pattern != nil {
+			for _, text := range []string{entry.Command, entry.Explanation, entry.Undo} {
+				if _, err := parseTemplate(text); err != nil {
+					return fmt.Errorf("generate entry %d: %w", i+1, err)
+				}
+			}
+		}
+		s.Generate[i].pattern = pattern
+		faults = append(faults, entry.Fault)
+	}
+	for i, entry := range s.Explain {
+		pattern, err := compilePattern(entry.Command, entry.CommandGlob, entry.CommandRegex)
+		if err != nil {
+			return fmt.Errorf("explain entry %d: %w", i+1, err)
+		}
+		if entry.Command == "" && pattern == nil {
+			return fmt.Errorf("explain entry %d has no command", i+1)
+		}
+		if pattern != nil {
+			if _, err := parseTemplate(entry.Explanation); err != nil {
+				return fmt.Errorf("explain entry %d: %w", i+1, err)
+			}
+		}
+		s.Explain[i].pattern = pattern
+		faults = append(faults, entry.Fault)
+	}
+	for _, fault := range faults {
+		if err := fault.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks the latency and fault kind
+func (f Fault) validate() error {
+	switch f.Kind {
+	case "", FaultTimeout, FaultRateLimit, FaultServerError, FaultMalformed:
+	default:
+		return fmt.Errorf("unknown fault %q (use %s, %s, %s or %s)", f.Kind, FaultTimeout, FaultRateLimit, FaultServerError, FaultMalformed)
+	}
+	if f.Latency != "" {
+		if _, err := time.ParseDuration(f.Latency); err != nil {
+			return fmt.Errorf("invalid latency %q: %w", f.Latency, err)
+		}
+	}
+	return nil
+}
+
+// findGenerate returns the entry for a query, if any, with the templates
+// of a pattern entry expanded
+func (s *Scenario) findGenerate(query string) (GenerateEntry, bool, error) {
+	query = strings.TrimSpace(query)
+	for _, entry := range s.Generate {
+		if entry.pattern == nil {
+			if strings.EqualFold(entry.Query, query) {
+				return entry, true, nil
+			}
+			continue
+		}
+		data, ok := matchPattern(entry.pattern, "Query", query)
+		if !ok {
+			continue
+		}
+		var err error
+		for _, text := range []*string{&entry.Command, &entry.Explanation, &entry.Undo} {
+			if *text, err = expandTemplate(*text, data); err != nil {
+				return GenerateEntry{}, false, fmt.Errorf("mock scenario entry for %q: %w", query, err)
+			}
+		}
+		return entry, true, nil
+	}
+	return GenerateEntry{}, false, nil
+}
+
+// findExplain returns the entry for a command, if any, with the template
+// of a pattern entry expanded
+func (s *Scenario) findExplain(command string) (ExplainEntry, bool, error) {
+	command = strings.TrimSpace(command)
+	for _, entry := range s.Explain {
+		if entry.pattern == nil {
+			if entry.Command == command {
+				return entry, true, nil
+			}
+			continue
+		}
+		data, ok := matchPattern(entry.pattern, "Command", command)
+		if !ok {
+			continue
+		}
+		var err error
+		if entry.Explanation, err = expandTemplate(entry.Explanation, data); err != nil {
+			return ExplainEntry{}, false, fmt.Errorf("mock scenario entry for %q: %w", command, err)
+		}
+		return entry, true, nil
+	}
+	return ExplainEntry{}, false, nil
+}
+
+// compilePattern compiles an entry's glob or regular expression. It
+// returns nil for entries matched exactly, and an error when an entry sets
+// more than one way to match.
+func compilePattern(exact, glob, expr string) (*regexp.Regexp, error) {
+	set := 0
+	for _, s := range []string{exact, glob, expr} {
+		if s != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("set only one of the exact text, the glob and the regex")
+	}
+	switch {
+	case glob != "":
+		return regexp.MustCompile(globExpr(glob)), nil
+	case expr != "":
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+		}
+		return re, nil
+	}
+	return nil, nil
+}
+
+// globExpr translates a glob into an anchored, case-insensitive regular
+// expression where each * and ? is a group
+func globExpr(glob string) string {
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			expr.WriteString("(.*)")
+		case '?':
+			expr.WriteString("(.)")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String()
+}
+
+// matchPattern matches text and returns the template data: the text under
+// key, the groups as Groups (0 is the whole match) and each named group
+func matchPattern(pattern *regexp.Regexp, key, text string) (map[string]any, bool) {
+	groups := pattern.FindStringSubmatch(text)
+	if groups == nil {
+		return nil, false
+	}
+	data := map[string]any{key: text, "Groups": groups}
+	for i, name := range pattern.SubexpNames() {
+		if name != "" {
+			data[name] = groups[i]
+		}
+	}
+	return data, true
+}
+
+// parseTemplate parses a response template; a missing named group is an
+// error rather than "<no value>"
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("response").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", text, err)
+	}
+	return tmpl, nil
+}
+
+// expandTemplate executes a response template with the match data
+func expandTemplate(text string, data map[string]any) (string, error) {
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// withDefaults fills in the scenario-wide latency and failure for an entry
+// that does not set its own
+func (f Fault) withDefaults(defaults Fault) Fault {
+	if f.Latency == "" {
+		f.Latency = defaults.Latency
+	}
+	if f.Kind == "" && f.Error == "" {
+		f.Kind, f.Error, f.Status = defaults.Kind, defaults.Error, defaults.Status
+	}
+	return f
+}
+
+// apply waits for the configured latency and returns the injected error,
+// if any
+func (f Fault) apply(ctx context.Context) error {
+	var delay time.Duration
+	if f.Latency != "" {
+		delay, _ = time.ParseDuration(f.Latency) // Validated on load
+	} else if f.Kind == FaultTimeout {
+		delay = defaultTimeout
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	switch {
+	case f.Error != "":
+		return APIError{Provider: "mock", StatusCode: f.Status, Message: f.Error}
+	case f.Kind == FaultTimeout:
+		return NetworkError{Provider: "mock", Err: context.DeadlineExceeded}
+	case f.Kind == FaultRateLimit:
+		return APIError{Provider: "mock", StatusCode: 429, Message: "Resource has been exhausted (e.g. check quota)."}
+	case f.Kind == FaultServerError:
+		return APIError{Provider: "mock", StatusCode: 503, Message: "The model is overloaded. Please try again later."}
+	case f.Kind == FaultMalformed:
+		// Run a truncated response through the real JSON decoder so callers
+		// see the same error a garbled provider response produces
+		var resp geminiResponse
+		err := json.Unmarshal([]byte(`{"command": "ls -la", "safety": "SA`), &resp)
+		return fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+	return nil
+}
+
+// candidates returns up to n scripted candidates, or none when fewer
+// than two were requested
+func (e GenerateEntry) candidates(n int) []Candidate {
+	if n < 2 {
+		return nil
+	}
+	if len(e.Candidates) > n {
+		return e.Candidates[:n]
+	}
+	return e.Candidates
+}
+
+// comments returns the entry's per-part comments when they were requested
+func (e GenerateEntry) comments(requested bool) []string {
+	if !requested {
+		return nil
+	}
+	return e.Comments
+}
+
+// safetyLevel returns the entry's safety, deriving it from the command
+// when the scenario does not say
+func (e GenerateEntry) safetyLevel() safety.SafetyLevel {
+	switch strings.ToLower(e.Safety) {
+	case "safe":
+		return safety.Safe
+	case "attention":
+		return safety.Attention
+	}
+	if containsDangerousPatterns(e.Command) {
+		return safety.Attention
+	}
+	return safety.Safe
+}
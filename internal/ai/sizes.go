@@ -0,0 +1,149 @@
+// Package ai - request and response size guards
+package ai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SizeLimits caps request and response sizes in bytes; zero means unlimited
+type SizeLimits struct {
+	Query    int // The query, or the command to explain
+	Context  int // Local context, the environment and similar extra input
+	Response int // The provider's raw HTTP response
+}
+
+// SizeError reports a request or response over its size limit
+type SizeError struct {
+	What  string // "query", "command", "context" or "response"
+	Size  int    // Bytes seen; for responses, as far as reading got
+	Limit int
+}
+
+func (e SizeError) Error() string {
+	if e.What == "response" {
+		return fmt.Sprintf("the provider's response is over the %s limit", formatSize(e.Limit))
+	}
+	return fmt.Sprintf("the %s is %s, over the %s limit", e.What, formatSize(e.Size), formatSize(e.Limit))
+}
+
+// formatSize prints a byte count in the largest fitting binary unit
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// SizeGuardedClient wraps a Client so requests over the limits fail before
+// they reach the provider (and its bill)
+type SizeGuardedClient struct {
+	Client
+	limits SizeLimits
+}
+
+// NewSizeGuardedClient wraps client with limits
+func NewSizeGuardedClient(client Client, limits SizeLimits) *SizeGuardedClient {
+	return &SizeGuardedClient{Client: client, limits: limits}
+}
+
+// GenerateCommand checks the query and context sizes
+func (c *SizeGuardedClient) GenerateCommand(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	if err := checkSize("query", len(req.Query)+len(req.Baseline), c.limits.Query); err != nil {
+		return nil, err
+	}
+	if err := checkSize("context", len(req.Context), c.limits.Context); err != nil {
+		return nil, err
+	}
+	return c.Client.GenerateCommand(ctx, req)
+}
+
+// ExplainCommand checks the command and environment sizes
+func (c *SizeGuardedClient) ExplainCommand(ctx context.Context, req ExplainRequest) (*ExplainResponse, error) {
+	if err := checkSize("command", len(req.Command)+len(req.CompareWith), c.limits.Query); err != nil {
+		return nil, err
+	}
+	if err := checkSize("context", len(strings.Join(req.Environment, "\n")), c.limits.Context); err != nil {
+		return nil, err
+	}
+	return c.Client.ExplainCommand(ctx, req)
+}
+
+// Ping passes through to the wrapped client when it supports it
+func (c *SizeGuardedClient) Ping(ctx context.Context) error {
+	if pinger, ok := c.Client.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return fmt.Errorf("provider does not support connection tests")
+}
+
+// checkSize returns a SizeError when size is over a non-zero limit
+func checkSize(what string, size, limit int) error {
+	if limit > 0 && size > limit {
+		return SizeError{What: what, Size: size, Limit: limit}
+	}
+	return nil
+}
+
+// limitResponses returns an HTTP client whose response bodies fail with a
+// SizeError past limit bytes, so a runaway answer cannot exhaust memory.
+// A nil client stands for http.DefaultClient.
+func limitResponses(client *http.Client, limit int) *http.Client {
+	if limit <= 0 {
+		return client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	limited := *client
+	transport := limited.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	limited.Transport = sizeLimitedTransport{next: transport, limit: limit}
+	return &limited
+}
+
+// sizeLimitedTransport wraps response bodies in a sizeLimitedBody
+type sizeLimitedTransport struct {
+	next  http.RoundTripper
+	limit int
+}
+
+func (t sizeLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &sizeLimitedBody{ReadCloser: resp.Body, left: t.limit, limit: t.limit}
+	return resp, nil
+}
+
+// sizeLimitedBody fails reads once more than limit bytes were read
+type sizeLimitedBody struct {
+	io.ReadCloser
+	left  int
+	limit int
+}
+
+func (b *sizeLimitedBody) Read(p []byte) (int, error) {
+	if b.left < 0 {
+		return 0, SizeError{What: "response", Size: b.limit - b.left, Limit: b.limit}
+	}
+	// Read one byte past the limit to tell a full body from a larger one
+	if len(p) > b.left+1 {
+		p = p[:b.left+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.left -= n
+	if b.left < 0 {
+		return 0, SizeError{What: "response", Size: b.limit - b.left, Limit: b.limit}
+	}
+	return n, err
+}
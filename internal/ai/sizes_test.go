@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSizeGuardedClient(t *testing.T) {
+	mock, err := NewMockClient(Config{MockResponse: "ls -la"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewSizeGuardedClient(mock, SizeLimits{Query: 16, Context: 32})
+
+	if _, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files", Context: "recent: ls"}); err != nil {
+		t.Fatalf("GenerateCommand() within the limits error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{"query", func() error {
+			_, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: strings.Repeat("a", 17)})
+			return err
+		}, "query"},
+		{"context", func() error {
+			_, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files", Context: strings.Repeat("a", 33)})
+			return err
+		}, "context"},
+		{"command", func() error {
+			_, err := client.ExplainCommand(context.Background(), ExplainRequest{Command: strings.Repeat("a", 17)})
+			return err
+		}, "command"},
+	}
+	for _, tt := range tests {
+		var sizeErr SizeError
+		if err := tt.call(); !errors.As(err, &sizeErr) || sizeErr.What != tt.want {
+			t.Errorf("%s: error = %v, want a %s SizeError", tt.name, err, tt.want)
+		}
+	}
+}
+
+// bodyTransport answers every request with a fixed body
+type bodyTransport string
+
+func (b bodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(b))), Request: req}, nil
+}
+
+func TestLimitResponses(t *testing.T) {
+	read := func(body string, limit int) ([]byte, error) {
+		client := limitResponses(&http.Client{Transport: bodyTransport(body)}, limit)
+		resp, err := client.Get("http://provider.invalid/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		return io.ReadAll(resp.Body)
+	}
+
+	if data, err := read("0123456789", 10); err != nil || string(data) != "0123456789" {
+		t.Errorf("body at the limit = %q, %v", data, err)
+	}
+	var sizeErr SizeError
+	if _, err := read("0123456789A", 10); !errors.As(err, &sizeErr) || sizeErr.What != "response" {
+		t.Errorf("body over the limit error = %v, want a response SizeError", err)
+	}
+	if client := limitResponses(nil, 0); client != nil {
+		t.Errorf("limitResponses(nil, 0) = %v, want nil to keep the default", client)
+	}
+}
@@ -0,0 +1,71 @@
+// Package ai - capturing prompts and raw responses for bug reports
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"hermes/internal/redact"
+)
+
+// Transcript collects the exact prompts sent to providers and the raw
+// answers received during one invocation, so a bad generation can be
+// reproduced from a bug report
+type Transcript struct {
+	Version   string     `json:"hermes_version"`
+	Command   string     `json:"command"` // The hermes subcommand, e.g. "generate"
+	Exchanges []Exchange `json:"exchanges"`
+
+	mu sync.Mutex
+}
+
+// Exchange is one provider call
+type Exchange struct {
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model,omitempty"`
+	Time       time.Time `json:"time"`
+	DurationMS int64     `json:"duration_ms"`
+	System     string    `json:"system"`             // Instructions, as sent or as cached by the provider
+	User       string    `json:"user"`               // The user turn carrying the request
+	Response   string    `json:"response,omitempty"` // The model's raw answer, before parsing
+	Error      string    `json:"error,omitempty"`
+	Tokens     int       `json:"tokens,omitempty"`
+}
+
+// record adds an exchange; a nil transcript records nothing
+func (t *Transcript) record(exchange Exchange) {
+	if t == nil {
+		return
+	}
+	exchange.DurationMS = time.Since(exchange.Time).Milliseconds()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Exchanges = append(t.Exchanges, exchange)
+}
+
+// Save writes the transcript as indented JSON with credentials masked,
+// whether or not the prompts were redacted before they were sent
+func (t *Transcript) Save(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := redact.New()
+	saved := Transcript{Version: t.Version, Command: t.Command, Exchanges: []Exchange{}}
+	for _, exchange := range t.Exchanges {
+		exchange.System = r.Redact(exchange.System)
+		exchange.User = r.Redact(exchange.User)
+		exchange.Response = r.Redact(exchange.Response)
+		exchange.Error = r.Redact(exchange.Error)
+		saved.Exchanges = append(saved.Exchanges, exchange)
+	}
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to save transcript: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Package ai - validating generated answers against the response schema
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// InvalidResponseError reports an answer to a generate prompt that breaks
+// the response schema (e.g., an empty command or an unknown safety level)
+type InvalidResponseError struct {
+	Problem string // What is wrong, phrased so the model can fix it
+}
+
+func (e InvalidResponseError) Error() string {
+	return "invalid response from the model: " + e.Problem
+}
+
+// generateValidated makes a generate call and, when the answer breaks the
+// schema, repeats it once with the problem added to the request
+func generateValidated(req GenerateRequest, generate func(GenerateRequest) (*GenerateResponse, error)) (*GenerateResponse, error) {
+	resp, err := generate(req)
+	var invalid InvalidResponseError
+	if !errors.As(err, &invalid) {
+		return resp, err
+	}
+	req.Correction = invalid.Problem
+	return generate(req)
+}
+
+// validateGenerateResponse checks a parsed answer: a command is present,
+// safety is SAFE or ATTENTION, and no command holds control characters or,
+// unless multi-line commands are allowed, line breaks
+func validateGenerateResponse(resp geminiResponse, multiLine bool) error {
+	if strings.TrimSpace(resp.Command) == "" {
+		return InvalidResponseError{Problem: `"command" is empty`}
+	}
+	if resp.Safety != "SAFE" && resp.Safety != "ATTENTION" {
+		return InvalidResponseError{Problem: fmt.Sprintf(`"safety" is %q, it must be SAFE or ATTENTION`, resp.Safety)}
+	}
+
+	commands := []string{resp.Command}
+	for _, step := range resp.Steps {
+		commands = append(commands, step.Command)
+	}
+	for _, candidate := range resp.Candidates {
+		commands = append(commands, candidate.Command)
+	}
+	for _, command := range commands {
+		if problem := commandProblem(command, multiLine); problem != "" {
+			return InvalidResponseError{Problem: problem}
+		}
+	}
+	return nil
+}
+
+// commandProblem describes the first character a command must not contain
+func commandProblem(command string, multiLine bool) string {
+	for i, r := range command {
+		switch {
+		case r == '\t':
+		case r == '\n' || r == '\r' && strings.HasPrefix(command[i+1:], "\n"):
+			if !multiLine {
+				return fmt.Sprintf("%q spans several lines; put it on one line (join commands with && or ;)", command)
+			}
+		case unicode.IsControl(r):
+			return fmt.Sprintf("%q contains the control character %U", command, r)
+		}
+	}
+	return ""
+}
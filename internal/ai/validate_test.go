@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseGenerateTextValidation(t *testing.T) {
+	tests := []struct {
+		answer    string
+		multiLine bool
+		wantErr   string
+	}{
+		{`{"command": "ls -la", "safety": "SAFE"}`, false, ""},
+		{`{"command": "  ", "safety": "SAFE"}`, false, `"command" is empty`},
+		{`{"command": "ls", "safety": "MAYBE"}`, false, `"safety" is "MAYBE"`},
+		{`{"command": "ls", "safety": "safe"}`, false, `"safety" is "safe"`},
+		{`{"command": "echo hi\u001b[2J", "safety": "SAFE"}`, false, "control character U+001B"},
+		{`{"command": "cat <<EOF > a\nx\nEOF", "safety": "ATTENTION"}`, false, "spans several lines"},
+		{`{"command": "cat <<EOF > a\r\nx\r\nEOF", "safety": "ATTENTION"}`, true, ""},
+		{`{"command": "awk -F'\t' '{print $1}' f", "safety": "SAFE"}`, false, ""},
+		{`{"command": "ls", "safety": "SAFE", "candidates": [{"command": "ls\u0000rm"}]}`, false, "control character U+0000"},
+		{`{"command": "ls", "safety": "SAFE", "steps": [{"command": "cd a\nls"}]}`, false, "spans several lines"},
+		{`command: ls`, false, "not a valid JSON object"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseGenerateText(tt.answer, tt.multiLine, false)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("parseGenerateText(%s) error = %v", tt.answer, err)
+			}
+			continue
+		}
+		var invalid InvalidResponseError
+		if !errors.As(err, &invalid) || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseGenerateText(%s) error = %v, want an InvalidResponseError about %s", tt.answer, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGenerateCommandRetriesInvalidAnswer(t *testing.T) {
+	answers := []string{
+		`{"command": "", "safety": "SAFE"}`,
+		`{"command": "ls -la", "safety": "SAFE"}`,
+	}
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompts = append(prompts, req.Prompt)
+		json.NewEncoder(w).Encode(ollamaResponse{Response: answers[0]})
+		if len(answers) > 1 {
+			answers = answers[1:]
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewOllamaClient(Config{BaseURL: server.URL, Model: "qwen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+	if err != nil || resp.Command != "ls -la" {
+		t.Fatalf("GenerateCommand() = %+v, %v, want the corrected answer", resp, err)
+	}
+	if len(prompts) != 2 || !strings.Contains(prompts[1], `Your previous answer was rejected: "command" is empty.`) {
+		t.Errorf("prompts = %q, want a second one naming the problem", prompts)
+	}
+
+	// A second invalid answer is an error, not a third call
+	answers, prompts = []string{`{"command": "ls", "safety": "UNKNOWN"}`}, nil
+	_, err = client.GenerateCommand(context.Background(), GenerateRequest{Query: "list files"})
+	var invalid InvalidResponseError
+	if !errors.As(err, &invalid) || len(prompts) != 2 {
+		t.Errorf("GenerateCommand() error = %v after %d calls, want InvalidResponseError after 2", err, len(prompts))
+	}
+}
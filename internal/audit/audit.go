@@ -0,0 +1,218 @@
+// Package audit keeps a tamper-evident log of the commands hermes produced.
+//
+// The log is JSON Lines. Every entry carries the SHA-256 hash of the
+// previous entry and its own hash over all of its fields, so editing,
+// deleting or reordering entries breaks the chain and is reported by
+// Verify. Truncating the tail cannot be detected from the file alone;
+// record the head hash printed by `hermes audit verify` elsewhere to
+// detect that too.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// genesis is the previous-hash of the first entry
+var genesis = strings.Repeat("0", 64)
+
+// lockTimeout bounds how long Append waits for a concurrent writer
+const lockTimeout = 2 * time.Second
+
+// staleLock is the age after which a leftover lock file is ignored
+const staleLock = 10 * time.Second
+
+// Entry is a single audit record
+type Entry struct {
+	Time    string `json:"time"` // RFC 3339, UTC
+	Host    string `json:"host"`
+	User    string `json:"user"`
+	Dir     string `json:"dir"`
+	Action  string `json:"action"` // "generate" or "explain"
+	Query   string `json:"query,omitempty"`
+	Command string `json:"command"`
+	Safety  string `json:"safety,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Remote  string `json:"remote,omitempty"` // SSH target the command was generated for
+	Prev    string `json:"prev"`
+	Hash    string `json:"hash"`
+}
+
+// computeHash hashes the entry with its Hash field cleared
+func (e Entry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e) // Only strings, cannot fail
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// DefaultPath returns the audit log location under the XDG state directory
+func DefaultPath() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "hermes", "audit.log")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "hermes", "audit.log")
+}
+
+// Append chains the entry onto the log at path. Time, host, user and
+// directory are filled in when empty.
+func Append(path string, e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	prev, err := headHash(path)
+	if err != nil {
+		return err
+	}
+
+	if e.Time == "" {
+		e.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	if e.Host == "" {
+		e.Host, _ = os.Hostname()
+	}
+	if e.User == "" {
+		e.User = os.Getenv("USER")
+		if current, err := user.Current(); e.User == "" && err == nil {
+			e.User = current.Username
+		}
+	}
+	if e.Dir == "" {
+		e.Dir, _ = os.Getwd()
+	}
+	e.Prev = prev
+	e.Hash = e.computeHash()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// VerifyError describes the first broken link in the chain
+type VerifyError struct {
+	Line   int
+	Reason string
+}
+
+func (e VerifyError) Error() string {
+	return fmt.Sprintf("audit log broken at line %d: %s", e.Line, e.Reason)
+}
+
+// Verify checks the whole chain and returns the number of entries and the
+// hash of the last one
+func Verify(path string) (int, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	prev := genesis
+	count := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			return count, prev, VerifyError{Line: line, Reason: "empty line"}
+		}
+
+		var e Entry
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&e); err != nil {
+			return count, prev, VerifyError{Line: line, Reason: fmt.Sprintf("invalid entry: %v", err)}
+		}
+		if e.Prev != prev {
+			return count, prev, VerifyError{Line: line, Reason: "previous-hash does not match the preceding entry (entries deleted or reordered)"}
+		}
+		if e.computeHash() != e.Hash {
+			return count, prev, VerifyError{Line: line, Reason: "hash does not match the entry contents (entry modified)"}
+		}
+		prev = e.Hash
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, prev, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return count, prev, nil
+}
+
+// headHash returns the hash of the last entry, or the genesis hash for a
+// missing or empty log
+func headHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return genesis, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	data = bytes.TrimRight(data, "\n")
+	if len(data) == 0 {
+		return genesis, nil
+	}
+	last := data[bytes.LastIndexByte(data, '\n')+1:]
+
+	var e Entry
+	if err := json.Unmarshal(last, &e); err != nil || e.Hash == "" {
+		return "", fmt.Errorf("audit log tail is corrupt; run 'hermes audit verify'")
+	}
+	return e.Hash, nil
+}
+
+// lock serializes writers with an exclusive lock file next to the log
+func lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock audit log: %w", err)
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for audit log lock %s", lockPath)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeChain(t *testing.T, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < n; i++ {
+		if err := Append(path, Entry{Action: "generate", Query: "q", Command: strings.Repeat("x", i+1), Safety: "safe"}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	return path
+}
+
+func TestVerifyIntactChain(t *testing.T) {
+	path := writeChain(t, 3)
+	count, head, err := Verify(path)
+	if err != nil || count != 3 || len(head) != 64 {
+		t.Errorf("Verify() = %d, %q, %v, want 3 entries", count, head, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(lines []string) []string
+		wantLine int
+	}{
+		{"modified command", func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], `"command":"xx"`, `"command":"rm -rf /"`, 1)
+			return lines
+		}, 2},
+		{"deleted entry", func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		}, 2},
+		{"reordered entries", func(lines []string) []string {
+			lines[0], lines[1] = lines[1], lines[0]
+			return lines
+		}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeChain(t, 3)
+			data, _ := os.ReadFile(path)
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if err := os.WriteFile(path, []byte(strings.Join(tt.tamper(lines), "\n")+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, _, err := Verify(path)
+			var verifyErr VerifyError
+			if !errors.As(err, &verifyErr) || verifyErr.Line != tt.wantLine {
+				t.Errorf("Verify() error = %v, want break at line %d", err, tt.wantLine)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package budget enforces client-side request rates, daily token budgets
+// and daily cost caps per provider, so scripted misuse cannot run up a
+// surprise bill
+package budget
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Limits caps how much a provider may be used; zero means unlimited
+type Limits struct {
+	RequestsPerMinute int
+	DailyTokens       int
+	MaxCostPerDay     float64 // Estimated US dollars
+	PricePerMillion   float64 // US dollars per million tokens, for the cost estimate
+}
+
+// Enabled reports whether any limit is set
+func (l Limits) Enabled() bool {
+	return l.RequestsPerMinute > 0 || l.DailyTokens > 0 || l.MaxCostPerDay > 0
+}
+
+// Prices are the built-in estimates in US dollars per million tokens,
+// blended for hermes' prompts: mostly input, with short answers. Local
+// and mock providers cost nothing.
+var Prices = map[string]float64{
+	"gemini": 0.65, // gemini-2.5-flash: $0.30 input, $2.50 output
+}
+
+// ExceededError reports a call refused because a limit was reached
+type ExceededError struct {
+	Provider   string
+	Limit      string        // Human-readable limit, e.g. "10 requests per minute"
+	RetryAfter time.Duration // When the limit frees up again
+}
+
+func (e ExceededError) Error() string {
+	return fmt.Sprintf("%s budget exceeded: %s (resets in %s)", e.Provider, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// usage is the persisted state for one provider
+type usage struct {
+	Requests []int64 `json:"requests"` // Unix times of calls in the last minute
+	Day      string  `json:"day"`      // Local date the token count applies to
+	Tokens   int     `json:"tokens"`
+	Calls    int     `json:"calls,omitempty"` // Calls made on Day
+	Cost     float64 `json:"cost,omitempty"`  // Estimated US dollars spent on Day
+}
+
+// Tracker checks and records the usage of one provider in a state file
+// shared by all hermes processes. Concurrent processes may each slip one
+// call past a limit; the budget is a guard rail, not an accounting system.
+type Tracker struct {
+	path     string
+	provider string
+	limits   Limits
+	now      func() time.Time
+}
+
+// New returns a tracker for provider keeping its state in the file at path
+func New(path, provider string, limits Limits) *Tracker {
+	return &Tracker{path: path, provider: provider, limits: limits, now: time.Now}
+}
+
+// DefaultPath returns the usage file under the XDG state directory
+func DefaultPath() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "hermes", "usage.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "hermes", "usage.json")
+}
+
+// Allow returns an ExceededError if another call would break a limit
+func (t *Tracker) Allow() error {
+	all, err := t.load()
+	if err != nil {
+		return err
+	}
+	now := t.now()
+	u := t.current(all, now)
+
+	if t.limits.RequestsPerMinute > 0 && len(u.Requests) >= t.limits.RequestsPerMinute {
+		oldest := time.Unix(u.Requests[0], 0)
+		return ExceededError{
+			Provider:   t.provider,
+			Limit:      fmt.Sprintf("%d requests per minute", t.limits.RequestsPerMinute),
+			RetryAfter: oldest.Add(time.Minute).Sub(now),
+		}
+	}
+	year, month, day := now.Date()
+	midnight := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+	if t.limits.DailyTokens > 0 && u.Tokens >= t.limits.DailyTokens {
+		return ExceededError{
+			Provider:   t.provider,
+			Limit:      fmt.Sprintf("%d tokens per day", t.limits.DailyTokens),
+			RetryAfter: midnight.Sub(now),
+		}
+	}
+	// Stop before the call that would go over the cap, assuming it costs
+	// as much as today's average call
+	if t.limits.MaxCostPerDay > 0 && u.Calls > 0 && u.Cost+u.Cost/float64(u.Calls) > t.limits.MaxCostPerDay {
+		return ExceededError{
+			Provider:   t.provider,
+			Limit:      fmt.Sprintf("$%.2f per day (estimated $%.2f spent)", t.limits.MaxCostPerDay, u.Cost),
+			RetryAfter: midnight.Sub(now),
+		}
+	}
+	return nil
+}
+
+// Record counts a call that used tokens and saves the state
+func (t *Tracker) Record(tokens int) error {
+	all, err := t.load()
+	if err != nil {
+		return err
+	}
+	now := t.now()
+	u := t.current(all, now)
+	u.Requests = append(u.Requests, now.Unix())
+	u.Tokens += tokens
+	u.Calls++
+	u.Cost += float64(tokens) * t.limits.PricePerMillion / 1e6
+	all[t.provider] = u
+	return t.save(all)
+}
+
+// current returns the provider's usage with expired entries dropped
+func (t *Tracker) current(all map[string]usage, now time.Time) usage {
+	u := all[t.provider]
+	cutoff := now.Add(-time.Minute).Unix()
+	var recent []int64
+	for _, at := range u.Requests {
+		if at > cutoff {
+			recent = append(recent, at)
+		}
+	}
+	u.Requests = recent
+
+	if today := now.Format("2006-01-02"); u.Day != today {
+		u.Day = today
+		u.Tokens, u.Calls, u.Cost = 0, 0, 0
+	}
+	return u
+}
+
+// load reads the state file; a missing file means nothing was used yet
+func (t *Tracker) load() (map[string]usage, error) {
+	all := map[string]usage{}
+	data, err := os.ReadFile(t.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage file: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("invalid usage file %s: %w", t.path, err)
+	}
+	return all, nil
+}
+
+// save writes the state file atomically
+func (t *Tracker) save(all map[string]usage) error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create usage directory: %w", err)
+	}
+	data, _ := json.Marshal(all) // Only plain values, cannot fail
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save usage file: %w", err)
+	}
+	return os.Rename(tmp, t.path)
+}
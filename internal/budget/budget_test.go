@@ -0,0 +1,105 @@
+package budget
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestTracker returns a tracker with a controllable clock
+func newTestTracker(t *testing.T, limits Limits) (*Tracker, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 3, 14, 23, 59, 0, 0, time.Local)
+	tracker := New(filepath.Join(t.TempDir(), "usage.json"), "gemini", limits)
+	tracker.now = func() time.Time { return now }
+	return tracker, &now
+}
+
+func TestRequestsPerMinute(t *testing.T) {
+	tracker, now := newTestTracker(t, Limits{RequestsPerMinute: 2})
+
+	for i := 0; i < 2; i++ {
+		if err := tracker.Allow(); err != nil {
+			t.Fatalf("call %d: Allow() error = %v", i, err)
+		}
+		if err := tracker.Record(10); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		*now = now.Add(10 * time.Second)
+	}
+
+	var exceeded ExceededError
+	if err := tracker.Allow(); !errors.As(err, &exceeded) {
+		t.Fatalf("Allow() error = %v, want ExceededError", err)
+	}
+	if exceeded.RetryAfter != 40*time.Second {
+		t.Errorf("RetryAfter = %v, want 40s", exceeded.RetryAfter)
+	}
+
+	// The first call leaves the window
+	*now = now.Add(41 * time.Second)
+	if err := tracker.Allow(); err != nil {
+		t.Errorf("Allow() after the window error = %v", err)
+	}
+}
+
+func TestDailyTokens(t *testing.T) {
+	tracker, now := newTestTracker(t, Limits{DailyTokens: 1000})
+
+	if err := tracker.Record(1200); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	var exceeded ExceededError
+	if err := tracker.Allow(); !errors.As(err, &exceeded) {
+		t.Fatalf("Allow() error = %v, want ExceededError", err)
+	}
+	if exceeded.RetryAfter != time.Minute {
+		t.Errorf("RetryAfter = %v, want 1m until midnight", exceeded.RetryAfter)
+	}
+
+	// A new day starts a new budget
+	*now = now.Add(2 * time.Minute)
+	if err := tracker.Allow(); err != nil {
+		t.Errorf("Allow() on the next day error = %v", err)
+	}
+}
+
+func TestMaxCostPerDay(t *testing.T) {
+	tracker, now := newTestTracker(t, Limits{MaxCostPerDay: 0.01, PricePerMillion: 1})
+
+	// Each call costs $0.004; a third would take the day to $0.012
+	for i := 0; i < 2; i++ {
+		if err := tracker.Allow(); err != nil {
+			t.Fatalf("call %d: Allow() error = %v", i, err)
+		}
+		if err := tracker.Record(4000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var exceeded ExceededError
+	if err := tracker.Allow(); !errors.As(err, &exceeded) {
+		t.Fatalf("Allow() error = %v, want ExceededError", err)
+	}
+	if exceeded.Limit != "$0.01 per day (estimated $0.01 spent)" {
+		t.Errorf("Limit = %q", exceeded.Limit)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if err := tracker.Allow(); err != nil {
+		t.Errorf("Allow() on the next day error = %v", err)
+	}
+}
+
+func TestProvidersAreIndependent(t *testing.T) {
+	tracker, _ := newTestTracker(t, Limits{RequestsPerMinute: 1})
+	if err := tracker.Record(0); err != nil {
+		t.Fatal(err)
+	}
+
+	other := New(tracker.path, "ollama", Limits{RequestsPerMinute: 1})
+	other.now = tracker.now
+	if err := other.Allow(); err != nil {
+		t.Errorf("ollama Allow() error = %v, gemini usage leaked", err)
+	}
+}
@@ -0,0 +1,67 @@
+// Package commands - audit subcommand
+package commands
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/audit"
+	"hermes/internal/exit"
+)
+
+// auditCmd groups the audit log subcommands
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the tamper-evident audit log",
+	Long: `Inspect the audit log of generated commands.
+
+Enable the log in ~/.config/hermes/config.toml:
+
+  [audit]
+  enabled = true
+  path = "/var/log/hermes/audit.log"   # optional
+
+Each entry records when, where and by whom a command was generated,
+chained to the previous entry by its SHA-256 hash.`,
+}
+
+// auditVerifyCmd checks the hash chain of the audit log
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the audit log hash chain",
+	Long: `Verify that no audit log entry was modified, deleted or reordered.
+
+Prints the number of entries and the hash of the newest one. Store that
+head hash somewhere else (a ticket, a remote log) to also detect entries
+being truncated from the end later.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("path")
+		if path == "" {
+			path = appCtx.Config.Audit.Path
+		}
+		if path == "" {
+			path = audit.DefaultPath()
+		}
+
+		out := consoleFor(cmd)
+		count, head, err := audit.Verify(path)
+		var verifyErr audit.VerifyError
+		if errors.As(err, &verifyErr) {
+			out.Infof("└─ %d entries verified before the break\n", count)
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+		if err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+
+		out.Resultf("OK: %d entries, head %s\n", count, head)
+		return nil
+	},
+}
+
+func init() {
+	auditVerifyCmd.Flags().String("path", "", "Audit log to verify (defaults to the configured log)")
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+}
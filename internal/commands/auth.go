@@ -0,0 +1,96 @@
+// Package commands - auth subcommand
+package commands
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/exit"
+)
+
+// authTimeout bounds the connection test so a hung provider fails fast
+const authTimeout = 20 * time.Second
+
+// authCmd groups the credential subcommands
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Check provider credentials",
+}
+
+// authTestCmd makes a minimal provider call with the configured key and model
+var authTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Verify the configured API key and model work",
+	Long: `Make the smallest possible request to the configured provider to check
+that the API key is valid, has access to the model and has quota left.
+
+Exits 0 when the provider answers, 2 for key, permission or model problems
+that need a config change, and 1 for quota, outage and network problems.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := &appCtx.Config
+		provider := providerName(cfg)
+		model := ai.DefaultGeminiModel
+		switch provider {
+		case "ollama":
+			model = cfg.Ollama.Model
+		case "mock":
+			model = "mock"
+		}
+
+		client, err := createAIClient(cfg)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		pinger, ok := client.(ai.Pinger)
+		if !ok {
+			return exit.NewError(exit.CodeError, "%s provider does not support connection tests", provider)
+		}
+
+		consoleFor(cmd).Infof("└─ Testing %s (%s)...\n", provider, model)
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), authTimeout)
+		defer cancel()
+		start := time.Now()
+		if err := pinger.Ping(ctx); err != nil {
+			return classifyAuthError(provider, model, err)
+		}
+
+		consoleFor(cmd).Resultf("OK: %s accepted the credentials, %s responded in %s\n", provider, model, time.Since(start).Round(time.Millisecond))
+		return nil
+	},
+}
+
+// classifyAuthError turns a provider error into a message saying what is
+// wrong and how to fix it, rather than the raw API error
+func classifyAuthError(provider, model string, err error) error {
+	if hint, ok := providerFailure(err, provider); ok {
+		return hint.exitError("", err)
+	}
+	var apiErr ai.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusForbidden:
+			return exit.NewError(exit.CodeConfig, "permission denied: the key is valid but may not use %s (%s)", model, apiErr.Message)
+		case apiErr.StatusCode == http.StatusNotFound:
+			return exit.NewError(exit.CodeConfig, "model not found: %s does not offer %s (%s)", provider, model, apiErr.Message)
+		case apiErr.StatusCode >= 500:
+			return exit.NewError(exit.CodeError, "%s is unavailable (HTTP %d): %s", provider, apiErr.StatusCode, apiErr.Message)
+		case apiErr.StatusCode == http.StatusBadRequest:
+			return exit.NewError(exit.CodeConfig, "%s rejected the request: %s", provider, apiErr.Message)
+		}
+		return exit.NewError(exit.CodeError, "%v", apiErr)
+	}
+	return exit.NewError(exit.CodeError, "connection test failed: %v", err)
+}
+
+func init() {
+	authCmd.AddCommand(authTestCmd)
+	rootCmd.AddCommand(authCmd)
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/exit"
+)
+
+func TestClassifyAuthError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantMsg  string
+	}{
+		{"unauthorized", ai.APIError{Provider: "gemini", StatusCode: 401, Message: "unauthenticated"}, exit.CodeConfig, "invalid API key"},
+		{"forbidden", ai.APIError{Provider: "gemini", StatusCode: 403, Message: "permission denied"}, exit.CodeConfig, "permission denied"},
+		{"unknown model", ai.APIError{Provider: "ollama", StatusCode: 404, Message: "model 'x' not found"}, exit.CodeConfig, "model not found"},
+		{"quota", ai.APIError{Provider: "gemini", StatusCode: 429, Message: "Resource has been exhausted"}, exit.CodeRateLimit, "quota exceeded"},
+		{"outage", ai.APIError{Provider: "gemini", StatusCode: 503, Message: "overloaded"}, exit.CodeError, "unavailable"},
+		{"network", ai.NetworkError{Provider: "ollama", Err: errors.New("connection refused")}, exit.CodeError, "could not reach"},
+		{"timeout", context.DeadlineExceeded, exit.CodeTimeout, "could not reach"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exitErr exit.Error
+			if !errors.As(classifyAuthError("gemini", "gemini-2.5-flash", tt.err), &exitErr) {
+				t.Fatal("classifyAuthError() did not return an exit.Error")
+			}
+			if exitErr.Code != tt.wantCode {
+				t.Errorf("Code = %d, want %d", exitErr.Code, tt.wantCode)
+			}
+			if !strings.Contains(exitErr.Error(), tt.wantMsg) {
+				t.Errorf("message %q does not mention %q", exitErr.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestAuthPingInvalidKey(t *testing.T) {
+	// Gemini reports a bad key as 400 INVALID_ARGUMENT, not 401
+	err := replayClient(t, "generate_invalid_key").(ai.Pinger).Ping(context.Background())
+
+	var exitErr exit.Error
+	if !errors.As(classifyAuthError("gemini", ai.DefaultGeminiModel, err), &exitErr) {
+		t.Fatalf("Ping() error = %v, want an API error", err)
+	}
+	if exitErr.Code != exit.CodeConfig || !strings.Contains(exitErr.Error(), "invalid API key") {
+		t.Errorf("classifyAuthError() = %d %q, want an invalid key config error", exitErr.Code, exitErr.Error())
+	}
+}
@@ -0,0 +1,224 @@
+// Package commands - cache subcommand
+package commands
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/config"
+	"hermes/internal/exit"
+	"hermes/internal/explaincache"
+	"hermes/internal/promptcache"
+)
+
+// cacheNames lists the local caches the cache subcommands manage: AI
+// explanations, and the Gemini prompt caches remembered between runs
+var cacheNames = []string{"explain", "prompt"}
+
+// cacheCmd groups the local cache subcommands
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect, clear and trim the local caches",
+	Long: `Inspect, clear and trim the local caches:
+
+  explain  AI explanations, keyed by command and model (explain_cache)
+  prompt   Gemini prompt caches remembered between runs (prompt_cache)
+
+The explain cache is bounded by the [cache] settings: max_entries,
+max_bytes and max_age. The least recently used explanations are evicted
+first.`,
+}
+
+// cacheStatsCmd summarizes what the caches hold
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how many entries each cache holds and how often they were used",
+	Long: `Show the number of entries, the size on disk and the hits of each local
+cache. A hit is a provider call saved: the explain cache answers commands
+explained before with the same model.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stats, err := explainCache(&appCtx.Config).Stats()
+		if err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+		prompts, err := promptcache.New(promptcache.DefaultPath()).List()
+		if err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+
+		w := cmd.OutOrStdout()
+		fmt.Fprintf(w, "%-8s %7s %10s %6s  %s\n", "CACHE", "ENTRIES", "SIZE", "HITS", "OLDEST")
+		oldest := "-"
+		if !stats.Oldest.IsZero() {
+			oldest = stats.Oldest.Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%-8s %7d %10s %6d  %s\n", "explain", stats.Entries, formatSize(stats.Bytes), stats.Hits, oldest)
+		fmt.Fprintf(w, "%-8s %7d %10s %6s  %s\n", "prompt", len(prompts), "-", "-", "-")
+		return nil
+	},
+}
+
+// cacheListCmd lists the entries of the caches
+var cacheListCmd = &cobra.Command{
+	Use:   "list [explain|prompt]",
+	Short: "List the cached entries",
+	Long: `List the entries of every cache, or of the one named: cached explanations
+most recently used first, with their model and hits, and the remembered
+prompt caches with when the provider drops them.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := selectCaches(args)
+		if err != nil {
+			return err
+		}
+		w := cmd.OutOrStdout()
+		for _, name := range names {
+			if err := listCache(w, name); err != nil {
+				return exit.NewError(exit.CodeError, "%v", err)
+			}
+		}
+		return nil
+	},
+}
+
+// cacheClearCmd empties caches
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [explain|prompt...]",
+	Short: "Remove every entry of the caches",
+	Long: `Remove every entry of the local caches, or of the ones named. Clearing
+the prompt cache only forgets the Gemini caches; the provider drops them
+when they expire.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := selectCaches(args)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			var err error
+			switch name {
+			case "explain":
+				err = explainCache(&appCtx.Config).Clear()
+			case "prompt":
+				err = promptcache.New(promptcache.DefaultPath()).Clear()
+			}
+			if err != nil {
+				return exit.NewError(exit.CodeError, "%v", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Cleared the %s cache\n", name)
+		}
+		return nil
+	},
+}
+
+// cacheGCCmd trims the caches to their limits
+var cacheGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Drop expired entries and evict beyond the configured limits",
+	Long: `Drop the entries the limits no longer allow: explanations unused for
+longer than max_age, then the least recently used ones beyond max_entries
+and max_bytes, and prompt caches the provider has already dropped. New
+explanations are evicted for automatically; gc applies lowered limits to
+what is already cached.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		explained, err := explainCache(&appCtx.Config).GC()
+		if err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+		prompts, err := promptcache.New(promptcache.DefaultPath()).GC()
+		if err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %d explanation(s) and %d expired prompt cache(s)\n", explained, prompts)
+		return nil
+	},
+}
+
+// explainCache opens the explain cache with the configured limits
+func explainCache(cfg *config.Config) *explaincache.Cache {
+	maxAge, _ := time.ParseDuration(cfg.Cache.MaxAge) // Checked by validateCache
+	return explaincache.New(explaincache.DefaultPath(), explaincache.Limits{
+		MaxEntries: cfg.Cache.MaxEntries,
+		MaxBytes:   cfg.Cache.MaxBytes,
+		MaxAge:     maxAge,
+	})
+}
+
+// validateCache checks the [cache] limits
+func validateCache(cache config.Cache) error {
+	if cache.MaxEntries < 0 || cache.MaxBytes < 0 {
+		return exit.NewError(exit.CodeConfig, "cache.max_entries and cache.max_bytes must be 0 (unlimited) or positive")
+	}
+	if cache.MaxAge != "" {
+		if maxAge, err := time.ParseDuration(cache.MaxAge); err != nil || maxAge <= 0 {
+			return exit.NewError(exit.CodeConfig, "cache.max_age must be a positive duration such as 720h, got %q", cache.MaxAge)
+		}
+	}
+	return nil
+}
+
+// selectCaches returns the caches named, or all of them
+func selectCaches(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return cacheNames, nil
+	}
+	for _, name := range args {
+		if !slices.Contains(cacheNames, name) {
+			return nil, exit.NewError(exit.CodeConfig, "unknown cache %s (supported: %s)", name, strings.Join(cacheNames, ", "))
+		}
+	}
+	return args, nil
+}
+
+// listCache prints the entries of one cache under its name
+func listCache(w io.Writer, name string) error {
+	switch name {
+	case "explain":
+		listings, err := explainCache(&appCtx.Config).List()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "explain (%d)\n", len(listings))
+		for _, listing := range listings {
+			fmt.Fprintf(w, "  %s  %4d hits  %-24s %s\n", listing.Used.Format("2006-01-02"), listing.Hits, listing.Model, listing.Command)
+		}
+	case "prompt":
+		listings, err := promptcache.New(promptcache.DefaultPath()).List()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "prompt (%d)\n", len(listings))
+		for _, listing := range listings {
+			cacheName := listing.Name
+			if cacheName == "" {
+				cacheName = "(refused by the provider)"
+			}
+			fmt.Fprintf(w, "  expires %s  %s\n", listing.Expires.Format("2006-01-02 15:04"), cacheName)
+		}
+	}
+	return nil
+}
+
+// formatSize prints a byte count in the largest fitting binary unit
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheGCCmd)
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"hermes/internal/config"
+	"hermes/internal/safety"
+)
+
+func TestExplainCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	appCtx = &AppContext{Config: config.Config{ExplainCache: true, Cache: config.Cache{MaxEntries: 10}, Target: safety.TargetPosix, MockResponse: "'frob' frobnicates"}}
+	t.Cleanup(func() { appCtx = nil })
+
+	if _, _, err := runWithConsole(t, explainCmd, "frob", "-x"); err != nil {
+		t.Fatalf("explain error = %v", err)
+	}
+
+	// The same command, spaced differently, comes from the cache
+	appCtx.Config.MockResponse = "'frob' does something else"
+	stdout, stderr, err := runWithConsole(t, explainCmd, "frob  -x")
+	if err != nil {
+		t.Fatalf("explain error = %v", err)
+	}
+	if !strings.Contains(stdout, "'frob' frobnicates") || !strings.Contains(stderr, "explain cache") {
+		t.Errorf("stdout = %q, stderr = %q, want the cached explanation", stdout, stderr)
+	}
+
+	// Another experience level asks the provider again
+	appCtx.Config.ExperienceLevel = "beginner"
+	if stdout, _, _ := runWithConsole(t, explainCmd, "frob", "-x"); !strings.Contains(stdout, "something else") {
+		t.Errorf("stdout = %q, want a fresh explanation for another level", stdout)
+	}
+
+	stdout, _, err = runWithConsole(t, cacheStatsCmd)
+	if err != nil {
+		t.Fatalf("cache stats error = %v", err)
+	}
+	if !strings.Contains(stdout, "\nexplain        2 ") || !strings.Contains(stdout, "      1  2") {
+		t.Errorf("cache stats = %q, want two entries and one hit", stdout)
+	}
+
+	stdout, _, err = runWithConsole(t, cacheListCmd, "explain")
+	if err != nil || !strings.HasPrefix(stdout, "explain (2)\n") || !strings.Contains(stdout, "mock") || !strings.Contains(stdout, "frob -x\n") {
+		t.Errorf("cache list = %q, %v, want both explanations", stdout, err)
+	}
+	if _, _, err := runWithConsole(t, cacheListCmd, "responses"); err == nil {
+		t.Error("cache list accepted an unknown cache")
+	}
+
+	appCtx.Config.Cache.MaxEntries = 1
+	if stdout, _, err := runWithConsole(t, cacheGCCmd); err != nil || !strings.HasPrefix(stdout, "Removed 1 explanation(s)") {
+		t.Errorf("cache gc = %q, %v, want the least recently used explanation evicted", stdout, err)
+	}
+	if _, _, err := runWithConsole(t, cacheClearCmd, "explain"); err != nil {
+		t.Fatalf("cache clear error = %v", err)
+	}
+	if stdout, _, _ := runWithConsole(t, cacheListCmd, "explain"); stdout != "explain (0)\n" {
+		t.Errorf("cache list after clear = %q, want nothing", stdout)
+	}
+}
+
+func TestValidateCache(t *testing.T) {
+	for _, cache := range []config.Cache{{MaxEntries: -1}, {MaxAge: "30d"}, {MaxAge: "-1h"}} {
+		if err := validateCache(cache); err == nil {
+			t.Errorf("validateCache(%+v) accepted invalid limits", cache)
+		}
+	}
+	if err := validateCache(config.Cache{MaxEntries: 100, MaxBytes: 1 << 20, MaxAge: "720h"}); err != nil {
+		t.Errorf("validateCache() error = %v", err)
+	}
+}
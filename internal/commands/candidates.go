@@ -0,0 +1,125 @@
+// Package commands - ranking of alternative candidate commands
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hermes/internal/ai"
+	"hermes/internal/lint"
+	"hermes/internal/safety"
+	"hermes/internal/shell"
+)
+
+// maxCandidates bounds --candidates; more would not fit a prompt screen
+const maxCandidates = 5
+
+// rankedCandidate is a candidate command annotated for ranking
+type rankedCandidate struct {
+	ai.Candidate
+	Safety      safety.Result
+	Destructive bool           // Deletes or overwrites where a safer form exists (rm, find -delete, ...)
+	Stages      int            // Simple commands in the line; more is more complex
+	Portability []lint.Finding // Bashisms and GNU-only options
+}
+
+// rankCandidates analyzes candidates and sorts them so the most
+// conservative working option comes first: by safety level, then whether
+// it destroys data, then portability, then complexity. Candidates that do not parse or that send
+// credentials are dropped.
+func rankCandidates(ctx context.Context, candidates []ai.Candidate, target string) []rankedCandidate {
+	analyzer := appCtx.analyzer(target)
+	var ranked []rankedCandidate
+	for _, candidate := range candidates {
+		entry := rankedCandidate{Candidate: candidate, Stages: 1}
+		if target != safety.TargetCmd {
+			script, err := shell.Parse(candidate.Command)
+			if err != nil {
+				continue
+			}
+			entry.Stages = len(script.Stages())
+			entry.Portability = lint.POSIX(candidate.Command)
+			entry.Destructive = len(safety.SaferAlternatives(candidate.Command)) > 0
+
+			exfil, reason := safety.CheckExfiltration(candidate.Command)
+			if exfil == safety.SendsSecrets {
+				continue
+			}
+			if exfil == safety.ExposesSecrets {
+				entry.Safety = safety.Result{Level: safety.Attention, Reason: "Command " + reason, Layer: "exfiltration-guard"}
+				ranked = append(ranked, entry)
+				continue
+			}
+		}
+		result, err := analyzer.AnalyzeCommand(ctx, candidate.Command)
+		if err != nil {
+			continue
+		}
+		entry.Safety = result
+		ranked = append(ranked, entry)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Safety.Level != b.Safety.Level {
+			return a.Safety.Level < b.Safety.Level
+		}
+		if a.Destructive != b.Destructive {
+			return !a.Destructive
+		}
+		if len(a.Portability) != len(b.Portability) {
+			return len(a.Portability) < len(b.Portability)
+		}
+		if a.Stages != b.Stages {
+			return a.Stages < b.Stages
+		}
+		return len(a.Command) < len(b.Command)
+	})
+	return ranked
+}
+
+// annotation summarizes a candidate's safety, complexity and portability
+func (c rankedCandidate) annotation() string {
+	notes := []string{c.Safety.Level.String()}
+	if c.Destructive {
+		notes = append(notes, "destructive")
+	}
+	if c.Stages == 1 {
+		notes = append(notes, "simple")
+	} else {
+		notes = append(notes, fmt.Sprintf("%d commands", c.Stages))
+	}
+	if len(c.Portability) == 0 {
+		notes = append(notes, "portable")
+	} else {
+		notes = append(notes, "not portable: "+c.Portability[0].Message)
+	}
+	return strings.Join(notes, ", ")
+}
+
+// chooseCandidate lists the ranked candidates on stderr and returns the
+// index of the one the user picks; without a prompt the first (most
+// conservative) one is used
+func chooseCandidate(candidates []rankedCandidate) int {
+	if len(candidates) < 2 || !interactive() {
+		return 0
+	}
+
+	stdio.Infof("└─ candidates, most conservative first:\n")
+	for i, candidate := range candidates {
+		stdio.Infof("   %d) %s  [%s]\n", i+1, candidate.Command, candidate.annotation())
+		if candidate.Description != "" {
+			stdio.Infof("      %s\n", candidate.Description)
+		}
+	}
+	answer := ask("Put which command in the buffer?", "1")
+	choice, err := strconv.Atoi(answer)
+	if err != nil || choice < 1 || choice > len(candidates) {
+		stdio.Infof("└─ unknown choice %q, using the first candidate\n", answer)
+		return 0
+	}
+	return choice - 1
+}
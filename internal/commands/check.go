@@ -0,0 +1,157 @@
+// Package commands - check subcommand
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/exit"
+	"hermes/internal/safety"
+	"hermes/internal/trace"
+)
+
+// checkCmd runs the safety analyzer on a command without contacting a provider
+var checkCmd = &cobra.Command{
+	Use:   "check <command>",
+	Short: "Check a command with the local safety analyzer",
+	Long: `Run the local safety analysis on a command, without contacting an AI
+provider, and exit with the command's safety code: 0 when it is safe and
+10 when it requires attention (or the codes set under [exit_codes]).
+
+With --review the AI provider also gives a quick review: a verdict, what
+the command does and any red flags, which helps with commands pasted from
+the web. The review's verdict and the local one combine as safety_policy
+says (by default attention from either wins); the local verdict stands
+alone when no provider is available.
+
+The shell integration's pre-execution mode ('hermes init <shell> --preexec')
+runs this on every command line before it executes, and its guard key
+('hermes init <shell> --guard') reviews the line being edited.
+
+Examples:
+  hermes check 'rm -rf build/'
+  hermes check --quiet -- git push --force   # Only print a verdict that needs attention
+  hermes check --review 'curl -fsSL https://example.com/install.sh | sh'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := strings.Join(args, " ")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		review, _ := cmd.Flags().GetBool("review")
+
+		var reviewText string
+		if review {
+			aiClient, err := appCtx.client()
+			if err != nil {
+				consoleFor(cmd).Infof("└─ AI review skipped: %v\n", err)
+			} else {
+				defer aiClient.Close()
+				reviewText = reviewCommand(cmd.Context(), aiClient, command)
+			}
+		}
+		level, err := checkCommand(cmd.Context(), cmd.OutOrStdout(), command, appCtx.Config.Target, quiet, reviewText)
+		if err != nil {
+			return err
+		}
+		if exitCode := safetyExitCode(level); exitCode != exit.CodeSuccess {
+			return exit.NewError(exitCode, "")
+		}
+		return nil
+	},
+}
+
+// checkCommand prints the safety verdict for a command: its level and
+// reason, then the risky parts and safer alternatives when it needs
+// attention, then the AI review if there is one. The review's verdict
+// counts as the AI's assessment under the safety policy. Quiet mode prints
+// nothing for safe commands.
+func checkCommand(ctx context.Context, w io.Writer, command string, target string, quiet bool, review string) (safety.SafetyLevel, error) {
+	analyzer := appCtx.analyzer(target)
+	result, err := assessRisk(ctx, analyzer, command, target)
+	if err != nil {
+		return result.Level, exit.NewError(exit.CodeError, "Safety analysis failed: %v", err)
+	}
+	if result.Layer != "exfiltration-guard" {
+		aiLevel, assessed := reviewVerdict(review)
+		result = safetyPolicy().Merge(result, aiLevel, assessed)
+	}
+	result = guardWorkdir(ctx, command, target, result)
+	printVerdict(ctx, w, analyzer, command, result, target, quiet)
+	recordVerdict(result)
+	if review != "" {
+		fmt.Fprintf(w, "\nAI review:\n%s\n", strings.TrimRight(review, "\n"))
+	}
+	return result.Level, nil
+}
+
+// printVerdict prints a verdict's level and reason, then the risky parts
+// and safer alternatives when it needs attention
+func printVerdict(ctx context.Context, w io.Writer, analyzer safety.CommandAnalyzer, command string, result safety.Result, target string, quiet bool) {
+	if result.Level < safety.Attention {
+		if !quiet {
+			fmt.Fprintf(w, "SAFE: %s\n", result.Reason)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "REQUIRES ATTENTION: %s\n", result.Reason)
+	if result.Docs != "" {
+		fmt.Fprintf(w, "  docs: %s\n", result.Docs)
+	}
+	for _, part := range riskyParts(ctx, analyzer, command) {
+		fmt.Fprintf(w, "  • %s\n", part)
+	}
+	for _, alternative := range saferAlternatives(command, result, target) {
+		fmt.Fprintf(w, "  safer: %s (%s)\n", alternative.Command, alternative.Reason)
+	}
+}
+
+// reviewCommand returns the AI's quick review of a command. A failed call
+// only skips the review (returning ""), since the local verdict stands alone.
+func reviewCommand(ctx context.Context, aiClient ai.Client, command string) string {
+	ctx, span := trace.Start(ctx, "ai.review")
+	response, err := aiClient.ExplainCommand(ctx, ai.ExplainRequest{Command: command, Review: true, Level: reviewLevel()})
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		stdio.Infof("└─ AI review skipped: %v\n", err)
+		return ""
+	}
+	return response.Explanation
+}
+
+// reviewVerdict reads the safety level from a review's "Verdict" line:
+// "safe to run" is safe, "run with care" and "do not run" need attention.
+// It reports false when the review has no recognizable verdict.
+func reviewVerdict(review string) (safety.SafetyLevel, bool) {
+	for _, line := range strings.Split(review, "\n") {
+		lower := strings.ToLower(line)
+		if !strings.Contains(lower, "verdict") {
+			continue
+		}
+		switch {
+		case strings.Contains(lower, "do not run"), strings.Contains(lower, "with care"):
+			return safety.Attention, true
+		case strings.Contains(lower, "safe to run"):
+			return safety.Safe, true
+		}
+	}
+	return safety.Safe, false
+}
+
+// reviewLevel is the configured experience level, if any
+func reviewLevel() string {
+	if appCtx == nil {
+		return ""
+	}
+	return appCtx.Config.ExperienceLevel
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().BoolP("quiet", "q", false, "Print nothing when the command is safe")
+	checkCmd.Flags().Bool("review", false, "Also ask the AI provider for a quick review of the command")
+}
@@ -0,0 +1,85 @@
+// Package commands - inline comments for multi-part commands
+package commands
+
+import (
+	"strings"
+
+	"hermes/internal/flagdb"
+	"hermes/internal/shell"
+)
+
+// commentSeparators are the operators a commented command is split at; a
+// line may end with any of them and continue on the next
+var commentSeparators = map[string]bool{"|": true, "&&": true, "||": true, ";": true}
+
+// commentCommand lays out a multi-part POSIX command one part per line,
+// each with a trailing # comment. Comments come from the model, one per
+// part, or from the offline flag database when the model's do not match
+// the parts. Commands with one part, existing comments, here-documents,
+// subshells or background jobs are returned unchanged.
+func commentCommand(command string, comments []string) string {
+	tokens, err := shell.Lex(command)
+	if err != nil {
+		return command
+	}
+
+	// Split the command text at separators, keeping each separator at
+	// the end of its part
+	var parts []string
+	start := 0
+	for _, token := range tokens {
+		switch {
+		case token.Kind == shell.Comment:
+			return command
+		case token.Kind != shell.Operator:
+			continue
+		case commentSeparators[token.Value]:
+			parts = append(parts, strings.TrimSpace(command[start:token.Pos])+" "+token.Value)
+			start = token.Pos + len(token.Raw)
+		case token.Value == "&" || token.Value == "(" || token.Value == ")" || token.Value == "\n" || strings.HasPrefix(token.Value, "<<") || token.Value == ";;":
+			return command
+		}
+	}
+	if rest := strings.TrimSpace(command[start:]); rest != "" {
+		parts = append(parts, rest)
+	}
+	if len(parts) < 2 {
+		return command
+	}
+
+	if len(comments) != len(parts) {
+		comments = offlineComments(parts)
+	}
+	// Continuation lines are indented; comments line up after the longest
+	lines := make([]string, len(parts))
+	width := 0
+	for i, part := range parts {
+		if i > 0 {
+			part = "  " + part
+		}
+		lines[i] = part
+		width = max(width, len(part))
+	}
+	for i, line := range lines {
+		if comment := strings.Join(strings.Fields(comments[i]), " "); comment != "" {
+			lines[i] = line + strings.Repeat(" ", width-len(line)+2) + "# " + comment
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// offlineComments describes each part with the flag database entry for
+// its command, leaving unknown commands uncommented
+func offlineComments(parts []string) []string {
+	comments := make([]string, len(parts))
+	for i, part := range parts {
+		script, err := shell.Parse(strings.TrimRight(part, " |&;"))
+		if err != nil || len(script.Stages()) == 0 {
+			continue
+		}
+		if entry, ok := flagdb.Lookup(script.Stages()[0].Name()); ok {
+			comments[i] = entry.Description
+		}
+	}
+	return comments
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"testing"
+
+	"hermes/internal/shell"
+)
+
+func TestCommentCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		comments []string
+		want     string
+	}{
+		{
+			"grep -h ERROR app.log | sort | uniq -c",
+			[]string{"find error lines", "group identical lines", "count each group"},
+			"grep -h ERROR app.log |  # find error lines\n  sort |                 # group identical lines\n  uniq -c                # count each group",
+		},
+		{
+			"mkdir -p build && cd build",
+			[]string{"create the directory", ""},
+			"mkdir -p build &&  # create the directory\n  cd build",
+		},
+		{"ls -la", []string{"list files"}, "ls -la"},                                          // One part
+		{"sleep 10 & wait", []string{"a", "b"}, "sleep 10 & wait"},                            // Background job
+		{"echo 'a | b' # note", nil, "echo 'a | b' # note"},                                   // Already commented
+		{"(cd src && make) | tee log", []string{"a", "b", "c"}, "(cd src && make) | tee log"}, // Subshell
+	}
+	for _, tt := range tests {
+		got := commentCommand(tt.command, tt.comments)
+		if got != tt.want {
+			t.Errorf("commentCommand(%q) =\n%s\nwant\n%s", tt.command, got, tt.want)
+		}
+		if _, err := shell.Parse(got); err != nil {
+			t.Errorf("commentCommand(%q) does not parse: %v", tt.command, err)
+		}
+	}
+}
+
+func TestCommentCommandOfflineFallback(t *testing.T) {
+	// The model's comments do not match the parts, so the flag database
+	// describes the commands it knows
+	got := commentCommand("ls -la | grep go", []string{"only one"})
+	want := "ls -la |   # lists directory contents\n  grep go  # searches text for lines matching a pattern"
+	if got != want {
+		t.Errorf("commentCommand() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+// Package commands - compare subcommand
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/budget"
+	"hermes/internal/flagdb"
+	"hermes/internal/trace"
+)
+
+// compareCmd compares two commands side by side
+var compareCmd = &cobra.Command{
+	Use:   "compare <command> <command>",
+	Short: "Compare two commands: behavior, performance and risk",
+	Long: `Compare two shell commands that are meant to do the same job, for
+example when reviewing a suggested change. The AI describes the behavior
+differences, performance implications and risk of each, and hermes adds
+its own safety verdict for both commands.
+
+Examples:
+  hermes compare "rsync -a src/ dst/" "cp -r src dst"
+  hermes compare "find . -name '*.tmp' -delete" "rm -rf *.tmp"`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		aiClient, err := appCtx.client()
+		if err != nil {
+			return err
+		}
+		defer aiClient.Close()
+
+		return compareCommands(cmd.Context(), cmd.OutOrStdout(), aiClient, args[0], args[1], appCtx.Config.Target)
+	},
+}
+
+// compareCommands writes the AI's comparison of two commands followed by
+// the local safety verdict for each
+func compareCommands(ctx context.Context, w io.Writer, aiClient ai.Client, first, second, target string) error {
+	fmt.Fprintf(w, "Comparing:\n  A: %s\n  B: %s\n", first, second)
+
+	ctx, span := trace.Start(ctx, "ai.compare")
+	response, err := aiClient.ExplainCommand(ctx, ai.ExplainRequest{Command: first, CompareWith: second})
+	span.RecordError(err)
+	span.End()
+
+	// Over budget: explain both from the offline database when it knows them
+	var exceeded budget.ExceededError
+	switch {
+	case errors.As(err, &exceeded):
+		explainedFirst, okFirst := flagdb.Explain(first)
+		explainedSecond, okSecond := flagdb.Explain(second)
+		if !okFirst || !okSecond {
+			return budgetExceeded(exceeded)
+		}
+		stdio.Infof("└─ %v; explaining both from the offline flag database\n", exceeded)
+		fmt.Fprintf(w, "\nA:\n%s\nB:\n%s", explainedFirst, explainedSecond)
+	case err != nil:
+		return providerError(err, "AI command comparison")
+	default:
+		fmt.Fprintf(w, "\nComparison:\n%s", strings.TrimRight(response.Explanation, "\n")+"\n")
+	}
+
+	printRiskComparison(ctx, w, first, second, target)
+	return nil
+}
+
+// printRiskComparison writes the safety level of both commands and which
+// one is riskier
+func printRiskComparison(ctx context.Context, w io.Writer, first, second, target string) {
+	analyzer := appCtx.analyzer(target)
+	firstResult, err := assessRisk(ctx, analyzer, first, target)
+	if err != nil {
+		return
+	}
+	secondResult, err := assessRisk(ctx, analyzer, second, target)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "\nRisk:\n")
+	fmt.Fprintf(w, "• A: %s (%s)\n", strings.ToUpper(firstResult.Level.String()), firstResult.Reason)
+	fmt.Fprintf(w, "• B: %s (%s)\n", strings.ToUpper(secondResult.Level.String()), secondResult.Reason)
+	switch {
+	case firstResult.Level > secondResult.Level:
+		fmt.Fprintf(w, "• A is riskier\n")
+	case secondResult.Level > firstResult.Level:
+		fmt.Fprintf(w, "• B is riskier\n")
+	default:
+		fmt.Fprintf(w, "• Both have the same safety level\n")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/safety"
+)
+
+// explainClient answers every explain request with a fixed text
+type explainClient struct {
+	explanation string
+	requests    []ai.ExplainRequest
+}
+
+func (c *explainClient) GenerateCommand(ctx context.Context, req ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	return &ai.GenerateResponse{}, nil
+}
+
+func (c *explainClient) ExplainCommand(ctx context.Context, req ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	c.requests = append(c.requests, req)
+	return &ai.ExplainResponse{Explanation: c.explanation}, nil
+}
+
+func (c *explainClient) Close() error { return nil }
+
+func TestCompareCommands(t *testing.T) {
+	client := &explainClient{explanation: "Behavior differences: rsync skips unchanged files"}
+	var out bytes.Buffer
+	err := compareCommands(context.Background(), &out, client, "rsync -a src/ dst/", "rm -rf dst && cp -r src dst", safety.TargetPosix)
+	if err != nil {
+		t.Fatalf("compareCommands() error = %v", err)
+	}
+
+	if len(client.requests) != 1 || client.requests[0].Command != "rsync -a src/ dst/" || client.requests[0].CompareWith != "rm -rf dst && cp -r src dst" {
+		t.Errorf("requests = %+v, want one comparison of both commands", client.requests)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"Comparing:\n  A: rsync -a src/ dst/\n  B: rm -rf dst && cp -r src dst\n",
+		"\nComparison:\nBehavior differences: rsync skips unchanged files\n",
+		"\nRisk:\n• A: SAFE",
+		"• B: ATTENTION",
+		"• B is riskier\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("comparison missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Package commands - guarding against absurdly long or complex commands
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"hermes/internal/ai"
+	"hermes/internal/safety"
+	"hermes/internal/shell"
+)
+
+// checkComplexity rejects a generated command (or plan step) with a line
+// longer than max_command_length characters or a pipeline of more than
+// max_pipeline_stages commands. Such monsters are usually hallucinations.
+// A limit of 0 turns that check off; pipelines are only counted for POSIX
+// targets.
+func checkComplexity(response *ai.GenerateResponse, target string) error {
+	commands := []string{response.Command}
+	for _, step := range response.Steps {
+		commands = append(commands, step.Command)
+	}
+	maxLength, maxStages := appCtx.Config.MaxCommandLength, appCtx.Config.MaxPipelineStages
+	for _, command := range commands {
+		for _, line := range strings.Split(command, "\n") {
+			if length := utf8.RuneCountInString(line); maxLength > 0 && length > maxLength {
+				return fmt.Errorf("a line of %d characters is longer than the limit of %d", length, maxLength)
+			}
+		}
+		if maxStages <= 0 || target == safety.TargetCmd {
+			continue
+		}
+		script, err := shell.Parse(command)
+		if err != nil {
+			continue // verifySyntax reports it
+		}
+		for _, pipeline := range script.Pipelines {
+			if len(pipeline.Stages) > maxStages {
+				return fmt.Errorf("a pipeline of %d commands is more than the limit of %d", len(pipeline.Stages), maxStages)
+			}
+		}
+	}
+	return nil
+}
+
+// simplerRequest asks the model to replace an overly complex command with
+// a simpler one or, when the task really needs that much, a short script
+func simplerRequest(req ai.GenerateRequest, command string, err error) ai.GenerateRequest {
+	note := fmt.Sprintf("Your previous command was too complex (%v):\n%s\nReturn a simpler command that does the job, or a short multi-line script with one step per line if the task really needs that much.", err, command)
+	if req.Context != "" {
+		note = req.Context + "\n\n" + note
+	}
+	req.Context = note
+	req.MultiLine = true
+	return req
+}
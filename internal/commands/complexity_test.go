@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+)
+
+func TestCheckComplexity(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{MaxCommandLength: 40, MaxPipelineStages: 3}}
+	t.Cleanup(func() { appCtx = nil })
+
+	tests := []struct {
+		command string
+		target  string
+		wantErr bool
+	}{
+		{"ls -la | sort | head", "posix", false},
+		{"ls -la | grep x | sort | head", "posix", true},
+		{"cd a && ls && cd b && ls", "posix", false},
+		{"echo " + strings.Repeat("x", 40), "posix", true},
+		{"cat <<EOF > notes.txt\n" + strings.Repeat("line ", 7) + "\nEOF", "posix", false},
+		{"dir | find \"x\" | sort | more", "cmd", false},
+	}
+	for _, tt := range tests {
+		err := checkComplexity(&ai.GenerateResponse{Command: tt.command}, tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkComplexity(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+		}
+	}
+
+	appCtx.Config = config.Config{}
+	if err := checkComplexity(&ai.GenerateResponse{Command: "a|b|c|d|e|f|g " + strings.Repeat("x", 600)}, "posix"); err != nil {
+		t.Errorf("checkComplexity() with the limits off error = %v", err)
+	}
+}
+
+func TestRunGenerationSimplifiesComplexCommand(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{MaxCommandLength: 500, MaxPipelineStages: 2}}
+	t.Cleanup(func() { appCtx = nil })
+
+	client := &sequenceClient{commands: []string{"cat log | grep x | sort | uniq -c", "grep x log | sort"}}
+	gen, err := runGeneration(context.Background(), client, ai.GenerateRequest{Query: "count x"})
+	if err != nil || gen.Command != "grep x log | sort" {
+		t.Fatalf("runGeneration() = %+v, %v, want the simpler command", gen, err)
+	}
+	if len(client.requests) != 2 || !strings.Contains(client.requests[1].Context, "pipeline of 4 commands") || !client.requests[1].MultiLine {
+		t.Errorf("retry request = %+v, want the problem in its context and scripts allowed", client.requests)
+	}
+
+	client = &sequenceClient{commands: []string{"a | b | c"}}
+	if _, err := runGeneration(context.Background(), client, ai.GenerateRequest{Query: "do it"}); err == nil {
+		t.Error("runGeneration() accepted a command that stays too complex")
+	}
+}
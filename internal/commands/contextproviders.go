@@ -0,0 +1,74 @@
+// Package commands - configurable context providers for generation
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"hermes/internal/config"
+	"hermes/internal/ctxprovider"
+	"hermes/internal/exit"
+	"hermes/internal/safety"
+)
+
+// validateContextProviders checks the [[context_provider]] tables: each
+// names a built-in or has a command, and names are unique
+func validateContextProviders(providers []config.ContextProvider) error {
+	seen := map[string]bool{}
+	for i, provider := range providers {
+		if provider.Name == "" {
+			return exit.NewError(exit.CodeConfig, "context_provider %d: missing name", i+1)
+		}
+		if seen[provider.Name] {
+			return exit.NewError(exit.CodeConfig, "context_provider %d: duplicate name: %s", i+1, provider.Name)
+		}
+		seen[provider.Name] = true
+		if _, builtin := ctxprovider.Builtins[provider.Name]; len(provider.Command) == 0 && !builtin {
+			return exit.NewError(exit.CodeConfig, "context_provider %s: no command and not a built-in (built-ins: %s)", provider.Name, strings.Join(ctxprovider.BuiltinNames(), ", "))
+		}
+		for _, profile := range provider.Profiles {
+			if profile == "" || !safety.Profile(profile).Valid() {
+				return exit.NewError(exit.CodeConfig, "context_provider %s: invalid profile: %s (supported: workstation, server, production)", provider.Name, profile)
+			}
+		}
+	}
+	return nil
+}
+
+// enabledContextProviders returns the configured providers enabled under
+// the risk profile of this host
+func enabledContextProviders(cfg *config.Config) []ctxprovider.Provider {
+	profile := riskProfile()
+	if profile == "" {
+		profile = safety.ProfileWorkstation
+	}
+	var providers []ctxprovider.Provider
+	for _, provider := range cfg.ContextProviders {
+		if len(provider.Profiles) > 0 && !slices.Contains(provider.Profiles, string(profile)) {
+			continue
+		}
+		if len(provider.Command) > 0 {
+			providers = append(providers, ctxprovider.Exec{ProviderName: provider.Name, Command: provider.Command})
+		} else if builtin, ok := ctxprovider.Builtins[provider.Name]; ok {
+			providers = append(providers, builtin)
+		}
+	}
+	return providers
+}
+
+// providedContext collects the sections of the enabled context providers;
+// a failing provider is reported on warnings and left out
+func providedContext(ctx context.Context, cfg *config.Config, warnings io.Writer) []string {
+	providers := enabledContextProviders(cfg)
+	if len(providers) == 0 {
+		return nil
+	}
+	sections, failures := ctxprovider.Collect(ctx, providers)
+	for _, failure := range failures {
+		fmt.Fprintf(warnings, "warning: %v\n", failure)
+	}
+	return sections
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/config"
+)
+
+func TestValidateContextProviders(t *testing.T) {
+	invalid := [][]config.ContextProvider{
+		{{Name: ""}},
+		{{Name: "kube"}},
+		{{Name: "git"}, {Name: "git"}},
+		{{Name: "cwd", Profiles: []string{"laptop"}}},
+	}
+	for _, providers := range invalid {
+		if err := validateContextProviders(providers); err == nil {
+			t.Errorf("validateContextProviders(%+v) accepted invalid providers", providers)
+		}
+	}
+	valid := []config.ContextProvider{{Name: "git"}, {Name: "kube", Command: []string{"kubectl", "config", "current-context"}, Profiles: []string{"server"}}}
+	if err := validateContextProviders(valid); err != nil {
+		t.Errorf("validateContextProviders() error = %v", err)
+	}
+}
+
+func TestProvidedContext(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{RiskProfile: "production", ContextProviders: []config.ContextProvider{
+		{Name: "desk", Command: []string{"echo", "desk only"}, Profiles: []string{"workstation"}},
+		{Name: "prod", Command: []string{"echo", "on call"}, Profiles: []string{"production", "server"}},
+		{Name: "fails", Command: []string{"false"}},
+	}}}
+	t.Cleanup(func() { appCtx = nil })
+
+	var warnings bytes.Buffer
+	sections := providedContext(context.Background(), &appCtx.Config, &warnings)
+	if len(sections) != 1 || sections[0] != "From prod:\non call" {
+		t.Errorf("sections = %q, want only the production provider", sections)
+	}
+	if !strings.Contains(warnings.String(), "warning: context provider fails:") {
+		t.Errorf("warnings = %q, want the failing provider reported", warnings.String())
+	}
+}
@@ -0,0 +1,116 @@
+// Package commands - cron subcommand
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/cron"
+	"hermes/internal/exit"
+	"hermes/internal/safety"
+)
+
+// cronCommandPlaceholder stands for the command when the request names none
+const cronCommandPlaceholder = "{command}"
+
+// cronCmd turns a schedule description into a crontab line
+var cronCmd = &cobra.Command{
+	Use:   "cron <schedule description>",
+	Short: "Generate a crontab line from a description",
+	Long: `Generate a crontab line from a plain-English schedule. The schedule is
+checked with a cron parser and shown with its next run times before the
+line is printed; the command part goes through the usual safety analysis.
+
+Examples:
+  hermes cron every weekday at 6:30
+  hermes cron "run /usr/local/bin/backup.sh every sunday at 3am"
+
+Use 'hermes explain' on a crontab line to read an existing schedule.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		description := strings.Join(args, " ")
+		consoleFor(cmd).Infof("└─ Generating crontab line for: '%s'\n", description)
+
+		aiClient, err := appCtx.client()
+		if err != nil {
+			return err
+		}
+		defer aiClient.Close()
+
+		ctx, out := cmd.Context(), consoleFor(cmd)
+		req := ai.GenerateRequest{
+			Query: fmt.Sprintf("Write one crontab line (minute hour day-of-month month day-of-week, then the command to run; no user field) that runs: %s. If no command is named, use %s as the command.", description, cronCommandPlaceholder),
+		}
+		response, err := generateCommand(ctx, aiClient, req)
+		if err != nil {
+			return err
+		}
+		schedule, command, ok := cron.Split(response.Command)
+		if !ok {
+			// Ask once more, quoting what the parser rejected
+			req.Context = fmt.Sprintf("Your previous answer was not a valid crontab line:\n%s\nThe first five fields must be a valid cron schedule.", response.Command)
+			if response, err = generateCommand(ctx, aiClient, req); err != nil {
+				return err
+			}
+			if schedule, command, ok = cron.Split(response.Command); !ok {
+				return exit.NewError(exit.CodeError, "AI generated an invalid crontab line: %s", response.Command)
+			}
+		}
+
+		if command == cronCommandPlaceholder || command == "" {
+			if answer := ask("Command to run", ""); answer != "" {
+				command = answer
+			} else {
+				command = cronCommandPlaceholder
+			}
+		}
+		line := schedule.Expr + " " + command
+
+		describeSchedule(out.Err, schedule)
+
+		// The command runs unattended, so its safety matters as much as
+		// anything typed at the prompt
+		result := safety.Result{Level: safety.Safe}
+		if command != cronCommandPlaceholder {
+			if result, err = safety.NewAnalyzer().AnalyzeCommand(ctx, command); err != nil {
+				return exit.NewError(exit.CodeError, "Safety analysis failed: %v", err)
+			}
+			if result.Level >= safety.Attention {
+				fmt.Fprintf(out.Err, "└─ attention: %s\n", result.Reason)
+			}
+		}
+
+		out.Resultf("%s\n", line)
+		recordVerdict(result)
+		if exitCode := safetyExitCode(result.Level); exitCode != exit.CodeSuccess {
+			return exit.NewError(exitCode, "")
+		}
+		return nil
+	},
+}
+
+// describeSchedule prints a schedule in plain English with its next runs
+func describeSchedule(w io.Writer, schedule *cron.Schedule) {
+	fmt.Fprintf(w, "└─ schedule: %s\n", schedule.Describe())
+	var runs []string
+	next := time.Now()
+	for i := 0; i < 3; i++ {
+		if next = schedule.Next(next); next.IsZero() {
+			break
+		}
+		runs = append(runs, next.Format("Mon 2006-01-02 15:04"))
+	}
+	if len(runs) > 0 {
+		fmt.Fprintf(w, "└─ next runs: %s\n", strings.Join(runs, ", "))
+	} else if !schedule.Reboot {
+		fmt.Fprintf(w, "└─ warning: this schedule never runs\n")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(cronCmd)
+}
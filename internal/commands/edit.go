@@ -0,0 +1,68 @@
+// Package commands - opening generated commands in an editor
+package commands
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"hermes/internal/exit"
+)
+
+// editorCommand returns the user's editor: $VISUAL, then $EDITOR, then vi
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+// runEditor opens path in the editor on the terminal. Stdout is captured
+// by the shell integration, so the editor talks to /dev/tty instead. It is
+// replaced in tests.
+var runEditor = func(ctx context.Context, editor, path string) error {
+	// Through sh so editors with arguments ("code --wait") work
+	cmd := exec.CommandContext(ctx, "sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		defer tty.Close()
+		cmd.Stdin, cmd.Stdout = tty, tty
+	}
+	return cmd.Run()
+}
+
+// editCommand lets the user change a command in their editor and returns
+// the edited command. Emptying the file discards the command.
+func editCommand(ctx context.Context, command string) (string, error) {
+	file, err := os.CreateTemp("", "hermes-*.sh")
+	if err != nil {
+		return "", exit.NewError(exit.CodeError, "Failed to create a file to edit: %v", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+	_, err = file.WriteString(command + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", exit.NewError(exit.CodeError, "Failed to write the command to edit: %v", err)
+	}
+
+	editor := editorCommand()
+	if err := runEditor(ctx, editor, path); err != nil {
+		return "", exit.NewError(exit.CodeError, "Editor %s failed: %v", editor, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", exit.NewError(exit.CodeError, "Failed to read the edited command: %v", err)
+	}
+
+	edited := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), " \t\n")
+	if strings.TrimSpace(edited) == "" {
+		return "", exit.NewError(exit.CodeAborted, "the edited command is empty, nothing to place")
+	}
+	return edited, nil
+}
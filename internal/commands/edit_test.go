@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"hermes/internal/exit"
+)
+
+func TestEditCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nano -w")
+	saved := runEditor
+	t.Cleanup(func() { runEditor = saved })
+
+	var gotEditor string
+	runEditor = func(ctx context.Context, editor, path string) error {
+		gotEditor = editor
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if string(data) != "rm -rf build\n" {
+			t.Errorf("editor opened %q, want the command", data)
+		}
+		return os.WriteFile(path, []byte("rm -ri build\r\n\n"), 0o600)
+	}
+	edited, err := editCommand(context.Background(), "rm -rf build")
+	if err != nil {
+		t.Fatalf("editCommand() error = %v", err)
+	}
+	if edited != "rm -ri build" {
+		t.Errorf("editCommand() = %q, want %q", edited, "rm -ri build")
+	}
+	if gotEditor != "nano -w" {
+		t.Errorf("editor = %q, want $EDITOR", gotEditor)
+	}
+
+	// Emptying the file discards the command
+	runEditor = func(ctx context.Context, editor, path string) error {
+		return os.WriteFile(path, []byte("\n"), 0o600)
+	}
+	_, err = editCommand(context.Background(), "rm -rf build")
+	var exitErr exit.Error
+	if !errors.As(err, &exitErr) || exitErr.Code != exit.CodeAborted {
+		t.Errorf("editCommand() with an empty file error = %v, want exit code %d", err, exit.CodeAborted)
+	}
+}
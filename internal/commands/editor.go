@@ -0,0 +1,133 @@
+// Package commands - editor integration mode
+package commands
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/config"
+	"hermes/internal/editor"
+	"hermes/internal/safety"
+)
+
+// editorGenerateResult is the result payload of a generate request
+type editorGenerateResult struct {
+	Command      string               `json:"command"`
+	Safety       string               `json:"safety"`
+	Reason       string               `json:"reason"`
+	ExitCode     int                  `json:"exit_code"`
+	Explanation  string               `json:"explanation,omitempty"`
+	Lint         []string             `json:"lint,omitempty"`
+	Alternatives []safety.Alternative `json:"alternatives,omitempty"` // Safer variants of an Attention-level command
+	Undo         string               `json:"undo,omitempty"`         // How to recover from an Attention-level command
+	Impact       []string             `json:"impact,omitempty"`       // Files the command would delete or change
+}
+
+// editorExplainResult is the result payload of an explain request
+type editorExplainResult struct {
+	Explanation string `json:"explanation"`
+}
+
+// editorHandler serves editor protocol requests with a shared AI client.
+// Requests hold mu for reading, so a config reload waits for those in
+// flight before it swaps the configuration and client.
+type editorHandler struct {
+	mu     sync.RWMutex
+	client ai.Client
+}
+
+// Generate runs the full generate pipeline for an editor request
+func (h *editorHandler) Generate(ctx context.Context, params editor.GenerateParams) (interface{}, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	result, err := runGeneration(ctx, h.client, ai.GenerateRequest{
+		Query:          params.Query,
+		Verbose:        params.Verbose,
+		Target:         appCtx.Config.Target,
+		POSIX:          appCtx.Config.POSIX,
+		MultiLine:      appCtx.Config.MultiLine,
+		PackageManager: localPackageManager(appCtx.Config.Target),
+		Preset:         appCtx.Config.Preset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []string
+	for _, finding := range result.Lint.Findings {
+		findings = append(findings, finding.String())
+	}
+
+	return editorGenerateResult{
+		Command:      result.Command,
+		Safety:       result.Safety.Level.String(),
+		Reason:       result.Safety.Reason,
+		ExitCode:     safetyExitCode(result.Safety.Level),
+		Explanation:  result.Response.Explanation,
+		Lint:         findings,
+		Alternatives: saferAlternatives(result.Command, result.Safety, appCtx.Config.Target),
+		Undo:         undoHint(result.Command, result.Safety, result.Response.Undo, appCtx.Config.Target),
+		Impact:       impactEstimates(ctx, result.Command, appCtx.Config.Target),
+	}, nil
+}
+
+// Explain explains a command for an editor request
+func (h *editorHandler) Explain(ctx context.Context, params editor.ExplainParams) (interface{}, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	response, err := h.client.ExplainCommand(ctx, ai.ExplainRequest{
+		Command: params.Command,
+		Level:   appCtx.Config.ExperienceLevel,
+	})
+	if err != nil {
+		return nil, providerError(err, "AI command explanation")
+	}
+	return editorExplainResult{Explanation: response.Explanation}, nil
+}
+
+// runEditorMode serves the JSON-over-stdio editor protocol until stdin closes
+func runEditorMode(cmd *cobra.Command) error {
+	editorModeConfig(&appCtx.Config)
+
+	aiClient, err := appCtx.client()
+	if err != nil {
+		return err
+	}
+	handler := &editorHandler{client: aiClient}
+	defer func() { handler.client.Close() }()
+
+	// Pick up provider, model and safety settings without a restart
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	watchConfig(ctx, cmd, handler.reload)
+
+	return editor.Serve(ctx, cmd.InOrStdin(), cmd.OutOrStdout(), handler)
+}
+
+// editorModeConfig adjusts a configuration for editor mode: stdout carries
+// protocol messages only, so debug output must stay off, and stdin carries
+// requests, so hermes must never prompt
+func editorModeConfig(cfg *config.Config) {
+	cfg.Debug = false
+	cfg.NonInteractive.Enabled = true
+}
+
+// reload switches to a changed configuration once the requests in flight
+// are done. A configuration the AI client cannot be built from is
+// rejected, keeping the previous one.
+func (h *editorHandler) reload(cfg config.Config) error {
+	editorModeConfig(&cfg)
+	client, err := appCtx.clientFor(&cfg)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	previous := h.client
+	h.client = client
+	appCtx.Config = cfg
+	h.mu.Unlock()
+	return previous.Close()
+}
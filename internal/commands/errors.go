@@ -0,0 +1,155 @@
+// Package commands - remediation hints for failed provider calls
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"hermes/internal/ai"
+	"hermes/internal/exit"
+)
+
+// failureHint explains a known kind of provider failure: what went wrong,
+// how to fix it and where to read more
+type failureHint struct {
+	Code    int    // Exit code for this kind of failure
+	Summary string // Short cause, e.g. "quota exceeded: gemini is rate limiting the API key"
+	Fix     string
+	Docs    string // Optional
+}
+
+// Provider documentation the hints point at
+const (
+	geminiRateLimitDocs = "https://ai.google.dev/gemini-api/docs/rate-limits"
+	geminiAPIKeyDocs    = "https://ai.google.dev/gemini-api/docs/api-key"
+	geminiTroubleDocs   = "https://ai.google.dev/gemini-api/docs/troubleshooting"
+	ollamaDocs          = "https://github.com/ollama/ollama/blob/main/docs/faq.md"
+)
+
+// providerFailure returns the hint for a size limit, quota, invalid key,
+// network or timeout failure. provider is used when the error does not
+// name one.
+// Other errors have no hint and are reported as they are.
+func providerFailure(err error, provider string) (failureHint, bool) {
+	var sizeErr ai.SizeError
+	if errors.As(err, &sizeErr) {
+		return sizeHint(sizeErr), true
+	}
+
+	var apiErr ai.APIError
+	var netErr ai.NetworkError
+	switch {
+	case errors.As(err, &apiErr):
+		provider = apiErr.Provider
+	case errors.As(err, &netErr):
+		provider = netErr.Provider
+	}
+	if provider == "" {
+		provider = "the AI provider"
+	}
+
+	switch {
+	case errors.As(err, &apiErr) && isQuotaError(apiErr):
+		hint := failureHint{
+			Code:    exit.CodeRateLimit,
+			Summary: fmt.Sprintf("quota exceeded: %s is rate limiting requests", provider),
+			Fix:     "wait a minute and try again, or switch providers with --provider",
+		}
+		if provider == "gemini" {
+			hint.Summary = "quota exceeded: gemini is rate limiting the API key"
+			hint.Fix = "wait a minute and try again, or enable billing on the key's Google Cloud project for higher limits"
+			hint.Docs = geminiRateLimitDocs
+		}
+		return hint, true
+
+	case errors.As(err, &apiErr) && isInvalidKeyError(apiErr):
+		return failureHint{
+			Code:    exit.CodeConfig,
+			Summary: fmt.Sprintf("invalid API key: %s rejected the configured key", provider),
+			Fix:     "create a new key and set it with GEMINI_API_KEY, --gemini-api-key or gemini_api_key in ~/.config/hermes/config.toml, then run 'hermes auth test'",
+			Docs:    geminiAPIKeyDocs,
+		}, true
+
+	case errors.Is(err, context.DeadlineExceeded):
+		return failureHint{
+			Code:    exit.CodeTimeout,
+			Summary: fmt.Sprintf("could not reach %s in time", provider),
+			Fix:     "try again, or check the connection with 'hermes providers ping'",
+			Docs:    providerDocs(provider),
+		}, true
+
+	case errors.As(err, &netErr):
+		hint := failureHint{
+			Code:    exit.CodeError,
+			Summary: fmt.Sprintf("could not reach %s", provider),
+			Fix:     "check the internet connection and any proxy settings (HTTPS_PROXY), or use a local model with --provider ollama",
+			Docs:    providerDocs(provider),
+		}
+		if provider == "ollama" {
+			hint.Fix = "start Ollama with 'ollama serve', or point ollama.url in ~/.config/hermes/config.toml at the running server"
+		}
+		return hint, true
+	}
+	return failureHint{}, false
+}
+
+// sizeHint explains a request or response over the configured [limits]
+func sizeHint(err ai.SizeError) failureHint {
+	hint := failureHint{
+		Code:    exit.CodeError,
+		Summary: "input too large",
+		Fix:     "pass less input (e.g. through head -c 100k), or raise limits.max_query_bytes in ~/.config/hermes/config.toml",
+	}
+	switch err.What {
+	case "context":
+		hint.Fix = "turn off some context (history, dir_context, explain_env), or raise limits.max_context_bytes in ~/.config/hermes/config.toml"
+	case "response":
+		hint.Summary = "response too large"
+		hint.Fix = "try again with a narrower request, or raise limits.max_response_bytes in ~/.config/hermes/config.toml"
+	}
+	return hint
+}
+
+// isQuotaError reports whether the provider refused the call for quota or
+// rate limit reasons
+func isQuotaError(err ai.APIError) bool {
+	lower := strings.ToLower(err.Message)
+	return err.StatusCode == http.StatusTooManyRequests ||
+		strings.Contains(lower, "resource_exhausted") || strings.Contains(lower, "quota")
+}
+
+// isInvalidKeyError reports whether the provider rejected the API key
+// itself. Gemini answers an invalid key with 400 and says so in the message.
+func isInvalidKeyError(err ai.APIError) bool {
+	lower := strings.ToLower(err.Message)
+	return err.StatusCode == http.StatusUnauthorized ||
+		strings.Contains(lower, "api key not valid") || strings.Contains(lower, "api_key_invalid")
+}
+
+// providerDocs points at a provider's troubleshooting documentation
+func providerDocs(provider string) string {
+	switch provider {
+	case "gemini":
+		return geminiTroubleDocs
+	case "ollama":
+		return ollamaDocs
+	}
+	return ""
+}
+
+// exitError formats the hint as an exit error. what names the failed call
+// (e.g. "AI command generation") and may be empty; err is kept as the cause.
+func (h failureHint) exitError(what string, err error) error {
+	var message strings.Builder
+	if what != "" {
+		message.WriteString(what + " failed: ")
+	}
+	fmt.Fprintf(&message, "%s\n  cause: %v\n  fix:   %s", h.Summary, err, h.Fix)
+	if h.Docs != "" {
+		fmt.Fprintf(&message, "\n  docs:  %s", h.Docs)
+	}
+	return exit.NewError(h.Code, "%s", message.String())
+}
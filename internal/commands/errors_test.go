@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/exit"
+)
+
+func TestProviderErrorHints(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		want     []string
+	}{
+		{"gemini quota", ai.APIError{Provider: "gemini", StatusCode: 429, Message: "Resource has been exhausted (e.g. check quota)."}, exit.CodeRateLimit,
+			[]string{"AI command generation failed: quota exceeded", "cause: gemini API error: Resource has been exhausted", "fix:   wait a minute", "docs:  " + geminiRateLimitDocs}},
+		{"invalid key", ai.APIError{Provider: "gemini", StatusCode: 400, Message: "API key not valid. Please pass a valid API key."}, exit.CodeConfig,
+			[]string{"invalid API key: gemini rejected the configured key", "GEMINI_API_KEY", "docs:  " + geminiAPIKeyDocs}},
+		{"ollama down", ai.NetworkError{Provider: "ollama", Err: errors.New("connection refused")}, exit.CodeError,
+			[]string{"could not reach ollama", "cause: ollama network error: connection refused", "ollama serve", "docs:  " + ollamaDocs}},
+		{"timeout", ai.NetworkError{Provider: "mock", Err: context.DeadlineExceeded}, exit.CodeTimeout,
+			[]string{"could not reach mock in time", "hermes providers ping"}},
+		{"input too large", ai.SizeError{What: "query", Size: 50 << 20, Limit: 256 << 10}, exit.CodeError,
+			[]string{"input too large", "cause: the query is 50.0 MiB, over the 256.0 KiB limit", "limits.max_query_bytes"}},
+	}
+	for _, tt := range tests {
+		var exitErr exit.Error
+		if !errors.As(providerError(tt.err, "AI command generation"), &exitErr) {
+			t.Fatalf("%s: providerError() did not return an exit.Error", tt.name)
+		}
+		if exitErr.Code != tt.wantCode {
+			t.Errorf("%s: Code = %d, want %d", tt.name, exitErr.Code, tt.wantCode)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(exitErr.Error(), want) {
+				t.Errorf("%s: message misses %q:\n%s", tt.name, want, exitErr.Error())
+			}
+		}
+	}
+
+	// Other failures are reported as they are
+	err := providerError(ai.APIError{Provider: "gemini", StatusCode: 500, Message: "internal"}, "AI command generation")
+	if strings.Contains(err.Error(), "fix:") {
+		t.Errorf("providerError() added a hint to an unknown failure: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package commands - eval subcommand
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/eval"
+	"hermes/internal/exit"
+)
+
+// evalCmd represents the eval command
+var evalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Run a prompt evaluation suite against the configured provider",
+	Long: `Run a corpus of natural-language queries through the full generation
+pipeline (provider, lint, safety analysis) and check each generated command
+against the suite's expectations.
+
+Each case can assert an exact command (expect), a regex the command must
+match (match) or must not match (not_match), and the expected safety
+verdict (safety). Suites are TOML or JSON files.
+
+Examples:
+  hermes eval --suite suites/basic.toml
+  hermes eval --suite suites/basic.toml --min-pass-rate 0.9
+  hermes eval --suite suites/basic.toml --temperature 0 --seed 1`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		suitePath, _ := cmd.Flags().GetString("suite")
+		minPassRate, _ := cmd.Flags().GetFloat64("min-pass-rate")
+		if minPassRate < 0 || minPassRate > 1 {
+			return exit.NewError(exit.CodeConfig, "--min-pass-rate must be between 0 and 1")
+		}
+
+		suite, err := eval.Load(suitePath)
+		if err != nil {
+			return exit.NewError(exit.CodeConfig, "%v", err)
+		}
+
+		// Create AI client (handles validation and debug logging)
+		aiClient, err := appCtx.client()
+		if err != nil {
+			return err
+		}
+		defer aiClient.Close()
+
+		report := &eval.Report{Suite: suite.Name}
+		for i := range suite.Cases {
+			c := &suite.Cases[i]
+			target := c.Target
+			if target == "" {
+				target = appCtx.Config.Target
+			}
+
+			result := eval.Result{Case: c}
+			gen, err := runGeneration(cmd.Context(), aiClient, ai.GenerateRequest{Query: c.Query, Target: target, POSIX: appCtx.Config.POSIX})
+			if err != nil {
+				result.Err = err
+			} else {
+				result.Command = gen.Command
+				result.Level = gen.Safety.Level
+				result.Failures = c.Check(gen.Command, gen.Safety.Level)
+			}
+			report.Results = append(report.Results, result)
+			printEvalResult(cmd.OutOrStdout(), result)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "\n%s: %d/%d passed (%.0f%%)\n", report.Suite, report.Passed(), len(report.Results), report.PassRate()*100)
+		if report.PassRate() < minPassRate {
+			return exit.NewError(exit.CodeError, "pass rate %.0f%% is below the required %.0f%%", report.PassRate()*100, minPassRate*100)
+		}
+		return nil
+	},
+}
+
+// printEvalResult prints one case outcome with its failed assertions
+func printEvalResult(w io.Writer, result eval.Result) {
+	status := "PASS"
+	if !result.Passed() {
+		status = "FAIL"
+	}
+
+	switch {
+	case result.Err != nil:
+		fmt.Fprintf(w, "%s  %s\n", status, result.Case.Query)
+		fmt.Fprintf(w, "      └─ %v\n", result.Err)
+	default:
+		fmt.Fprintf(w, "%s  %s → %s [%s]\n", status, result.Case.Query, result.Command, result.Level)
+		if len(result.Failures) > 0 {
+			fmt.Fprintf(w, "      └─ %s\n", strings.Join(result.Failures, "; "))
+		}
+	}
+}
+
+func init() {
+	evalCmd.Flags().String("suite", "", "Evaluation suite file (TOML or JSON)")
+	evalCmd.Flags().Float64("min-pass-rate", 1, "Fail (exit 1) when the pass rate is below this fraction")
+	_ = evalCmd.MarkFlagRequired("suite")
+	rootCmd.AddCommand(evalCmd)
+}
@@ -0,0 +1,105 @@
+// Package commands - exit-codes subcommand
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/config"
+	"hermes/internal/exit"
+)
+
+// exitCodesCmd documents the exit codes for scripts and integrations
+var exitCodesCmd = &cobra.Command{
+	Use:   "exit-codes",
+	Short: "List the exit codes hermes uses",
+	Long: `List every exit code hermes uses with a stable name and its meaning, so
+scripts and editor integrations can react to each case.
+
+Examples:
+  hermes exit-codes          # Table for humans
+  hermes exit-codes --json   # For tools
+
+The [exit_codes] table moves the success and attention codes to other
+numbers (e.g., attention = 20) for wrappers that reserve 10; the list
+shows the codes after that mapping. They may take one of the narrow
+failure codes 3-7 (attention = 3, say), which then no longer tells that
+failure apart; 1, 2 and 130 stay reserved. In non-interactive mode the
+Attention code can be remapped again with
+non_interactive.attention_exit_code.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var codes config.ExitCodes
+		if appCtx != nil {
+			codes = appCtx.Config.ExitCodes
+		}
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			return printExitCodesJSON(cmd.OutOrStdout(), codes)
+		}
+		printExitCodes(cmd.OutOrStdout(), codes)
+		return nil
+	},
+}
+
+// mappedRegistry returns the registry with the success and attention
+// codes moved as [exit_codes] says, in numeric order
+func mappedRegistry(codes config.ExitCodes) []exit.Info {
+	safe, attention := mappedExitCodes(codes)
+	registry := slices.Clone(exit.Codes)
+	for i, info := range registry {
+		switch info.Code {
+		case exit.CodeSuccess:
+			registry[i].Code = safe
+		case exit.CodeDangerous:
+			registry[i].Code = attention
+		}
+	}
+	slices.SortStableFunc(registry, func(a, b exit.Info) int { return a.Code - b.Code })
+	return registry
+}
+
+// printExitCodes prints the registry as an aligned table, marking codes
+// moved by [exit_codes] and failure codes a level now shares
+func printExitCodes(w io.Writer, codes config.ExitCodes) {
+	safe, attention := mappedExitCodes(codes)
+	for _, info := range mappedRegistry(codes) {
+		note := ""
+		switch {
+		case info.Name == "success" && info.Code != exit.CodeSuccess:
+			note = fmt.Sprintf(" (exit_codes.safe, default %d)", exit.CodeSuccess)
+		case info.Name == "attention" && info.Code != exit.CodeDangerous:
+			note = fmt.Sprintf(" (exit_codes.attention, default %d)", exit.CodeDangerous)
+		case info.Name != "success" && info.Name != "attention" && (info.Code == safe || info.Code == attention):
+			note = " (shared with a safety level by exit_codes)"
+		}
+		fmt.Fprintf(w, "%3d  %-12s %s%s\n", info.Code, info.Name, info.Meaning, note)
+	}
+}
+
+// printExitCodesJSON prints the registry as a JSON array
+func printExitCodesJSON(w io.Writer, codes config.ExitCodes) error {
+	type entry struct {
+		Code    int    `json:"code"`
+		Name    string `json:"name"`
+		Meaning string `json:"meaning"`
+	}
+	registry := mappedRegistry(codes)
+	entries := make([]entry, len(registry))
+	for i, info := range registry {
+		entries[i] = entry(info)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return exit.NewError(exit.CodeError, "%v", err)
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(exitCodesCmd)
+	exitCodesCmd.Flags().Bool("json", false, "Print the codes as JSON")
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hermes/internal/config"
+	"hermes/internal/exit"
+	"hermes/internal/safety"
+)
+
+func TestExitCodeRegistry(t *testing.T) {
+	names := map[string]bool{}
+	for i, info := range exit.Codes {
+		if i > 0 && info.Code <= exit.Codes[i-1].Code {
+			t.Errorf("code %d listed after %d; keep the registry in numeric order", info.Code, exit.Codes[i-1].Code)
+		}
+		if names[info.Name] {
+			t.Errorf("name %q used twice", info.Name)
+		}
+		names[info.Name] = true
+	}
+
+	// Every code hermes returns is documented
+	for _, code := range []int{exit.CodeTimeout, exit.CodeRateLimit, exit.CodeForbidden, exit.CodeOffline, exit.CodeAborted, exit.CodeDangerous, exit.CodeInterrupted} {
+		if _, ok := exit.Lookup(code); !ok {
+			t.Errorf("exit code %d is not in the registry", code)
+		}
+	}
+}
+
+func TestPrintExitCodes(t *testing.T) {
+	var table bytes.Buffer
+	printExitCodes(&table, config.ExitCodes{})
+	if !strings.Contains(table.String(), "  4  rate-limit ") {
+		t.Errorf("table misses the rate-limit row:\n%s", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := printExitCodesJSON(&out, config.ExitCodes{}); err != nil {
+		t.Fatal(err)
+	}
+	var entries []struct {
+		Code int    `json:"code"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(entries) != len(exit.Codes) || entries[len(entries)-1].Code != exit.CodeInterrupted {
+		t.Errorf("JSON entries = %+v", entries)
+	}
+}
+
+func TestPrintExitCodesMapped(t *testing.T) {
+	var table bytes.Buffer
+	printExitCodes(&table, config.ExitCodes{Attention: 20})
+	if !strings.Contains(table.String(), " 20  attention ") || !strings.Contains(table.String(), "(exit_codes.attention, default 10)") {
+		t.Errorf("table does not show the remapped attention code:\n%s", table.String())
+	}
+	if strings.Contains(table.String(), " 10  ") {
+		t.Errorf("table still lists code 10:\n%s", table.String())
+	}
+
+	table.Reset()
+	printExitCodes(&table, config.ExitCodes{Attention: 3})
+	if !strings.Contains(table.String(), "(shared with a safety level by exit_codes)") {
+		t.Errorf("table does not mark the timeout code attention now shares:\n%s", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := printExitCodesJSON(&out, config.ExitCodes{Safe: 50, Attention: 51}); err != nil {
+		t.Fatal(err)
+	}
+	var entries []struct {
+		Code int    `json:"code"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if first, last := entries[0], entries[len(entries)-1]; first.Name != "error" || last.Code != exit.CodeInterrupted || entries[len(entries)-3].Code != 50 || entries[len(entries)-2].Code != 51 {
+		t.Errorf("JSON entries = %+v, want the mapped codes in numeric order", entries)
+	}
+}
+
+func TestValidateExitCodes(t *testing.T) {
+	tests := []struct {
+		codes   config.ExitCodes
+		wantErr string
+	}{
+		{config.ExitCodes{}, ""},
+		{config.ExitCodes{Safe: 0, Attention: 10}, ""},
+		{config.ExitCodes{Attention: 20}, ""},
+		{config.ExitCodes{Safe: 50, Attention: 51}, ""},
+		{config.ExitCodes{Safe: 10, Attention: 20}, ""},
+		// The narrow failure codes may be given up for a level
+		{config.ExitCodes{Attention: 3}, ""},
+		{config.ExitCodes{Safe: 5}, ""},
+		{config.ExitCodes{Attention: 7}, ""},
+		{config.ExitCodes{Attention: 1}, "reserved for error"},
+		{config.ExitCodes{Safe: 2}, "reserved for config"},
+		{config.ExitCodes{Safe: 130}, "reserved for interrupted"},
+		{config.ExitCodes{Safe: 20, Attention: 20}, "must differ"},
+		{config.ExitCodes{Attention: 256}, "between 0 and 255"},
+	}
+	for _, tt := range tests {
+		err := validateExitCodes(tt.codes)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("validateExitCodes(%+v) = %v, want nil", tt.codes, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("validateExitCodes(%+v) = %v, want error containing %q", tt.codes, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateAttentionExitCode(t *testing.T) {
+	tests := []struct {
+		code    int
+		codes   config.ExitCodes
+		wantErr string
+	}{
+		{0, config.ExitCodes{}, ""},
+		{20, config.ExitCodes{}, ""},
+		{3, config.ExitCodes{}, ""},
+		{1, config.ExitCodes{}, "reserved for error"},
+		{130, config.ExitCodes{}, "reserved for interrupted"},
+		{256, config.ExitCodes{}, "between 0 and 255"},
+		{-1, config.ExitCodes{}, "between 0 and 255"},
+		{20, config.ExitCodes{Safe: 20, Attention: 21}, "must differ from the safe code 20"},
+	}
+	for _, tt := range tests {
+		err := validateAttentionExitCode(tt.code, tt.codes)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("validateAttentionExitCode(%d, %+v) = %v, want nil", tt.code, tt.codes, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("validateAttentionExitCode(%d, %+v) = %v, want error containing %q", tt.code, tt.codes, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReadConfigAttentionExitCode(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	orig := systemConfigPath
+	systemConfigPath = filepath.Join(dir, "system.toml")
+	t.Cleanup(func() { systemConfigPath = orig })
+	if err := os.MkdirAll(filepath.Dir(configPath()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(configPath(), []byte("[non_interactive]\nattention_exit_code = 130\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var exitErr exit.Error
+	if _, err := readConfig(rootCmd); !errors.As(err, &exitErr) || exitErr.Code != exit.CodeConfig {
+		t.Errorf("readConfig() with attention_exit_code = 130 error = %v, want a config error", err)
+	}
+}
+
+func TestSafetyExitCodeMapping(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{ExitCodes: config.ExitCodes{Attention: 20}}}
+	t.Cleanup(func() { appCtx = nil })
+	if got := safetyExitCode(safety.Safe); got != exit.CodeSuccess {
+		t.Errorf("safe = %d, want %d", got, exit.CodeSuccess)
+	}
+	if got := safetyExitCode(safety.Attention); got != 20 {
+		t.Errorf("attention = %d, want 20", got)
+	}
+
+	// The non-interactive code still wins in strict mode
+	appCtx.Config.NonInteractive = config.NonInteractive{Enabled: true, AttentionExitCode: 42}
+	if got := safetyExitCode(safety.Attention); got != 42 {
+		t.Errorf("non-interactive attention = %d, want 42", got)
+	}
+}
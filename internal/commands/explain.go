@@ -2,14 +2,36 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 	"hermes/internal/ai"
+	"hermes/internal/budget"
+	"hermes/internal/config"
+	"hermes/internal/cron"
+	"hermes/internal/dataflow"
+	"hermes/internal/envref"
 	"hermes/internal/exit"
+	"hermes/internal/exitstatus"
+	"hermes/internal/explaincache"
+	"hermes/internal/flagdb"
+	"hermes/internal/manpage"
+	"hermes/internal/sysfile"
+	"hermes/internal/trace"
 )
 
+// maxConfigFileBytes caps how much of a configuration file explain reads
+const maxConfigFileBytes = 256 * 1024
+
 // explainCmd represents the explain command
 var explainCmd = &cobra.Command{
 	Use:     "explain [command]",
@@ -30,6 +52,28 @@ Examples:
   hermes explain "find . -name '*.go'"         # Explain a find command
   hermes exp grep -r "TODO" --include="*.py"   # Explain a complex grep
   hermes explain tar -czf archive.tar.gz dir/  # Explain a tar command
+  hermes exp "30 6 * * 1-5 /opt/backup.sh"     # Explain a crontab line
+  hermes exp --exit-code 137 -- docker run app # Why did it exit with 137?
+  hermes exp --env 'ls $HOME/src'              # Include the value of $HOME
+  hermes exp --annotate tar -czf b.tgz src     # Numbered markers and a legend
+  hermes exp --file /etc/systemd/system/backup.service
+  sudo cat /etc/sudoers | hermes exp           # Explain a sudoers file
+
+Common commands are explained offline from an embedded flag database;
+unknown commands and complex pipelines go to the AI (use --ai to always
+ask the AI). Without an API key or provider, explain falls back to the
+flag database and the local manual pages. Pipelines of three or more stages also get an ASCII
+data-flow diagram showing what passes between the stages. Every
+explanation ends with a risk assessment: the safety level, the parts that
+need attention, safer alternatives and how to undo the command.
+
+Systemd unit files, crontabs, fstab and sudoers entries given with --file
+or on standard input are recognized and explained entry by entry, with
+warnings about risky settings.
+
+Flags for explain itself go before the command; everything from the
+first word of the command on belongs to the command, so
+"hermes explain docker build --file Dockerfile.prod ." explains docker.
 
 Note: You can use quotes around the command or the delimiter (--)
 if the commands contains special characters or flags or you want to be
@@ -39,35 +83,397 @@ explicit about the command boundaries.`,
 	FParseErrWhitelist: cobra.FParseErrWhitelist{
 		UnknownFlags: true,
 	},
-	Args:               cobra.MinimumNArgs(1), // Require at least one argument
+	Args: func(cmd *cobra.Command, args []string) error {
+		// Without arguments the input is a file or standard input
+		if cmd.Flags().Changed("file") || len(args) > 0 {
+			return nil
+		}
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := consoleFor(cmd)
 		command := strings.Join(args, " ")
-		fmt.Printf("Explaining command: '%s'\n", command)
+		if path, _ := cmd.Flags().GetString("file"); path != "" || len(args) == 0 {
+			text, err := readExplainInput(path)
+			if err != nil {
+				return err
+			}
+			// Configuration files get a structured explanation; anything
+			// else is explained as a command
+			if format, ok := sysfile.Detect(text); ok {
+				forceAI, _ := cmd.Flags().GetBool("ai")
+				return explainConfigFile(cmd.Context(), out, format, text, forceAI)
+			}
+			command = strings.TrimSpace(text)
+		}
+		if cmd.Flags().Changed("exit-code") {
+			code, _ := cmd.Flags().GetInt("exit-code")
+			return explainExitCode(cmd.Context(), out, command, code)
+		}
+		out.Infof("Explaining command: '%s'\n", command)
+		
+		// Crontab lines: explain the schedule offline, then the command
+		if schedule, rest, ok := cron.Split(command); ok {
+			describeSchedule(out.Out, schedule)
+			if rest == "" {
+				return nil
+			}
+			command = rest
+		}
+
+		// Referenced environment variables with their current values
+		var environment []string
+		if showEnv, _ := cmd.Flags().GetBool("env"); showEnv || appCtx.Config.ExplainEnv {
+			variables := envref.Resolve(envref.References(command), os.LookupEnv)
+			printEnvironment(out.Out, variables)
+			for _, variable := range variables {
+				environment = append(environment, variable.String())
+			}
+		}
+		
+		forceAI, _ := cmd.Flags().GetBool("ai")
+		if annotate, _ := cmd.Flags().GetBool("annotate"); annotate {
+			return explainAnnotated(cmd.Context(), out, command, environment, forceAI)
+		}
+
+		// Answer common commands from the embedded flag database, reserving
+		// the AI for unknown commands and complex pipelines
+		if appCtx.Config.OfflineExplain && !forceAI {
+			if explanation, ok := flagdb.Explain(command); ok {
+				printExplanation(cmd.Context(), out, command, explanation)
+				return nil
+			}
+		}
+		
+		// Commands explained before with the same model are answered from
+		// the cache; explanations that depend on variable values are not kept
+		var cache *explaincache.Cache
+		model := explainModel(&appCtx.Config)
+		cacheKey := explaincache.Key(command, model, appCtx.Config.ExperienceLevel)
+		if appCtx.Config.ExplainCache && len(environment) == 0 {
+			cache = explainCache(&appCtx.Config)
+			if explanation, ok := cache.Get(cacheKey); ok {
+				out.Infof("└─ Answered from the explain cache\n")
+				printExplanation(cmd.Context(), out, command, explanation)
+				return nil
+			}
+		}
 		
 		// Create AI client (handles validation and debug logging)
-		aiClient, err := createAIClient(&appCtx.Config)
+		aiClient, err := appCtx.client()
+		if err != nil && !providerConfigured(&appCtx.Config) {
+			// No API key or provider: a heuristic explanation beats none
+			return explainOffline(cmd.Context(), out, command, err)
+		}
 		if err != nil {
 			return err
 		}
 		defer aiClient.Close()
 		
 		// Explain command using AI
-		ctx := cmd.Context()
+		ctx, span := trace.Start(cmd.Context(), "ai.explain")
 		response, err := aiClient.ExplainCommand(ctx, ai.ExplainRequest{
-			Command: command,
+			Command:     command,
+			Environment: environment,
+			Level:       appCtx.Config.ExperienceLevel,
 		})
+		span.RecordError(err)
+		span.End()
 		
+		// Over budget: degrade to the offline database when it knows the command
+		var exceeded budget.ExceededError
+		if errors.As(err, &exceeded) {
+			explanation, ok := flagdb.Explain(command)
+			if !ok {
+				return budgetExceeded(exceeded)
+			}
+			out.Infof("└─ %v; answering from the offline flag database\n", exceeded)
+			printExplanation(ctx, out, command, explanation)
+			return nil
+		}
 		if err != nil {
-			return exit.NewError(exit.CodeError, "AI command explanation failed: %v", err)
+			return providerError(err, "AI command explanation")
 		}
 		
-		// Output the explanation
-		fmt.Printf("Command explanation:\n%s", response.Explanation)
+		if cache != nil {
+			if err := cache.Put(cacheKey, command, model, response.Explanation); err != nil && appCtx.Config.Debug {
+				out.Debugf("Failed to cache the explanation: %v\n", err)
+			}
+		}
+		
+		// Output the explanation and the safety verdict
+		printExplanation(ctx, out, command, response.Explanation)
 		
 		return nil
 	},
 }
 
+// explainModel names the configured provider and model, which the explain
+// cache keeps explanations apart by
+func explainModel(cfg *config.Config) string {
+	model := providerName(cfg)
+	switch model {
+	case "gemini":
+		model += "/" + ai.DefaultGeminiModel
+	case "ollama":
+		model += "/" + cfg.Ollama.Model
+	}
+	return model
+}
+
+// explainExitCode interprets the exit status of a failed command: first
+// from the built-in table (shell codes, signals, command-specific codes),
+// then by the AI in the context of the full command when it is available
+func explainExitCode(ctx context.Context, out console, command string, code int) error {
+	meanings, err := exitstatus.Describe(command, code)
+	if err != nil {
+		return exit.NewError(exit.CodeConfig, "%v", err)
+	}
+	out.Infof("Explaining exit status %d of: '%s'\n", code, command)
+	out.Resultf("Exit status %d:\n", code)
+	for _, meaning := range meanings {
+		out.Resultf("• %s\n", meaning)
+	}
+
+	aiClient, err := appCtx.client()
+	if err != nil {
+		fmt.Fprintf(out.Err, "└─ AI interpretation unavailable: %v\n", err)
+		return nil
+	}
+	defer aiClient.Close()
+
+	ctx, span := trace.Start(ctx, "ai.explain")
+	response, err := aiClient.ExplainCommand(ctx, ai.ExplainRequest{Command: command, ExitCode: &code, Level: appCtx.Config.ExperienceLevel})
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		// The built-in interpretation already answers the question
+		fmt.Fprintf(out.Err, "└─ AI interpretation unavailable: %v\n", err)
+		return nil
+	}
+	out.Resultf("\nAI interpretation:\n%s", response.Explanation)
+	return nil
+}
+
+// explainOffline explains a command from the flag database and the local
+// manual pages when no AI provider is configured. Parts neither covers
+// are marked as undocumented; the provider error is returned only when
+// the command cannot be parsed at all.
+func explainOffline(ctx context.Context, out console, command string, cause error) error {
+	explanation, ok := flagdb.ExplainWith(command, func(name string) (flagdb.Entry, bool) {
+		page, err := manpage.Lookup(ctx, name)
+		if err != nil {
+			return flagdb.Entry{}, false
+		}
+		return flagdb.Entry{Description: "- " + page.Description, Flags: page.Options, TakesValue: page.TakesValue}, true
+	})
+	if !ok {
+		return cause
+	}
+	out.Infof("└─ No AI provider configured; explaining from the offline flag database and manual pages\n")
+	printExplanation(ctx, out, command, explanation)
+	return nil
+}
+
+// maxLegendRaw caps how wide the parts column of the legend grows for
+// long parts such as quoted scripts
+const maxLegendRaw = 24
+
+// explainAnnotated prints the command with a numbered marker under each
+// part and a legend of what the parts do. The parsed command and the flag
+// database describe what they can; the AI describes the rest, or every
+// part with --ai, and the markers stay when it is unavailable.
+func explainAnnotated(ctx context.Context, out console, command string, environment []string, forceAI bool) error {
+	parts, ok := flagdb.Annotate(command)
+	if !ok {
+		return exit.NewError(exit.CodeError, "Cannot annotate '%s': the command does not parse", command)
+	}
+	replace := forceAI || !appCtx.Config.OfflineExplain
+	if replace || slices.ContainsFunc(parts, func(part flagdb.Part) bool { return part.Text == "" }) {
+		if err := describeParts(ctx, command, environment, parts, replace); err != nil {
+			fmt.Fprintf(out.Err, "└─ AI details unavailable: %v\n", err)
+		}
+	}
+
+	out.Resultf("Annotated command:\n%s\nLegend:\n", flagdb.Markers(command, parts))
+	width, rawWidth := len(strconv.Itoa(len(parts))), 0
+	for _, part := range parts {
+		rawWidth = max(rawWidth, min(utf8.RuneCountInString(part.Raw), maxLegendRaw))
+	}
+	for i, part := range parts {
+		text := part.Text
+		switch {
+		case text != "":
+		case part.Command != "":
+			text = "is an argument of '" + part.Command + "'"
+		default:
+			text = "is not documented offline"
+		}
+		out.Resultf("%*d  %-*s  %s\n", width, i+1, rawWidth, part.Raw, text)
+	}
+	printRiskAssessment(ctx, out.Out, command, appCtx.Config.Target)
+	return nil
+}
+
+// partLine matches one described part in an annotation answer, e.g.
+// "• 3: excludes hidden files"
+var partLine = regexp.MustCompile(`^\s*•\s*(\d+)\s*[:.)]\s*(.+)$`)
+
+// describeParts asks the AI what each part of the command does and fills
+// in the parts without a description, or all of them when replace is set
+func describeParts(ctx context.Context, command string, environment []string, parts []flagdb.Part, replace bool) error {
+	aiClient, err := appCtx.client()
+	if err != nil {
+		return err
+	}
+	defer aiClient.Close()
+
+	raw := make([]string, len(parts))
+	for i, part := range parts {
+		raw[i] = part.Raw
+	}
+	ctx, span := trace.Start(ctx, "ai.explain")
+	response, err := aiClient.ExplainCommand(ctx, ai.ExplainRequest{
+		Command:     command,
+		Environment: environment,
+		Level:       appCtx.Config.ExperienceLevel,
+		Parts:       raw,
+	})
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(response.Explanation, "\n") {
+		groups := partLine.FindStringSubmatch(line)
+		if groups == nil {
+			continue
+		}
+		number, _ := strconv.Atoi(groups[1])
+		if number >= 1 && number <= len(parts) && (replace || parts[number-1].Text == "") {
+			parts[number-1].Text = strings.TrimSpace(groups[2])
+		}
+	}
+	return nil
+}
+
+// readExplainInput reads the input to explain from a file, or from
+// standard input when no file is given
+func readExplainInput(path string) (string, error) {
+	var r io.Reader = stdin
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return "", exit.NewError(exit.CodeError, "Cannot read input: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+	// One byte more than the cap tells a full input from a larger one
+	data, err := io.ReadAll(io.LimitReader(r, maxConfigFileBytes+1))
+	if err != nil {
+		return "", exit.NewError(exit.CodeError, "Cannot read input: %v", err)
+	}
+	if len(data) > maxConfigFileBytes {
+		return "", exit.NewError(exit.CodeError, "Input too large: explain reads at most %d KiB; pass the part to explain (e.g. through head)", maxConfigFileBytes/1024)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return "", exit.NewError(exit.CodeError, "Nothing to explain: the input is empty")
+	}
+	return string(data), nil
+}
+
+// explainConfigFile explains a configuration file entry by entry from the
+// offline database, then rates every command it runs. The AI adds its
+// reading when settings are unknown to the database or --ai is given.
+func explainConfigFile(ctx context.Context, out console, format sysfile.Format, text string, forceAI bool) error {
+	explanation := sysfile.Explain(format, text)
+	out.Infof("Explaining %s\n", format)
+	out.Resultf("Explanation:\n%s", explanation.Text)
+
+	if len(explanation.Warnings) > 0 {
+		out.Resultf("\nWarnings:\n")
+		for _, warning := range explanation.Warnings {
+			out.Resultf("• %s\n", warning)
+		}
+	}
+
+	if len(explanation.Commands) > 0 {
+		target := appCtx.Config.Target
+		analyzer := appCtx.analyzer(target)
+		out.Resultf("\nCommands run:\n")
+		for _, command := range explanation.Commands {
+			result, err := assessRisk(ctx, analyzer, command, target)
+			if err != nil {
+				out.Resultf("• %s\n", command)
+				continue
+			}
+			out.Resultf("• %s: %s (%s)\n", command, strings.ToUpper(result.Level.String()), result.Reason)
+		}
+	}
+
+	if explanation.Complete && !forceAI {
+		return nil
+	}
+	aiClient, err := appCtx.client()
+	if err != nil {
+		fmt.Fprintf(out.Err, "└─ AI explanation unavailable: %v\n", err)
+		return nil
+	}
+	defer aiClient.Close()
+
+	ctx, span := trace.Start(ctx, "ai.explain")
+	response, err := aiClient.ExplainCommand(ctx, ai.ExplainRequest{Command: text, Level: appCtx.Config.ExperienceLevel})
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		// The offline explanation already covers the recognized entries
+		fmt.Fprintf(out.Err, "└─ AI explanation unavailable: %v\n", err)
+		return nil
+	}
+	out.Resultf("\nAI explanation:\n%s", response.Explanation)
+	return nil
+}
+
+// printEnvironment lists the environment variables a command references,
+// with their current values and what the well-known ones do
+func printEnvironment(w io.Writer, variables []envref.Variable) {
+	if len(variables) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Environment:\n")
+	for _, variable := range variables {
+		if variable.Effect != "" {
+			fmt.Fprintf(w, "• %s (%s)\n", variable, variable.Effect)
+		} else {
+			fmt.Fprintf(w, "• %s\n", variable)
+		}
+	}
+}
+
+// printExplanation prints an explanation followed by the risk assessment,
+// so explain doubles as a pre-flight review. Long pipelines also get a
+// data-flow diagram drawn from the parsed command.
+func printExplanation(ctx context.Context, out console, command, explanation string) {
+	out.Resultf("Command explanation:\n%s", explanation)
+	if diagram, ok := dataflow.Render(command); ok {
+		out.Resultf("\nData flow:\n%s", diagram)
+	}
+	printRiskAssessment(ctx, out.Out, command, appCtx.Config.Target)
+}
+
 func init() {
 	rootCmd.AddCommand(explainCmd)
+	explainCmd.Flags().Bool("env", false, "Show the current values of environment variables the command references (secrets redacted) and how they affect it")
+	explainCmd.Flags().Int("exit-code", 0, "Interpret this exit status of the command (signals, OOM kills, command-specific codes)")
+	explainCmd.Flags().Bool("annotate", false, "Print the command with a numbered marker under each part and a legend of what each part does")
+	explainCmd.Flags().Bool("ai", false, "Always ask the AI, even for commands the offline flag database covers")
+	explainCmd.Flags().String("file", "", "Explain this file (systemd unit, crontab, fstab, sudoers or a script) instead of a command")
+	// Flags after the first word belong to the explained command:
+	// "hermes explain git diff --exit-code" must not set --exit-code
+	explainCmd.Flags().SetInterspersed(false)
 }
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+)
+
+// runExplain runs "hermes explain args..." against an explainClient and
+// resets explain's flags afterwards
+func runExplain(t *testing.T, args ...string) (client *explainClient, stdout string, err error) {
+	t.Helper()
+	t.Cleanup(func() {
+		for _, name := range []string{"env", "annotate", "ai"} {
+			explainCmd.Flags().Set(name, "false")
+		}
+		explainCmd.Flags().Set("exit-code", "0")
+		explainCmd.Flags().Set("file", "")
+		for _, name := range []string{"env", "annotate", "ai", "exit-code", "file"} {
+			explainCmd.Flags().Lookup(name).Changed = false
+		}
+	})
+	client = &explainClient{explanation: "• explained by the AI\n"}
+	deps := &AppContext{NewClient: func(*config.Config) (ai.Client, error) { return client, nil }}
+	stdout, _, err = runHermes(t, deps, append([]string{"explain"}, args...)...)
+	return client, stdout, err
+}
+
+func TestExplainKeepsCommandExitCodeFlag(t *testing.T) {
+	client, stdout, err := runExplain(t, "git", "diff", "--exit-code")
+	if err != nil {
+		t.Fatalf("hermes explain git diff --exit-code error = %v", err)
+	}
+	if len(client.requests) != 1 || client.requests[0].Command != "git diff --exit-code" {
+		t.Errorf("requests = %+v, want the whole git command", client.requests)
+	}
+	if strings.Contains(stdout, "Exit status") {
+		t.Errorf("stdout = %q, want a command explanation, not an exit status", stdout)
+	}
+}
+
+func TestExplainKeepsCommandEnvFlag(t *testing.T) {
+	client, stdout, err := runExplain(t, "docker", "run", "--env", "A=b", "img")
+	if err != nil {
+		t.Fatalf("hermes explain docker run --env A=b img error = %v", err)
+	}
+	if len(client.requests) != 1 || client.requests[0].Command != "docker run --env A=b img" {
+		t.Errorf("requests = %+v, want the docker command with its --env", client.requests)
+	}
+	if strings.Contains(stdout, "Environment") {
+		t.Errorf("stdout = %q, want no environment report", stdout)
+	}
+}
+
+func TestExplainKeepsCommandFileFlag(t *testing.T) {
+	// A Dockerfile of that name must not be read instead of the command
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("Dockerfile.prod", []byte("FROM alpine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, _, err := runExplain(t, "docker", "build", "--file", "Dockerfile.prod", ".")
+	if err != nil {
+		t.Fatalf("hermes explain docker build --file Dockerfile.prod . error = %v", err)
+	}
+	if len(client.requests) != 1 || client.requests[0].Command != "docker build --file Dockerfile.prod ." {
+		t.Errorf("requests = %+v, want the docker command", client.requests)
+	}
+}
+
+func TestExplainKeepsCommandAnnotateFlag(t *testing.T) {
+	client, stdout, err := runExplain(t, "git", "blame", "--annotate", "main.go")
+	if err != nil {
+		t.Fatalf("hermes explain git blame --annotate main.go error = %v", err)
+	}
+	if len(client.requests) != 1 || client.requests[0].Command != "git blame --annotate main.go" {
+		t.Errorf("requests = %+v, want the git command with its --annotate", client.requests)
+	}
+	if strings.Contains(stdout, "Legend") {
+		t.Errorf("stdout = %q, want a plain explanation, not an annotation", stdout)
+	}
+}
+
+func TestExplainKeepsCommandAIFlag(t *testing.T) {
+	client, _, err := runExplain(t, "review-tool", "--ai", "src/")
+	if err != nil {
+		t.Fatalf("hermes explain review-tool --ai src/ error = %v", err)
+	}
+	if len(client.requests) != 1 || client.requests[0].Command != "review-tool --ai src/" {
+		t.Errorf("requests = %+v, want the command with its --ai", client.requests)
+	}
+}
@@ -0,0 +1,84 @@
+// Package commands - feedback subcommand
+package commands
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/exit"
+	"hermes/internal/feedback"
+)
+
+// feedbackCmd rates the last generated command
+var feedbackCmd = &cobra.Command{
+	Use:   "feedback good|bad",
+	Short: "Rate the last generated command",
+	Long: `Rate the last generated command as good or bad, optionally with a note
+saying what was wrong. Ratings stay on this machine
+(~/.local/state/hermes/feedback.json); a few ratings for similar requests
+are included in future prompts so hermes learns your preferences.
+
+  hermes feedback bad --note "use fd instead of find"`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"good", "bad"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var good bool
+		switch args[0] {
+		case "good":
+			good = true
+		case "bad":
+		default:
+			return exit.NewError(exit.CodeError, "unknown rating %q (use good or bad)", args[0])
+		}
+		if !appCtx.Config.Feedback {
+			return exit.NewError(exit.CodeConfig, "feedback is off (feedback = false)")
+		}
+		note, _ := cmd.Flags().GetString("note")
+
+		store, err := feedback.Load(feedback.DefaultPath())
+		if err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+		entry, err := store.Rate(good, note)
+		if errors.Is(err, feedback.ErrNoGeneration) {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+		if err := store.Save(); err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+		consoleFor(cmd).Infof("└─ rated %s: %s\n", args[0], entry.Command)
+		return nil
+	},
+}
+
+// rememberGeneration keeps the latest generation so it can be rated
+func rememberGeneration(query, command string) {
+	if !appCtx.Config.Feedback {
+		return
+	}
+	store, err := feedback.Load(feedback.DefaultPath())
+	if err == nil {
+		store.SetLast(query, command)
+		err = store.Save()
+	}
+	if err != nil && appCtx.Config.Debug {
+		stdio.Debugf("%v\n", err)
+	}
+}
+
+// feedbackContext describes past ratings for similar queries, or returns ""
+func feedbackContext(query string) string {
+	store, err := feedback.Load(feedback.DefaultPath())
+	if err != nil {
+		if appCtx.Config.Debug {
+			stdio.Debugf("%v\n", err)
+		}
+		return ""
+	}
+	return feedback.Context(store.Relevant(query, 3))
+}
+
+func init() {
+	feedbackCmd.Flags().String("note", "", "What was wrong or what you prefer, used in future prompts")
+	rootCmd.AddCommand(feedbackCmd)
+}
@@ -0,0 +1,200 @@
+// Package commands - filter subcommand
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/exit"
+	"hermes/internal/filter"
+	"hermes/internal/safety"
+)
+
+// Sample limits: how much input is read for the test run, and how much of
+// it goes into the prompt
+const (
+	maxSampleBytes   = 1 << 20
+	promptSampleSize = 4 << 10
+	promptSampleRows = 30
+	resultPreviewRow = 10
+)
+
+// filterCmd generates a jq, awk or sed program from a description and a
+// sample of the input
+var filterCmd = &cobra.Command{
+	Use:   "filter <description>",
+	Short: "Generate a jq, awk or sed program and try it on sample input",
+	Long: `Generate a jq, awk or sed program from a description and a sample of the
+data it will process. The sample comes from standard input or --sample-file;
+the program is test-run locally on it (GNU awk and sed with --sandbox) and
+the result is shown before the program is printed.
+
+Examples:
+  curl -s https://api.example.com/users | head -c 4000 | hermes filter "names of active users"
+  hermes filter --sample-file access.log --tool awk "count requests per status code"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		description := strings.Join(args, " ")
+		tool, _ := cmd.Flags().GetString("tool")
+		switch tool {
+		case "", "jq", "awk", "sed":
+		default:
+			return exit.NewError(exit.CodeConfig, "--tool must be jq, awk or sed, got %q", tool)
+		}
+
+		sampleFile, _ := cmd.Flags().GetString("sample-file")
+		sample, err := readSample(sampleFile)
+		if err != nil {
+			return err
+		}
+		out := consoleFor(cmd)
+		out.Infof("└─ Generating filter for: '%s'\n", description)
+
+		aiClient, err := appCtx.client()
+		if err != nil {
+			return err
+		}
+		defer aiClient.Close()
+
+		ctx := cmd.Context()
+		req := ai.GenerateRequest{
+			Query:   filterQuery(description, tool),
+			Context: "Sample of the input data:\n" + promptSample(sample),
+		}
+		command, err := generateFilter(ctx, out, aiClient, req, sample)
+		if err != nil {
+			return err
+		}
+
+		result, err := safety.NewAnalyzer().AnalyzeCommand(ctx, command)
+		if err != nil {
+			return exit.NewError(exit.CodeError, "Safety analysis failed: %v", err)
+		}
+		if result.Level >= safety.Attention {
+			fmt.Fprintf(out.Err, "└─ attention: %s\n", result.Reason)
+		}
+
+		out.Resultf("%s\n", command)
+		recordVerdict(result)
+		if exitCode := safetyExitCode(result.Level); exitCode != exit.CodeSuccess {
+			return exit.NewError(exitCode, "")
+		}
+		return nil
+	},
+}
+
+// generateFilter generates the program, test-runs it on the sample and
+// shows the result, asking once more with the error when it is not a stdin
+// filter or fails on the sample
+func generateFilter(ctx context.Context, out console, aiClient ai.Client, req ai.GenerateRequest, sample []byte) (string, error) {
+	sampleContext := req.Context
+	for attempt := 0; ; attempt++ {
+		response, err := generateCommand(ctx, aiClient, req)
+		if err != nil {
+			return "", err
+		}
+		output, err := tryFilter(ctx, response.Command, sample)
+		if errors.Is(err, filter.ErrUnsafe) {
+			out.Infof("└─ not test-run: %v\n", err)
+			return response.Command, nil
+		}
+		if err == nil {
+			showResult(out, output)
+			return response.Command, nil
+		}
+		if attempt == 1 {
+			return "", exit.NewError(exit.CodeError, "AI generated a filter that fails on the sample (%v): %s", err, response.Command)
+		}
+		req.Context = fmt.Sprintf("%s\n\nYour previous answer failed on this sample:\n%s\nError: %v", sampleContext, response.Command, err)
+	}
+}
+
+// tryFilter checks that command is a single jq, awk or sed program reading
+// standard input and runs it on the sample
+func tryFilter(ctx context.Context, command string, sample []byte) (string, error) {
+	program, err := filter.Prepare(command)
+	if err != nil {
+		return "", err
+	}
+	return program.Run(ctx, sample)
+}
+
+// filterQuery builds the generation query for a filter description
+func filterQuery(description, tool string) string {
+	tools := "jq (for JSON), awk or sed, whichever suits the sample best"
+	if tool != "" {
+		tools = tool
+	}
+	return fmt.Sprintf("Write one %s command that reads the data from standard input (no file arguments, pipes or redirections) and does this: %s", tools, description)
+}
+
+// readSample reads the sample from a file, or from standard input when no
+// file is given
+func readSample(path string) ([]byte, error) {
+	var r io.Reader = stdin
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, exit.NewError(exit.CodeError, "Cannot read sample: %v", err)
+		}
+		defer file.Close()
+		r = file
+	} else if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return nil, exit.NewError(exit.CodeError, "No sample input: pipe some of the data into hermes filter or use --sample-file")
+	}
+
+	sample, err := io.ReadAll(io.LimitReader(r, maxSampleBytes))
+	if err != nil {
+		return nil, exit.NewError(exit.CodeError, "Cannot read sample: %v", err)
+	}
+	if len(sample) == 0 {
+		return nil, exit.NewError(exit.CodeError, "Sample input is empty")
+	}
+	return sample, nil
+}
+
+// promptSample trims the sample to its first lines for the prompt
+func promptSample(sample []byte) string {
+	text := string(sample)
+	if len(text) > promptSampleSize {
+		text = text[:promptSampleSize]
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > promptSampleRows {
+		lines = lines[:promptSampleRows]
+	}
+	text = strings.Join(lines, "")
+	if len(text) < len(sample) {
+		text += "\n(truncated)"
+	}
+	return text
+}
+
+// showResult prints the first lines the program produced on the sample
+func showResult(out console, output string) {
+	if output == "" {
+		out.Infof("└─ result on sample: (no output)\n")
+		return
+	}
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	out.Infof("└─ result on sample:\n")
+	for i, line := range lines {
+		if i == resultPreviewRow {
+			out.Infof("   ... %d more lines\n", len(lines)-i)
+			break
+		}
+		out.Infof("   %s\n", line)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(filterCmd)
+	filterCmd.Flags().String("sample-file", "", "Read the sample input from this file instead of standard input")
+	filterCmd.Flags().String("tool", "", "Program to generate: jq, awk or sed (default: whichever suits the sample)")
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+)
+
+func TestGenerateFilterRegeneratesOnSampleFailure(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not installed")
+	}
+	appCtx = &AppContext{Config: config.Config{}}
+	t.Cleanup(func() { appCtx = nil })
+
+	client := &sequenceClient{commands: []string{"jq -r '.[].name' users.json", "jq -r '.[].name'"}}
+	req := ai.GenerateRequest{Query: filterQuery("names", "jq"), Context: "Sample of the input data:\n" + `[{"name": "a"}]`}
+	command, err := generateFilter(context.Background(), stdio, client, req, []byte(`[{"name": "a"}]`))
+	if err != nil {
+		t.Fatalf("generateFilter() error: %v", err)
+	}
+	if command != "jq -r '.[].name'" {
+		t.Errorf("generateFilter() = %q, want the regenerated command", command)
+	}
+	if len(client.requests) != 2 || !strings.Contains(client.requests[1].Context, `reads "users.json" instead of standard input`) {
+		t.Errorf("retry request = %+v, want the error in the context", client.requests[len(client.requests)-1])
+	}
+}
+
+func TestPromptSample(t *testing.T) {
+	sample := strings.Repeat("line\n", promptSampleRows+5)
+	got := promptSample([]byte(sample))
+	if strings.Count(got, "line") != promptSampleRows || !strings.HasSuffix(got, "(truncated)") {
+		t.Errorf("promptSample() = %q, want %d lines and a truncation note", got, promptSampleRows)
+	}
+	if got := promptSample([]byte("a\nb\n")); got != "a\nb\n" {
+		t.Errorf("promptSample() = %q, want the whole short sample", got)
+	}
+}
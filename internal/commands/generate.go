@@ -2,14 +2,32 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"hermes/internal/ai"
+	"hermes/internal/audit"
+	"hermes/internal/budget"
+	"hermes/internal/config"
 	"hermes/internal/exit"
+	"hermes/internal/history"
+	"hermes/internal/lint"
+	"hermes/internal/locale"
+	"hermes/internal/notify"
+	"hermes/internal/redact"
+	"hermes/internal/remote"
 	"hermes/internal/safety"
+	"hermes/internal/sandbox"
+	"hermes/internal/toolver"
+	"hermes/internal/trace"
+	"hermes/internal/workdir"
+	"hermes/internal/wsl"
 )
 
 // generateCmd represents the generate command
@@ -32,6 +50,7 @@ Examples:
   hermes generate delete old log files         # Generate command to delete old logs
   hermes gen find all python files             # Generate command to find Python files
   hermes generate compress this directory      # Generate command to compress directory
+  hermes gen --from 'find . -mtime +7' only log files   # Adjust an existing command
 
 Tip: Set up an alias for faster access:
   alias h='hermes gen'
@@ -40,95 +59,725 @@ Then you can use: h list all files`,
 
 	Args: cobra.MinimumNArgs(1), // Require at least one argument
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := consoleFor(cmd)
 		verbose, _ := cmd.Flags().GetBool("verbose")
+		useSandbox, _ := cmd.Flags().GetBool("sandbox")
+		remoteTarget, _ := cmd.Flags().GetString("remote")
+		remoteExec, _ := cmd.Flags().GetBool("remote-exec")
+		edit, _ := cmd.Flags().GetBool("edit")
+		baseline, _ := cmd.Flags().GetString("from")
+		baseline = strings.TrimSpace(baseline)
 		query := strings.Join(args, " ")
 		
 		// Show immediate feedback about what we're processing (to stderr)
-		fmt.Fprintf(os.Stderr, "└─ Generating command for: '%s'\n", query)
+		if baseline != "" {
+			out.Infof("└─ Adjusting '%s': '%s'\n", baseline, query)
+		} else {
+			out.Infof("└─ Generating command for: '%s'\n", query)
+		}
+		
+		target := appCtx.Config.Target
+		if target != safety.TargetPosix && target != safety.TargetCmd {
+			return exit.NewError(exit.CodeConfig, "unsupported target: %s (supported: posix, cmd)", target)
+		}
+		if plan := appCtx.Config.Plan; plan != planFirst && plan != planChain {
+			return exit.NewError(exit.CodeConfig, "unsupported plan mode: %s (supported: first, chain)", plan)
+		}
+		if n := appCtx.Config.Candidates; n < 1 || n > maxCandidates {
+			return exit.NewError(exit.CodeConfig, "candidates must be between 1 and %d, got %d", maxCandidates, n)
+		}
+		if edit && !interactive() {
+			return exit.NewError(exit.CodeConfig, "--edit needs an interactive terminal")
+		}
+		if remoteExec && remoteTarget == "" {
+			return exit.NewError(exit.CodeConfig, "--remote-exec needs --remote")
+		}
+		if remoteTarget != "" {
+			if err := remote.ValidateTarget(remoteTarget); err != nil {
+				return exit.NewError(exit.CodeConfig, "invalid --remote: %v", err)
+			}
+		}
+		if remoteTarget != "" && !appCtx.Config.NetworkEnabled() {
+			return exit.NewError(exit.CodeOffline, "--remote needs the network, which is off (network = \"off\")")
+		}
 		
 		// Create AI client (handles validation and debug logging)
-		aiClient, err := createAIClient(&appCtx.Config)
+		aiClient, err := appCtx.client()
 		if err != nil {
 			return err
 		}
 		defer aiClient.Close()
 		
-		// Generate command using AI
+		// Gather related shell history as context (opt-in, redacted)
 		ctx := cmd.Context()
-		response, err := aiClient.GenerateCommand(ctx, ai.GenerateRequest{
-			Query:   query,
-			Verbose: verbose,
-		})
+		var historyEntries []string
+		var historyContext string
+		if appCtx.Config.History {
+			entries, source, err := history.Load(ctx)
+			if err != nil {
+				fmt.Fprintf(out.Err, "warning: shell history unavailable: %v\n", err)
+			} else {
+				historyEntries = entries
+				if related := history.Relevant(entries, query, 5); len(related) > 0 {
+					historyContext = "Related commands from the user's shell history:\n" + strings.Join(related, "\n")
+				}
+				if appCtx.Config.Debug {
+					out.Debugf("Loaded %d history entries from %s\n", len(entries), source)
+				}
+			}
+		}
+		
+		// Collect the optional context sections for the prompt
+		contextSections := []string{}
+		if historyContext != "" {
+			contextSections = append(contextSections, historyContext)
+		}
+		if appCtx.Config.DirContext && remoteTarget == "" {
+			if listing := directoryContext(out, &appCtx.Config); listing != "" {
+				contextSections = append(contextSections, listing)
+			}
+		}
+		if appCtx.Config.Feedback {
+			if corrections := feedbackContext(query); corrections != "" {
+				contextSections = append(contextSections, corrections)
+			}
+		}
+		if appCtx.Config.ToolVersions && remoteTarget == "" {
+			if versions := toolver.Context(ctx, query); versions != "" {
+				contextSections = append(contextSections, versions)
+			}
+		}
+		if appCtx.Config.LocaleContext {
+			contextSections = append(contextSections, locale.Context(time.Now()))
+		}
+		if remoteTarget == "" {
+			contextSections = append(contextSections, providedContext(ctx, &appCtx.Config, out.Err)...)
+		}
 		
+		// Describe the remote host so the command fits its OS and tools
+		if remoteTarget != "" {
+			fmt.Fprintf(out.Err, "└─ Gathering context from %s...\n", remoteTarget)
+			host, err := remote.Probe(ctx, remoteTarget)
+			if err != nil {
+				return exit.NewError(exit.CodeError, "Failed to gather remote host context: %v", err)
+			}
+			contextSections = append(contextSections, host.Context())
+		}
+		
+		// Under WSL, explain the /mnt/c layout and which tools to prefer
+		underWSL := target == safety.TargetPosix && remoteTarget == "" && wsl.Detect()
+		if underWSL {
+			contextSections = append(contextSections, wsl.Context(query))
+		}
+		
+		// Generate command using AI, then lint and analyze its safety
+		started := time.Now()
+		req := ai.GenerateRequest{
+			Query:      query,
+			Verbose:    verbose,
+			Context:    strings.Join(contextSections, "\n\n"),
+			Target:     target,
+			POSIX:      appCtx.Config.POSIX,
+			Candidates: appCtx.Config.Candidates,
+			Commented:  appCtx.Config.Commented && target == safety.TargetPosix,
+			Baseline:   baseline,
+			MultiLine:  appCtx.Config.MultiLine,
+			Preset:     appCtx.Config.Preset,
+		}
+		if remoteTarget == "" {
+			req.PackageManager = localPackageManager(target)
+		}
+		result, err := runGeneration(ctx, aiClient, req)
 		if err != nil {
-			return exit.NewError(exit.CodeError, "AI command generation failed: %v", err)
+			notifyRefusal(ctx, out, err)
+			return err
 		}
 		
-		generatedCommand := response.Command
-		aiSafetyLevel := response.SafetyLevel
+		// Make sure the command only runs programs that are installed here
+		if target == safety.TargetPosix && remoteTarget == "" {
+			if result, err = checkInstalled(ctx, out, aiClient, req, result); err != nil {
+				notifyRefusal(ctx, out, err)
+				return err
+			}
+		}
 		
-		// Display verbose explanation if requested (to stderr)
-		if verbose {
-			fmt.Fprintf(os.Stderr, "\nExplanation:\n%s\n\n", response.Explanation)
+		// The user may have switched windows while waiting on a slow API call
+		notifySlowGeneration(ctx, time.Since(started), result.Command)
+		
+		generatedCommand := result.Command
+		safetyResult := result.Safety
+		lintResult := result.Lint
+		
+		// Give Linux tools /mnt/c paths and Windows interop tools C:\ paths
+		if underWSL && appCtx.Config.WSL.TranslatePaths != "off" {
+			if translated, changed := wsl.TranslateCommand(generatedCommand); changed {
+				if appCtx.Config.WSL.TranslatePaths == "auto" {
+					fmt.Fprintf(out.Err, "└─ wsl: translated paths for WSL\n")
+					generatedCommand = translated
+				} else if confirm(fmt.Sprintf("WSL: use translated paths?\n  %s\n  %s\n", generatedCommand, translated)) {
+					generatedCommand = translated
+				}
+			}
+		}
+		
+		// Show how many files the command would touch before the user
+		// picks a variant or runs it
+		if remoteTarget == "" {
+			for _, estimate := range impactEstimates(ctx, generatedCommand, target) {
+				fmt.Fprintf(out.Err, "└─ impact: %s\n", estimate)
+			}
 		}
 		
-		// Analyze safety of generated command (hybrid approach)
-		analyzer := safety.NewAnalyzer()
-		var safetyResult safety.Result
+		// Offer safer variants of risky commands (rm -i, rsync --dry-run, ...)
+		alternatives := saferAlternatives(generatedCommand, safetyResult, target)
+		chosen, safetyResult := chooseAlternative(ctx, out, generatedCommand, safetyResult, alternatives, target)
 		
-		if appCtx.Config.MockExitCode != 0 {
-			// Use mock exit code for testing
-			safetyResult = analyzer.MockAnalyzeCommand(generatedCommand, appCtx.Config.MockExitCode)
-		} else {
-			// Use hybrid safety analysis (AI assessment + pattern matching)
-			ctx := cmd.Context()
-			result, err := analyzer.AnalyzeCommand(ctx, generatedCommand)
+		// Show how to recover before the command can run; the model's
+		// suggestion only fits the command it generated
+		suggestedUndo := result.Response.Undo
+		if chosen != generatedCommand {
+			suggestedUndo = ""
+		}
+		generatedCommand = chosen
+		
+		// Let the user tweak the command by hand; the edited version passes
+		// the same gates as a generated one
+		if edit {
+			edited, err := editCommand(ctx, generatedCommand)
 			if err != nil {
-				return exit.NewError(exit.CodeError, "Safety analysis failed: %v", err)
+				return err
+			}
+			if edited != generatedCommand {
+				if safetyResult, err = gateCommand(ctx, edited, target, nil); err != nil {
+					notifyRefusal(ctx, out, err)
+					return err
+				}
+				fmt.Fprintf(out.Err, "└─ edited: safety re-checked: %s (%s)\n", safetyResult.Level, safetyResult.Reason)
+				generatedCommand, suggestedUndo = edited, ""
+			}
+		}
+		// Say why the command needs attention; the shell integration's
+		// warning only says that it does
+		if safetyResult.Level >= safety.Attention {
+			fmt.Fprintf(out.Err, "└─ attention: %s\n", safetyResult.Reason)
+			if safetyResult.Docs != "" {
+				fmt.Fprintf(out.Err, "└─ docs: %s\n", safetyResult.Docs)
 			}
-			
-			// Apply upgrade-only logic: if patterns detected something requiring attention,
-			// upgrade the AI's assessment
-			if result.Level == safety.Attention {
-				safetyResult = result
+		}
+		if undo := undoHint(generatedCommand, safetyResult, suggestedUndo, target); undo != "" {
+			fmt.Fprintf(out.Err, "└─ undo: %s\n", undo)
+		}
+		
+		// Display verbose explanation if requested (to stderr)
+		if verbose {
+			fmt.Fprintf(out.Err, "\nExplanation:\n%s\n\n", result.Response.Explanation)
+		}
+		
+		// Show every step of a multi-command plan (to stderr)
+		if len(result.Plan) > 0 {
+			renderPlan(out.Err, result.Plan, result.PlanUsed)
+		}
+		
+		// Surface lint warnings next to the safety verdict (to stderr)
+		for _, finding := range lintResult.Findings {
+			fmt.Fprintf(out.Err, "└─ %s: %s\n", lintResult.Source, finding)
+		}
+		
+		// Remind the user when they have run something similar before
+		if entry, exact, found := history.Similar(historyEntries, generatedCommand); found {
+			if exact {
+				fmt.Fprintf(out.Err, "└─ history: you've run this exact command before\n")
 			} else {
-				// AI detected attention but patterns say safe - use AI's assessment
-				if aiSafetyLevel == safety.Attention {
-					safetyResult = safety.Result{
-						Level:  safety.Attention,
-						Reason: "AI flagged as requiring attention",
-						Layer:  "ai-assessment",
-					}
+				fmt.Fprintf(out.Err, "└─ history: you've run something similar before: %s\n", entry)
+			}
+		}
+		
+		// Preview the command's effect in a throwaway sandbox (to stderr)
+		if useSandbox {
+			previewInSandbox(ctx, out.Err, generatedCommand)
+		}
+		
+		// Record the command before it can run anywhere
+		recordAudit(out, query, generatedCommand, remoteTarget, safetyResult)
+		rememberGeneration(query, generatedCommand)
+		
+		// Remote commands either run over SSH after confirmation, or are
+		// wrapped in ssh so the shell buffer never runs them locally
+		if remoteTarget != "" {
+			if remoteExec {
+				fmt.Fprintf(out.Err, "\n%s\n\n", generatedCommand)
+				if !confirm(fmt.Sprintf("Run this command on %s (safety: %s)?", remoteTarget, safetyResult.Level)) {
+					return exit.NewError(exit.CodeAborted, "remote execution cancelled")
+				}
+				if err := remote.Run(ctx, remoteTarget, generatedCommand, os.Stdin, out.Err, out.Err); err != nil {
+					return exit.NewError(exit.CodeError, "remote command failed: %v", err)
+				}
+				return nil
+			}
+			generatedCommand = remote.Wrap(remoteTarget, generatedCommand)
+		}
+		
+		// Lay out multi-part commands with a comment per part; with
+		// strip_comments they are shown but the buffer gets the plain command
+		bufferCommand := generatedCommand
+		if appCtx.Config.Commented && target == safety.TargetPosix && remoteTarget == "" {
+			if commented := commentCommand(generatedCommand, result.Response.Comments); commented != generatedCommand {
+				if appCtx.Config.StripComments {
+					fmt.Fprintf(out.Err, "\n%s\n\n", commented)
 				} else {
-					safetyResult = result
+					bufferCommand = commented
 				}
 			}
 		}
 		
-		// Output only the command (for shell buffer)
-		fmt.Printf("%s\n", generatedCommand)
+		// Output only the command (for shell buffer); CRLF line endings
+		// from the model would break the terminators of multi-line here-docs
+		out.Resultf("%s\n", strings.ReplaceAll(bufferCommand, "\r\n", "\n"))
 		
 		if appCtx.Config.Debug {
-			fmt.Printf("DEBUG: Generated command: %s\n", generatedCommand)
-			fmt.Printf("DEBUG: Safety level: %s\n", safetyResult.Level)
-			fmt.Printf("DEBUG: Safety analysis: %s (reason: %s, layer: %s)\n", 
+			out.Debugf("Generated command: %s\n", generatedCommand)
+			out.Debugf("Safety level: %s\n", safetyResult.Level)
+			out.Debugf("Safety analysis: %s (reason: %s, layer: %s)\n", 
 				safetyResult.Level, safetyResult.Reason, safetyResult.Layer)
 		}
 		
+		// Let the team know about risky generations on designated hosts
+		if safetyResult.Level >= safety.Attention {
+			notifyAttention(ctx, out, generatedCommand, safetyResult.Level.String(), safetyResult.Reason)
+		}
+		
 		// Check for shell integration and warn if not active
-		checkShellIntegration()
+		checkShellIntegration(out)
 		
 		// Handle exit code
-		if safetyResult.Level.ExitCode() != exit.CodeSuccess {
+		recordVerdict(safetyResult)
+		if exitCode := safetyExitCode(safetyResult.Level); exitCode != exit.CodeSuccess {
 			// Return clean error for shell integration - no error message, just exit code
-			return exit.NewError(safetyResult.Level.ExitCode(), "")
+			return exit.NewError(exitCode, "")
 		}
 		
 		return nil
 	},
 }
 
+// recordAudit appends the generation to the audit log when enabled.
+// Credentials are masked so the log never holds secrets.
+func recordAudit(out console, query, command, remoteTarget string, result safety.Result) {
+	cfg := appCtx.Config.Audit
+	if !cfg.Enabled {
+		return
+	}
+	
+	path := cfg.Path
+	if path == "" {
+		path = audit.DefaultPath()
+	}
+	r := redact.New()
+	err := audit.Append(path, audit.Entry{
+		Action:  "generate",
+		Query:   r.Redact(query),
+		Command: r.Redact(command),
+		Safety:  result.Level.String(),
+		Reason:  result.Reason,
+		Remote:  remoteTarget,
+	})
+	if err != nil {
+		fmt.Fprintf(out.Err, "warning: %v\n", err)
+	}
+}
+
+// directoryContext lists the working directory for the prompt. File names
+// can reveal sensitive project names, so unless the provider is local the
+// user is asked once per directory and the answer is remembered.
+func directoryContext(out console, cfg *config.Config) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	names, total, err := workdir.List(dir)
+	if err != nil || total == 0 {
+		return ""
+	}
+
+	if providerName(cfg) != "ollama" {
+		consent, err := workdir.LoadConsent(workdir.DefaultConsentPath())
+		if err != nil {
+			fmt.Fprintf(out.Err, "warning: %v\n", err)
+			return ""
+		}
+		allowed, known := consent.Decision(dir)
+		if !known {
+			if !interactive() {
+				// Never decide on the user's behalf; ask next interactive run
+				return ""
+			}
+			allowed = confirm(fmt.Sprintf("└─ Send the names of %d files in %s to the provider? (remembered for this directory)", total, dir))
+			if err := consent.Record(dir, allowed); err != nil {
+				fmt.Fprintf(out.Err, "warning: %v\n", err)
+			}
+		}
+		if !allowed {
+			return ""
+		}
+	}
+
+	return workdir.Context(names, total)
+}
+
+// notifyRefusal posts a command the gates refused to the attention
+// webhook; a Forbidden command is riskier than any Attention one
+func notifyRefusal(ctx context.Context, out console, err error) {
+	var exitErr exit.Error
+	if !errors.As(err, &exitErr) {
+		return
+	}
+	if r, ok := exitErr.Err.(refusal); ok {
+		notifyAttention(ctx, out, r.command, notify.LevelForbidden, r.reason)
+	}
+}
+
+// notifyAttention posts an Attention-level generation or a refusal to the
+// configured webhook. Delivery failures only produce a warning.
+func notifyAttention(ctx context.Context, out console, command, level, reason string) {
+	cfg := appCtx.Config.Notify
+	if cfg.WebhookURL == "" {
+		return
+	}
+	if !appCtx.Config.NetworkEnabled() {
+		if appCtx.Config.Debug {
+			out.Debugf("Network is off, skipping webhook notification\n")
+		}
+		return
+	}
+	
+	host, _ := os.Hostname()
+	if !notify.HostMatches(cfg.Hosts, host) {
+		return
+	}
+	
+	err := notify.Webhook(ctx, cfg.WebhookURL, notify.Event{
+		Host:    host,
+		User:    os.Getenv("USER"),
+		Command: command,
+		Level:   level,
+		Reason:  reason,
+		Time:    time.Now(),
+	})
+	if err != nil {
+		fmt.Fprintf(out.Err, "warning: %v\n", err)
+	}
+}
+
+// previewInSandbox runs the command against a copy of the working directory
+// and prints to w which files it would create, modify or delete
+func previewInSandbox(ctx context.Context, w io.Writer, command string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(w, "warning: sandbox preview unavailable: %v\n", err)
+		return
+	}
+	
+	fmt.Fprintf(w, "└─ Running sandbox preview...\n")
+	report, err := sandbox.Preview(ctx, sandbox.Config{
+		Runtime: appCtx.Config.Sandbox.Runtime,
+		Image:   appCtx.Config.Sandbox.Image,
+	}, cwd, command)
+	if err != nil {
+		fmt.Fprintf(w, "warning: sandbox preview failed: %v\n", err)
+		return
+	}
+	
+	fmt.Fprintf(w, "\nSandbox preview (%s, exit code %d):\n", report.Runtime, report.ExitCode)
+	if !report.Changed() {
+		fmt.Fprintf(w, "  No files in the working directory would change\n")
+	}
+	for _, path := range report.Created {
+		fmt.Fprintf(w, "  + %s\n", path)
+	}
+	for _, path := range report.Modified {
+		fmt.Fprintf(w, "  ~ %s\n", path)
+	}
+	for _, path := range report.Deleted {
+		fmt.Fprintf(w, "  - %s\n", path)
+	}
+	if report.Output != "" {
+		fmt.Fprintf(w, "\nSandbox output:\n%s\n", report.Output)
+	}
+	fmt.Fprintln(w)
+}
+
+// desktopNotify shows a desktop notification; it is replaced in tests
+var desktopNotify = notify.Desktop
+
+// notifySlowGeneration fires a desktop notification when the generation took
+// longer than the configured threshold
+func notifySlowGeneration(ctx context.Context, elapsed time.Duration, command string) {
+	cfg := appCtx.Config.Notify
+	if !cfg.Desktop || elapsed < time.Duration(cfg.DesktopAfter)*time.Second {
+		return
+	}
+	
+	if err := desktopNotify(ctx, "hermes: command ready", command); err != nil && appCtx.Config.Debug {
+		stdio.Debugf("%v\n", err)
+	}
+}
+
+// generation holds the outcome of the generate pipeline
+type generation struct {
+	Command    string               // Final command (after lint auto-fixes)
+	Response   *ai.GenerateResponse // Raw AI response
+	Safety     safety.Result        // Merged safety verdict
+	Lint       lint.Result          // Lint findings for the final command
+	Plan       []planStep           // Steps of a multi-command plan, if any
+	PlanUsed   int                  // How many plan steps Command covers
+	Candidates []rankedCandidate    // Ranked alternatives, when several were requested
+}
+
+// generateCommand makes one traced generation call
+func generateCommand(ctx context.Context, aiClient ai.Client, req ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	aiCtx, span := trace.Start(ctx, "ai.generate")
+	response, err := aiClient.GenerateCommand(aiCtx, req)
+	span.RecordError(err)
+	span.End()
+	var exceeded budget.ExceededError
+	if errors.As(err, &exceeded) {
+		return nil, budgetExceeded(exceeded)
+	}
+	if err != nil {
+		return nil, providerError(err, "AI command generation")
+	}
+	return response, nil
+}
+
+// runGeneration asks the AI for a command, lints it and runs the hybrid
+// safety analysis. It is shared by the CLI and the editor protocol.
+func runGeneration(ctx context.Context, aiClient ai.Client, req ai.GenerateRequest) (*generation, error) {
+	response, err := generateCommand(ctx, aiClient, req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Never hand the user a line the shell cannot parse; ask once more with
+	// the parse error before giving up
+	if req.Target != safety.TargetCmd {
+		if syntaxErr := verifySyntax(response, req.Query); syntaxErr != nil {
+			if appCtx.Config.Debug {
+				stdio.Debugf("Regenerating invalid command %q: %v\n", response.Command, syntaxErr)
+			}
+			response, err = generateCommand(ctx, aiClient, retryRequest(req, response.Command, syntaxErr))
+			if err != nil {
+				return nil, err
+			}
+			if syntaxErr := verifySyntax(response, req.Query); syntaxErr != nil {
+				return nil, exit.NewError(exit.CodeError, "AI generated an invalid command (%v): %s", syntaxErr, response.Command)
+			}
+		}
+	}
+	
+	// Absurdly long lines and pipelines are usually hallucinations; ask
+	// once for something simpler (or a script) before giving up
+	if complexErr := checkComplexity(response, req.Target); complexErr != nil {
+		if appCtx.Config.Debug {
+			stdio.Debugf("Regenerating overly complex command %q: %v\n", response.Command, complexErr)
+		}
+		response, err = generateCommand(ctx, aiClient, simplerRequest(req, response.Command, complexErr))
+		if err != nil {
+			return nil, err
+		}
+		if complexErr := checkComplexity(response, req.Target); complexErr != nil {
+			return nil, exit.NewError(exit.CodeError, "AI generated an overly complex command (%v); try splitting the task into smaller requests", complexErr)
+		}
+		if req.Target != safety.TargetCmd {
+			if syntaxErr := verifySyntax(response, req.Query); syntaxErr != nil {
+				return nil, exit.NewError(exit.CodeError, "AI generated an invalid command (%v): %s", syntaxErr, response.Command)
+			}
+		}
+	}
+	
+	// Installs use this system's package manager, not another
+	// distribution's; ask once more before giving up
+	if wrongErr := checkPackageManager(response, req); wrongErr != nil {
+		if appCtx.Config.Debug {
+			stdio.Debugf("Regenerating command for another package manager %q: %v\n", response.Command, wrongErr)
+		}
+		response, err = generateCommand(ctx, aiClient, packageManagerRequest(req, response.Command, wrongErr))
+		if err != nil {
+			return nil, err
+		}
+		if wrongErr := checkPackageManager(response, req); wrongErr != nil {
+			return nil, exit.NewError(exit.CodeError, "AI generated a command for another system (%v): %s", wrongErr, response.Command)
+		}
+		if req.Target != safety.TargetCmd {
+			if syntaxErr := verifySyntax(response, req.Query); syntaxErr != nil {
+				return nil, exit.NewError(exit.CodeError, "AI generated an invalid command (%v): %s", syntaxErr, response.Command)
+			}
+		}
+	}
+	
+	result := &generation{
+		Command:  response.Command,
+		Response: response,
+	}
+	
+	// Several candidates are ranked so the default is the most
+	// conservative working option
+	if len(response.Candidates) > 1 {
+		result.Candidates = rankCandidates(ctx, response.Candidates, req.Target)
+		if len(result.Candidates) > 0 {
+			result.Command = result.Candidates[chooseCandidate(result.Candidates)].Command
+			if result.Command != response.Command {
+				response.Undo = "" // Written for the first candidate
+				response.Comments = nil
+			}
+		}
+	}
+	
+	// Multi-command plans put the first step or a chain of safe steps
+	// into the buffer
+	if len(response.Steps) > 1 {
+		result.Plan = analyzePlan(ctx, response.Steps, req.Target)
+		result.Command, result.PlanUsed = planCommand(result.Plan, appCtx.Config.Plan)
+	}
+	
+	// Fill in template placeholders before linting and safety analysis, so
+	// both see the command that will actually run
+	if len(response.Placeholders) > 0 {
+		result.Command = fillPlaceholders(result.Command, response.Placeholders, req.Target)
+	}
+	
+	// Lint the generated command before safety analysis so the verdict
+	// applies to the command after any trivial auto-fixes. The linters only
+	// understand POSIX shells.
+	if appCtx.Config.Lint && req.Target != safety.TargetCmd {
+		_, span := trace.Start(ctx, "lint.check")
+		result.Lint = lint.Check(ctx, result.Command)
+		result.Command = result.Lint.Command
+		span.SetAttr("lint.source", result.Lint.Source)
+		span.End()
+	}
+	
+	// Strict POSIX generation flags bashisms and GNU-only options the
+	// model let through, even with linting off
+	if req.POSIX && req.Target != safety.TargetCmd {
+		result.Lint.Findings = append(result.Lint.Findings, lint.POSIX(result.Command)...)
+		if result.Lint.Source == "" {
+			result.Lint.Source = "posix"
+		}
+	}
+	
+	result.Safety, err = gateCommand(ctx, result.Command, req.Target, response)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// gateCommand runs the checks every command passes before it is handed
+// out, whether the model generated it or the user edited it: the
+// exfiltration guard, the risk profile and change freeze refusals, and the
+// hybrid safety analysis. response carries the model's own assessment and
+// is nil for commands it did not write.
+func gateCommand(ctx context.Context, command string, target string, response *ai.GenerateResponse) (safety.Result, error) {
+	_, span := trace.Start(ctx, "safety.analyze")
+	defer span.End()
+	analyzer := appCtx.analyzer(target)
+	
+	// Never hand out a command that leaks credentials, even on request
+	exfil, exfilReason := safety.NoExfiltration, ""
+	if target != safety.TargetCmd {
+		exfil, exfilReason = safety.CheckExfiltration(command)
+	}
+	if exfil == safety.SendsSecrets {
+		return safety.Result{}, refuse(command, "%s", exfilReason)
+	}
+	// A change freeze refuses what the risk profile forbids, without the
+	// override, and with refuse = "attention" every command that needs it
+	freeze := activeFreeze(clock())
+	if target != safety.TargetCmd && (!appCtx.Config.RiskOverride || freeze != nil) {
+		if reason, forbidden := riskProfile().Forbids(command); forbidden {
+			if freeze != nil {
+				return safety.Result{}, refuse(command, "%s on a %s host during a change freeze (%s)", reason, riskProfile(), freezeReason(freeze))
+			}
+			return safety.Result{}, refuse(command, "%s on a %s host (--override-risk-profile generates it anyway)", reason, riskProfile())
+		}
+	}
+	if exfil == safety.ExposesSecrets || response != nil && response.Exfiltration {
+		if exfilReason == "" {
+			exfilReason = "handles credentials"
+		}
+		verdict := safety.Result{
+			Level:  safety.Attention,
+			Reason: "Command " + exfilReason + "; check where the output goes before running it",
+			Layer:  "exfiltration-guard",
+		}
+		return verdict, refuseDuringFreeze(command, verdict, freeze)
+	}
+	
+	if appCtx.Config.MockExitCode != 0 {
+		// Use mock exit code for testing
+		verdict := safety.NewAnalyzerFor(target).MockAnalyzeCommand(command, appCtx.Config.MockExitCode)
+		return verdict, refuseDuringFreeze(command, verdict, freeze)
+	}
+	
+	// Combine the AI's assessment, when it made one for this command, with
+	// the pattern analysis as the configured safety policy says
+	patternResult, err := analyzer.AnalyzeCommand(ctx, command)
+	if err != nil {
+		return safety.Result{}, exit.NewError(exit.CodeError, "Safety analysis failed: %v", err)
+	}
+	aiLevel, assessed := safety.Safe, response != nil
+	if assessed {
+		aiLevel = response.SafetyLevel
+	}
+	verdict := guardWorkdir(ctx, command, target, safetyPolicy().Merge(patternResult, aiLevel, assessed))
+	span.SetAttr("safety.layer", verdict.Layer)
+	
+	return verdict, refuseDuringFreeze(command, verdict, freeze)
+}
+
+// refuseDuringFreeze refuses a command that requires attention while a
+// change freeze with refuse = "attention" is active
+func refuseDuringFreeze(command string, verdict safety.Result, freeze *config.Freeze) error {
+	if freeze == nil || freeze.Refuse != "attention" || verdict.Level < safety.Attention {
+		return nil
+	}
+	return refuse(command, "requires attention during a change freeze (%s): %s", freezeReason(freeze), verdict.Reason)
+}
+
+// refusal is a command the gates refuse to hand out, kept apart from the
+// message so the refusal can be reported without parsing it
+type refusal struct {
+	command string
+	reason  string
+}
+
+func (r refusal) Error() string {
+	return "refusing to generate a command that " + r.reason + ": " + r.command
+}
+
+// refuse returns the Forbidden error for a command the gates refuse
+func refuse(command, format string, a ...interface{}) error {
+	return exit.Error{Code: exit.CodeForbidden, Err: refusal{command: command, reason: fmt.Sprintf(format, a...)}}
+}
+
 func init() {
 	rootCmd.AddCommand(generateCmd)
 	generateCmd.Flags().BoolP("verbose", "v", false, "Show detailed explanation of the generated command")
+	generateCmd.Flags().String("target", "", "Target shell syntax: posix (default) or cmd (Windows cmd.exe batch)")
+	generateCmd.Flags().Bool("override-risk-profile", false, "Generate a command the production risk profile forbids (it still requires attention)")
+	generateCmd.Flags().Bool("no-lint", false, "Skip shellcheck/built-in lint checks on the generated command")
+	generateCmd.Flags().Bool("sandbox", false, "Preview the command in a throwaway sandbox (bubblewrap, podman or docker) and report file changes")
+	generateCmd.Flags().String("remote", "", "Generate for a remote host (user@host), using its OS and tools gathered over SSH")
+	generateCmd.Flags().Bool("remote-exec", false, "With --remote, run the command on the remote host after confirmation")
+	generateCmd.Flags().Bool("history", false, "Use related shell history (atuin or HISTFILE) as redacted context")
+	generateCmd.Flags().String("plan", "", "For multi-step tasks, put the first step (first) or all leading safe steps joined with && (chain) into the buffer")
+	generateCmd.Flags().Bool("posix", false, "Generate strict POSIX sh without bashisms or GNU-only options (for BusyBox/Alpine and macOS)")
+	generateCmd.Flags().Bool("dir-context", false, "Send the file names in the current directory as context (asks once per directory)")
+	generateCmd.Flags().Bool("commented", false, "Put each part of a multi-part command on its own line with a # comment")
+	generateCmd.Flags().String("preset", "", "Add curated guidance for a domain to the prompt: docker, git, ffmpeg, k8s or networking")
+	generateCmd.Flags().Bool("multi-line", false, "Allow commands that span several lines, such as here-documents and loops")
+	generateCmd.Flags().String("from", "", "Adjust this command (e.g., the current shell buffer) as the description asks instead of starting over")
+	generateCmd.Flags().Bool("edit", false, "Open the generated command in $VISUAL or $EDITOR before it is placed; the edited version is analyzed again")
+	generateCmd.Flags().Int("candidates", 1, "Ask for several alternative commands, ranked by safety, portability and simplicity")
+	generateCmd.Flags().Bool("tool-versions", false, "Run --version for tools named in the query (ffmpeg, git, ...) so flags match the installed versions")
 }
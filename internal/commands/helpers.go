@@ -2,107 +2,566 @@
 package commands
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
-	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 	"hermes/internal/ai"
+	"hermes/internal/budget"
 	"hermes/internal/config"
 	"hermes/internal/exit"
+	"hermes/internal/promptcache"
+	"hermes/internal/safety"
 )
 
 // createAIClient is a factory function that creates an AI client based on app config.
-// It abstracts away the logic of choosing between the real Gemini client and the mock client.
+// It abstracts away the logic of choosing between the real Gemini client, a local
+// Ollama model (when the network is off) and the mock client.
 // It also handles API key validation and debug logging in one place.
 func createAIClient(cfg *config.Config) (ai.Client, error) {
-	// Validate API key is available (unless using mock)
-	if cfg.GeminiAPIKey == "" && cfg.MockResponse == "" {
+	if len(cfg.Race) > 0 {
+		return createRaceClient(cfg)
+	}
+	provider := providerName(cfg)
+
+	// With the network off only local providers may be constructed
+	local := !cfg.NetworkEnabled()
+	if local && provider == "gemini" {
+		return nil, exit.NewError(exit.CodeOffline, "network is off (network = \"off\") and the gemini provider needs it.\n"+
+			"Set ollama.model (and optionally ollama.url) in ~/.config/hermes/config.toml and use --provider ollama")
+	}
+	if provider == "ollama" {
+		if cfg.Ollama.Model == "" {
+			if local {
+				return nil, exit.NewError(exit.CodeOffline, "network is off (network = \"off\") and no local provider is configured.\n"+
+					"Set ollama.model (and optionally ollama.url) in ~/.config/hermes/config.toml to use a local Ollama model")
+			}
+			return nil, exit.NewError(exit.CodeConfig, "the ollama provider needs a model: set ollama.model in ~/.config/hermes/config.toml")
+		}
+		if local && !ai.IsLocalEndpoint(cfg.Ollama.URL) {
+			return nil, exit.NewError(exit.CodeOffline, "network is off (network = \"off\"): refusing to use the non-local Ollama endpoint %s", cfg.Ollama.URL)
+		}
+	}
+
+	// Validate API key is available (only Gemini takes one)
+	if cfg.GeminiAPIKey == "" && provider == "gemini" {
 		return nil, exit.NewError(exit.CodeConfig, "Gemini API key is required. Set it via (in priority order):\n"+
 			"  - CLI flag: --gemini-api-key\n"+
 			"  - Environment variable: GEMINI_API_KEY\n"+
 			"  - Config file: ~/.config/hermes/config.toml")
 	}
 
-	var provider string
+	// The mock client doesn't require a real key, and Ollama takes none
 	var apiKey string
-
-	// Determine the provider and API key based on the configuration.
-	// The mock client is used for testing and development.
-	if cfg.MockResponse != "" {
-		provider = "mock"
-		apiKey = "mock-key" // The mock client doesn't require a real key.
-	} else {
-		provider = "gemini"
+	switch provider {
+	case "mock":
+		apiKey = "mock-key"
+	case "gemini":
 		apiKey = cfg.GeminiAPIKey
 	}
 
 	// Debug logging for API key (centralized)
 	if cfg.Debug {
 		if apiKey == "mock-key" {
-			fmt.Printf("DEBUG: Using mock AI client\n")
+			stdio.Debugf("Using mock AI client\n")
+		} else if provider == "ollama" {
+			stdio.Debugf("Using local Ollama model %s at %s\n", cfg.Ollama.Model, cfg.Ollama.URL)
 		} else if len(apiKey) > 4 {
-			fmt.Printf("DEBUG: Using API key ending in ...%s\n", apiKey[len(apiKey)-4:])
+			stdio.Debugf("Using API key ending in ...%s\n", apiKey[len(apiKey)-4:])
 		} else {
-			fmt.Printf("DEBUG: Using API key (too short to truncate)\n")
+			stdio.Debugf("Using API key (too short to truncate)\n")
 		}
 	}
 
-	// Create the new AI client using the determined provider.
-	client, err := ai.NewClient(provider, ai.Config{
+	aiConfig := ai.Config{
 		APIKey:       apiKey,
 		Debug:        cfg.Debug,
 		MockResponse: cfg.MockResponse,
-	})
+		MockScenario: cfg.MockScenario,
+		MockLatency:  cfg.MockLatency,
+		MockFault:    cfg.MockFault,
+	}
+	if provider == "ollama" {
+		aiConfig.Model = cfg.Ollama.Model
+		aiConfig.BaseURL = cfg.Ollama.URL
+	}
+	if err := applySampling(&aiConfig, cfg.Generation); err != nil {
+		return nil, err
+	}
+	if cfg.Limits.MaxQueryBytes < 0 || cfg.Limits.MaxContextBytes < 0 || cfg.Limits.MaxResponseBytes < 0 {
+		return nil, exit.NewError(exit.CodeConfig, "[limits] sizes must be 0 (unlimited) or positive")
+	}
+	aiConfig.MaxResponseBytes = cfg.Limits.MaxResponseBytes
+	if cfg.Capture != "" {
+		if transcript == nil {
+			transcript = &ai.Transcript{}
+		}
+		aiConfig.Transcript = transcript
+	}
+	if cfg.PromptCache.Enabled && provider == "gemini" {
+		ttl, err := time.ParseDuration(cfg.PromptCache.TTL)
+		if err != nil || ttl < time.Minute {
+			return nil, exit.NewError(exit.CodeConfig, "prompt_cache.ttl must be a duration of at least 1m, got %q", cfg.PromptCache.TTL)
+		}
+		aiConfig.PromptCache = promptcache.New(promptcache.DefaultPath())
+		aiConfig.PromptCacheTTL = ttl
+	}
+
+	// Create the new AI client using the determined provider.
+	client, err := ai.NewClient(provider, aiConfig)
 
 	// If client creation fails, return a structured error.
 	if err != nil {
 		return nil, exit.NewError(exit.CodeError, "Failed to create AI client: %v", err)
 	}
 
+	// Refuse calls beyond the configured request rate, token budget and
+	// daily cost: after asking with over_budget = "ask", and by switching
+	// to the fallback provider when there is one
+	providerBudget := cfg.Budget[provider]
+	limits := budget.Limits{
+		RequestsPerMinute: providerBudget.RequestsPerMinute,
+		DailyTokens:       providerBudget.DailyTokens,
+		MaxCostPerDay:     providerBudget.MaxCostPerDay,
+		PricePerMillion:   budget.Prices[provider],
+	}
+	if providerBudget.PricePerMillion != nil {
+		limits.PricePerMillion = *providerBudget.PricePerMillion
+	}
+	if limits.Enabled() {
+		limited := ai.NewLimitedClient(client, budget.New(budget.DefaultPath(), provider, limits), func(err error) {
+			fmt.Fprintf(stdio.Err, "warning: failed to record provider usage: %v\n", err)
+		})
+		if providerBudget.OverBudget == "ask" {
+			limited.WithApproval(func(err error) bool {
+				return confirm(fmt.Sprintf("%v. Call %s anyway?", err, provider))
+			})
+		}
+		client = limited
+		if fallback := providerBudget.Fallback; fallback != "" {
+			client = ai.NewFallbackClient(client, func() (ai.Client, error) {
+				return createFallbackClient(cfg, fallback)
+			}, func(err error) bool {
+				var exceeded budget.ExceededError
+				if !errors.As(err, &exceeded) {
+					return false
+				}
+				stdio.Infof("└─ %v; using %s instead\n", err, fallback)
+				return true
+			})
+		}
+	}
+
+	// Refuse oversized input before it is counted against the budget or
+	// sent anywhere
+	client = ai.NewSizeGuardedClient(client, ai.SizeLimits{
+		Query:   cfg.Limits.MaxQueryBytes,
+		Context: cfg.Limits.MaxContextBytes,
+	})
+
+	// Keep credentials in queries and context on this machine
+	if cfg.Redact {
+		return ai.NewRedactingClient(client, func(count int) {
+			stdio.Infof("└─ Redacted %d secret(s) before contacting the provider\n", count)
+		}), nil
+	}
+
 	return client, nil
 }
 
-// checkShellIntegration detects if hermes shell integration is active and warns if not
-func checkShellIntegration() {
-	// Check if we're running from the hermes shell function
-	// The shell function sets HERMES_SHELL_INTEGRATION=1 when calling hermes
-	if os.Getenv("HERMES_SHELL_INTEGRATION") == "1" {
-		return // Shell integration is active
+// createFallbackClient creates the provider a budget falls back to. The
+// primary's size guard and redaction already wrap it, and it does not fall
+// back any further.
+func createFallbackClient(cfg *config.Config, name string) (ai.Client, error) {
+	selected := *cfg
+	selected.Race = nil
+	selected.Provider = name
+	selected.Redact = false
+	selected.Budget = map[string]config.Budget{}
+	for provider, limits := range cfg.Budget {
+		selected.Budget[provider] = limits
 	}
-	
-	// Check if user wants to suppress the tip
-	if os.Getenv("HERMES_SUPPRESS_INTEGRATION_TIP") == "1" {
-		return // User has chosen to suppress the tip
+	limits := selected.Budget[name]
+	limits.Fallback = ""
+	selected.Budget[name] = limits
+	return createAIClient(&selected)
+}
+
+// validateBudgets checks the [budget.<provider>] tables
+func validateBudgets(budgets map[string]config.Budget) error {
+	for provider, limits := range budgets {
+		if limits.MaxCostPerDay < 0 || limits.PricePerMillion != nil && *limits.PricePerMillion < 0 {
+			return exit.NewError(exit.CodeConfig, "budget.%s: max_cost_per_day and price_per_million_tokens must not be negative", provider)
+		}
+		switch limits.OverBudget {
+		case "", "refuse", "ask":
+		default:
+			return exit.NewError(exit.CodeConfig, "budget.%s: invalid over_budget: %s (supported: refuse, ask)", provider, limits.OverBudget)
+		}
+		if fallback := limits.Fallback; fallback != "" && (fallback == provider || !slices.Contains(ai.Providers, fallback)) {
+			return exit.NewError(exit.CodeConfig, "budget.%s: invalid fallback: %s (use another provider: %s)", provider, fallback, strings.Join(ai.Providers, ", "))
+		}
+	}
+	return nil
+}
+
+// createRaceClient builds a client for each provider in cfg.Race and races
+// them. A provider that cannot be used is left out with a warning, as long
+// as another one remains.
+func createRaceClient(cfg *config.Config) (ai.Client, error) {
+	var names, skipped []string
+	var clients []ai.Client
+	var firstErr error
+	for _, name := range cfg.Race {
+		selected := *cfg
+		selected.Race = nil
+		selected.Provider = name
+		client, err := createAIClient(&selected)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			// The first line says what is missing; the rest is setup help
+			reason, _, _ := strings.Cut(err.Error(), "\n")
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", name, reason))
+			continue
+		}
+		names = append(names, name)
+		clients = append(clients, client)
+	}
+	if len(clients) == 0 {
+		return nil, firstErr
+	}
+	for _, reason := range skipped {
+		fmt.Fprintf(stdio.Err, "warning: racing without %s\n", reason)
+	}
+	if len(clients) == 1 {
+		return clients[0], nil
+	}
+	return ai.NewRaceClient(names, clients, func(name string) {
+		if cfg.Debug {
+			stdio.Debugf("%s answered first\n", name)
+		}
+	}), nil
+}
+
+// validateRace checks the race setting: at least two distinct, known
+// providers, or none
+func validateRace(race []string) error {
+	if len(race) == 0 {
+		return nil
+	}
+	if len(race) < 2 {
+		return exit.NewError(exit.CodeConfig, "race needs at least two providers, got %s; use provider for a single one", strings.Join(race, ", "))
+	}
+	for i, name := range race {
+		if !slices.Contains(ai.Providers, name) {
+			return exit.NewError(exit.CodeConfig, "invalid provider in race: %s (supported: %s)", name, strings.Join(ai.Providers, ", "))
+		}
+		if slices.Contains(race[:i], name) {
+			return exit.NewError(exit.CodeConfig, "provider %s appears twice in race", name)
+		}
+	}
+	return nil
+}
+
+// providerName determines which provider createAIClient uses: the one
+// set with --provider or the provider key, otherwise the mock client when
+// a mock option is set, and the local Ollama provider with the network off
+func providerName(cfg *config.Config) string {
+	switch {
+	case cfg.Provider != "":
+		return cfg.Provider
+	case cfg.MockResponse != "" || cfg.MockScenario != "" || cfg.MockLatency != "" || cfg.MockFault != "":
+		return "mock"
+	case !cfg.NetworkEnabled():
+		return "ollama"
+	default:
+		return "gemini"
+	}
+}
+
+// providerConfigured reports whether createAIClient has a provider to
+// use: the mock client, a Gemini API key with the network on, or an
+// Ollama model (on a local endpoint when the network is off)
+func providerConfigured(cfg *config.Config) bool {
+	switch providerName(cfg) {
+	case "mock":
+		return true
+	case "ollama":
+		return cfg.Ollama.Model != "" && (cfg.NetworkEnabled() || ai.IsLocalEndpoint(cfg.Ollama.URL))
+	default:
+		return cfg.GeminiAPIKey != "" && cfg.NetworkEnabled()
+	}
+}
+
+// providerError wraps a failed provider call in an exit error whose code
+// tells scripts why it failed: a timeout, rate limiting, or anything else.
+// Known kinds of failure also say how to fix them.
+func providerError(err error, what string) error {
+	if hint, ok := providerFailure(err, ""); ok {
+		return hint.exitError(what, err)
+	}
+	code := exit.CodeError
+	var apiErr ai.APIError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		code = exit.CodeTimeout
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+		code = exit.CodeRateLimit
+	}
+	return exit.NewError(code, "%s failed: %v", what, err)
+}
+
+// budgetExceeded explains a call refused by the client-side budget
+func budgetExceeded(err budget.ExceededError) error {
+	return exit.NewError(exit.CodeRateLimit, "%v\n"+
+		"hermes stopped calling %s to prevent unexpected charges; adjust [budget.%s] in ~/.config/hermes/config.toml to change the limit",
+		err, err.Provider, err.Provider)
+}
+
+// maxThinkingBudget is the largest thinking budget Gemini 2.5 models accept
+const maxThinkingBudget = 32768
+
+// applySampling validates the configured sampling controls and copies them
+// into the AI client config
+func applySampling(aiConfig *ai.Config, gen config.Generation) error {
+	if gen.Temperature != nil {
+		if *gen.Temperature < 0 || *gen.Temperature > 2 {
+			return exit.NewError(exit.CodeConfig, "temperature must be between 0 and 2, got %g", *gen.Temperature)
+		}
+		temperature := float32(*gen.Temperature)
+		aiConfig.Temperature = &temperature
+	}
+	if gen.TopP != nil {
+		if *gen.TopP < 0 || *gen.TopP > 1 {
+			return exit.NewError(exit.CodeConfig, "top-p must be between 0 and 1, got %g", *gen.TopP)
+		}
+		topP := float32(*gen.TopP)
+		aiConfig.TopP = &topP
+	}
+	if gen.Seed != nil {
+		seed := int32(*gen.Seed)
+		aiConfig.Seed = &seed
+	}
+	if gen.ThinkingBudget != nil {
+		if *gen.ThinkingBudget < -1 || *gen.ThinkingBudget > maxThinkingBudget {
+			return exit.NewError(exit.CodeConfig, "thinking budget must be -1 (dynamic), 0 (off) or up to %d tokens, got %d", maxThinkingBudget, *gen.ThinkingBudget)
+		}
+		budget := int32(*gen.ThinkingBudget)
+		aiConfig.ThinkingBudget = &budget
+	}
+	return nil
+}
+
+// stdin is shared by all prompts so input buffered for one answer is not
+// lost to the next
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin.
+// Stdout is reserved for the shell buffer, so prompts never go there.
+func confirm(question string) bool {
+	if !interactive() {
+		fmt.Fprintf(stdio.Err, "%s [y/N] n (non-interactive)\n", question)
+		return false
 	}
 	
-	// Check if we're in an interactive shell that could benefit from integration
-	shellPath := os.Getenv("SHELL")
-	if shellPath == "" {
-		return // No shell detected, probably running in a script
+	fmt.Fprintf(stdio.Err, "%s [y/N] ", question)
+	answer, err := stdin.ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(stdio.Err)
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// ask prompts on stderr for a value, returning def for an empty answer or
+// when hermes may not prompt
+func ask(question, def string) string {
+	if !interactive() {
+		return def
 	}
 	
-	// Only show tip for shells we actually support
-	shellName := filepath.Base(shellPath)
-	switch shellName {
-	case "zsh":
-		// Show integration hint for zsh
-		fmt.Fprintf(os.Stderr, "\n   TIP: Enable shell integration for the best experience!\n")
-		fmt.Fprintf(os.Stderr, "   Run: eval \"$(hermes init zsh)\" >> ~/.zshrc && source ~/.zshrc\n")
-		fmt.Fprintf(os.Stderr, "   This allows hermes to put commands directly in your shell buffer.\n")
-		fmt.Fprintf(os.Stderr, "   To suppress this tip: export HERMES_SUPPRESS_INTEGRATION_TIP=1\n\n")
-	case "bash":
-		// Show integration hint for bash
-		fmt.Fprintf(os.Stderr, "\n   TIP: Enable shell integration for the best experience!\n")
-		fmt.Fprintf(os.Stderr, "   Run: eval \"$(hermes init bash)\" >> ~/.bashrc && source ~/.bashrc\n")
-		fmt.Fprintf(os.Stderr, "   This allows hermes to put commands directly in your shell buffer.\n")
-		fmt.Fprintf(os.Stderr, "   To suppress this tip: export HERMES_SUPPRESS_INTEGRATION_TIP=1\n\n")
-	case "fish":
-		// Show integration hint for fish
-		fmt.Fprintf(os.Stderr, "\n   TIP: Enable shell integration for the best experience!\n")
-		fmt.Fprintf(os.Stderr, "   Run: echo 'hermes init fish | source' >> ~/.config/fish/config.fish\n")
-		fmt.Fprintf(os.Stderr, "   This allows hermes to put commands directly in your shell buffer.\n")
-		fmt.Fprintf(os.Stderr, "   To suppress this tip: export HERMES_SUPPRESS_INTEGRATION_TIP=1\n\n")
-	default:
-		// For unsupported shells, show no tip
-		return
+	if def != "" {
+		fmt.Fprintf(stdio.Err, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(stdio.Err, "%s: ", question)
+	}
+	answer, err := stdin.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if err != nil && answer == "" {
+		fmt.Fprintln(stdio.Err)
+		return def
+	}
+	if answer == "" {
+		return def
+	}
+	return answer
+}
+
+// interactive reports whether hermes may prompt the user or print tips
+func interactive() bool {
+	return appCtx == nil || !appCtx.Config.NonInteractive.Enabled
+}
+
+// safetyPolicy is the configured safety policy
+func safetyPolicy() safety.Policy {
+	if appCtx == nil {
+		return safety.PolicyStrictestWins
+	}
+	return safety.Policy(appCtx.Config.SafetyPolicy)
+}
+
+// riskProfile is the configured risk profile
+func riskProfile() safety.Profile {
+	if appCtx == nil {
+		return safety.ProfileWorkstation
+	}
+	return safety.Profile(appCtx.Config.RiskProfile)
+}
+
+// clock tells the time for change-freeze windows; tests replace it
+var clock = time.Now
+
+// validateFreeze checks the [[freeze]] windows
+func validateFreeze(windows []config.Freeze) error {
+	for i, freeze := range windows {
+		if _, err := safety.ParseFreezeWindow(freeze.From, freeze.Until); err != nil {
+			return exit.NewError(exit.CodeConfig, "freeze %d: %v", i+1, err)
+		}
+		switch freeze.Refuse {
+		case "", "forbidden", "attention":
+		default:
+			return exit.NewError(exit.CodeConfig, "freeze %d: invalid refuse: %s (supported: forbidden, attention)", i+1, freeze.Refuse)
+		}
+		for _, profile := range freeze.Profiles {
+			if profile == "" || !safety.Profile(profile).Valid() {
+				return exit.NewError(exit.CodeConfig, "freeze %d: invalid profile: %s (supported: workstation, server, production)", i+1, profile)
+			}
+		}
+	}
+	return nil
+}
+
+// activeFreeze returns the first change-freeze window that covers t on a
+// host with the configured risk profile, or nil
+func activeFreeze(t time.Time) *config.Freeze {
+	if appCtx == nil {
+		return nil
+	}
+	profile := riskProfile()
+	if profile == "" {
+		profile = safety.ProfileWorkstation
+	}
+	for i, freeze := range appCtx.Config.Freeze {
+		if len(freeze.Profiles) > 0 && !slices.Contains(freeze.Profiles, string(profile)) {
+			continue
+		}
+		window, err := safety.ParseFreezeWindow(freeze.From, freeze.Until)
+		if err == nil && window.Contains(t) {
+			return &appCtx.Config.Freeze[i]
+		}
+	}
+	return nil
+}
+
+// freezeReason describes a change freeze in a refusal
+func freezeReason(freeze *config.Freeze) string {
+	if freeze.Reason != "" {
+		return freeze.Reason
+	}
+	return "change freeze until " + freeze.Until
+}
+
+// safetyExitCode returns the process exit code for a safety level, honoring
+// the [exit_codes] mapping and the non-interactive Attention exit code
+func safetyExitCode(level safety.SafetyLevel) int {
+	if appCtx == nil {
+		return level.ExitCode()
+	}
+	if level == safety.Attention && !interactive() && appCtx.Config.NonInteractive.AttentionExitCode != 0 {
+		return appCtx.Config.NonInteractive.AttentionExitCode
+	}
+	safe, attention := mappedExitCodes(appCtx.Config.ExitCodes)
+	switch level {
+	case safety.Safe:
+		return safe
+	case safety.Attention:
+		return attention
+	}
+	return level.ExitCode()
+}
+
+// mappedExitCodes returns the exit codes of the Safe and Attention levels
+// after the [exit_codes] mapping
+func mappedExitCodes(codes config.ExitCodes) (safe, attention int) {
+	safe, attention = exit.CodeSuccess, exit.CodeDangerous
+	if codes.Safe != 0 {
+		safe = codes.Safe
+	}
+	if codes.Attention != 0 {
+		attention = codes.Attention
+	}
+	return safe, attention
+}
+
+// validateExitCodes rejects mappings the shell integration could not tell
+// apart: codes outside 0-255, both levels on one code, or a code any run
+// can end with (1 error, 2 configuration, 130 Ctrl-C). The narrow failure
+// codes 3-7 may be taken: an integrator mapping attention onto 3 gives up
+// telling a timeout apart, and the generated scripts read a 3 without a
+// command as that failure.
+func validateExitCodes(codes config.ExitCodes) error {
+	safe, attention := mappedExitCodes(codes)
+	for _, mapped := range []struct {
+		name string
+		code int
+	}{
+		{"safe", safe},
+		{"attention", attention},
+	} {
+		if err := checkExitCode("exit_codes."+mapped.name, mapped.code); err != nil {
+			return err
+		}
+	}
+	if safe == attention {
+		return exit.NewError(exit.CodeConfig, "exit_codes.safe and exit_codes.attention must differ, both are %d", safe)
+	}
+	return nil
+}
+
+// validateAttentionExitCode applies the [exit_codes] rules to
+// non_interactive.attention_exit_code, which replaces the attention code
+// in automation; 0 leaves it unset
+func validateAttentionExitCode(code int, codes config.ExitCodes) error {
+	if code == 0 {
+		return nil
+	}
+	if err := checkExitCode("non_interactive.attention_exit_code", code); err != nil {
+		return err
+	}
+	if safe, _ := mappedExitCodes(codes); code == safe {
+		return exit.NewError(exit.CodeConfig, "non_interactive.attention_exit_code must differ from the safe code %d", safe)
+	}
+	return nil
+}
+
+// checkExitCode rejects a configured exit code outside 0-255, where the OS
+// truncates it, or one reserved for outcomes every run can have
+func checkExitCode(name string, code int) error {
+	if code < 0 || code > 255 {
+		return exit.NewError(exit.CodeConfig, "%s must be between 0 and 255, got %d", name, code)
+	}
+	switch code {
+	case exit.CodeError, exit.CodeConfig, exit.CodeInterrupted:
+		info, _ := exit.Lookup(code)
+		return exit.NewError(exit.CodeConfig, "%s = %d is reserved for %s (see hermes exit-codes)", name, code, info.Name)
 	}
+	return nil
 }
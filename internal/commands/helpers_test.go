@@ -0,0 +1,297 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/budget"
+	"hermes/internal/config"
+	"hermes/internal/exit"
+	"hermes/internal/safety"
+)
+
+func TestProviderName(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Config)
+		want   string
+	}{
+		{"default", func(cfg *config.Config) {}, "gemini"},
+		{"mock response", func(cfg *config.Config) { cfg.MockResponse = "ls" }, "mock"},
+		{"network off", func(cfg *config.Config) { cfg.Network = "off" }, "ollama"},
+		{"explicit provider", func(cfg *config.Config) { cfg.Provider = "ollama" }, "ollama"},
+		{"explicit provider wins over mock options", func(cfg *config.Config) {
+			cfg.Provider = "gemini"
+			cfg.MockResponse = "ls"
+		}, "gemini"},
+	}
+	for _, tt := range tests {
+		cfg := config.Default()
+		tt.modify(&cfg)
+		if got := providerName(&cfg); got != tt.want {
+			t.Errorf("%s: providerName() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateAIClientProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *config.Config)
+		wantCode int // 0 when the client is created
+	}{
+		{"mock", func(cfg *config.Config) { cfg.Provider = "mock" }, 0},
+		{"ollama with a model", func(cfg *config.Config) {
+			cfg.Provider = "ollama"
+			cfg.Ollama.Model = "llama3"
+		}, 0},
+		{"ollama without a model", func(cfg *config.Config) { cfg.Provider = "ollama" }, exit.CodeConfig},
+		{"gemini without a key", func(cfg *config.Config) { cfg.Provider = "gemini" }, exit.CodeConfig},
+		{"gemini with the network off", func(cfg *config.Config) {
+			cfg.Provider = "gemini"
+			cfg.GeminiAPIKey = "key"
+			cfg.Network = "off"
+		}, exit.CodeOffline},
+	}
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.Redact = false
+		tt.modify(&cfg)
+		client, err := createAIClient(&cfg)
+		if tt.wantCode == 0 {
+			if err != nil {
+				t.Errorf("%s: createAIClient() error = %v", tt.name, err)
+				continue
+			}
+			client.Close()
+			continue
+		}
+		var exitErr exit.Error
+		if !errors.As(err, &exitErr) || exitErr.Code != tt.wantCode {
+			t.Errorf("%s: createAIClient() error = %v, want exit code %d", tt.name, err, tt.wantCode)
+		}
+	}
+}
+
+func TestCreateAIClientCostCap(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	price := 1000.0
+	cfg := config.Default()
+	cfg.Redact = false
+	cfg.Provider = "mock"
+	cfg.MockResponse = "ls -la"
+	cfg.Budget = map[string]config.Budget{"mock": {MaxCostPerDay: 0.5, PricePerMillion: &price}}
+
+	// The first call costs about $0.50, so the second would go over
+	client, err := createAIClient(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+	if _, err := client.GenerateCommand(ctx, ai.GenerateRequest{Query: "list files"}); err != nil {
+		t.Fatalf("first GenerateCommand() error = %v", err)
+	}
+	var exceeded budget.ExceededError
+	if _, err := client.GenerateCommand(ctx, ai.GenerateRequest{Query: "list files"}); !errors.As(err, &exceeded) {
+		t.Fatalf("GenerateCommand() over the cap error = %v, want ExceededError", err)
+	}
+
+	// With a fallback provider the call goes there instead
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response": "{\"command\": \"ls -1\", \"safety\": \"SAFE\", \"explanation\": \"Lists files\"}", "done": true}`)
+	}))
+	defer ollama.Close()
+	cfg.Ollama = config.Ollama{URL: ollama.URL, Model: "qwen"}
+	cfg.Budget["mock"] = config.Budget{MaxCostPerDay: 0.5, PricePerMillion: &price, Fallback: "ollama"}
+	client, err = createAIClient(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if resp, err := client.GenerateCommand(ctx, ai.GenerateRequest{Query: "list files"}); err != nil || resp.Command != "ls -1" {
+		t.Errorf("GenerateCommand() with a fallback = %v, %v, want the fallback's answer", resp, err)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response": "{\"command\": \"ls -1\", \"safety\": \"SAFE\", \"explanation\": \"Lists files\"}"}`)
+	}))
+	defer ollama.Close()
+	path := filepath.Join(t.TempDir(), "transcript.json")
+	appCtx = &AppContext{Config: config.Default()}
+	appCtx.Config.Provider = "ollama"
+	appCtx.Config.Ollama = config.Ollama{URL: ollama.URL, Model: "qwen"}
+	appCtx.Config.Capture = path
+	ranCommand = "generate"
+	t.Cleanup(func() {
+		appCtx, transcript, ranCommand = nil, nil, ""
+	})
+
+	client, err := appCtx.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := client.GenerateCommand(context.Background(), ai.GenerateRequest{Query: "list files"}); err != nil {
+		t.Fatalf("GenerateCommand() error = %v", err)
+	}
+	finishCapture()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("transcript not saved: %v", err)
+	}
+	for _, want := range []string{`"command": "generate"`, `"provider": "ollama"`, `"user": "User Query: list files"`, `ls -1`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("transcript = %s, want it to contain %s", data, want)
+		}
+	}
+}
+
+func TestValidateBudgets(t *testing.T) {
+	negative := -1.0
+	for _, budgets := range []map[string]config.Budget{
+		{"gemini": {MaxCostPerDay: -1}},
+		{"gemini": {PricePerMillion: &negative}},
+		{"gemini": {OverBudget: "maybe"}},
+		{"gemini": {Fallback: "gemini"}},
+		{"gemini": {Fallback: "openai"}},
+	} {
+		var exitErr exit.Error
+		if err := validateBudgets(budgets); !errors.As(err, &exitErr) || exitErr.Code != exit.CodeConfig {
+			t.Errorf("validateBudgets(%+v) error = %v, want a config error", budgets, err)
+		}
+	}
+	if err := validateBudgets(map[string]config.Budget{"gemini": {MaxCostPerDay: 1, OverBudget: "ask", Fallback: "ollama"}}); err != nil {
+		t.Errorf("validateBudgets() error = %v", err)
+	}
+}
+
+func TestApplySamplingThinkingBudget(t *testing.T) {
+	for _, budget := range []int{-1, 0, 1024} {
+		var aiConfig ai.Config
+		if err := applySampling(&aiConfig, config.Generation{ThinkingBudget: &budget}); err != nil {
+			t.Errorf("applySampling(%d) error = %v", budget, err)
+			continue
+		}
+		if aiConfig.ThinkingBudget == nil || int(*aiConfig.ThinkingBudget) != budget {
+			t.Errorf("applySampling(%d) ThinkingBudget = %v", budget, aiConfig.ThinkingBudget)
+		}
+	}
+	for _, budget := range []int{-2, maxThinkingBudget + 1} {
+		var aiConfig ai.Config
+		var exitErr exit.Error
+		if err := applySampling(&aiConfig, config.Generation{ThinkingBudget: &budget}); !errors.As(err, &exitErr) || exitErr.Code != exit.CodeConfig {
+			t.Errorf("applySampling(%d) error = %v, want a config error", budget, err)
+		}
+	}
+}
+
+func TestCreateRaceClient(t *testing.T) {
+	if err := validateRace([]string{"gemini"}); err == nil {
+		t.Error("validateRace() accepted a single provider")
+	}
+	if err := validateRace([]string{"gemini", "gemini"}); err == nil {
+		t.Error("validateRace() accepted a duplicate provider")
+	}
+	if err := validateRace([]string{"gemini", "openai"}); err == nil {
+		t.Error("validateRace() accepted an unknown provider")
+	}
+
+	cfg := config.Default()
+	cfg.Redact = false
+	cfg.Race = []string{"mock", "ollama"}
+	cfg.Ollama.Model = "llama3"
+	client, err := createAIClient(&cfg)
+	if err != nil {
+		t.Fatalf("createAIClient() error = %v", err)
+	}
+	defer client.Close()
+	if _, ok := client.(*ai.RaceClient); !ok {
+		t.Errorf("createAIClient() = %T, want a race client", client)
+	}
+
+	// Without an Ollama model only the mock provider is left to use
+	cfg.Ollama.Model = ""
+	single, err := createAIClient(&cfg)
+	if err != nil {
+		t.Fatalf("createAIClient() without ollama error = %v", err)
+	}
+	if _, ok := single.(*ai.RaceClient); ok {
+		t.Errorf("createAIClient() raced a single usable provider")
+	}
+	single.Close()
+
+	cfg.Race = []string{"gemini", "ollama"}
+	if _, err := createAIClient(&cfg); err == nil {
+		t.Error("createAIClient() succeeded with no usable provider")
+	}
+}
+
+func TestPromptsNonInteractive(t *testing.T) {
+	origStdin := stdin
+	t.Cleanup(func() { appCtx, stdin = nil, origStdin })
+
+	// Interactive runs read the answer
+	appCtx = &AppContext{Config: config.Config{}}
+	stdin = bufio.NewReader(strings.NewReader("y\nstaging\n\n"))
+	if !confirm("Run it?") {
+		t.Error("interactive confirm(y) = false, want true")
+	}
+	if got := ask("Environment?", "dev"); got != "staging" {
+		t.Errorf("interactive ask() = %q, want the answer", got)
+	}
+	if got := ask("Environment?", "dev"); got != "dev" {
+		t.Errorf("interactive ask() with an empty answer = %q, want the default", got)
+	}
+
+	// Automation never blocks on stdin: confirm declines, ask takes the default
+	appCtx.Config.NonInteractive.Enabled = true
+	input := strings.NewReader("y\nstaging\n")
+	stdin = bufio.NewReader(input)
+	if confirm("Run it?") {
+		t.Error("non-interactive confirm() = true, want the safe default false")
+	}
+	if got := ask("Environment?", "dev"); got != "dev" {
+		t.Errorf("non-interactive ask() = %q, want the default", got)
+	}
+	if got := ask("Name?", ""); got != "" {
+		t.Errorf("non-interactive ask() without a default = %q, want empty", got)
+	}
+	if input.Len() != len("y\nstaging\n") {
+		t.Error("non-interactive prompts read from stdin")
+	}
+}
+
+func TestSafetyExitCodeNonInteractive(t *testing.T) {
+	t.Cleanup(func() { appCtx = nil })
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		level    safety.SafetyLevel
+		wantCode int
+	}{
+		{"no config", config.Config{}, safety.Attention, exit.CodeDangerous},
+		{"interactive ignores the strict code", config.Config{NonInteractive: config.NonInteractive{AttentionExitCode: 42}}, safety.Attention, exit.CodeDangerous},
+		{"strict code", config.Config{NonInteractive: config.NonInteractive{Enabled: true, AttentionExitCode: 42}}, safety.Attention, 42},
+		{"strict without a code keeps exit_codes", config.Config{NonInteractive: config.NonInteractive{Enabled: true}, ExitCodes: config.ExitCodes{Attention: 20}}, safety.Attention, 20},
+		{"strict safe", config.Config{NonInteractive: config.NonInteractive{Enabled: true, AttentionExitCode: 42}}, safety.Safe, exit.CodeSuccess},
+	}
+	for _, tt := range tests {
+		appCtx = &AppContext{Config: tt.cfg}
+		if got := safetyExitCode(tt.level); got != tt.wantCode {
+			t.Errorf("%s: safetyExitCode(%v) = %d, want %d", tt.name, tt.level, got, tt.wantCode)
+		}
+	}
+}
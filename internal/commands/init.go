@@ -3,8 +3,11 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"hermes/internal/config"
 	"hermes/internal/exit"
 )
 
@@ -17,7 +20,8 @@ var initCmd = &cobra.Command{
 This command outputs shell-specific integration code that you can evaluate
 in your shell to enable Hermes functionality. The integration includes:
   - The hermes function that handles command generation and safety warnings
-  - Proper exit code handling for different command types
+  - Proper exit code handling for different command types (see
+    'hermes exit-codes'), matching any remapping under [exit_codes]
   - Shell-specific buffer manipulation
 
 Supported shells:
@@ -29,6 +33,22 @@ Examples:
   hermes init zsh                              # Generate zsh integration script
   hermes init bash                             # Generate bash integration script
   hermes init fish                             # Generate fish function
+  hermes init zsh --preexec                    # Also check every command before it runs
+  hermes init bash --guard                     # Alt-G reviews the line being edited
+  hermes init fish --refine                    # Alt-R adjusts the line being edited
+  hermes init zsh --explain                    # Ctrl-E explains the line being edited
+  hermes init zsh --confirm yes                # Type "yes" before risky commands reach the buffer
+
+With --preexec every command line you run goes through 'hermes check'
+first (locally, no AI provider); lines that require attention only run
+after you confirm them. In zsh and fish a declined line stays in the
+buffer for editing. The bash version uses a DEBUG trap and turns on
+'shopt -s extdebug'.
+
+With --explain, Ctrl-E sends the line being edited to 'hermes explain'
+and shows the explanation above the prompt, leaving the line in place:
+explain-before-you-run for anything typed or pasted. Ctrl-E normally
+moves to the end of the line; the End key still does.
 
 Installation:
   For zsh - Add to ~/.zshrc:
@@ -45,27 +65,48 @@ Installation:
 	Args: cobra.ExactArgs(1), // Require exactly one argument (shell name)
 	RunE: func(cmd *cobra.Command, args []string) error {
 		shell := args[0]
+		preexec, _ := cmd.Flags().GetBool("preexec")
+		guard, _ := cmd.Flags().GetBool("guard")
+		refine, _ := cmd.Flags().GetBool("refine")
+		explain, _ := cmd.Flags().GetBool("explain")
+		confirm, _ := cmd.Flags().GetString("confirm")
+		if _, ok := confirmGates[confirm]; !ok && confirm != confirmOff {
+			return exit.NewError(exit.CodeError, "unsupported confirm mode: %s (supported: off, key, yes)", confirm)
+		}
 		
 		// Generate shell-specific integration script
+		var script, preexecScript, guardScript, refineScript, explainScript string
 		switch shell {
 		case "zsh":
-			fmt.Print(generateZshScript())
-			return nil
+			script, preexecScript, guardScript, refineScript, explainScript = generateZshScript(confirm), generateZshPreexec(), generateZshGuard(), generateZshRefine(), generateZshExplain()
 		case "bash":
-			fmt.Print(generateBashScript())
-			return nil
+			script, preexecScript, guardScript, refineScript, explainScript = generateBashScript(confirm), generateBashPreexec(), generateBashGuard(), generateBashRefine(), generateBashExplain()
 		case "fish":
-			fmt.Print(generateFishScript())
-			return nil
+			script, preexecScript, guardScript, refineScript, explainScript = generateFishScript(confirm), generateFishPreexec(), generateFishGuard(), generateFishRefine(), generateFishExplain()
 		default:
 			return exit.NewError(exit.CodeError, "unsupported shell: %s (supported: zsh, bash, fish)", shell)
 		}
+		out := cmd.OutOrStdout()
+		fmt.Fprint(out, script)
+		if preexec {
+			fmt.Fprint(out, preexecScript)
+		}
+		if guard {
+			fmt.Fprint(out, guardScript)
+		}
+		if refine {
+			fmt.Fprint(out, refineScript)
+		}
+		if explain {
+			fmt.Fprint(out, explainScript)
+		}
+		return nil
 	},
 }
 
 // generateZshScript returns the zsh integration script
-func generateZshScript() string {
-	return `# Hermes zsh integration
+func generateZshScript(confirm string) string {
+	return withConfirm("zsh", confirm, withExitCodes(`# Hermes zsh integration
 # This function provides natural language command generation with safety warnings
 
 hermes() {
@@ -102,17 +143,49 @@ hermes() {
     output=$(HERMES_SHELL_INTEGRATION=1 command hermes "$@")
     exit_code=$?
     
+    # [exit_codes] may map a level onto a failure code such as 3; that
+    # failure then ends with the level's code, but without a command
+    if [[ -z "$output" && ( $exit_code -eq {{safe}} || $exit_code -eq {{attention}} ) ]]; then
+        return $exit_code
+    fi
+    
     case $exit_code in
-        0)
-            # Safe command - place directly in buffer
-            print -z "$output"
+        {{safe}})
+            # Safe command - place directly in buffer (-r keeps backslashes,
+            # multi-line scripts land in the buffer whole)
+            print -rz -- "$output"
             ;;
-        10)
+        {{attention}})
             # Requires attention - show warning above prompt
             echo ""
             echo "REQUIRES ATTENTION - Potentially destructive action ahead, review before execution"
             echo ""
-            print -z "$output"
+{{confirm}}
+            print -rz -- "$output"
+            ;;
+        3)
+            # Provider timeout - retrying at once rarely helps
+            echo "hermes: the AI provider did not answer in time; try again later" >&2
+            return $exit_code
+            ;;
+        4)
+            # Rate limited - rerunning would only make it worse
+            echo "hermes: rate limited by the AI provider or the configured budget" >&2
+            return $exit_code
+            ;;
+        5)
+            # Forbidden - hermes refused to produce the command
+            echo "hermes: refused to generate this command" >&2
+            return $exit_code
+            ;;
+        6)
+            # Offline mode - no local provider to fall back to
+            echo "hermes: the network is off; configure a local provider (ollama.model)" >&2
+            return $exit_code
+            ;;
+        7|130)
+            # Declined or interrupted (Ctrl-C) - discard partial output, do not retry
+            return $exit_code
             ;;
         *)
             # Error condition - show error message
@@ -125,14 +198,29 @@ hermes() {
 # Optional: Set up alias for faster access
 # Uncomment the line below if you want 'h' as a shortcut
 # alias h='hermes'
-`
+`))
 }
 
 // generateBashScript returns the bash integration script
-func generateBashScript() string {
-	return `# Hermes bash integration
+func generateBashScript(confirm string) string {
+	return withConfirm("bash", confirm, withExitCodes(`# Hermes bash integration
 # This function provides natural language command generation with safety warnings
 
+# read -e edits a single line, so multi-line scripts (here-docs, loops)
+# go to the history instead, where Up recalls them whole
+__hermes_buffer() {
+    case "$1" in
+        *$'\n'*)
+            printf '%s\n' "$1"
+            history -s -- "$1"
+            echo "hermes: multi-line command added to history; press Up to edit and run it" >&2
+            ;;
+        *)
+            read -e -i "$1"
+            ;;
+    esac
+}
+
 hermes() {
     # If no arguments provided, show help
     if [ "$#" -eq 0 ]; then
@@ -165,17 +253,48 @@ hermes() {
     output=$(HERMES_SHELL_INTEGRATION=1 command hermes "$@")
     exit_code=$?
     
+    # [exit_codes] may map a level onto a failure code such as 3; that
+    # failure then ends with the level's code, but without a command
+    if [[ -z "$output" && ( $exit_code -eq {{safe}} || $exit_code -eq {{attention}} ) ]]; then
+        return $exit_code
+    fi
+    
     case $exit_code in
-        0)
+        {{safe}})
             # Safe command - place directly in buffer
-            read -e -i "$output"
+            __hermes_buffer "$output"
             ;;
-        10)
+        {{attention}})
             # Requires attention - show warning above prompt
             echo ""
             echo "REQUIRES ATTENTION - Potentially destructive action ahead, review before execution"
             echo ""
-            read -e -i "$output"
+{{confirm}}
+            __hermes_buffer "$output"
+            ;;
+        3)
+            # Provider timeout - retrying at once rarely helps
+            echo "hermes: the AI provider did not answer in time; try again later" >&2
+            return $exit_code
+            ;;
+        4)
+            # Rate limited - rerunning would only make it worse
+            echo "hermes: rate limited by the AI provider or the configured budget" >&2
+            return $exit_code
+            ;;
+        5)
+            # Forbidden - hermes refused to produce the command
+            echo "hermes: refused to generate this command" >&2
+            return $exit_code
+            ;;
+        6)
+            # Offline mode - no local provider to fall back to
+            echo "hermes: the network is off; configure a local provider (ollama.model)" >&2
+            return $exit_code
+            ;;
+        7|130)
+            # Declined or interrupted (Ctrl-C) - discard partial output, do not retry
+            return $exit_code
             ;;
         *)
             # Error condition - show error message
@@ -188,12 +307,12 @@ hermes() {
 # Optional: Set up alias for faster access
 # Uncomment the line below if you want 'h' as a shortcut
 # alias h='hermes'
-`
+`))
 }
 
 // generateFishScript returns the fish function (pure function, no installation comments)
-func generateFishScript() string {
-	return `function hermes
+func generateFishScript(confirm string) string {
+	return withConfirm("fish", confirm, withExitCodes(`function hermes
     # If no arguments provided, show help
     if test (count $argv) -eq 0
         command hermes --help
@@ -216,26 +335,438 @@ func generateFishScript() string {
     # Otherwise, it's a generation command - capture output for buffer
     set -l output (HERMES_SHELL_INTEGRATION=1 command hermes $argv)
     set -l exit_code $status
+
+    # Command substitution splits lines into a list; join multi-line
+    # scripts back so they reach the buffer as one piece
+    set output (string join \n -- $output | string collect)
+    
+    # [exit_codes] may map a level onto a failure code such as 3; that
+    # failure then ends with the level's code, but without a command
+    if test -z "$output"; and contains -- $exit_code {{safe}} {{attention}}
+        return $exit_code
+    end
     
     switch $exit_code
-        case 0
+        case {{safe}}
             # Safe command - place directly in buffer
-            commandline $output
-        case 10
+            commandline -- "$output"
+        case {{attention}}
             # Requires attention - show warning above prompt
             echo ""
             echo "REQUIRES ATTENTION - Potentially destructive action ahead, review before execution"
             echo ""
-            commandline $output
+{{confirm}}
+            commandline -- "$output"
+        case 3
+            # Provider timeout - retrying at once rarely helps
+            echo "hermes: the AI provider did not answer in time; try again later" >&2
+            return 3
+        case 4
+            # Rate limited - rerunning would only make it worse
+            echo "hermes: rate limited by the AI provider or the configured budget" >&2
+            return 4
+        case 5
+            # Forbidden - hermes refused to produce the command
+            echo "hermes: refused to generate this command" >&2
+            return 5
+        case 6
+            # Offline mode - no local provider to fall back to
+            echo "hermes: the network is off; configure a local provider (ollama.model)" >&2
+            return 6
+        case 7 130
+            # Declined or interrupted (Ctrl-C) - discard partial output, do not retry
+            return $exit_code
         case '*'
             # Error condition - show error message
             HERMES_SHELL_INTEGRATION=1 command hermes $argv
             return 1
     end
 end
+`))
+}
+
+// generateZshPreexec returns the zsh pre-execution check, which wraps the
+// accept-line widget
+func generateZshPreexec() string {
+	return withExitCodes(`
+# Pre-execution check (hermes init zsh --preexec): Enter runs the line
+# through 'hermes check' first; a line that requires attention only runs
+# after confirmation and otherwise stays in the buffer. The aliases go
+# along for safety_expand.
+__hermes_accept_line() {
+    if [[ -n "${BUFFER//[[:space:]]/}" ]]; then
+        zle -I
+        HERMES_SHELL_INTEGRATION=1 HERMES_ALIASES="$(alias -L)" command hermes check --quiet -- "$BUFFER" </dev/null
+        if [[ $? -eq {{attention}} ]]; then
+            local answer
+            if ! read -q "answer?hermes: run it anyway? [y/N] " </dev/tty; then
+                print
+                zle reset-prompt
+                return 0
+            fi
+            print
+        fi
+    fi
+    zle .accept-line
+}
+zle -N accept-line __hermes_accept_line
+`)
+}
+
+// generateBashPreexec returns the bash pre-execution check, a DEBUG trap
+// that skips the rest of a declined line
+func generateBashPreexec() string {
+	return withExitCodes(`
+# Pre-execution check (hermes init bash --preexec): each command line goes
+# through 'hermes check' before it runs; a line that requires attention
+# only runs after confirmation. extdebug lets the DEBUG trap skip commands.
+# The aliases go along for safety_expand.
+__hermes_preexec() {
+    # Commands before the next prompt belong to the line already checked;
+    # extdebug also traps inside functions, so let the prompt hook run
+    [ "$BASH_COMMAND" = __hermes_prompt ] && return 0
+    [ "${FUNCNAME[1]}" = __hermes_prompt ] && return 0
+    [ "$__hermes_skip" = 1 ] && return 1
+    [ "$__hermes_at_prompt" = 1 ] || return 0
+    __hermes_at_prompt=0
+    # Completion functions run commands too
+    [ -n "$COMP_LINE" ] && return 0
+
+    # The whole line comes from the history; lines kept out of it
+    # (ignorespace) fall back to the current simple command
+    local line
+    line=$(HISTTIMEFORMAT= builtin history 1)
+    if [ "$line" = "$__hermes_last_history" ]; then
+        line=$BASH_COMMAND
+    else
+        line=$(printf '%s\n' "$line" | sed '1s/^ *[0-9]*[* ] *//')
+    fi
+    [ -n "$line" ] || return 0
+
+    HERMES_SHELL_INTEGRATION=1 HERMES_ALIASES="$(alias -p)" command hermes check --quiet -- "$line" </dev/null
+    if [ $? -eq {{attention}} ]; then
+        local answer
+        read -r -p "hermes: run it anyway? [y/N] " answer </dev/tty
+        case "$answer" in
+            y|Y|yes) return 0 ;;
+        esac
+        __hermes_skip=1
+        return 1
+    fi
+    return 0
+}
+
+__hermes_prompt() {
+    __hermes_skip=0
+    __hermes_at_prompt=0
+    __hermes_last_history=$(HISTTIMEFORMAT= builtin history 1)
+}
+
+shopt -s extdebug
+trap '__hermes_preexec' DEBUG
+PROMPT_COMMAND="__hermes_prompt${PROMPT_COMMAND:+;$PROMPT_COMMAND};__hermes_at_prompt=1"
+`)
+}
+
+// generateFishPreexec returns the fish pre-execution check, which rebinds
+// Enter
+func generateFishPreexec() string {
+	return withExitCodes(`
+# Pre-execution check (hermes init fish --preexec): Enter runs the line
+# through 'hermes check' first; a line that requires attention only runs
+# after confirmation and otherwise stays in the buffer. The aliases go
+# along for safety_expand.
+function __hermes_execute
+    set -l line (commandline | string collect)
+    if string match -qr '\S' -- "$line"
+        HERMES_SHELL_INTEGRATION=1 HERMES_ALIASES=(alias | string collect) command hermes check --quiet -- "$line" </dev/null
+        if test $status -eq {{attention}}
+            read -l -P "hermes: run it anyway? [y/N] " answer
+            if not contains -- "$answer" y Y yes
+                commandline -f repaint
+                return
+            end
+        end
+    end
+    commandline -f execute
+end
+bind \r __hermes_execute
+bind \n __hermes_execute
+bind -M insert \r __hermes_execute
+bind -M insert \n __hermes_execute
+`)
+}
+
+// generateZshGuard returns the zsh guard widget, which reviews the buffer
+// without running it
+func generateZshGuard() string {
+	return `
+# Guard key (hermes init zsh --guard): Alt-G reviews the line being edited,
+# e.g. a pasted command, with the safety analyzer and the AI, and shows the
+# verdict above the prompt without running it
+hermes-guard() {
+    [[ -n "${BUFFER//[[:space:]]/}" ]] || return 0
+    zle -I
+    HERMES_SHELL_INTEGRATION=1 command hermes check --review -- "$BUFFER" </dev/null
+}
+zle -N hermes-guard
+bindkey '\eg' hermes-guard
 `
 }
 
+// generateBashGuard returns the bash guard key binding, which reviews the
+// readline buffer without running it
+func generateBashGuard() string {
+	return `
+# Guard key (hermes init bash --guard): Alt-G reviews the line being edited,
+# e.g. a pasted command, with the safety analyzer and the AI, and shows the
+# verdict above the prompt without running it
+__hermes_guard() {
+    [ -n "${READLINE_LINE//[[:space:]]/}" ] || return 0
+    HERMES_SHELL_INTEGRATION=1 command hermes check --review -- "$READLINE_LINE" </dev/null
+}
+bind -x '"\eg": __hermes_guard'
+`
+}
+
+// generateFishGuard returns the fish guard key binding, which reviews the
+// command line without running it
+func generateFishGuard() string {
+	return `
+# Guard key (hermes init fish --guard): Alt-G reviews the line being edited,
+# e.g. a pasted command, with the safety analyzer and the AI, and shows the
+# verdict above the prompt without running it
+function __hermes_guard
+    set -l line (commandline | string collect)
+    string match -qr '\S' -- "$line"; or return
+    echo
+    HERMES_SHELL_INTEGRATION=1 command hermes check --review -- "$line" </dev/null
+    commandline -f repaint
+end
+bind \eg __hermes_guard
+bind -M insert \eg __hermes_guard
+`
+}
+
+// generateZshExplain returns the zsh explain widget, which explains the
+// buffer above the prompt without changing it
+func generateZshExplain() string {
+	return `
+# Explain key (hermes init zsh --explain): Ctrl-E explains the line being
+# edited, e.g. a pasted command, above the prompt; the line stays as it is
+hermes-explain() {
+    [[ -n "${BUFFER//[[:space:]]/}" ]] || return 0
+    zle -I
+    HERMES_SHELL_INTEGRATION=1 command hermes explain -- "$BUFFER" </dev/null
+}
+zle -N hermes-explain
+bindkey '^E' hermes-explain
+`
+}
+
+// generateBashExplain returns the bash explain key binding, which explains
+// the readline buffer above the prompt without changing it
+func generateBashExplain() string {
+	return `
+# Explain key (hermes init bash --explain): Ctrl-E explains the line being
+# edited, e.g. a pasted command, above the prompt; the line stays as it is
+__hermes_explain() {
+    [ -n "${READLINE_LINE//[[:space:]]/}" ] || return 0
+    HERMES_SHELL_INTEGRATION=1 command hermes explain -- "$READLINE_LINE" </dev/null
+}
+bind -x '"\C-e": __hermes_explain'
+`
+}
+
+// generateFishExplain returns the fish explain key binding, which explains
+// the command line above the prompt without changing it
+func generateFishExplain() string {
+	return `
+# Explain key (hermes init fish --explain): Ctrl-E explains the line being
+# edited, e.g. a pasted command, above the prompt; the line stays as it is
+function __hermes_explain
+    set -l line (commandline | string collect)
+    string match -qr '\S' -- "$line"; or return
+    echo
+    HERMES_SHELL_INTEGRATION=1 command hermes explain -- "$line" </dev/null
+    commandline -f repaint
+end
+bind \ce __hermes_explain
+bind -M insert \ce __hermes_explain
+`
+}
+
+// generateZshRefine returns the zsh refine widget, which adjusts the
+// buffer with hermes gen --from
+func generateZshRefine() string {
+	return withExitCodes(`
+# Refine key (hermes init zsh --refine): Alt-R asks what to change and sends
+# the line being edited, tweaks included, to 'hermes gen --from'; the
+# adjusted command replaces the line
+autoload -Uz read-from-minibuffer
+hermes-refine() {
+    local request output exit_code
+    read-from-minibuffer "hermes adjust: " || return 0
+    request=$REPLY
+    [[ -n "${request//[[:space:]]/}" ]] || return 0
+    zle -I
+    output=$(HERMES_SHELL_INTEGRATION=1 command hermes gen --from "$BUFFER" -- "$request" </dev/tty)
+    exit_code=$?
+    case $exit_code in
+        {{safe}}|{{attention}})
+            BUFFER=$output
+            CURSOR=${#BUFFER}
+            if [[ $exit_code -eq {{attention}} ]]; then
+                zle -M "REQUIRES ATTENTION - Potentially destructive action ahead, review before execution"
+            fi
+            ;;
+    esac
+}
+zle -N hermes-refine
+bindkey '\er' hermes-refine
+`)
+}
+
+// generateBashRefine returns the bash refine key binding, which adjusts
+// the readline buffer with hermes gen --from
+func generateBashRefine() string {
+	return withExitCodes(`
+# Refine key (hermes init bash --refine): Alt-R asks what to change and
+# sends the line being edited, tweaks included, to 'hermes gen --from'; the
+# adjusted command replaces the line
+__hermes_refine() {
+    local request output exit_code
+    read -r -p "hermes adjust: " request </dev/tty || return 0
+    [ -n "${request//[[:space:]]/}" ] || return 0
+    output=$(HERMES_SHELL_INTEGRATION=1 command hermes gen --from "$READLINE_LINE" -- "$request" </dev/tty)
+    exit_code=$?
+    case $exit_code in
+        {{safe}}|{{attention}})
+            if [ $exit_code -eq {{attention}} ]; then
+                echo "REQUIRES ATTENTION - Potentially destructive action ahead, review before execution"
+            fi
+            READLINE_LINE=$output
+            READLINE_POINT=${#READLINE_LINE}
+            ;;
+    esac
+}
+bind -x '"\er": __hermes_refine'
+`)
+}
+
+// generateFishRefine returns the fish refine key binding, which adjusts
+// the command line with hermes gen --from
+func generateFishRefine() string {
+	return withExitCodes(`
+# Refine key (hermes init fish --refine): Alt-R asks what to change and
+# sends the line being edited, tweaks included, to 'hermes gen --from'; the
+# adjusted command replaces the line
+function __hermes_refine
+    set -l line (commandline | string collect)
+    echo
+    read -l -P "hermes adjust: " request; or begin
+        commandline -f repaint
+        return
+    end
+    if string match -qr '\S' -- "$request"
+        set -l output (HERMES_SHELL_INTEGRATION=1 command hermes gen --from "$line" -- "$request" </dev/tty)
+        set -l exit_code $status
+        set output (string join \n -- $output | string collect)
+        switch $exit_code
+            case {{safe}} {{attention}}
+                if test $exit_code -eq {{attention}}
+                    echo "REQUIRES ATTENTION - Potentially destructive action ahead, review before execution"
+                end
+                commandline -- "$output"
+        end
+    end
+    commandline -f repaint
+end
+bind \er __hermes_refine
+bind -M insert \er __hermes_refine
+`)
+}
+
+// confirmOff places Attention-level commands without asking
+const confirmOff = "off"
+
+// confirmGates holds the prompts asked before an Attention-level command
+// reaches the buffer, by confirm mode and shell. A refusal returns 7
+// (aborted) without touching the buffer.
+var confirmGates = map[string]map[string]string{
+	"key": {
+		"zsh": `            # Confirmation gate - one key press places the command
+            if ! read -q "?Place it in the buffer? [y/N] "; then
+                echo ""
+                echo "hermes: discarded" >&2
+                return 7
+            fi
+            echo ""`,
+		"bash": `            # Confirmation gate - one key press places the command
+            local answer
+            read -r -n 1 -p "Place it in the buffer? [y/N] " answer
+            echo ""
+            if [[ "$answer" != [yY] ]]; then
+                echo "hermes: discarded" >&2
+                return 7
+            fi`,
+		"fish": `            # Confirmation gate - one key press places the command
+            read -l -n 1 -P "Place it in the buffer? [y/N] " answer
+            if not contains -- "$answer" y Y
+                echo "hermes: discarded" >&2
+                return 7
+            end`,
+	},
+	"yes": {
+		"zsh": `            # Confirmation gate - only a typed "yes" places the command
+            local answer
+            read -r "answer?Type yes to place it in the buffer: "
+            if [[ "$answer" != yes ]]; then
+                echo "hermes: discarded" >&2
+                return 7
+            fi`,
+		"bash": `            # Confirmation gate - only a typed "yes" places the command
+            local answer
+            read -r -p "Type yes to place it in the buffer: " answer
+            if [ "$answer" != yes ]; then
+                echo "hermes: discarded" >&2
+                return 7
+            fi`,
+		"fish": `            # Confirmation gate - only a typed "yes" places the command
+            read -l -P "Type yes to place it in the buffer: " answer
+            if test "$answer" != yes
+                echo "hermes: discarded" >&2
+                return 7
+            end`,
+	},
+}
+
+// withConfirm fills the confirmation gate of the confirm mode into an
+// integration script; mode off places Attention-level commands directly
+func withConfirm(shell, mode, script string) string {
+	gate := confirmGates[mode][shell]
+	if gate == "" {
+		return strings.ReplaceAll(script, "{{confirm}}\n", "")
+	}
+	return strings.ReplaceAll(script, "{{confirm}}", gate)
+}
+
+// withExitCodes fills the configured [exit_codes] of the safety levels
+// into an integration script, so remapped codes still reach the buffer
+func withExitCodes(script string) string {
+	var codes config.ExitCodes
+	if appCtx != nil {
+		codes = appCtx.Config.ExitCodes
+	}
+	safe, attention := mappedExitCodes(codes)
+	return strings.NewReplacer("{{safe}}", strconv.Itoa(safe), "{{attention}}", strconv.Itoa(attention)).Replace(script)
+}
+
 func init() {
 	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().Bool("preexec", false, "Also check every command line with 'hermes check' before it runs")
+	initCmd.Flags().String("confirm", confirmOff, "Ask before an Attention-level command reaches the buffer: off, key (press y) or yes (type yes)")
+	initCmd.Flags().Bool("refine", false, "Bind Alt-R to adjust the line being edited with 'hermes gen --from'")
+	initCmd.Flags().Bool("guard", false, "Bind Alt-G to review the line being edited with 'hermes check --review'")
+	initCmd.Flags().Bool("explain", false, "Bind Ctrl-E to explain the line being edited with 'hermes explain'")
 }
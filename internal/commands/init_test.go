@@ -0,0 +1,270 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+	"hermes/internal/shelltest"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// integrationScripts maps each supported shell to its generator
+var integrationScripts = map[string]func() string{
+	"bash": func() string { return generateBashScript(confirmOff) },
+	"zsh":  func() string { return generateZshScript(confirmOff) },
+	"fish": func() string { return generateFishScript(confirmOff) },
+}
+
+// preexecScripts maps each supported shell to its --preexec addition
+var preexecScripts = map[string]func() string{
+	"bash": generateBashPreexec,
+	"zsh":  generateZshPreexec,
+	"fish": generateFishPreexec,
+}
+
+// guardScripts maps each supported shell to its --guard addition
+var guardScripts = map[string]func() string{
+	"bash": generateBashGuard,
+	"zsh":  generateZshGuard,
+	"fish": generateFishGuard,
+}
+
+// refineScripts maps each supported shell to its --refine addition
+var refineScripts = map[string]func() string{
+	"bash": generateBashRefine,
+	"zsh":  generateZshRefine,
+	"fish": generateFishRefine,
+}
+
+// explainScripts maps each supported shell to its --explain addition
+var explainScripts = map[string]func() string{
+	"bash": generateBashExplain,
+	"zsh":  generateZshExplain,
+	"fish": generateFishExplain,
+}
+
+func TestInitScriptsGolden(t *testing.T) {
+	scripts := map[string]func() string{}
+	for shell, generate := range integrationScripts {
+		scripts[shell] = generate
+	}
+	for shell, generate := range preexecScripts {
+		scripts[shell+"-preexec"] = generate
+	}
+	for shell, generate := range guardScripts {
+		scripts[shell+"-guard"] = generate
+	}
+	for shell, generate := range refineScripts {
+		scripts[shell+"-refine"] = generate
+	}
+	for shell, generate := range explainScripts {
+		scripts[shell+"-explain"] = generate
+	}
+	for _, mode := range []string{"key", "yes"} {
+		scripts["bash-confirm-"+mode] = func() string { return generateBashScript(mode) }
+		scripts["zsh-confirm-"+mode] = func() string { return generateZshScript(mode) }
+		scripts["fish-confirm-"+mode] = func() string { return generateFishScript(mode) }
+	}
+	for name, generate := range scripts {
+		t.Run(name, func(t *testing.T) {
+			golden := filepath.Join("testdata", "init", name+".golden")
+			got := generate()
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run go test -update to create it): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("%s script differs from %s; run go test ./internal/commands -update if the change is intended", name, golden)
+			}
+		})
+	}
+}
+
+func TestInitScriptsBehavior(t *testing.T) {
+	const warning = "REQUIRES ATTENTION"
+
+	tests := []struct {
+		name        string
+		args        []string
+		fake        shelltest.Fake
+		wantBuffer  string // Empty means nothing may reach the buffer
+		wantWarning bool
+		wantExit    int
+		wantCalls   int
+	}{
+		{"safe command goes to buffer", []string{"gen", "list", "files"}, shelltest.Fake{Stdout: "ls -la"}, "ls -la", false, 0, 1},
+		{"multi-line script stays whole", []string{"gen", "write", "notes"}, shelltest.Fake{Stdout: "cat <<'EOF' > notes.txt\nfirst  line\n\nEOF\n"}, "cat <<'EOF' > notes.txt\nfirst  line\n\nEOF", false, 0, 1},
+		{"backslashes are kept", []string{"gen", "split", "csv"}, shelltest.Fake{Stdout: `sed 's/,/\n/g' data.csv`}, `sed 's/,/\n/g' data.csv`, false, 0, 1},
+		{"attention command warns", []string{"gen", "delete", "logs"}, shelltest.Fake{Stdout: "rm -rf logs", ExitCode: 10}, "rm -rf logs", true, 0, 1},
+		{"error reruns for the message", []string{"gen", "oops"}, shelltest.Fake{Stderr: "Error: boom", ExitCode: 1}, "", false, 1, 2},
+		{"interrupt discards output", []string{"gen", "slow"}, shelltest.Fake{Stdout: "partial", ExitCode: 130}, "", false, 130, 1},
+		{"timeout is not retried", []string{"gen", "slow"}, shelltest.Fake{ExitCode: 3}, "", false, 3, 1},
+		{"rate limit is not retried", []string{"gen", "again"}, shelltest.Fake{ExitCode: 4}, "", false, 4, 1},
+		{"forbidden command", []string{"gen", "upload", "keys"}, shelltest.Fake{ExitCode: 5}, "", false, 5, 1},
+		{"offline mode", []string{"gen", "list", "files"}, shelltest.Fake{ExitCode: 6}, "", false, 6, 1},
+		{"declined prompt", []string{"gen", "--remote", "host", "reboot"}, shelltest.Fake{ExitCode: 7}, "", false, 7, 1},
+		{"non-generation passes through", []string{"explain", "ls"}, shelltest.Fake{Stdout: "lists files"}, "", false, 0, 1},
+	}
+
+	for _, shell := range shelltest.Shells {
+		for _, tt := range tests {
+			t.Run(shell+"/"+tt.name, func(t *testing.T) {
+				result := shelltest.Run(t, shell, integrationScripts[shell](), tt.fake, tt.args...)
+
+				if tt.wantBuffer != "" && result.Buffer != tt.wantBuffer {
+					t.Errorf("buffer = %q (set=%v), want %q", result.Buffer, result.BufferSet, tt.wantBuffer)
+				}
+				if tt.wantBuffer == "" && result.BufferSet {
+					t.Errorf("buffer = %q, want nothing placed in the buffer", result.Buffer)
+				}
+				if got := strings.Contains(result.Stdout, warning); got != tt.wantWarning {
+					t.Errorf("warning shown = %v, want %v (stdout %q)", got, tt.wantWarning, result.Stdout)
+				}
+				if result.ExitCode != tt.wantExit {
+					t.Errorf("exit code = %d, want %d", result.ExitCode, tt.wantExit)
+				}
+				if len(result.Calls) != tt.wantCalls {
+					t.Fatalf("hermes called %d times %v, want %d", len(result.Calls), result.Calls, tt.wantCalls)
+				}
+				for _, call := range result.Calls {
+					if !call.Integration {
+						t.Errorf("call %q ran without HERMES_SHELL_INTEGRATION=1", call.Args)
+					}
+					if call.Args != strings.Join(tt.args, " ") {
+						t.Errorf("call args = %q, want %q", call.Args, strings.Join(tt.args, " "))
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestInitScriptsRemappedExitCodes(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{ExitCodes: config.ExitCodes{Safe: 20, Attention: 21}}}
+	t.Cleanup(func() { appCtx = nil })
+
+	for _, shell := range shelltest.Shells {
+		t.Run(shell, func(t *testing.T) {
+			script := integrationScripts[shell]()
+			safe := shelltest.Run(t, shell, script, shelltest.Fake{Stdout: "ls -la", ExitCode: 20}, "gen", "list", "files")
+			if safe.Buffer != "ls -la" {
+				t.Errorf("remapped safe code: buffer = %q, want %q", safe.Buffer, "ls -la")
+			}
+			attention := shelltest.Run(t, shell, script, shelltest.Fake{Stdout: "rm -rf logs", ExitCode: 21}, "gen", "delete", "logs")
+			if attention.Buffer != "rm -rf logs" || !strings.Contains(attention.Stdout, "REQUIRES ATTENTION") {
+				t.Errorf("remapped attention code: buffer = %q, stdout = %q", attention.Buffer, attention.Stdout)
+			}
+			old := shelltest.Run(t, shell, script, shelltest.Fake{Stdout: "rm -rf logs", ExitCode: 10}, "gen", "delete", "logs")
+			if old.BufferSet {
+				t.Errorf("default attention code still reaches the buffer: %q", old.Buffer)
+			}
+		})
+	}
+}
+
+// TestInitScriptsAttentionOnFailureCode maps attention onto the timeout
+// code, as the [exit_codes] documentation suggests
+func TestInitScriptsAttentionOnFailureCode(t *testing.T) {
+	codes := config.ExitCodes{Attention: 3}
+	if err := validateExitCodes(codes); err != nil {
+		t.Fatalf("validateExitCodes(%+v) = %v, want it accepted", codes, err)
+	}
+	appCtx = &AppContext{Config: config.Config{ExitCodes: codes}}
+	t.Cleanup(func() { appCtx = nil })
+
+	for _, shell := range shelltest.Shells {
+		t.Run(shell, func(t *testing.T) {
+			script := integrationScripts[shell]()
+			attention := shelltest.Run(t, shell, script, shelltest.Fake{Stdout: "rm -rf logs", ExitCode: 3}, "gen", "delete", "logs")
+			if attention.Buffer != "rm -rf logs" || !strings.Contains(attention.Stdout, "REQUIRES ATTENTION") {
+				t.Errorf("attention on 3: buffer = %q, stdout = %q", attention.Buffer, attention.Stdout)
+			}
+			// A timeout still ends with 3, but prints no command
+			timeout := shelltest.Run(t, shell, script, shelltest.Fake{ExitCode: 3}, "gen", "slow", "query")
+			if timeout.BufferSet || timeout.ExitCode != 3 {
+				t.Errorf("timeout on 3: buffer = %q (set=%v), exit = %d; want no buffer and 3", timeout.Buffer, timeout.BufferSet, timeout.ExitCode)
+			}
+		})
+	}
+}
+
+// TestInitScriptsGeneratedHereDoc takes a here-document through syntax
+// verification and safety analysis and then into each shell's buffer
+func TestInitScriptsGeneratedHereDoc(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{MultiLine: true}}
+	t.Cleanup(func() { appCtx = nil })
+
+	heredoc := "cat <<'EOF' > notes.txt\nDon't forget (milk)\n  and $bread\nEOF"
+	client := &sequenceClient{commands: []string{heredoc}}
+	gen, err := runGeneration(context.Background(), client, ai.GenerateRequest{Query: "write a shopping note", MultiLine: true})
+	if err != nil {
+		t.Fatalf("runGeneration() error = %v", err)
+	}
+	if gen.Command != heredoc || len(client.requests) != 1 {
+		t.Fatalf("runGeneration() = %q after %d requests, want the here-document unchanged on the first try", gen.Command, len(client.requests))
+	}
+
+	for _, shell := range shelltest.Shells {
+		t.Run(shell, func(t *testing.T) {
+			fake := shelltest.Fake{Stdout: gen.Command + "\n", ExitCode: safetyExitCode(gen.Safety.Level)}
+			result := shelltest.Run(t, shell, integrationScripts[shell](), fake, "gen", "write", "a", "note")
+			if result.Buffer != heredoc {
+				t.Errorf("buffer = %q (set=%v), want the whole here-document %q", result.Buffer, result.BufferSet, heredoc)
+			}
+		})
+	}
+}
+
+func TestInitScriptsConfirmGate(t *testing.T) {
+	generators := map[string]func(confirm string) string{
+		"bash": generateBashScript,
+		"zsh":  generateZshScript,
+		"fish": generateFishScript,
+	}
+	tests := []struct {
+		name       string
+		mode       string
+		fake       shelltest.Fake
+		wantBuffer string // Empty means nothing may reach the buffer
+		wantExit   int
+	}{
+		{"key accepted", "key", shelltest.Fake{Stdout: "rm -rf logs", ExitCode: 10, Input: "y"}, "rm -rf logs", 0},
+		{"key declined", "key", shelltest.Fake{Stdout: "rm -rf logs", ExitCode: 10, Input: "n"}, "", 7},
+		{"yes typed", "yes", shelltest.Fake{Stdout: "rm -rf logs", ExitCode: 10, Input: "yes\n"}, "rm -rf logs", 0},
+		{"y is not yes", "yes", shelltest.Fake{Stdout: "rm -rf logs", ExitCode: 10, Input: "y\n"}, "", 7},
+		{"safe commands skip the gate", "yes", shelltest.Fake{Stdout: "ls -la"}, "ls -la", 0},
+	}
+
+	for _, shell := range shelltest.Shells {
+		for _, tt := range tests {
+			t.Run(shell+"/"+tt.name, func(t *testing.T) {
+				result := shelltest.Run(t, shell, generators[shell](tt.mode), tt.fake, "gen", "clean", "up")
+				if tt.wantBuffer != "" && result.Buffer != tt.wantBuffer {
+					t.Errorf("buffer = %q (set=%v), want %q", result.Buffer, result.BufferSet, tt.wantBuffer)
+				}
+				if tt.wantBuffer == "" && result.BufferSet {
+					t.Errorf("buffer = %q, want nothing placed", result.Buffer)
+				}
+				if result.ExitCode != tt.wantExit {
+					t.Errorf("exit code = %d, want %d (stderr %q)", result.ExitCode, tt.wantExit, result.Stderr)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package commands - install subcommand
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/exit"
+	"hermes/internal/safety"
+)
+
+// runInstall runs an install command in the user's terminal; tests
+// replace it
+var runInstall = func(ctx context.Context, command string) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// packageName matches the tool and package names install accepts; the
+// install command runs through sh, so nothing else may reach it
+var packageName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+_-]*$`)
+
+// installCmd installs tools with the system package manager
+var installCmd = &cobra.Command{
+	Use:   "install <tool>...",
+	Short: "Install tools with the system package manager",
+	Long: `Build the command installing the given tools with this machine's package
+manager (apt, dnf, pacman, zypper, apk or brew), using the package names
+that manager knows them by, and show it with its safety verdict. After
+confirmation hermes runs it; with --print, or when not interactive, the
+command is only printed.
+
+Examples:
+  hermes install imagemagick     # sudo pacman -S imagemagick on Arch
+  hermes install rg fd           # sudo apt install ripgrep fd-find on Debian
+  hermes install jq --print      # Print the command without running it`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := consoleFor(cmd)
+		ctx := cmd.Context()
+		for _, arg := range args {
+			if !packageName.MatchString(arg) {
+				return exit.NewError(exit.CodeError, "invalid package name: %q", arg)
+			}
+		}
+		manager, ok := detectPackageManager()
+		if !ok {
+			return exit.NewError(exit.CodeError, "no supported package manager found (apt, dnf, pacman, zypper, apk or brew)")
+		}
+		install := manager.InstallCommand(args...)
+		if reason, forbidden := riskProfile().Forbids(install); forbidden {
+			return exit.NewError(exit.CodeForbidden, "refusing a command that %s on a %s host: %s", reason, riskProfile(), install)
+		}
+
+		result, err := assessRisk(ctx, appCtx.analyzer(safety.TargetPosix), install, safety.TargetPosix)
+		if err != nil {
+			return exit.NewError(exit.CodeError, "Safety analysis failed: %v", err)
+		}
+		recordVerdict(result)
+		out.Resultf("%s\n", install)
+		if result.Level >= safety.Attention {
+			fmt.Fprintf(out.Err, "└─ attention: %s\n", result.Reason)
+		}
+
+		if printOnly, _ := cmd.Flags().GetBool("print"); printOnly || !interactive() {
+			return nil
+		}
+		if !confirm("Run it?") {
+			return exit.NewError(exit.CodeAborted, "not installed")
+		}
+		if err := runInstall(ctx, install); err != nil {
+			return exit.NewError(exit.CodeError, "%s failed: %v", manager.Name, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(installCmd)
+	installCmd.Flags().Bool("print", false, "Only print the install command, never run it")
+}
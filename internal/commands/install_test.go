@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"hermes/internal/config"
+	"hermes/internal/exit"
+	"hermes/internal/pkgmgr"
+)
+
+func TestInstall(t *testing.T) {
+	origStdin := stdin
+	appCtx = &AppContext{Config: config.Config{}}
+	detectPackageManager = func() (pkgmgr.Manager, bool) { return pkgmgr.Lookup("dnf") }
+	var ran []string
+	runInstall = func(_ context.Context, command string) error {
+		ran = append(ran, command)
+		return nil
+	}
+	t.Cleanup(func() {
+		appCtx, stdin = nil, origStdin
+		detectPackageManager, runInstall = pkgmgr.Detect, defaultRunInstall
+	})
+
+	installCmd.Flags().Set("print", "true")
+	stdout, stderr, err := runWithConsole(t, installCmd, "imagemagick", "rg")
+	installCmd.Flags().Set("print", "false")
+	if err != nil || stdout != "sudo dnf install ImageMagick ripgrep\n" || !strings.Contains(stderr, "attention:") {
+		t.Errorf("install --print = %q, %q, %v, want the dnf command with a warning", stdout, stderr, err)
+	}
+
+	stdin = bufio.NewReader(strings.NewReader("n\n"))
+	var exitErr exit.Error
+	if _, _, err := runWithConsole(t, installCmd, "jq"); !errors.As(err, &exitErr) || exitErr.Code != exit.CodeAborted || len(ran) != 0 {
+		t.Errorf("declined install = %v, ran %v, want aborted without running", err, ran)
+	}
+	stdin = bufio.NewReader(strings.NewReader("y\n"))
+	if _, _, err := runWithConsole(t, installCmd, "jq"); err != nil || len(ran) != 1 || ran[0] != "sudo dnf install jq" {
+		t.Errorf("confirmed install = %v, ran %v, want the dnf command run", err, ran)
+	}
+
+	// Arguments are never spliced into the shell command unchecked
+	for _, arg := range []string{"jq;curl x|sh", "$(id)", "-y", "jq extra", ""} {
+		if _, _, err := runWithConsole(t, installCmd, arg); err == nil || !strings.Contains(err.Error(), "invalid package name") {
+			t.Errorf("install %q = %v, want an invalid package name error", arg, err)
+		}
+	}
+	if len(ran) != 1 {
+		t.Errorf("invalid names ran %v", ran[1:])
+	}
+	installCmd.Flags().Set("print", "true")
+	stdout, _, err = runWithConsole(t, installCmd, "g++", "python3.12", "libssl-dev")
+	installCmd.Flags().Set("print", "false")
+	if err != nil || !strings.Contains(stdout, "g++ python3.12 libssl-dev") {
+		t.Errorf("install --print of valid names = %q, %v", stdout, err)
+	}
+
+	appCtx.Config.RiskProfile = "production"
+	if _, _, err := runWithConsole(t, installCmd, "jq"); !errors.As(err, &exitErr) || exitErr.Code != exit.CodeForbidden {
+		t.Errorf("install on a production host = %v, want it refused", err)
+	}
+}
+
+var defaultRunInstall = runInstall
@@ -0,0 +1,47 @@
+// Package commands - routing results and informational output
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// console routes a command's output. Results (commands, explanations,
+// reports) go to Out, which the shell integration and pipes capture;
+// informational chatter (headings, progress, notes) goes to Err so it
+// never ends up in a captured command.
+type console struct {
+	Out io.Writer
+	Err io.Writer
+}
+
+// stdio is the console for code that runs without access to its command
+var stdio = console{Out: os.Stdout, Err: os.Stderr}
+
+// consoleFor returns the console for cmd, honoring cobra's SetOut and SetErr
+func consoleFor(cmd *cobra.Command) console {
+	return console{Out: cmd.OutOrStdout(), Err: cmd.ErrOrStderr()}
+}
+
+// Resultf prints part of the command's result
+func (c console) Resultf(format string, a ...interface{}) {
+	fmt.Fprintf(c.Out, format, a...)
+}
+
+// Infof prints informational chatter. Non-interactive runs leave it out,
+// since only the result and errors matter to automation.
+func (c console) Infof(format string, a ...interface{}) {
+	if interactive() {
+		fmt.Fprintf(c.Err, format, a...)
+	}
+}
+
+// Debugf prints a --debug line. Debug output always goes to Err, so
+// debugging a run never changes what the shell integration or a pipe
+// captures.
+func (c console) Debugf(format string, a ...interface{}) {
+	fmt.Fprintf(c.Err, "DEBUG: "+format, a...)
+}
@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/config"
+	"hermes/internal/safety"
+)
+
+func TestConsoleRoutesInfoToStderr(t *testing.T) {
+	appCtx = &AppContext{Config: config.Default()}
+	var out, errOut bytes.Buffer
+	c := console{Out: &out, Err: &errOut}
+
+	c.Infof("Explaining command: '%s'\n", "ls")
+	c.Resultf("Command explanation:\n%s", "• 'ls' lists files\n")
+	if out.String() != "Command explanation:\n• 'ls' lists files\n" {
+		t.Errorf("stdout = %q, want only the result", out.String())
+	}
+	if errOut.String() != "Explaining command: 'ls'\n" {
+		t.Errorf("stderr = %q, want the heading", errOut.String())
+	}
+
+	// Automation only gets results
+	appCtx.Config.NonInteractive.Enabled = true
+	errOut.Reset()
+	c.Infof("└─ Generating command for: '%s'\n", "list files")
+	if errOut.Len() != 0 {
+		t.Errorf("non-interactive stderr = %q, want nothing", errOut.String())
+	}
+}
+
+// runWithConsole runs cmd's RunE with its output captured
+func runWithConsole(t *testing.T, cmd *cobra.Command, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	cmd.SetContext(context.Background())
+	t.Cleanup(func() {
+		cmd.SetOut(nil)
+		cmd.SetErr(nil)
+		cmd.SetContext(nil)
+	})
+	err = cmd.RunE(cmd, args)
+	return out.String(), errOut.String(), err
+}
+
+func TestGenerateOutput(t *testing.T) {
+	t.Setenv("HERMES_SUPPRESS_INTEGRATION_TIP", "1")
+	appCtx = &AppContext{Config: config.Config{
+		MockResponse: "ls -la",
+		Target:       safety.TargetPosix,
+		Plan:         planFirst,
+		Candidates:   1,
+	}}
+	t.Cleanup(func() { appCtx = nil })
+
+	stdout, stderr, err := runWithConsole(t, generateCmd, "list", "files")
+	if err != nil {
+		t.Fatalf("generate error = %v", err)
+	}
+	// The shell integration puts all of stdout in the buffer
+	if stdout != "ls -la\n" {
+		t.Errorf("stdout = %q, want only the command", stdout)
+	}
+	if !strings.Contains(stderr, "└─ Generating command for: 'list files'\n") {
+		t.Errorf("stderr = %q, want the progress line", stderr)
+	}
+}
+
+func TestGenerateDebugOutput(t *testing.T) {
+	t.Setenv("HERMES_SUPPRESS_INTEGRATION_TIP", "1")
+	appCtx = &AppContext{Config: config.Config{
+		MockResponse: "ls -la",
+		Target:       safety.TargetPosix,
+		Plan:         planFirst,
+		Candidates:   1,
+		Debug:        true,
+	}}
+	t.Cleanup(func() { appCtx = nil })
+
+	stdout, stderr, err := runWithConsole(t, generateCmd, "list", "files")
+	if err != nil {
+		t.Fatalf("generate error = %v", err)
+	}
+	// --debug must not leak into what the shell integration captures
+	if stdout != "ls -la\n" {
+		t.Errorf("stdout = %q, want only the command", stdout)
+	}
+	if !strings.Contains(stderr, "DEBUG: Generated command: ls -la\n") {
+		t.Errorf("stderr = %q, want the debug lines", stderr)
+	}
+}
+
+func TestExplainOutput(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{OfflineExplain: true, Target: safety.TargetPosix}}
+	t.Cleanup(func() { appCtx = nil })
+
+	stdout, stderr, err := runWithConsole(t, explainCmd, "ls", "-la")
+	if err != nil {
+		t.Fatalf("explain error = %v", err)
+	}
+	if !strings.HasPrefix(stdout, "Command explanation:\n• 'ls'") {
+		t.Errorf("stdout = %q, want the explanation first", stdout)
+	}
+	if !strings.Contains(stdout, "SAFE") {
+		t.Errorf("stdout = %q, want the risk assessment", stdout)
+	}
+	if stderr != "Explaining command: 'ls -la'\n" {
+		t.Errorf("stderr = %q, want only the heading", stderr)
+	}
+}
+
+func TestExplainAnnotateOutput(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{OfflineExplain: true, Target: safety.TargetPosix, MockResponse: "• 4: is the directory to archive"}}
+	explainCmd.Flags().Set("annotate", "true")
+	t.Cleanup(func() {
+		appCtx = nil
+		explainCmd.Flags().Set("annotate", "false")
+	})
+
+	stdout, stderr, err := runWithConsole(t, explainCmd, "tar", "-czf", "b.tgz", "src")
+	if err != nil {
+		t.Fatalf("explain --annotate error = %v", err)
+	}
+	for _, want := range []string{
+		"Annotated command:\ntar -czf b.tgz src\n│   │    │     └─ 4\n",
+		"1  tar    creates and extracts archive files\n",
+		"3  b.tgz  is the value of -f\n",
+		"4  src    is the directory to archive\n", // From the AI
+		"SAFE",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout = %q, want it to contain %q", stdout, want)
+		}
+	}
+	if strings.Contains(stderr, "AI details unavailable") {
+		t.Errorf("stderr = %q, want the AI details used", stderr)
+	}
+}
+
+func TestExplainExitCodeOutput(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{Network: "off"}}
+	t.Cleanup(func() { appCtx = nil })
+	var out, errOut bytes.Buffer
+
+	// Without a provider the built-in table answers alone
+	if err := explainExitCode(context.Background(), console{Out: &out, Err: &errOut}, "sleep 60", 130); err != nil {
+		t.Fatalf("explainExitCode() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Exit status 130:\n• ") {
+		t.Errorf("stdout = %q, want the built-in interpretation", out.String())
+	}
+	if strings.Contains(out.String(), "AI interpretation") {
+		t.Errorf("stdout = %q, want no AI interpretation", out.String())
+	}
+	if !strings.Contains(errOut.String(), "└─ AI interpretation unavailable") {
+		t.Errorf("stderr = %q, want the skipped interpretation noted", errOut.String())
+	}
+}
+
+func TestCommandsWriteToCobraOutput(t *testing.T) {
+	for _, args := range [][]string{
+		{"init", "bash"},
+		{"telemetry", "show"},
+	} {
+		stdout, _, err := runHermes(t, &AppContext{}, args...)
+		if err != nil {
+			t.Errorf("hermes %v error = %v", args, err)
+			continue
+		}
+		if stdout == "" {
+			t.Errorf("hermes %v wrote nothing to the command's stdout", args)
+		}
+	}
+}
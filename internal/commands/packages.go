@@ -0,0 +1,72 @@
+// Package commands - installing packages with this system's package manager
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"hermes/internal/ai"
+	"hermes/internal/pkgmgr"
+	"hermes/internal/safety"
+)
+
+// detectPackageManager finds the system package manager; tests replace it
+var detectPackageManager = pkgmgr.Detect
+
+// queryWord splits a query into words that may name a package manager
+var queryWord = regexp.MustCompile(`[A-Za-z][A-Za-z-]*`)
+
+// localPackageManager names the package manager of this machine for
+// POSIX generation, or returns "" when there is none
+func localPackageManager(target string) string {
+	if target == safety.TargetCmd {
+		return ""
+	}
+	manager, ok := detectPackageManager()
+	if !ok {
+		return ""
+	}
+	return manager.Name
+}
+
+// checkPackageManager rejects a generated command (or plan step) that
+// installs with another package manager than the system's, unless the
+// query asks for that one by name (say, for a Dockerfile)
+func checkPackageManager(response *ai.GenerateResponse, req ai.GenerateRequest) error {
+	system, ok := pkgmgr.Lookup(req.PackageManager)
+	if !ok {
+		return nil
+	}
+	commands := []string{response.Command}
+	for _, step := range response.Steps {
+		commands = append(commands, step.Command)
+	}
+	mentioned := queryWord.FindAllString(strings.ToLower(req.Query), -1)
+	for _, command := range commands {
+		used, ok := pkgmgr.Invoked(command)
+		if !ok || used.Name == system.Name {
+			continue
+		}
+		named := slices.ContainsFunc(used.Names(), func(name string) bool {
+			return slices.Contains(mentioned, name)
+		})
+		if !named {
+			return fmt.Errorf("it uses %s, but this system's package manager is %s", used.Name, system.Name)
+		}
+	}
+	return nil
+}
+
+// packageManagerRequest asks the model to redo a command with the
+// system's package manager
+func packageManagerRequest(req ai.GenerateRequest, command string, err error) ai.GenerateRequest {
+	system, _ := pkgmgr.Lookup(req.PackageManager)
+	note := fmt.Sprintf("Your previous command was wrong for this system (%v):\n%s\nUse %s (%s <package>) with its package names instead.", err, command, system.Name, system.Install)
+	if req.Context != "" {
+		note = req.Context + "\n\n" + note
+	}
+	req.Context = note
+	return req
+}
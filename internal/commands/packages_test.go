@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+	"hermes/internal/safety"
+)
+
+func TestRunGenerationUsesSystemPackageManager(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{}}
+	t.Cleanup(func() { appCtx = nil })
+
+	client := &sequenceClient{commands: []string{"sudo apt-get install -y htop", "sudo dnf install -y htop"}}
+	gen, err := runGeneration(context.Background(), client, ai.GenerateRequest{Query: "install htop", PackageManager: "dnf"})
+	if err != nil || gen.Command != "sudo dnf install -y htop" {
+		t.Fatalf("runGeneration() = %+v, %v, want the dnf command", gen, err)
+	}
+	if len(client.requests) != 2 || !strings.Contains(client.requests[1].Context, "Use dnf (sudo dnf install <package>)") {
+		t.Errorf("retry request = %+v, want the system's package manager in its context", client.requests)
+	}
+	if gen.Safety.Level != safety.Attention {
+		t.Errorf("install safety = %s, want attention", gen.Safety.Level)
+	}
+
+	// A query naming another manager gets it, e.g. for a Dockerfile
+	client = &sequenceClient{commands: []string{"apt-get install -y htop"}}
+	if gen, err := runGeneration(context.Background(), client, ai.GenerateRequest{Query: "apt line to install htop in my Dockerfile", PackageManager: "dnf"}); err != nil || len(client.requests) != 1 {
+		t.Errorf("runGeneration() = %+v, %v, want the apt command the query asked for", gen, err)
+	}
+
+	client = &sequenceClient{commands: []string{"brew install htop"}}
+	if _, err := runGeneration(context.Background(), client, ai.GenerateRequest{Query: "install htop", PackageManager: "apk"}); err == nil {
+		t.Error("runGeneration() accepted a command for another package manager twice")
+	}
+}
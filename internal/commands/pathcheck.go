@@ -0,0 +1,63 @@
+// Package commands - checks that generated commands use installed programs
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"hermes/internal/ai"
+	"hermes/internal/pathcheck"
+	"hermes/internal/pkgmgr"
+	"hermes/internal/safety"
+)
+
+// checkInstalled warns about programs the generated command runs that are
+// not on PATH. It offers to regenerate using installed tools, returning the
+// new generation, and otherwise suggests how to install them.
+func checkInstalled(ctx context.Context, out console, aiClient ai.Client, req ai.GenerateRequest, result *generation) (*generation, error) {
+	missing := pathcheck.Missing(result.Command)
+	if len(missing) == 0 {
+		return result, nil
+	}
+	fmt.Fprintf(out.Err, "└─ path: not installed: %s\n", strings.Join(missing, ", "))
+
+	if interactive() && confirm("Regenerate using installed tools?") {
+		note := "These programs are NOT installed on this machine, do not use them: " + strings.Join(missing, ", ")
+		if req.Context != "" {
+			note = req.Context + "\n\n" + note
+		}
+		req.Context = note
+		regenerated, err := runGeneration(ctx, aiClient, req)
+		if err != nil {
+			return nil, err
+		}
+		if still := pathcheck.Missing(regenerated.Command); len(still) > 0 {
+			fmt.Fprintf(out.Err, "└─ path: still not installed: %s\n", strings.Join(still, ", "))
+			suggestInstall(ctx, out, still)
+		}
+		return regenerated, nil
+	}
+
+	suggestInstall(ctx, out, missing)
+	return result, nil
+}
+
+// suggestInstall prints the command installing the missing programs with
+// the package manager found on this machine, along with its safety verdict
+func suggestInstall(ctx context.Context, out console, missing []string) {
+	manager, ok := pkgmgr.Detect()
+	if !ok {
+		return
+	}
+	install := manager.InstallCommand(missing...)
+	verdict, err := safety.NewAnalyzer().AnalyzeCommand(ctx, install)
+	if err != nil {
+		return
+	}
+	if verdict.Level >= safety.Attention {
+		out.Infof("└─ install with: %s  (attention: %s)\n", install, verdict.Reason)
+		return
+	}
+	out.Infof("└─ install with: %s\n", install)
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+)
+
+// contextClient generates one command, or another once the request
+// context says which programs are missing
+type contextClient struct {
+	command, fallback string
+}
+
+func (c contextClient) GenerateCommand(ctx context.Context, req ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	if strings.Contains(req.Context, "NOT installed") {
+		return &ai.GenerateResponse{Command: c.fallback}, nil
+	}
+	return &ai.GenerateResponse{Command: c.command}, nil
+}
+
+func (c contextClient) ExplainCommand(ctx context.Context, req ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	return &ai.ExplainResponse{}, nil
+}
+
+func (c contextClient) Close() error { return nil }
+
+func TestCheckInstalled(t *testing.T) {
+	appCtx = &AppContext{Config: config.Default()}
+	origStdin := stdin
+	t.Cleanup(func() { appCtx, stdin = nil, origStdin })
+
+	client := contextClient{command: "hermes-missing-tool --all", fallback: "echo fallback"}
+	req := ai.GenerateRequest{Query: "do it", Target: "posix"}
+	gen, err := runGeneration(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("runGeneration() error = %v", err)
+	}
+
+	stdin = bufio.NewReader(strings.NewReader("n\n"))
+	kept, err := checkInstalled(context.Background(), stdio, client, req, gen)
+	if err != nil || kept.Command != "hermes-missing-tool --all" {
+		t.Errorf("declined checkInstalled() = %+v, %v, want the original command", kept, err)
+	}
+
+	stdin = bufio.NewReader(strings.NewReader("y\n"))
+	regenerated, err := checkInstalled(context.Background(), stdio, client, req, gen)
+	if err != nil || regenerated.Command != "echo fallback" {
+		t.Errorf("accepted checkInstalled() = %+v, %v, want the regenerated command", regenerated, err)
+	}
+}
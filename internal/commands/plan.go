@@ -0,0 +1,80 @@
+// Package commands - multi-command plans
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"hermes/internal/ai"
+	"hermes/internal/safety"
+)
+
+// Plan modes: which part of a multi-command plan goes into the shell buffer
+const (
+	planFirst = "first" // Only the first step (default)
+	planChain = "chain" // The leading safe steps joined with &&
+)
+
+// planStep is a plan step with its own safety verdict
+type planStep struct {
+	ai.PlanStep
+	Safety safety.Result
+}
+
+// analyzePlan runs the pattern analysis and exfiltration guard on each step
+func analyzePlan(ctx context.Context, steps []ai.PlanStep, target string) []planStep {
+	analyzer := appCtx.analyzer(target)
+	plan := make([]planStep, 0, len(steps))
+	for _, step := range steps {
+		result, err := analyzer.AnalyzeCommand(ctx, step.Command)
+		if err != nil {
+			result = safety.Result{Level: safety.Attention, Reason: err.Error(), Layer: "error"}
+		}
+		if target != safety.TargetCmd {
+			if exfil, reason := safety.CheckExfiltration(step.Command); exfil != safety.NoExfiltration {
+				result = safety.Result{Level: safety.Attention, Reason: "Command " + reason, Layer: "exfiltration-guard"}
+			}
+		}
+		plan = append(plan, planStep{PlanStep: step, Safety: result})
+	}
+	return plan
+}
+
+// planCommand returns the command for the shell buffer and how many steps
+// it covers. Chains stop before the first step needing attention, so
+// nothing risky runs as a side effect of running something safe.
+func planCommand(plan []planStep, mode string) (string, int) {
+	used := 1
+	if mode == planChain && plan[0].Safety.Level == safety.Safe {
+		for used < len(plan) && plan[used].Safety.Level == safety.Safe {
+			used++
+		}
+	}
+
+	commands := make([]string, used)
+	for i := range commands {
+		commands[i] = plan[i].Command
+	}
+	return strings.Join(commands, " && "), used
+}
+
+// renderPlan prints the numbered steps and which of them are in the buffer
+func renderPlan(w io.Writer, plan []planStep, used int) {
+	fmt.Fprintf(w, "\nPlan:\n")
+	for i, step := range plan {
+		fmt.Fprintf(w, "  %d. %s  [%s]\n", i+1, step.Command, step.Safety.Level)
+		if step.Description != "" {
+			fmt.Fprintf(w, "     %s\n", step.Description)
+		}
+	}
+	switch {
+	case used == len(plan):
+		fmt.Fprintf(w, "└─ All %d steps are in your buffer\n\n", used)
+	case used == 1:
+		fmt.Fprintf(w, "└─ Step 1 is in your buffer; run the others after it\n\n")
+	default:
+		fmt.Fprintf(w, "└─ Steps 1-%d are in your buffer; run the others after them\n\n", used)
+	}
+}
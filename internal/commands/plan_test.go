@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"hermes/internal/ai"
+)
+
+func TestPlanCommand(t *testing.T) {
+	steps := []ai.PlanStep{
+		{Command: "python3 -m venv .venv"},
+		{Command: "pip install -r requirements.txt"},
+		{Command: "sudo systemctl restart app"},
+		{Command: "ls"},
+	}
+	plan := analyzePlan(context.Background(), steps, "posix")
+
+	tests := []struct {
+		mode     string
+		plan     []planStep
+		want     string
+		wantUsed int
+	}{
+		{planFirst, plan, "python3 -m venv .venv", 1},
+		// The chain stops before the step needing attention
+		{planChain, plan, "python3 -m venv .venv && pip install -r requirements.txt", 2},
+		// A risky first step is never chained
+		{planChain, plan[2:], "sudo systemctl restart app", 1},
+	}
+	for _, tt := range tests {
+		got, used := planCommand(tt.plan, tt.mode)
+		if got != tt.want || used != tt.wantUsed {
+			t.Errorf("planCommand(%s) = %q, %d; want %q, %d", tt.mode, got, used, tt.want, tt.wantUsed)
+		}
+	}
+}
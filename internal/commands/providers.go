@@ -0,0 +1,167 @@
+// Package commands - providers subcommand
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/config"
+	"hermes/internal/exit"
+)
+
+// providersCmd groups the provider overview subcommands
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "List AI providers and test their latency",
+}
+
+// providersListCmd shows every registered provider and its configuration
+var providersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List providers with their model and credentials",
+	Long: `List every provider hermes supports with the model it would use, whether
+its credentials are present, and whether it is ready to use. The provider
+used by default is marked with *.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printProviders(cmd.OutOrStdout(), providerStatuses(&appCtx.Config))
+		return nil
+	},
+}
+
+// providersPingCmd measures the round-trip latency of each provider
+var providersPingCmd = &cobra.Command{
+	Use:   "ping [provider...]",
+	Short: "Measure the round-trip latency to each provider",
+	Long: `Make the smallest possible request to each ready provider and print how
+long it took to answer, to help choose a default or track down slowness.
+
+Without arguments every ready provider is pinged, except the mock
+provider unless it is the default.
+
+Examples:
+  hermes providers ping          # All ready providers
+  hermes providers ping ollama   # Only the local Ollama model`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := &appCtx.Config
+		names := args
+		if len(names) == 0 {
+			for _, status := range providerStatuses(cfg) {
+				if status.Ready && (status.Name != "mock" || status.Default) {
+					names = append(names, status.Name)
+				}
+			}
+			if len(names) == 0 {
+				return exit.NewError(exit.CodeConfig, "no provider is ready; see hermes providers list")
+			}
+		}
+
+		failed := 0
+		for _, name := range names {
+			if !slices.Contains(ai.Providers, name) {
+				return exit.NewError(exit.CodeConfig, "unknown provider %s (supported: %s)", name, strings.Join(ai.Providers, ", "))
+			}
+			latency, err := pingProvider(cmd.Context(), cfg, name)
+			if err != nil {
+				failed++
+				fmt.Fprintf(cmd.OutOrStdout(), "%-8s failed: %v\n", name, err)
+				continue
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%-8s %s\n", name, latency.Round(time.Millisecond))
+		}
+		if failed > 0 {
+			return exit.NewError(exit.CodeError, "%d of %d providers failed", failed, len(names))
+		}
+		return nil
+	},
+}
+
+// providerStatus is one row of hermes providers list
+type providerStatus struct {
+	Name        string
+	Model       string
+	Credentials string
+	Ready       bool // createAIClient can build a client for it
+	Default     bool // Used when no --provider is given
+}
+
+// providerStatuses describes every registered provider under cfg
+func providerStatuses(cfg *config.Config) []providerStatus {
+	current := providerName(cfg)
+	statuses := make([]providerStatus, 0, len(ai.Providers))
+	for _, name := range ai.Providers {
+		status := providerStatus{Name: name, Credentials: "none needed", Default: name == current}
+		switch name {
+		case "gemini":
+			status.Model = ai.DefaultGeminiModel
+			status.Credentials = "no API key"
+			if cfg.GeminiAPIKey != "" {
+				status.Credentials = "API key set"
+			}
+		case "ollama":
+			status.Model = cfg.Ollama.Model
+			if status.Model == "" {
+				status.Model = "(no ollama.model)"
+			}
+		case "mock":
+			status.Model = "mock"
+		}
+		selected := *cfg
+		selected.Provider = name
+		status.Ready = providerConfigured(&selected)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// printProviders prints the provider statuses as an aligned table
+func printProviders(w io.Writer, statuses []providerStatus) {
+	for _, status := range statuses {
+		marker, ready := " ", "not configured"
+		if status.Default {
+			marker = "*"
+		}
+		if status.Ready {
+			ready = "ready"
+		}
+		fmt.Fprintf(w, "%s %-8s %-24s %-12s %s\n", marker, status.Name, status.Model, status.Credentials, ready)
+	}
+}
+
+// pingProvider makes a minimal call to one provider and returns how long
+// it took to answer
+func pingProvider(ctx context.Context, cfg *config.Config, name string) (time.Duration, error) {
+	selected := *cfg
+	selected.Provider = name
+	client, err := createAIClient(&selected)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	pinger, ok := client.(ai.Pinger)
+	if !ok {
+		return 0, fmt.Errorf("%s does not support connection tests", name)
+	}
+	stdio.Infof("└─ Pinging %s...\n", name)
+
+	ctx, cancel := context.WithTimeout(ctx, authTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := pinger.Ping(ctx); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+func init() {
+	providersCmd.AddCommand(providersListCmd)
+	providersCmd.AddCommand(providersPingCmd)
+	rootCmd.AddCommand(providersCmd)
+}
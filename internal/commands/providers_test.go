@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/config"
+)
+
+func TestProviderStatuses(t *testing.T) {
+	cfg := config.Default()
+	cfg.GeminiAPIKey = "key"
+	cfg.Ollama.Model = "qwen2.5-coder:7b"
+
+	var table bytes.Buffer
+	printProviders(&table, providerStatuses(&cfg))
+	for _, want := range []string{
+		"* gemini   gemini-2.5-flash         API key set  ready",
+		"  ollama   qwen2.5-coder:7b         none needed  ready",
+	} {
+		if !strings.Contains(table.String(), want) {
+			t.Errorf("table misses %q:\n%s", want, table.String())
+		}
+	}
+
+	// With the network off Gemini cannot be used and Ollama is the default
+	cfg.Network = "off"
+	for _, status := range providerStatuses(&cfg) {
+		switch status.Name {
+		case "gemini":
+			if status.Ready || status.Default {
+				t.Errorf("gemini = %+v, want neither ready nor default", status)
+			}
+		case "ollama":
+			if !status.Ready || !status.Default {
+				t.Errorf("ollama = %+v, want ready and default", status)
+			}
+		}
+	}
+}
+
+func TestPingProvider(t *testing.T) {
+	cfg := config.Default()
+	if _, err := pingProvider(context.Background(), &cfg, "mock"); err != nil {
+		t.Errorf("pingProvider(mock) error = %v", err)
+	}
+
+	cfg.MockFault = "rate_limit"
+	if _, err := pingProvider(context.Background(), &cfg, "mock"); err == nil {
+		t.Errorf("pingProvider(mock) with a fault succeeded")
+	}
+	if _, err := pingProvider(context.Background(), &cfg, "gemini"); err == nil || !strings.Contains(err.Error(), "API key is required") {
+		t.Errorf("pingProvider(gemini) without a key error = %v", err)
+	}
+}
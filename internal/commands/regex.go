@@ -0,0 +1,138 @@
+// Package commands - regex subcommand
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/exit"
+	"hermes/internal/regex"
+)
+
+// maxRegexAttempts bounds how often hermes asks for a regex that passes
+// the examples
+const maxRegexAttempts = 3
+
+// regexCmd builds a regular expression from a description and examples
+var regexCmd = &cobra.Command{
+	Use:   "regex <description>",
+	Short: "Build a regular expression and test it against examples",
+	Long: `Build a regular expression for a flavor (pcre, ere or go) from a
+description. The pattern is tested locally against the examples that
+should and should not match; failing examples are sent back to the model
+until they all pass (up to 3 attempts). Matching is unanchored, like grep.
+
+Examples:
+  hermes regex "match ISO dates but not times" -m 2024-01-31 -n 2024-01-31T12:30
+  hermes regex --flavor ere "IPv4 address" -m 192.168.0.1 -n 999.1`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		description := strings.Join(args, " ")
+		flavorName, _ := cmd.Flags().GetString("flavor")
+		flavor, err := regex.ParseFlavor(flavorName)
+		if err != nil {
+			return exit.NewError(exit.CodeConfig, "%v", err)
+		}
+		matches, _ := cmd.Flags().GetStringArray("match")
+		rejects, _ := cmd.Flags().GetStringArray("no-match")
+		out := consoleFor(cmd)
+		out.Infof("└─ Generating %s regex for: '%s'\n", flavor, description)
+
+		aiClient, err := appCtx.client()
+		if err != nil {
+			return err
+		}
+		defer aiClient.Close()
+
+		req := ai.GenerateRequest{Query: regexQuery(description, flavor, matches, rejects)}
+		pattern, err := buildRegex(cmd.Context(), out, aiClient, req, flavor, matches, rejects)
+		if err != nil {
+			return err
+		}
+		out.Resultf("%s\n", pattern)
+		return nil
+	},
+}
+
+// buildRegex generates a pattern and tests it against the examples,
+// sending failures back to the model until every example passes
+func buildRegex(ctx context.Context, out console, aiClient ai.Client, req ai.GenerateRequest, flavor regex.Flavor, matches, rejects []string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		response, err := generateCommand(ctx, aiClient, req)
+		if err != nil {
+			return "", err
+		}
+		pattern := response.Command
+
+		results, err := regex.Test(ctx, flavor, pattern, matches, rejects)
+		if errors.Is(err, regex.ErrNoPCRE) {
+			out.Infof("└─ not tested: %v\n", err)
+			return pattern, nil
+		}
+		failures := regexFailures(results)
+		if err == nil && len(failures) == 0 {
+			if len(results) > 0 {
+				out.Infof("└─ tested: all %d examples pass\n", len(results))
+			}
+			return pattern, nil
+		}
+
+		problem := fmt.Sprintf("invalid %s pattern: %v", flavor, err)
+		if err == nil {
+			problem = strings.Join(failures, "\n")
+		}
+		if attempt == maxRegexAttempts {
+			return "", exit.NewError(exit.CodeError, "no regex passed the examples after %d attempts; last attempt %s:\n%s", attempt, pattern, problem)
+		}
+		out.Infof("└─ attempt %d failed (%s), retrying\n", attempt, strings.ReplaceAll(problem, "\n", "; "))
+		req.Context = fmt.Sprintf("Your previous answer %s was wrong:\n%s", pattern, problem)
+	}
+}
+
+// regexQuery builds the generation query for a regex description
+func regexQuery(description string, flavor regex.Flavor, matches, rejects []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write a regular expression in %s for: %s. Put only the bare pattern in \"command\": no delimiters, quotes, flags or surrounding command. It is tested unanchored, like grep, against single lines.", flavor.Description(), description)
+	if len(matches) > 0 {
+		fmt.Fprintf(&b, " It must match: %s.", quoteExamples(matches))
+	}
+	if len(rejects) > 0 {
+		fmt.Fprintf(&b, " It must not match: %s.", quoteExamples(rejects))
+	}
+	return b.String()
+}
+
+// quoteExamples formats examples for a prompt
+func quoteExamples(examples []string) string {
+	quoted := make([]string, len(examples))
+	for i, example := range examples {
+		quoted[i] = fmt.Sprintf("%q", example)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// regexFailures describes the examples that did not behave as wanted
+func regexFailures(results []regex.Result) []string {
+	var failures []string
+	for _, r := range results {
+		switch {
+		case r.Passed():
+		case r.Want:
+			failures = append(failures, fmt.Sprintf("should match %q but does not", r.Example))
+		default:
+			failures = append(failures, fmt.Sprintf("should not match %q but does", r.Example))
+		}
+	}
+	return failures
+}
+
+func init() {
+	rootCmd.AddCommand(regexCmd)
+	regexCmd.Flags().String("flavor", string(regex.PCRE), "Regex flavor: pcre, ere (POSIX extended) or go (RE2)")
+	regexCmd.Flags().StringArrayP("match", "m", nil, "Example the regex must match (repeatable)")
+	regexCmd.Flags().StringArrayP("no-match", "n", nil, "Example the regex must not match (repeatable)")
+}
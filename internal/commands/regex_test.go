@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+	"hermes/internal/regex"
+)
+
+func TestBuildRegexRetriesFailingExamples(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{}}
+	t.Cleanup(func() { appCtx = nil })
+
+	client := &sequenceClient{commands: []string{`(unclosed`, `\d{4}-\d{2}-\d{2}`, `\d{4}-\d{2}-\d{2}(?:[^T]|$)`}}
+	matches, rejects := []string{"2024-01-31"}, []string{"2024-01-31T12:30"}
+	req := ai.GenerateRequest{Query: regexQuery("ISO dates but not times", regex.Go, matches, rejects)}
+
+	pattern, err := buildRegex(context.Background(), stdio, client, req, regex.Go, matches, rejects)
+	if err != nil {
+		t.Fatalf("buildRegex() error: %v", err)
+	}
+	if pattern != `\d{4}-\d{2}-\d{2}(?:[^T]|$)` {
+		t.Errorf("buildRegex() = %q, want the third attempt", pattern)
+	}
+	if len(client.requests) != 3 {
+		t.Fatalf("made %d requests, want 3", len(client.requests))
+	}
+	if !strings.Contains(client.requests[1].Context, "invalid go pattern") {
+		t.Errorf("second request context = %q, want the compile error", client.requests[1].Context)
+	}
+	if !strings.Contains(client.requests[2].Context, `should not match "2024-01-31T12:30" but does`) {
+		t.Errorf("third request context = %q, want the failing example", client.requests[2].Context)
+	}
+}
+
+func TestBuildRegexGivesUp(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{}}
+	t.Cleanup(func() { appCtx = nil })
+
+	client := &sequenceClient{commands: []string{`x`}}
+	_, err := buildRegex(context.Background(), stdio, client, ai.GenerateRequest{}, regex.Go, []string{"y"}, nil)
+	if err == nil || !strings.Contains(err.Error(), `should match "y" but does not`) {
+		t.Errorf("buildRegex() error = %v, want the failing example", err)
+	}
+	if len(client.requests) != maxRegexAttempts {
+		t.Errorf("made %d requests, want %d", len(client.requests), maxRegexAttempts)
+	}
+}
@@ -0,0 +1,65 @@
+// Package commands - live config reload for long-running modes
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/spf13/cobra"
+	"hermes/internal/config"
+	"hermes/internal/exit"
+)
+
+// watchConfig calls apply with the new configuration whenever the config
+// file changes, until ctx is done. A file that does not parse or validate,
+// or that apply rejects, is reported on stderr and the previous
+// configuration stays in effect.
+func watchConfig(ctx context.Context, cmd *cobra.Command, apply func(cfg config.Config) error) {
+	path := configPath()
+	if path == "" {
+		return
+	}
+	provider := file.Provider(path)
+	err := provider.Watch(func(event interface{}, err error) {
+		if err == nil {
+			err = reloadConfig(cmd, path, apply)
+		}
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "hermes: config reload rejected, keeping the previous config: %v\n", err)
+			return
+		}
+		consoleFor(cmd).Infof("hermes: reloaded %s\n", path)
+	})
+	if err != nil {
+		// Nothing to watch until the file exists
+		if appCtx.Config.Debug {
+			stdio.Debugf("not watching the config file: %v\n", err)
+		}
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		provider.Unwatch()
+	}()
+}
+
+// reloadConfig reads the changed config file and hands the result to apply.
+// Unlike at startup, a file that does not parse is an error rather than a
+// warning, since the running configuration is still good.
+func reloadConfig(cmd *cobra.Command, path string, apply func(cfg config.Config) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := toml.Parser().Unmarshal(data); err != nil {
+		return exit.NewError(exit.CodeConfig, "%s: %v", path, err)
+	}
+	cfg, err := readConfig(cmd)
+	if err != nil {
+		return err
+	}
+	return apply(cfg)
+}
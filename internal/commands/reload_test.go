@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+)
+
+func TestReloadConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := configPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var applied []config.Config
+	apply := func(cfg config.Config) error {
+		applied = append(applied, cfg)
+		return nil
+	}
+
+	write("mock_response = \"ls\"\nexperience_level = \"wizard\"\n")
+	if err := reloadConfig(rootCmd, path, apply); err == nil {
+		t.Errorf("reloadConfig() accepted an invalid experience level")
+	}
+	write("mock_response = \"ls\"\nexperience_level = [\n")
+	if err := reloadConfig(rootCmd, path, apply); err == nil {
+		t.Errorf("reloadConfig() accepted a file that does not parse")
+	}
+	if len(applied) != 0 {
+		t.Fatalf("rejected configs were applied: %+v", applied)
+	}
+
+	write("mock_response = \"ls\"\nexperience_level = \"expert\"\n")
+	if err := reloadConfig(rootCmd, path, apply); err != nil {
+		t.Fatalf("reloadConfig() error = %v", err)
+	}
+	if len(applied) != 1 || applied[0].ExperienceLevel != "expert" || applied[0].MockResponse != "ls" {
+		t.Errorf("applied = %+v, want the expert config", applied)
+	}
+}
+
+func TestEditorHandlerReload(t *testing.T) {
+	appCtx = &AppContext{Config: config.Default()}
+	cfg := config.Default()
+	cfg.MockResponse = "ls"
+	client, err := createAIClient(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &editorHandler{client: client}
+
+	cfg.MockResponse = "pwd"
+	cfg.Debug = true
+	if err := handler.reload(cfg); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if handler.client == client || appCtx.Config.MockResponse != "pwd" {
+		t.Errorf("reload() kept the previous client or config")
+	}
+	if appCtx.Config.Debug || !appCtx.Config.NonInteractive.Enabled {
+		t.Errorf("reload() dropped the editor mode overrides: %+v", appCtx.Config)
+	}
+
+	// A provider that cannot be built keeps the current client
+	current := handler.client
+	bad := config.Default()
+	bad.Provider = "ollama"
+	if err := handler.reload(bad); err == nil || handler.client != current {
+		t.Errorf("reload() with an unusable provider error = %v", err)
+	}
+	handler.client.Close()
+}
+
+func TestEditorHandlerReloadUsesFactory(t *testing.T) {
+	var built []config.Config
+	appCtx = &AppContext{
+		Config: config.Default(),
+		NewClient: func(cfg *config.Config) (ai.Client, error) {
+			built = append(built, *cfg)
+			return &sequenceClient{commands: []string{"ls"}}, nil
+		},
+	}
+	t.Cleanup(func() { appCtx = nil })
+	handler := &editorHandler{client: &sequenceClient{}}
+
+	cfg := config.Default()
+	cfg.Provider = "ollama"
+	if err := handler.reload(cfg); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if len(built) != 1 || built[0].Provider != "ollama" || !built[0].NonInteractive.Enabled {
+		t.Errorf("factory built %+v, want one client for the reloaded editor-mode config", built)
+	}
+	if _, ok := handler.client.(*sequenceClient); !ok {
+		t.Errorf("reload() client = %T, want the factory's", handler.client)
+	}
+}
+
+func TestReadConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("GEMINI_API_KEY", "")
+	system := filepath.Join(dir, "system.toml")
+	orig := systemConfigPath
+	systemConfigPath = system
+	t.Cleanup(func() { systemConfigPath = orig })
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(system, "provider = \"ollama\"\nsafety_policy = \"pattern-only\"\nexperience_level = \"beginner\"\n\n[ollama]\nurl = \"http://llm.internal:11434\"\nmodel = \"qwen2.5-coder:7b\"\n")
+	write(configPath(), "experience_level = \"expert\"\n\n[ollama]\nmodel = \"llama3.2\"\n")
+
+	cfg, err := readConfig(rootCmd)
+	if err != nil {
+		t.Fatalf("readConfig() error = %v", err)
+	}
+	// Machine-wide settings hold unless the user sets them, key by key
+	if cfg.Provider != "ollama" || cfg.SafetyPolicy != "pattern-only" || cfg.Ollama.URL != "http://llm.internal:11434" {
+		t.Errorf("readConfig() = provider %q, policy %q, url %q, want the machine-wide settings", cfg.Provider, cfg.SafetyPolicy, cfg.Ollama.URL)
+	}
+	if cfg.ExperienceLevel != "expert" || cfg.Ollama.Model != "llama3.2" {
+		t.Errorf("readConfig() = level %q, model %q, want the user's preferences", cfg.ExperienceLevel, cfg.Ollama.Model)
+	}
+}
@@ -0,0 +1,127 @@
+// Package commands - risk assessment for explained commands
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"hermes/internal/safety"
+	"hermes/internal/shell"
+	"hermes/internal/workdir"
+)
+
+// printRiskAssessment appends the safety verdict for an explained command:
+// its level, the parts that need attention, safer alternatives, the
+// expected impact and how to undo it
+func printRiskAssessment(ctx context.Context, w io.Writer, command string, target string) {
+	analyzer := appCtx.analyzer(target)
+	result, err := assessRisk(ctx, analyzer, command, target)
+	if err != nil {
+		return
+	}
+	result = guardWorkdir(ctx, command, target, result)
+
+	fmt.Fprintf(w, "\nRisk assessment:\n")
+	fmt.Fprintf(w, "• Level: %s (%s)\n", strings.ToUpper(result.Level.String()), result.Reason)
+	if result.Level < safety.Attention {
+		return
+	}
+	if result.Docs != "" {
+		fmt.Fprintf(w, "• Docs: %s\n", result.Docs)
+	}
+
+	if parts := riskyParts(ctx, analyzer, command); len(parts) > 0 {
+		fmt.Fprintf(w, "• Needs attention:\n")
+		for _, part := range parts {
+			fmt.Fprintf(w, "  • %s\n", part)
+		}
+	}
+	if target != safety.TargetCmd {
+		for _, estimate := range impactEstimates(ctx, command, target) {
+			fmt.Fprintf(w, "• Impact: %s\n", estimate)
+		}
+	}
+	if alternatives := saferAlternatives(command, result, target); len(alternatives) > 0 {
+		fmt.Fprintf(w, "• Safer alternatives:\n")
+		for _, alternative := range alternatives {
+			fmt.Fprintf(w, "  • %s (%s)\n", alternative.Command, alternative.Reason)
+		}
+	}
+	if undo := undoHint(command, result, "", target); undo != "" {
+		fmt.Fprintf(w, "• Undo: %s\n", undo)
+	}
+}
+
+// assessRisk analyzes a command for the target shell, treating data
+// exfiltration as needing attention
+func assessRisk(ctx context.Context, analyzer safety.CommandAnalyzer, command string, target string) (safety.Result, error) {
+	result, err := analyzer.AnalyzeCommand(ctx, command)
+	if err != nil {
+		return result, err
+	}
+	if target != safety.TargetCmd {
+		if exfil, reason := safety.CheckExfiltration(command); exfil != safety.NoExfiltration {
+			result = safety.Result{Level: safety.Attention, Reason: "Command " + reason, Layer: "exfiltration-guard"}
+		}
+	}
+	return result, nil
+}
+
+// riskyParts returns the simple commands of a command line that need
+// attention on their own. When only the combination is risky (curl ... |
+// sh), no single part is returned.
+func riskyParts(ctx context.Context, analyzer safety.CommandAnalyzer, command string) []string {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return nil
+	}
+	stages := script.Stages()
+	if len(stages) < 2 {
+		return nil // The whole command is the risky part
+	}
+
+	var parts []string
+	for _, stage := range stages {
+		var words []string
+		for _, arg := range stage.Args {
+			words = append(words, arg.Raw)
+		}
+		for _, redirect := range stage.Redirects {
+			words = append(words, redirect.Op+redirect.Target)
+		}
+		text := strings.Join(words, " ")
+		if result, err := analyzer.AnalyzeCommand(ctx, text); err == nil && result.Level >= safety.Attention {
+			parts = append(parts, text)
+		}
+	}
+	return parts
+}
+
+// guardWorkdir raises a safe verdict to attention when the command clears
+// the current directory and the directory makes that worse than usual: it
+// is /, on a network share, or a git repository with uncommitted changes
+func guardWorkdir(ctx context.Context, command string, target string, result safety.Result) safety.Result {
+	if result.Level >= safety.Attention || target == safety.TargetCmd || appCtx == nil || !appCtx.Config.WorkdirGuard {
+		return result
+	}
+	clears, ok := safety.ClearsWorkdir(command)
+	if !ok {
+		return result
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return result
+	}
+	hazard := workdir.Hazard(ctx, dir)
+	if hazard == "" {
+		return result
+	}
+	return safety.Result{
+		Level:  safety.Attention,
+		Reason: fmt.Sprintf("Command %s, and %s is %s", clears, dir, hazard),
+		Layer:  "workdir-guard",
+	}
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/config"
+	"hermes/internal/safety"
+)
+
+func TestPrintRiskAssessment(t *testing.T) {
+	var out bytes.Buffer
+	printRiskAssessment(context.Background(), &out, "cd /tmp/build && rm -rf cache", safety.TargetPosix)
+	got := out.String()
+	for _, want := range []string{
+		"Risk assessment:\n• Level: ATTENTION",
+		"• Needs attention:\n  • rm -rf cache\n",
+		"  • cd /tmp/build && rm -ri cache (asks before removing each file)\n",
+		"• Undo: not reversible",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("risk assessment missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Needs attention:\n  • cd") {
+		t.Errorf("risk assessment lists the safe cd as risky:\n%s", got)
+	}
+
+	out.Reset()
+	printRiskAssessment(context.Background(), &out, "ls -la", safety.TargetPosix)
+	if got := out.String(); !strings.Contains(got, "• Level: SAFE") || strings.Contains(got, "Undo") {
+		t.Errorf("risk assessment for ls =\n%s\nwant only the safe level", got)
+	}
+}
+
+func TestPrintRiskAssessmentExfiltration(t *testing.T) {
+	var out bytes.Buffer
+	printRiskAssessment(context.Background(), &out, "cat ~/.aws/credentials", safety.TargetPosix)
+	if got := out.String(); !strings.Contains(got, "ATTENTION (Command prints or copies") {
+		t.Errorf("risk assessment =\n%s\nwant the exfiltration verdict", got)
+	}
+}
+
+func TestGuardWorkdir(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{WorkdirGuard: true}}
+	t.Cleanup(func() { appCtx = nil })
+	t.Chdir("/")
+
+	safe := safety.Result{Level: safety.Safe, Layer: "default-safe"}
+	result := guardWorkdir(context.Background(), "find . -name '*.tmp' -delete", safety.TargetPosix, safe)
+	if result.Level != safety.Attention || result.Layer != "workdir-guard" || !strings.Contains(result.Reason, "/ is the root directory") {
+		t.Errorf("guardWorkdir() in / = %+v, want attention from the workdir guard", result)
+	}
+	if result := guardWorkdir(context.Background(), "find . -name '*.tmp'", safety.TargetPosix, safe); result != safe {
+		t.Errorf("guardWorkdir() of a harmless find = %+v, want it unchanged", result)
+	}
+
+	appCtx.Config.WorkdirGuard = false
+	if result := guardWorkdir(context.Background(), "find . -delete", safety.TargetPosix, safe); result != safe {
+		t.Errorf("guardWorkdir() with workdir_guard off = %+v, want it unchanged", result)
+	}
+}
@@ -2,19 +2,61 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/knadh/koanf/parsers/toml/v2"
 	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
 	"github.com/spf13/cobra"
+	"hermes/internal/ai"
 	"hermes/internal/config"
+	"hermes/internal/exit"
+	"hermes/internal/safety"
+	"hermes/internal/telemetry"
+	"hermes/internal/trace"
 )
 
 // AppContext holds dependencies for the application
 type AppContext struct {
 	Config config.Config
+
+	// Factories for the AI client and the safety analyzer; nil uses the
+	// configured provider and the pattern analyzer. Tests inject fakes.
+	NewClient   func(cfg *config.Config) (ai.Client, error)
+	NewAnalyzer func(target string) safety.CommandAnalyzer
+}
+
+// client creates the AI client for the loaded config
+func (a *AppContext) client() (ai.Client, error) {
+	return a.clientFor(&a.Config)
+}
+
+// clientFor creates the AI client for cfg, such as a reloaded config
+func (a *AppContext) clientFor(cfg *config.Config) (ai.Client, error) {
+	if a.NewClient != nil {
+		return a.NewClient(cfg)
+	}
+	return createAIClient(cfg)
+}
+
+// analyzer creates the safety analyzer for a target shell
+func (a *AppContext) analyzer(target string) safety.CommandAnalyzer {
+	if a != nil && a.NewAnalyzer != nil {
+		return a.NewAnalyzer(target)
+	}
+	analyzer := patternAnalyzer(target)
+	if a != nil && a.Config.SafetyExpand && target != safety.TargetCmd {
+		return safety.ExpandingAnalyzer{CommandAnalyzer: analyzer, Expansion: shellExpansion()}
+	}
+	return analyzer
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -53,11 +95,21 @@ Configuration:
 	
 	// Load configuration before any command runs
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		_, span := trace.Start(cmd.Context(), "config.load")
+		defer span.End()
+		ranCommand = strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
 		return loadConfig(cmd)
 	},
 	
-	// Show help when no subcommand is provided
+	// Show help when no subcommand is provided, after offering setup on
+	// the first run
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if editorMode, _ := cmd.Flags().GetBool("editor-mode"); editorMode {
+			return runEditorMode(cmd)
+		}
+		if firstRun() {
+			return runSetup(cmd)
+		}
 		return cmd.Help()
 	},
 }
@@ -65,22 +117,175 @@ Configuration:
 // Global app context
 var appCtx *AppContext
 
+// ranCommand is the subcommand path being run (e.g., "auth test"), for telemetry
+var ranCommand string
+
+// interruptGrace is how long a command may take to wind down after Ctrl-C
+// before hermes exits anyway (e.g., while blocked reading a prompt answer)
+const interruptGrace = 2 * time.Second
+
 // Execute is the main entry point for the CLI
 func Execute() error {
-	return rootCmd.Execute()
+	tracer := trace.New()
+	ctx, span := trace.Start(trace.WithTracer(context.Background(), tracer), "hermes")
+
+	// Ctrl-C and SIGTERM cancel the context, so in-flight provider calls
+	// return instead of leaving the terminal waiting
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	done := make(chan struct{})
+	defer close(done)
+	go watchInterrupt(ctx, stop, done)
+
+	err := rootCmd.ExecuteContext(ctx)
+	if ctx.Err() != nil {
+		// Whatever the command was doing, it did not finish; the shell
+		// integration discards partial output on this exit code
+		err = exit.NewError(exit.CodeInterrupted, "interrupted")
+	}
+	span.RecordError(err)
+	span.End()
+	
+	finishTracing(tracer)
+	finishTelemetry(tracer)
+	finishCapture()
+	finishSummary(tracer)
+	return err
+}
+
+// watchInterrupt restores the default signal handling after the first
+// interrupt, so a second Ctrl-C kills hermes at once, and exits if the
+// command does not return within interruptGrace
+func watchInterrupt(ctx context.Context, stop context.CancelFunc, done <-chan struct{}) {
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+	stop()
+	select {
+	case <-done:
+	case <-time.After(interruptGrace):
+		fmt.Fprintln(os.Stderr)
+		os.Exit(exit.CodeInterrupted)
+	}
+}
+
+// finishTracing prints and exports the collected spans when tracing is enabled
+func finishTracing(tracer *trace.Tracer) {
+	if appCtx == nil {
+		return // Config was never loaded (e.g., --help)
+	}
+	
+	cfg, errOut := appCtx.Config.Tracing, rootCmd.ErrOrStderr()
+	if cfg.Enabled {
+		fmt.Fprintf(errOut, "\nTrace:\n")
+		tracer.Summary(errOut)
+	}
+	if cfg.Endpoint != "" && !appCtx.Config.NetworkEnabled() && !ai.IsLocalEndpoint(cfg.Endpoint) {
+		fmt.Fprintf(errOut, "warning: network is off, not exporting spans to %s\n", cfg.Endpoint)
+	} else if cfg.Endpoint != "" {
+		if err := tracer.Export(context.Background(), cfg.Endpoint); err != nil {
+			fmt.Fprintf(errOut, "warning: %v\n", err)
+		}
+	}
+}
+
+// transcript collects the provider calls of this invocation for --capture
+var transcript *ai.Transcript
+
+// finishCapture saves the provider transcript requested with --capture. A
+// run that never called a provider saves an empty transcript, which tells
+// the reader the answer came from offline sources.
+func finishCapture() {
+	if appCtx == nil || appCtx.Config.Capture == "" {
+		return
+	}
+	if transcript == nil {
+		transcript = &ai.Transcript{}
+	}
+	transcript.Version = rootCmd.Version
+	transcript.Command = ranCommand
+	out := consoleFor(rootCmd)
+	if err := transcript.Save(appCtx.Config.Capture); err != nil {
+		fmt.Fprintf(out.Err, "warning: %v\n", err)
+		return
+	}
+	out.Infof("└─ Saved the provider transcript (secrets masked) to %s\n", appCtx.Config.Capture)
+}
+
+// finishTelemetry updates the opt-in usage counters and sends them when due.
+// Telemetry never interrupts the user: failures are only shown with --debug.
+func finishTelemetry(tracer *trace.Tracer) {
+	if appCtx == nil || ranCommand == "" || appCtx.Config.Telemetry.Mode == telemetry.ModeOff {
+		return
+	}
+	cfg := appCtx.Config.Telemetry
+
+	store, err := telemetry.Load(telemetry.DefaultPath())
+	if err != nil {
+		if appCtx.Config.Debug {
+			stdio.Debugf("telemetry: %v\n", err)
+		}
+		return
+	}
+	store.RecordCommand(ranCommand)
+	for _, span := range []string{"ai.generate", "ai.explain"} {
+		if latency, failed, ok := tracer.Timing(span); ok {
+			store.RecordCall(providerName(&appCtx.Config), latency, failed)
+		}
+	}
+
+	if cfg.Mode == telemetry.ModeOn && cfg.Endpoint != "" && appCtx.Config.NetworkEnabled() && store.Due(time.Now()) {
+		if err := store.Send(context.Background(), cfg.Endpoint, rootCmd.Version); err != nil && appCtx.Config.Debug {
+			stdio.Debugf("telemetry: %v\n", err)
+		}
+	}
+	if err := store.Save(); err != nil && appCtx.Config.Debug {
+		stdio.Debugf("telemetry: %v\n", err)
+	}
 }
 
 func loadConfig(cmd *cobra.Command) error {
-	// Initialize app context
-	appCtx = &AppContext{
-		Config: config.Default(),
+	cfg, err := readConfig(cmd)
+	if appCtx != nil {
+		// Keep injected factories
+		appCtx.Config = cfg
+		return err
 	}
+	appCtx = &AppContext{Config: cfg}
+	return err
+}
+
+// systemConfigPath is the machine-wide config file, where fleet management
+// can set provider endpoints, safety policy and other defaults for every
+// user. User config, environment variables and flags override it.
+var systemConfigPath = "/etc/hermes/config.toml"
 
-	// 1. Load config file (lowest priority)
+// configPath returns the path of the config file, or "" when the user
+// config directory is unknown
+func configPath() string {
 	userConfigDir, err := os.UserConfigDir()
-	if err == nil {
-		configPath := filepath.Join(userConfigDir, "hermes", "config.toml")
-		if err := config.K.Load(file.Provider(configPath), toml.Parser()); err != nil {
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(userConfigDir, "hermes", "config.toml")
+}
+
+// readConfig builds and validates the configuration from the config file,
+// environment variables and flags. The global koanf instance is only
+// replaced once the result is valid, so a rejected reload leaves it alone.
+func readConfig(cmd *cobra.Command) (config.Config, error) {
+	cfg := config.Default()
+	k := koanf.New(".")
+
+	// 1. Load the machine-wide config file, then the user's on top of it
+	// (lowest priority); tables merge key by key
+	for _, path := range []string{systemConfigPath, configPath()} {
+		if path == "" {
+			continue
+		}
+		if err := k.Load(file.Provider(path), toml.Parser()); err != nil {
 			// It's okay if the file doesn't exist
 			if !os.IsNotExist(err) {
 				fmt.Fprintf(os.Stderr, "warning: failed to load config file: %v\n", err)
@@ -91,31 +296,170 @@ func loadConfig(cmd *cobra.Command) error {
 	// 2. Load environment variables (higher priority) 
 	// Check for GEMINI_API_KEY and map it to gemini_api_key
 	if geminiKey := os.Getenv("GEMINI_API_KEY"); geminiKey != "" {
-		config.K.Set("gemini_api_key", geminiKey)
+		k.Set("gemini_api_key", geminiKey)
+	}
+
+	// Automation can opt into strict mode without touching flags
+	if os.Getenv("HERMES_NON_INTERACTIVE") == "1" {
+		k.Set("non_interactive.enabled", true)
+	}
+
+	// Standard OpenTelemetry variable for the OTLP collector endpoint
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		k.Set("tracing.endpoint", endpoint)
 	}
 
 	// 3. Load CLI flags (highest priority) by manually mapping them.
 	// This is explicit and avoids confusion from automatic providers when
 	// flag names (kebab-case) differ from config keys (snake_case).
 	if flagValue, _ := cmd.Flags().GetString("gemini-api-key"); flagValue != "" {
-		config.K.Set("gemini_api_key", flagValue)
+		k.Set("gemini_api_key", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetString("provider"); flagValue != "" {
+		k.Set("provider", flagValue)
+		k.Delete("race") // One provider asked for by name is not raced
+	}
+	if flagValue, _ := cmd.Flags().GetStringSlice("race"); len(flagValue) > 0 {
+		k.Set("race", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetString("capture"); flagValue != "" {
+		k.Set("capture", flagValue)
 	}
 	if flagValue, _ := cmd.Flags().GetString("mock-response"); flagValue != "" {
-		config.K.Set("mock_response", flagValue)
+		k.Set("mock_response", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetString("mock-scenario"); flagValue != "" {
+		k.Set("mock_scenario", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetString("mock-latency"); flagValue != "" {
+		k.Set("mock_latency", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetString("mock-fault"); flagValue != "" {
+		k.Set("mock_fault", flagValue)
 	}
 	if flagValue, _ := cmd.Flags().GetInt("mock-exit-code"); flagValue != 0 {
-		config.K.Set("mock_exit_code", flagValue)
+		k.Set("mock_exit_code", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetString("target"); flagValue != "" {
+		k.Set("target", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("posix"); flagValue {
+		k.Set("posix", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("override-risk-profile"); flagValue {
+		k.Set("risk_override", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("no-lint"); flagValue {
+		k.Set("lint", false)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("history"); flagValue {
+		k.Set("history", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetString("plan"); flagValue != "" {
+		k.Set("plan", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("dir-context"); flagValue {
+		k.Set("dir_context", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("commented"); flagValue {
+		k.Set("commented", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("multi-line"); flagValue {
+		k.Set("multi_line", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetString("preset"); flagValue != "" {
+		k.Set("preset", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("tool-versions"); flagValue {
+		k.Set("tool_versions", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("non-interactive"); flagValue {
+		k.Set("non_interactive.enabled", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("trace"); flagValue {
+		k.Set("tracing.enabled", flagValue)
+	}
+	if cmd.Flags().Changed("candidates") {
+		flagValue, _ := cmd.Flags().GetInt("candidates")
+		k.Set("candidates", flagValue)
+	}
+	if cmd.Flags().Changed("temperature") {
+		flagValue, _ := cmd.Flags().GetFloat64("temperature")
+		k.Set("generation.temperature", flagValue)
+	}
+	if cmd.Flags().Changed("top-p") {
+		flagValue, _ := cmd.Flags().GetFloat64("top-p")
+		k.Set("generation.top_p", flagValue)
+	}
+	if cmd.Flags().Changed("seed") {
+		flagValue, _ := cmd.Flags().GetInt("seed")
+		k.Set("generation.seed", flagValue)
+	}
+	if cmd.Flags().Changed("thinking-budget") {
+		flagValue, _ := cmd.Flags().GetInt("thinking-budget")
+		k.Set("generation.thinking_budget", flagValue)
+	}
+	if flagValue, _ := cmd.Flags().GetBool("no-thinking"); flagValue {
+		k.Set("generation.thinking_budget", 0)
 	}
 	if flagValue, _ := cmd.Flags().GetBool("debug"); flagValue {
-		config.K.Set("debug", flagValue)
+		k.Set("debug", flagValue)
 	}
 
 	// 4. Unmarshal all configuration into the Config struct
-	if err := config.K.Unmarshal("", &appCtx.Config); err != nil {
-		return fmt.Errorf("failed to unmarshal config: %w", err)
+	if err := k.Unmarshal("", &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if network := cfg.Network; network != "on" && network != "off" {
+		return cfg, exit.NewError(exit.CodeConfig, "invalid network setting: %s (supported: on, off)", network)
+	}
+	if provider := cfg.Provider; provider != "" && !slices.Contains(ai.Providers, provider) {
+		return cfg, exit.NewError(exit.CodeConfig, "invalid provider: %s (supported: %s)", provider, strings.Join(ai.Providers, ", "))
+	}
+	if err := validateRace(cfg.Race); err != nil {
+		return cfg, err
+	}
+	if err := validateBudgets(cfg.Budget); err != nil {
+		return cfg, err
+	}
+	if err := validateCache(cfg.Cache); err != nil {
+		return cfg, err
+	}
+	if _, ok := ai.Presets[cfg.Preset]; cfg.Preset != "" && !ok {
+		return cfg, exit.NewError(exit.CodeConfig, "invalid preset: %s (supported: %s)", cfg.Preset, strings.Join(ai.PresetNames(), ", "))
+	}
+	switch cfg.ExperienceLevel {
+	case ai.LevelBeginner, ai.LevelIntermediate, ai.LevelExpert:
+	default:
+		return cfg, exit.NewError(exit.CodeConfig, "invalid experience_level: %s (supported: beginner, intermediate, expert)", cfg.ExperienceLevel)
+	}
+	if !safety.Policy(cfg.SafetyPolicy).Valid() {
+		return cfg, exit.NewError(exit.CodeConfig, "invalid safety_policy: %s (supported: strictest-wins, pattern-only, ai-only, ai-overrides)", cfg.SafetyPolicy)
+	}
+	if !safety.Profile(cfg.RiskProfile).Valid() {
+		return cfg, exit.NewError(exit.CodeConfig, "invalid risk_profile: %s (supported: workstation, server, production)", cfg.RiskProfile)
+	}
+	if err := validateFreeze(cfg.Freeze); err != nil {
+		return cfg, err
+	}
+	if err := validateContextProviders(cfg.ContextProviders); err != nil {
+		return cfg, err
+	}
+	if err := validateExitCodes(cfg.ExitCodes); err != nil {
+		return cfg, err
+	}
+	if err := validateAttentionExitCode(cfg.NonInteractive.AttentionExitCode, cfg.ExitCodes); err != nil {
+		return cfg, err
+	}
+	switch cfg.Telemetry.Mode {
+	case telemetry.ModeOff, telemetry.ModeLocal, telemetry.ModeOn:
+	default:
+		return cfg, exit.NewError(exit.CodeConfig, "invalid telemetry mode: %s (supported: off, local, on)", cfg.Telemetry.Mode)
 	}
 
-	return nil
+	config.K = k
+	return cfg, nil
 }
 
 func init() {
@@ -125,7 +469,22 @@ func init() {
 
 	// Add global flags
 	rootCmd.PersistentFlags().String("gemini-api-key", "", "Gemini API key for AI command generation and explanation")
+	rootCmd.PersistentFlags().String("provider", "", "AI provider to use: gemini, ollama or mock (default: inferred from the config)")
+	rootCmd.PersistentFlags().StringSlice("race", nil, "Send each request to these providers at once and use the first valid answer (e.g. gemini,ollama)")
+	rootCmd.MarkFlagsMutuallyExclusive("provider", "race")
 	rootCmd.PersistentFlags().Bool("debug", false, "Enable debug output")
 	rootCmd.PersistentFlags().String("mock-response", "", "Mock AI response for testing (bypasses API call)")
+	rootCmd.PersistentFlags().String("mock-scenario", "", "Mock AI scenario file (JSON or TOML) mapping queries to responses, errors and latencies")
+	rootCmd.PersistentFlags().String("mock-latency", "", "Artificial latency for mock AI calls (e.g., 2s)")
+	rootCmd.PersistentFlags().String("mock-fault", "", "Inject a mock AI failure: timeout, rate_limit, server_error or malformed")
 	rootCmd.PersistentFlags().Int("mock-exit-code", 0, "Mock exit code for testing (0=safe, 10=attention)")
+	rootCmd.PersistentFlags().Bool("non-interactive", false, "Strict mode for automation: no tips or prompts, stable stdout, configurable Attention exit code")
+	rootCmd.PersistentFlags().String("capture", "", "Save the exact prompts sent and raw responses received to this JSON file (secrets masked), for bug reports")
+	rootCmd.PersistentFlags().Bool("trace", false, "Print a timing breakdown of the pipeline to stderr")
+	rootCmd.PersistentFlags().Float64("temperature", 0, "Sampling temperature (0 = most deterministic, up to 2)")
+	rootCmd.PersistentFlags().Float64("top-p", 0, "Nucleus sampling probability mass (0 to 1)")
+	rootCmd.PersistentFlags().Int("seed", 0, "Sampling seed for reproducible output (where the model supports it)")
+	rootCmd.PersistentFlags().Int("thinking-budget", 0, "Tokens Gemini may spend thinking before it answers (0 = off, -1 = dynamic)")
+	rootCmd.PersistentFlags().Bool("no-thinking", false, "Turn Gemini thinking off for faster answers (same as --thinking-budget 0)")
+	rootCmd.Flags().Bool("editor-mode", false, "Serve the JSON-over-stdio protocol for editor plugins")
 }
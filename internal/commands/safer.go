@@ -0,0 +1,77 @@
+// Package commands - safer alternatives, undo hints and impact estimates
+// for risky commands
+package commands
+
+import (
+	"context"
+	"strconv"
+
+	"hermes/internal/impact"
+	"hermes/internal/safety"
+)
+
+// saferAlternatives returns safer variants of an Attention-level POSIX
+// command, or nil when the command is safe or has none
+func saferAlternatives(command string, result safety.Result, target string) []safety.Alternative {
+	if result.Level < safety.Attention || target == safety.TargetCmd {
+		return nil
+	}
+	return safety.SaferAlternatives(command)
+}
+
+// undoHint returns how to reverse or recover from an Attention-level
+// command: the model's suggestion when it has one, else the built-in hint
+func undoHint(command string, result safety.Result, suggested string, target string) string {
+	if result.Level < safety.Attention || target == safety.TargetCmd {
+		return ""
+	}
+	if suggested != "" {
+		return suggested
+	}
+	return safety.Undo(command)
+}
+
+// impactEstimates describes how many files a POSIX command would delete or
+// change (rm, find -delete, rsync --delete, globs), scanned read-only
+func impactEstimates(ctx context.Context, command string, target string) []string {
+	if target == safety.TargetCmd {
+		return nil
+	}
+	var estimates []string
+	for _, estimate := range impact.Scan(ctx, command) {
+		estimates = append(estimates, estimate.String())
+	}
+	return estimates
+}
+
+// chooseAlternative lists the generated command and its safer variants on
+// stderr and asks which one goes into the buffer. The chosen variant gets
+// its own safety verdict; without a prompt the generated command is kept.
+func chooseAlternative(ctx context.Context, out console, command string, result safety.Result, alternatives []safety.Alternative, target string) (string, safety.Result) {
+	if len(alternatives) == 0 || !interactive() {
+		return command, result
+	}
+
+	out.Infof("└─ safer alternatives:\n")
+	out.Infof("   1) %s  (as generated)\n", command)
+	for i, alternative := range alternatives {
+		out.Infof("   %d) %s  (%s)\n", i+2, alternative.Command, alternative.Reason)
+	}
+	answer := ask("Put which command in the buffer?", "1")
+	choice, err := strconv.Atoi(answer)
+	if err != nil || choice < 1 || choice > len(alternatives)+1 {
+		out.Infof("└─ unknown choice %q, keeping the generated command\n", answer)
+		return command, result
+	}
+	if choice == 1 {
+		return command, result
+	}
+
+	chosen := alternatives[choice-2].Command
+	verdict, err := appCtx.analyzer(target).AnalyzeCommand(ctx, chosen)
+	if err != nil {
+		// Keep the stricter verdict of the original command
+		return chosen, result
+	}
+	return chosen, verdict
+}
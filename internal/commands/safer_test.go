@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/config"
+	"hermes/internal/safety"
+)
+
+func TestChooseAlternative(t *testing.T) {
+	appCtx = &AppContext{Config: config.Default()}
+	origStdin := stdin
+	t.Cleanup(func() { appCtx, stdin = nil, origStdin })
+
+	attention := safety.Result{Level: safety.Attention, Reason: "force push", Layer: "pattern"}
+	alternatives := []safety.Alternative{
+		{Command: "git push --force-with-lease origin main", Reason: "refuses to overwrite commits pushed by someone else"},
+	}
+
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"\n", "git push --force origin main"},
+		{"2\n", "git push --force-with-lease origin main"},
+		{"9\n", "git push --force origin main"},
+	}
+	for _, tt := range tests {
+		stdin = bufio.NewReader(strings.NewReader(tt.answer))
+		got, _ := chooseAlternative(context.Background(), stdio, "git push --force origin main", attention, alternatives, safety.TargetPosix)
+		if got != tt.want {
+			t.Errorf("answer %q: chooseAlternative() = %q, want %q", tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestChooseAlternativeNonInteractive(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{NonInteractive: config.NonInteractive{Enabled: true}}}
+	t.Cleanup(func() { appCtx = nil })
+
+	attention := safety.Result{Level: safety.Attention}
+	alternatives := safety.SaferAlternatives("rsync -a src/ dest/")
+	got, result := chooseAlternative(context.Background(), stdio, "rsync -a src/ dest/", attention, alternatives, safety.TargetPosix)
+	if got != "rsync -a src/ dest/" || result != attention {
+		t.Errorf("chooseAlternative() = %q, %v; want the generated command unchanged", got, result)
+	}
+}
@@ -0,0 +1,309 @@
+// Package commands - safety subcommands
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/exit"
+	"hermes/internal/history"
+	"hermes/internal/safety"
+)
+
+// safetyCmd groups the safety analyzer maintenance subcommands
+var safetyCmd = &cobra.Command{
+	Use:   "safety",
+	Short: "Evaluate the local safety analyzer",
+	Long: `Evaluate the local safety analyzer's pattern rules, for example before
+and after changing them.
+
+Teams can add their own rules in rule packs: TOML files in
+/etc/hermes/rules/ (machine-wide) or ~/.config/hermes/rules/, which every
+safety check uses. Each rule can carry tests for 'hermes safety test':
+
+  [[rule]]
+  name = "terraform-destroy"
+  pattern = '\bterraform\s+destroy\b'
+  level = "attention"                  # or "safe"; attention is the default
+  reason = "Destroys the managed infrastructure"
+  docs = "https://developer.hashicorp.com/terraform/cli/commands/destroy"  # optional
+  target = "posix"                     # or "cmd"; posix is the default
+
+  [[rule.test]]
+  command = "terraform destroy -auto-approve"
+  expect = "attention"
+
+  [[rule.test]]
+  command = "terraform plan"
+  expect = "safe"
+
+Attention rules apply after the built-in attention patterns, and safe
+rules cannot override either.
+
+To check a single command, use 'hermes check'.`,
+}
+
+// safetyBenchCmd runs the analyzer over a corpus of commands
+var safetyBenchCmd = &cobra.Command{
+	Use:   "bench --corpus <file>",
+	Short: "Run the safety analyzer over a corpus of commands",
+	Long: `Run the local safety analysis over a corpus of real commands and report
+how the verdicts are distributed, how often each pattern rule matched,
+false-positive candidates and how long the analysis took.
+
+The corpus is a zsh, bash or fish history file, or a plain file with one
+command per line. Rule hits count every pattern a command matches, not only
+the one that decided, so overlapping rules and rules that never match show
+up. False-positive candidates are commands flagged by an attention pattern
+that also match a known-safe pattern (say, grep sudo /var/log/auth.log).
+
+Examples:
+  hermes safety bench --corpus ~/.zsh_history
+  hermes safety bench --corpus commands.txt --target cmd
+  hermes safety bench --corpus commands.txt --candidates 100`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		corpus, _ := cmd.Flags().GetString("corpus")
+		limit, _ := cmd.Flags().GetInt("candidates")
+
+		commands, err := history.ReadFile(corpus)
+		if err != nil {
+			return exit.NewError(exit.CodeConfig, "%v", err)
+		}
+		if len(commands) == 0 {
+			return exit.NewError(exit.CodeConfig, "no commands in %s", corpus)
+		}
+
+		report, err := patternAnalyzer(appCtx.Config.Target).Bench(cmd.Context(), commands)
+		if err != nil {
+			return exit.NewError(exit.CodeError, "Safety analysis failed: %v", err)
+		}
+		printBenchReport(cmd.OutOrStdout(), report, limit)
+		return nil
+	},
+}
+
+// safetyTestCmd runs the tests of rule packs
+var safetyTestCmd = &cobra.Command{
+	Use:   "test [pack.toml...]",
+	Short: "Run the tests of safety rule packs",
+	Long: `Run the [[rule.test]] cases of rule packs and report each one that does
+not get the expected verdict. Without arguments the installed packs are
+tested.
+
+Each pack is tested on its own against the built-in patterns, so a pack
+can be tested before it is installed. The verdict is the one 'hermes check'
+gives, including the built-in patterns and the exfiltration guard.
+
+Examples:
+  hermes safety test
+  hermes safety test rules/terraform.toml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths := args
+		if len(paths) == 0 {
+			paths = rulePackPaths()
+			if len(paths) == 0 {
+				return exit.NewError(exit.CodeConfig, "no rule packs in %s", strings.Join(ruleDirs(), " or "))
+			}
+		}
+
+		out := consoleFor(cmd)
+		passed, total := 0, 0
+		for _, path := range paths {
+			pack, err := safety.LoadRulePack(path)
+			if err != nil {
+				return exit.NewError(exit.CodeConfig, "%v", err)
+			}
+			packPassed, packTotal, err := testRulePack(cmd.Context(), out.Out, pack)
+			if err != nil {
+				return exit.NewError(exit.CodeError, "Safety analysis failed: %v", err)
+			}
+			out.Resultf("%s: %d/%d passed\n\n", path, packPassed, packTotal)
+			passed += packPassed
+			total += packTotal
+		}
+
+		if passed < total {
+			return exit.NewError(exit.CodeError, "%d of %d rule tests failed", total-passed, total)
+		}
+		return nil
+	},
+}
+
+// testRulePack runs a pack's tests against the built-in patterns and the
+// pack's own rules, printing failures and rules without tests. It returns
+// how many tests passed out of how many ran.
+func testRulePack(ctx context.Context, w io.Writer, pack *safety.RulePack) (passed, total int, err error) {
+	analyzers := map[string]safety.CommandAnalyzer{}
+	for i := range pack.Rules {
+		rule := &pack.Rules[i]
+		target := rule.Target
+		if target == "" {
+			target = safety.TargetPosix
+		}
+		if len(rule.Tests) == 0 {
+			fmt.Fprintf(w, "NOTE  %s has no tests\n", rule.Name)
+		}
+
+		analyzer, ok := analyzers[target]
+		if !ok {
+			analyzer = safety.NewAnalyzerFor(target).WithRules(rulesFor(pack.Rules, target))
+			analyzers[target] = analyzer
+		}
+		for _, test := range rule.Tests {
+			result, err := assessRisk(ctx, analyzer, test.Command, target)
+			if err != nil {
+				return passed, total, err
+			}
+			total++
+			if result.Level == test.Expected() {
+				passed++
+				continue
+			}
+			fmt.Fprintf(w, "FAIL  %s: %s → %s, expected %s\n", rule.Name, test.Command, result.Level, test.Expected())
+			fmt.Fprintf(w, "      └─ %s: %s\n", result.Layer, result.Reason)
+		}
+	}
+	return passed, total, nil
+}
+
+// ruleDirs returns the rule pack directories: machine-wide, then the user's
+func ruleDirs() []string {
+	dirs := []string{filepath.Join(filepath.Dir(systemConfigPath), "rules")}
+	if path := configPath(); path != "" {
+		dirs = append(dirs, filepath.Join(filepath.Dir(path), "rules"))
+	}
+	return dirs
+}
+
+// rulePackPaths returns the installed rule packs
+func rulePackPaths() []string {
+	var paths []string
+	for _, dir := range ruleDirs() {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.toml"))
+		paths = append(paths, matches...)
+	}
+	return paths
+}
+
+// rulesFor returns the rules meant for the target shell
+func rulesFor(rules []safety.Rule, target string) []safety.Rule {
+	var matching []safety.Rule
+	for i := range rules {
+		if rules[i].AppliesTo(target) {
+			matching = append(matching, rules[i])
+		}
+	}
+	return matching
+}
+
+// patternAnalyzer creates the pattern analyzer for a target shell with the
+// risk profile's patterns and the installed rule packs. A pack that does not load is reported on stderr
+// and left out, like a config file that does not parse.
+func patternAnalyzer(target string) *safety.Analyzer {
+	analyzer := safety.NewAnalyzerFor(target).WithProfile(riskProfile())
+	for _, path := range rulePackPaths() {
+		pack, err := safety.LoadRulePack(path)
+		if err != nil {
+			fmt.Fprintf(stdio.Err, "warning: skipping rule pack: %v\n", err)
+			continue
+		}
+		analyzer.WithRules(rulesFor(pack.Rules, target))
+	}
+	return analyzer
+}
+
+// expandableVariables are the environment variables safe to substitute
+// into a command before analysis: they hold paths and program names, not
+// secrets
+var expandableVariables = []string{"HOME", "PWD", "OLDPWD", "USER", "LOGNAME", "SHELL", "TMPDIR", "EDITOR", "VISUAL", "PAGER"}
+
+// shellExpansion returns the aliases the shell integration passed in
+// HERMES_ALIASES and the set expandable variables
+func shellExpansion() safety.Expansion {
+	expansion := safety.Expansion{
+		Aliases:   safety.ParseAliases(os.Getenv("HERMES_ALIASES")),
+		Variables: map[string]string{},
+	}
+	for _, name := range expandableVariables {
+		if value, ok := os.LookupEnv(name); ok {
+			expansion.Variables[name] = value
+		}
+	}
+	return expansion
+}
+
+// printBenchReport prints a bench report, listing at most limit
+// false-positive candidates
+func printBenchReport(w io.Writer, report safety.BenchReport, limit int) {
+	fmt.Fprintf(w, "%d commands analyzed in %s (%s per command)\n", report.Commands,
+		report.Elapsed.Round(time.Microsecond), (report.Elapsed / time.Duration(report.Commands)).Round(time.Nanosecond))
+
+	fmt.Fprintf(w, "\nVerdicts:\n")
+	for _, level := range []safety.SafetyLevel{safety.Safe, safety.Attention} {
+		fmt.Fprintf(w, "  %-10s %7d  %5.1f%%\n", level, report.Levels[level], percent(report.Levels[level], report.Commands))
+	}
+
+	fmt.Fprintf(w, "\nDeciding layers:\n")
+	layers := make([]string, 0, len(report.Layers))
+	for layer := range report.Layers {
+		layers = append(layers, layer)
+	}
+	sort.Slice(layers, func(i, j int) bool {
+		if report.Layers[layers[i]] != report.Layers[layers[j]] {
+			return report.Layers[layers[i]] > report.Layers[layers[j]]
+		}
+		return layers[i] < layers[j]
+	})
+	for _, layer := range layers {
+		fmt.Fprintf(w, "  %-20s %7d  %5.1f%%\n", layer, report.Layers[layer], percent(report.Layers[layer], report.Commands))
+	}
+
+	fmt.Fprintf(w, "\nRule hits:\n")
+	for _, rule := range report.Rules {
+		if rule.Name != "" {
+			fmt.Fprintf(w, "  %7d  %-9s %s: %s\n", rule.Hits, rule.Kind, rule.Name, rule.Pattern)
+			continue
+		}
+		fmt.Fprintf(w, "  %7d  %-9s %s\n", rule.Hits, rule.Kind, rule.Pattern)
+	}
+
+	fmt.Fprintf(w, "\nFalse-positive candidates: %d\n", len(report.FalsePositives))
+	for i, candidate := range report.FalsePositives {
+		if i == limit {
+			fmt.Fprintf(w, "  ... and %d more (raise --candidates to list them)\n", len(report.FalsePositives)-limit)
+			break
+		}
+		fmt.Fprintf(w, "  %s\n      └─ flagged by %s, safe by %s\n", candidate.Command, candidate.Rule, candidate.Safe)
+	}
+
+	fmt.Fprintf(w, "\nSlowest commands:\n")
+	for _, timing := range report.Slowest {
+		fmt.Fprintf(w, "  %10s  %s\n", timing.Duration.Round(time.Nanosecond), timing.Command)
+	}
+}
+
+// percent returns n as a percentage of total
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+func init() {
+	safetyBenchCmd.Flags().String("corpus", "", "History file or file with one command per line")
+	safetyBenchCmd.Flags().String("target", "", "Pattern set to run: posix (default) or cmd (Windows cmd.exe batch)")
+	safetyBenchCmd.Flags().Int("candidates", 20, "List at most this many false-positive candidates")
+	_ = safetyBenchCmd.MarkFlagRequired("corpus")
+	safetyCmd.AddCommand(safetyBenchCmd)
+	safetyCmd.AddCommand(safetyTestCmd)
+	rootCmd.AddCommand(safetyCmd)
+}
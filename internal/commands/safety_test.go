@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hermes/internal/config"
+	"hermes/internal/safety"
+)
+
+func TestSafetyBench(t *testing.T) {
+	corpus := filepath.Join(t.TempDir(), "history")
+	history := ": 1700000000:0;ls -la\n: 1700000001:0;sudo rm -rf /tmp/x\ngrep sudo /var/log/auth.log\necho 'mount it'\n"
+	if err := os.WriteFile(corpus, []byte(history), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	appCtx = &AppContext{Config: config.Config{}}
+	t.Cleanup(func() { appCtx = nil })
+	safetyBenchCmd.Flags().Set("corpus", corpus)
+	safetyBenchCmd.Flags().Set("candidates", "1")
+	t.Cleanup(func() {
+		safetyBenchCmd.Flags().Set("corpus", "")
+		safetyBenchCmd.Flags().Set("candidates", "20")
+	})
+
+	stdout, _, err := runWithConsole(t, safetyBenchCmd)
+	if err != nil {
+		t.Fatalf("safety bench error = %v", err)
+	}
+	for _, want := range []string{
+		"4 commands analyzed",
+		"  safe             1   25.0%",
+		"  attention        3   75.0%",
+		"        2  attention \\bsudo\\b",
+		"False-positive candidates: 2",
+		"  grep sudo /var/log/auth.log\n      └─ flagged by \\bsudo\\b, safe by ^grep\\b",
+		"... and 1 more",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("safety bench output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestSafetyTest(t *testing.T) {
+	pack := filepath.Join(t.TempDir(), "terraform.toml")
+	rules := `
+[[rule]]
+name = "terraform-destroy"
+pattern = '\bterraform\s+destroy\b'
+
+[[rule.test]]
+command = "terraform destroy"
+expect = "attention"
+
+[[rule.test]]
+command = "terraform apply -destroy"
+expect = "attention"
+
+[[rule]]
+name = "terraform-plan"
+pattern = '^terraform\s+plan\b'
+level = "safe"
+`
+	if err := os.WriteFile(pack, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, err := runWithConsole(t, safetyTestCmd, pack)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 rule tests failed") {
+		t.Errorf("safety test error = %v, want 1 of 2 failed", err)
+	}
+	for _, want := range []string{
+		"NOTE  terraform-plan has no tests",
+		"FAIL  terraform-destroy: terraform apply -destroy → safe, expected attention",
+		pack + ": 1/2 passed",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("safety test output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestPatternAnalyzerRulePacks(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	orig := systemConfigPath
+	systemConfigPath = filepath.Join(dir, "etc", "config.toml")
+	t.Cleanup(func() { systemConfigPath = orig })
+
+	if err := os.MkdirAll(filepath.Join(dir, "hermes", "rules"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rules := "[[rule]]\nname = \"kubectl-delete\"\npattern = '\\bkubectl\\s+delete\\b'\n"
+	if err := os.WriteFile(filepath.Join(dir, "hermes", "rules", "k8s.toml"), []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := patternAnalyzer(safety.TargetPosix).AnalyzeCommand(context.Background(), "kubectl delete pod web")
+	if err != nil || result.Level != safety.Attention || result.Layer != "custom-rules" {
+		t.Errorf("AnalyzeCommand() = %+v, %v, want attention from the installed rule", result, err)
+	}
+	if result, _ := patternAnalyzer(safety.TargetCmd).AnalyzeCommand(context.Background(), "kubectl delete pod web"); result.Layer == "custom-rules" {
+		t.Error("a posix rule applied to the cmd analyzer")
+	}
+}
@@ -0,0 +1,185 @@
+// Package commands - first-run setup and the shell integration tip
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/ai"
+	"hermes/internal/exit"
+	"hermes/internal/tips"
+)
+
+// defaultOllamaModel is what setup suggests for local generation
+const defaultOllamaModel = "qwen2.5-coder:7b"
+
+// shellIntegration says where a shell loads the integration from
+type shellIntegration struct {
+	RC   string // Startup file, relative to the home directory
+	Line string // Line that loads the integration
+}
+
+// shellIntegrations holds the startup file and loader line by shell
+var shellIntegrations = map[string]shellIntegration{
+	"zsh":  {RC: ".zshrc", Line: `eval "$(hermes init zsh)"`},
+	"bash": {RC: ".bashrc", Line: `eval "$(hermes init bash)"`},
+	"fish": {RC: filepath.Join(".config", "fish", "config.fish"), Line: "hermes init fish | source"},
+}
+
+// userShell returns the name of the user's login shell and its integration,
+// if hermes supports it
+func userShell() (string, shellIntegration, bool) {
+	shellPath := os.Getenv("SHELL")
+	if shellPath == "" {
+		return "", shellIntegration{}, false // No shell detected, probably running in a script
+	}
+	name := filepath.Base(shellPath)
+	integration, ok := shellIntegrations[name]
+	return name, integration, ok
+}
+
+// checkShellIntegration suggests enabling the shell integration when hermes
+// runs without it, at most once a week
+func checkShellIntegration(out console) {
+	// Automation never wants tips
+	if !interactive() {
+		return
+	}
+
+	// The shell function sets HERMES_SHELL_INTEGRATION=1 when calling hermes
+	if os.Getenv("HERMES_SHELL_INTEGRATION") == "1" || os.Getenv("HERMES_SUPPRESS_INTEGRATION_TIP") == "1" {
+		return
+	}
+	_, integration, ok := userShell()
+	if !ok || !tips.New(tips.DefaultPath()).Take(tips.ShellIntegration) {
+		return
+	}
+
+	out.Infof("\n   TIP: Enable shell integration for the best experience!\n")
+	out.Infof("   Run: echo '%s' >> ~/%s && source ~/%s\n", integration.Line, integration.RC, integration.RC)
+	out.Infof("   This allows hermes to put commands directly in your shell buffer.\n")
+	out.Infof("   This tip shows at most once a week; to turn it off: export HERMES_SUPPRESS_INTEGRATION_TIP=1\n\n")
+}
+
+// firstRun reports whether to offer setup: an interactive terminal, no
+// user or machine-wide config file yet, and no offer in the past week
+func firstRun() bool {
+	if !interactive() || os.Getenv("HERMES_SHELL_INTEGRATION") == "1" {
+		return false
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	path := configPath()
+	if path == "" {
+		return false
+	}
+	for _, existing := range []string{path, systemConfigPath} {
+		if _, err := os.Stat(existing); !os.IsNotExist(err) {
+			return false
+		}
+	}
+	return tips.New(tips.DefaultPath()).Take(tips.Setup)
+}
+
+// runSetup walks a new user through choosing a provider, writes the config
+// file and offers to install the shell integration
+func runSetup(cmd *cobra.Command) error {
+	path, out := configPath(), consoleFor(cmd)
+	out.Infof("Welcome to hermes! There is no config file at %s yet.\n", path)
+	if !confirm("Set up hermes now?") {
+		out.Infof("Skipped; 'hermes' offers again in a week, or edit %s yourself.\n\n", path)
+		return cmd.Help()
+	}
+
+	var lines []string
+	provider := ask("Provider: gemini (hosted, needs an API key) or ollama (runs locally)", "gemini")
+	switch provider {
+	case "gemini":
+		lines = append(lines, `provider = "gemini"`)
+		if os.Getenv("GEMINI_API_KEY") != "" {
+			out.Infof("└─ Using GEMINI_API_KEY from the environment\n")
+		} else if key := ask("Gemini API key (create one at https://aistudio.google.com/apikey; empty to add later)", ""); key != "" {
+			lines = append(lines, fmt.Sprintf("gemini_api_key = %q", key))
+		}
+	case "ollama":
+		lines = append(lines, `provider = "ollama"`, "", "[ollama]")
+		lines = append(lines, fmt.Sprintf("model = %q", ask("Ollama model", defaultOllamaModel)))
+		if url := ask("Ollama URL", ai.DefaultOllamaURL); url != ai.DefaultOllamaURL {
+			lines = append(lines, fmt.Sprintf("url = %q", url))
+		}
+	default:
+		return exit.NewError(exit.CodeConfig, "unsupported provider: %s (supported: gemini, ollama)", provider)
+	}
+	if err := writeSetupConfig(path, lines); err != nil {
+		return err
+	}
+	out.Infof("└─ Wrote %s\n", path)
+
+	if name, integration, ok := userShell(); ok {
+		if err := offerIntegration(out, name, integration); err != nil {
+			return err
+		}
+	}
+	out.Infof("\nAll set. Check the provider with 'hermes auth test', then try: hermes gen list files\n")
+	return nil
+}
+
+// writeSetupConfig writes a new config file readable only by the user,
+// since it may hold an API key
+func writeSetupConfig(path string, lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return exit.NewError(exit.CodeConfig, "Failed to create the config directory: %v", err)
+	}
+	content := "# Written by hermes setup; see the README for every setting\n" + strings.Join(lines, "\n") + "\n"
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return exit.NewError(exit.CodeConfig, "Failed to create the config file: %v", err)
+	}
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return exit.NewError(exit.CodeConfig, "Failed to write the config file: %v", err)
+	}
+	return nil
+}
+
+// offerIntegration adds the integration to the shell's startup file after
+// asking, unless it is there already
+func offerIntegration(out console, shell string, integration shellIntegration) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	rc := filepath.Join(home, integration.RC)
+	if data, err := os.ReadFile(rc); err == nil && strings.Contains(string(data), "hermes init "+shell) {
+		out.Infof("└─ Shell integration already loads from %s\n", rc)
+		return nil
+	}
+	if !confirm(fmt.Sprintf("Add the %s integration to %s, so generated commands land in your prompt?", shell, rc)) {
+		out.Infof("└─ To add it later: echo '%s' >> %s\n", integration.Line, rc)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rc), 0o755); err != nil {
+		return exit.NewError(exit.CodeError, "Failed to update %s: %v", rc, err)
+	}
+	file, err := os.OpenFile(rc, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return exit.NewError(exit.CodeError, "Failed to update %s: %v", rc, err)
+	}
+	_, err = fmt.Fprintf(file, "\n# hermes shell integration\n%s\n", integration.Line)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return exit.NewError(exit.CodeError, "Failed to update %s: %v", rc, err)
+	}
+	out.Infof("└─ Added to %s; open a new shell or run: source %s\n", rc, rc)
+	return nil
+}
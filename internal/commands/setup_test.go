@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"hermes/internal/config"
+)
+
+func TestRunSetup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".local", "state"))
+	t.Setenv("SHELL", "/bin/zsh")
+	t.Setenv("GEMINI_API_KEY", "")
+	origStdin := stdin
+	appCtx = &AppContext{Config: config.Default()}
+	t.Cleanup(func() { appCtx, stdin = nil, origStdin })
+
+	// Set up, provider gemini, an API key, add the integration
+	stdin = bufio.NewReader(strings.NewReader("y\n\ntest-key\ny\n"))
+	if err := runSetup(rootCmd); err != nil {
+		t.Fatalf("runSetup() error = %v", err)
+	}
+
+	path := configPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("config file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("config file mode = %v, want 0600 since it holds the API key", info.Mode().Perm())
+	}
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), toml.Parser()); err != nil {
+		t.Fatalf("written config does not parse: %v", err)
+	}
+	if k.String("provider") != "gemini" || k.String("gemini_api_key") != "test-key" {
+		t.Errorf("config = %v, want the chosen provider and key", k.All())
+	}
+
+	rc, err := os.ReadFile(filepath.Join(home, ".zshrc"))
+	if err != nil || !strings.Contains(string(rc), `eval "$(hermes init zsh)"`) {
+		t.Errorf(".zshrc = %q, %v, want the integration line", rc, err)
+	}
+
+	// Setup never overwrites an existing config, and the integration is
+	// only added once
+	if err := writeSetupConfig(path, []string{`provider = "ollama"`}); err == nil {
+		t.Error("writeSetupConfig() overwrote the existing config")
+	}
+	if err := offerIntegration(stdio, "zsh", shellIntegrations["zsh"]); err != nil {
+		t.Fatalf("offerIntegration() error = %v", err)
+	}
+	if again, _ := os.ReadFile(filepath.Join(home, ".zshrc")); string(again) != string(rc) {
+		t.Errorf(".zshrc = %q after a second offer, want it unchanged", again)
+	}
+}
@@ -0,0 +1,99 @@
+// Package commands - machine-readable exit summary for shell integrations
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"hermes/internal/safety"
+	"hermes/internal/trace"
+)
+
+// exitSummary is the JSON line written for shell integrations that want
+// more than the exit code, e.g. to color the prompt by the safety layer
+type exitSummary struct {
+	Level     string `json:"level,omitempty"`    // safe or attention; empty when nothing was assessed
+	Layer     string `json:"layer,omitempty"`    // The analysis layer that decided the level
+	Provider  string `json:"provider,omitempty"` // Empty when no provider was called
+	LatencyMS int64  `json:"latency_ms"`         // Time spent waiting for the provider
+}
+
+// verdict is the safety verdict of this invocation, for the exit summary
+var verdict *safety.Result
+
+// recordVerdict remembers the final safety verdict for the exit summary
+func recordVerdict(result safety.Result) {
+	verdict = &result
+}
+
+// finishSummary writes the exit summary when hermes runs under the shell
+// integration and HERMES_SUMMARY_FD (an open file descriptor, usually 3)
+// or HERMES_SUMMARY_FILE (a file to append to) asks for it
+func finishSummary(tracer *trace.Tracer) {
+	if appCtx == nil || os.Getenv("HERMES_SHELL_INTEGRATION") != "1" {
+		return
+	}
+	w, err := summaryWriter(os.Getenv("HERMES_SUMMARY_FD"), os.Getenv("HERMES_SUMMARY_FILE"))
+	if w == nil {
+		if err != nil && appCtx.Config.Debug {
+			stdio.Debugf("exit summary: %v\n", err)
+		}
+		return
+	}
+	defer w.Close()
+	if err := writeSummary(w, buildSummary(tracer)); err != nil && appCtx.Config.Debug {
+		stdio.Debugf("exit summary: %v\n", err)
+	}
+}
+
+// buildSummary collects the verdict and the provider call of this run
+func buildSummary(tracer *trace.Tracer) exitSummary {
+	var summary exitSummary
+	if verdict != nil {
+		summary.Level = verdict.Level.String()
+		summary.Layer = verdict.Layer
+	}
+	for _, span := range []string{"ai.generate", "ai.explain", "ai.review", "ai.compare"} {
+		if latency, _, ok := tracer.Timing(span); ok {
+			summary.Provider = providerName(&appCtx.Config)
+			summary.LatencyMS += latency.Milliseconds()
+		}
+	}
+	return summary
+}
+
+// writeSummary writes the summary as one JSON line
+func writeSummary(w io.Writer, summary exitSummary) error {
+	data, _ := json.Marshal(summary) // Only plain values, cannot fail
+	_, err := w.Write(append(data, '\n'))
+	return err
+}
+
+// summaryWriter opens the summary destination: the file descriptor if one
+// is given, else the file. A descriptor the shell did not open for hermes
+// (say, one the Go runtime uses internally) is refused.
+func summaryWriter(fd, path string) (io.WriteCloser, error) {
+	if fd != "" {
+		n, err := strconv.Atoi(fd)
+		if err != nil || n < 3 {
+			return nil, fmt.Errorf("HERMES_SUMMARY_FD must be a file descriptor above 2, got %q", fd)
+		}
+		// Check through /dev/fd before wrapping the descriptor: an
+		// os.File closes it when garbage collected
+		info, err := os.Stat(fmt.Sprintf("/dev/fd/%d", n))
+		if err != nil {
+			return nil, fmt.Errorf("file descriptor %d is not open", n)
+		}
+		if mode := info.Mode(); !mode.IsRegular() && mode&(os.ModeNamedPipe|os.ModeCharDevice|os.ModeSocket) == 0 {
+			return nil, fmt.Errorf("file descriptor %d is not a file or pipe", n)
+		}
+		return os.NewFile(uintptr(n), "summary"), nil
+	}
+	if path != "" {
+		return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	}
+	return nil, nil
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hermes/internal/config"
+	"hermes/internal/safety"
+	"hermes/internal/trace"
+)
+
+func TestFinishSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.jsonl")
+	t.Setenv("HERMES_SHELL_INTEGRATION", "1")
+	t.Setenv("HERMES_SUMMARY_FILE", path)
+	appCtx, verdict = &AppContext{Config: config.Config{Provider: "ollama"}}, nil
+	t.Cleanup(func() { appCtx, verdict = nil, nil })
+
+	// Nothing assessed and no provider called
+	tracer := trace.New()
+	finishSummary(tracer)
+
+	_, span := trace.Start(trace.WithTracer(context.Background(), tracer), "ai.generate")
+	span.End()
+	recordVerdict(safety.Result{Level: safety.Attention, Layer: "pattern"})
+	finishSummary(tracer)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"latency_ms":0}` + "\n" + `{"level":"attention","layer":"pattern","provider":"ollama","latency_ms":0}` + "\n"
+	if string(data) != want {
+		t.Errorf("summary lines = %q, want %q", data, want)
+	}
+
+	// Only under the shell integration
+	t.Setenv("HERMES_SHELL_INTEGRATION", "")
+	finishSummary(tracer)
+	if after, _ := os.ReadFile(path); len(after) != len(data) {
+		t.Error("summary written outside the shell integration")
+	}
+}
+
+func TestSummaryWriterRefusesUnusableDescriptors(t *testing.T) {
+	for _, fd := range []string{"1", "x", "987"} {
+		if w, err := summaryWriter(fd, ""); w != nil || err == nil {
+			t.Errorf("summaryWriter(%q) = %v, %v, want an error", fd, w, err)
+		}
+	}
+	if w, err := summaryWriter("", ""); w != nil || err != nil {
+		t.Errorf("summaryWriter() without a destination = %v, %v, want nothing", w, err)
+	}
+}
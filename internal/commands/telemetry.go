@@ -0,0 +1,64 @@
+// Package commands - telemetry subcommand
+package commands
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+	"hermes/internal/exit"
+	"hermes/internal/telemetry"
+)
+
+// telemetryCmd groups the telemetry subcommands
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Inspect the opt-in anonymous usage counters",
+	Long: `Hermes can count how often each subcommand runs and how long provider
+calls take. Query text, generated commands, paths and hostnames are never
+recorded. Telemetry is off unless enabled in ~/.config/hermes/config.toml:
+
+  [telemetry]
+  mode = "local"   # keep counters on this machine only
+  mode = "on"      # ...and send them once a day
+  endpoint = "https://collector.example.com/hermes"
+
+Run 'hermes telemetry show' to see exactly what would be sent.`,
+}
+
+// telemetryShowCmd prints the payload that would be sent
+var telemetryShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show exactly what telemetry would send",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := appCtx.Config.Telemetry
+		store, err := telemetry.Load(telemetry.DefaultPath())
+		if err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+
+		out := consoleFor(cmd)
+		switch {
+		case cfg.Mode == telemetry.ModeOff:
+			out.Infof("└─ Telemetry is off: nothing is recorded or sent. With it enabled, this is the payload:\n")
+		case cfg.Mode == telemetry.ModeLocal:
+			out.Infof("└─ Telemetry is local: counters stay on this machine. This is what \"on\" would send:\n")
+		case cfg.Endpoint == "" || !appCtx.Config.NetworkEnabled():
+			out.Infof("└─ Telemetry is on but has no endpoint or the network is off, so nothing is sent. Payload:\n")
+		default:
+			out.Infof("└─ Telemetry is on: this payload is sent to %s at most once a day:\n", cfg.Endpoint)
+		}
+
+		data, err := json.MarshalIndent(store.Payload(rootCmd.Version), "", "  ")
+		if err != nil {
+			return exit.NewError(exit.CodeError, "%v", err)
+		}
+		out.Resultf("%s\n", data)
+		return nil
+	},
+}
+
+func init() {
+	telemetryCmd.AddCommand(telemetryShowCmd)
+	rootCmd.AddCommand(telemetryCmd)
+}
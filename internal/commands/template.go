@@ -0,0 +1,38 @@
+// Package commands - command templates with fill-in placeholders
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"hermes/internal/ai"
+	"hermes/internal/placeholder"
+	"hermes/internal/safety"
+)
+
+// fillPlaceholders asks for a value for each placeholder the command uses
+// and fills them in. Without a terminal the defaults are used; placeholders
+// without a value stay in the command so it cannot run by accident.
+func fillPlaceholders(command string, placeholders []ai.Placeholder, target string) string {
+	values := map[string]string{}
+	var missing []string
+	for _, p := range placeholders {
+		if _, done := values[p.Name]; done || !placeholder.Present(command, p.Name) {
+			continue
+		}
+		question := p.Name
+		if p.Description != "" {
+			question = fmt.Sprintf("%s (%s)", p.Name, p.Description)
+		}
+		if value := ask("└─ "+question, p.Default); value != "" {
+			values[p.Name] = value
+		} else {
+			missing = append(missing, "{"+p.Name+"}")
+		}
+	}
+
+	if len(missing) > 0 {
+		fmt.Fprintf(stdio.Err, "warning: fill in %s before running the command\n", strings.Join(missing, ", "))
+	}
+	return placeholder.Fill(command, values, target != safety.TargetCmd)
+}
@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+)
+
+func TestFillPlaceholdersNonInteractive(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{NonInteractive: config.NonInteractive{Enabled: true}}}
+	t.Cleanup(func() { appCtx = nil })
+
+	placeholders := []ai.Placeholder{
+		{Name: "archive_name", Default: "backup"},
+		{Name: "directory"},
+		{Name: "unused", Default: "x"},
+	}
+	got := fillPlaceholders("tar -czf {archive_name}.tar.gz {directory}", placeholders, "posix")
+	if want := "tar -czf backup.tar.gz {directory}"; got != want {
+		t.Errorf("fillPlaceholders() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+// Package commands - syntax and quoting checks for generated commands
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"hermes/internal/ai"
+	"hermes/internal/shell"
+)
+
+// quotedLiteral matches text the user quoted in a query
+var quotedLiteral = regexp.MustCompile(`"([^"]+)"|'([^']+)'`)
+
+// verifySyntax checks that the generated command and every plan step parse
+// as shell, and that names the user quoted (files with spaces, quotes or
+// other special characters) stay single words. Constructs the parser does
+// not model leave the command unknown rather than invalid.
+func verifySyntax(response *ai.GenerateResponse, query string) error {
+	commands := []string{response.Command}
+	for _, step := range response.Steps {
+		commands = append(commands, step.Command)
+	}
+	for _, command := range commands {
+		tokens, err := shell.Lex(command)
+		if shell.IsUnsupported(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := shell.Parse(command); err != nil {
+			if shell.IsUnsupported(err) {
+				continue
+			}
+			return err
+		}
+		for _, literal := range queryLiterals(query) {
+			if splitsLiteral(command, tokens, literal) {
+				return fmt.Errorf("%q is not quoted as a single word", literal)
+			}
+		}
+	}
+	return nil
+}
+
+// queryLiterals returns the quoted parts of a query that need quoting in a
+// shell command
+func queryLiterals(query string) []string {
+	var literals []string
+	for _, match := range quotedLiteral.FindAllStringSubmatch(query, -1) {
+		literal := match[1] + match[2]
+		if strings.ContainsAny(literal, " \t'\"$`\\;&|<>()*?[]#~") {
+			literals = append(literals, literal)
+		}
+	}
+	return literals
+}
+
+// splitsLiteral reports whether the command uses a literal verbatim but no
+// single word holds it, i.e. the shell would split or expand it
+func splitsLiteral(command string, tokens []shell.Token, literal string) bool {
+	if !strings.Contains(command, literal) {
+		return false // Not used verbatim; it may be escaped or not used at all
+	}
+	for _, token := range tokens {
+		if token.Kind == shell.Word && strings.Contains(token.Value, literal) {
+			return false
+		}
+	}
+	return true
+}
+
+// retryRequest asks the model to fix a command that failed verification
+func retryRequest(req ai.GenerateRequest, command string, err error) ai.GenerateRequest {
+	note := fmt.Sprintf("Your previous command was not valid shell (%v):\n%s\nReturn a corrected command with proper quoting and escaping.", err, command)
+	if req.Context != "" {
+		note = req.Context + "\n\n" + note
+	}
+	req.Context = note
+	return req
+}
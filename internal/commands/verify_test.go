@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hermes/internal/ai"
+	"hermes/internal/config"
+)
+
+func TestVerifySyntax(t *testing.T) {
+	tests := []struct {
+		command string
+		query   string
+		wantErr bool
+	}{
+		{"ls -la", "list files", false},
+		{`mv "my file.txt" notes.txt`, `rename "my file.txt" to notes.txt`, false},
+		{`mv my\ file.txt notes.txt`, `rename "my file.txt" to notes.txt`, false},
+		{"mv my file.txt notes.txt", `rename "my file.txt" to notes.txt`, true},
+		{"echo 'it's done'", "print it's done", true},
+		{"tar -czf backup.tar.gz src &&", "archive src", true},
+		{`case "$1" in start) echo s;; esac`, "dispatch on the first argument", false},
+		{"diff <(ls a) <(ls b)", "compare directory listings", false},
+		{"cat <<'EOF' > notes.txt\nDon't forget (milk)\nEOF", "write a note", false},
+		{"cat <<-EOF\n\tfirst (a)\n\tEOF\nwc -l notes.txt", "write and count", false},
+		{"echo done >| status.txt", "overwrite the status file", false},
+		{"echo ${x:-(}", "print x or a parenthesis", false},
+		{"echo ${x:-${y:-)}}", "print x, y or a parenthesis", false},
+		{"echo $'it\\'s done'", "print it's done", false},
+		{`case "$1" in a) echo a;& b) echo b;; esac`, "fall through to b", false},
+	}
+	for _, tt := range tests {
+		err := verifySyntax(&ai.GenerateResponse{Command: tt.command}, tt.query)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("verifySyntax(%q, %q) error = %v, wantErr %v", tt.command, tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+// sequenceClient returns its commands in order, one per call
+type sequenceClient struct {
+	commands []string
+	requests []ai.GenerateRequest
+}
+
+func (c *sequenceClient) GenerateCommand(ctx context.Context, req ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	c.requests = append(c.requests, req)
+	command := c.commands[0]
+	if len(c.commands) > 1 {
+		c.commands = c.commands[1:]
+	}
+	return &ai.GenerateResponse{Command: command}, nil
+}
+
+func (c *sequenceClient) ExplainCommand(ctx context.Context, req ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	return &ai.ExplainResponse{}, nil
+}
+
+func (c *sequenceClient) Close() error { return nil }
+
+func TestRunGenerationRegeneratesInvalidSyntax(t *testing.T) {
+	appCtx = &AppContext{Config: config.Config{}}
+	t.Cleanup(func() { appCtx = nil })
+
+	client := &sequenceClient{commands: []string{"echo 'unterminated", "echo 'fixed'"}}
+	gen, err := runGeneration(context.Background(), client, ai.GenerateRequest{Query: "say fixed"})
+	if err != nil || gen.Command != "echo 'fixed'" {
+		t.Fatalf("runGeneration() = %+v, %v, want the corrected command", gen, err)
+	}
+	if len(client.requests) != 2 || !strings.Contains(client.requests[1].Context, "unterminated single quote") {
+		t.Errorf("retry request = %+v, want the parse error in its context", client.requests)
+	}
+
+	client = &sequenceClient{commands: []string{"ls |"}}
+	if _, err := runGeneration(context.Background(), client, ai.GenerateRequest{Query: "list"}); err == nil {
+		t.Error("runGeneration() accepted a command that never parses")
+	}
+}
@@ -11,17 +11,251 @@ var K = koanf.New(".")
 // Config holds all configuration for the application
 type Config struct {
 	GeminiAPIKey  string `koanf:"gemini_api_key" mapstructure:"gemini_api_key"`
+	Provider      string `koanf:"provider" mapstructure:"provider"`
 	Debug         bool   `koanf:"debug" mapstructure:"debug"`
 	MockResponse  string `koanf:"mock_response" mapstructure:"mock_response"`
 	MockExitCode  int    `koanf:"mock_exit_code" mapstructure:"mock_exit_code"`
+	MockScenario  string `koanf:"mock_scenario" mapstructure:"mock_scenario"`
+	MockLatency   string `koanf:"mock_latency" mapstructure:"mock_latency"`
+	MockFault     string `koanf:"mock_fault" mapstructure:"mock_fault"`
+	Lint          bool   `koanf:"lint" mapstructure:"lint"`
+	Target        string `koanf:"target" mapstructure:"target"`
+	SafetyPolicy  string `koanf:"safety_policy" mapstructure:"safety_policy"` // How the AI's assessment and the patterns combine
+	SafetyExpand  bool   `koanf:"safety_expand" mapstructure:"safety_expand"` // Also analyze commands with aliases and known variables expanded
+	WorkdirGuard  bool   `koanf:"workdir_guard" mapstructure:"workdir_guard"` // Flag clearing the current directory when it is /, a network share or a dirty git repository
+	RiskProfile   string `koanf:"risk_profile" mapstructure:"risk_profile"` // workstation, server or production
+	RiskOverride  bool   `koanf:"risk_override" mapstructure:"risk_override"` // Set by --override-risk-profile for one generation
+	Capture       string `koanf:"capture" mapstructure:"capture"` // Set by --capture: file to save the provider transcript of one invocation in
+	Freeze        []Freeze `koanf:"freeze" mapstructure:"freeze"` // Change-freeze windows
+	POSIX         bool   `koanf:"posix" mapstructure:"posix"`
+	History       bool   `koanf:"history" mapstructure:"history"`
+	DirContext    bool   `koanf:"dir_context" mapstructure:"dir_context"`
+	ToolVersions  bool   `koanf:"tool_versions" mapstructure:"tool_versions"`
+	Feedback      bool   `koanf:"feedback" mapstructure:"feedback"`
+	LocaleContext bool   `koanf:"locale_context" mapstructure:"locale_context"`
+	ContextProviders []ContextProvider `koanf:"context_provider" mapstructure:"context_provider"` // Extra context sections for generation
+	Plan          string `koanf:"plan" mapstructure:"plan"`
+	Candidates    int    `koanf:"candidates" mapstructure:"candidates"`
+	Commented     bool   `koanf:"commented" mapstructure:"commented"`
+	StripComments bool   `koanf:"strip_comments" mapstructure:"strip_comments"`
+	MultiLine     bool   `koanf:"multi_line" mapstructure:"multi_line"`
+	Preset        string `koanf:"preset" mapstructure:"preset"` // Domain guidance for generation: docker, git, ffmpeg, k8s or networking
+	MaxCommandLength  int `koanf:"max_command_length" mapstructure:"max_command_length"`
+	MaxPipelineStages int `koanf:"max_pipeline_stages" mapstructure:"max_pipeline_stages"`
+	OfflineExplain bool  `koanf:"offline_explain" mapstructure:"offline_explain"`
+	ExplainEnv    bool   `koanf:"explain_env" mapstructure:"explain_env"`
+	ExplainCache  bool   `koanf:"explain_cache" mapstructure:"explain_cache"`
+	ExperienceLevel string `koanf:"experience_level" mapstructure:"experience_level"`
+	Redact        bool   `koanf:"redact" mapstructure:"redact"`
+	Network       string `koanf:"network" mapstructure:"network"`
+	Ollama        Ollama `koanf:"ollama" mapstructure:"ollama"`
+	Audit         Audit  `koanf:"audit" mapstructure:"audit"`
+	Budget        map[string]Budget `koanf:"budget" mapstructure:"budget"` // Keyed by provider name
+	Notify        Notify `koanf:"notify" mapstructure:"notify"`
+	Tracing       Tracing `koanf:"tracing" mapstructure:"tracing"`
+	Sandbox       Sandbox `koanf:"sandbox" mapstructure:"sandbox"`
+	NonInteractive NonInteractive `koanf:"non_interactive" mapstructure:"non_interactive"`
+	ExitCodes     ExitCodes `koanf:"exit_codes" mapstructure:"exit_codes"`
+	WSL           WSL     `koanf:"wsl" mapstructure:"wsl"`
+	Generation    Generation `koanf:"generation" mapstructure:"generation"`
+	PromptCache   PromptCache `koanf:"prompt_cache" mapstructure:"prompt_cache"`
+	Cache         Cache   `koanf:"cache" mapstructure:"cache"`
+	Limits        Limits     `koanf:"limits" mapstructure:"limits"`
+	Race          []string   `koanf:"race" mapstructure:"race"` // Providers to send each request to at once; the first valid answer wins
+	Telemetry     Telemetry  `koanf:"telemetry" mapstructure:"telemetry"`
+}
+
+// Telemetry configures the opt-in anonymous usage counters
+type Telemetry struct {
+	Mode     string `koanf:"mode" mapstructure:"mode"`         // "off", "local" (keep counters, never send) or "on"
+	Endpoint string `koanf:"endpoint" mapstructure:"endpoint"` // Where "on" sends the counters once a day
+}
+
+// Generation holds sampling controls passed to the model. Unset values
+// leave the provider's defaults in place.
+type Generation struct {
+	Temperature *float64 `koanf:"temperature" mapstructure:"temperature"` // 0.0 (deterministic) to 2.0
+	TopP        *float64 `koanf:"top_p" mapstructure:"top_p"`             // Nucleus sampling, 0.0 to 1.0
+	Seed        *int     `koanf:"seed" mapstructure:"seed"`               // Fixed seed for reproducible output (where supported)
+
+	// Tokens Gemini 2.5 models may spend reasoning before they answer:
+	// 0 turns thinking off, -1 lets the model decide
+	ThinkingBudget *int `koanf:"thinking_budget" mapstructure:"thinking_budget"`
+}
+
+// PromptCache configures provider-side caching of the static prompt
+// instructions (Gemini cached content). Providers without caching ignore it.
+type PromptCache struct {
+	Enabled bool   `koanf:"enabled" mapstructure:"enabled"`
+	TTL     string `koanf:"ttl" mapstructure:"ttl"` // How long the provider keeps a cache (Go duration)
+}
+
+// Cache bounds the local explain cache; the least recently used entries
+// are evicted first and 0 means unlimited
+type Cache struct {
+	MaxEntries int    `koanf:"max_entries" mapstructure:"max_entries"`
+	MaxBytes   int    `koanf:"max_bytes" mapstructure:"max_bytes"`
+	MaxAge     string `koanf:"max_age" mapstructure:"max_age"` // Drop entries unused for longer (Go duration); empty keeps them
+}
+
+// Limits caps request and response sizes in bytes; 0 means unlimited
+type Limits struct {
+	MaxQueryBytes    int `koanf:"max_query_bytes" mapstructure:"max_query_bytes"`       // The query, or the command or file to explain
+	MaxContextBytes  int `koanf:"max_context_bytes" mapstructure:"max_context_bytes"`   // History, directory and environment context
+	MaxResponseBytes int `koanf:"max_response_bytes" mapstructure:"max_response_bytes"` // The provider's raw response
+}
+
+// Budget caps client-side usage of one provider; zero means unlimited
+type Budget struct {
+	RequestsPerMinute int      `koanf:"requests_per_minute" mapstructure:"requests_per_minute"`
+	DailyTokens       int      `koanf:"daily_tokens" mapstructure:"daily_tokens"`
+	MaxCostPerDay     float64  `koanf:"max_cost_per_day" mapstructure:"max_cost_per_day"`                 // Estimated US dollars
+	PricePerMillion   *float64 `koanf:"price_per_million_tokens" mapstructure:"price_per_million_tokens"` // Unset uses the built-in price
+	OverBudget        string   `koanf:"over_budget" mapstructure:"over_budget"`                           // "refuse" (default) or "ask"
+	Fallback          string   `koanf:"fallback" mapstructure:"fallback"`                                 // Provider to use instead when over budget
+}
+
+// Audit configures the tamper-evident log of generated commands
+type Audit struct {
+	Enabled bool   `koanf:"enabled" mapstructure:"enabled"`
+	Path    string `koanf:"path" mapstructure:"path"` // Defaults to $XDG_STATE_HOME/hermes/audit.log
+}
+
+// Ollama configures the local Ollama provider
+type Ollama struct {
+	URL   string `koanf:"url" mapstructure:"url"`     // Server address
+	Model string `koanf:"model" mapstructure:"model"` // Model name, e.g. "qwen2.5-coder:7b"
+}
+
+// NetworkEnabled reports whether hermes may talk to remote services.
+// With network = "off" only local providers and offline fallbacks are used.
+func (c Config) NetworkEnabled() bool {
+	return c.Network != "off"
+}
+
+// WSL configures behavior under Windows Subsystem for Linux
+type WSL struct {
+	TranslatePaths string `koanf:"translate_paths" mapstructure:"translate_paths"` // "ask", "auto" or "off"
+}
+
+// NonInteractive configures strict mode for CI and other automation
+type NonInteractive struct {
+	Enabled           bool `koanf:"enabled" mapstructure:"enabled"`                         // No tips, prompts or decoration
+	AttentionExitCode int  `koanf:"attention_exit_code" mapstructure:"attention_exit_code"` // Exit code for Attention-level commands (0 = exit_codes.attention)
+}
+
+// ExitCodes remaps the exit codes of the safety levels for wrappers that
+// expect other values; the shell integration is generated to match.
+// Zero keeps the default.
+type ExitCodes struct {
+	Safe      int `koanf:"safe" mapstructure:"safe"`           // Default 0
+	Attention int `koanf:"attention" mapstructure:"attention"` // Default 10
+}
+
+// Sandbox configures the --sandbox execution preview
+type Sandbox struct {
+	Runtime string `koanf:"runtime" mapstructure:"runtime"` // "auto", "bwrap", "podman" or "docker"
+	Image   string `koanf:"image" mapstructure:"image"`     // Container image for podman/docker
+}
+
+// Tracing configures span tracing of the generation pipeline
+type Tracing struct {
+	Enabled  bool   `koanf:"enabled" mapstructure:"enabled"`   // Print a timing summary to stderr
+	Endpoint string `koanf:"endpoint" mapstructure:"endpoint"` // OTLP/HTTP collector (e.g., http://localhost:4318)
+}
+
+// Freeze is a change-freeze window in which risky generations are refused
+type Freeze struct {
+	From     string   `koanf:"from" mapstructure:"from"`         // "Fri 16:00" weekly, or "2026-12-20 18:00" once (local time)
+	Until    string   `koanf:"until" mapstructure:"until"`       // Same form as from
+	Profiles []string `koanf:"profiles" mapstructure:"profiles"` // Risk profiles the window applies to (empty = all)
+	Refuse   string   `koanf:"refuse" mapstructure:"refuse"`     // "forbidden" (default) or "attention"
+	Reason   string   `koanf:"reason" mapstructure:"reason"`     // Optional; shown when refusing
+}
+
+// ContextProvider adds a section of local context to generation prompts
+type ContextProvider struct {
+	Name     string   `koanf:"name" mapstructure:"name"`         // os, cwd, git, tools, or a name for the command
+	Command  []string `koanf:"command" mapstructure:"command"`   // Program and arguments printing the section (empty = built-in)
+	Profiles []string `koanf:"profiles" mapstructure:"profiles"` // Risk profiles the provider runs under (empty = all)
+}
+
+// Notify configures webhook notifications about Attention-level generations
+// and refused commands, and desktop notifications about slow generations
+type Notify struct {
+	WebhookURL   string   `koanf:"webhook_url" mapstructure:"webhook_url"`       // Slack-compatible webhook endpoint
+	Hosts        []string `koanf:"hosts" mapstructure:"hosts"`                   // Hostname globs to notify from (empty = all)
+	Desktop      bool     `koanf:"desktop" mapstructure:"desktop"`               // Desktop notification for slow generations
+	DesktopAfter int      `koanf:"desktop_after" mapstructure:"desktop_after"`   // Seconds before a generation counts as slow
 }
 
 // Default returns a new Config with default values
 func Default() Config {
 	return Config{
 		GeminiAPIKey: "", // No default API key
+		Provider:     "", // Inferred: mock options, then ollama with the network off, then gemini
 		Debug:        false,
 		MockResponse: "", // No default mock response
 		MockExitCode: 0,  // Default to safe exit code
+		Lint:         true,  // Lint generated commands (shellcheck or built-in checks)
+		Target:       "posix", // Generate POSIX shell syntax unless cmd.exe is requested
+		SafetyPolicy: "strictest-wins", // Attention from the AI or the patterns wins
+		SafetyExpand: false,   // Analyze commands as written unless expansion is requested
+		WorkdirGuard: true,    // Where a command runs matters for rm -rf . and friends
+		RiskProfile:  "workstation", // The built-in patterns alone; servers opt into stricter profiles
+		POSIX:        false,   // GNU extensions and bashisms are allowed unless strict POSIX is requested
+		History:      false, // Shell history context is strictly opt-in
+		DirContext:   false, // Directory listings are opt-in and need per-directory consent
+		ToolVersions: false, // Probing installed tools runs local binaries, so it is opt-in
+		Feedback:     true,  // Remember the last generation for `hermes feedback`; ratings stay local
+		LocaleContext: true, // Send the local date, timezone and locale so relative dates resolve locally
+		Plan:         "first", // Multi-command plans put only their first step into the buffer
+		Candidates:   1,       // One command per query unless alternatives are requested
+		Commented:    false,   // Plain one-line commands unless per-part comments are requested
+		StripComments: false,  // Commented commands go into the buffer with their comments
+		MultiLine:    false,   // Generated commands with line breaks are rejected and asked for again
+		Preset:       "",      // No domain guidance unless a preset is chosen
+		MaxCommandLength:  500, // Longer generated lines are asked for again in a simpler form
+		MaxPipelineStages: 5,   // ...as are pipelines of more commands
+		OfflineExplain: true, // Explain common commands from the embedded flag database
+		ExplainEnv:   false, // Do not show the values of environment variables explained commands reference
+		ExplainCache: true,  // Reuse AI explanations of commands explained before with the same model
+		ExperienceLevel: "intermediate", // Explanations for users who know the basics
+		Redact:       true,  // Replace credentials with placeholders before contacting the provider
+		Network:      "on",  // "off" restricts hermes to local providers and offline fallbacks
+		Ollama: Ollama{
+			URL: "http://localhost:11434",
+		},
+		Notify: Notify{
+			Desktop:      false,
+			DesktopAfter: 10,
+		},
+		NonInteractive: NonInteractive{
+			Enabled:           false,
+			AttentionExitCode: 0, // Same as exit_codes.attention unless set
+		},
+		WSL: WSL{
+			TranslatePaths: "ask", // Offer to fix /mnt/c vs C:\ paths in generated commands
+		},
+		Sandbox: Sandbox{
+			Runtime: "auto",
+			Image:   "alpine:latest",
+		},
+		Telemetry: Telemetry{
+			Mode: "off", // Strictly opt-in
+		},
+		Limits: Limits{
+			MaxQueryBytes:    256 * 1024, // As much as explain reads from a file
+			MaxContextBytes:  256 * 1024,
+			MaxResponseBytes: 4 << 20, // Far above any real answer
+		},
+		PromptCache: PromptCache{
+			Enabled: false, // Cache storage is billed by the hour, so it is opt-in
+			TTL:     "1h",
+		},
+		Cache: Cache{
+			MaxEntries: 1000,
+			MaxBytes:   4 << 20,
+		},
 	}
 }
\ No newline at end of file
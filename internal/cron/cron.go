@@ -0,0 +1,207 @@
+// Package cron parses, describes and evaluates standard five-field crontab
+// schedules, so generated crontab lines can be validated before use
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field describes one of the five schedule fields
+type field struct {
+	name     string
+	min, max int
+	names    []string // Accepted names, indexed from min (months, weekdays)
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	dowField    = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// macros are the @ shorthands cron accepts in place of five fields
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Schedule is a parsed crontab schedule
+type Schedule struct {
+	Expr   string // The expression as written
+	Reboot bool   // @reboot: runs once at startup, never on a clock
+
+	fields                        [5]string // Normalized field text
+	minute, hour, dom, month, dow uint64    // Bit sets of allowed values
+}
+
+// Parse parses a five-field schedule or an @ macro
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	s := &Schedule{Expr: expr}
+	if expr == "@reboot" {
+		s.Reboot = true
+		return s, nil
+	}
+	normalized := expr
+	if strings.HasPrefix(expr, "@") {
+		var ok bool
+		if normalized, ok = macros[strings.ToLower(expr)]; !ok {
+			return nil, fmt.Errorf("unknown schedule %s", expr)
+		}
+	}
+
+	parts := strings.Fields(normalized)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("schedule needs 5 fields (minute hour day-of-month month day-of-week), got %d", len(parts))
+	}
+	copy(s.fields[:], parts)
+	var err error
+	targets := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, f := range []field{minuteField, hourField, domField, monthField, dowField} {
+		if *targets[i], err = f.parse(parts[i]); err != nil {
+			return nil, err
+		}
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// Split separates a crontab entry into its schedule and command. It
+// reports false when the line does not start with a valid schedule.
+func Split(line string) (schedule *Schedule, command string, ok bool) {
+	line = strings.TrimSpace(line)
+	n := 5
+	if strings.HasPrefix(line, "@") {
+		n = 1
+	}
+	parts := strings.Fields(line)
+	if len(parts) < n {
+		return nil, "", false
+	}
+	schedule, err := Parse(strings.Join(parts[:n], " "))
+	if err != nil {
+		return nil, "", false
+	}
+	// Keep the command's own spacing and quoting
+	rest := line
+	for i := 0; i < n; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		rest = rest[len(parts[i]):]
+	}
+	return schedule, strings.TrimSpace(rest), true
+}
+
+// parse turns one field into a bit set of allowed values
+func (f field) parse(text string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(text, ",") {
+		rangeText, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			rangeText = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", part[i+1:], f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if f.name == dowField.name {
+			hi = 6 // "*" means every day once; 7 only as an explicit alias for Sunday
+		}
+		switch {
+		case rangeText == "*":
+		case strings.Contains(rangeText, "-"):
+			bounds := strings.SplitN(rangeText, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeText, f.name)
+			}
+		default:
+			value, err := f.value(rangeText)
+			if err != nil {
+				return 0, err
+			}
+			lo = value
+			if step == 1 {
+				hi = value
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or name within the field's bounds
+func (f field) value(text string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(text, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", f.name, text)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s %d out of range %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// matchesDay applies cron's rule that a restricted day of month and day of
+// week match if either does
+func (s *Schedule) matchesDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.fields[2] != "*" && s.fields[4] != "*" {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the first time after t the schedule fires, or the zero
+// time for @reboot and schedules that never fire (such as February 31st)
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.Reboot {
+		return time.Time{}
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
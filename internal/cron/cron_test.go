@@ -0,0 +1,90 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@fortnightly",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"30 6 * * 1-5", "At 06:30 on Monday through Friday"},
+		{"0 9,17 * * *", "At 09:00 and 17:00"},
+		{"*/15 * * * *", "Every 15 minutes"},
+		{"5 * * * *", "At minute 5 past every hour"},
+		{"0 */2 * * *", "At minute 0 past every 2nd hour"},
+		{"0 0 1 jan,jul *", "At 00:00 on day-of-month 1 in January and July"},
+		{"0 3 * * sun", "At 03:00 on Sunday"},
+		{"*/10 9-17 * * mon-fri", "Every 10 minutes past hour 9 through 17 on Monday through Friday"},
+		{"@daily", "At 00:00"},
+		{"@reboot", "Once at system startup"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if got := s.Describe(); got != tt.want {
+			t.Errorf("Describe(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2026, 10, 16, 19, 0, 0, 0, time.UTC) // A Friday evening
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"30 6 * * 1-5", time.Date(2026, 10, 19, 6, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 16, 19, 15, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		// Day of month and day of week match if either does
+		{"0 8 20 * 6", time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	s, command, ok := Split(`30 6 * * 1-5  /usr/local/bin/backup.sh --to "my disk"`)
+	if !ok || s.Expr != "30 6 * * 1-5" || command != `/usr/local/bin/backup.sh --to "my disk"` {
+		t.Errorf("Split() = %v, %q, %v", s, command, ok)
+	}
+	if _, command, ok := Split("@hourly date >> /tmp/log"); !ok || command != "date >> /tmp/log" {
+		t.Errorf("Split(@hourly) = %q, %v", command, ok)
+	}
+	if _, _, ok := Split("ls -la /tmp"); ok {
+		t.Error("Split() accepted a plain command")
+	}
+}
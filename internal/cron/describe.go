@@ -0,0 +1,142 @@
+// Package cron - plain-English schedule descriptions
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// monthNames and dayNames are used in descriptions
+var (
+	monthNames = []string{"", "January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	dayNames   = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+)
+
+// Describe explains the schedule in plain English, e.g. "At 06:30 on
+// Monday through Friday"
+func (s *Schedule) Describe() string {
+	if s.Reboot {
+		return "Once at system startup"
+	}
+	minute, hour, dom, month, dow := s.fields[0], s.fields[1], s.fields[2], s.fields[3], s.fields[4]
+
+	var b strings.Builder
+	b.WriteString(describeTime(minute, hour))
+	if dom != "*" {
+		b.WriteString(" on day-of-month " + describeList(dom, domField, strconv.Itoa))
+	}
+	if dow != "*" {
+		if dom != "*" {
+			b.WriteString(" and")
+		}
+		b.WriteString(" on " + describeList(dow, dowField, func(v int) string { return dayNames[v] }))
+	}
+	if month != "*" {
+		b.WriteString(" in " + describeList(month, monthField, func(v int) string { return monthNames[v] }))
+	}
+	return b.String()
+}
+
+// describeTime explains the minute and hour fields
+func describeTime(minute, hour string) string {
+	m, mErr := strconv.Atoi(minute)
+	if mErr == nil {
+		// Fixed minute at one or more listed hours: "At 06:30 and 18:30"
+		var times []string
+		for _, h := range strings.Split(hour, ",") {
+			n, err := strconv.Atoi(h)
+			if err != nil {
+				times = nil
+				break
+			}
+			times = append(times, fmt.Sprintf("%02d:%02d", n, m))
+		}
+		if times != nil {
+			return "At " + joinAnd(times)
+		}
+	}
+
+	var minutePart string
+	switch {
+	case minute == "*":
+		minutePart = "Every minute"
+	case strings.HasPrefix(minute, "*/"):
+		minutePart = "Every " + minute[2:] + " minutes"
+	case mErr == nil:
+		minutePart = fmt.Sprintf("At minute %d", m)
+	default:
+		minutePart = "At minutes " + describeList(minute, minuteField, strconv.Itoa)
+	}
+
+	switch {
+	case hour == "*":
+		if mErr == nil {
+			return minutePart + " past every hour"
+		}
+		return minutePart
+	case strings.HasPrefix(hour, "*/"):
+		return minutePart + " past every " + ordinal(hour[2:]) + " hour"
+	default:
+		return minutePart + " past hour " + describeList(hour, hourField, strconv.Itoa)
+	}
+}
+
+// describeList explains a field's list of values, ranges and steps
+func describeList(text string, f field, name func(int) string) string {
+	var parts []string
+	for _, part := range strings.Split(text, ",") {
+		rangeText, step := part, ""
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			rangeText, step = part[:i], part[i+1:]
+		}
+		var desc string
+		switch {
+		case rangeText == "*":
+			desc = "every " + ordinal(step) + " " + f.name
+		case strings.Contains(rangeText, "-"):
+			bounds := strings.SplitN(rangeText, "-", 2)
+			lo, _ := f.value(bounds[0])
+			hi, _ := f.value(bounds[1])
+			desc = name(lo) + " through " + name(hi)
+			if step != "" {
+				desc = "every " + ordinal(step) + " " + f.name + " from " + desc
+			}
+		default:
+			v, _ := f.value(rangeText)
+			desc = name(v)
+			if step != "" {
+				desc = "every " + ordinal(step) + " " + f.name + " from " + desc
+			}
+		}
+		parts = append(parts, desc)
+	}
+	return joinAnd(parts)
+}
+
+// ordinal turns a step into "2nd", "3rd", ...; a step of 1 reads as ""
+func ordinal(step string) string {
+	n, err := strconv.Atoi(step)
+	if err != nil || n <= 1 {
+		return ""
+	}
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// joinAnd joins items as "a, b and c"
+func joinAnd(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,136 @@
+package ctxprovider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"hermes/internal/pkgmgr"
+)
+
+// osReleasePath, lookPath and run are replaced in tests
+var (
+	osReleasePath = "/etc/os-release"
+	lookPath      = exec.LookPath
+	run           = func(ctx context.Context, dir, binary string, args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := exec.CommandContext(ctx, binary, args...)
+		cmd.Dir = dir
+		cmd.Stdout = &out
+		err := cmd.Run()
+		return strings.TrimSpace(out.String()), err
+	}
+)
+
+// helpers are the programs the tools provider reports when installed,
+// modern replacements the model should prefer over their classic
+// counterparts
+var helpers = []string{"rg", "fd", "fdfind", "jq", "yq", "bat", "batcat", "fzf", "gawk", "trash-put", "eza", "delta", "sd", "parallel", "pv"}
+
+// osProvider describes the operating system and shell
+type osProvider struct{}
+
+func (osProvider) Name() string { return "os" }
+
+func (osProvider) Collect(context.Context) (string, error) {
+	system := runtime.GOOS + "/" + runtime.GOARCH
+	if name := prettyName(osReleasePath); name != "" {
+		system = fmt.Sprintf("%s (%s)", name, system)
+	}
+	lines := []string{"Operating system: " + system}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		lines = append(lines, "Shell: "+filepath.Base(shell))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// prettyName reads PRETTY_NAME from an os-release file
+func prettyName(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"'`)
+		}
+	}
+	return ""
+}
+
+// cwdProvider names the working directory
+type cwdProvider struct{}
+
+func (cwdProvider) Name() string { return "cwd" }
+
+func (cwdProvider) Collect(context.Context) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return "Current directory: " + dir, nil
+}
+
+// gitProvider describes the repository around the working directory
+type gitProvider struct{}
+
+func (gitProvider) Name() string { return "git" }
+
+func (gitProvider) Collect(ctx context.Context) (string, error) {
+	if _, err := lookPath("git"); err != nil {
+		return "", nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	root, err := run(ctx, dir, "git", "rev-parse", "--show-toplevel")
+	if err != nil || root == "" {
+		return "", nil // Not in a repository
+	}
+	lines := []string{"Git repository: " + root}
+	if branch, err := run(ctx, dir, "git", "branch", "--show-current"); err == nil {
+		if branch == "" {
+			branch = "(detached HEAD)"
+		}
+		lines = append(lines, "Branch: "+branch)
+	}
+	if status, err := run(ctx, dir, "git", "status", "--porcelain"); err == nil {
+		changed := 0
+		if status != "" {
+			changed = strings.Count(status, "\n") + 1
+		}
+		lines = append(lines, fmt.Sprintf("Uncommitted changes: %d file(s)", changed))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// toolsProvider lists the package manager and installed helper programs
+type toolsProvider struct{}
+
+func (toolsProvider) Name() string { return "tools" }
+
+func (toolsProvider) Collect(context.Context) (string, error) {
+	var lines []string
+	if manager, ok := pkgmgr.Detect(); ok {
+		lines = append(lines, "Package manager: "+manager.Name)
+	}
+	var installed []string
+	for _, helper := range helpers {
+		if _, err := lookPath(helper); err == nil {
+			installed = append(installed, helper)
+		}
+	}
+	if len(installed) > 0 {
+		lines = append(lines, "Installed helper tools: "+strings.Join(installed, ", "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
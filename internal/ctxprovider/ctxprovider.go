@@ -0,0 +1,133 @@
+// Package ctxprovider gathers sections of local context for generation
+// prompts, from built-in providers and from programs the user configures
+// (say, one printing the current kubectl context)
+package ctxprovider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// collectTimeout bounds each provider
+const collectTimeout = 2 * time.Second
+
+// maxSectionBytes bounds the section one provider adds to the prompt
+const maxSectionBytes = 2048
+
+// Provider contributes one section of context. An empty section with a
+// nil error means there is nothing to say (e.g., not in a git repository).
+type Provider interface {
+	Name() string
+	Collect(ctx context.Context) (string, error)
+}
+
+// Builtins maps the names of the built-in providers to them
+var Builtins = map[string]Provider{
+	"os":    osProvider{},
+	"cwd":   cwdProvider{},
+	"git":   gitProvider{},
+	"tools": toolsProvider{},
+}
+
+// BuiltinNames returns the built-in provider names in order
+func BuiltinNames() []string {
+	names := make([]string, 0, len(Builtins))
+	for name := range Builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Exec is a provider backed by an external program; its standard output
+// becomes the section
+type Exec struct {
+	ProviderName string
+	Command      []string // Program and arguments, run without a shell
+}
+
+// Name returns the configured name
+func (e Exec) Name() string {
+	return e.ProviderName
+}
+
+// Collect runs the program and returns its output under a heading
+func (e Exec) Collect(ctx context.Context) (string, error) {
+	if len(e.Command) == 0 {
+		return "", fmt.Errorf("no command")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if line, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); line != "" {
+			return "", fmt.Errorf("%w: %s", err, line)
+		}
+		return "", err
+	}
+	output := strings.TrimSpace(stdout.String())
+	if output == "" {
+		return "", nil
+	}
+	return fmt.Sprintf("From %s:\n%s", e.ProviderName, output), nil
+}
+
+// Failure is a provider that could not collect its section
+type Failure struct {
+	Provider string
+	Err      error
+}
+
+func (f Failure) Error() string {
+	return fmt.Sprintf("context provider %s: %v", f.Provider, f.Err)
+}
+
+// Collect runs the providers concurrently, each within collectTimeout, and
+// returns their non-empty sections in the order given along with the
+// providers that failed
+func Collect(ctx context.Context, providers []Provider) ([]string, []Failure) {
+	sections := make([]string, len(providers))
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, collectTimeout)
+			defer cancel()
+			sections[i], errs[i] = provider.Collect(ctx)
+		}()
+	}
+	wg.Wait()
+
+	var collected []string
+	var failures []Failure
+	for i, section := range sections {
+		if errs[i] != nil {
+			failures = append(failures, Failure{Provider: providers[i].Name(), Err: errs[i]})
+			continue
+		}
+		if section != "" {
+			collected = append(collected, truncate(section))
+		}
+	}
+	return collected, failures
+}
+
+// truncate cuts a section to maxSectionBytes at a line boundary
+func truncate(section string) string {
+	if len(section) <= maxSectionBytes {
+		return section
+	}
+	cut := section[:maxSectionBytes]
+	if i := strings.LastIndex(cut, "\n"); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "\n(truncated)"
+}
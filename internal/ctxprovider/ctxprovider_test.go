@@ -0,0 +1,77 @@
+package ctxprovider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollect(t *testing.T) {
+	providers := []Provider{
+		Exec{ProviderName: "kube", Command: []string{"sh", "-c", "echo 'Kubernetes context: staging'"}},
+		Exec{ProviderName: "quiet", Command: []string{"true"}},
+		Exec{ProviderName: "broken", Command: []string{"sh", "-c", "echo oops >&2; exit 3"}},
+		Exec{ProviderName: "long", Command: []string{"sh", "-c", "i=0; while [ $i -lt 300 ]; do echo line $i; i=$((i+1)); done"}},
+	}
+	sections, failures := Collect(context.Background(), providers)
+	if len(sections) != 2 || sections[0] != "From kube:\nKubernetes context: staging" {
+		t.Fatalf("Collect() sections = %q, want kube's and the long one in order", sections)
+	}
+	if long := sections[1]; len(long) > maxSectionBytes+len("\n(truncated)") || !strings.HasSuffix(long, "\n(truncated)") {
+		t.Errorf("long section has %d bytes, want it truncated to %d", len(long), maxSectionBytes)
+	}
+	if len(failures) != 1 || failures[0].Provider != "broken" || !strings.Contains(failures[0].Error(), "oops") {
+		t.Errorf("Collect() failures = %v, want broken with its stderr", failures)
+	}
+}
+
+func TestOSProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-release")
+	if err := os.WriteFile(path, []byte("NAME=Debian\nPRETTY_NAME=\"Debian GNU/Linux 13 (trixie)\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	osReleasePath = path
+	t.Cleanup(func() { osReleasePath = "/etc/os-release" })
+	t.Setenv("SHELL", "/usr/bin/zsh")
+
+	section, err := osProvider{}.Collect(context.Background())
+	if err != nil || !strings.HasPrefix(section, "Operating system: Debian GNU/Linux 13 (trixie) (") || !strings.HasSuffix(section, "\nShell: zsh") {
+		t.Errorf("os section = %q, %v", section, err)
+	}
+}
+
+func TestGitProvider(t *testing.T) {
+	lookPath = func(string) (string, error) { return "/usr/bin/git", nil }
+	outputs := map[string]string{
+		"rev-parse": "/src/hermes",
+		"branch":    "main",
+		"status":    " M README.md\n?? notes.txt",
+	}
+	run = func(_ context.Context, _, _ string, args ...string) (string, error) {
+		return outputs[args[0]], nil
+	}
+	t.Cleanup(func() {
+		lookPath = defaultLookPath
+		run = defaultRun
+	})
+
+	section, _ := gitProvider{}.Collect(context.Background())
+	if want := "Git repository: /src/hermes\nBranch: main\nUncommitted changes: 2 file(s)"; section != want {
+		t.Errorf("git section = %q, want %q", section, want)
+	}
+
+	run = func(context.Context, string, string, ...string) (string, error) {
+		return "", errors.New("not a git repository")
+	}
+	if section, err := (gitProvider{}).Collect(context.Background()); section != "" || err != nil {
+		t.Errorf("git section outside a repository = %q, %v, want nothing", section, err)
+	}
+}
+
+var (
+	defaultLookPath = lookPath
+	defaultRun      = run
+)
@@ -0,0 +1,181 @@
+// Package dataflow draws long pipelines as ASCII flow diagrams: one box
+// per stage, with arrows annotated with the data passing between them
+package dataflow
+
+import (
+	"fmt"
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// Diagram layout
+const (
+	MinStages = 3  // Shorter pipelines read fine as bullets alone
+	maxLabel  = 48 // Longer stage text is shortened with "..."
+	indent    = 4  // Column of the arrows, relative to the box edge
+)
+
+// outputs describes what each command writes to standard output. Keys
+// with a flag ("grep -c") take precedence when the stage uses that flag.
+var outputs = map[string]string{
+	"cat":        "file contents",
+	"tac":        "lines in reverse order",
+	"zcat":       "decompressed contents",
+	"echo":       "text",
+	"printf":     "formatted text",
+	"ls":         "file names",
+	"find":       "file paths",
+	"fd":         "file paths",
+	"locate":     "file paths",
+	"ps":         "process list",
+	"df":         "disk usage table",
+	"du":         "sizes per path",
+	"env":        "environment variables",
+	"history":    "past commands",
+	"git":        "git output",
+	"docker":     "docker output",
+	"kubectl":    "kubectl output",
+	"curl":       "response body",
+	"wget":       "downloaded data",
+	"journalctl": "log lines",
+	"dmesg":      "kernel messages",
+	"grep":       "matching lines",
+	"grep -v":    "non-matching lines",
+	"grep -c":    "match counts",
+	"grep -l":    "names of matching files",
+	"grep -o":    "matched text",
+	"egrep":      "matching lines",
+	"rg":         "matching lines",
+	"sed":        "edited lines",
+	"awk":        "selected fields",
+	"cut":        "selected fields",
+	"tr":         "translated characters",
+	"sort":       "sorted lines",
+	"uniq":       "deduplicated lines",
+	"uniq -c":    "lines with their counts",
+	"uniq -d":    "duplicated lines",
+	"head":       "first lines",
+	"tail":       "last lines",
+	"wc":         "counts",
+	"wc -l":      "line count",
+	"wc -w":      "word count",
+	"wc -c":      "byte count",
+	"jq":         "JSON",
+	"yq":         "YAML",
+	"xargs":      "output of the commands run",
+	"tee":        "the same data (also saved to a file)",
+	"column":     "aligned columns",
+	"nl":         "numbered lines",
+	"rev":        "reversed lines",
+	"paste":      "merged lines",
+	"base64":     "base64 text",
+	"gzip":       "compressed data",
+	"gunzip":     "decompressed data",
+	"tar":        "archive data",
+	"sha256sum":  "checksums",
+	"md5sum":     "checksums",
+	"basename":   "file names",
+	"dirname":    "directory names",
+	"shuf":       "shuffled lines",
+}
+
+// Render draws the diagram of a command's longest pipeline. It reports
+// false when the command does not parse or has no pipeline of MinStages
+// stages or more.
+func Render(command string) (string, bool) {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return "", false
+	}
+	var pipeline shell.Pipeline
+	for _, p := range script.Pipelines {
+		if len(p.Stages) > len(pipeline.Stages) {
+			pipeline = p
+		}
+	}
+	if len(pipeline.Stages) < MinStages {
+		return "", false
+	}
+
+	labels := make([]string, len(pipeline.Stages))
+	width := 0
+	for i, stage := range pipeline.Stages {
+		labels[i] = stageLabel(command, stage)
+		width = max(width, len(labels[i]))
+	}
+
+	var b strings.Builder
+	first, last := pipeline.Stages[0], pipeline.Stages[len(pipeline.Stages)-1]
+	for _, source := range redirectTargets(first, "<") {
+		arrow(&b, "from "+source)
+	}
+	border := "+" + strings.Repeat("-", width+2) + "+\n"
+	for i, stage := range pipeline.Stages {
+		b.WriteString(border)
+		fmt.Fprintf(&b, "| %-*s |\n", width, labels[i])
+		b.WriteString(border)
+		if i < len(pipeline.Stages)-1 {
+			arrow(&b, output(stage))
+		}
+	}
+	for _, sink := range redirectTargets(last, ">", ">>", "&>", "&>>") {
+		arrow(&b, output(last)+" into "+sink)
+	}
+	return b.String(), true
+}
+
+// output describes what a stage writes to standard output
+func output(stage shell.Stage) string {
+	name := stage.Name()
+	for _, arg := range stage.Args {
+		if !strings.HasPrefix(arg.Value, "-") || strings.HasPrefix(arg.Value, "--") {
+			continue
+		}
+		// A flag cluster like -rc counts for each of its letters
+		for _, letter := range arg.Value[1:] {
+			if description, ok := outputs[name+" -"+string(letter)]; ok {
+				return description
+			}
+		}
+	}
+	if description, ok := outputs[name]; ok {
+		return description
+	}
+	return "output of " + name
+}
+
+// arrow draws a downward arrow annotated with the data it carries
+func arrow(b *strings.Builder, label string) {
+	pad := strings.Repeat(" ", indent)
+	fmt.Fprintf(b, "%s|  %s\n", pad, label)
+	fmt.Fprintf(b, "%sv\n", pad)
+}
+
+// stageLabel is the stage's source text, shortened to fit a box
+func stageLabel(command string, stage shell.Stage) string {
+	if len(stage.Args) == 0 {
+		return "(redirection)"
+	}
+	first, last := stage.Args[0], stage.Args[len(stage.Args)-1]
+	label := command[first.Pos : last.Pos+len(last.Raw)]
+	label = strings.Join(strings.Fields(label), " ")
+	if len(label) > maxLabel {
+		label = label[:maxLabel-3] + "..."
+	}
+	return label
+}
+
+// redirectTargets returns the targets of a stage's redirections with the
+// given operators
+func redirectTargets(stage shell.Stage, ops ...string) []string {
+	var targets []string
+	for _, redirect := range stage.Redirects {
+		for _, op := range ops {
+			if redirect.Op == op && !strings.HasPrefix(redirect.Target, "&") {
+				targets = append(targets, redirect.Target)
+			}
+		}
+	}
+	return targets
+}
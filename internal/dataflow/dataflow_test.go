@@ -0,0 +1,79 @@
+package dataflow
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	got, ok := Render("grep -v DEBUG < app.log | cut -d' ' -f3 | sort | uniq -c > counts.txt")
+	if !ok {
+		t.Fatalf("Render() reported no diagram")
+	}
+	want := `    |  from app.log
+    v
++---------------+
+| grep -v DEBUG |
++---------------+
+    |  non-matching lines
+    v
++---------------+
+| cut -d' ' -f3 |
++---------------+
+    |  selected fields
+    v
++---------------+
+| sort          |
++---------------+
+    |  sorted lines
+    v
++---------------+
+| uniq -c       |
++---------------+
+    |  lines with their counts into counts.txt
+    v
+`
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderShortPipelines(t *testing.T) {
+	for _, command := range []string{
+		"ls -la",
+		"ps aux | grep nginx",
+		"make && make test && make install",
+		"echo 'unterminated",
+	} {
+		if got, ok := Render(command); ok {
+			t.Errorf("Render(%q) = %q, want no diagram", command, got)
+		}
+	}
+}
+
+func TestRenderLongestPipeline(t *testing.T) {
+	got, ok := Render("cd /var/log && cat syslog | grep -c error | tee count.txt | mail -s errors root")
+	if !ok {
+		t.Fatalf("Render() reported no diagram")
+	}
+	for _, want := range []string{"| cat syslog", "|  file contents", "|  match counts", "| mail -s errors root"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render() misses %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "cd /var/log") {
+		t.Errorf("Render() drew the stage outside the pipeline:\n%s", got)
+	}
+}
+
+func TestRenderShortensLongStages(t *testing.T) {
+	got, _ := Render("find . -type f -name '*.go' -not -path './vendor/*' -newer go.mod | xargs wc -l | sort -n")
+	if !strings.Contains(got, "...") {
+		t.Errorf("Render() did not shorten the long stage:\n%s", got)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if len(line) > maxLabel+4 {
+			t.Errorf("line %q is wider than a box", line)
+		}
+	}
+}
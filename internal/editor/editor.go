@@ -0,0 +1,214 @@
+// Package editor implements the JSON-over-stdio protocol used by editor plugins
+//
+// Framing: every message is a single JSON object terminated by a newline
+// (JSON Lines). Requests are read from stdin and responses are written to
+// stdout; responses may arrive out of order, so clients match them by id.
+//
+// Requests:
+//
+//	{"id": 1, "method": "generate", "params": {"query": "list files", "verbose": false}}
+//	{"id": 2, "method": "explain", "params": {"command": "ls -la"}}
+//	{"id": 3, "method": "cancel", "params": {"id": 1}}
+//	{"id": 4, "method": "shutdown"}
+//
+// Responses carry either a result or an error:
+//
+//	{"id": 1, "result": {"command": "ls -la", "safety": "safe", "exit_code": 0}}
+//	{"id": 1, "error": {"code": 130, "message": "request cancelled"}}
+//
+// Error codes are hermes exit codes, so plugins can reuse the same handling
+// as the shell integration.
+package editor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"hermes/internal/exit"
+)
+
+// Protocol-level error codes (everything else is a hermes exit code)
+const (
+	CodeCancelled      = 130 // Request was cancelled by the client
+	CodeInvalidRequest = 64  // Malformed request or unknown method
+)
+
+// Request is a single message sent by the editor
+type Request struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a single message sent back to the editor
+type Response struct {
+	ID     int            `json:"id"`
+	Result interface{}    `json:"result,omitempty"`
+	Error  *ResponseError `json:"error,omitempty"`
+}
+
+// ResponseError describes a failed request
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// GenerateParams are the parameters of a generate request
+type GenerateParams struct {
+	Query   string `json:"query"`
+	Verbose bool   `json:"verbose"`
+}
+
+// ExplainParams are the parameters of an explain request
+type ExplainParams struct {
+	Command string `json:"command"`
+}
+
+// cancelParams are the parameters of a cancel request
+type cancelParams struct {
+	ID int `json:"id"`
+}
+
+// Handler performs the actual work behind protocol requests
+type Handler interface {
+	Generate(ctx context.Context, params GenerateParams) (interface{}, error)
+	Explain(ctx context.Context, params ExplainParams) (interface{}, error)
+}
+
+// server tracks in-flight requests for a single editor session
+type server struct {
+	handler Handler
+	encoder *json.Encoder
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	inFlight map[int]context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// Serve reads requests from r and writes responses to w until the input is
+// closed, a shutdown request arrives, or ctx is cancelled
+func Serve(ctx context.Context, r io.Reader, w io.Writer, handler Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &server{
+		handler:  handler,
+		encoder:  json.NewEncoder(w),
+		inFlight: map[int]context.CancelFunc{},
+	}
+	defer s.wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.reply(Response{Error: &ResponseError{Code: CodeInvalidRequest, Message: fmt.Sprintf("invalid request: %v", err)}})
+			continue
+		}
+
+		switch req.Method {
+		case "shutdown":
+			s.reply(Response{ID: req.ID, Result: map[string]bool{"ok": true}})
+			return nil
+		case "cancel":
+			var params cancelParams
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				s.reply(Response{ID: req.ID, Error: &ResponseError{Code: CodeInvalidRequest, Message: "cancel requires an id"}})
+				continue
+			}
+			s.reply(Response{ID: req.ID, Result: map[string]bool{"cancelled": s.cancel(params.ID)}})
+		case "generate", "explain":
+			s.start(ctx, req)
+		default:
+			s.reply(Response{ID: req.ID, Error: &ResponseError{Code: CodeInvalidRequest, Message: fmt.Sprintf("unknown method: %s", req.Method)}})
+		}
+	}
+
+	return scanner.Err()
+}
+
+// start runs a generate or explain request in the background
+func (s *server) start(ctx context.Context, req Request) {
+	reqCtx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.inFlight[req.ID] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.inFlight, req.ID)
+			s.mu.Unlock()
+			cancel()
+		}()
+
+		result, err := s.dispatch(reqCtx, req)
+		switch {
+		case reqCtx.Err() != nil:
+			s.reply(Response{ID: req.ID, Error: &ResponseError{Code: CodeCancelled, Message: "request cancelled"}})
+		case err != nil:
+			s.reply(Response{ID: req.ID, Error: toResponseError(err)})
+		default:
+			s.reply(Response{ID: req.ID, Result: result})
+		}
+	}()
+}
+
+// dispatch decodes the params and calls the matching handler method
+func (s *server) dispatch(ctx context.Context, req Request) (interface{}, error) {
+	switch req.Method {
+	case "generate":
+		var params GenerateParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Query == "" {
+			return nil, exit.NewError(CodeInvalidRequest, "generate requires a query")
+		}
+		return s.handler.Generate(ctx, params)
+	default:
+		var params ExplainParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Command == "" {
+			return nil, exit.NewError(CodeInvalidRequest, "explain requires a command")
+		}
+		return s.handler.Explain(ctx, params)
+	}
+}
+
+// cancel cancels an in-flight request, reporting whether it was found
+func (s *server) cancel(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cancel, ok := s.inFlight[id]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// reply writes a single response line
+func (s *server) reply(resp Response) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.encoder.Encode(resp)
+}
+
+// toResponseError converts handler errors, preserving hermes exit codes
+func toResponseError(err error) *ResponseError {
+	var exitErr exit.Error
+	if errors.As(err, &exitErr) {
+		return &ResponseError{Code: exitErr.Code, Message: exitErr.Error()}
+	}
+	return &ResponseError{Code: exit.CodeError, Message: err.Error()}
+}
@@ -0,0 +1,97 @@
+package editor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"hermes/internal/exit"
+)
+
+// fakeHandler answers generate requests immediately and blocks explain
+// requests until they are cancelled
+type fakeHandler struct{}
+
+func (fakeHandler) Generate(ctx context.Context, params GenerateParams) (interface{}, error) {
+	if params.Query == "fail" {
+		return nil, exit.NewError(exit.CodeConfig, "missing key")
+	}
+	return map[string]string{"command": "ls -la"}, nil
+}
+
+func (fakeHandler) Explain(ctx context.Context, params ExplainParams) (interface{}, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// readResponses decodes all response lines keyed by id
+func readResponses(t *testing.T, out string) map[int]Response {
+	t.Helper()
+	responses := map[int]Response{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var resp Response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response line %q: %v", line, err)
+		}
+		responses[resp.ID] = resp
+	}
+	return responses
+}
+
+func TestServe(t *testing.T) {
+	input := strings.Join([]string{
+		`{"id": 1, "method": "generate", "params": {"query": "list files"}}`,
+		`{"id": 2, "method": "generate", "params": {"query": "fail"}}`,
+		`{"id": 3, "method": "generate", "params": {}}`,
+		`{"id": 4, "method": "bogus"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := Serve(context.Background(), strings.NewReader(input), &out, fakeHandler{}); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := readResponses(t, out.String())
+	if resp := responses[1]; resp.Error != nil || resp.Result == nil {
+		t.Errorf("generate response = %+v, want result", resp)
+	}
+	if resp := responses[2]; resp.Error == nil || resp.Error.Code != exit.CodeConfig {
+		t.Errorf("failing generate response = %+v, want code %d", resp, exit.CodeConfig)
+	}
+	if resp := responses[3]; resp.Error == nil || resp.Error.Code != CodeInvalidRequest {
+		t.Errorf("empty generate response = %+v, want code %d", resp, CodeInvalidRequest)
+	}
+	if resp := responses[4]; resp.Error == nil || resp.Error.Code != CodeInvalidRequest {
+		t.Errorf("unknown method response = %+v, want code %d", resp, CodeInvalidRequest)
+	}
+}
+
+func TestServe_Cancel(t *testing.T) {
+	reader, writer := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- Serve(context.Background(), reader, &out, fakeHandler{})
+	}()
+
+	writer.Write([]byte(`{"id": 1, "method": "explain", "params": {"command": "ls"}}` + "\n"))
+	time.Sleep(10 * time.Millisecond)
+	writer.Write([]byte(`{"id": 2, "method": "cancel", "params": {"id": 1}}` + "\n"))
+	writer.Close()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	responses := readResponses(t, out.String())
+	if resp := responses[1]; resp.Error == nil || resp.Error.Code != CodeCancelled {
+		t.Errorf("cancelled explain response = %+v, want code %d", resp, CodeCancelled)
+	}
+	if resp := responses[2]; resp.Error != nil {
+		t.Errorf("cancel response = %+v, want result", resp)
+	}
+}
@@ -0,0 +1,220 @@
+// Package envref finds the environment variables a shell command
+// references and describes how well-known ones affect its behavior
+package envref
+
+import (
+	"regexp"
+	"strings"
+
+	"hermes/internal/redact"
+	"hermes/internal/shell"
+)
+
+// Variable is an environment variable referenced by a command
+type Variable struct {
+	Name     string
+	Value    string // Current value; empty when unset or redacted
+	Set      bool
+	Redacted bool   // The value looks like a secret and is withheld
+	Effect   string // How the variable affects commands; empty if unknown
+}
+
+// String formats the variable as NAME=value, with secrets and unset
+// variables marked
+func (v Variable) String() string {
+	switch {
+	case !v.Set:
+		return v.Name + " is not set (expands to an empty string)"
+	case v.Redacted:
+		return v.Name + "=<redacted>"
+	default:
+		return v.Name + "=" + v.Value
+	}
+}
+
+// secretName matches variable names that usually hold credentials
+var secretName = regexp.MustCompile(`(?i)(token|secret|passw|api_?key|access_?key|private_?key|credential|cookie)`)
+
+// effects describes how well-known variables change what commands do
+var effects = map[string]string{
+	"PATH":                           "directories searched, in order, for the programs the command runs",
+	"LD_PRELOAD":                     "shared libraries loaded into every dynamically linked program before all others; can override any library function",
+	"LD_LIBRARY_PATH":                "extra directories searched for shared libraries before the system ones",
+	"JAVA_HOME":                      "the JDK used by java tools and build systems such as Maven and Gradle",
+	"JAVA_OPTS":                      "extra JVM options (heap size, system properties) passed by many launch scripts",
+	"HOME":                           "the user's home directory; ~ expands to it and programs read their configuration from it",
+	"USER":                           "the current user name as seen by scripts",
+	"SHELL":                          "the user's login shell, used by programs that spawn a shell",
+	"EDITOR":                         "the editor programs open for editing (git commit, crontab -e, visudo)",
+	"VISUAL":                         "the full-screen editor, preferred over EDITOR by most programs",
+	"PAGER":                          "the pager used by man, git and others for long output",
+	"LANG":                           "the default locale: message language, character encoding, sorting and number formats",
+	"LC_ALL":                         "overrides every locale setting, including sort order and character classes in grep and sed",
+	"TZ":                             "the time zone used to display and parse times",
+	"TMPDIR":                         "the directory for temporary files",
+	"PWD":                            "the current directory as tracked by the shell",
+	"OLDPWD":                         "the previous directory; cd - returns to it",
+	"IFS":                            "the characters the shell splits unquoted expansions on",
+	"CDPATH":                         "directories cd searches for relative paths",
+	"HTTP_PROXY":                     "the proxy for HTTP requests made by curl, wget, package managers and others",
+	"HTTPS_PROXY":                    "the proxy for HTTPS requests",
+	"NO_PROXY":                       "hosts that bypass the proxy",
+	"http_proxy":                     "the proxy for HTTP requests made by curl, wget, package managers and others",
+	"https_proxy":                    "the proxy for HTTPS requests",
+	"no_proxy":                       "hosts that bypass the proxy",
+	"PYTHONPATH":                     "extra directories Python searches for modules, ahead of site-packages",
+	"VIRTUAL_ENV":                    "the active Python virtual environment",
+	"GOPATH":                         "the Go workspace for downloaded modules and installed binaries",
+	"GOROOT":                         "the Go installation the go tool uses",
+	"NODE_ENV":                       "the Node.js environment (production disables development behavior in many packages)",
+	"NODE_OPTIONS":                   "extra options passed to every node process",
+	"KUBECONFIG":                     "the kubeconfig files kubectl reads, which select the cluster and credentials",
+	"DOCKER_HOST":                    "the Docker daemon the docker CLI talks to; may be a remote host",
+	"AWS_PROFILE":                    "the AWS credentials profile the AWS CLI and SDKs use",
+	"AWS_REGION":                     "the default AWS region",
+	"AWS_DEFAULT_REGION":             "the default AWS region for the AWS CLI",
+	"SSH_AUTH_SOCK":                  "the ssh-agent socket used for key authentication",
+	"DISPLAY":                        "the X display graphical programs open windows on",
+	"TERM":                           "the terminal type, which controls colors and cursor handling",
+	"GIT_DIR":                        "the repository git operates on instead of discovering it",
+	"XDG_CONFIG_HOME":                "the base directory for user configuration files",
+	"SUDO_USER":                      "the user who invoked sudo",
+	"HISTFILE":                       "the file the shell saves command history to",
+	"MANPATH":                        "directories man searches for manual pages",
+	"GNUPGHOME":                      "the GnuPG keyring directory",
+	"GIT_SSH_COMMAND":                "the ssh command git uses for remote operations",
+	"PKG_CONFIG_PATH":                "extra directories pkg-config searches when building software",
+	"CC":                             "the C compiler used by make and build scripts",
+	"CFLAGS":                         "flags passed to the C compiler by make and build scripts",
+	"GOOGLE_APPLICATION_CREDENTIALS": "the service account key file Google Cloud clients authenticate with",
+}
+
+// namePattern matches a variable name at the start of a string
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+// References returns the variables a command expands, in order of first
+// use. Positional and special parameters ($1, $?) and variables the
+// command assigns itself (loop variables, read targets, assignments) are
+// left out.
+func References(command string) []string {
+	tokens, err := shell.Lex(command)
+	if err != nil {
+		return nil
+	}
+	assigned := assignedNames(command)
+
+	var names []string
+	seen := map[string]bool{}
+	for _, token := range tokens {
+		if token.Kind != shell.Word {
+			continue
+		}
+		for _, name := range expansions(token.Raw) {
+			if !seen[name] && !assigned[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// expansions returns the variable names expanded in a raw word, skipping
+// single-quoted and escaped text
+func expansions(raw string) []string {
+	var names []string
+	single, double := false, false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case single:
+			single = c != '\''
+		case c == '\\':
+			i++
+		case c == '\'' && !double:
+			single = true
+		case c == '"':
+			double = !double
+		case c == '$' && i+1 < len(raw):
+			rest := raw[i+1:]
+			if strings.HasPrefix(rest, "{") {
+				rest = strings.TrimPrefix(rest[1:], "#")
+			}
+			if name := namePattern.FindString(rest); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// assignedNames returns the variables a command sets itself: standalone
+// assignments, export/local/declare/readonly, for loops and read
+func assignedNames(command string) map[string]bool {
+	assigned := map[string]bool{}
+	script, err := shell.Parse(command)
+	if err != nil {
+		return assigned
+	}
+	for _, stage := range script.Stages() {
+		var words []string
+		for _, arg := range stage.Args {
+			words = append(words, arg.Value)
+		}
+		if len(words) == 0 {
+			continue
+		}
+		switch words[0] {
+		case "for", "select":
+			if len(words) > 1 {
+				assigned[words[1]] = true
+			}
+			continue
+		case "read":
+			for _, word := range words[1:] {
+				if !strings.HasPrefix(word, "-") {
+					assigned[word] = true
+				}
+			}
+			continue
+		case "export", "local", "declare", "typeset", "readonly":
+			words = words[1:]
+		default:
+			if !isAssignment(words[len(words)-1]) {
+				continue // NAME=value cmd: the assignment applies to cmd only
+			}
+		}
+		for _, word := range words {
+			if name, _, ok := strings.Cut(word, "="); ok && namePattern.FindString(name) == name {
+				assigned[name] = true
+			}
+		}
+	}
+	return assigned
+}
+
+// isAssignment reports whether a word is NAME=value
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	return ok && name != "" && namePattern.FindString(name) == name
+}
+
+// Resolve looks up the current values of the referenced variables with
+// lookup (os.LookupEnv outside tests). Values are withheld when the name
+// or the value looks like a credential.
+func Resolve(names []string, lookup func(string) (string, bool)) []Variable {
+	variables := make([]Variable, 0, len(names))
+	for _, name := range names {
+		value, set := lookup(name)
+		v := Variable{Name: name, Set: set, Effect: effects[name]}
+		if set {
+			if secretName.MatchString(name) || redact.New().Redact(value) != value {
+				v.Redacted = true
+			} else {
+				v.Value = value
+			}
+		}
+		variables = append(variables, v)
+	}
+	return variables
+}
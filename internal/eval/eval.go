@@ -0,0 +1,169 @@
+// Package eval checks generated commands against a suite of expectations,
+// for validating prompt or model changes
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"hermes/internal/safety"
+)
+
+// Suite is a named corpus of evaluation cases. Suites are JSON or TOML:
+//
+//	name = "basic"
+//
+//	[[case]]
+//	query = "list files"
+//	match = "^ls\\b"
+//	safety = "safe"
+type Suite struct {
+	Name  string `json:"name" koanf:"name"`
+	Cases []Case `json:"case" koanf:"case"`
+}
+
+// Case is a single query with its expectations. Every assertion that is
+// set must hold for the case to pass.
+type Case struct {
+	Query    string `json:"query" koanf:"query"`
+	Target   string `json:"target" koanf:"target"`       // Optional target shell ("posix" or "cmd")
+	Expect   string `json:"expect" koanf:"expect"`       // Exact command
+	Match    string `json:"match" koanf:"match"`         // Regex the command must match
+	NotMatch string `json:"not_match" koanf:"not_match"` // Regex the command must not match
+	Safety   string `json:"safety" koanf:"safety"`       // Expected verdict: "safe" or "attention"
+
+	match    *regexp.Regexp
+	notMatch *regexp.Regexp
+}
+
+// Load reads a suite file, chosen by extension, and compiles its regexes
+func Load(path string) (*Suite, error) {
+	var suite Suite
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read suite: %w", err)
+		}
+		if err := json.Unmarshal(data, &suite); err != nil {
+			return nil, fmt.Errorf("invalid suite %s: %w", path, err)
+		}
+	case ".toml":
+		k := koanf.New(".")
+		if err := k.Load(file.Provider(path), toml.Parser()); err != nil {
+			return nil, fmt.Errorf("failed to read suite: %w", err)
+		}
+		if err := k.Unmarshal("", &suite); err != nil {
+			return nil, fmt.Errorf("invalid suite %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported suite format %q (use .json or .toml)", filepath.Ext(path))
+	}
+
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if len(suite.Cases) == 0 {
+		return nil, fmt.Errorf("suite %s has no cases", path)
+	}
+	for i := range suite.Cases {
+		if err := suite.Cases[i].compile(); err != nil {
+			return nil, fmt.Errorf("suite %s case %d: %w", path, i+1, err)
+		}
+	}
+	return &suite, nil
+}
+
+// compile validates the case and prepares its regexes
+func (c *Case) compile() error {
+	if c.Query == "" {
+		return fmt.Errorf("missing query")
+	}
+	switch strings.ToLower(c.Safety) {
+	case "", "safe", "attention":
+	default:
+		return fmt.Errorf("unknown safety %q", c.Safety)
+	}
+	switch c.Target {
+	case "", safety.TargetPosix, safety.TargetCmd:
+	default:
+		return fmt.Errorf("unsupported target %q", c.Target)
+	}
+
+	var err error
+	if c.Match != "" {
+		if c.match, err = regexp.Compile(c.Match); err != nil {
+			return fmt.Errorf("invalid match: %w", err)
+		}
+	}
+	if c.NotMatch != "" {
+		if c.notMatch, err = regexp.Compile(c.NotMatch); err != nil {
+			return fmt.Errorf("invalid not_match: %w", err)
+		}
+	}
+	return nil
+}
+
+// Check returns the assertions the generated command violates
+func (c *Case) Check(command string, level safety.SafetyLevel) []string {
+	var failures []string
+	if c.Expect != "" && command != c.Expect {
+		failures = append(failures, fmt.Sprintf("expected %q", c.Expect))
+	}
+	if c.match != nil && !c.match.MatchString(command) {
+		failures = append(failures, fmt.Sprintf("does not match /%s/", c.Match))
+	}
+	if c.notMatch != nil && c.notMatch.MatchString(command) {
+		failures = append(failures, fmt.Sprintf("matches forbidden /%s/", c.NotMatch))
+	}
+	if c.Safety != "" && !strings.EqualFold(c.Safety, level.String()) {
+		failures = append(failures, fmt.Sprintf("safety %s, expected %s", level, strings.ToLower(c.Safety)))
+	}
+	return failures
+}
+
+// Result is the outcome of a single case
+type Result struct {
+	Case     *Case
+	Command  string
+	Level    safety.SafetyLevel
+	Failures []string
+	Err      error // Generation failed before assertions could run
+}
+
+// Passed reports whether the case met all its expectations
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Report summarizes a suite run
+type Report struct {
+	Suite   string
+	Results []Result
+}
+
+// Passed returns the number of passing cases
+func (r *Report) Passed() int {
+	passed := 0
+	for _, result := range r.Results {
+		if result.Passed() {
+			passed++
+		}
+	}
+	return passed
+}
+
+// PassRate returns the fraction of passing cases
+func (r *Report) PassRate() float64 {
+	if len(r.Results) == 0 {
+		return 0
+	}
+	return float64(r.Passed()) / float64(len(r.Results))
+}
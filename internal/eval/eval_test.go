@@ -0,0 +1,62 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"hermes/internal/safety"
+)
+
+func TestLoadBundledSuite(t *testing.T) {
+	suite, err := Load(filepath.Join("..", "..", "suites", "basic.toml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if suite.Name != "basic" || len(suite.Cases) == 0 {
+		t.Errorf("Load() = %q with %d cases, want the basic suite", suite.Name, len(suite.Cases))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	c := Case{Query: "q", Expect: "ls -la", Match: `^ls\b`, NotMatch: `rm`, Safety: "safe"}
+	if err := c.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command string
+		level   safety.SafetyLevel
+		want    []string
+	}{
+		{"ls -la", safety.Safe, nil},
+		{"ls -l", safety.Safe, []string{`expected "ls -la"`}},
+		{"rm -la", safety.Attention, []string{`expected "ls -la"`, `does not match /^ls\b/`, `matches forbidden /rm/`, "safety attention, expected safe"}},
+	}
+	for _, tt := range tests {
+		if got := c.Check(tt.command, tt.level); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Check(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidSuites(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"empty.json":     `{"case": []}`,
+		"no_query.json":  `{"case": [{"match": "ls"}]}`,
+		"bad_regex.json": `{"case": [{"query": "q", "match": "("}]}`,
+		"bad_level.json": `{"case": [{"query": "q", "safety": "dangerous"}]}`,
+		"suite.yaml":     `case: []`,
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%s) succeeded, want error", name)
+		}
+	}
+}
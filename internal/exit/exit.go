@@ -33,8 +33,46 @@ func NewError(code int, format string, a ...interface{}) Error {
 
 // Exit code constants for hermes
 const (
-	CodeSuccess   = 0  // Safe command
-	CodeError     = 1  // Generic error
-	CodeConfig    = 2  // Configuration error (missing API key, etc.)
-	CodeDangerous = 10 // Requires attention (dangerous, sudo, etc.)
-)
\ No newline at end of file
+	CodeSuccess     = 0   // Safe command
+	CodeError       = 1   // Generic error
+	CodeConfig      = 2   // Configuration error (missing API key, etc.)
+	CodeTimeout     = 3   // The AI provider did not answer in time
+	CodeRateLimit   = 4   // Rate limited by the provider or over the client-side budget
+	CodeForbidden   = 5   // Refused to produce the command (e.g., it would leak credentials)
+	CodeOffline     = 6   // Needs the network, which is off (network = "off")
+	CodeAborted     = 7   // The user declined a confirmation prompt
+	CodeDangerous   = 10  // Requires attention (dangerous, sudo, etc.)
+	CodeInterrupted = 130 // Interrupted by Ctrl-C or SIGTERM (128 + SIGINT, like shells)
+)
+
+// Info documents one exit code
+type Info struct {
+	Code    int
+	Name    string // Stable identifier for scripts, e.g. "rate-limit"
+	Meaning string
+}
+
+// Codes is the registry of every exit code hermes uses, in numeric order.
+// `hermes exit-codes` prints it and the shell integration handles each.
+var Codes = []Info{
+	{CodeSuccess, "success", "the command is safe; with shell integration it is placed in the buffer"},
+	{CodeError, "error", "generic error, such as a failed provider call or invalid input"},
+	{CodeConfig, "config", "configuration error, such as a missing API key or an invalid setting"},
+	{CodeTimeout, "timeout", "the AI provider did not answer in time"},
+	{CodeRateLimit, "rate-limit", "rate limited by the AI provider, or over the configured budget"},
+	{CodeForbidden, "forbidden", "hermes refused to produce the command, e.g. because it would leak credentials"},
+	{CodeOffline, "offline", "the request needs the network, which is off (network = \"off\")"},
+	{CodeAborted, "aborted", "the user declined a confirmation prompt"},
+	{CodeDangerous, "attention", "the command requires attention (destructive, privileged, ...); it is printed but should be reviewed"},
+	{CodeInterrupted, "interrupted", "interrupted by Ctrl-C or SIGTERM; partial output is discarded"},
+}
+
+// Lookup returns the registry entry for an exit code
+func Lookup(code int) (Info, bool) {
+	for _, info := range Codes {
+		if info.Code == code {
+			return info, true
+		}
+	}
+	return Info{}, false
+}
@@ -0,0 +1,166 @@
+// Package exitstatus interprets the exit status of a failed command from a
+// built-in table of shell conventions, signals and command-specific codes
+package exitstatus
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// signals names the common signals by number (Linux numbering)
+var signals = map[int]string{
+	1:  "SIGHUP: the terminal or session went away",
+	2:  "SIGINT: interrupted, usually by Ctrl-C",
+	3:  "SIGQUIT: quit, usually by Ctrl-\\",
+	4:  "SIGILL: illegal instruction, often a binary built for another CPU",
+	6:  "SIGABRT: the program aborted itself, typically a failed assertion",
+	7:  "SIGBUS: bus error, e.g. a truncated memory-mapped file",
+	8:  "SIGFPE: arithmetic error such as division by zero",
+	9:  "SIGKILL: killed forcibly; commonly the kernel OOM killer, a container memory limit, kill -9 or timeout -s KILL",
+	11: "SIGSEGV: segmentation fault, the program crashed",
+	13: "SIGPIPE: it wrote to a pipe whose reader had exited (harmless after head and similar)",
+	14: "SIGALRM: an alarm timer expired",
+	15: "SIGTERM: asked to terminate, e.g. by kill, systemctl stop, docker stop or timeout",
+	24: "SIGXCPU: CPU time limit exceeded (ulimit -t)",
+	25: "SIGXFSZ: file size limit exceeded (ulimit -f)",
+}
+
+// specific lists command-specific exit statuses
+var specific = map[string]map[int]string{
+	"grep":  {1: "no lines matched (not an error)", 2: "an error occurred, e.g. a missing file or invalid pattern"},
+	"egrep": {1: "no lines matched (not an error)", 2: "an error occurred"},
+	"rg":    {1: "no matches found (not an error)", 2: "an error occurred"},
+	"diff":  {1: "the inputs differ (not an error)", 2: "trouble, e.g. a missing file"},
+	"cmp":   {1: "the files differ (not an error)", 2: "trouble, e.g. a missing file"},
+	"test":  {1: "the condition is false"},
+	"[":     {1: "the condition is false"},
+	"curl": {
+		3: "malformed URL", 6: "could not resolve the host", 7: "failed to connect to the host",
+		22: "the server returned an HTTP error (400 or above) and -f was given", 23: "failed to write output",
+		28: "the operation timed out", 35: "TLS/SSL handshake failed", 47: "too many redirects",
+		52: "the server sent an empty reply", 56: "failure receiving network data", 60: "the server certificate could not be verified",
+	},
+	"wget": {
+		1: "generic error", 2: "command-line parse error", 3: "file I/O error", 4: "network failure",
+		5: "TLS verification failure", 6: "authentication failure", 7: "protocol error", 8: "the server issued an error response (e.g. 404)",
+	},
+	"timeout": {124: "the command timed out", 125: "timeout itself failed", 126: "the command could not be run", 127: "the command was not found"},
+	"git":     {1: "generic failure (e.g. merge conflicts or nothing to commit)", 128: "fatal error, e.g. not a repository, bad revision or authentication failure", 129: "invalid usage"},
+	"ssh":     {255: "ssh itself failed: connection refused, host unreachable or authentication failed"},
+	"scp":     {1: "the copy failed", 255: "the connection failed"},
+	"rsync": {
+		1: "syntax or usage error", 2: "protocol incompatibility", 3: "errors selecting input/output files",
+		5: "error starting the client-server protocol", 10: "error in socket I/O", 11: "error in file I/O",
+		12: "error in the rsync protocol data stream", 23: "partial transfer due to errors", 24: "partial transfer: source files vanished",
+		30: "timeout in data send/receive", 35: "timeout waiting for a daemon connection", 255: "the remote shell (ssh) failed",
+	},
+	"tar":       {1: "some files differed or changed while being read", 2: "fatal error"},
+	"make":      {1: "make -q: targets are out of date", 2: "a rule failed or there was an error"},
+	"systemctl": {1: "the unit failed or the operation was refused", 3: "the unit is not active", 4: "no such unit", 5: "the unit is not loaded"},
+	"ping":      {1: "no reply was received", 2: "another error, e.g. unknown host"},
+	"find":      {1: "some paths could not be read (e.g. permission denied); results may be partial"},
+	"docker": {
+		125: "the docker daemon failed to start the container (bad flags, missing image, port in use)",
+		126: "the container command could not be invoked (permission problem or not executable)",
+		127: "the container command was not found in the image",
+		137: "the container was killed with SIGKILL: check docker inspect --format '{{.State.OOMKilled}}' for an out-of-memory kill",
+		139: "the container process crashed with a segmentation fault",
+		143: "the container was stopped with SIGTERM, e.g. by docker stop",
+	},
+	"podman": {125: "podman itself failed", 126: "the container command could not be invoked", 127: "the container command was not found"},
+	"kubectl": {
+		1:   "the request failed (see the error message)",
+		137: "the process was killed with SIGKILL; for pods check kubectl describe pod for OOMKilled",
+	},
+	"java":    {137: "the JVM was killed with SIGKILL, frequently by the OOM killer when the heap exceeds the container limit"},
+	"npm":     {1: "a script or install step failed", 243: "npm itself crashed or was interrupted"},
+	"python":  {1: "an uncaught exception (see the traceback)", 2: "command-line usage error"},
+	"python3": {1: "an uncaught exception (see the traceback)", 2: "command-line usage error"},
+	"apt":     {100: "apt failed: a package could not be found, downloaded or configured"},
+	"apt-get": {100: "apt failed: a package could not be found, downloaded or configured"},
+}
+
+// wrappers run the command that follows them
+var wrappers = map[string]bool{
+	"sudo": true, "doas": true, "env": true, "nohup": true, "nice": true, "time": true, "exec": true, "command": true,
+}
+
+// Describe interprets the exit status of a command. It returns one line
+// per applicable explanation, most specific first; statuses outside 0-255
+// are rejected.
+func Describe(command string, code int) ([]string, error) {
+	if code < 0 || code > 255 {
+		return nil, fmt.Errorf("exit status %d is out of range (0-255)", code)
+	}
+	name, pipeline := commandName(command)
+
+	var lines []string
+	if pipeline {
+		lines = append(lines, "this is a pipeline: its status is the last command's unless set -o pipefail is on")
+	}
+	if meaning, ok := specific[name][code]; ok {
+		lines = append(lines, name+": "+meaning)
+		if code <= 128 || code == 255 {
+			return lines, nil // The generic meaning would only contradict it
+		}
+	}
+
+	switch {
+	case code == 0:
+		lines = append(lines, "success")
+	case code == 1:
+		lines = append(lines, "general error: the command reported a failure")
+	case code == 2:
+		lines = append(lines, "misuse: usually invalid arguments or options")
+	case code == 126:
+		lines = append(lines, "the command was found but could not be executed: missing execute permission, a directory, or a wrong interpreter line")
+	case code == 127:
+		lines = append(lines, "command not found: check the spelling, PATH, or whether the program is installed")
+	case code == 128:
+		lines = append(lines, "invalid exit argument, or a program-specific fatal error")
+	case code > 128 && code < 128+65:
+		signal := code - 128
+		if description, ok := signals[signal]; ok {
+			lines = append(lines, fmt.Sprintf("killed by signal %d (%s)", signal, description))
+		} else {
+			lines = append(lines, fmt.Sprintf("killed by signal %d", signal))
+		}
+	case code == 255:
+		lines = append(lines, "exit status out of range, or a program-specific fatal error (ssh uses it for connection failures)")
+	default:
+		lines = append(lines, "a program-specific failure code; see the command's documentation (EXIT STATUS in its man page)")
+	}
+	return lines, nil
+}
+
+// commandName returns the program whose status the shell reports (the
+// last stage of the last pipeline, without wrappers or a path) and whether
+// that pipeline has several stages
+func commandName(command string) (string, bool) {
+	script, err := shell.Parse(command)
+	if err != nil || len(script.Pipelines) == 0 {
+		return "", false
+	}
+	pipeline := script.Pipelines[len(script.Pipelines)-1]
+	stage := pipeline.Stages[len(pipeline.Stages)-1]
+
+	var words []string
+	for _, arg := range stage.Args {
+		words = append(words, arg.Value)
+	}
+	for len(words) > 0 {
+		word := words[0]
+		if strings.Contains(word, "=") || strings.HasPrefix(word, "-") || wrappers[filepath.Base(word)] {
+			words = words[1:]
+			continue
+		}
+		break
+	}
+	if len(words) == 0 {
+		return "", len(pipeline.Stages) > 1
+	}
+	return filepath.Base(words[0]), len(pipeline.Stages) > 1
+}
@@ -0,0 +1,46 @@
+package exitstatus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		command string
+		code    int
+		want    []string
+	}{
+		{"docker run --rm -m 64m app", 137, []string{"docker: the container was killed with SIGKILL", "killed by signal 9 (SIGKILL"}},
+		{"sudo /usr/bin/grep -r TODO src", 1, []string{"grep: no lines matched"}},
+		{"curl -fsS https://example.com", 22, []string{"curl: the server returned an HTTP error"}},
+		{"frobnicate --all", 127, []string{"command not found"}},
+		{"./build.sh", 126, []string{"could not be executed"}},
+		{"make | tee log", 0, []string{"this is a pipeline", "success"}},
+		{"sleep 100", 130, []string{"killed by signal 2 (SIGINT"}},
+		{"myapp", 42, []string{"program-specific failure code"}},
+	}
+	for _, tt := range tests {
+		got, err := Describe(tt.command, tt.code)
+		if err != nil {
+			t.Fatalf("Describe(%q, %d) error: %v", tt.command, tt.code, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Describe(%q, %d) = %q, want %d lines", tt.command, tt.code, got, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.HasPrefix(got[i], want) && !strings.Contains(got[i], want) {
+				t.Errorf("Describe(%q, %d)[%d] = %q, want it to contain %q", tt.command, tt.code, i, got[i], want)
+			}
+		}
+	}
+}
+
+func TestDescribeRejectsOutOfRange(t *testing.T) {
+	for _, code := range []int{-1, 256} {
+		if _, err := Describe("ls", code); err == nil {
+			t.Errorf("Describe(ls, %d) succeeded, want an error", code)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Package explaincache keeps AI explanations on disk, keyed by the
+// normalized command and the model that wrote them, so explaining the same
+// command again is instant and free
+package explaincache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"hermes/internal/redact"
+	"hermes/internal/shell"
+)
+
+// entry is one cached explanation
+type entry struct {
+	Command     string `json:"command"` // Normalized, for hermes cache list
+	Model       string `json:"model"`
+	Explanation string `json:"explanation"`
+	Created     int64  `json:"created"` // Unix time the explanation was written
+	Used        int64  `json:"used"`    // Unix time it was last served
+	Hits        int    `json:"hits"`    // Times it was served instead of asking the provider
+}
+
+// Listing describes one cached explanation for hermes cache list
+type Listing struct {
+	Command string
+	Model   string
+	Created time.Time
+	Used    time.Time
+	Hits    int
+	Bytes   int // Space the entry takes in the cache file
+}
+
+// Stats summarizes the cache for hermes cache stats
+type Stats struct {
+	Entries int
+	Bytes   int64     // Size of the cache file
+	Hits    int       // Provider calls saved so far
+	Oldest  time.Time // When the oldest entry was written; zero when empty
+}
+
+// Limits bound the cache; the least recently used entries are evicted
+// first. Zero values do not limit.
+type Limits struct {
+	MaxEntries int
+	MaxBytes   int           // Total size of the entries
+	MaxAge     time.Duration // Entries unused for longer are dropped
+}
+
+// Cache stores explanations in a file shared by all hermes processes. The
+// last writer wins when two processes add entries at the same time; the
+// lost entry is simply asked for again.
+type Cache struct {
+	path   string
+	limits Limits
+	now    func() time.Time
+}
+
+// New returns a cache keeping its entries in the file at path within limits
+func New(path string, limits Limits) *Cache {
+	return &Cache{path: path, limits: limits, now: time.Now}
+}
+
+// DefaultPath returns the cache file under the XDG cache directory
+func DefaultPath() string {
+	if cache := os.Getenv("XDG_CACHE_HOME"); cache != "" {
+		return filepath.Join(cache, "hermes", "explain_cache.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "hermes", "explain_cache.json")
+}
+
+// Key identifies the explanation of a command by a model. Commands that
+// differ only in the spacing between words share a key; anything else
+// that shapes the answer (the reader's experience level) goes in variant.
+func Key(command, model, variant string) string {
+	sum := sha256.Sum256([]byte(Normalize(command) + "\x00" + model + "\x00" + variant))
+	return hex.EncodeToString(sum[:])
+}
+
+// Normalize collapses the whitespace between the words of a command,
+// leaving quoted text alone
+func Normalize(command string) string {
+	tokens, err := shell.Lex(command)
+	if err != nil {
+		return strings.TrimSpace(command)
+	}
+	raw := make([]string, len(tokens))
+	for i, token := range tokens {
+		raw[i] = token.Raw
+	}
+	return strings.Join(raw, " ")
+}
+
+// Get returns the cached explanation for key and records the hit
+func (c *Cache) Get(key string) (string, bool) {
+	all, err := c.load()
+	if err != nil {
+		return "", false
+	}
+	e, found := all[key]
+	if !found || c.limits.MaxAge > 0 && e.Used < c.now().Add(-c.limits.MaxAge).Unix() {
+		return "", false
+	}
+	e.Used = c.now().Unix()
+	e.Hits++
+	all[key] = e
+	_ = c.save(all) // Only the statistics are lost
+	return e.Explanation, true
+}
+
+// Put caches the explanation of command by model under key, evicting the
+// least recently used entries beyond the limits. The command is kept with
+// its credentials masked, for listing only; key identifies the entry.
+func (c *Cache) Put(key, command, model, explanation string) error {
+	all, err := c.load()
+	if err != nil {
+		// A corrupt file only loses cached explanations
+		all = map[string]entry{}
+	}
+	now := c.now().Unix()
+	all[key] = entry{Command: redact.New().Redact(Normalize(command)), Model: model, Explanation: explanation, Created: now, Used: now}
+	c.evict(all)
+	return c.save(all)
+}
+
+// GC drops the entries beyond the limits and returns how many it dropped
+func (c *Cache) GC() (int, error) {
+	all, err := c.load()
+	if err != nil {
+		return 0, err
+	}
+	removed := c.evict(all)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, c.save(all)
+}
+
+// Clear removes every cached explanation
+func (c *Cache) Clear() error {
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear explain cache: %w", err)
+	}
+	return nil
+}
+
+// List returns the cached explanations, most recently used first
+func (c *Cache) List() ([]Listing, error) {
+	all, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	listings := make([]Listing, 0, len(all))
+	for _, e := range all {
+		listings = append(listings, Listing{
+			Command: e.Command,
+			Model:   e.Model,
+			Created: time.Unix(e.Created, 0),
+			Used:    time.Unix(e.Used, 0),
+			Hits:    e.Hits,
+			Bytes:   e.size(),
+		})
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		if !listings[i].Used.Equal(listings[j].Used) {
+			return listings[i].Used.After(listings[j].Used)
+		}
+		return listings[i].Command < listings[j].Command
+	})
+	return listings, nil
+}
+
+// evict drops entries unused for longer than MaxAge, then the least
+// recently used ones until the cache fits MaxEntries and MaxBytes. It
+// returns how many entries it dropped.
+func (c *Cache) evict(all map[string]entry) int {
+	removed := 0
+	if c.limits.MaxAge > 0 {
+		cutoff := c.now().Add(-c.limits.MaxAge).Unix()
+		for key, e := range all {
+			if e.Used < cutoff {
+				delete(all, key)
+				removed++
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(all))
+	total := 0
+	for key, e := range all {
+		keys = append(keys, key)
+		total += e.size()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if all[keys[i]].Used != all[keys[j]].Used {
+			return all[keys[i]].Used < all[keys[j]].Used
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		overEntries := c.limits.MaxEntries > 0 && len(all) > c.limits.MaxEntries
+		overBytes := c.limits.MaxBytes > 0 && total > c.limits.MaxBytes
+		if !overEntries && !overBytes {
+			break
+		}
+		total -= all[key].size()
+		delete(all, key)
+		removed++
+	}
+	return removed
+}
+
+// size is the space an entry takes in the cache file, near enough
+func (e entry) size() int {
+	return len(e.Command) + len(e.Model) + len(e.Explanation) + 120 // Key, field names and numbers
+}
+
+// Stats summarizes the cached explanations
+func (c *Cache) Stats() (Stats, error) {
+	all, err := c.load()
+	if err != nil {
+		return Stats{}, err
+	}
+	stats := Stats{Entries: len(all)}
+	if info, err := os.Stat(c.path); err == nil {
+		stats.Bytes = info.Size()
+	}
+	for _, e := range all {
+		stats.Hits += e.Hits
+		if created := time.Unix(e.Created, 0); stats.Oldest.IsZero() || created.Before(stats.Oldest) {
+			stats.Oldest = created
+		}
+	}
+	return stats, nil
+}
+
+// load reads the cache file; a missing file means nothing is cached yet
+func (c *Cache) load() (map[string]entry, error) {
+	all := map[string]entry{}
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read explain cache file: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("invalid explain cache file %s: %w", c.path, err)
+	}
+	return all, nil
+}
+
+// save writes the cache file atomically
+func (c *Cache) save(all map[string]entry) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create explain cache directory: %w", err)
+	}
+	data, _ := json.Marshal(all) // Only plain values, cannot fail
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save explain cache file: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,121 @@
+package explaincache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	cache := New(filepath.Join(t.TempDir(), "explain_cache.json"), Limits{})
+	cache.now = func() time.Time { return now }
+
+	key := Key("tar  -xzf  a.tgz", "gemini/gemini-2.5-flash", "intermediate")
+	if _, ok := cache.Get(key); ok {
+		t.Fatal("Get() found an explanation in an empty cache")
+	}
+	if err := cache.Put(key, "tar  -xzf  a.tgz", "gemini/gemini-2.5-flash", "'tar' extracts a.tgz"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got, ok := cache.Get(Key("tar -xzf a.tgz", "gemini/gemini-2.5-flash", "intermediate")); !ok || got != "'tar' extracts a.tgz" {
+		t.Errorf("Get() of the same command = %q, %v, want the cached explanation", got, ok)
+	}
+	for _, other := range []string{
+		Key("tar -xzf a.tgz", "ollama/qwen", "intermediate"),
+		Key("tar -xzf a.tgz", "gemini/gemini-2.5-flash", "beginner"),
+		Key("tar -xzf b.tgz", "gemini/gemini-2.5-flash", "intermediate"),
+	} {
+		if _, ok := cache.Get(other); ok {
+			t.Errorf("Get(%s) found an explanation written for another command, model or level", other)
+		}
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Entries != 1 || stats.Hits != 1 || stats.Bytes == 0 || !stats.Oldest.Equal(now) {
+		t.Errorf("Stats() = %+v, want one entry served once", stats)
+	}
+}
+
+func TestCacheMasksCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "explain_cache.json")
+	cache := New(path, Limits{})
+
+	command := "curl -H 'Authorization: Bearer s3cr3t-t0ken-value' https://api.example.com"
+	key := Key(command, "m", "")
+	if err := cache.Put(key, command, "m", "'curl' fetches the URL"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got, ok := cache.Get(key); !ok || got != "'curl' fetches the URL" {
+		t.Errorf("Get() = %q, %v, want the explanation under the original key", got, ok)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cr3t") {
+		t.Errorf("cache file keeps the token:\n%s", data)
+	}
+	listings, err := cache.List()
+	if err != nil || len(listings) != 1 || !strings.Contains(listings[0].Command, "Bearer __SECRET_1__") {
+		t.Errorf("List() = %+v, %v, want the masked command", listings, err)
+	}
+}
+
+func TestCacheLimits(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	cache := New(filepath.Join(t.TempDir(), "explain_cache.json"), Limits{MaxEntries: 2, MaxAge: 24 * time.Hour})
+	cache.now = func() time.Time { return now }
+
+	for _, command := range []string{"ls", "pwd", "df"} {
+		if err := cache.Put(Key(command, "m", ""), command, "m", "explains "+command); err != nil {
+			t.Fatalf("Put(%s) error = %v", command, err)
+		}
+		now = now.Add(time.Minute)
+		if command == "pwd" {
+			cache.Get(Key("ls", "m", "")) // ls is now used more recently than pwd
+		}
+	}
+	listings, err := cache.List()
+	if err != nil || len(listings) != 2 || listings[0].Command != "df" || listings[1].Command != "ls" {
+		t.Fatalf("List() = %+v, %v, want df and ls with the least recently used pwd evicted", listings, err)
+	}
+
+	// Entries unused for longer than MaxAge are misses and dropped by GC
+	now = now.Add(24*time.Hour - 2*time.Minute)
+	cache.Get(Key("df", "m", ""))
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get(Key("ls", "m", "")); ok {
+		t.Error("Get() served an entry unused for longer than MaxAge")
+	}
+	if removed, err := cache.GC(); err != nil || removed != 1 {
+		t.Errorf("GC() = %d, %v, want the stale ls entry removed", removed, err)
+	}
+
+	cache.limits.MaxBytes = 1
+	if removed, err := cache.GC(); err != nil || removed != 1 {
+		t.Errorf("GC() with a lowered byte limit = %d, %v, want the last entry evicted", removed, err)
+	}
+	if err := cache.Clear(); err != nil {
+		t.Errorf("Clear() error = %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"  ls   -la  ":         "ls -la",
+		"grep 'a  b'   file":   "grep 'a  b' file",
+		"echo 'unterminated  ": "echo 'unterminated",
+	}
+	for command, want := range tests {
+		if got := Normalize(command); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package feedback keeps the user's ratings of generated commands on this
+// machine and turns relevant past corrections into prompt context. Nothing
+// leaves the machine except as part of a later prompt.
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"hermes/internal/redact"
+)
+
+// maxEntries bounds how many ratings are kept; the oldest are dropped
+const maxEntries = 200
+
+// ErrNoGeneration is returned when rating before anything was generated
+var ErrNoGeneration = errors.New("nothing to rate yet: generate a command first")
+
+// Generation is a query and the command hermes generated for it
+type Generation struct {
+	Query   string    `json:"query"`
+	Command string    `json:"command"`
+	Time    time.Time `json:"time"`
+}
+
+// Entry is a rated generation
+type Entry struct {
+	Generation
+	Good bool   `json:"good"`
+	Note string `json:"note,omitempty"`
+}
+
+// Store holds the last generation and past ratings
+type Store struct {
+	path string
+
+	Last    *Generation `json:"last,omitempty"`
+	Entries []Entry     `json:"entries"`
+}
+
+// DefaultPath returns the feedback file under the XDG state directory
+func DefaultPath() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "hermes", "feedback.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "hermes", "feedback.json")
+}
+
+// Load reads the feedback at path; a missing file starts empty
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read feedback file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("invalid feedback file %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// SetLast remembers the latest generation so it can be rated. Credentials
+// are masked so the file never holds secrets.
+func (s *Store) SetLast(query, command string) {
+	r := redact.New()
+	s.Last = &Generation{Query: r.Redact(query), Command: r.Redact(command), Time: time.Now()}
+}
+
+// Rate records a rating for the last generation. Rating the same
+// generation again replaces the earlier rating.
+func (s *Store) Rate(good bool, note string) (Entry, error) {
+	if s.Last == nil {
+		return Entry{}, ErrNoGeneration
+	}
+	entry := Entry{Generation: *s.Last, Good: good, Note: redact.New().Redact(strings.TrimSpace(note))}
+	for i, existing := range s.Entries {
+		if existing.Generation == entry.Generation {
+			s.Entries = append(s.Entries[:i], s.Entries[i+1:]...)
+			break
+		}
+	}
+	s.Entries = append(s.Entries, entry)
+	if len(s.Entries) > maxEntries {
+		s.Entries = s.Entries[len(s.Entries)-maxEntries:]
+	}
+	return entry, nil
+}
+
+// Relevant returns up to n ratings whose queries share the most words with
+// query, most recent first among equals
+func (s *Store) Relevant(query string, n int) []Entry {
+	queryWords := words(query)
+	type scored struct {
+		entry Entry
+		score int
+		index int
+	}
+	var matches []scored
+	for i, entry := range s.Entries {
+		score := 0
+		for word := range words(entry.Query) {
+			if queryWords[word] {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{entry, score, i})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].index > matches[j].index
+	})
+
+	var result []Entry
+	for _, m := range matches {
+		if len(result) == n {
+			break
+		}
+		result = append(result, m.entry)
+	}
+	return result
+}
+
+// Context describes past ratings for the prompt, or returns "" without any
+func Context(entries []Entry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	lines := []string{"The user rated these earlier commands for similar requests; follow their corrections:"}
+	for _, entry := range entries {
+		rating := "good"
+		if !entry.Good {
+			rating = "bad"
+		}
+		line := fmt.Sprintf("- %q -> %s (rated %s)", entry.Query, entry.Command, rating)
+		if entry.Note != "" {
+			line += ": " + entry.Note
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Save writes the feedback back to its file
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create feedback directory: %w", err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save feedback file: %w", err)
+	}
+	return nil
+}
+
+// stopWords carry no meaning for matching queries
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "in": true, "of": true, "to": true,
+	"for": true, "all": true, "and": true, "with": true, "my": true, "on": true,
+}
+
+// words returns the lowercase words of a query, without stop words
+func words(query string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+	}) {
+		if !stopWords[word] {
+			set[word] = true
+		}
+	}
+	return set
+}
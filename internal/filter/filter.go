@@ -0,0 +1,217 @@
+// Package filter checks and test-runs jq, awk and sed programs on sample
+// input, so a generated data transformation can be tried before it is used
+package filter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"hermes/internal/shell"
+)
+
+// Limits on a test run
+const (
+	runTimeout = 5 * time.Second
+	maxOutput  = 64 << 10
+)
+
+// ErrUnsafe is returned by Run for programs that could run commands or
+// write files and cannot be sandboxed by the installed tool
+var ErrUnsafe = errors.New("program may run commands or write files")
+
+// tools maps the supported program names to their family
+var tools = map[string]string{
+	"jq":   "jq",
+	"awk":  "awk",
+	"gawk": "awk",
+	"mawk": "awk",
+	"nawk": "awk",
+	"sed":  "sed",
+	"gsed": "sed",
+}
+
+// arity is the number of arguments each option takes, per family
+var arity = map[string]map[string]int{
+	"jq": {
+		"-f": 1, "--from-file": 1, "-L": 1, "--indent": 1,
+		"--arg": 2, "--argjson": 2, "--slurpfile": 2, "--rawfile": 2,
+	},
+	"awk": {"-F": 1, "-v": 1, "-f": 1},
+	"sed": {"-e": 1, "-f": 1, "--expression": 1, "--file": 1, "-l": 1},
+}
+
+// programFiles are the options that supply the program instead of the
+// first operand
+var programFiles = map[string]bool{
+	"-f": true, "--from-file": true, "--file": true, "-e": true, "--expression": true,
+}
+
+// Program is a single jq, awk or sed invocation that reads standard input
+type Program struct {
+	Name   string   // Program name as written (jq, awk, gsed, ...)
+	Family string   // "jq", "awk" or "sed"
+	Args   []string // Arguments after the name, unquoted
+	Script string   // The program text, when given inline
+}
+
+// Prepare checks that command is one jq, awk or sed invocation without
+// pipes, redirections, in-place edits or file operands, so running it on a
+// sample shows what it does to input on standard input
+func Prepare(command string) (*Program, error) {
+	tokens, err := shell.Lex(command)
+	if err != nil {
+		return nil, err
+	}
+	var words []string
+	for _, token := range tokens {
+		if token.Kind != shell.Word {
+			return nil, fmt.Errorf("expected a single command, found %q", token.Value)
+		}
+		words = append(words, token.Value)
+	}
+	if len(words) == 0 {
+		return nil, errors.New("empty command")
+	}
+
+	family, ok := tools[words[0]]
+	if !ok {
+		return nil, fmt.Errorf("expected a jq, awk or sed command, found %q", words[0])
+	}
+	p := &Program{Name: words[0], Family: family, Args: words[1:]}
+
+	var operands []string
+	inline := true
+	options := true
+	for i := 0; i < len(p.Args); i++ {
+		arg := p.Args[i]
+		if !options || arg == "-" || !strings.HasPrefix(arg, "-") {
+			operands = append(operands, arg)
+			continue
+		}
+		if arg == "--" {
+			options = false
+			continue
+		}
+		if family == "jq" && (arg == "--args" || arg == "--jsonargs") {
+			break // The rest are positional arguments, not files
+		}
+		option, value, attached := splitOption(family, arg)
+		if family == "sed" && (option == "--in-place" || !strings.HasPrefix(arg, "--") && strings.ContainsRune(arg[:len(arg)-len(value)], 'i')) {
+			return nil, errors.New("sed -i edits files in place")
+		}
+		if programFiles[option] {
+			inline = false
+			if family == "sed" && option != "-f" && option != "--file" {
+				if attached {
+					p.Script = value
+				} else if i+1 < len(p.Args) {
+					p.Script = p.Args[i+1]
+				}
+			}
+		}
+		if n := arity[family][option]; n > 0 && !attached {
+			i += n
+		}
+	}
+
+	if inline {
+		if len(operands) == 0 {
+			return nil, fmt.Errorf("%s has no program", p.Name)
+		}
+		p.Script, operands = operands[0], operands[1:]
+	}
+	for _, operand := range operands {
+		if family == "awk" && strings.Contains(operand, "=") {
+			continue // var=value assignment
+		}
+		return nil, fmt.Errorf("reads %q instead of standard input", operand)
+	}
+	return p, nil
+}
+
+// splitOption splits an option argument into the option and an attached
+// value: --file=x gives --file and x, -ne 'p' gives -e, and -F, gives -F
+// and ",". Clusters of flags without values are returned whole.
+func splitOption(family, arg string) (option, value string, attached bool) {
+	if strings.HasPrefix(arg, "--") {
+		return strings.Cut(arg, "=")
+	}
+	for i := 1; i < len(arg); i++ {
+		if short := "-" + arg[i:i+1]; arity[family][short] > 0 {
+			return short, arg[i+1:], i+1 < len(arg)
+		}
+	}
+	return arg, "", false
+}
+
+// unsafeAwk and unsafeSed match constructs that run commands or write
+// files, for tools without a sandbox option
+var (
+	unsafeAwk = regexp.MustCompile(`system|getline|\||>`)
+	unsafeSed = regexp.MustCompile(`[wWe](\s|$)|[rR]\s`)
+)
+
+// Run executes the program with sample on standard input and returns its
+// output. GNU awk and sed run with --sandbox; other awk and sed
+// implementations only run programs that cannot run commands or write
+// files, and ErrUnsafe is returned for the rest.
+func (p *Program) Run(ctx context.Context, sample []byte) (string, error) {
+	args := p.Args
+	if p.Family != "jq" {
+		if gnu(ctx, p.Name) {
+			args = append([]string{"--sandbox"}, args...)
+		} else if (p.Family == "awk" && unsafeAwk.MatchString(p.Script)) ||
+			(p.Family == "sed" && unsafeSed.MatchString(p.Script)) {
+			return "", ErrUnsafe
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Name, args...)
+	cmd.Stdin = bytes.NewReader(sample)
+	cmd.Stdout = &limitedWriter{&stdout, maxOutput}
+	cmd.Stderr = &limitedWriter{&stderr, maxOutput}
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("timed out after %s", runTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return stdout.String(), errors.New(message)
+		}
+		return stdout.String(), err
+	}
+	return stdout.String(), nil
+}
+
+// gnu reports whether an awk or sed is the GNU implementation, which
+// supports --sandbox
+var gnu = func(ctx context.Context, name string) bool {
+	out, err := exec.CommandContext(ctx, name, "--version").Output()
+	return err == nil && bytes.Contains(out, []byte("GNU"))
+}
+
+// limitedWriter keeps the first n bytes written and discards the rest
+type limitedWriter struct {
+	buf *bytes.Buffer
+	n   int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if room := w.n - w.buf.Len(); room > 0 {
+		if len(p) > room {
+			w.buf.Write(p[:room])
+		} else {
+			w.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestPrepare(t *testing.T) {
+	tests := []struct {
+		command string
+		script  string
+		wantErr bool
+	}{
+		{`jq -r '.items[].name'`, ".items[].name", false},
+		{`jq --arg env prod '.[] | select(.env == $env)'`, ".[] | select(.env == $env)", false},
+		{`awk -F, '{ print $2 }'`, "{ print $2 }", false},
+		{`awk -F , -v OFS=: '{ $1 = $1; print }'`, "{ $1 = $1; print }", false},
+		{`awk '{ print x }' x=1`, "{ print x }", false},
+		{`sed -n 's/^id=//p'`, "s/^id=//p", false},
+		{`sed -ne 's/a/b/p'`, "s/a/b/p", false},
+		{`sed --expression='s/a/b/'`, "s/a/b/", false},
+		{`jq . data.json`, "", true},            // Reads a file
+		{`sed -i 's/a/b/'`, "", true},           // Edits in place
+		{`sed -ie 's/a/b/'`, "", true},          // Edits in place
+		{`jq .name | head -1`, "", true},        // Pipeline
+		{`awk '{ print }' > out.txt`, "", true}, // Redirection
+		{`grep foo`, "", true},                  // Not a filter tool
+		{`jq`, "", true},                        // No program
+	}
+	for _, tt := range tests {
+		p, err := Prepare(tt.command)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Prepare(%q) = %+v, want an error", tt.command, p)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Prepare(%q) error: %v", tt.command, err)
+			continue
+		}
+		if p.Script != tt.script {
+			t.Errorf("Prepare(%q).Script = %q, want %q", tt.command, p.Script, tt.script)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not installed")
+	}
+	p, err := Prepare(`jq -r '.[].name'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Run(context.Background(), []byte(`[{"name": "a"}, {"name": "b"}]`))
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got != "a\nb\n" {
+		t.Errorf("Run() = %q, want %q", got, "a\nb\n")
+	}
+
+	if _, err := p.Run(context.Background(), []byte("not json")); err == nil {
+		t.Error("Run() on invalid input succeeded, want the jq error")
+	}
+}
+
+func TestRunRefusesUnsandboxedCommands(t *testing.T) {
+	orig := gnu
+	t.Cleanup(func() { gnu = orig })
+	gnu = func(ctx context.Context, name string) bool { return false }
+
+	for _, command := range []string{
+		`awk '{ system("rm -rf /") }'`,
+		`awk '{ print > "out" }'`,
+		`sed 'w out'`,
+		`sed 's/a/b/e'`,
+	} {
+		p, err := Prepare(command)
+		if err != nil {
+			t.Fatalf("Prepare(%q) error: %v", command, err)
+		}
+		if _, err := p.Run(context.Background(), []byte("a\n")); !errors.Is(err, ErrUnsafe) {
+			t.Errorf("Run(%q) error = %v, want ErrUnsafe", command, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package flagdb - annotating each part of a command
+package flagdb
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"hermes/internal/shell"
+)
+
+// Part is one marked part of a command: a command name, flag, operand,
+// redirection or operator
+type Part struct {
+	Raw     string // Exact source text, e.g. "-czf" or "> out.txt"
+	Pos     int    // Byte offset of Raw in the command
+	Command string // The command the part belongs to, empty for operators
+	Text    string // What the part does; empty when the database does not know
+}
+
+// controlOperators describes the operators that join commands
+var controlOperators = map[string]string{
+	"|":  "pipes the output into the next command",
+	"|&": "pipes the output and errors into the next command",
+	"&&": "runs the next command only if this one succeeds",
+	"||": "runs the next command only if this one fails",
+	";":  "runs the next command after this one",
+	";;": "ends a case branch",
+	"&":  "runs the command in the background",
+	"(":  "starts a group of commands run in a subshell",
+	")":  "ends the subshell group",
+}
+
+// redirectOperators take the following word as their target
+var redirectOperators = map[string]bool{
+	">": true, ">>": true, "<": true, "2>": true, "2>>": true, "&>": true, "&>>": true,
+	">&": true, "<&": true, "<<": true, "<<-": true, "<<<": true, ">|": true, "<>": true,
+}
+
+// Annotate splits a command into the parts worth a marker and describes
+// each from the database. Parts it does not know keep an empty Text, for
+// the AI to fill in. It reports false when the command does not lex.
+func Annotate(command string) ([]Part, bool) {
+	tokens, err := shell.Lex(command)
+	if err != nil || len(tokens) == 0 {
+		return nil, false
+	}
+
+	var parts []Part
+	var name, valueOf string
+	var entry Entry
+	commandPosition := true
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		part := Part{Raw: token.Raw, Pos: token.Pos}
+		switch {
+		case token.Kind == shell.Comment:
+			part.Text = "is a comment, ignored by the shell"
+		case token.Kind == shell.HereDoc:
+			part.Text = "is the here-document text, given to the command as input"
+		case token.Kind == shell.Operator && token.Value == "\n":
+			commandPosition = true
+			continue
+		case token.Value == "2>&1":
+			part.Command = name
+			part.Text = redirectEffect(shell.Redirect{Op: "2>", Target: "&1"})
+		case token.Kind == shell.Operator && redirectOperators[token.Value]:
+			part.Command = name
+			if i+1 < len(tokens) && tokens[i+1].Kind == shell.Word {
+				i++
+				part.Raw = command[token.Pos : tokens[i].Pos+len(tokens[i].Raw)]
+				part.Text = redirectEffect(shell.Redirect{Op: token.Value, Target: tokens[i].Value})
+			}
+		case token.Kind == shell.Operator:
+			part.Text = controlOperators[token.Value]
+			commandPosition = true
+			name, valueOf = "", ""
+		case commandPosition && strings.Contains(token.Value, "=") && !strings.HasPrefix(token.Value, "=") && !token.Quoted() && !strings.HasPrefix(token.Value, "-"):
+			part.Text = "sets an environment variable for this command"
+		case commandPosition:
+			name = path.Base(token.Value)
+			entry, _ = Lookup(name)
+			valueOf = ""
+			part.Command = name
+			part.Text = entry.Description
+			commandPosition = false
+		default:
+			part.Command = name
+			part.Text, valueOf, commandPosition = describeArgument(entry, name, token, valueOf)
+			if commandPosition {
+				i-- // The wrapped command starts here
+				continue
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts, true
+}
+
+// describeArgument describes one argument of a command. It returns the
+// flag that takes the next argument as its value, if any, and whether the
+// argument starts a command run by a wrapper such as sudo.
+func describeArgument(entry Entry, name string, token shell.Token, valueOf string) (string, string, bool) {
+	value := token.Value
+	switch {
+	case strings.Contains(token.Raw, "$(") || strings.Contains(token.Raw, "`"):
+		return "includes the output of a nested command", "", false
+	case valueOf != "":
+		return "is the value of " + valueOf, "", false
+	case value == "--":
+		return "ends the options; the rest are operands", "", false
+	case entry.Flags[value] != "":
+		if entry.takesValue(value) {
+			return entry.Flags[value], value, false
+		}
+		return entry.Flags[value], "", false
+	case strings.HasPrefix(value, "--") && strings.Contains(value, "="):
+		return entry.Flags[value[:strings.Index(value, "=")]], "", false
+	case strings.Contains(value, "=") && entry.Flags[value[:strings.Index(value, "=")+1]] != "":
+		return entry.Flags[value[:strings.Index(value, "=")+1]], "", false
+	case strings.HasPrefix(value, "-") && !strings.HasPrefix(value, "--") && len(value) > 1:
+		// A cluster like -czf; its last flag may take the next argument
+		var effects []string
+		for _, letter := range value[1:] {
+			if description := entry.Flags["-"+string(letter)]; description != "" {
+				effects = append(effects, fmt.Sprintf("-%c %s", letter, description))
+			}
+		}
+		if last := "-" + value[len(value)-1:]; entry.takesValue(last) {
+			return strings.Join(effects, "; "), last, false
+		}
+		return strings.Join(effects, "; "), "", false
+	case wrappers[name] && !strings.HasPrefix(value, "-") && !strings.Contains(value, "="):
+		return "", "", true
+	}
+	return "", "", false
+}
+
+// Markers draws the command with a numbered marker under each part, one
+// line per marker so markers never overlap:
+//
+//	tar -czf backup.tar.gz src
+//	│   │    │             └─ 4
+//	│   │    └─ 3
+//	│   └─ 2
+//	└─ 1
+//
+// Multi-line commands get the markers of each line under that line.
+func Markers(command string, parts []Part) string {
+	var b strings.Builder
+	next, lineStart := 0, 0
+	for _, line := range strings.SplitAfter(command, "\n") {
+		if line == "" {
+			continue
+		}
+		lineEnd := lineStart + len(line)
+		var columns, numbers []int
+		for ; next < len(parts) && parts[next].Pos < lineEnd; next++ {
+			columns = append(columns, utf8.RuneCountInString(command[lineStart:parts[next].Pos]))
+			numbers = append(numbers, next+1)
+		}
+
+		b.WriteString(strings.TrimSuffix(line, "\n") + "\n")
+		for i := len(columns) - 1; i >= 0; i-- {
+			row := []rune(strings.Repeat(" ", columns[i]))
+			for _, column := range columns[:i] {
+				row[column] = '│'
+			}
+			fmt.Fprintf(&b, "%s└─ %d\n", string(row), numbers[i])
+		}
+		lineStart = lineEnd
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package flagdb
+
+import (
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []Part
+	}{
+		{"tar -czf backup.tar.gz src", []Part{
+			{Raw: "tar", Pos: 0, Command: "tar", Text: "creates and extracts archive files"},
+			{Raw: "-czf", Pos: 4, Command: "tar", Text: "-c create a new archive; -z filter through gzip; -f use the given archive file"},
+			{Raw: "backup.tar.gz", Pos: 9, Command: "tar", Text: "is the value of -f"},
+			{Raw: "src", Pos: 23, Command: "tar"},
+		}},
+		{"sudo rm -r /tmp/x 2>&1 | wc -l > n.txt", []Part{
+			{Raw: "sudo", Pos: 0, Command: "sudo", Text: "runs a command as another user (root by default)"},
+			{Raw: "rm", Pos: 5, Command: "rm", Text: "removes files or directories"},
+			{Raw: "-r", Pos: 8, Command: "rm", Text: "remove directories and their contents recursively"},
+			{Raw: "/tmp/x", Pos: 11, Command: "rm"},
+			{Raw: "2>&1", Pos: 18, Command: "rm", Text: "sends errors to the same place as the output"},
+			{Raw: "|", Pos: 23, Text: "pipes the output into the next command"},
+			{Raw: "wc", Pos: 25, Command: "wc", Text: "counts lines, words and bytes"},
+			{Raw: "-l", Pos: 28, Command: "wc", Text: "count lines"},
+			{Raw: "> n.txt", Pos: 31, Command: "wc", Text: "writes the output to n.txt, replacing its contents"},
+		}},
+		{"FOO=1 frobnicate $(date)", []Part{
+			{Raw: "FOO=1", Pos: 0, Text: "sets an environment variable for this command"},
+			{Raw: "frobnicate", Pos: 6, Command: "frobnicate"},
+			{Raw: "$(date)", Pos: 17, Command: "frobnicate", Text: "includes the output of a nested command"},
+		}},
+	}
+
+	for _, tt := range tests {
+		got, ok := Annotate(tt.command)
+		if !ok {
+			t.Fatalf("Annotate(%q) failed", tt.command)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("Annotate(%q) = %+v, want %d parts", tt.command, got, len(tt.want))
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Annotate(%q) part %d = %+v, want %+v", tt.command, i+1, got[i], tt.want[i])
+			}
+		}
+	}
+
+	if _, ok := Annotate("echo 'unterminated"); ok {
+		t.Error("Annotate() of an unterminated quote should fail")
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	command := "tar -czf backup.tar.gz src\nls"
+	parts, _ := Annotate(command)
+	want := "tar -czf backup.tar.gz src\n" +
+		"│   │    │             └─ 4\n" +
+		"│   │    └─ 3\n" +
+		"│   └─ 2\n" +
+		"└─ 1\n" +
+		"ls\n" +
+		"└─ 5\n"
+	if got := Markers(command, parts); got != want {
+		t.Errorf("Markers() =\n%s\nwant\n%s", got, want)
+	}
+}
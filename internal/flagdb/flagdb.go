@@ -0,0 +1,301 @@
+// Package flagdb provides an embedded database of common commands and flags
+// so simple commands can be explained without calling the AI
+package flagdb
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"hermes/internal/shell"
+)
+
+//go:embed commands.json
+var commandsJSON []byte
+
+// Entry describes a single command
+type Entry struct {
+	Description string            `json:"description"`           // Lowercase verb phrase, e.g. "lists directory contents"
+	Flags       map[string]string `json:"flags"`                 // Flag or subcommand -> description
+	TakesValue  []string          `json:"takes_value,omitempty"` // Flags that consume the next argument
+}
+
+// takesValue reports whether flag consumes the following argument
+func (e Entry) takesValue(flag string) bool {
+	for _, f := range e.TakesValue {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	loadOnce sync.Once
+	commands map[string]Entry
+)
+
+// load parses the embedded database once
+func load() map[string]Entry {
+	loadOnce.Do(func() {
+		if err := json.Unmarshal(commandsJSON, &commands); err != nil {
+			panic(fmt.Sprintf("flagdb: invalid embedded database: %v", err))
+		}
+	})
+	return commands
+}
+
+// Lookup returns the entry for a command name
+func Lookup(name string) (Entry, bool) {
+	entry, ok := load()[name]
+	return entry, ok
+}
+
+// Names returns all known command names, sorted
+func Names() []string {
+	var names []string
+	for name := range load() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Explain builds a bullet-point explanation of command from the database.
+// It reports false when the command uses anything the database does not
+// cover (unknown commands or flags, substitutions), so the caller can fall
+// back to the AI.
+func Explain(command string) (string, bool) {
+	return explainer{lookup: Lookup, strict: true}.explain(command)
+}
+
+// ExplainWith builds a best-effort explanation for when the AI is not
+// available: commands missing from the database are looked up with
+// fallback (such as the manual pages), and whatever neither covers is
+// marked as undocumented instead of failing. It reports false only when
+// the command does not parse.
+func ExplainWith(command string, fallback func(name string) (Entry, bool)) (string, bool) {
+	lookup := func(name string) (Entry, bool) {
+		if entry, ok := Lookup(name); ok {
+			return entry, true
+		}
+		return fallback(name)
+	}
+	return explainer{lookup: lookup}.explain(command)
+}
+
+// explainer explains commands from a source of entries. A strict
+// explainer gives up on anything the entries do not cover.
+type explainer struct {
+	lookup func(name string) (Entry, bool)
+	strict bool
+}
+
+// explain explains every stage of every pipeline in command
+func (x explainer) explain(command string) (string, bool) {
+	script, err := shell.Parse(command)
+	if err != nil || len(script.Pipelines) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	for _, pipeline := range script.Pipelines {
+		for i, stage := range pipeline.Stages {
+			sections, ok := x.explainStage(stage)
+			if !ok {
+				return "", false
+			}
+			last := &sections[len(sections)-1]
+			if i < len(pipeline.Stages)-1 {
+				last.details = append(last.details, fmt.Sprintf("its output is piped into '%s'", pipeline.Stages[i+1].Name()))
+			} else {
+				switch pipeline.Next {
+				case "&&":
+					last.details = append(last.details, "'&&' runs the next command only if this one succeeds")
+				case "||":
+					last.details = append(last.details, "'||' runs the next command only if this one fails")
+				case "&":
+					last.details = append(last.details, "'&' runs it in the background")
+				}
+			}
+
+			for _, section := range sections {
+				fmt.Fprintf(&b, "• %s\n", section.text)
+				for _, detail := range section.details {
+					fmt.Fprintf(&b, "  • %s\n", detail)
+				}
+			}
+		}
+	}
+	return b.String(), true
+}
+
+// section is a single explained stage
+type section struct {
+	text    string
+	details []string
+}
+
+// wrappers run another command given as their operands
+var wrappers = map[string]bool{
+	"sudo": true, "xargs": true, "nohup": true, "time": true, "nice": true, "env": true, "watch": true,
+}
+
+// explainStage explains one simple command. Wrapper commands (sudo, xargs,
+// ...) produce an extra section for the command they run.
+func (x explainer) explainStage(stage shell.Stage) ([]section, bool) {
+	name := stage.Name()
+	entry, ok := x.lookup(name)
+	if !ok && x.strict {
+		return nil, false
+	}
+
+	result := section{text: fmt.Sprintf("'%s' %s.", name, entry.Description)}
+	if !ok {
+		result.text = fmt.Sprintf("'%s' is not documented offline.", name)
+	}
+	var operands []string
+	seenName := false
+	for i := 0; i < len(stage.Args); i++ {
+		arg := stage.Args[i]
+		if strings.Contains(arg.Raw, "$(") || strings.Contains(arg.Raw, "`") {
+			if x.strict {
+				return nil, false // Substitutions need real understanding
+			}
+			result.details = append(result.details, fmt.Sprintf("'%s' includes the output of a nested command", arg.Raw))
+			if !seenName {
+				seenName = arg.Value == name
+			}
+			continue
+		}
+		if !seenName {
+			if arg.Value == name || strings.HasSuffix(arg.Value, "/"+name) {
+				seenName = true
+			} else {
+				result.details = append(result.details, fmt.Sprintf("'%s' sets an environment variable for this command", arg.Raw))
+			}
+			continue
+		}
+
+		value := arg.Value
+		switch {
+		case value == "--":
+			continue
+		case entry.Flags[value] != "":
+			if entry.takesValue(value) && i+1 < len(stage.Args) {
+				i++
+				result.details = append(result.details, fmt.Sprintf("'%s %s' %s", value, stage.Args[i].Raw, entry.Flags[value]))
+			} else {
+				result.details = append(result.details, fmt.Sprintf("'%s' %s", value, entry.Flags[value]))
+			}
+		case strings.HasPrefix(value, "--") && strings.Contains(value, "="):
+			key := value[:strings.Index(value, "=")]
+			description, ok := entry.Flags[key]
+			if !ok {
+				if x.strict {
+					return nil, false
+				}
+				description = undocumented
+			}
+			result.details = append(result.details, fmt.Sprintf("'%s' %s", value, description))
+		case strings.Contains(value, "=") && entry.Flags[value[:strings.Index(value, "=")+1]] != "":
+			// dd-style operands (if=..., of=...)
+			result.details = append(result.details, fmt.Sprintf("'%s' %s", value, entry.Flags[value[:strings.Index(value, "=")+1]]))
+		case strings.HasPrefix(value, "-") && len(value) > 1:
+			details, ok := x.expandFlags(entry, value)
+			if !ok {
+				return nil, false
+			}
+			result.details = append(result.details, details...)
+		case wrappers[name] && !strings.Contains(value, "="):
+			// The remaining arguments form the wrapped command
+			nested := shell.Stage{Args: stage.Args[i:], Redirects: stage.Redirects}
+			sections, ok := x.explainStage(nested)
+			if !ok {
+				return nil, false
+			}
+			result.details = append(result.details, fmt.Sprintf("runs '%s' with the remaining arguments", nested.Name()))
+			return append([]section{result}, sections...), true
+		default:
+			operands = append(operands, "'"+arg.Raw+"'")
+		}
+	}
+
+	if len(operands) > 0 {
+		result.details = append(result.details, "operates on "+strings.Join(operands, ", "))
+	}
+	for _, redirect := range stage.Redirects {
+		result.details = append(result.details, describeRedirect(redirect))
+	}
+	return []section{result}, true
+}
+
+// undocumented stands in for the description of a flag neither the
+// database nor the fallback knows
+const undocumented = "is not documented offline"
+
+// expandFlags explains a cluster of single-letter flags like -la. A
+// lenient explainer marks unknown flags instead of giving up.
+func (x explainer) expandFlags(entry Entry, cluster string) ([]string, bool) {
+	if strings.HasPrefix(cluster, "--") || (!x.strict && len(entry.Flags) == 0) {
+		if x.strict {
+			return nil, false
+		}
+		// Without documentation -abc may as well be one flag
+		return []string{fmt.Sprintf("'%s' %s", cluster, undocumented)}, true
+	}
+	var details []string
+	for _, letter := range cluster[1:] {
+		flag := "-" + string(letter)
+		description, ok := entry.Flags[flag]
+		if !ok {
+			if x.strict {
+				return nil, false
+			}
+			description = undocumented
+		}
+		details = append(details, fmt.Sprintf("'%s' %s", flag, description))
+	}
+	return details, true
+}
+
+// describeRedirect explains an I/O redirection
+func describeRedirect(r shell.Redirect) string {
+	return fmt.Sprintf("'%s' %s", redirectText(r), redirectEffect(r))
+}
+
+// redirectText is a redirection as written
+func redirectText(r shell.Redirect) string {
+	if r.Op == "2>" && r.Target == "&1" {
+		return "2>&1"
+	}
+	return r.Op + " " + r.Target
+}
+
+// redirectEffect says what a redirection does
+func redirectEffect(r shell.Redirect) string {
+	switch r.Op {
+	case ">":
+		return fmt.Sprintf("writes the output to %s, replacing its contents", r.Target)
+	case ">|":
+		return fmt.Sprintf("writes the output to %s, replacing its contents even when noclobber is set", r.Target)
+	case ">>":
+		return fmt.Sprintf("appends the output to %s", r.Target)
+	case "<":
+		return fmt.Sprintf("reads input from %s", r.Target)
+	case "2>":
+		if r.Target == "&1" {
+			return "sends errors to the same place as the output"
+		}
+		return fmt.Sprintf("writes errors to %s", r.Target)
+	case "<<", "<<-":
+		return fmt.Sprintf("reads input from the here-document ending at %s", r.Target)
+	case "&>", "&>>":
+		return fmt.Sprintf("sends both output and errors to %s", r.Target)
+	default:
+		return "redirects input or output"
+	}
+}
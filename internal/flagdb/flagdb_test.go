@@ -0,0 +1,99 @@
+package flagdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		wantOK   bool
+		contains []string
+	}{
+		{"simple flags", "ls -la", true, []string{"'ls' lists directory contents.", "'-l' use a long listing format", "'-a' show all entries"}},
+		{"flag with value", "head -n 5 file.txt", true, []string{"'-n 5' number of lines to print", "operates on 'file.txt'"}},
+		{"long flag with value", "grep -r --include=*.go TODO .", true, []string{"'--include=*.go' search only files matching the glob"}},
+		{"pipeline", "ps aux | grep nginx", true, []string{"its output is piped into 'grep'", "'grep' searches text"}},
+		{"wrapper command", "sudo rm -rf /tmp/x", true, []string{"runs 'rm' with the remaining arguments", "'-r' remove directories"}},
+		{"redirect", "echo hi > out.txt", true, []string{"'> out.txt' writes the output to out.txt"}},
+		{"and chain", "mkdir -p build && cd build", true, []string{"'&&' runs the next command only if this one succeeds"}},
+		{"dd operands", "dd if=/dev/zero of=disk.img bs=1M count=10", true, []string{"'of=disk.img' write to the given file"}},
+		{"multi-letter flag", "nmap -sV -p 22,80 host", true, []string{"'-sV' detect service versions", "'-p 22,80' scan the given ports"}},
+		{"plus flag", "chattr +i /etc/resolv.conf", true, []string{"'+i' make the file immutable"}},
+		{"unknown command", "frobnicate --all", false, nil},
+		{"unknown flag", "ls --frobnicate", false, nil},
+		{"command substitution", "echo $(date)", false, nil},
+		{"syntax error", "echo 'unterminated", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Explain(tt.command)
+			if ok != tt.wantOK {
+				t.Fatalf("Explain(%q) ok = %v, want %v (output: %q)", tt.command, ok, tt.wantOK, got)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("Explain(%q) = %q, want it to contain %q", tt.command, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExplainWith(t *testing.T) {
+	fallback := func(name string) (Entry, bool) {
+		if name != "restic" {
+			return Entry{}, false
+		}
+		return Entry{
+			Description: "- backup program",
+			Flags:       map[string]string{"-r": "repository to back up to", "-v": "be verbose"},
+			TakesValue:  []string{"-r"},
+		}, true
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		wantOK   bool
+		contains []string
+	}{
+		{"database first", "ls -la", true, []string{"'ls' lists directory contents.", "'-l' use a long listing format"}},
+		{"fallback entry", "restic -v -r /srv/repo backup ~/work", true, []string{"'restic' - backup program.", "'-v' be verbose", "'-r /srv/repo' repository to back up to"}},
+		{"unknown flag", "ls --frobnicate", true, []string{"'--frobnicate' is not documented offline"}},
+		{"unknown short flag", "restic -vq snapshots", true, []string{"'-v' be verbose", "'-q' is not documented offline"}},
+		{"unknown command", "frobnicate -abc file | wc -l", true, []string{"'frobnicate' is not documented offline.", "'-abc' is not documented offline", "its output is piped into 'wc'"}},
+		{"command substitution", "echo $(date)", true, []string{"'$(date)' includes the output of a nested command"}},
+		{"syntax error", "echo 'unterminated", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExplainWith(tt.command, fallback)
+			if ok != tt.wantOK {
+				t.Fatalf("ExplainWith(%q) ok = %v, want %v (output: %q)", tt.command, ok, tt.wantOK, got)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("ExplainWith(%q) = %q, want it to contain %q", tt.command, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDatabase(t *testing.T) {
+	names := Names()
+	if len(names) < 200 {
+		t.Errorf("database has %d utilities, want at least 200", len(names))
+	}
+	for _, name := range names {
+		entry, _ := Lookup(name)
+		if entry.Description == "" || strings.ToLower(entry.Description[:1]) != entry.Description[:1] {
+			t.Errorf("%s: description %q, want a lowercase verb phrase", name, entry.Description)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package history reads the user's shell history to provide generation context for hermes
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"hermes/internal/redact"
+)
+
+// maxEntries bounds how much history is kept in memory
+const maxEntries = 5000
+
+// atuinTimeout bounds how long we wait for the atuin CLI
+const atuinTimeout = 2 * time.Second
+
+// Load returns the most recent shell history entries (oldest first) and the
+// name of the source they came from. Atuin is preferred when installed,
+// otherwise the history file of the user's shell is read.
+func Load(ctx context.Context) ([]string, string, error) {
+	if path, err := exec.LookPath("atuin"); err == nil {
+		if entries, err := loadAtuin(ctx, path); err == nil && len(entries) > 0 {
+			return entries, "atuin", nil
+		}
+	}
+
+	histFile := historyFile()
+	if histFile == "" {
+		return nil, "", fmt.Errorf("no shell history file found")
+	}
+	entries, err := loadFile(histFile)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, histFile, nil
+}
+
+// loadAtuin reads recent commands through the atuin CLI
+func loadAtuin(ctx context.Context, path string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, atuinTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "search", "--cmd-only", "--limit", fmt.Sprint(maxEntries)).Output()
+	if err != nil {
+		return nil, fmt.Errorf("atuin search failed: %w", err)
+	}
+
+	var entries []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+// historyFile locates the history file for the user's shell
+func historyFile() string {
+	if histFile := os.Getenv("HISTFILE"); histFile != "" {
+		return histFile
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	candidates := []string{".zsh_history", ".bash_history", ".local/share/fish/fish_history"}
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "bash":
+		candidates = []string{".bash_history", ".zsh_history", ".local/share/fish/fish_history"}
+	case "fish":
+		candidates = []string{".local/share/fish/fish_history", ".zsh_history", ".bash_history"}
+	}
+
+	for _, candidate := range candidates {
+		path := filepath.Join(home, candidate)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadFile parses a zsh, bash or fish history file, keeping the most
+// recent entries
+func loadFile(path string) ([]string, error) {
+	entries, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	return entries, nil
+}
+
+// ReadFile returns every command of a zsh, bash or fish history file, or
+// of a plain file with one command per line, oldest first
+func ReadFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	var entries []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, ": ") && strings.Contains(line, ";"):
+			// zsh extended history: ": 1700000000:0;command"
+			line = line[strings.Index(line, ";")+1:]
+		case strings.HasPrefix(line, "- cmd: "):
+			// fish history: "- cmd: command"
+			line = strings.TrimPrefix(line, "- cmd: ")
+		case strings.HasPrefix(line, "  when: "), strings.HasPrefix(line, "  paths:"), strings.HasPrefix(line, "    - "), strings.HasPrefix(line, "#"):
+			// fish metadata and bash timestamps
+			continue
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+// Relevant returns up to n redacted entries that share the most words with
+// the query, most recent first, for use as generation context
+func Relevant(entries []string, query string, n int) []string {
+	words := tokens(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		entry string
+		score int
+	}
+	var matches []scored
+	seen := map[string]bool{}
+	for i := len(entries) - 1; i >= 0 && len(matches) < n*10; i-- {
+		entry := entries[i]
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+
+		score := 0
+		for word := range tokens(entry) {
+			if words[word] {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{entry, score})
+		}
+	}
+
+	// Stable selection of the best scores, preserving recency order
+	redactor := redact.New()
+	var result []string
+	for best := len(words); best > 0 && len(result) < n; best-- {
+		for _, m := range matches {
+			if m.score == best && len(result) < n {
+				result = append(result, redactor.Redact(m.entry))
+			}
+		}
+	}
+	return result
+}
+
+// Similar finds the most recent history entry resembling the command.
+// It reports whether an entry was found and whether it is an exact match.
+func Similar(entries []string, command string) (entry string, exact bool, found bool) {
+	commandTokens := tokens(command)
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "", false, false
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		candidate := entries[i]
+		if candidate == command {
+			return redact.New().Redact(candidate), true, true
+		}
+		candidateFields := strings.Fields(candidate)
+		if len(candidateFields) == 0 || candidateFields[0] != fields[0] {
+			continue
+		}
+		if similarity(commandTokens, tokens(candidate)) >= 0.6 {
+			return redact.New().Redact(candidate), false, true
+		}
+	}
+	return "", false, false
+}
+
+// similarity computes the Jaccard index of two token sets
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for token := range a {
+		if b[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// tokenPattern splits text into comparable words
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9_.-]{2,}`)
+
+// tokens returns the lowercase word set of a string
+func tokens(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, token := range tokenPattern.FindAllString(strings.ToLower(s), -1) {
+		set[strings.Trim(token, ".-")] = true
+	}
+	delete(set, "")
+	return set
+}
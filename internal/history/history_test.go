@@ -0,0 +1,136 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeHistory writes a history file and returns its path
+func writeHistory(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			"zsh extended",
+			": 1700000000:0;git status\n: 1700000005:2;make test; echo done\n\n",
+			[]string{"git status", "make test; echo done"},
+		},
+		{
+			"bash timestamps",
+			"#1700000000\nls -la\n#1700000010\n  cd /tmp  \n",
+			[]string{"ls -la", "cd /tmp"},
+		},
+		{
+			"fish",
+			"- cmd: git log\n  when: 1700000000\n- cmd: cat notes.txt\n  when: 1700000001\n  paths:\n    - notes.txt\n",
+			[]string{"git log", "cat notes.txt"},
+		},
+		{
+			"plain",
+			"uptime\ndf -h\n",
+			[]string{"uptime", "df -h"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadFile(writeHistory(t, "history", tt.content))
+			if err != nil {
+				t.Fatalf("loadFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileKeepsMostRecent(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < maxEntries+10; i++ {
+		fmt.Fprintf(&b, ": 1700000000:0;echo %d\n", i)
+	}
+	got, err := loadFile(writeHistory(t, ".zsh_history", b.String()))
+	if err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	if len(got) != maxEntries || got[0] != "echo 10" || got[len(got)-1] != fmt.Sprintf("echo %d", maxEntries+9) {
+		t.Errorf("loadFile() kept %d entries from %q to %q, want the last %d", len(got), got[0], got[len(got)-1], maxEntries)
+	}
+
+	if _, err := loadFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("loadFile() of a missing file succeeded")
+	}
+}
+
+func TestRelevant(t *testing.T) {
+	entries := []string{
+		"git status",
+		"git push origin main",
+		"docker ps",
+		"git push --force origin main",
+		"git status",
+		"mysql --password=hunter22 -h db1 shop",
+	}
+
+	if got, want := Relevant(entries, "push to origin main", 5), []string{"git push --force origin main", "git push origin main"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Relevant() = %q, want the best matches, most recent first %q", got, want)
+	}
+	if got := Relevant(entries, "push to origin main", 1); len(got) != 1 || got[0] != "git push --force origin main" {
+		t.Errorf("Relevant(n=1) = %q", got)
+	}
+	if got := Relevant(entries, "git status", 5); got[0] != "git status" || len(got) != 3 {
+		t.Errorf("Relevant() = %q, want duplicates dropped and the exact match first", got)
+	}
+	if got := Relevant(entries, "connect to the db1 database", 5); len(got) != 1 || strings.Contains(got[0], "hunter22") {
+		t.Errorf("Relevant() = %q, want the password masked", got)
+	}
+	if got := Relevant(entries, "?!", 5); got != nil {
+		t.Errorf("Relevant() without words = %q, want nil", got)
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	entries := []string{
+		"rsync -av src/ backup/",
+		"tar -czf logs.tar.gz /var/log/app",
+		"curl -H 'Authorization: Bearer s3cr3t-t0ken-value' https://api.example.com/v1/items",
+	}
+	tests := []struct {
+		command   string
+		wantEntry string
+		wantExact bool
+		wantFound bool
+	}{
+		{"rsync -av src/ backup/", "rsync -av src/ backup/", true, true},
+		{"tar -czf logs.tar.gz /var/log/app/", "tar -czf logs.tar.gz /var/log/app", false, true},
+		{"zip -r logs.zip /var/log/app", "", false, false},
+		{"rsync -n --delete /srv/ mirror:/srv/", "", false, false},
+		{"", "", false, false},
+	}
+	for _, tt := range tests {
+		entry, exact, found := Similar(entries, tt.command)
+		if entry != tt.wantEntry || exact != tt.wantExact || found != tt.wantFound {
+			t.Errorf("Similar(%q) = %q, %v, %v, want %q, %v, %v", tt.command, entry, exact, found, tt.wantEntry, tt.wantExact, tt.wantFound)
+		}
+	}
+
+	entry, _, found := Similar(entries, "curl -H 'Authorization: Bearer s3cr3t-t0ken-value' https://api.example.com/v1/items?page=2")
+	if !found || strings.Contains(entry, "s3cr3t") {
+		t.Errorf("Similar() = %q, %v, want the token masked", entry, found)
+	}
+}
@@ -0,0 +1,683 @@
+// Package impact estimates how many files a command would delete or change
+// by expanding its targets read-only. It never runs the commands it
+// inspects, not even in a dry-run mode.
+package impact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"hermes/internal/shell"
+)
+
+// Limits on a scan, so estimates stay fast on huge trees
+const (
+	scanTimeout = 3 * time.Second
+	maxEntries  = 1000000
+)
+
+// errLimit stops a walk that reached the scan limits
+var errLimit = errors.New("scan limit reached")
+
+// Estimate is what a command would do to files of one kind of change
+type Estimate struct {
+	Verb    string // "delete", "move", "copy" or "change"
+	Files   int
+	Bytes   int64
+	Partial bool // The scan stopped early; the numbers are lower bounds
+}
+
+// String describes the estimate, e.g. "this will delete 14,302 files, 3.2 GB"
+func (e Estimate) String() string {
+	count := formatCount(e.Files)
+	if e.Partial {
+		count = "at least " + count
+	}
+	noun := "files"
+	if e.Files == 1 && !e.Partial {
+		noun = "file"
+	}
+	return fmt.Sprintf("this will %s %s %s, %s", e.Verb, count, noun, formatSize(e.Bytes))
+}
+
+// globVerbs are commands whose operands are affected when given as globs
+var globVerbs = map[string]string{
+	"mv": "move", "cp": "copy", "chmod": "change", "chown": "change",
+	"chgrp": "change", "truncate": "change",
+}
+
+// deleteOptions are the rsync options that delete files on the receiver
+var deleteOptions = []string{"--delete", "--del", "--delete-before", "--delete-during", "--delete-delay", "--delete-after", "--delete-excluded"}
+
+// tally accumulates one estimate while scanning
+type tally struct {
+	Estimate
+	seen map[string]bool
+}
+
+// add counts a path once; directories are walked when recursive
+func (t *tally) add(ctx context.Context, path string, recursive bool) error {
+	if t.seen[path] {
+		return nil
+	}
+	t.seen[path] = true
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil // Missing targets affect nothing
+	}
+	if !info.IsDir() {
+		return t.count(info)
+	}
+	if !recursive {
+		return nil
+	}
+	return filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errLimit
+		}
+		return t.count(info)
+	})
+}
+
+// count adds one file to the tally
+func (t *tally) count(info fs.FileInfo) error {
+	if t.Files >= maxEntries {
+		return errLimit
+	}
+	t.Files++
+	if info.Mode().IsRegular() {
+		t.Bytes += info.Size()
+	}
+	return nil
+}
+
+// Scan estimates the files a POSIX command would delete or change: the
+// targets of rm, the matches of find -delete, what rsync --delete would
+// remove from a local destination, and glob operands of mv, cp, chmod and
+// similar commands. It only reads the file system; commands it cannot
+// expand (variables, command substitutions, remote paths) are skipped.
+func Scan(ctx context.Context, command string) []Estimate {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+	defer cancel()
+
+	tallies := map[string]*tally{}
+	var order []string
+	get := func(verb string) *tally {
+		if tallies[verb] == nil {
+			tallies[verb] = &tally{Estimate: Estimate{Verb: verb}, seen: map[string]bool{}}
+			order = append(order, verb)
+		}
+		return tallies[verb]
+	}
+
+	for _, stage := range script.Stages() {
+		args := commandArgs(stage.Args)
+		if len(args) == 0 {
+			continue
+		}
+		name := filepath.Base(args[0].Value)
+		var err error
+		switch {
+		case name == "rm":
+			err = scanRm(ctx, get("delete"), args[1:])
+		case name == "find" && hasWord(args, "-delete"):
+			err = scanFind(ctx, get("delete"), args[1:])
+		case name == "rsync" && hasOption(args, deleteOptions):
+			err = scanRsync(ctx, get("delete"), args[1:])
+		case globVerbs[name] != "" && hasGlob(args[1:]):
+			err = scanGlobs(ctx, get(globVerbs[name]), args[1:])
+		}
+		if errors.Is(err, errLimit) || ctx.Err() != nil {
+			get(verbOf(name)).Partial = true
+		}
+	}
+
+	var estimates []Estimate
+	for _, verb := range order {
+		if t := tallies[verb]; t.Files > 0 {
+			estimates = append(estimates, t.Estimate)
+		}
+	}
+	return estimates
+}
+
+// verbOf returns the estimate a command contributes to
+func verbOf(name string) string {
+	if verb, ok := globVerbs[name]; ok {
+		return verb
+	}
+	return "delete"
+}
+
+// commandArgs drops leading assignments and sudo with its options
+func commandArgs(args []shell.Token) []shell.Token {
+	for len(args) > 0 && strings.Contains(args[0].Value, "=") && !args[0].Quoted() && !strings.HasPrefix(args[0].Value, "-") {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0].Value == "sudo" {
+		args = args[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0].Value, "-") {
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+// scanRm counts rm's operands, walking directories under -r
+func scanRm(ctx context.Context, t *tally, args []shell.Token) error {
+	recursive := false
+	options := true
+	for _, arg := range args {
+		if options && arg.Value == "--" {
+			options = false
+			continue
+		}
+		if options && strings.HasPrefix(arg.Value, "-") && !arg.Quoted() {
+			if arg.Value == "--recursive" || !strings.HasPrefix(arg.Value, "--") && strings.ContainsAny(arg.Value, "rR") {
+				recursive = true
+			}
+			continue
+		}
+		paths, ok := expand(arg)
+		if !ok {
+			continue
+		}
+		for _, path := range paths {
+			if err := t.add(ctx, path, recursive); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanFind evaluates find's start paths and tests in Go and counts the
+// matches of -delete. Only tests that cannot have side effects are
+// understood; any other primary, operator or action skips the estimate,
+// since find itself is never run.
+func scanFind(ctx context.Context, t *tally, args []shell.Token) error {
+	var starts []string
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg.Value, "-") || arg.Value == "(" || arg.Value == "!" {
+			break
+		}
+		paths, ok := expand(arg)
+		if !ok {
+			return nil
+		}
+		starts = append(starts, paths...)
+	}
+	if len(starts) == 0 {
+		starts = []string{"."}
+	}
+	expr, ok := parseFind(args[i:])
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	for _, start := range starts {
+		err := filepath.WalkDir(start, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return errLimit
+			}
+			depth := 0
+			if rel, err := filepath.Rel(start, path); err == nil && rel != "." {
+				depth = strings.Count(rel, string(filepath.Separator)) + 1
+			}
+			if expr.maxDepth >= 0 && depth > expr.maxDepth {
+				return filepath.SkipDir
+			}
+			if depth < expr.minDepth || entry.IsDir() {
+				return nil // -delete only removes empty directories
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			for _, test := range expr.tests {
+				if !test(path, info, now) {
+					return nil
+				}
+			}
+			return t.add(ctx, path, false)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// findExpr is a find expression reduced to a conjunction of tests
+type findExpr struct {
+	tests              []func(path string, info fs.FileInfo, now time.Time) bool
+	minDepth, maxDepth int
+}
+
+// parseFind understands the side-effect-free subset of find expressions
+// that cleanup commands use: -name, -iname, -path, -type, -size, -mtime,
+// -mmin, -empty and depth limits, joined by implicit or explicit -a
+func parseFind(args []shell.Token) (findExpr, bool) {
+	expr := findExpr{maxDepth: -1}
+	value := func(i int) (string, bool) {
+		if i+1 >= len(args) {
+			return "", false
+		}
+		return args[i+1].Value, true
+	}
+	for i := 0; i < len(args); i++ {
+		primary := args[i].Value
+		switch primary {
+		case "-delete", "-print", "-a", "-and", "-depth":
+			continue
+		case "-name", "-iname", "-path", "-wholename", "-ipath":
+			pattern, ok := value(i)
+			if !ok {
+				return expr, false
+			}
+			i++
+			fold := primary == "-iname" || primary == "-ipath"
+			base := primary == "-name" || primary == "-iname"
+			re, err := globRegexp(pattern, fold)
+			if err != nil {
+				return expr, false
+			}
+			expr.tests = append(expr.tests, func(path string, _ fs.FileInfo, _ time.Time) bool {
+				if base {
+					path = filepath.Base(path)
+				}
+				return re.MatchString(path)
+			})
+		case "-type":
+			kind, ok := value(i)
+			if !ok {
+				return expr, false
+			}
+			i++
+			var want fs.FileMode
+			switch kind {
+			case "f":
+			case "l":
+				want = fs.ModeSymlink
+			default:
+				return expr, false // Directories are not counted anyway
+			}
+			expr.tests = append(expr.tests, func(_ string, info fs.FileInfo, _ time.Time) bool {
+				return info.Mode().Type() == want
+			})
+		case "-empty":
+			expr.tests = append(expr.tests, func(_ string, info fs.FileInfo, _ time.Time) bool {
+				return info.Mode().IsRegular() && info.Size() == 0
+			})
+		case "-size":
+			arg, ok := value(i)
+			if !ok {
+				return expr, false
+			}
+			i++
+			unit := int64(512)
+			if n := len(arg); n > 0 {
+				if u, ok := sizeUnits[arg[n-1]]; ok {
+					unit, arg = u, arg[:n-1]
+				}
+			}
+			compare, ok := numericTest(arg)
+			if !ok {
+				return expr, false
+			}
+			expr.tests = append(expr.tests, func(_ string, info fs.FileInfo, _ time.Time) bool {
+				return compare((info.Size() + unit - 1) / unit)
+			})
+		case "-mtime", "-mmin":
+			arg, ok := value(i)
+			if !ok {
+				return expr, false
+			}
+			i++
+			compare, ok := numericTest(arg)
+			if !ok {
+				return expr, false
+			}
+			period := 24 * time.Hour
+			if primary == "-mmin" {
+				period = time.Minute
+			}
+			expr.tests = append(expr.tests, func(_ string, info fs.FileInfo, now time.Time) bool {
+				return compare(int64(now.Sub(info.ModTime()) / period))
+			})
+		case "-maxdepth", "-mindepth":
+			arg, ok := value(i)
+			if !ok {
+				return expr, false
+			}
+			i++
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				return expr, false
+			}
+			if primary == "-maxdepth" {
+				expr.maxDepth = n
+			} else {
+				expr.minDepth = n
+			}
+		default:
+			// -exec, -o, !, (, -newer, -fprint and anything else
+			return expr, false
+		}
+	}
+	return expr, true
+}
+
+// sizeUnits are the unit suffixes of find -size
+var sizeUnits = map[byte]int64{'c': 1, 'w': 2, 'b': 512, 'k': 1 << 10, 'M': 1 << 20, 'G': 1 << 30}
+
+// numericTest parses find's +n, -n and n arguments
+func numericTest(arg string) (func(int64) bool, bool) {
+	sign := byte(0)
+	if arg != "" && (arg[0] == '+' || arg[0] == '-') {
+		sign, arg = arg[0], arg[1:]
+	}
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return nil, false
+	}
+	switch sign {
+	case '+':
+		return func(v int64) bool { return v > n }, true
+	case '-':
+		return func(v int64) bool { return v < n }, true
+	}
+	return func(v int64) bool { return v == n }, true
+}
+
+// globRegexp compiles a find glob, where * and ? also match "/"
+func globRegexp(pattern string, fold bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if fold {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// rsyncOptions are the rsync options the estimate understands: they
+// neither filter the transfer nor have effects beyond the destination.
+// Anything else (filters, --log-file, --write-batch, -e) skips the estimate.
+var rsyncOptions = map[string]bool{
+	"--archive": true, "--recursive": true, "--verbose": true, "--quiet": true,
+	"--compress": true, "--human-readable": true, "--progress": true, "--stats": true,
+	"--checksum": true, "--update": true, "--links": true, "--perms": true,
+	"--times": true, "--group": true, "--owner": true, "--hard-links": true,
+	"--acls": true, "--xattrs": true, "--partial": true, "--itemize-changes": true,
+	"--force": true, "--delete-excluded": true, "--dry-run": true,
+}
+
+// rsyncShortOptions are the single-letter forms of rsyncOptions, plus -n
+const rsyncShortOptions = "arvqzhPcultpgoDHAXin"
+
+// scanRsync counts the files rsync --delete would remove from a local
+// destination by comparing the source and destination trees in Go
+func scanRsync(ctx context.Context, t *tally, args []shell.Token) error {
+	var operands []string
+	recursive, dryRun := false, false
+	for _, arg := range args {
+		value := arg.Value
+		switch {
+		case strings.HasPrefix(value, "--"):
+			if !rsyncOptions[value] && !hasOption([]shell.Token{arg}, deleteOptions) {
+				return nil
+			}
+			recursive = recursive || value == "--archive" || value == "--recursive"
+			dryRun = dryRun || value == "--dry-run"
+		case strings.HasPrefix(value, "-") && len(value) > 1:
+			for _, letter := range value[1:] {
+				if !strings.ContainsRune(rsyncShortOptions, letter) {
+					return nil
+				}
+			}
+			recursive = recursive || strings.ContainsAny(value, "ar")
+			dryRun = dryRun || strings.Contains(value, "n")
+		default:
+			if strings.Contains(value, ":") {
+				return nil // Remote side
+			}
+			paths, ok := expand(arg)
+			if !ok {
+				return nil
+			}
+			operands = append(operands, paths...)
+		}
+	}
+	if !recursive || dryRun || len(operands) < 2 {
+		return nil
+	}
+	destination := operands[len(operands)-1]
+
+	// Paths the sources provide, relative to the destination, and the
+	// destination directories rsync brings in line with them
+	expected := map[string]bool{}
+	var roots []string
+	for _, source := range operands[:len(operands)-1] {
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil // rsync fails before deleting anything
+		}
+		prefix := ""
+		if !strings.HasSuffix(source, "/") {
+			prefix = filepath.Base(source)
+			expected[prefix] = true
+			if !info.IsDir() {
+				continue
+			}
+		}
+		roots = append(roots, prefix)
+		err = filepath.WalkDir(source, func(path string, _ fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return errLimit
+			}
+			rel, _ := filepath.Rel(source, path)
+			expected[filepath.Join(prefix, rel)] = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, root := range roots {
+		base := filepath.Join(destination, root)
+		err := filepath.WalkDir(base, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(destination, path)
+			if expected[rel] {
+				return nil
+			}
+			if err := t.add(ctx, path, true); err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// scanGlobs counts the files matched by glob operands, walking directories
+// when the command is recursive
+func scanGlobs(ctx context.Context, t *tally, args []shell.Token) error {
+	recursive := hasOption(args, []string{"-R", "-r", "--recursive"})
+	for _, arg := range args {
+		if arg.Quoted() || !isGlob(arg.Value) {
+			continue
+		}
+		paths, ok := expand(arg)
+		if !ok {
+			continue
+		}
+		for _, path := range paths {
+			if err := t.add(ctx, path, recursive); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expand returns the paths an operand names after tilde and glob
+// expansion, or false when it depends on variables, command substitution
+// or brace expansion
+func expand(arg shell.Token) ([]string, bool) {
+	value := arg.Value
+	if arg.Quoted() {
+		return []string{value}, true
+	}
+	if strings.ContainsAny(value, "$`{") {
+		return nil, false
+	}
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, false
+		}
+		value = home + value[1:]
+	}
+	if !isGlob(value) {
+		return []string{value}, true
+	}
+	matches, err := filepath.Glob(value)
+	if err != nil {
+		return nil, false
+	}
+	return matches, true
+}
+
+// isGlob reports whether a word contains glob characters
+func isGlob(value string) bool {
+	return strings.ContainsAny(value, "*?[")
+}
+
+// hasGlob reports whether any unquoted argument is a glob
+func hasGlob(args []shell.Token) bool {
+	for _, arg := range args {
+		if !arg.Quoted() && isGlob(arg.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWord reports whether any argument is one of the words
+func hasWord(args []shell.Token, words ...string) bool {
+	for _, arg := range args {
+		for _, word := range words {
+			if arg.Value == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasOption reports whether any argument is one of the options, alone or
+// with an attached =value
+func hasOption(args []shell.Token, options []string) bool {
+	for _, arg := range args {
+		for _, option := range options {
+			if arg.Value == option || strings.HasPrefix(arg.Value, option+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatCount formats a number with thousands separators (14,302)
+func formatCount(n int) string {
+	digits := fmt.Sprint(n)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
+}
+
+// formatSize formats a byte count with decimal units (3.2 GB)
+func formatSize(bytes int64) string {
+	if bytes < 1000 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size := float64(bytes)
+	for _, unit := range []string{"kB", "MB", "GB", "TB"} {
+		size /= 1000
+		if size < 1000 || unit == "TB" {
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+	}
+	return ""
+}
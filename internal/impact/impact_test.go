@@ -0,0 +1,180 @@
+package impact
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tree creates files of the given sizes under a temporary directory
+func tree(t *testing.T, files map[string]int) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	dir := tree(t, map[string]int{
+		"a.log":       100,
+		"b.log":       200,
+		"keep.txt":    50,
+		"cache/1.tmp": 1000,
+		"cache/2.tmp": 2000,
+		"cache/sub/3": 3000,
+	})
+
+	tests := []struct {
+		command string
+		want    []Estimate
+	}{
+		{"rm " + dir + "/*.log", []Estimate{{Verb: "delete", Files: 2, Bytes: 300}}},
+		{"rm -rf " + dir + "/cache", []Estimate{{Verb: "delete", Files: 3, Bytes: 6000}}},
+		{"rm " + dir + "/cache", nil},   // Not recursive: rm refuses directories
+		{"rm '" + dir + "/*.log'", nil}, // Quoted: no such file
+		{"rm $DIR/*.log", nil},          // Depends on a variable
+		{"sudo rm -r " + dir + "/cache/sub " + dir + "/a.log", []Estimate{{Verb: "delete", Files: 2, Bytes: 3100}}},
+		{"chmod 600 " + dir + "/*.log", []Estimate{{Verb: "change", Files: 2, Bytes: 300}}},
+		{"chmod 600 " + dir + "/a.log", nil}, // No glob
+		{"ls " + dir + "/*.log", nil},
+	}
+	for _, tt := range tests {
+		if got := Scan(context.Background(), tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Scan(%q) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestScanFind(t *testing.T) {
+	dir := tree(t, map[string]int{"x/1.tmp": 10, "x/2.tmp": 20, "x/3.txt": 30, "x/deep/4.TMP": 2048, "x/empty": 0})
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "x/1.tmp"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command string
+		want    []Estimate
+	}{
+		{"find " + dir + " -name '*.tmp' -delete", []Estimate{{Verb: "delete", Files: 2, Bytes: 30}}},
+		{"find " + dir + " -iname '*.tmp' -type f -delete", []Estimate{{Verb: "delete", Files: 3, Bytes: 2078}}},
+		{"find " + dir + " -maxdepth 2 -iname '*.tmp' -delete", []Estimate{{Verb: "delete", Files: 2, Bytes: 30}}},
+		{"find " + dir + " -path '*/deep/*' -delete", []Estimate{{Verb: "delete", Files: 1, Bytes: 2048}}},
+		{"find " + dir + " -name '*.tmp' -mtime +1 -delete", []Estimate{{Verb: "delete", Files: 1, Bytes: 10}}},
+		{"find " + dir + " -size +1k -delete", []Estimate{{Verb: "delete", Files: 1, Bytes: 2048}}},
+		{"find " + dir + " -empty -delete", []Estimate{{Verb: "delete", Files: 1, Bytes: 0}}},
+		// Anything beyond the understood tests skips the estimate
+		{"find " + dir + " -name '*.tmp' -exec rm {} \\; -delete", nil},
+		{"find " + dir + " -name '*.tmp' -fprint /tmp/list -delete", nil},
+		{"find " + dir + " -name a -o -name b -delete", nil},
+		{"find " + dir + " -newer ref -delete", nil},
+	}
+	for _, tt := range tests {
+		if got := Scan(context.Background(), tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Scan(%q) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+	for _, path := range []string{"x/1.tmp", "x/2.tmp"} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("%s was removed by the scan: %v", path, err)
+		}
+	}
+}
+
+func TestScanRsync(t *testing.T) {
+	dir := tree(t, map[string]int{
+		"src/a":         1,
+		"src/sub/b":     2,
+		"dst/a":         1,
+		"dst/stale":     10,
+		"dst/sub/b":     2,
+		"dst/sub/old":   20,
+		"dst/gone/c":    30,
+		"dst/gone/d":    40,
+		"other/src/a":   1,
+		"other/src/x":   5,
+		"other/keep.me": 7,
+	})
+	src, dst, other := filepath.Join(dir, "src"), filepath.Join(dir, "dst"), filepath.Join(dir, "other")
+
+	tests := []struct {
+		command string
+		want    []Estimate
+	}{
+		{"rsync -av --delete " + src + "/ " + dst + "/", []Estimate{{Verb: "delete", Files: 4, Bytes: 100}}},
+		// Without a trailing slash the source directory itself is synced
+		{"rsync -a --delete " + src + " " + other, []Estimate{{Verb: "delete", Files: 1, Bytes: 5}}},
+		{"rsync -rv --delete-after --progress " + src + "/ " + dst, []Estimate{{Verb: "delete", Files: 4, Bytes: 100}}},
+		{"rsync --delete " + src + "/ " + dst, nil},       // Not recursive
+		{"rsync -an --delete " + src + "/ " + dst, nil},   // Dry run
+		{"rsync -a --delete " + src + "/ host:/srv", nil}, // Remote
+		{"rsync -a --delete " + dir + "/missing/ " + dst, nil},
+		// Options with effects beyond the destination, or that filter
+		// the transfer, skip the estimate
+		{"rsync -a --delete --log-file=/tmp/log " + src + "/ " + dst, nil},
+		{"rsync -a --delete --write-batch=b " + src + "/ " + dst, nil},
+		{"rsync -a --delete -e 'sh -c touch' " + src + "/ " + dst, nil},
+		{"rsync -ae ssh --delete " + src + "/ " + dst, nil},
+		{"rsync -a --delete --exclude '*.log' " + src + "/ " + dst, nil},
+	}
+	for _, tt := range tests {
+		if got := Scan(context.Background(), tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Scan(%q) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "stale")); err != nil {
+		t.Errorf("stale was removed by the scan: %v", err)
+	}
+}
+
+func TestScanRunsNothing(t *testing.T) {
+	// Tools on PATH that would leave a trace if the scan ran them
+	bin, marker := t.TempDir(), filepath.Join(t.TempDir(), "ran")
+	for _, name := range []string{"find", "rsync", "rm"} {
+		script := "#!/bin/sh\necho " + name + " >> " + marker + "\n"
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+
+	dir := tree(t, map[string]int{"src/a": 1, "dst/b": 2})
+	for _, command := range []string{
+		"find " + dir + " -name b -delete",
+		"rsync -a --delete " + dir + "/src/ " + dir + "/dst/",
+		"rm -rf " + dir + "/dst",
+	} {
+		Scan(context.Background(), command)
+	}
+	if data, err := os.ReadFile(marker); err == nil {
+		t.Errorf("Scan ran external commands: %s", data)
+	}
+}
+
+func TestEstimateString(t *testing.T) {
+	tests := []struct {
+		estimate Estimate
+		want     string
+	}{
+		{Estimate{Verb: "delete", Files: 14302, Bytes: 3200000000}, "this will delete 14,302 files, 3.2 GB"},
+		{Estimate{Verb: "change", Files: 1, Bytes: 512}, "this will change 1 file, 512 B"},
+		{Estimate{Verb: "delete", Files: 1000000, Bytes: 1500, Partial: true}, "this will delete at least 1,000,000 files, 1.5 kB"},
+	}
+	for _, tt := range tests {
+		if got := tt.estimate.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
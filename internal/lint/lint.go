@@ -0,0 +1,225 @@
+// Package lint provides static validation of generated shell commands for hermes
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Finding represents a single issue reported by a lint pass
+type Finding struct {
+	Code    string // Check identifier (e.g., "SC2086")
+	Level   string // Severity: "error", "warning", "info" or "style"
+	Message string // Human-readable description of the issue
+}
+
+// String returns the finding formatted for terminal output
+func (f Finding) String() string {
+	return fmt.Sprintf("%s (%s): %s", f.Code, f.Level, f.Message)
+}
+
+// Result represents the outcome of linting a command
+type Result struct {
+	Command  string    // Command after trivial auto-fixes were applied
+	Findings []Finding // Remaining issues worth surfacing to the user
+	Source   string    // Which linter produced the result ("shellcheck" or "builtin")
+}
+
+// autoFixCodes lists shellcheck codes whose suggested fixes are safe to apply
+// automatically (quoting and modern substitution syntax only)
+var autoFixCodes = map[int]bool{
+	2006: true, // Use $(...) instead of legacy backticks
+	2086: true, // Double quote to prevent globbing and word splitting
+}
+
+// shellcheckTimeout bounds how long we wait for an external shellcheck run
+const shellcheckTimeout = 2 * time.Second
+
+// Check lints a command, preferring an installed shellcheck binary and
+// falling back to the built-in subset of checks when it is unavailable
+func Check(ctx context.Context, command string) Result {
+	if path, err := exec.LookPath("shellcheck"); err == nil {
+		if result, err := runShellcheck(ctx, path, command); err == nil {
+			return result
+		}
+	}
+	return Builtin(command)
+}
+
+// shellcheckComment mirrors a single entry of shellcheck's json1 output
+type shellcheckComment struct {
+	Line      int    `json:"line"`
+	EndLine   int    `json:"endLine"`
+	Column    int    `json:"column"`
+	EndColumn int    `json:"endColumn"`
+	Level     string `json:"level"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	Fix       *struct {
+		Replacements []shellcheckReplacement `json:"replacements"`
+	} `json:"fix"`
+}
+
+// shellcheckReplacement mirrors a fix replacement in shellcheck's json1 output
+type shellcheckReplacement struct {
+	Line        int    `json:"line"`
+	EndLine     int    `json:"endLine"`
+	Column      int    `json:"column"`
+	EndColumn   int    `json:"endColumn"`
+	Replacement string `json:"replacement"`
+}
+
+// runShellcheck runs the external shellcheck binary on the command
+func runShellcheck(ctx context.Context, path, command string) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, shellcheckTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "--shell=bash", "--format=json1", "-")
+	cmd.Stdin = strings.NewReader(command + "\n")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	// shellcheck exits with 1 when it reports findings, so only treat
+	// missing output as a failure
+	if err := cmd.Run(); err != nil && stdout.Len() == 0 {
+		return Result{}, fmt.Errorf("shellcheck failed: %w", err)
+	}
+
+	var output struct {
+		Comments []shellcheckComment `json:"comments"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return Result{}, fmt.Errorf("failed to parse shellcheck output: %w", err)
+	}
+
+	result := Result{Command: command, Source: "shellcheck"}
+	var replacements []shellcheckReplacement
+	for _, comment := range output.Comments {
+		// Only auto-fix single-line commands where columns map directly
+		if autoFixCodes[comment.Code] && comment.Fix != nil && !strings.Contains(command, "\n") {
+			replacements = append(replacements, comment.Fix.Replacements...)
+			continue
+		}
+		result.Findings = append(result.Findings, Finding{
+			Code:    fmt.Sprintf("SC%d", comment.Code),
+			Level:   comment.Level,
+			Message: comment.Message,
+		})
+	}
+	result.Command = applyReplacements(command, replacements)
+
+	return result, nil
+}
+
+// applyReplacements applies shellcheck fix replacements to a single-line command.
+// Replacements are applied right to left so earlier columns stay valid.
+func applyReplacements(command string, replacements []shellcheckReplacement) string {
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].Column > replacements[j].Column
+	})
+
+	lastStart := len(command)
+	for _, r := range replacements {
+		start, end := columnOffset(command, r.Column), columnOffset(command, r.EndColumn)
+		// Skip anything out of range or overlapping a replacement already applied
+		if start < 0 || end < start || end > lastStart {
+			continue
+		}
+		command = command[:start] + r.Replacement + command[end:]
+		lastStart = start
+	}
+
+	return command
+}
+
+// columnOffset converts a 1-based shellcheck column, which counts
+// characters, to a byte offset in line. It returns -1 when the column is
+// out of range.
+func columnOffset(line string, column int) int {
+	if column < 1 {
+		return -1
+	}
+	col := 1
+	for offset := range line {
+		if col == column {
+			return offset
+		}
+		col++
+	}
+	if col == column {
+		return len(line)
+	}
+	return -1
+}
+
+// builtinCheck is a single embedded lint rule
+type builtinCheck struct {
+	code    string
+	level   string
+	message string
+	pattern *regexp.Regexp
+}
+
+// builtinChecks is the embedded subset of shellcheck rules used when shellcheck is not installed
+var builtinChecks = []builtinCheck{
+	{"SC2006", "style", "Use $(...) notation instead of legacy backticks `...`", regexp.MustCompile("`[^`]*`")},
+	{"SC2115", "warning", "Use \"${var:?}\" to ensure this never expands to /*", regexp.MustCompile(`\brm\s+(-\S+\s+)*"?\$\{?\w+\}?"?/`)},
+	{"SC2010", "warning", "Don't use ls | grep. Use a glob or a for loop with a condition to allow non-alphanumeric filenames", regexp.MustCompile(`\bls\b[^|]*\|\s*grep\b`)},
+	{"SC2002", "style", "Useless cat. Consider 'cmd < file | ..' or 'cmd file | ..' instead", regexp.MustCompile(`^\s*cat\s+[^\s|;&<>-][^\s|;&<>]*\s*\|`)},
+	{"SC2162", "info", "read without -r will mangle backslashes", regexp.MustCompile(`\bread\s+([^-\s]|-[^r\s]*\s)`)},
+	{"SC2045", "error", "Iterating over ls output is fragile. Use globs", regexp.MustCompile(`\bfor\s+\w+\s+in\s+\$\(\s*ls\b`)},
+}
+
+// Builtin lints a command using only the embedded checks
+func Builtin(command string) Result {
+	result := Result{Command: command, Source: "builtin"}
+
+	if quote := unterminatedQuote(command); quote != 0 {
+		result.Findings = append(result.Findings, Finding{
+			Code:    "SC1009",
+			Level:   "error",
+			Message: fmt.Sprintf("Unterminated %c quote", quote),
+		})
+		// Pattern checks are meaningless on a line that does not parse
+		return result
+	}
+
+	for _, check := range builtinChecks {
+		if check.pattern.MatchString(command) {
+			result.Findings = append(result.Findings, Finding{
+				Code:    check.code,
+				Level:   check.level,
+				Message: check.message,
+			})
+		}
+	}
+
+	return result
+}
+
+// unterminatedQuote returns the quote character left open at the end of the
+// command, or 0 if all quotes are balanced
+func unterminatedQuote(command string) rune {
+	var open rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && open != '\'':
+			escaped = true
+		case open == 0 && (r == '\'' || r == '"'):
+			open = r
+		case r == open:
+			open = 0
+		}
+	}
+	return open
+}
@@ -0,0 +1,78 @@
+package lint
+
+import (
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string // Expected finding codes, in order
+	}{
+		{"clean command", "ls -la", nil},
+		{"backticks", "echo `date`", []string{"SC2006"}},
+		{"rm with variable prefix", `rm -rf "$DIR/"*`, []string{"SC2115"}},
+		{"ls piped to grep", "ls -la | grep foo", []string{"SC2010"}},
+		{"useless cat", "cat file.txt | grep foo", []string{"SC2002"}},
+		{"read without -r", "while read line; do echo $line; done < f", []string{"SC2162"}},
+		{"read with -r", "while read -r line; do echo $line; done < f", nil},
+		{"loop over ls", "for f in $(ls); do echo $f; done", []string{"SC2045"}},
+		{"unterminated single quote", "echo 'hello", []string{"SC1009"}},
+		{"unterminated double quote", `echo "hello`, []string{"SC1009"}},
+		{"escaped quote", `echo \"hello`, nil},
+		{"quote inside other quote", `echo "it's fine"`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Builtin(tt.command)
+			if result.Source != "builtin" {
+				t.Errorf("Builtin() source = %q, want %q", result.Source, "builtin")
+			}
+			if len(result.Findings) != len(tt.want) {
+				t.Fatalf("Builtin(%q) returned %d findings %v, want %v", tt.command, len(result.Findings), result.Findings, tt.want)
+			}
+			for i, finding := range result.Findings {
+				if finding.Code != tt.want[i] {
+					t.Errorf("Builtin(%q) finding %d = %s, want %s", tt.command, i, finding.Code, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyReplacements(t *testing.T) {
+	command := "echo $foo $bar"
+	replacements := []shellcheckReplacement{
+		{Column: 6, EndColumn: 6, Replacement: `"`},
+		{Column: 10, EndColumn: 10, Replacement: `"`},
+		{Column: 11, EndColumn: 11, Replacement: `"`},
+		{Column: 15, EndColumn: 15, Replacement: `"`},
+	}
+
+	want := `echo "$foo" "$bar"`
+	if got := applyReplacements(command, replacements); got != want {
+		t.Errorf("applyReplacements() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyReplacementsMultibyte(t *testing.T) {
+	// shellcheck counts characters, so é is one column but two bytes
+	command := `echo "café" $x`
+	replacements := []shellcheckReplacement{
+		{Column: 13, EndColumn: 13, Replacement: `"`},
+		{Column: 15, EndColumn: 15, Replacement: `"`},
+	}
+
+	want := `echo "café" "$x"`
+	if got := applyReplacements(command, replacements); got != want {
+		t.Errorf("applyReplacements() = %q, want %q", got, want)
+	}
+
+	// Columns past the end of the line are skipped
+	out := []shellcheckReplacement{{Column: 16, EndColumn: 17, Replacement: "x"}}
+	if got := applyReplacements(command, out); got != command {
+		t.Errorf("applyReplacements() out of range = %q, want the command unchanged", got)
+	}
+}
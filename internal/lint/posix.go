@@ -0,0 +1,45 @@
+// Package lint - portability checks for strict POSIX generation
+package lint
+
+import (
+	"regexp"
+)
+
+// posixChecks flag bashisms (HP1xx) and GNU-only options (HP2xx) that break
+// on BusyBox (Alpine) and BSD userlands (macOS)
+var posixChecks = []builtinCheck{
+	{"HP101", "warning", "[[ ]] is a bashism; use [ ] in POSIX sh", regexp.MustCompile(`\[\[`)},
+	{"HP102", "warning", "'function name' is a bashism; use name() { ...; }", regexp.MustCompile(`(^|[;&|\s])function\s+\w+`)},
+	{"HP103", "warning", "'source' is a bashism; use '.'", regexp.MustCompile(`(^|[;&|]\s*|\s)source\s`)},
+	{"HP104", "warning", "Process substitution <(...) is a bashism; use a temporary file or pipe", regexp.MustCompile(`[<>]\(`)},
+	{"HP105", "warning", "$'...' quoting is a bashism; use printf", regexp.MustCompile(`\$'`)},
+	{"HP106", "warning", "&> and |& are bashisms; use > file 2>&1 and 2>&1 |", regexp.MustCompile(`&>|\|&`)},
+	{"HP107", "warning", "Brace expansion {a,b} is a bashism; list the words", regexp.MustCompile(`\{[^{}\s'"$]*,[^{}\s'"]*\}`)},
+	{"HP108", "warning", "Arrays are a bashism", regexp.MustCompile(`(^|[;&|\s])\w+=\(|\$\{\w+\[`)},
+	{"HP109", "warning", "== in [ ] is a bashism; use =", regexp.MustCompile(`\[\s[^]]*\s==\s`)},
+	{"HP110", "warning", "${var//...} and ${var:n:m} are bashisms; use sed, cut or expr", regexp.MustCompile(`\$\{\w+(//?|:-?\d)`)},
+	{"HP111", "warning", "declare, typeset and let are bashisms", regexp.MustCompile(`(^|[;&|\s])(declare|typeset|let)\s`)},
+	{"HP112", "warning", "$RANDOM is a bashism", regexp.MustCompile(`\$\{?RANDOM\b`)},
+	{"HP113", "warning", "echo -e and -n are not portable; use printf", regexp.MustCompile(`\becho\s+-[neE]+\s`)},
+
+	{"HP201", "warning", "grep -P is a GNU extension; use grep -E", regexp.MustCompile(`\bgrep\s+(\S+\s+)*-\w*P`)},
+	{"HP202", "warning", "sed -i without a suffix differs between GNU and BSD sed; write to a temporary file", regexp.MustCompile(`\bsed\s+(\S+\s+)*-i(\s|$)`)},
+	{"HP203", "warning", "find -printf is a GNU extension; use -exec printf or stat", regexp.MustCompile(`\bfind\s.*\s-printf\b`)},
+	{"HP204", "warning", "xargs -r is a GNU extension", regexp.MustCompile(`\bxargs\s+(\S+\s+)*(-r\b|--no-run-if-empty)`)},
+	{"HP205", "warning", "date -d is a GNU extension (BSD uses -j -f)", regexp.MustCompile(`\bdate\s+(\S+\s+)*(-d\s|--date)`)},
+	{"HP206", "warning", "readlink -f is not available everywhere; use cd and pwd -P", regexp.MustCompile(`\breadlink\s+-\w*f`)},
+	{"HP207", "warning", "stat -c is GNU syntax (BSD uses -f)", regexp.MustCompile(`\bstat\s+(\S+\s+)*-c\b`)},
+	{"HP208", "warning", "sort -V is a GNU extension", regexp.MustCompile(`\bsort\s+(\S+\s+)*-\w*V`)},
+	{"HP209", "warning", "Long --options are mostly GNU extensions; use the short POSIX option", regexp.MustCompile(`\b(ls|cp|mv|rm|du|df|grep|sed|sort|head|tail|cut|wc|mkdir|touch|find|xargs)\s+(\S+\s+)*--\w`)},
+}
+
+// POSIX reports bashisms and GNU-only options in a command
+func POSIX(command string) []Finding {
+	var findings []Finding
+	for _, check := range posixChecks {
+		if check.pattern.MatchString(command) {
+			findings = append(findings, Finding{Code: check.code, Level: check.level, Message: check.message})
+		}
+	}
+	return findings
+}
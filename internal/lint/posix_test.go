@@ -0,0 +1,39 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPOSIX(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{`find . -name '*.log' -exec rm {} \;`, nil},
+		{"awk '{print $1,$2}' file", nil},
+		{"[ \"$a\" = b ] && printf '%s\\n' \"$a\"", nil},
+		{"grep -E 'a|b' file | sed -e 's/a/b/' > out", nil},
+		{"if [[ -f x ]]; then source env.sh; fi", []string{"HP101", "HP103"}},
+		{"diff <(sort a) <(sort b)", []string{"HP104"}},
+		{"cp file.{txt,bak}", []string{"HP107"}},
+		{"echo -e 'a\\tb'", []string{"HP113"}},
+		{"grep -oP '\\d+' f", []string{"HP201"}},
+		{"sed -i 's/a/b/' f", []string{"HP202"}},
+		{"sed -i.bak 's/a/b/' f", nil},
+		{"find . -type f -printf '%s\\n' | xargs -r echo", []string{"HP203", "HP204"}},
+		{"date -d yesterday", []string{"HP205"}},
+		{"du --max-depth=1", []string{"HP209"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			var got []string
+			for _, finding := range POSIX(tt.command) {
+				got = append(got, finding.Code)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("POSIX(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
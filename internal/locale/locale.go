@@ -0,0 +1,94 @@
+// Package locale describes the user's locale, timezone and current date so
+// generated date arithmetic resolves relative dates the way the user means
+package locale
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sundayFirst lists territories whose weeks start on Sunday; elsewhere
+// weeks start on Monday (ISO 8601)
+var sundayFirst = map[string]bool{
+	"US": true, "CA": true, "MX": true, "BR": true, "JP": true, "KR": true,
+	"TW": true, "HK": true, "IL": true, "PH": true, "ZA": true, "IN": true,
+}
+
+// localtime is the symlink naming the system timezone
+var localtime = "/etc/localtime"
+
+// Name returns the locale that governs dates: LC_ALL, then LC_TIME, then
+// LANG, or "" when none is set or the locale is C/POSIX
+func Name() string {
+	for _, key := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if value := os.Getenv(key); value != "" {
+			if value == "C" || value == "POSIX" || strings.HasPrefix(value, "C.") {
+				return ""
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// Timezone returns the IANA name of the local timezone (Europe/Berlin),
+// from TZ or the /etc/localtime link, or "" when it cannot be named
+func Timezone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && !strings.HasPrefix(tz, "/") {
+		return tz
+	}
+	target, err := filepath.EvalSymlinks(localtime)
+	if err != nil {
+		return ""
+	}
+	if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+		return name
+	}
+	return ""
+}
+
+// FirstWeekday returns the first day of the week for a locale such as
+// en_US.UTF-8
+func FirstWeekday(locale string) time.Weekday {
+	territory := locale
+	if _, rest, ok := strings.Cut(locale, "_"); ok {
+		territory = rest
+	}
+	territory, _, _ = strings.Cut(territory, ".")
+	territory, _, _ = strings.Cut(territory, "@")
+	if sundayFirst[strings.ToUpper(territory)] {
+		return time.Sunday
+	}
+	return time.Monday
+}
+
+// Context returns a prompt section with the current local date and time,
+// timezone and locale
+func Context(now time.Time) string {
+	abbreviation, offset := now.Zone()
+	zone := fmt.Sprintf("UTC%+03d:%02d", offset/3600, abs(offset%3600)/60)
+	if name := Timezone(); name != "" {
+		zone = fmt.Sprintf("%s (%s, %s)", name, abbreviation, zone)
+	} else {
+		zone = fmt.Sprintf("%s (%s)", abbreviation, zone)
+	}
+
+	lines := []string{
+		fmt.Sprintf("Current local time: %s, timezone %s.", now.Format("Monday 2006-01-02 15:04"), zone),
+	}
+	if name := Name(); name != "" {
+		lines = append(lines, fmt.Sprintf("Locale: %s; weeks start on %s.", name, FirstWeekday(name)))
+	}
+	lines = append(lines, "Resolve relative dates (\"since Monday\", \"yesterday\", \"last week\") against this date in this timezone, e.g. as find -newermt or date -d arguments, rather than assuming UTC or a fixed number of days.")
+	return strings.Join(lines, "\n")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
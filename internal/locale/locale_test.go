@@ -0,0 +1,57 @@
+package locale
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestName(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_TIME", "de_DE.UTF-8")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if got := Name(); got != "de_DE.UTF-8" {
+		t.Errorf("Name() = %q, want LC_TIME over LANG", got)
+	}
+	t.Setenv("LC_ALL", "C.UTF-8")
+	if got := Name(); got != "" {
+		t.Errorf("Name() = %q, want none for the C locale", got)
+	}
+}
+
+func TestFirstWeekday(t *testing.T) {
+	tests := map[string]time.Weekday{
+		"en_US.UTF-8":       time.Sunday,
+		"en_GB.UTF-8":       time.Monday,
+		"de_DE":             time.Monday,
+		"ja_JP.UTF-8":       time.Sunday,
+		"sr_RS.UTF-8@latin": time.Monday,
+		"":                  time.Monday,
+	}
+	for locale, want := range tests {
+		if got := FirstWeekday(locale); got != want {
+			t.Errorf("FirstWeekday(%q) = %v, want %v", locale, got, want)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	t.Setenv("TZ", "America/New_York")
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	zone, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone database unavailable")
+	}
+	now := time.Date(2026, 10, 16, 9, 5, 0, 0, zone)
+
+	got := Context(now)
+	for _, want := range []string{
+		"Friday 2026-10-16 09:05",
+		"America/New_York (EDT, UTC-04:00)",
+		"Locale: en_US.UTF-8; weeks start on Sunday.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Context() = %q, want it to contain %q", got, want)
+		}
+	}
+}
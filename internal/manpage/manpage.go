@@ -0,0 +1,165 @@
+// Package manpage reads the locally installed manual pages, so commands
+// missing from the flag database can still be explained offline
+package manpage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// lookupTimeout bounds rendering one manual page
+const lookupTimeout = 3 * time.Second
+
+// Page is what a manual page says about a command
+type Page struct {
+	Description string            // Summary from the NAME section, e.g. "list directory contents"
+	Options     map[string]string // Flag -> first sentence of its description
+	TakesValue  []string          // Flags documented with an argument (-n NUM, --lines=NUM)
+}
+
+var (
+	// namePattern guards the command name passed to man
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.+-]*$`)
+
+	// overstrike matches the bold (x\bx) and underline (_\bx) sequences
+	// man emits when it thinks it writes to a terminal
+	overstrike = regexp.MustCompile(".\b")
+
+	// flagSpec matches one flag of an option header, with its argument
+	flagSpec = regexp.MustCompile(`^(--?[A-Za-z0-9?#][A-Za-z0-9_-]*)(\[?[ =]\S.*)?$`)
+
+	// sentenceEnd finds the end of the first sentence of a description
+	// (a lone "." is a file name, as in "entries starting with .")
+	sentenceEnd = regexp.MustCompile(`[^\s.][.;:]\s`)
+)
+
+// run renders a manual page as plain text; it is replaced in tests
+var run = func(ctx context.Context, name string) ([]byte, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "man", name)
+	cmd.Env = append(os.Environ(), "MANPAGER=cat", "PAGER=cat", "MANWIDTH=100", "MAN_KEEP_FORMATTING=")
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return out.Bytes(), err
+}
+
+// Lookup renders and parses the manual page of a command
+func Lookup(ctx context.Context, name string) (*Page, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid command name %q", name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	text, err := run(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("no manual page for %s: %w", name, err)
+	}
+	page := Parse(string(text))
+	if page.Description == "" {
+		return nil, fmt.Errorf("no manual page for %s", name)
+	}
+	return page, nil
+}
+
+// Parse extracts the summary and option descriptions from a rendered
+// manual page. Both the GNU layout (description on the lines below the
+// flags) and the BSD layout (description after the flags on the same
+// line) are understood.
+func Parse(text string) *Page {
+	text = overstrike.ReplaceAllString(text, "")
+	lines := strings.Split(text, "\n")
+	page := &Page{Options: map[string]string{}}
+
+	section := ""
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if line == trimmed && strings.ToUpper(line) == line {
+			section = line
+			continue
+		}
+
+		if section == "NAME" && page.Description == "" {
+			if _, summary, ok := strings.Cut(trimmed, " - "); ok {
+				page.Description = strings.TrimSpace(summary)
+			} else if _, summary, ok := strings.Cut(trimmed, " — "); ok {
+				page.Description = strings.TrimSpace(summary)
+			}
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+
+		// Option header; the BSD layout puts the description after a gap
+		header, description := trimmed, ""
+		if gap := strings.Index(trimmed, "  "); gap > 0 {
+			header, description = trimmed[:gap], strings.TrimSpace(trimmed[gap:])
+		}
+		if description == "" {
+			// The GNU layout continues on more indented lines
+			indent := len(line) - len(strings.TrimLeft(line, " \t"))
+			var body []string
+			for j := i + 1; j < len(lines); j++ {
+				next := strings.TrimRight(lines[j], " \t")
+				if strings.TrimSpace(next) == "" || len(next)-len(strings.TrimLeft(next, " \t")) <= indent {
+					break
+				}
+				body = append(body, strings.TrimSpace(next))
+			}
+			description = strings.Join(body, " ")
+		}
+		if description == "" {
+			continue
+		}
+		page.addOption(header, firstSentence(description))
+	}
+	return page
+}
+
+// addOption records the flags of one option header, such as
+// "-n, --lines=NUM", with their description
+func (p *Page) addOption(header, description string) {
+	var flags []string
+	takesValue := false
+	for _, spec := range strings.Split(header, ",") {
+		match := flagSpec.FindStringSubmatch(strings.TrimSpace(spec))
+		if match == nil {
+			return // Not an option header after all
+		}
+		flags = append(flags, match[1])
+		// A required argument; optional ones are written [=ARG]
+		if match[2] != "" && !strings.HasPrefix(match[2], "[") {
+			takesValue = true
+		}
+	}
+	for _, flag := range flags {
+		if _, seen := p.Options[flag]; seen {
+			continue
+		}
+		p.Options[flag] = description
+		// -n NUM takes the next argument; --lines=NUM is written as one
+		if takesValue && !strings.HasPrefix(flag, "--") {
+			p.TakesValue = append(p.TakesValue, flag)
+		}
+	}
+}
+
+// firstSentence shortens a description to its first sentence, without
+// the trailing period, to match the flag database's style
+func firstSentence(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if loc := sentenceEnd.FindStringIndex(text + " "); loc != nil {
+		text = text[:loc[0]+1]
+	}
+	return text
+}
@@ -0,0 +1,100 @@
+package manpage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const gnuPage = "LS(1)                        User Commands                        LS(1)\n" + `
+NAME
+       ls - list directory contents
+
+SYNOPSIS
+       ls [OPTION]... [FILE]...
+
+DESCRIPTION
+       List  information  about  the FILEs (the current directory by default).
+
+       -a, --all
+              do not ignore entries starting with .
+
+       --block-size=SIZE
+              with -l, scale sizes by SIZE when printing them; e.g., '--block-size=M'; see SIZE format below
+
+       -I, --ignore=PATTERN
+              do not list implied entries matching shell PATTERN
+
+       --color[=WHEN]
+              color the output WHEN; more info below
+
+       -l     use a long listing format
+`
+
+const bsdPage = `
+NAME
+     rsync — a fast, versatile, remote file-copying tool
+
+DESCRIPTION
+     -n      Perform a trial run with no changes made.  Output is the same.
+     -e command
+             Specify the remote shell to use.
+`
+
+func TestParse(t *testing.T) {
+	page := Parse(gnuPage)
+	if page.Description != "list directory contents" {
+		t.Errorf("Description = %q", page.Description)
+	}
+	want := map[string]string{
+		"-a":           "do not ignore entries starting with .",
+		"--all":        "do not ignore entries starting with .",
+		"--block-size": "with -l, scale sizes by SIZE when printing them",
+		"-I":           "do not list implied entries matching shell PATTERN",
+		"--ignore":     "do not list implied entries matching shell PATTERN",
+		"--color":      "color the output WHEN",
+		"-l":           "use a long listing format",
+	}
+	if !reflect.DeepEqual(page.Options, want) {
+		t.Errorf("Options = %q, want %q", page.Options, want)
+	}
+	if want := []string{"-I"}; !reflect.DeepEqual(page.TakesValue, want) {
+		t.Errorf("TakesValue = %q, want %q", page.TakesValue, want)
+	}
+
+	page = Parse(bsdPage)
+	if page.Description != "a fast, versatile, remote file-copying tool" {
+		t.Errorf("Description = %q", page.Description)
+	}
+	if page.Options["-n"] != "Perform a trial run with no changes made" || page.Options["-e"] != "Specify the remote shell to use" {
+		t.Errorf("Options = %q", page.Options)
+	}
+}
+
+func TestParseOverstrike(t *testing.T) {
+	page := Parse("N\bNA\bAM\bME\bE\n       g\bgr\bre\bep\bp - print lines that match patterns\n       -\b-v\bv, -\b-_\bi_\bn_\bv_\be_\br_\bt\n              Invert the sense of matching.\n")
+	if page.Description != "print lines that match patterns" || page.Options["-v"] != "Invert the sense of matching" {
+		t.Errorf("Parse() = %+v", page)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	orig := run
+	t.Cleanup(func() { run = orig })
+	run = func(ctx context.Context, name string) ([]byte, error) {
+		if name == "ls" {
+			return []byte(gnuPage), nil
+		}
+		return nil, errors.New("exit status 16")
+	}
+
+	if page, err := Lookup(context.Background(), "ls"); err != nil || page.Options["-l"] == "" {
+		t.Errorf("Lookup(ls) = %+v, %v", page, err)
+	}
+	for _, name := range []string{"frobnicate", "-rf", "ls;reboot"} {
+		if _, err := Lookup(context.Background(), name); err == nil {
+			t.Errorf("Lookup(%q) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// desktopTimeout bounds how long sending a desktop notification may take
+const desktopTimeout = 2 * time.Second
+
+// Desktop shows a desktop notification using the platform's native tool
+// (notify-send on Linux/BSD, osascript on macOS, PowerShell toast on Windows)
+func Desktop(ctx context.Context, title, message string) error {
+	ctx, cancel := context.WithTimeout(ctx, desktopTimeout)
+	defer cancel()
+
+	name, args := desktopCommand(runtime.GOOS, title, message)
+	if err := runDesktop(ctx, name, args...); err != nil {
+		return fmt.Errorf("desktop notification failed: %w", err)
+	}
+	return nil
+}
+
+// runDesktop executes the notification tool; it is replaced in tests
+var runDesktop = func(ctx context.Context, name string, args ...string) error {
+	return exec.CommandContext(ctx, name, args...).Run()
+}
+
+// desktopCommand returns the notification tool and its arguments for goos
+func desktopCommand(goos, title, message string) (string, []string) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptQuote(message), appleScriptQuote(title))
+		return "osascript", []string{"-e", script}
+	case "windows":
+		script := fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode(%s)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode(%s)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('hermes').Show([Windows.UI.Notifications.ToastNotification]::new($xml))`,
+			powerShellQuote(title), powerShellQuote(message))
+		return "powershell", []string{"-NoProfile", "-Command", script}
+	default:
+		return "notify-send", []string{"--app-name=hermes", title, message}
+	}
+}
+
+// appleScriptQuote quotes s as an AppleScript string literal
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// powerShellQuote quotes s as a PowerShell single-quoted string literal
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
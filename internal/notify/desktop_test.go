@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDesktopCommand(t *testing.T) {
+	title, message := `hermes: "ready"`, `rm -rf 'build' \ dist`
+
+	name, args := desktopCommand("linux", title, message)
+	if want := []string{"--app-name=hermes", title, message}; name != "notify-send" || !reflect.DeepEqual(args, want) {
+		t.Errorf("linux = %s %q, want notify-send %q", name, args, want)
+	}
+	if name, _ := desktopCommand("freebsd", title, message); name != "notify-send" {
+		t.Errorf("freebsd tool = %s, want notify-send", name)
+	}
+
+	name, args = desktopCommand("darwin", title, message)
+	want := `display notification "rm -rf 'build' \\ dist" with title "hermes: \"ready\""`
+	if name != "osascript" || !reflect.DeepEqual(args, []string{"-e", want}) {
+		t.Errorf("darwin = %s %q, want osascript -e %q", name, args, want)
+	}
+
+	name, args = desktopCommand("windows", title, message)
+	if name != "powershell" || len(args) != 3 || args[0] != "-NoProfile" || args[1] != "-Command" {
+		t.Fatalf("windows = %s %q, want powershell -NoProfile -Command <script>", name, args)
+	}
+	for _, quoted := range []string{`CreateTextNode('hermes: "ready"')`, `CreateTextNode('rm -rf ''build'' \ dist')`} {
+		if !strings.Contains(args[2], quoted) {
+			t.Errorf("windows script is missing %s:\n%s", quoted, args[2])
+		}
+	}
+}
+
+func TestDesktop(t *testing.T) {
+	var gotName string
+	var gotArgs []string
+	var hasDeadline bool
+	original := runDesktop
+	t.Cleanup(func() { runDesktop = original })
+	runDesktop = func(ctx context.Context, name string, args ...string) error {
+		gotName, gotArgs = name, args
+		_, hasDeadline = ctx.Deadline()
+		return nil
+	}
+
+	if err := Desktop(context.Background(), "hermes: command ready", "ls -la"); err != nil {
+		t.Fatalf("Desktop() error = %v", err)
+	}
+	wantName, wantArgs := desktopCommand(runtime.GOOS, "hermes: command ready", "ls -la")
+	if gotName != wantName || !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("ran %s %q, want %s %q", gotName, gotArgs, wantName, wantArgs)
+	}
+	if !hasDeadline {
+		t.Error("the notification tool ran without a timeout")
+	}
+
+	runDesktop = func(ctx context.Context, name string, args ...string) error {
+		return errors.New("exit status 1")
+	}
+	if err := Desktop(context.Background(), "t", "m"); err == nil || !strings.Contains(err.Error(), "desktop notification failed") {
+		t.Errorf("Desktop() with a failing tool error = %v, want it wrapped", err)
+	}
+}
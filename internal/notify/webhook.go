@@ -0,0 +1,89 @@
+// Package notify delivers notifications about hermes generations
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"hermes/internal/redact"
+)
+
+// webhookTimeout bounds how long a webhook delivery may delay the CLI
+const webhookTimeout = 3 * time.Second
+
+// Event describes a generation worth reporting
+type Event struct {
+	Host    string    `json:"host"`
+	User    string    `json:"user"`
+	Command string    `json:"command"`
+	Level   string    `json:"level"`
+	Reason  string    `json:"reason"`
+	Time    time.Time `json:"time"`
+}
+
+// LevelForbidden is the Event level of a command hermes refused to hand out
+const LevelForbidden = "forbidden"
+
+// webhookPayload is compatible with Slack incoming webhooks (which read
+// "text") while still carrying the structured event for other receivers
+type webhookPayload struct {
+	Text  string `json:"text"`
+	Event Event  `json:"event"`
+}
+
+// HostMatches reports whether host matches any of the glob patterns.
+// An empty pattern list matches every host.
+func HostMatches(patterns []string, host string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, host); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook posts the event as JSON to the given URL. Credentials in the
+// command are masked first, since the receiver is usually a third party.
+func Webhook(ctx context.Context, url string, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	event.Command = redact.New().Redact(event.Command)
+
+	text := fmt.Sprintf(":warning: hermes generated a %s command on %s (%s): `%s` (%s)", event.Level, event.Host, event.User, event.Command, event.Reason)
+	if event.Level == LevelForbidden {
+		text = fmt.Sprintf(":no_entry: hermes refused a command on %s (%s): `%s` (%s)", event.Host, event.User, event.Command, event.Reason)
+	}
+	body, err := json.Marshal(webhookPayload{
+		Text:  text,
+		Event: event,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHostMatches(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		host     string
+		want     bool
+	}{
+		{nil, "laptop", true},
+		{[]string{"prod-*"}, "prod-db1", true},
+		{[]string{"prod-*"}, "staging-db1", false},
+		{[]string{"db?", "*.example.com"}, "web.example.com", true},
+		{[]string{"db?"}, "db10", false},
+		{[]string{"[invalid"}, "[invalid", false},
+	}
+	for _, tt := range tests {
+		if got := HostMatches(tt.patterns, tt.host); got != tt.want {
+			t.Errorf("HostMatches(%q, %q) = %v, want %v", tt.patterns, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	var payload webhookPayload
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid payload %s: %v", body, err)
+		}
+	}))
+	defer server.Close()
+
+	event := Event{
+		Host:    "prod-db1",
+		User:    "alice",
+		Command: "mysql -u root --password=hunter22 -e 'DROP TABLE users'",
+		Level:   "Requires Attention",
+		Reason:  "drops a table",
+		Time:    time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}
+	if err := Webhook(context.Background(), server.URL, event); err != nil {
+		t.Fatalf("Webhook() error = %v", err)
+	}
+
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	if strings.Contains(payload.Text, "hunter22") || strings.Contains(payload.Event.Command, "hunter22") {
+		t.Errorf("payload leaks the password: %+v", payload)
+	}
+	if want := "mysql -u root --password=__SECRET_1__ -e 'DROP TABLE users'"; payload.Event.Command != want {
+		t.Errorf("Event.Command = %q, want %q", payload.Event.Command, want)
+	}
+	if !strings.Contains(payload.Text, "Requires Attention command on prod-db1 (alice)") || !strings.Contains(payload.Text, "(drops a table)") {
+		t.Errorf("Text = %q", payload.Text)
+	}
+	if payload.Event.Host != "prod-db1" || !payload.Event.Time.Equal(event.Time) {
+		t.Errorf("Event = %+v, want the structured event", payload.Event)
+	}
+}
+
+func TestWebhookRefusalText(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	event := Event{Host: "prod-db1", User: "alice", Command: "apt-get install -y nginx", Level: LevelForbidden, Reason: "installs packages on a production host"}
+	if err := Webhook(context.Background(), server.URL, event); err != nil {
+		t.Fatalf("Webhook() error = %v", err)
+	}
+	if want := "hermes refused a command on prod-db1 (alice): `apt-get install -y nginx` (installs packages on a production host)"; !strings.Contains(payload.Text, want) {
+		t.Errorf("Text = %q, want it to contain %q", payload.Text, want)
+	}
+}
+
+func TestWebhookStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if err := Webhook(context.Background(), server.URL, Event{Command: "ls"}); err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("Webhook() error = %v, want the status reported", err)
+	}
+}
@@ -0,0 +1,125 @@
+// Package pathcheck finds programs a command runs that are not installed
+package pathcheck
+
+import (
+	"os/exec"
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// lookPath is replaced in tests
+var lookPath = exec.LookPath
+
+// builtins are shell builtins and keywords that never live on PATH
+var builtins = map[string]bool{
+	".": true, ":": true, "[": true, "[[": true, "!": true, "{": true, "}": true,
+	"alias": true, "bg": true, "break": true, "builtin": true, "case": true, "cd": true,
+	"command": true, "continue": true, "declare": true, "do": true, "done": true,
+	"echo": true, "elif": true, "else": true, "esac": true, "eval": true, "exec": true,
+	"exit": true, "export": true, "false": true, "fg": true, "fi": true, "for": true,
+	"function": true, "getopts": true, "hash": true, "if": true, "in": true, "jobs": true,
+	"let": true, "local": true, "printf": true, "pwd": true, "read": true, "readonly": true,
+	"return": true, "select": true, "set": true, "shift": true, "source": true, "test": true,
+	"then": true, "time": true, "trap": true, "true": true, "type": true, "typeset": true,
+	"ulimit": true, "umask": true, "unalias": true, "unset": true, "until": true,
+	"wait": true, "while": true,
+}
+
+// prefixes are keywords followed by a command
+var prefixes = map[string]bool{
+	"!": true, "{": true, "do": true, "elif": true, "else": true, "if": true,
+	"then": true, "until": true, "while": true,
+}
+
+// wrappers run the command that follows them; the value lists options
+// that take an argument, which must be skipped along with the option
+var wrappers = map[string][]string{
+	"sudo":    {"-u", "-g", "-C", "-D", "-h", "-p", "-U"},
+	"doas":    {"-u", "-C"},
+	"env":     {"-u", "-C", "-S"},
+	"nohup":   nil,
+	"nice":    {"-n"},
+	"ionice":  {"-c", "-n", "-p"},
+	"exec":    {"-a"},
+	"command": nil,
+	"time":    {"-f", "-o"},
+	"timeout": {"-s", "-k"},
+	"xargs":   {"-I", "-n", "-P", "-d", "-L", "-s", "-E", "-a"},
+	"watch":   {"-n", "-d"},
+	"stdbuf":  {"-i", "-o", "-e"},
+}
+
+// Missing returns the programs a POSIX command runs that are neither shell
+// builtins nor found on PATH, in order of appearance. Programs given by
+// path or through variables are not checked, since they may be created or
+// set by an earlier part of the command. Unparsable commands yield nil.
+func Missing(command string) []string {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return nil
+	}
+
+	var missing []string
+	seen := map[string]bool{}
+	for _, stage := range script.Stages() {
+		for _, name := range programs(stage.Args) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if _, err := lookPath(name); err != nil {
+				missing = append(missing, name)
+			}
+		}
+	}
+	return missing
+}
+
+// programs returns the names of the programs a stage runs: its command
+// name, plus the command run by wrappers like sudo and xargs
+func programs(args []shell.Token) []string {
+	var names []string
+	i := 0
+	for i < len(args) {
+		// Skip leading variable assignments (FOO=bar cmd) and keywords
+		word := args[i]
+		if prefixes[word.Value] || strings.Contains(word.Value, "=") && !word.Quoted() && !strings.HasPrefix(word.Value, "-") {
+			i++
+			continue
+		}
+		name := word.Value
+		if !checkable(name) {
+			return names
+		}
+		if !builtins[name] {
+			names = append(names, name)
+		}
+		takesArg, wraps := wrappers[name]
+		if !wraps {
+			return names
+		}
+
+		// Skip the wrapper's options to find the command it runs
+		i++
+		for i < len(args) && strings.HasPrefix(args[i].Value, "-") {
+			option := args[i].Value
+			i++
+			for _, o := range takesArg {
+				if option == o {
+					i++
+					break
+				}
+			}
+		}
+		if name == "timeout" && i < len(args) {
+			i++ // The duration
+		}
+	}
+	return names
+}
+
+// checkable reports whether a command name can be looked up on PATH
+func checkable(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "/$`{}*?") && !strings.HasPrefix(name, "(")
+}
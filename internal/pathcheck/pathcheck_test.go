@@ -0,0 +1,39 @@
+package pathcheck
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMissing(t *testing.T) {
+	installed := map[string]bool{"ls": true, "grep": true, "sudo": true, "xargs": true, "find": true, "timeout": true}
+	origLookPath := lookPath
+	t.Cleanup(func() { lookPath = origLookPath })
+	lookPath = func(file string) (string, error) {
+		if installed[file] {
+			return "/usr/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"ls -la | grep go", nil},
+		{"ffmpeg -i in.mkv out.mp4", []string{"ffmpeg"}},
+		{"sudo -u www-data rg TODO", []string{"rg"}},
+		{"find . -name '*.png' | xargs -I {} magick {} {}.jpg", []string{"magick"}},
+		{"timeout 5s jq . data.json && jq -r .name data.json", []string{"jq"}},
+		{"cd /tmp && echo done", nil},
+		{"LANG=C ./configure && $EDITOR notes", nil},
+		{"for f in *.txt; do cat \"$f\"; done", []string{"cat"}},
+		{"ls 'unterminated", nil},
+	}
+	for _, tt := range tests {
+		if got := Missing(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Missing(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
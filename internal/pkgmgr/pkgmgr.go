@@ -0,0 +1,201 @@
+// Package pkgmgr detects the system package manager and builds install
+// commands for missing programs
+package pkgmgr
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// Manager is a system package manager
+type Manager struct {
+	Name    string // apt, dnf, pacman, zypper, apk or brew
+	Binary  string // Program that identifies the manager on PATH
+	Install string // Install command prefix
+}
+
+// managers in detection order; brew comes first on macOS, where Linux
+// package managers are never the system's own
+var managers = []Manager{
+	{Name: "apt", Binary: "apt-get", Install: "sudo apt install"},
+	{Name: "dnf", Binary: "dnf", Install: "sudo dnf install"},
+	{Name: "pacman", Binary: "pacman", Install: "sudo pacman -S"},
+	{Name: "zypper", Binary: "zypper", Install: "sudo zypper install"},
+	{Name: "apk", Binary: "apk", Install: "sudo apk add"},
+	{Name: "brew", Binary: "brew", Install: "brew install"},
+}
+
+// packages maps programs to their package where the names differ, per
+// manager; "" applies to every manager without its own entry
+var packages = map[string]map[string]string{
+	"rg":         {"": "ripgrep"},
+	"fd":         {"": "fd", "apt": "fd-find"},
+	"magick":     {"": "imagemagick", "dnf": "ImageMagick", "zypper": "ImageMagick"},
+	"convert":    {"": "imagemagick", "dnf": "ImageMagick", "zypper": "ImageMagick"},
+	"ffprobe":    {"": "ffmpeg"},
+	"http":       {"": "httpie"},
+	"trash-put":  {"": "trash-cli"},
+	"7z":         {"": "p7zip", "apt": "p7zip-full"},
+	"dig":        {"": "bind-utils", "apt": "dnsutils", "pacman": "bind", "brew": "bind"},
+	"pip3":       {"": "python3-pip", "pacman": "python-pip", "apk": "py3-pip", "brew": "python"},
+	"shellcheck": {"": "shellcheck", "dnf": "ShellCheck", "zypper": "ShellCheck"},
+	"batcat":     {"": "bat"},
+	"gpg":        {"": "gnupg"},
+
+	// Programs and project names users ask to install by
+	"imagemagick": {"": "imagemagick", "dnf": "ImageMagick", "zypper": "ImageMagick"},
+	"node":        {"": "nodejs", "brew": "node"},
+	"python":      {"": "python3", "pacman": "python", "brew": "python"},
+	"nvim":        {"": "neovim"},
+	"ag":          {"": "the_silver_searcher", "apt": "silversearcher-ag"},
+	"delta":       {"": "git-delta"},
+	"docker":      {"": "docker", "apt": "docker.io"},
+}
+
+// aliases maps other programs that drive a manager to its name
+var aliases = map[string]string{
+	"apt": "apt", "aptitude": "apt", "yum": "dnf",
+}
+
+// lookPath is replaced in tests
+var lookPath = exec.LookPath
+
+// Detect returns the system package manager, if any is installed
+func Detect() (Manager, bool) {
+	candidates := managers
+	if runtime.GOOS == "darwin" {
+		candidates = append([]Manager{managers[len(managers)-1]}, managers[:len(managers)-1]...)
+	}
+	for _, m := range candidates {
+		if _, err := lookPath(m.Binary); err == nil {
+			return m, true
+		}
+	}
+	return Manager{}, false
+}
+
+// Lookup returns the manager with the given name
+func Lookup(name string) (Manager, bool) {
+	for _, m := range managers {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Manager{}, false
+}
+
+// Invoked returns the package manager a command line runs, if any,
+// looking through sudo
+func Invoked(command string) (Manager, bool) {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return Manager{}, false
+	}
+	for _, stage := range script.Stages() {
+		name := stage.Name()
+		if name == "sudo" || name == "doas" {
+			name = elevated(stage.Args)
+		}
+		if m, ok := managerFor(name); ok {
+			return m, true
+		}
+	}
+	return Manager{}, false
+}
+
+// sudoValueOptions are the sudo and doas options that take a value
+var sudoValueOptions = map[string]bool{
+	"-u": true, "-g": true, "-C": true, "-D": true, "-h": true, "-p": true, "-r": true,
+	"-t": true, "-T": true, "-U": true, "-R": true,
+	"--user": true, "--group": true, "--close-from": true, "--chdir": true, "--host": true,
+	"--prompt": true, "--role": true, "--type": true, "--command-timeout": true,
+	"--other-user": true, "--chroot": true,
+}
+
+// elevated returns the program a sudo or doas stage runs, skipping its
+// options, their values and environment assignments
+func elevated(args []shell.Token) string {
+	for i := 1; i < len(args); i++ {
+		arg := args[i].Value
+		switch {
+		case arg == "--":
+			if i+1 < len(args) {
+				return args[i+1].Value
+			}
+			return ""
+		case strings.HasPrefix(arg, "--"):
+			if !strings.Contains(arg, "=") && sudoValueOptions[arg] {
+				i++
+			}
+		case strings.HasPrefix(arg, "-"):
+			// A cluster such as -Eu takes a value when its last option
+			// does, unless the value is attached (-uroot)
+			for j := 1; j < len(arg); j++ {
+				if sudoValueOptions["-"+arg[j:j+1]] {
+					if j == len(arg)-1 {
+						i++
+					}
+					break
+				}
+			}
+		case strings.Contains(arg, "=") && !strings.HasPrefix(arg, "="):
+			// VAR=value environment assignment
+		default:
+			return arg
+		}
+	}
+	return ""
+}
+
+// managerFor returns the manager a program belongs to
+func managerFor(program string) (Manager, bool) {
+	if name, ok := aliases[program]; ok {
+		return Lookup(name)
+	}
+	for _, m := range managers {
+		if m.Binary == program {
+			return m, true
+		}
+	}
+	return Manager{}, false
+}
+
+// Names returns the names by which a query might mention the manager
+func (m Manager) Names() []string {
+	names := []string{m.Name, m.Binary}
+	for alias, name := range aliases {
+		if name == m.Name && alias != m.Name {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+// Package returns the package that provides a program
+func (m Manager) Package(program string) string {
+	names, ok := packages[program]
+	if !ok {
+		return program
+	}
+	if name, ok := names[m.Name]; ok {
+		return name
+	}
+	return names[""]
+}
+
+// InstallCommand returns the command installing the packages that provide
+// the given programs
+func (m Manager) InstallCommand(programs ...string) string {
+	var pkgs []string
+	seen := map[string]bool{}
+	for _, program := range programs {
+		if pkg := m.Package(program); !seen[pkg] {
+			seen[pkg] = true
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return m.Install + " " + strings.Join(pkgs, " ")
+}
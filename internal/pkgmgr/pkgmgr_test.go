@@ -0,0 +1,76 @@
+package pkgmgr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInstallCommand(t *testing.T) {
+	tests := []struct {
+		manager  string
+		programs []string
+		want     string
+	}{
+		{"apt", []string{"rg", "fd"}, "sudo apt install ripgrep fd-find"},
+		{"pacman", []string{"magick", "convert"}, "sudo pacman -S imagemagick"},
+		{"dnf", []string{"magick", "jq"}, "sudo dnf install ImageMagick jq"},
+		{"brew", []string{"ffprobe"}, "brew install ffmpeg"},
+	}
+	for _, tt := range tests {
+		var m Manager
+		for _, candidate := range managers {
+			if candidate.Name == tt.manager {
+				m = candidate
+			}
+		}
+		if got := m.InstallCommand(tt.programs...); got != tt.want {
+			t.Errorf("%s InstallCommand(%v) = %q, want %q", tt.manager, tt.programs, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	origLookPath := lookPath
+	t.Cleanup(func() { lookPath = origLookPath })
+	lookPath = func(file string) (string, error) {
+		if file == "pacman" {
+			return "/usr/bin/pacman", nil
+		}
+		return "", errors.New("not found")
+	}
+	if m, ok := Detect(); !ok || m.Name != "pacman" {
+		t.Errorf("Detect() = %+v, %v, want pacman", m, ok)
+	}
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	if _, ok := Detect(); ok {
+		t.Error("Detect() found a package manager on an empty PATH")
+	}
+}
+
+func TestInvoked(t *testing.T) {
+	tests := map[string]string{
+		"sudo apt-get install -y htop":                             "apt",
+		"sudo -E yum install nginx":                                "dnf",
+		"sudo -u root yum install x":                               "dnf",
+		"sudo -Eu root apt install x":                              "apt",
+		"sudo -uroot pacman -S x":                                  "pacman",
+		"sudo --user root zypper install x":                        "zypper",
+		"sudo --user=root apk add x":                               "apk",
+		"sudo -g wheel -D / dnf install x":                         "dnf",
+		"sudo DEBIAN_FRONTEND=noninteractive apt-get install -y x": "apt",
+		"sudo -- apt install x":                                    "apt",
+		"doas -u root apk add curl":                                "apk",
+		"sudo -u apt ls":                                           "",
+		"brew update && brew install jq":                           "brew",
+		"cd /tmp && doas apk add curl":                             "apk",
+		"pip install requests":                                     "",
+		"echo apt-get install is documented":                       "",
+	}
+	for command, want := range tests {
+		m, ok := Invoked(command)
+		if ok != (want != "") || m.Name != want {
+			t.Errorf("Invoked(%q) = %q, %v, want %q", command, m.Name, ok, want)
+		}
+	}
+}
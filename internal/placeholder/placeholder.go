@@ -0,0 +1,72 @@
+// Package placeholder fills named {placeholders} in generated command
+// templates, quoting each value for where it appears in the command
+package placeholder
+
+import (
+	"regexp"
+	"strings"
+)
+
+// namePattern is what a placeholder name may look like
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// plainValue needs no quoting anywhere in a POSIX command
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=~-]+$`)
+
+// Present reports whether {name} appears in command. Only names the model
+// declared are ever treated as placeholders, so braces in find -exec {},
+// ${VAR} or awk programs are left alone.
+func Present(command, name string) bool {
+	return namePattern.MatchString(name) && strings.Contains(command, "{"+name+"}")
+}
+
+// Fill replaces every {name} in command with its value. With posix set,
+// values are quoted for the context they land in: bare, inside single
+// quotes or inside double quotes. Names without a value are left as is.
+func Fill(command string, values map[string]string, posix bool) string {
+	var b strings.Builder
+	var quote byte // 0, '\'' or '"': the quoting context at position i
+	for i := 0; i < len(command); i++ {
+		if command[i] == '{' {
+			if end := strings.IndexByte(command[i:], '}'); end > 0 {
+				name := command[i+1 : i+end]
+				if value, ok := values[name]; ok && namePattern.MatchString(name) {
+					if posix {
+						value = quoteFor(value, quote)
+					}
+					b.WriteString(value)
+					i += end
+					continue
+				}
+			}
+		}
+
+		c := command[i]
+		b.WriteByte(c)
+		switch {
+		case c == '\\' && quote != '\'' && i+1 < len(command):
+			// Escaped character: copy it without changing the context
+			i++
+			b.WriteByte(command[i])
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote == c:
+			quote = 0
+		}
+	}
+	return b.String()
+}
+
+// quoteFor quotes value for the given quoting context
+func quoteFor(value string, quote byte) string {
+	switch {
+	case quote == '\'':
+		return strings.ReplaceAll(value, "'", `'\''`)
+	case quote == '"':
+		return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+	case plainValue.MatchString(value):
+		return value
+	default:
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	}
+}
@@ -0,0 +1,36 @@
+package placeholder
+
+import "testing"
+
+func TestFill(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		values  map[string]string
+		want    string
+	}{
+		{"plain", "tar -czf {archive_name}.tar.gz {directory}", map[string]string{"archive_name": "backup", "directory": "src/"}, "tar -czf backup.tar.gz src/"},
+		{"bare with spaces", "tar -czf {archive}.tar.gz {directory}", map[string]string{"archive": "my backup", "directory": "it's here"}, `tar -czf 'my backup'.tar.gz 'it'\''s here'`},
+		{"inside double quotes", `grep -r "{pattern}" .`, map[string]string{"pattern": `say "$HOME"`}, `grep -r "say \"\$HOME\"" .`},
+		{"inside single quotes", "awk '/{pattern}/'", map[string]string{"pattern": "it's"}, `awk '/it'\''s/'`},
+		{"undeclared braces untouched", `find . -name '*.log' -exec rm {} \; && echo ${HOME} {dir}`, map[string]string{"dir": "logs"}, `find . -name '*.log' -exec rm {} \; && echo ${HOME} logs`},
+		{"escaped quote keeps context", `echo \"{name}`, map[string]string{"name": "a b"}, `echo \"'a b'`},
+		{"missing value left", "scp {file} {host}:", map[string]string{"file": "a.txt"}, "scp a.txt {host}:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fill(tt.command, tt.values, true); got != tt.want {
+				t.Errorf("Fill() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPresent(t *testing.T) {
+	if !Present("ls {dir}", "dir") {
+		t.Error("Present(ls {dir}, dir) = false")
+	}
+	if Present("rm {}", "") || Present("ls {a b}", "a b") {
+		t.Error("Present() accepted an invalid name")
+	}
+}
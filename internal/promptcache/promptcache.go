@@ -0,0 +1,164 @@
+// Package promptcache remembers provider-side prompt caches between hermes
+// runs, so the static instructions are uploaded once per cache lifetime
+// instead of with every request
+package promptcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// entry is one remembered cache
+type entry struct {
+	Name    string `json:"name"`    // Provider cache name; empty when the provider refused to cache
+	Expires int64  `json:"expires"` // Unix time the provider drops the cache
+}
+
+// Store keeps cache names in a state file shared by all hermes processes.
+// Two processes may each create a cache for the same instructions; the
+// spare one simply expires.
+type Store struct {
+	path string
+	now  func() time.Time
+}
+
+// New returns a store keeping its state in the file at path
+func New(path string) *Store {
+	return &Store{path: path, now: time.Now}
+}
+
+// DefaultPath returns the cache file under the XDG state directory
+func DefaultPath() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "hermes", "prompt_cache.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "hermes", "prompt_cache.json")
+}
+
+// Get returns the cache remembered for key. ok is false when there is none
+// or it has expired; an empty name with ok set means the provider refused
+// to cache these instructions and should not be asked again yet.
+func (s *Store) Get(key string) (name string, ok bool) {
+	all, err := s.load()
+	if err != nil {
+		return "", false
+	}
+	e, found := all[key]
+	if !found || s.now().Unix() >= e.Expires {
+		return "", false
+	}
+	return e.Name, true
+}
+
+// Put remembers the cache for key until expires, dropping expired entries
+func (s *Store) Put(key, name string, expires time.Time) error {
+	all, err := s.load()
+	if err != nil {
+		// A corrupt file only loses remembered caches
+		all = map[string]entry{}
+	}
+	now := s.now().Unix()
+	for k, e := range all {
+		if now >= e.Expires {
+			delete(all, k)
+		}
+	}
+	all[key] = entry{Name: name, Expires: expires.Unix()}
+	return s.save(all)
+}
+
+// Listing describes one remembered cache for hermes cache list
+type Listing struct {
+	Key     string
+	Name    string // Empty when the provider refused to cache
+	Expires time.Time
+}
+
+// List returns the remembered caches that have not expired, soonest to
+// expire first
+func (s *Store) List() ([]Listing, error) {
+	all, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().Unix()
+	var listings []Listing
+	for key, e := range all {
+		if now < e.Expires {
+			listings = append(listings, Listing{Key: key, Name: e.Name, Expires: time.Unix(e.Expires, 0)})
+		}
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		if !listings[i].Expires.Equal(listings[j].Expires) {
+			return listings[i].Expires.Before(listings[j].Expires)
+		}
+		return listings[i].Key < listings[j].Key
+	})
+	return listings, nil
+}
+
+// GC drops expired entries and returns how many it dropped
+func (s *Store) GC() (int, error) {
+	all, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	now := s.now().Unix()
+	removed := 0
+	for k, e := range all {
+		if now >= e.Expires {
+			delete(all, k)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.save(all)
+}
+
+// Clear forgets every remembered cache; the provider drops them when they
+// expire
+func (s *Store) Clear() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear prompt cache file: %w", err)
+	}
+	return nil
+}
+
+// load reads the state file; a missing file means nothing is cached yet
+func (s *Store) load() (map[string]entry, error) {
+	all := map[string]entry{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt cache file: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("invalid prompt cache file %s: %w", s.path, err)
+	}
+	return all, nil
+}
+
+// save writes the state file atomically
+func (s *Store) save(all map[string]entry) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create prompt cache directory: %w", err)
+	}
+	data, _ := json.Marshal(all) // Only plain values, cannot fail
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save prompt cache file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
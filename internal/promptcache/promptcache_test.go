@@ -0,0 +1,84 @@
+package promptcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	store := New(filepath.Join(t.TempDir(), "prompt_cache.json"))
+	store.now = func() time.Time { return now }
+
+	if _, ok := store.Get("generate"); ok {
+		t.Fatal("Get() found a cache in an empty store")
+	}
+	if err := store.Put("generate", "cachedContents/abc", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put("explain", "", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if name, ok := store.Get("generate"); !ok || name != "cachedContents/abc" {
+		t.Errorf("Get(generate) = %q, %v, want the cache name", name, ok)
+	}
+	if name, ok := store.Get("explain"); !ok || name != "" {
+		t.Errorf("Get(explain) = %q, %v, want a remembered refusal", name, ok)
+	}
+
+	// Expired entries are gone and dropped on the next write
+	now = now.Add(30 * time.Minute)
+	if _, ok := store.Get("explain"); ok {
+		t.Error("Get(explain) returned an expired entry")
+	}
+	if err := store.Put("other", "cachedContents/def", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	all, err := store.load()
+	if err != nil || len(all) != 2 {
+		t.Errorf("load() = %v, %v, want the two live entries", all, err)
+	}
+}
+
+func TestStoreListAndGC(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	store := New(filepath.Join(t.TempDir(), "prompt_cache.json"))
+	store.now = func() time.Time { return now }
+	store.Put("generate", "cachedContents/abc", now.Add(time.Hour))
+	store.Put("explain", "", now.Add(time.Minute))
+
+	listings, err := store.List()
+	if err != nil || len(listings) != 2 || listings[0].Key != "explain" || listings[1].Name != "cachedContents/abc" {
+		t.Fatalf("List() = %+v, %v, want both entries, soonest to expire first", listings, err)
+	}
+
+	now = now.Add(30 * time.Minute)
+	if removed, err := store.GC(); err != nil || removed != 1 {
+		t.Errorf("GC() = %d, %v, want the expired entry removed", removed, err)
+	}
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if listings, err := store.List(); err != nil || len(listings) != 0 {
+		t.Errorf("List() after Clear() = %+v, %v, want nothing", listings, err)
+	}
+}
+
+func TestStoreCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt_cache.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := New(path)
+	if _, ok := store.Get("generate"); ok {
+		t.Error("Get() found a cache in a corrupt file")
+	}
+	if err := store.Put("generate", "cachedContents/abc", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Put() over a corrupt file error = %v", err)
+	}
+	if name, ok := store.Get("generate"); !ok || name != "cachedContents/abc" {
+		t.Errorf("Get() = %q, %v after rewriting the file", name, ok)
+	}
+}
@@ -0,0 +1,157 @@
+// Package redact replaces credentials in text with placeholders before it
+// is sent to an AI provider, and restores them in the generated command
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// detector finds one kind of secret. When the pattern has a capture group,
+// only the first group is the secret (the rest is context like "password=").
+type detector struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// detectors run in order; multi-line blocks come first so their contents
+// are not picked apart by the token detectors
+var detectors = []detector{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"aws access key", regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{"aws secret key", regexp.MustCompile(`(?i)aws_secret_access_key\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"google api key", regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})`)},
+	{"github token", regexp.MustCompile(`\b((?:gh[pousr]_[A-Za-z0-9]{36,})|(?:github_pat_[A-Za-z0-9_]{22,}))`)},
+	{"slack token", regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})`)},
+	{"stripe key", regexp.MustCompile(`\b((?:sk|rk)_live_[0-9A-Za-z]{24,})`)},
+	{"api key", regexp.MustCompile(`\b(sk-[A-Za-z0-9_-]{20,})`)},
+	{"jwt", regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,})`)},
+	{"url credentials", regexp.MustCompile(`://[^:/\s@]+:([^@\s]+)@`)},
+	{"authorization header", regexp.MustCompile(`(?i)authorization:\s*(?:bearer|basic|token)\s+([^\s"']+)`)},
+	{"password", regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api[_-]?key)[\w-]*["']?\s*[=:]\s*["']?([^\s"']{4,})`)},
+	{"password flag", regexp.MustCompile(`(?i)--(?:password|token|secret|api-key)[\s=]+["']?([^\s"']+)`)},
+}
+
+// candidatePattern finds long opaque tokens for the entropy check
+var candidatePattern = regexp.MustCompile(`[A-Za-z0-9+/_=-]{24,}`)
+
+// hexPattern matches hashes and checksums, which are useful in commands
+// (git SHAs, sha256sum output) and never credentials on their own
+var hexPattern = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// minEntropy is the Shannon entropy (bits per character) above which a
+// long mixed-case alphanumeric token is treated as a secret
+const minEntropy = 4.2
+
+// Redactor replaces secrets with stable placeholders and remembers them
+// so they can be restored later. The same secret always maps to the same
+// placeholder.
+type Redactor struct {
+	bySecret      map[string]string
+	byPlaceholder map[string]string
+}
+
+// New creates an empty redactor
+func New() *Redactor {
+	return &Redactor{bySecret: map[string]string{}, byPlaceholder: map[string]string{}}
+}
+
+// Redact returns text with every detected secret replaced by a placeholder
+// such as __SECRET_1__
+func (r *Redactor) Redact(text string) string {
+	for _, d := range detectors {
+		text = replaceSecrets(text, d.pattern, r.placeholder)
+	}
+
+	return candidatePattern.ReplaceAllStringFunc(text, func(token string) string {
+		if strings.HasPrefix(token, "__SECRET_") || !looksRandom(token) {
+			return token
+		}
+		return r.placeholder(token)
+	})
+}
+
+// Restore puts the original secrets back in place of their placeholders
+func (r *Redactor) Restore(text string) string {
+	for placeholder, secret := range r.byPlaceholder {
+		text = strings.ReplaceAll(text, placeholder, secret)
+	}
+	return text
+}
+
+// Count returns the number of distinct secrets redacted so far
+func (r *Redactor) Count() int {
+	return len(r.byPlaceholder)
+}
+
+// placeholder returns the placeholder for a secret, allocating one if needed
+func (r *Redactor) placeholder(secret string) string {
+	if placeholder, ok := r.bySecret[secret]; ok {
+		return placeholder
+	}
+	placeholder := fmt.Sprintf("__SECRET_%d__", len(r.bySecret)+1)
+	r.bySecret[secret] = placeholder
+	r.byPlaceholder[placeholder] = secret
+	return placeholder
+}
+
+// replaceSecrets replaces each match of pattern (or its first capture group)
+// with the result of replace
+func replaceSecrets(text string, pattern *regexp.Regexp, replace func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if len(loc) >= 4 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+		if strings.HasPrefix(text[start:end], "__SECRET_") {
+			continue // Already redacted by an earlier detector
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(replace(text[start:end]))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// looksRandom reports whether a token is a high-entropy mix of letters and
+// digits, like a generated key, rather than a word, path or hash
+func looksRandom(token string) bool {
+	if hexPattern.MatchString(token) {
+		return false
+	}
+	var hasUpper, hasLower, hasDigit bool
+	for _, c := range token {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			hasUpper = true
+		case c >= 'a' && c <= 'z':
+			hasLower = true
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		}
+	}
+	if !hasDigit || !(hasUpper && hasLower) {
+		return false
+	}
+	return entropy(token) >= minEntropy
+}
+
+// entropy computes the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, c := range s {
+		counts[c]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,192 @@
+// Package regex tests regular expressions of several flavors against
+// example strings
+package regex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flavor is a regular expression dialect
+type Flavor string
+
+// Supported flavors
+const (
+	PCRE Flavor = "pcre" // Perl-compatible (grep -P, Python, JavaScript, ...)
+	ERE  Flavor = "ere"  // POSIX extended (grep -E, awk, sed -E)
+	Go   Flavor = "go"   // RE2 (Go's regexp, ripgrep's default engine)
+)
+
+// Flavors lists the supported flavors
+var Flavors = []Flavor{PCRE, ERE, Go}
+
+// Description names a flavor for prompts
+func (f Flavor) Description() string {
+	switch f {
+	case ERE:
+		return "POSIX ERE (as used by grep -E, awk and sed -E: no \\d, \\b, lookarounds or lazy quantifiers; use [[:digit:]] and similar classes)"
+	case Go:
+		return "Go RE2 syntax (Go's regexp package: no lookarounds or backreferences)"
+	default:
+		return "PCRE (Perl-compatible, as used by grep -P)"
+	}
+}
+
+// ParseFlavor parses a flavor name, accepting common aliases
+func ParseFlavor(name string) (Flavor, error) {
+	switch strings.ToLower(name) {
+	case "pcre", "perl", "pcre2":
+		return PCRE, nil
+	case "ere", "posix", "egrep":
+		return ERE, nil
+	case "go", "re2", "golang":
+		return Go, nil
+	}
+	return "", fmt.Errorf("unsupported regex flavor %q (supported: pcre, ere, go)", name)
+}
+
+// Result is the outcome of one example
+type Result struct {
+	Example string
+	Want    bool // The example should match
+	Got     bool
+}
+
+// Passed reports whether the example behaved as wanted
+func (r Result) Passed() bool {
+	return r.Want == r.Got
+}
+
+// ErrNoPCRE is returned when neither grep -P nor perl is available to test
+// a PCRE pattern
+var ErrNoPCRE = errors.New("no PCRE engine found (grep -P or perl)")
+
+// testTimeout bounds an external PCRE test
+const testTimeout = 5 * time.Second
+
+// run executes an external PCRE engine; replaced in tests
+var run = func(ctx context.Context, stdin string, env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return out, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
+
+// lookPath is replaced in tests
+var lookPath = exec.LookPath
+
+// Test checks that pattern is valid in the flavor and reports, for each
+// example, whether it matched. Matching is unanchored, like grep: a pattern
+// must use ^ and $ to match whole examples. Examples must be single lines.
+func Test(ctx context.Context, flavor Flavor, pattern string, matches, rejects []string) ([]Result, error) {
+	var results []Result
+	for _, example := range matches {
+		results = append(results, Result{Example: example, Want: true})
+	}
+	for _, example := range rejects {
+		results = append(results, Result{Example: example, Want: false})
+	}
+	for _, r := range results {
+		if strings.ContainsAny(r.Example, "\r\n") {
+			return nil, fmt.Errorf("example %q spans several lines", r.Example)
+		}
+	}
+
+	if flavor == PCRE {
+		matched, err := testPCRE(ctx, pattern, results)
+		if err != nil {
+			return nil, err
+		}
+		for i := range results {
+			results[i].Got = matched[i]
+		}
+		return results, nil
+	}
+
+	compile := regexp.Compile
+	if flavor == ERE {
+		compile = regexp.CompilePOSIX
+	}
+	re, err := compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Got = re.MatchString(results[i].Example)
+	}
+	return results, nil
+}
+
+// testPCRE runs the examples, one per line, through grep -P or perl and
+// returns which lines matched
+func testPCRE(ctx context.Context, pattern string, results []Result) ([]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+
+	var input strings.Builder
+	for _, r := range results {
+		input.WriteString(r.Example + "\n")
+	}
+
+	var out []byte
+	var err error
+	switch {
+	case grepP(ctx):
+		out, err = run(ctx, input.String(), nil, "grep", "-n", "-P", "-e", pattern)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			err = nil // No example matched
+		}
+	case hasPerl():
+		// The pattern travels in the environment so it is never parsed as
+		// Perl code
+		out, err = run(ctx, input.String(), []string{"HERMES_REGEX=" + pattern}, "perl", "-ne",
+			`BEGIN { $re = eval { qr/$ENV{HERMES_REGEX}/ } or die $@ } chomp; print "$.:\n" if $_ =~ $re`)
+	default:
+		return nil, ErrNoPCRE
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid PCRE pattern: %v", err)
+	}
+
+	matched := make([]bool, len(results))
+	for _, line := range strings.Split(string(out), "\n") {
+		number, _, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(number); err == nil && n >= 1 && n <= len(results) {
+			matched[n-1] = true
+		}
+	}
+	return matched, nil
+}
+
+// grepP reports whether grep supports -P
+func grepP(ctx context.Context) bool {
+	if _, err := lookPath("grep"); err != nil {
+		return false
+	}
+	_, err := run(ctx, "x\n", nil, "grep", "-q", "-P", "x")
+	return err == nil
+}
+
+// hasPerl reports whether perl is installed
+func hasPerl() bool {
+	_, err := lookPath("perl")
+	return err == nil
+}
@@ -0,0 +1,90 @@
+package regex
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestTest(t *testing.T) {
+	dates := []string{"2024-01-31", "due 1999-12-01"}
+	times := []string{"12:30:00", "2024-01-31T12:30"}
+
+	tests := []struct {
+		flavor  Flavor
+		pattern string
+		failing int
+	}{
+		{Go, `\b\d{4}-\d{2}-\d{2}\b(?:[^T]|$)`, 0},
+		{Go, `\d{4}-\d{2}-\d{2}`, 1}, // Also matches the timestamp
+		{ERE, `[[:digit:]]{4}-[[:digit:]]{2}-[[:digit:]]{2}([^T[:digit:]]|$)`, 0},
+	}
+	for _, tt := range tests {
+		results, err := Test(context.Background(), tt.flavor, tt.pattern, dates, times)
+		if err != nil {
+			t.Fatalf("Test(%s, %q) error: %v", tt.flavor, tt.pattern, err)
+		}
+		failing := 0
+		for _, r := range results {
+			if !r.Passed() {
+				failing++
+			}
+		}
+		if failing != tt.failing {
+			t.Errorf("Test(%s, %q): %d failing, want %d (%+v)", tt.flavor, tt.pattern, failing, tt.failing, results)
+		}
+	}
+}
+
+func TestTestRejectsInvalidPatterns(t *testing.T) {
+	if _, err := Test(context.Background(), Go, `(unclosed`, []string{"x"}, nil); err == nil {
+		t.Error("Test() accepted an invalid Go pattern")
+	}
+	// \d is a Perl extension, not POSIX ERE
+	if _, err := Test(context.Background(), ERE, `\d+`, []string{"1"}, nil); err == nil {
+		t.Error("Test() accepted \\d as POSIX ERE")
+	}
+	if _, err := Test(context.Background(), Go, `a`, []string{"a\nb"}, nil); err == nil {
+		t.Error("Test() accepted a multi-line example")
+	}
+}
+
+func TestTestPCRE(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep not installed")
+	}
+	if !grepP(context.Background()) && !hasPerl() {
+		t.Skip("no PCRE engine")
+	}
+	results, err := Test(context.Background(), PCRE, `\d{4}-\d{2}-\d{2}(?!T)`, []string{"2024-01-31"}, []string{"2024-01-31T12:30"})
+	if err != nil {
+		t.Fatalf("Test() error: %v", err)
+	}
+	for _, r := range results {
+		if !r.Passed() {
+			t.Errorf("%q: matched = %v, want %v", r.Example, r.Got, r.Want)
+		}
+	}
+}
+
+func TestTestPCREUnavailable(t *testing.T) {
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+	if _, err := Test(context.Background(), PCRE, `a`, []string{"a"}, nil); !errors.Is(err, ErrNoPCRE) {
+		t.Errorf("Test() error = %v, want ErrNoPCRE", err)
+	}
+}
+
+func TestParseFlavor(t *testing.T) {
+	for name, want := range map[string]Flavor{"PCRE": PCRE, "posix": ERE, "re2": Go} {
+		if got, err := ParseFlavor(name); err != nil || got != want {
+			t.Errorf("ParseFlavor(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseFlavor("emacs"); err == nil {
+		t.Error("ParseFlavor(emacs) succeeded")
+	}
+}
@@ -0,0 +1,149 @@
+// Package remote gathers context about, and runs commands on, remote hosts over SSH
+package remote
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// probeTimeout bounds how long gathering remote context may take
+const probeTimeout = 10 * time.Second
+
+// probeTools lists the tools whose presence is reported to the model
+var probeTools = []string{
+	"bash", "zsh", "fish", "busybox", "apt", "dnf", "yum", "pacman", "apk", "zypper", "brew",
+	"systemctl", "docker", "podman", "kubectl", "rsync", "curl", "wget", "jq", "python3",
+	"gawk", "gsed", "rg", "fd", "ip", "ss", "netstat",
+}
+
+// probeScript prints key=value facts about the host; it only uses POSIX sh
+var probeScript = `echo "os=$(uname -s)"
+echo "kernel=$(uname -r)"
+echo "arch=$(uname -m)"
+if [ -r /etc/os-release ]; then . /etc/os-release; echo "distro=$PRETTY_NAME"; fi
+echo "shell=$SHELL"
+for t in ` + strings.Join(probeTools, " ") + `; do
+  command -v "$t" >/dev/null 2>&1 && echo "tool=$t"
+done
+exit 0
+`
+
+// Host describes a remote machine
+type Host struct {
+	Target string   // user@host as passed to ssh
+	OS     string   // uname -s
+	Kernel string   // uname -r
+	Arch   string   // uname -m
+	Distro string   // PRETTY_NAME from /etc/os-release
+	Shell  string   // Login shell
+	Tools  []string // Installed tools from probeTools
+}
+
+// ValidateTarget rejects targets ssh would not read as a destination: a
+// leading dash would make it an option (-oProxyCommand=...), and
+// whitespace or control characters cannot be part of a host name
+func ValidateTarget(target string) error {
+	if target == "" {
+		return errors.New("remote target is empty")
+	}
+	if strings.HasPrefix(target, "-") {
+		return fmt.Errorf("remote target %q must not start with '-'", target)
+	}
+	if strings.IndexFunc(target, func(r rune) bool { return r <= ' ' || r == 0x7f }) >= 0 {
+		return fmt.Errorf("remote target %q must not contain whitespace or control characters", target)
+	}
+	return nil
+}
+
+// Probe connects to target over SSH (non-interactively) and collects host facts
+func Probe(ctx context.Context, target string) (*Host, error) {
+	if err := ValidateTarget(target); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ssh", "-o", "BatchMode=yes", "-o", "ConnectTimeout=5", "--", target, "sh", "-s")
+	cmd.Stdin = strings.NewReader(probeScript)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("ssh %s: %s", target, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("ssh %s: %w", target, err)
+	}
+
+	return parseProbe(target, string(out)), nil
+}
+
+// parseProbe parses the key=value output of probeScript
+func parseProbe(target, output string) *Host {
+	host := &Host{Target: target}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "os":
+			host.OS = value
+		case "kernel":
+			host.Kernel = value
+		case "arch":
+			host.Arch = value
+		case "distro":
+			host.Distro = value
+		case "shell":
+			host.Shell = value
+		case "tool":
+			host.Tools = append(host.Tools, value)
+		}
+	}
+	return host
+}
+
+// Context renders the host facts as a prompt section
+func (h *Host) Context() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The command will run on the remote host %s, not on the local machine.\n", h.Target)
+	fmt.Fprintf(&b, "Remote OS: %s %s (%s)\n", h.OS, h.Kernel, h.Arch)
+	if h.Distro != "" {
+		fmt.Fprintf(&b, "Remote distribution: %s\n", h.Distro)
+	}
+	if h.Shell != "" {
+		fmt.Fprintf(&b, "Remote login shell: %s\n", h.Shell)
+	}
+	if len(h.Tools) > 0 {
+		fmt.Fprintf(&b, "Installed tools on the remote host: %s\n", strings.Join(h.Tools, ", "))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Wrap returns a local command line that runs command on target
+func Wrap(target, command string) string {
+	return fmt.Sprintf("ssh -t -- %s %s", Quote(target), Quote(command))
+}
+
+// Quote single-quotes s for POSIX shells
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Run executes command on target with an interactive terminal
+func Run(ctx context.Context, target, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if err := ValidateTarget(target); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "ssh", "-t", "--", target, command)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
@@ -0,0 +1,76 @@
+package remote
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProbe(t *testing.T) {
+	output := "os=Linux\nkernel=6.1.0\narch=x86_64\ndistro=Debian GNU/Linux 12 (bookworm)\nshell=/bin/bash\ntool=apt\ntool=rsync\nnoise without a key\n"
+	got := parseProbe("admin@db1", output)
+	want := &Host{
+		Target: "admin@db1",
+		OS:     "Linux",
+		Kernel: "6.1.0",
+		Arch:   "x86_64",
+		Distro: "Debian GNU/Linux 12 (bookworm)",
+		Shell:  "/bin/bash",
+		Tools:  []string{"apt", "rsync"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProbe() = %+v, want %+v", got, want)
+	}
+	if context := got.Context(); !strings.Contains(context, "remote host admin@db1") || !strings.Contains(context, "apt, rsync") {
+		t.Errorf("Context() = %q", context)
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	for _, target := range []string{"host", "admin@db1.example.com", "ops@[2001:db8::1]"} {
+		if err := ValidateTarget(target); err != nil {
+			t.Errorf("ValidateTarget(%q) = %v, want nil", target, err)
+		}
+	}
+	for _, target := range []string{"", "-oProxyCommand=touch /tmp/pwned", "-p", "host name", "host\nid"} {
+		if err := ValidateTarget(target); err == nil {
+			t.Errorf("ValidateTarget(%q) = nil, want an error", target)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"ls -la":          `'ls -la'`,
+		"echo 'hi there'": `'echo '\''hi there'\'''`,
+		"":                `''`,
+		"$(id) `id` \\":   `'$(id) ` + "`id`" + ` \'`,
+	}
+	for in, want := range tests {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if got, want := Wrap("admin@db1", "df -h | grep '/var'"), `ssh -t -- 'admin@db1' 'df -h | grep '\''/var'\'''`; got != want {
+		t.Errorf("Wrap() = %s, want %s", got, want)
+	}
+
+	// The shell reads the target and command back as exactly two words
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not installed")
+	}
+	target, command := "we;ird$(id)@host", "echo 'it''s' \"$HOME\""
+	wrapped := Wrap(target, command)
+	out, err := exec.Command(sh, "-c", "set -- "+strings.TrimPrefix(wrapped, "ssh ")+`; printf '%s\n' "$@"`).Output()
+	if err != nil {
+		t.Fatalf("sh -c %q: %v", wrapped, err)
+	}
+	if got, want := string(out), "-t\n--\n"+target+"\n"+command+"\n"; got != want {
+		t.Errorf("%s splits into %q, want %q", wrapped, got, want)
+	}
+}
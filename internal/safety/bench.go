@@ -0,0 +1,148 @@
+// Package safety - benchmarking the analyzer over a command corpus
+package safety
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// RuleHits counts the corpus commands one pattern matched
+type RuleHits struct {
+	Kind    string // "attention" or "safe"
+	Name    string // User-defined rules only
+	Pattern string
+	Hits    int
+}
+
+// Timing is how long the analysis of one command took
+type Timing struct {
+	Command  string
+	Duration time.Duration
+}
+
+// FalsePositive is a command flagged by an attention pattern that also
+// matches a known-safe pattern, such as grep sudo /var/log/auth.log. These
+// are the first commands to look at when tuning a rule.
+type FalsePositive struct {
+	Command string
+	Rule    string // The attention pattern or rule that flagged it
+	Safe    string // The safe pattern or rule it also matches
+}
+
+// BenchReport summarizes the analyzer's verdicts over a corpus
+type BenchReport struct {
+	Commands       int
+	Levels         map[SafetyLevel]int
+	Layers         map[string]int // Deciding layer, e.g. "attention-patterns"
+	Rules          []RuleHits     // Built-in patterns, then user-defined rules, including those without hits
+	FalsePositives []FalsePositive
+	Elapsed        time.Duration
+	Slowest        []Timing // The slowest commands, slowest first
+}
+
+// slowestKept is how many of the slowest commands a report keeps
+const slowestKept = 5
+
+// Bench runs the analysis over every command and reports the verdicts,
+// how often each pattern matched and how long it took. Rule hits count
+// every matching pattern, not only the one that decided, so overlapping
+// and dead rules show up. POSIX analyzers include the exfiltration guard,
+// as the commands do.
+func (a *Analyzer) Bench(ctx context.Context, commands []string) (BenchReport, error) {
+	report := BenchReport{
+		Commands: len(commands),
+		Levels:   map[SafetyLevel]int{},
+		Layers:   map[string]int{},
+	}
+	for _, pattern := range a.attentionPatterns {
+		report.Rules = append(report.Rules, RuleHits{Kind: "attention", Pattern: pattern.re.String()})
+	}
+	for _, pattern := range a.safePatterns {
+		report.Rules = append(report.Rules, RuleHits{Kind: "safe", Pattern: pattern.String()})
+	}
+	for _, rule := range a.rules {
+		report.Rules = append(report.Rules, RuleHits{Kind: rule.level.String(), Name: rule.Name, Pattern: rule.Pattern})
+	}
+
+	for _, command := range commands {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		start := time.Now()
+		result, err := a.AnalyzeCommand(ctx, command)
+		if err != nil {
+			return report, err
+		}
+		if a.posixQuoting {
+			if exfil, reason := CheckExfiltration(command); exfil != NoExfiltration {
+				result = Result{Level: Attention, Reason: "Command " + reason, Layer: "exfiltration-guard"}
+			}
+		}
+		elapsed := time.Since(start)
+		report.Elapsed += elapsed
+		report.Slowest = keepSlowest(report.Slowest, Timing{Command: command, Duration: elapsed})
+
+		report.Levels[result.Level]++
+		report.Layers[result.Layer]++
+		a.countHits(&report, command, result)
+	}
+	return report, nil
+}
+
+// countHits adds the command's pattern matches to the report and records
+// it as a false-positive candidate when attention and safe patterns both
+// match
+func (a *Analyzer) countHits(report *BenchReport, command string, result Result) {
+	candidates := forms(command, a.posixQuoting)
+
+	var flagged, safe string
+	for i, pattern := range a.attentionPatterns {
+		for _, candidate := range candidates {
+			if pattern.re.MatchString(candidate) {
+				report.Rules[i].Hits++
+				if flagged == "" {
+					flagged = pattern.re.String()
+				}
+				break
+			}
+		}
+	}
+	for i, pattern := range a.safePatterns {
+		if pattern.MatchString(command) {
+			report.Rules[len(a.attentionPatterns)+i].Hits++
+			if safe == "" {
+				safe = pattern.String()
+			}
+		}
+	}
+	builtin := len(a.attentionPatterns) + len(a.safePatterns)
+	for i := range a.rules {
+		rule := &a.rules[i]
+		if !rule.matches(candidates) {
+			continue
+		}
+		report.Rules[builtin+i].Hits++
+		switch {
+		case rule.level == Attention && flagged == "":
+			flagged = rule.Name
+		case rule.level == Safe && safe == "":
+			safe = rule.Name
+		}
+	}
+
+	if result.Layer != "exfiltration-guard" && result.Level == Attention && flagged != "" && safe != "" {
+		report.FalsePositives = append(report.FalsePositives, FalsePositive{Command: command, Rule: flagged, Safe: safe})
+	}
+}
+
+// keepSlowest adds timing to the slowest list when it belongs there
+func keepSlowest(slowest []Timing, timing Timing) []Timing {
+	slowest = append(slowest, timing)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].Duration > slowest[j].Duration })
+	if len(slowest) > slowestKept {
+		slowest = slowest[:slowestKept]
+	}
+	return slowest
+}
@@ -0,0 +1,85 @@
+// Package safety - credential exfiltration guard
+package safety
+
+import (
+	"regexp"
+)
+
+// Exfiltration classifies how a command handles credentials
+type Exfiltration int
+
+const (
+	NoExfiltration Exfiltration = iota
+	ExposesSecrets              // Prints or copies credentials locally
+	SendsSecrets                // Sends credentials to a network tool
+)
+
+// secretPaths match files that hold credentials. SSH public keys are
+// excluded by checking the match afterwards (RE2 has no lookahead).
+var secretPaths = []*regexp.Regexp{
+	regexp.MustCompile(`\.ssh/(id_[A-Za-z0-9_]+|identity)(\.pub)?\b`),
+	regexp.MustCompile(`\.ssh/\*`),
+	regexp.MustCompile(`\.aws/credentials\b`),
+	regexp.MustCompile(`\.config/gcloud/(credentials\.db|access_tokens\.db|application_default_credentials\.json|legacy_credentials)`),
+	regexp.MustCompile(`\.azure/(accessTokens\.json|msal_token_cache)`),
+	regexp.MustCompile(`\.kube/config\b`),
+	regexp.MustCompile(`\.docker/config\.json\b`),
+	regexp.MustCompile(`\.(netrc|git-credentials|pgpass|npmrc|pypirc)\b`),
+	regexp.MustCompile(`\.gnupg/`),
+	regexp.MustCompile(`/etc/(shadow|gshadow)\b`),
+}
+
+// identityFlag matches a key passed to ssh, scp and friends for
+// authentication, which uses the key without revealing it
+var identityFlag = regexp.MustCompile(`(^|\s)(-i\s*|--identity(-file)?[=\s]|-o\s*IdentityFile=)\S+`)
+
+// secretReaders are commands that print or copy file contents
+var secretReaders = regexp.MustCompile(`(^|[\s|;&(` + "`" + `])(cat|tac|less|more|head|tail|nl|bat|strings|base64|base32|xxd|od|hexdump|cp|mv|tar|zip|gzip|openssl|gpg|awk|sed|grep)\s`)
+
+// environmentDump matches commands printing the whole environment, which
+// usually holds tokens
+var environmentDump = regexp.MustCompile(`(^|[\s|;&(])(env|printenv|export\s+-p|set)\s*($|[|>;&)])`)
+
+// networkTools send data off the machine
+var networkTools = regexp.MustCompile(`(^|[\s|;&(])(curl|wget|nc|ncat|netcat|socat|telnet|scp|sftp|ftp|rsync|http|xh)\s|/dev/(tcp|udp)/`)
+
+// CheckExfiltration reports whether a POSIX command reads credential files
+// or the environment, and whether it sends them to a network tool.
+// Quoting, escapes and homoglyphs are normalized first so they cannot
+// hide a path.
+func CheckExfiltration(command string) (Exfiltration, string) {
+	candidates := forms(command, true)
+
+	worst, reason := NoExfiltration, ""
+	for _, candidate := range candidates {
+		candidate = identityFlag.ReplaceAllString(candidate, " ")
+		secret := findSecret(candidate)
+		if secret == "" {
+			continue
+		}
+		if networkTools.MatchString(candidate) {
+			return SendsSecrets, "sends " + secret + " over the network"
+		}
+		// Printing the environment locally is routine; only sending it counts
+		if worst == NoExfiltration && secret != "the environment" && secretReaders.MatchString(candidate) {
+			worst, reason = ExposesSecrets, "prints or copies "+secret
+		}
+	}
+	return worst, reason
+}
+
+// findSecret names the credential a command touches, or returns ""
+func findSecret(command string) string {
+	for _, pattern := range secretPaths {
+		for _, match := range pattern.FindAllString(command, -1) {
+			if len(match) > 4 && match[len(match)-4:] == ".pub" {
+				continue
+			}
+			return match
+		}
+	}
+	if environmentDump.MatchString(command) {
+		return "the environment"
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+// Package safety - expanding aliases and variables before analysis
+package safety
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// Expansion is what the words of a POSIX command stand for in the user's
+// shell: aliases, and the values of variables that are safe to reveal
+type Expansion struct {
+	Aliases   map[string]string
+	Variables map[string]string
+}
+
+// lookupSubstitution matches a command substitution that only looks up a
+// program's path, such as $(which rm) or `command -v rm`
+var lookupSubstitution = regexp.MustCompile("\\$\\(\\s*(?:which|command\\s+-v|type\\s+-[pP])\\s+([\\w.+-]+)\\s*\\)|`\\s*(?:which|command\\s+-v|type\\s+-[pP])\\s+([\\w.+-]+)\\s*`")
+
+// variableReference matches $NAME and ${NAME}
+var variableReference = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// commandWord matches the first word of each simple command, including
+// the command sudo runs
+var commandWord = regexp.MustCompile(`(^|[;&|(\n])(\s*(?:sudo\s+)?)([\w.+-]+)`)
+
+// maxAliasDepth bounds alias expansion, like the shell's own recursion
+const maxAliasDepth = 5
+
+// Expand returns the command with program lookups replaced by the program,
+// known variables by their values and aliases by their definitions
+func (e Expansion) Expand(command string) string {
+	command = lookupSubstitution.ReplaceAllStringFunc(command, func(match string) string {
+		groups := lookupSubstitution.FindStringSubmatch(match)
+		return groups[1] + groups[2]
+	})
+
+	command = variableReference.ReplaceAllStringFunc(command, func(match string) string {
+		groups := variableReference.FindStringSubmatch(match)
+		if value, ok := e.Variables[groups[1]+groups[2]]; ok {
+			return value
+		}
+		return match
+	})
+
+	// An alias is expanded once per command line, so one that refers to
+	// itself (alias ls='ls -G') stops there
+	expanded := map[string]bool{}
+	for depth := 0; depth < maxAliasDepth; depth++ {
+		pass := map[string]bool{}
+		command = commandWord.ReplaceAllStringFunc(command, func(match string) string {
+			groups := commandWord.FindStringSubmatch(match)
+			value, ok := e.Aliases[groups[3]]
+			if !ok || expanded[groups[3]] {
+				return match
+			}
+			pass[groups[3]] = true
+			return groups[1] + groups[2] + value
+		})
+		if len(pass) == 0 {
+			break
+		}
+		for name := range pass {
+			expanded[name] = true
+		}
+	}
+	return command
+}
+
+// ParseAliases reads alias definitions as printed by zsh (alias -L), bash
+// (alias -p) and fish (alias): alias name='value' or alias name 'value'.
+// Lines that do not parse are skipped.
+func ParseAliases(definitions string) map[string]string {
+	aliases := map[string]string{}
+	for _, line := range strings.Split(definitions, "\n") {
+		tokens, err := shell.Lex(line)
+		if err != nil {
+			continue
+		}
+		var words []string
+		for _, token := range tokens {
+			if token.Kind != shell.Comment {
+				words = append(words, token.Value)
+			}
+		}
+		if len(words) < 2 || words[0] != "alias" {
+			continue
+		}
+		words = words[1:]
+		for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+			words = words[1:] // zsh -g/-s and fish --save style options
+		}
+		switch {
+		case len(words) == 1 && strings.Contains(words[0], "="):
+			name, value, _ := strings.Cut(words[0], "=")
+			aliases[name] = value
+		case len(words) >= 2:
+			aliases[words[0]] = strings.Join(words[1:], " ")
+		}
+	}
+	return aliases
+}
+
+// ExpandingAnalyzer also analyzes a command as the shell will see it after
+// expansion, so alias cleanup='rm -rf' or $(which rm) -rf / can't hide a
+// dangerous command. The expanded form can only raise the verdict.
+type ExpandingAnalyzer struct {
+	CommandAnalyzer
+	Expansion Expansion
+}
+
+// AnalyzeCommand returns the stricter verdict of the command as written and
+// as expanded
+func (a ExpandingAnalyzer) AnalyzeCommand(ctx context.Context, command string) (Result, error) {
+	result, err := a.CommandAnalyzer.AnalyzeCommand(ctx, command)
+	if err != nil || result.Level >= Attention {
+		return result, err
+	}
+	expanded := a.Expansion.Expand(command)
+	if expanded == command {
+		return result, nil
+	}
+	expandedResult, err := a.CommandAnalyzer.AnalyzeCommand(ctx, expanded)
+	if err != nil || expandedResult.Level < Attention {
+		return result, err
+	}
+	expandedResult.Reason += " (expands to: " + expanded + ")"
+	return expandedResult, nil
+}
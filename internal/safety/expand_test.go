@@ -0,0 +1,73 @@
+package safety
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAliases(t *testing.T) {
+	definitions := strings.Join([]string{
+		`alias ll='ls -l'`,                  // zsh alias -L and bash alias -p
+		`alias -g G='| grep'`,               // zsh global alias
+		`alias cleanup 'rm -rf build dist'`, // fish
+		`alias quote='echo '\''hi'\'''`,
+		`not an alias`,
+		`alias broken='unterminated`,
+	}, "\n")
+	want := map[string]string{
+		"ll":      "ls -l",
+		"G":       "| grep",
+		"cleanup": "rm -rf build dist",
+		"quote":   "echo 'hi'",
+	}
+	if got := ParseAliases(definitions); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAliases() = %q, want %q", got, want)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	expansion := Expansion{
+		Aliases:   map[string]string{"cleanup": "rm -rf", "ls": "ls -G", "nuke": "cleanup /", "please": "sudo"},
+		Variables: map[string]string{"HOME": "/home/me", "EDITOR": "vim"},
+	}
+	tests := []struct{ command, want string }{
+		{"$(which rm) -rf /", "rm -rf /"},
+		{"`command -v rm` -rf /", "rm -rf /"},
+		{"cleanup build", "rm -rf build"},
+		{"cd src && cleanup build", "cd src && rm -rf build"},
+		{"sudo cleanup /var", "sudo rm -rf /var"},
+		{"nuke", "rm -rf /"},
+		{"ls; ls", "ls -G; ls -G"},
+		{"echo cleanup", "echo cleanup"},
+		{"$EDITOR ${HOME}/notes $TOKEN", "vim /home/me/notes $TOKEN"},
+	}
+	for _, tt := range tests {
+		if got := expansion.Expand(tt.command); got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestExpandingAnalyzer(t *testing.T) {
+	analyzer := ExpandingAnalyzer{
+		CommandAnalyzer: NewAnalyzer(),
+		Expansion:       Expansion{Aliases: map[string]string{"cleanup": "rm -rf", "ll": "ls -l"}},
+	}
+	ctx := context.Background()
+
+	for _, command := range []string{"cleanup /", "$(which rm) -rf /"} {
+		result, err := analyzer.AnalyzeCommand(ctx, command)
+		if err != nil || result.Level != Attention || !strings.Contains(result.Reason, "expands to: rm -rf /") {
+			t.Errorf("AnalyzeCommand(%q) = %+v, %v, want attention with the expansion", command, result, err)
+		}
+	}
+	// The expansion only raises the verdict
+	if result, _ := analyzer.AnalyzeCommand(ctx, "ll"); result.Level != Safe || result.Layer != "default-safe" {
+		t.Errorf("AnalyzeCommand(ll) = %+v, want the verdict as written", result)
+	}
+	if result, _ := NewAnalyzer().AnalyzeCommand(ctx, "cleanup /"); result.Level != Safe {
+		t.Errorf("plain AnalyzeCommand(cleanup /) = %v, want safe without expansion", result.Level)
+	}
+}
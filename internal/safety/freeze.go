@@ -0,0 +1,84 @@
+// Package safety - change-freeze windows
+package safety
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FreezeWindow is a period in which risky generations are refused: every
+// week, such as Fri 16:00 to Mon 08:00, or once, between two local times
+// such as 2026-12-20 18:00 and 2027-01-04 08:00
+type FreezeWindow struct {
+	weekly           bool
+	weekFrom, weekTo int // Minutes since Sunday 00:00
+	from, to         time.Time
+}
+
+// minutesPerWeek wraps weekly windows that span the weekend
+const minutesPerWeek = 7 * 24 * 60
+
+// freezeDateLayout is the layout of one-off window boundaries
+const freezeDateLayout = "2006-01-02 15:04"
+
+// ParseFreezeWindow reads a window from its start and end, both weekly
+// ("Fri 16:00") or both dates ("2026-12-20 18:00") in local time
+func ParseFreezeWindow(from, until string) (FreezeWindow, error) {
+	weekFrom, fromErr := parseWeekTime(from)
+	weekTo, untilErr := parseWeekTime(until)
+	if fromErr == nil && untilErr == nil {
+		if weekFrom == weekTo {
+			return FreezeWindow{}, fmt.Errorf("freeze window %s to %s is empty", from, until)
+		}
+		return FreezeWindow{weekly: true, weekFrom: weekFrom, weekTo: weekTo}, nil
+	}
+
+	start, fromErr := time.ParseInLocation(freezeDateLayout, strings.TrimSpace(from), time.Local)
+	end, untilErr := time.ParseInLocation(freezeDateLayout, strings.TrimSpace(until), time.Local)
+	if fromErr != nil || untilErr != nil {
+		return FreezeWindow{}, fmt.Errorf("invalid freeze window %q to %q (use \"Fri 16:00\" or \"2026-12-20 18:00\" for both)", from, until)
+	}
+	if !end.After(start) {
+		return FreezeWindow{}, fmt.Errorf("freeze window %s to %s ends before it starts", from, until)
+	}
+	return FreezeWindow{from: start, to: end}, nil
+}
+
+// parseWeekTime reads "Fri 16:00" or "friday 16:00" as minutes since
+// Sunday 00:00
+func parseWeekTime(value string) (int, error) {
+	day, clock, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok || len(day) < 3 {
+		return 0, fmt.Errorf("invalid weekly time %q", value)
+	}
+	weekday := -1
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.HasPrefix(strings.ToLower(d.String()), strings.ToLower(day)) {
+			weekday = int(d)
+			break
+		}
+	}
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(clock), ":")
+	h, hourErr := strconv.Atoi(hours)
+	m, minuteErr := strconv.Atoi(minutes)
+	if weekday < 0 || !ok || hourErr != nil || minuteErr != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid weekly time %q", value)
+	}
+	return weekday*24*60 + h*60 + m, nil
+}
+
+// Contains reports whether t falls inside the window, including its start
+// but not its end
+func (w FreezeWindow) Contains(t time.Time) bool {
+	if !w.weekly {
+		return !t.Before(w.from) && t.Before(w.to)
+	}
+	minute := int(t.Weekday())*24*60 + t.Hour()*60 + t.Minute()
+	if w.weekFrom < w.weekTo {
+		return minute >= w.weekFrom && minute < w.weekTo
+	}
+	// Spans the end of the week, e.g. Fri 16:00 to Mon 08:00
+	return minute >= w.weekFrom || minute < w.weekTo
+}
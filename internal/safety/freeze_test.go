@@ -0,0 +1,55 @@
+package safety
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreezeWindowContains(t *testing.T) {
+	// 2026-10-16 is a Friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		from, until string
+		time        time.Time
+		want        bool
+	}{
+		{"Fri 16:00", "Mon 08:00", at(16, 15, 59), false},
+		{"Fri 16:00", "Mon 08:00", at(16, 16, 0), true},
+		{"Fri 16:00", "Mon 08:00", at(18, 12, 0), true}, // Sunday
+		{"Fri 16:00", "Mon 08:00", at(19, 7, 59), true},
+		{"Fri 16:00", "Mon 08:00", at(19, 8, 0), false},
+		{"friday 16:00", "monday 8:00", at(17, 0, 0), true},
+		{"Mon 09:00", "Mon 10:00", at(19, 9, 30), true},
+		{"Mon 09:00", "Mon 10:00", at(20, 9, 30), false},
+		{"2026-10-16 18:00", "2026-10-20 08:00", at(16, 17, 0), false},
+		{"2026-10-16 18:00", "2026-10-20 08:00", at(19, 12, 0), true},
+		{"2026-10-16 18:00", "2026-10-20 08:00", at(23, 12, 0), false},
+	}
+	for _, tt := range tests {
+		window, err := ParseFreezeWindow(tt.from, tt.until)
+		if err != nil {
+			t.Fatalf("ParseFreezeWindow(%q, %q) error = %v", tt.from, tt.until, err)
+		}
+		if got := window.Contains(tt.time); got != tt.want {
+			t.Errorf("%s to %s Contains(%s) = %v, want %v", tt.from, tt.until, tt.time.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestParseFreezeWindowErrors(t *testing.T) {
+	tests := []struct{ from, until string }{
+		{"Fri", "Mon 08:00"},
+		{"Fri 25:00", "Mon 08:00"},
+		{"Xyz 16:00", "Mon 08:00"},
+		{"Fri 16:00", "Fri 16:00"},
+		{"Fri 16:00", "2026-12-20 18:00"},
+		{"2026-12-20 18:00", "2026-12-19 18:00"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseFreezeWindow(tt.from, tt.until); err == nil {
+			t.Errorf("ParseFreezeWindow(%q, %q) expected an error", tt.from, tt.until)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package safety - normalizing evasive spellings before pattern matching
+package safety
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// homoglyphs maps Cyrillic and Greek letters to the Latin letters they
+// look like. NFKC already folds fullwidth and other compatibility forms.
+var homoglyphs = strings.NewReplacer(
+	// Cyrillic
+	"а", "a", "в", "b", "е", "e", "к", "k", "о", "o", "р", "p", "с", "c", "у", "y", "х", "x",
+	"ѕ", "s", "і", "i", "ј", "j", "ԁ", "d", "һ", "h", "ԛ", "q", "ԝ", "w", "ӏ", "l",
+	"А", "A", "В", "B", "Е", "E", "К", "K", "М", "M", "Н", "H", "О", "O", "Р", "P",
+	"С", "C", "Т", "T", "Х", "X", "Ѕ", "S", "І", "I", "Ј", "J",
+	// Greek
+	"α", "a", "ι", "i", "κ", "k", "ν", "v", "ο", "o", "ρ", "p", "υ", "u",
+	"Α", "A", "Β", "B", "Ε", "E", "Ζ", "Z", "Η", "H", "Ι", "I", "Κ", "K", "Μ", "M",
+	"Ν", "N", "Ο", "O", "Ρ", "P", "Τ", "T", "Υ", "Y", "Χ", "X",
+)
+
+// ifsSeparator matches $IFS used as a word separator: $IFS, ${IFS},
+// ${IFS%?} and the like, optionally followed by an empty positional
+// parameter ($IFS$9) that ends the variable name
+var ifsSeparator = regexp.MustCompile(`\$\{IFS[^}]*\}|\$IFS\b(\$[0-9@*])?`)
+
+// normalize rewrites a command the way it looks to a reader rather than the
+// way it is spelled: compatibility forms and homoglyphs become ASCII,
+// invisible format characters (zero-width spaces, joiners, bidi controls)
+// are removed and other whitespace becomes a space. POSIX commands also
+// have $IFS separators replaced by a space.
+func normalize(command string, posix bool) string {
+	command = homoglyphs.Replace(norm.NFKC.String(command))
+	command = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case unicode.Is(unicode.Cf, r):
+			return -1
+		case unicode.IsSpace(r):
+			return ' '
+		}
+		return r
+	}, command)
+	if posix {
+		command = ifsSeparator.ReplaceAllString(command, " ")
+	}
+	return command
+}
+
+// forms returns the spellings of a command that patterns are matched
+// against: as written, normalized and, for POSIX, with quotes and escapes
+// removed from both
+func forms(command string, posix bool) []string {
+	candidates := []string{command}
+	add := func(form string) {
+		for _, candidate := range candidates {
+			if candidate == form {
+				return
+			}
+		}
+		candidates = append(candidates, form)
+	}
+
+	normalized := normalize(command, posix)
+	add(normalized)
+	if posix {
+		for _, form := range []string{command, normalized} {
+			if unquoted, ok := unquote(form); ok {
+				add(unquoted)
+			}
+		}
+	}
+	return candidates
+}
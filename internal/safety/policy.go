@@ -0,0 +1,64 @@
+// Package safety - combining the AI's assessment with the pattern analysis
+package safety
+
+// Policy decides how the AI's safety assessment and the pattern analysis
+// combine into one verdict
+type Policy string
+
+// Safety policies
+const (
+	PolicyStrictestWins Policy = "strictest-wins" // Attention from either side wins (default)
+	PolicyPatternOnly   Policy = "pattern-only"   // The pattern analysis alone; the AI is not trusted
+	PolicyAIOnly        Policy = "ai-only"        // The AI alone; no assessment means attention
+	PolicyAIOverrides   Policy = "ai-overrides"   // The AI when it gave an assessment, the patterns otherwise
+)
+
+// Policies lists the valid policies, the default first
+var Policies = []Policy{PolicyStrictestWins, PolicyPatternOnly, PolicyAIOnly, PolicyAIOverrides}
+
+// Valid reports whether p is a known policy; empty means the default
+func (p Policy) Valid() bool {
+	if p == "" {
+		return true
+	}
+	for _, policy := range Policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge combines the pattern verdict with the AI's level. assessed is false
+// when the AI gave no assessment (e.g. `hermes check` without --review).
+// The exfiltration guard runs before any policy and is not affected.
+func (p Policy) Merge(pattern Result, aiLevel SafetyLevel, assessed bool) Result {
+	switch p {
+	case PolicyPatternOnly:
+		return pattern
+	case PolicyAIOnly:
+		if !assessed {
+			return Result{Level: Attention, Reason: "No AI assessment to rely on (safety policy ai-only)", Layer: "policy"}
+		}
+		return aiResult(aiLevel)
+	case PolicyAIOverrides:
+		if !assessed {
+			return pattern
+		}
+		return aiResult(aiLevel)
+	default:
+		// The pattern verdict wins ties, since its reason is more specific
+		if !assessed || pattern.Level >= aiLevel {
+			return pattern
+		}
+		return aiResult(aiLevel)
+	}
+}
+
+// aiResult describes the AI's assessment as a verdict
+func aiResult(level SafetyLevel) Result {
+	if level >= Attention {
+		return Result{Level: Attention, Reason: "AI flagged as requiring attention", Layer: "ai-assessment"}
+	}
+	return Result{Level: Safe, Reason: "AI assessed the command as safe", Layer: "ai-assessment"}
+}
@@ -0,0 +1,52 @@
+package safety
+
+import "testing"
+
+func TestPolicyMerge(t *testing.T) {
+	safe := Result{Level: Safe, Reason: "Command is known to be safe", Layer: "safe-patterns"}
+	attention := Result{Level: Attention, Reason: "Command requires user attention", Layer: "attention-patterns"}
+
+	tests := []struct {
+		policy    Policy
+		pattern   Result
+		aiLevel   SafetyLevel
+		assessed  bool
+		wantLevel SafetyLevel
+		wantLayer string
+	}{
+		// Either side can raise the verdict, neither can lower it
+		{PolicyStrictestWins, safe, Attention, true, Attention, "ai-assessment"},
+		{PolicyStrictestWins, attention, Safe, true, Attention, "attention-patterns"},
+		{PolicyStrictestWins, attention, Attention, true, Attention, "attention-patterns"},
+		{PolicyStrictestWins, safe, Safe, false, Safe, "safe-patterns"},
+		{"", safe, Attention, true, Attention, "ai-assessment"},
+
+		{PolicyPatternOnly, safe, Attention, true, Safe, "safe-patterns"},
+		{PolicyPatternOnly, attention, Safe, true, Attention, "attention-patterns"},
+
+		{PolicyAIOnly, attention, Safe, true, Safe, "ai-assessment"},
+		{PolicyAIOnly, safe, Safe, false, Attention, "policy"},
+
+		{PolicyAIOverrides, attention, Safe, true, Safe, "ai-assessment"},
+		{PolicyAIOverrides, safe, Attention, true, Attention, "ai-assessment"},
+		{PolicyAIOverrides, attention, Safe, false, Attention, "attention-patterns"},
+	}
+	for _, tt := range tests {
+		got := tt.policy.Merge(tt.pattern, tt.aiLevel, tt.assessed)
+		if got.Level != tt.wantLevel || got.Layer != tt.wantLayer {
+			t.Errorf("%q.Merge(%s, %s, %v) = %s from %s, want %s from %s",
+				tt.policy, tt.pattern.Level, tt.aiLevel, tt.assessed, got.Level, got.Layer, tt.wantLevel, tt.wantLayer)
+		}
+	}
+}
+
+func TestPolicyValid(t *testing.T) {
+	for _, policy := range append(Policies, "") {
+		if !policy.Valid() {
+			t.Errorf("%q.Valid() = false, want true", policy)
+		}
+	}
+	if Policy("ai-first").Valid() {
+		t.Error(`"ai-first".Valid() = true, want false`)
+	}
+}
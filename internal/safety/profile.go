@@ -0,0 +1,81 @@
+// Package safety - per-host risk profiles
+package safety
+
+import "regexp"
+
+// Profile tightens the analysis for the kind of host hermes runs on
+type Profile string
+
+// Risk profiles, from the most relaxed
+const (
+	ProfileWorkstation Profile = "workstation" // The built-in patterns alone (default)
+	ProfileServer      Profile = "server"      // Also flags changes to /etc, reboots and package managers
+	ProfileProduction  Profile = "production"  // Like server, and package changes are forbidden
+)
+
+// Profiles lists the valid profiles, the default first
+var Profiles = []Profile{ProfileWorkstation, ProfileServer, ProfileProduction}
+
+// Valid reports whether p is a known profile; empty means the default
+func (p Profile) Valid() bool {
+	if p == "" {
+		return true
+	}
+	for _, profile := range Profiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// packageChanges matches system package managers installing, removing or
+// upgrading packages
+var packageChanges = []attentionPattern{
+	{regexp.MustCompile(`\b(apt|apt-get|aptitude)\s+(.*\s)?(install|remove|purge|upgrade|dist-upgrade|full-upgrade)\b`), "changes system packages", manPage("apt-get", 8)},
+	{regexp.MustCompile(`\b(yum|dnf|zypper)\s+(.*\s)?(install|remove|erase|update|upgrade)\b`), "changes system packages", ""},
+	{regexp.MustCompile(`\bpacman\s+-(S|R|U)`), "changes system packages", "https://man.archlinux.org/man/pacman.8"},
+	{regexp.MustCompile(`\bapk\s+(add|del|upgrade)\b`), "changes system packages", ""},
+	{regexp.MustCompile(`\b(dpkg\s+(-i|--install|-r|--remove|-P|--purge)|rpm\s+-(i|U|e|F))`), "changes system packages", manPage("dpkg", 1)},
+	{regexp.MustCompile(`\bsnap\s+(install|remove|refresh)\b`), "changes system packages", ""},
+}
+
+// serverPatterns need attention on servers, on top of the built-in ones
+var serverPatterns = []attentionPattern{
+	{regexp.MustCompile(`>>?\s*/etc/`), "writes to a file under /etc", manPage("hier", 7)},
+	{regexp.MustCompile(`\btee\s+(-a\s+)?/etc/`), "writes to a file under /etc", manPage("hier", 7)},
+	{regexp.MustCompile(`\bsed\s+(.*\s)?-i.*\s/etc/`), "edits a file under /etc in place", manPage("hier", 7)},
+	{regexp.MustCompile(`\b(cp|mv|install|ln)\s+.*\s/etc/[^\s;&|]*\s*($|[;&|])`), "copies or moves files into /etc", manPage("hier", 7)},
+	{regexp.MustCompile(`\b(rm|chmod|chown|chgrp|truncate|touch)\s+(.*\s)?/etc/`), "changes files under /etc", manPage("hier", 7)},
+	{regexp.MustCompile(`\b(vi|vim|nvim|nano|emacs|ed)\s+(.*\s)?/etc/`), "edits a file under /etc", manPage("hier", 7)},
+	{regexp.MustCompile(`\b(reboot|shutdown|halt|poweroff)\b`), "restarts or shuts down the machine", manPage("shutdown", 8)},
+	{regexp.MustCompile(`\b(kill|pkill|killall)\s`), "stops running processes", manPage("kill", 1)},
+}
+
+// WithProfile adds the profile's attention patterns to a POSIX analyzer
+// and returns it. The cmd.exe pattern set has no profile patterns.
+func (a *Analyzer) WithProfile(p Profile) *Analyzer {
+	if !a.posixQuoting || p != ProfileServer && p != ProfileProduction {
+		return a
+	}
+	a.attentionPatterns = append(a.attentionPatterns, serverPatterns...)
+	a.attentionPatterns = append(a.attentionPatterns, packageChanges...)
+	return a
+}
+
+// Forbids returns why the profile refuses to generate a POSIX command, if
+// it does. Only the production profile forbids anything: package changes,
+// which belong in the host's configuration management.
+func (p Profile) Forbids(command string) (string, bool) {
+	if p != ProfileProduction {
+		return "", false
+	}
+	for _, candidate := range forms(command, true) {
+		for _, pattern := range packageChanges {
+			if pattern.re.MatchString(candidate) {
+				return pattern.description, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,79 @@
+package safety
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAnalyzerWithProfile(t *testing.T) {
+	tests := []struct {
+		command string
+		server  SafetyLevel // With the server and production profiles
+	}{
+		{"echo 'nameserver 1.1.1.1' >> /etc/resolv.conf", Attention},
+		{"echo 127.0.0.1 web | tee -a /etc/hosts", Attention},
+		{"sed -i 's/no/yes/' /etc/ssh/sshd_config", Attention},
+		{"cp nginx.conf /etc/nginx/", Attention},
+		{"vim /etc/fstab", Attention},
+		{"reboot", Attention},
+		{"pkill -f worker", Attention},
+		{"dnf install -y nginx", Attention},
+		{"cat /etc/hosts", Safe},
+		{"cp /etc/hosts /tmp/hosts", Safe},
+		{"grep -r listen /etc/nginx", Safe},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		for _, profile := range Profiles {
+			want := tt.server
+			if profile == ProfileWorkstation {
+				want = Safe
+			}
+			result, err := NewAnalyzer().WithProfile(profile).AnalyzeCommand(ctx, tt.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Level != want {
+				t.Errorf("%s: AnalyzeCommand(%q) = %s (%s), want %s", profile, tt.command, result.Level, result.Reason, want)
+			}
+		}
+	}
+
+	// The profile patterns are POSIX syntax
+	if result, _ := NewCmdAnalyzer().WithProfile(ProfileProduction).AnalyzeCommand(ctx, "reboot"); result.Level != Safe {
+		t.Errorf("cmd analyzer with a profile flagged reboot: %s", result.Reason)
+	}
+}
+
+func TestProfileForbids(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		command string
+		want    bool
+	}{
+		{ProfileProduction, "sudo apt-get install -y nginx", true},
+		{ProfileProduction, "apt -q upgrade", true},
+		{ProfileProduction, "dpkg -i pkg.deb", true},
+		{ProfileProduction, "apt\u200b-get install jq", true},
+		{ProfileProduction, "apt list --installed", false},
+		{ProfileProduction, "echo x >> /etc/hosts", false},
+		{ProfileServer, "apt-get install -y nginx", false},
+		{ProfileWorkstation, "apt-get install -y nginx", false},
+	}
+	for _, tt := range tests {
+		if reason, got := tt.profile.Forbids(tt.command); got != tt.want {
+			t.Errorf("%s.Forbids(%q) = %v (%s), want %v", tt.profile, tt.command, got, reason, tt.want)
+		}
+	}
+}
+
+func TestProfileValid(t *testing.T) {
+	for _, profile := range append(Profiles, "") {
+		if !profile.Valid() {
+			t.Errorf("%q.Valid() = false, want true", profile)
+		}
+	}
+	if Profile("laptop").Valid() {
+		t.Error(`"laptop".Valid() = true, want false`)
+	}
+}
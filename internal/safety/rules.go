@@ -0,0 +1,160 @@
+// Package safety - user-defined rules and their tests
+package safety
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+// RulePack is a TOML file of user-defined rules, each with its tests:
+//
+//	[[rule]]
+//	name = "terraform-destroy"
+//	pattern = '\bterraform\s+destroy\b'
+//	reason = "Destroys the managed infrastructure"
+//
+//	[[rule.test]]
+//	command = "terraform destroy -auto-approve"
+//	expect = "attention"
+type RulePack struct {
+	Path  string `koanf:"-"`
+	Rules []Rule `koanf:"rule"`
+}
+
+// Rule is a user-defined pattern. Attention rules flag matching commands
+// after the built-in attention patterns; safe rules mark them safe, but
+// never override an attention pattern.
+type Rule struct {
+	Name    string     `koanf:"name"`
+	Pattern string     `koanf:"pattern"` // Go regular expression
+	Level   string     `koanf:"level"`   // "attention" (default) or "safe"
+	Reason  string     `koanf:"reason"`  // Optional; shown with the verdict
+	Docs    string     `koanf:"docs"`    // Optional link explaining the risk
+	Target  string     `koanf:"target"`  // Optional target shell ("posix" or "cmd")
+	Tests   []RuleTest `koanf:"test"`
+
+	pattern *regexp.Regexp
+	level   SafetyLevel
+}
+
+// RuleTest is a command with the verdict expected for it
+type RuleTest struct {
+	Command string `koanf:"command"`
+	Expect  string `koanf:"expect"` // "safe" or "attention"
+}
+
+// LoadRulePack reads a rule pack and compiles its rules
+func LoadRulePack(path string) (*RulePack, error) {
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), toml.Parser()); err != nil {
+		return nil, fmt.Errorf("failed to read rule pack: %w", err)
+	}
+	pack := &RulePack{Path: path}
+	if err := k.Unmarshal("", pack); err != nil {
+		return nil, fmt.Errorf("invalid rule pack %s: %w", path, err)
+	}
+	for i := range pack.Rules {
+		if err := pack.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("rule pack %s rule %d: %w", path, i+1, err)
+		}
+	}
+	return pack, nil
+}
+
+// compile validates the rule and its tests and prepares its pattern
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("%s: missing pattern", r.Name)
+	}
+	var err error
+	if r.pattern, err = regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("%s: invalid pattern: %w", r.Name, err)
+	}
+	if r.level, err = parseLevel(r.Level, Attention); err != nil {
+		return fmt.Errorf("%s: %w", r.Name, err)
+	}
+	switch r.Target {
+	case "", TargetPosix, TargetCmd:
+	default:
+		return fmt.Errorf("%s: unsupported target %q", r.Name, r.Target)
+	}
+	for i, test := range r.Tests {
+		if test.Command == "" {
+			return fmt.Errorf("%s test %d: missing command", r.Name, i+1)
+		}
+		if _, err := parseLevel(test.Expect, -1); err != nil {
+			return fmt.Errorf("%s test %d: %w", r.Name, i+1, err)
+		}
+	}
+	return nil
+}
+
+// parseLevel reads "safe" or "attention"; empty means fallback, and a
+// negative fallback makes the level required
+func parseLevel(level string, fallback SafetyLevel) (SafetyLevel, error) {
+	switch strings.ToLower(level) {
+	case "safe":
+		return Safe, nil
+	case "attention":
+		return Attention, nil
+	case "":
+		if fallback >= 0 {
+			return fallback, nil
+		}
+		return fallback, fmt.Errorf("missing expect (safe or attention)")
+	}
+	return -1, fmt.Errorf("unknown level %q (use safe or attention)", level)
+}
+
+// AppliesTo reports whether the rule is meant for the target shell; rules
+// without a target are for POSIX shells
+func (r *Rule) AppliesTo(target string) bool {
+	if target == "" {
+		target = TargetPosix
+	}
+	ruleTarget := r.Target
+	if ruleTarget == "" {
+		ruleTarget = TargetPosix
+	}
+	return ruleTarget == target
+}
+
+// Expected returns the verdict the test expects
+func (t RuleTest) Expected() SafetyLevel {
+	level, _ := parseLevel(t.Expect, -1)
+	return level
+}
+
+// matches reports whether the rule matches any form of the command
+func (r *Rule) matches(candidates []string) bool {
+	for _, candidate := range candidates {
+		if r.pattern.MatchString(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// result describes a match of the rule as a verdict
+func (r *Rule) result() Result {
+	reason := r.Reason
+	if reason == "" {
+		reason = "Matches rule " + r.Name
+	}
+	return Result{Level: r.level, Reason: reason, Layer: "custom-rules", Docs: r.Docs}
+}
+
+// WithRules adds compiled rules (from LoadRulePack) to the analyzer and
+// returns it
+func (a *Analyzer) WithRules(rules []Rule) *Analyzer {
+	a.rules = append(a.rules, rules...)
+	return a
+}
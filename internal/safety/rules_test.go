@@ -0,0 +1,97 @@
+package safety
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const terraformPack = `
+[[rule]]
+name = "terraform-destroy"
+pattern = '\bterraform\s+destroy\b'
+reason = "Destroys the managed infrastructure"
+
+[[rule.test]]
+command = "terraform destroy -auto-approve"
+expect = "attention"
+
+[[rule]]
+name = "make"
+pattern = '^make\b'
+level = "safe"
+
+[[rule]]
+name = "format"
+pattern = '(?i)\bformat-volume\b'
+target = "cmd"
+`
+
+func writePack(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pack.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRulePack(t *testing.T) {
+	pack, err := LoadRulePack(writePack(t, terraformPack))
+	if err != nil {
+		t.Fatalf("LoadRulePack() error = %v", err)
+	}
+	if len(pack.Rules) != 3 || len(pack.Rules[0].Tests) != 1 || pack.Rules[0].Tests[0].Expected() != Attention {
+		t.Fatalf("LoadRulePack() = %+v, want 3 rules with one attention test on the first", pack.Rules)
+	}
+	if !pack.Rules[0].AppliesTo("") || pack.Rules[2].AppliesTo(TargetPosix) || !pack.Rules[2].AppliesTo(TargetCmd) {
+		t.Error("AppliesTo() does not default rules to posix")
+	}
+
+	for _, tt := range []struct{ pack, want string }{
+		{"[[rule]]\npattern = 'x'\n", "missing name"},
+		{"[[rule]]\nname = 'x'\npattern = '('\n", "invalid pattern"},
+		{"[[rule]]\nname = 'x'\npattern = 'x'\nlevel = 'danger'\n", `unknown level "danger"`},
+		{"[[rule]]\nname = 'x'\npattern = 'x'\ntarget = 'pwsh'\n", `unsupported target "pwsh"`},
+		{"[[rule]]\nname = 'x'\npattern = 'x'\n[[rule.test]]\ncommand = 'x'\n", "missing expect"},
+	} {
+		if _, err := LoadRulePack(writePack(t, tt.pack)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadRulePack(%q) error = %v, want %q", tt.pack, err, tt.want)
+		}
+	}
+}
+
+func TestAnalyzerWithRules(t *testing.T) {
+	pack, err := LoadRulePack(writePack(t, terraformPack))
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer := NewAnalyzer().WithRules(pack.Rules[:2])
+
+	tests := []struct {
+		command   string
+		wantLevel SafetyLevel
+		wantLayer string
+	}{
+		{"terraform destroy", Attention, "custom-rules"},
+		{"terraform 'destroy'", Attention, "custom-rules"},
+		{"make test", Safe, "custom-rules"},
+		// Built-in attention patterns win over safe rules
+		{"make install && sudo reboot", Attention, "attention-patterns"},
+		{"terraform plan", Safe, "default-safe"},
+	}
+	for _, tt := range tests {
+		result, err := analyzer.AnalyzeCommand(context.Background(), tt.command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Level != tt.wantLevel || result.Layer != tt.wantLayer {
+			t.Errorf("AnalyzeCommand(%q) = %s from %s, want %s from %s", tt.command, result.Level, result.Layer, tt.wantLevel, tt.wantLayer)
+		}
+	}
+	if result, _ := analyzer.AnalyzeCommand(context.Background(), "terraform destroy"); result.Reason != "Destroys the managed infrastructure" {
+		t.Errorf("Reason = %q, want the rule's reason", result.Reason)
+	}
+}
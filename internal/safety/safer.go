@@ -0,0 +1,262 @@
+// Package safety - safer rewrites of risky commands
+package safety
+
+import (
+	"os/exec"
+	"sort"
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// Alternative is a safer variant of a command
+type Alternative struct {
+	Command string `json:"command"`
+	Reason  string `json:"reason"` // What makes it safer
+}
+
+// lookPath is replaced in tests
+var lookPath = exec.LookPath
+
+// edit replaces command[start:end] with text
+type edit struct {
+	start, end int
+	text       string
+}
+
+// rewrite produces the edits for one simple command, given its tokens
+// starting at the command name, or nil when the rule does not apply
+type rewrite struct {
+	reason string
+	apply  func(name shell.Token, args []shell.Token) []edit
+}
+
+// rewrites are tried in order; each one that applies to any command in the
+// line yields one alternative
+var rewrites = []rewrite{
+	{"asks before removing each file", rmInteractive},
+	{"moves files to the trash, where they can be restored", rmTrash},
+	{"previews the transfer without changing anything", rsyncDryRun},
+	{"refuses to overwrite commits pushed by someone else", gitForceWithLease},
+	{"lists what would be removed without removing it", gitCleanDryRun},
+	{"lists the matches instead of deleting them", findPrint},
+}
+
+// SaferAlternatives proposes safer variants of a POSIX command: rm -i or
+// trash-put instead of rm, rsync --dry-run, git push --force-with-lease,
+// and dry runs of git clean and find -delete. It returns nil when the
+// command cannot be lexed or has no safer form.
+func SaferAlternatives(command string) []Alternative {
+	tokens, err := shell.Lex(command)
+	if err != nil {
+		return nil
+	}
+
+	var alternatives []Alternative
+	seen := map[string]bool{command: true}
+	for _, rw := range rewrites {
+		var edits []edit
+		for _, command := range simpleCommands(tokens) {
+			edits = append(edits, rw.apply(command.words[0], command.words[1:])...)
+		}
+		if len(edits) == 0 {
+			continue
+		}
+		rewritten := applyEdits(command, edits)
+		if !seen[rewritten] {
+			seen[rewritten] = true
+			alternatives = append(alternatives, Alternative{Command: rewritten, Reason: rw.reason})
+		}
+	}
+	return alternatives
+}
+
+// simpleCommand is one command of a command line, starting at its name
+type simpleCommand struct {
+	words []shell.Token
+	sudo  bool // Run through sudo
+}
+
+// simpleCommands splits tokens at operators into simple commands. Leading
+// assignments and sudo with its options are skipped so "sudo rm -rf x" is
+// treated like "rm -rf x".
+func simpleCommands(tokens []shell.Token) []simpleCommand {
+	var commands []simpleCommand
+	var words []shell.Token
+	flush := func() {
+		for len(words) > 0 && isAssignment(words[0]) {
+			words = words[1:]
+		}
+		sudo := len(words) > 0 && words[0].Value == "sudo"
+		if sudo {
+			words = words[1:]
+			for len(words) > 0 && strings.HasPrefix(words[0].Value, "-") {
+				words = words[1:]
+			}
+		}
+		if len(words) > 0 {
+			commands = append(commands, simpleCommand{words: words, sudo: sudo})
+		}
+		words = nil
+	}
+	for _, token := range tokens {
+		if token.Kind == shell.Word {
+			words = append(words, token)
+		} else {
+			flush()
+		}
+	}
+	flush()
+	return commands
+}
+
+// isAssignment reports whether a word is a variable assignment (FOO=bar)
+func isAssignment(token shell.Token) bool {
+	return strings.Contains(token.Value, "=") && !token.Quoted() && !strings.HasPrefix(token.Value, "-") && !strings.HasPrefix(token.Value, "=")
+}
+
+// applyEdits applies non-overlapping edits to command
+func applyEdits(command string, edits []edit) string {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		command = command[:e.start] + e.text + command[e.end:]
+	}
+	return command
+}
+
+// end returns the offset just past a token
+func end(token shell.Token) int {
+	return token.Pos + len(token.Raw)
+}
+
+// remove deletes a token along with the space before it
+func remove(token shell.Token) edit {
+	return edit{token.Pos - 1, end(token), ""}
+}
+
+// isShortOptions reports whether a word is a cluster of short options
+// such as -rf
+func isShortOptions(token shell.Token) bool {
+	return len(token.Value) > 1 && token.Value[0] == '-' && token.Value[1] != '-' && !token.Quoted()
+}
+
+// hasOption reports whether the options before any "--" include one of
+// the long options or a short option cluster containing one of the letters
+func hasOption(args []shell.Token, long []string, short string) bool {
+	for _, arg := range args {
+		if arg.Value == "--" {
+			return false
+		}
+		for _, option := range long {
+			if arg.Value == option || strings.HasPrefix(arg.Value, option+"=") {
+				return true
+			}
+		}
+		if short != "" && isShortOptions(arg) && strings.ContainsAny(arg.Value[1:], short) {
+			return true
+		}
+	}
+	return false
+}
+
+// rmInteractive adds -i to rm and drops -f, which would override it
+func rmInteractive(name shell.Token, args []shell.Token) []edit {
+	if name.Value != "rm" || hasOption(args, []string{"--interactive"}, "iI") {
+		return nil
+	}
+	var edits []edit
+	added := false
+	for _, arg := range args {
+		if arg.Value == "--" || !strings.HasPrefix(arg.Value, "-") || arg.Quoted() {
+			break
+		}
+		switch {
+		case arg.Value == "--force":
+			edits = append(edits, remove(arg))
+		case isShortOptions(arg):
+			cluster := strings.ReplaceAll(arg.Value, "f", "")
+			if !added {
+				cluster += "i"
+				added = true
+			}
+			if cluster == "-" {
+				edits = append(edits, remove(arg))
+			} else if cluster != arg.Value {
+				edits = append(edits, edit{arg.Pos, end(arg), cluster})
+			}
+		}
+	}
+	if !added {
+		edits = append(edits, edit{end(name), end(name), " -i"})
+	}
+	return edits
+}
+
+// rmTrash moves rm's operands to the trash with trash-put, when installed
+func rmTrash(name shell.Token, args []shell.Token) []edit {
+	if name.Value != "rm" {
+		return nil
+	}
+	if _, err := lookPath("trash-put"); err != nil {
+		return nil
+	}
+	operands := nonOptions(args)
+	if len(operands) == 0 {
+		return nil
+	}
+	return []edit{{name.Pos, end(args[len(args)-1]), "trash-put " + strings.Join(operands, " ")}}
+}
+
+// rsyncDryRun adds --dry-run to rsync
+func rsyncDryRun(name shell.Token, args []shell.Token) []edit {
+	if name.Value != "rsync" || len(args) == 0 || hasOption(args, []string{"--dry-run"}, "n") {
+		return nil
+	}
+	return []edit{{end(name), end(name), " --dry-run"}}
+}
+
+// gitForceWithLease replaces --force and -f in git push with
+// --force-with-lease
+func gitForceWithLease(name shell.Token, args []shell.Token) []edit {
+	if name.Value != "git" || len(args) == 0 || args[0].Value != "push" {
+		return nil
+	}
+	var edits []edit
+	for _, arg := range args[1:] {
+		switch arg.Value {
+		case "--force", "-f":
+			edits = append(edits, edit{arg.Pos, end(arg), "--force-with-lease"})
+		}
+	}
+	return edits
+}
+
+// gitCleanDryRun turns git clean -f into git clean -n
+func gitCleanDryRun(name shell.Token, args []shell.Token) []edit {
+	if name.Value != "git" || len(args) == 0 || args[0].Value != "clean" || hasOption(args[1:], []string{"--dry-run"}, "n") {
+		return nil
+	}
+	for _, arg := range args[1:] {
+		if arg.Value == "--force" {
+			return []edit{{arg.Pos, end(arg), "--dry-run"}}
+		}
+		if isShortOptions(arg) && strings.Contains(arg.Value, "f") {
+			return []edit{{arg.Pos, end(arg), strings.Replace(strings.ReplaceAll(arg.Value, "f", ""), "-", "-n", 1)}}
+		}
+	}
+	return nil
+}
+
+// findPrint replaces find's -delete action with -print
+func findPrint(name shell.Token, args []shell.Token) []edit {
+	if name.Value != "find" {
+		return nil
+	}
+	var edits []edit
+	for _, arg := range args {
+		if arg.Value == "-delete" {
+			edits = append(edits, edit{arg.Pos, end(arg), "-print"})
+		}
+	}
+	return edits
+}
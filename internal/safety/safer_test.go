@@ -0,0 +1,57 @@
+package safety
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSaferAlternatives(t *testing.T) {
+	origLookPath := lookPath
+	t.Cleanup(func() { lookPath = origLookPath })
+	lookPath = func(file string) (string, error) {
+		if file == "trash-put" {
+			return "/usr/bin/trash-put", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"rm -rf build", []string{"rm -ri build", "trash-put build"}},
+		{"sudo rm -f -r /var/tmp/cache", []string{"sudo rm -i -r /var/tmp/cache", "sudo trash-put /var/tmp/cache"}},
+		{"rm --force --recursive 'my dir'", []string{"rm -i --recursive 'my dir'", "trash-put 'my dir'"}},
+		{"rm -i notes.txt", []string{"trash-put notes.txt"}},
+		{"rsync -av --delete src/ dest/", []string{"rsync --dry-run -av --delete src/ dest/"}},
+		{"rsync -avn src/ dest/", nil},
+		{"git push --force origin main", []string{"git push --force-with-lease origin main"}},
+		{"git push -f", []string{"git push --force-with-lease"}},
+		{"git clean -fdx", []string{"git clean -ndx"}},
+		{"find . -name '*.tmp' -delete", []string{"find . -name '*.tmp' -print"}},
+		{"cd build && rm -rf out", []string{"cd build && rm -ri out", "cd build && trash-put out"}},
+		{"ls -la", nil},
+		{"rm 'unterminated", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, alternative := range SaferAlternatives(tt.command) {
+			got = append(got, alternative.Command)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SaferAlternatives(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestSaferAlternativesWithoutTrash(t *testing.T) {
+	origLookPath := lookPath
+	t.Cleanup(func() { lookPath = origLookPath })
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+	got := SaferAlternatives("rm -rf build")
+	if len(got) != 1 || got[0].Command != "rm -ri build" {
+		t.Errorf("SaferAlternatives() = %v, want only rm -ri", got)
+	}
+}
@@ -3,8 +3,12 @@ package safety
 
 import (
 	"context"
+	"fmt"
 	"regexp"
+	"strings"
+
 	"hermes/internal/exit"
+	"hermes/internal/shell"
 )
 
 // SafetyLevel represents the safety level of a command
@@ -44,53 +48,100 @@ type Result struct {
 	Level  SafetyLevel
 	Reason string
 	Layer  string // Which layer made the decision
+	Docs   string // Optional link explaining the risk
+}
+
+// CommandAnalyzer rates the safety of a command. *Analyzer is the pattern
+// implementation; tests substitute their own.
+type CommandAnalyzer interface {
+	AnalyzeCommand(ctx context.Context, command string) (Result, error)
 }
 
 // Analyzer provides binary command safety analysis
 type Analyzer struct {
 	// Pre-compiled regex patterns for performance
-	attentionPatterns []*regexp.Regexp
+	attentionPatterns []attentionPattern
 	safePatterns      []*regexp.Regexp
 	
+	// Attention patterns also match the command normalized (homoglyphs,
+	// zero-width characters), so su\u200bdo can't dodge them. POSIX analyzers
+	// also match it with quotes and escapes removed and $IFS separators
+	// replaced by spaces, so su''do, r\m -rf or rm$IFS-rf can't either.
+	posixQuoting bool
+
+	// User-defined rules from rule packs, checked after the built-in
+	// attention patterns and before the built-in safe patterns
+	rules []Rule
+	
 	// AI client will be injected here in Phase 2
 	// For now, this is a placeholder for the interface
 }
 
+// attentionPattern is a built-in pattern for commands that need attention,
+// with why and where to read more
+type attentionPattern struct {
+	re          *regexp.Regexp
+	description string // Completes "flagged because it ...", e.g. "runs a command as root"
+	docs        string // Optional documentation link
+}
+
+// result describes a match of the pattern as a verdict
+func (p attentionPattern) result() Result {
+	return Result{
+		Level:  Attention,
+		Reason: "Flagged because it " + p.description,
+		Layer:  "attention-patterns",
+		Docs:   p.docs,
+	}
+}
+
+// manPage links a Linux manual page
+func manPage(name string, section int) string {
+	return fmt.Sprintf("https://man7.org/linux/man-pages/man%d/%s.%d.html", section, name, section)
+}
+
+// windowsDocs links the reference of a Windows command
+func windowsDocs(name string) string {
+	return "https://learn.microsoft.com/windows-server/administration/windows-commands/" + name
+}
+
 // NewAnalyzer creates a new binary safety analyzer
 func NewAnalyzer() *Analyzer {
 	return &Analyzer{
+		posixQuoting: true,
+
 		// Patterns that require user attention (dangerous, sudo, etc.)
-		attentionPatterns: []*regexp.Regexp{
+		attentionPatterns: []attentionPattern{
 			// Sudo commands (always need attention)
-			regexp.MustCompile(`\bsudo\b`),
+			{regexp.MustCompile(`\bsudo\b`), "runs a command as root", manPage("sudo", 8)},
 			
 			// Dangerous operations
-			regexp.MustCompile(`\brm\s+.*(-[rf]+|--recursive|--force)`),           // rm with recursive/force flags
-			regexp.MustCompile(`\bdd\s+.*of=/dev/sd`),                              // dd to disk
-			regexp.MustCompile(`\bmkfs\b`),                                         // format filesystem
-			regexp.MustCompile(`\bfdisk\b`),                                        // disk partitioning
-			regexp.MustCompile(`\bshred\b`),                                        // secure delete
-			regexp.MustCompile(`\bwipe\b`),                                         // secure delete
-			regexp.MustCompile(`\bchmod\s+(.*-R.*\s+)?777`),                        // dangerous permissions (with or without -R)
-			regexp.MustCompile(`>\s*/dev/sd`),                                      // redirect to disk
-			regexp.MustCompile(`\bcurl\s+.*\|\s*(sh|bash)`),                        // pipe to shell
-			regexp.MustCompile(`\bwget\s+.*\|\s*(sh|bash)`),                        // pipe to shell
-			regexp.MustCompile(`(sh|bash)\s+-c\s+"?\$\(curl\s+`),                   // sh -c "$(curl ...)"
-			regexp.MustCompile(`(sh|bash)\s+<\(curl\s+`),                           // bash <(curl ...)
-			regexp.MustCompile(`\$\(curl\s+.*\)\s*\|\s*(sh|bash)`),                 // $(curl ...) | sh
-			regexp.MustCompile(`(sh|bash)\s+-c\s+"?\$\(wget\s+`),                   // sh -c "$(wget ...)"
-			regexp.MustCompile(`(sh|bash)\s+<\(wget\s+`),                           // bash <(wget ...)
-			regexp.MustCompile(`\$\(wget\s+.*\)\s*\|\s*(sh|bash)`),                 // $(wget ...) | sh
+			{regexp.MustCompile(`\brm\s+.*(-[rf]+|--recursive|--force)`), "deletes files recursively or without asking", manPage("rm", 1)},
+			{regexp.MustCompile(`\bdd\s+.*of=/dev/sd`), "writes raw data to a disk device", manPage("dd", 1)},
+			{regexp.MustCompile(`\bmkfs\b`), "formats a filesystem, erasing what is on it", manPage("mkfs", 8)},
+			{regexp.MustCompile(`\bfdisk\b`), "changes disk partitions", manPage("fdisk", 8)},
+			{regexp.MustCompile(`\bshred\b`), "overwrites files so they cannot be recovered", manPage("shred", 1)},
+			{regexp.MustCompile(`\bwipe\b`), "erases files so they cannot be recovered", ""},
+			{regexp.MustCompile(`\bchmod\s+(.*-R.*\s+)?777`), "makes files writable and executable by everyone", manPage("chmod", 1)},
+			{regexp.MustCompile(`>\s*/dev/sd`), "writes directly to a disk device", manPage("sd", 4)},
+			{regexp.MustCompile(`\bcurl\s+.*\|\s*(sh|bash)`), "pipes a remote script into a shell", manPage("curl", 1)},
+			{regexp.MustCompile(`\bwget\s+.*\|\s*(sh|bash)`), "pipes a remote script into a shell", manPage("wget", 1)},
+			{regexp.MustCompile(`(sh|bash)\s+-c\s+"?\$\(curl\s+`), "runs a remote script in a shell", manPage("curl", 1)},
+			{regexp.MustCompile(`(sh|bash)\s+<\(curl\s+`), "runs a remote script in a shell", manPage("curl", 1)},
+			{regexp.MustCompile(`\$\(curl\s+.*\)\s*\|\s*(sh|bash)`), "pipes a remote script into a shell", manPage("curl", 1)},
+			{regexp.MustCompile(`(sh|bash)\s+-c\s+"?\$\(wget\s+`), "runs a remote script in a shell", manPage("wget", 1)},
+			{regexp.MustCompile(`(sh|bash)\s+<\(wget\s+`), "runs a remote script in a shell", manPage("wget", 1)},
+			{regexp.MustCompile(`\$\(wget\s+.*\)\s*\|\s*(sh|bash)`), "pipes a remote script into a shell", manPage("wget", 1)},
 			
 			// Commands that typically need sudo (even without sudo keyword)
-			regexp.MustCompile(`\bsystemctl\s+(start|stop|restart|enable|disable)\b`), // service management
-			regexp.MustCompile(`\bapt\s+(install|remove|update|upgrade)\b`),            // package management
-			regexp.MustCompile(`\byum\s+(install|remove|update)\b`),                   // package management
-			regexp.MustCompile(`\bpacman\s+-S\b`),                                     // package management
-			regexp.MustCompile(`\bmodprobe\b`),                                        // kernel modules
-			regexp.MustCompile(`\bmount\b`),                                           // mounting
-			regexp.MustCompile(`\bumount\b`),                                          // unmounting
-			regexp.MustCompile(`\biptables\b`),                                        // firewall
+			{regexp.MustCompile(`\bsystemctl\s+(start|stop|restart|enable|disable)\b`), "starts, stops or changes system services", manPage("systemctl", 1)},
+			{regexp.MustCompile(`\bapt\s+(install|remove|update|upgrade)\b`), "installs, removes or updates system packages", manPage("apt", 8)},
+			{regexp.MustCompile(`\byum\s+(install|remove|update)\b`), "installs, removes or updates system packages", ""},
+			{regexp.MustCompile(`\bpacman\s+-S\b`), "installs or updates system packages", "https://man.archlinux.org/man/pacman.8"},
+			{regexp.MustCompile(`\bmodprobe\b`), "loads or unloads kernel modules", manPage("modprobe", 8)},
+			{regexp.MustCompile(`\bmount\b`), "mounts a filesystem", manPage("mount", 8)},
+			{regexp.MustCompile(`\bumount\b`), "unmounts a filesystem", manPage("umount", 8)},
+			{regexp.MustCompile(`\biptables\b`), "changes firewall rules", manPage("iptables", 8)},
 		},
 		
 		// High-confidence safe patterns (can execute directly)
@@ -115,16 +166,88 @@ func NewAnalyzer() *Analyzer {
 	}
 }
 
+// Target shells the analyzer knows pattern sets for
+const (
+	TargetPosix = "posix" // bash, zsh, fish and other POSIX-like shells (default)
+	TargetCmd   = "cmd"   // Windows cmd.exe batch syntax
+)
+
+// NewAnalyzerFor creates an analyzer with the pattern set for the target shell.
+// Unknown or empty targets use the POSIX pattern set.
+func NewAnalyzerFor(target string) *Analyzer {
+	if target == TargetCmd {
+		return NewCmdAnalyzer()
+	}
+	return NewAnalyzer()
+}
+
+// NewCmdAnalyzer creates an analyzer for Windows cmd.exe batch syntax.
+// cmd.exe is case-insensitive and uses "/" for switches, so every pattern
+// is case-insensitive and matches switches anywhere in the arguments.
+func NewCmdAnalyzer() *Analyzer {
+	return &Analyzer{
+		attentionPatterns: []attentionPattern{
+			// Destructive file operations
+			{regexp.MustCompile(`(?i)\b(del|erase)\s+.*/[sq]\b`), "deletes files recursively or without asking", windowsDocs("del")},
+			{regexp.MustCompile(`(?i)\b(rd|rmdir)\s+.*/s\b`), "removes a whole directory tree", windowsDocs("rmdir")},
+			{regexp.MustCompile(`(?i)\bformat(\.com)?\s+[a-z]:`), "formats a drive, erasing what is on it", windowsDocs("format")},
+			{regexp.MustCompile(`(?i)\bcipher\s+.*/w\b`), "overwrites the free space of a drive", windowsDocs("cipher")},
+			{regexp.MustCompile(`(?i)\bdiskpart\b`), "changes disks and partitions", windowsDocs("diskpart")},
+			{regexp.MustCompile(`(?i)\bbcdedit\b`), "changes the boot configuration", windowsDocs("bcdedit")},
+			
+			// System configuration
+			{regexp.MustCompile(`(?i)\breg(\.exe)?\s+(add|delete|import|restore|load)\b`), "changes the registry", windowsDocs("reg")},
+			{regexp.MustCompile(`(?i)\bsc(\.exe)?\s+(create|delete|stop|config)\b`), "creates, stops or reconfigures services", windowsDocs("sc-config")},
+			{regexp.MustCompile(`(?i)\bnet\s+(user|localgroup|stop|share)\b`), "changes accounts, shares or services", ""},
+			{regexp.MustCompile(`(?i)\bschtasks\s+.*/(create|delete)\b`), "creates or deletes scheduled tasks", windowsDocs("schtasks")},
+			{regexp.MustCompile(`(?i)\btakeown\b`), "takes ownership of files", windowsDocs("takeown")},
+			{regexp.MustCompile(`(?i)\bicacls\s+.*/(grant|reset|setowner)\b`), "changes file permissions", windowsDocs("icacls")},
+			{regexp.MustCompile(`(?i)\bshutdown\s+.*/[rsp]\b`), "restarts or shuts down the machine", windowsDocs("shutdown")},
+			{regexp.MustCompile(`(?i)\bnetsh\s+.*(firewall|advfirewall)\b`), "changes firewall settings", windowsDocs("netsh")},
+			{regexp.MustCompile(`(?i)\brunas\b`), "runs a command as another user", windowsDocs("runas")},
+			
+			// Remote code execution
+			{regexp.MustCompile(`(?i)\bpowershell(\.exe)?\s+.*-(e|enc|encodedcommand)\b`), "runs an encoded PowerShell payload", ""},
+			{regexp.MustCompile(`(?i)\b(certutil|bitsadmin)\s+.*(-urlcache|/transfer)\b`), "downloads files with a system tool", windowsDocs("certutil")},
+			{regexp.MustCompile(`(?i)\b(mshta|rundll32|regsvr32)\b`), "runs code through a system binary often abused by malware", ""},
+		},
+		
+		// High-confidence safe patterns (can execute directly)
+		safePatterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)^dir\b`),      // directory listing
+			regexp.MustCompile(`(?i)^cd\b`),       // change directory
+			regexp.MustCompile(`(?i)^type\b`),     // print file
+			regexp.MustCompile(`(?i)^echo\b`),     // echo
+			regexp.MustCompile(`(?i)^where\b`),    // locate binaries
+			regexp.MustCompile(`(?i)^findstr\b`),  // search text
+			regexp.MustCompile(`(?i)^tasklist\b`), // process list
+			regexp.MustCompile(`(?i)^ipconfig(\s+/all)?\s*$`), // network configuration
+			regexp.MustCompile(`(?i)^ver\b`),      // Windows version
+			regexp.MustCompile(`(?i)^tree\b`),     // directory tree
+		},
+	}
+}
+
 // AnalyzeCommand performs binary safety analysis of a command
 func (a *Analyzer) AnalyzeCommand(ctx context.Context, command string) (Result, error) {
 	// Layer 1: Check for attention patterns first (dangerous, sudo, etc.)
+	candidates := forms(command, a.posixQuoting)
 	for _, pattern := range a.attentionPatterns {
-		if pattern.MatchString(command) {
-			return Result{
-				Level:  Attention,
-				Reason: "Command requires user attention",
-				Layer:  "attention-patterns",
-			}, nil
+		for _, candidate := range candidates {
+			if pattern.re.MatchString(candidate) {
+				return pattern.result(), nil
+			}
+		}
+	}
+
+	// User-defined rules: attention rules first, so a safe rule can't
+	// override one
+	for _, level := range []SafetyLevel{Attention, Safe} {
+		for i := range a.rules {
+			rule := &a.rules[i]
+			if rule.level == level && rule.matches(candidates) {
+				return rule.result(), nil
+			}
 		}
 	}
 	
@@ -149,6 +272,22 @@ func (a *Analyzer) AnalyzeCommand(ctx context.Context, command string) (Result,
 	}, nil
 }
 
+// unquote rebuilds a command from its lexed words and operators with quotes,
+// escapes and line continuations removed
+func unquote(command string) (string, bool) {
+	tokens, err := shell.Lex(command)
+	if err != nil {
+		return "", false
+	}
+	var parts []string
+	for _, token := range tokens {
+		if token.Kind != shell.Comment {
+			parts = append(parts, token.Value)
+		}
+	}
+	return strings.Join(parts, " "), true
+}
+
 // MockAnalyzeCommand provides mock safety analysis for testing
 // This will be controlled by --mock-exit-code flag
 func (a *Analyzer) MockAnalyzeCommand(command string, mockExitCode int) Result {
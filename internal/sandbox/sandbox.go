@@ -0,0 +1,281 @@
+// Package sandbox previews the effect of a command by running it in a
+// throwaway sandbox against a copy of the working directory
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Limits on the working directory copy, so previews stay fast
+const (
+	maxFiles     = 10000
+	maxTotalSize = 200 << 20 // 200 MB
+	runTimeout   = 30 * time.Second
+)
+
+// ErrTooLarge is returned when the working directory is too big to copy
+var ErrTooLarge = errors.New("working directory too large to sandbox")
+
+// Config selects the sandbox runtime
+type Config struct {
+	Runtime string // "auto", "bwrap", "docker" or "podman"
+	Image   string // Container image for docker/podman runtimes
+}
+
+// Report describes what a command did inside the sandbox
+type Report struct {
+	Runtime  string
+	ExitCode int
+	Output   string   // Combined stdout/stderr (truncated)
+	Created  []string // Paths relative to the working directory
+	Modified []string
+	Deleted  []string
+}
+
+// Changed reports whether the command touched any file
+func (r Report) Changed() bool {
+	return len(r.Created)+len(r.Modified)+len(r.Deleted) > 0
+}
+
+// fileState is the snapshot of a single file
+type fileState struct {
+	mode fs.FileMode
+	hash [32]byte
+}
+
+// Preview copies dir into a temporary directory, runs command there inside
+// the configured sandbox runtime and reports which files changed
+func Preview(ctx context.Context, cfg Config, dir, command string) (*Report, error) {
+	runtime, err := selectRuntime(cfg.Runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := os.MkdirTemp("", "hermes-sandbox-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := copyTree(dir, workDir); err != nil {
+		return nil, err
+	}
+
+	before, err := snapshot(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	cmd := buildCommand(ctx, runtime, cfg.Image, workDir, command)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	report := &Report{Runtime: runtime}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("sandbox run failed: %w", err)
+		}
+		report.ExitCode = exitErr.ExitCode()
+	}
+	report.Output = truncate(output.String(), 2000)
+
+	after, err := snapshot(workDir)
+	if err != nil {
+		return nil, err
+	}
+	report.Created, report.Modified, report.Deleted = diff(before, after)
+
+	return report, nil
+}
+
+// selectRuntime resolves "auto" to the first available runtime
+func selectRuntime(runtime string) (string, error) {
+	candidates := []string{"bwrap", "podman", "docker"}
+	if runtime != "" && runtime != "auto" {
+		candidates = []string{runtime}
+	}
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no sandbox runtime available (install bubblewrap, podman or docker)")
+}
+
+// buildCommand creates the sandboxed invocation. The network is always
+// disabled and only the copied working directory is writable.
+func buildCommand(ctx context.Context, runtime, image, workDir, command string) *exec.Cmd {
+	switch runtime {
+	case "bwrap":
+		return exec.CommandContext(ctx, "bwrap",
+			"--ro-bind", "/", "/",
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--tmpfs", "/tmp",
+			"--bind", workDir, workDir,
+			"--chdir", workDir,
+			"--unshare-all",
+			"--die-with-parent",
+			"sh", "-c", command)
+	default:
+		if image == "" {
+			image = "alpine:latest"
+		}
+		args := []string{"run", "--rm", "--network", "none"}
+		// Run as the invoking user, so files the command creates in the
+		// copy are not root-owned and the copy can be removed afterwards.
+		// Rootless podman maps container IDs to subordinate ones unless
+		// told to keep the user's own.
+		if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 && gid >= 0 {
+			if runtime == "podman" && uid != 0 {
+				args = append(args, "--userns", "keep-id")
+			}
+			args = append(args, "--user", fmt.Sprintf("%d:%d", uid, gid))
+		}
+		args = append(args,
+			"-v", workDir+":/work",
+			"-w", "/work",
+			image, "sh", "-c", command)
+		return exec.CommandContext(ctx, runtime, args...)
+	}
+}
+
+// copyTree copies regular files, directories and symlinks from src to dst
+func copyTree(src, dst string) error {
+	var files int
+	var total int64
+
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			files++
+			total += info.Size()
+			if files > maxFiles || total > maxTotalSize {
+				return ErrTooLarge
+			}
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return nil // Skip sockets, devices and pipes
+		}
+	})
+}
+
+// copyFile copies a single regular file
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// snapshot records the mode and content hash of every file under dir
+func snapshot(dir string) (map[string]fileState, error) {
+	states := map[string]fileState{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Files the command made unreadable still count as present
+			if errors.Is(err, fs.ErrPermission) {
+				return nil
+			}
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if rel == "." {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		state := fileState{mode: info.Mode()}
+		if info.Mode().IsRegular() {
+			data, err := os.ReadFile(path)
+			if err == nil {
+				state.hash = sha256.Sum256(data)
+			}
+		}
+		states[rel] = state
+		return nil
+	})
+	return states, err
+}
+
+// diff compares two snapshots
+func diff(before, after map[string]fileState) (created, modified, deleted []string) {
+	for path, state := range after {
+		old, existed := before[path]
+		switch {
+		case !existed:
+			created = append(created, path)
+		case old != state:
+			modified = append(modified, path)
+		}
+	}
+	for path := range before {
+		if _, exists := after[path]; !exists {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(created)
+	sort.Strings(modified)
+	sort.Strings(deleted)
+	return created, modified, deleted
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "\n... (output truncated)"
+}
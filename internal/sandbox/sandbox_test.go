@@ -0,0 +1,165 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile creates path under dir with its parent directories
+func writeFile(t *testing.T, dir, path, content string, perm os.FileMode) {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	// WriteFile only applies perm to new files, and the umask to those
+	if err := os.Chmod(full, perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, src, "README.md", "hello\n", 0o644)
+	writeFile(t, src, "bin/run.sh", "#!/bin/sh\n", 0o755)
+	writeFile(t, src, "src/deep/main.go", "package main\n", 0o600)
+	if err := os.Symlink("README.md", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		t.Fatalf("copyTree() error = %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"README.md":        "hello\n",
+		"bin/run.sh":       "#!/bin/sh\n",
+		"src/deep/main.go": "package main\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dst, path))
+		if err != nil || string(data) != want {
+			t.Errorf("copied %s = %q, %v; want %q", path, data, err, want)
+		}
+	}
+	for path, want := range map[string]os.FileMode{"bin/run.sh": 0o755, "src/deep/main.go": 0o600} {
+		info, err := os.Stat(filepath.Join(dst, path))
+		if err != nil || info.Mode().Perm() != want {
+			t.Errorf("copied %s mode = %v, %v; want %v", path, info.Mode().Perm(), err, want)
+		}
+	}
+	// Symlinks are copied as links, not followed
+	if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != "README.md" {
+		t.Errorf("copied link = %q, %v; want README.md", link, err)
+	}
+}
+
+func TestCopyTreeTooLarge(t *testing.T) {
+	src := t.TempDir()
+	for i := 0; i <= maxFiles; i++ {
+		writeFile(t, src, fmt.Sprintf("d%d/f%d", i%100, i), "", 0o644)
+	}
+	if err := copyTree(src, t.TempDir()); err != ErrTooLarge {
+		t.Errorf("copyTree() of %d files error = %v, want ErrTooLarge", maxFiles+1, err)
+	}
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "keep.txt", "same\n", 0o644)
+	writeFile(t, dir, "edit.txt", "old\n", 0o644)
+	writeFile(t, dir, "chmod.sh", "echo\n", 0o644)
+	writeFile(t, dir, "gone/old.log", "log\n", 0o644)
+
+	before, err := snapshot(dir)
+	if err != nil {
+		t.Fatalf("snapshot() error = %v", err)
+	}
+	if _, ok := before["."]; ok {
+		t.Error("snapshot() recorded the root directory itself")
+	}
+
+	writeFile(t, dir, "edit.txt", "new\n", 0o644)
+	if err := os.Chmod(filepath.Join(dir, "chmod.sh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "gone")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "out/b.txt", "b\n", 0o644)
+	writeFile(t, dir, "a.txt", "a\n", 0o644)
+
+	after, err := snapshot(dir)
+	if err != nil {
+		t.Fatalf("snapshot() error = %v", err)
+	}
+	created, modified, deleted := diff(before, after)
+
+	// Results are sorted and include directories
+	if want := []string{"a.txt", "out", filepath.Join("out", "b.txt")}; !reflect.DeepEqual(created, want) {
+		t.Errorf("created = %q, want %q", created, want)
+	}
+	if want := []string{"chmod.sh", "edit.txt"}; !reflect.DeepEqual(modified, want) {
+		t.Errorf("modified = %q, want %q", modified, want)
+	}
+	if want := []string{"gone", filepath.Join("gone", "old.log")}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %q, want %q", deleted, want)
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	states := map[string]fileState{"a": {mode: 0o644}, "b": {mode: 0o755}}
+	created, modified, deleted := diff(states, states)
+	if created != nil || modified != nil || deleted != nil {
+		t.Errorf("diff() of identical snapshots = %q, %q, %q; want nothing", created, modified, deleted)
+	}
+	if (Report{Created: created, Modified: modified, Deleted: deleted}).Changed() {
+		t.Error("Changed() = true for an untouched tree")
+	}
+	if !(Report{Deleted: []string{"a"}}).Changed() {
+		t.Error("Changed() = false after a deletion")
+	}
+}
+
+func TestBuildCommand(t *testing.T) {
+	ctx := context.Background()
+	user := fmt.Sprintf("--user %d:%d", os.Getuid(), os.Getgid())
+
+	docker := strings.Join(buildCommand(ctx, "docker", "", "/tmp/w", "rm -rf build").Args, " ")
+	for _, want := range []string{"--network none", user, "-v /tmp/w:/work", "-w /work", "alpine:latest sh -c rm -rf build"} {
+		if !strings.Contains(docker, want) {
+			t.Errorf("docker command %q is missing %q", docker, want)
+		}
+	}
+
+	podman := strings.Join(buildCommand(ctx, "podman", "debian:stable", "/tmp/w", "ls").Args, " ")
+	if !strings.Contains(podman, user) || !strings.Contains(podman, "debian:stable sh -c ls") {
+		t.Errorf("podman command %q, want %q and the configured image", podman, user)
+	}
+	if keepID := strings.Contains(podman, "--userns keep-id"); keepID != (os.Getuid() != 0) {
+		t.Errorf("podman command %q: --userns keep-id = %v, want it only for rootless runs", podman, keepID)
+	}
+
+	bwrap := strings.Join(buildCommand(ctx, "bwrap", "", "/tmp/w", "ls").Args, " ")
+	for _, want := range []string{"--bind /tmp/w /tmp/w", "--chdir /tmp/w", "--unshare-all", "sh -c ls"} {
+		if !strings.Contains(bwrap, want) {
+			t.Errorf("bwrap command %q is missing %q", bwrap, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("truncate(short) = %q", got)
+	}
+	if got := truncate("0123456789abc", 10); got != "0123456789\n... (output truncated)" {
+		t.Errorf("truncate(long) = %q", got)
+	}
+}
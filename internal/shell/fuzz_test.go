@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+)
+
+// FuzzParse checks that the lexer and parser never panic and that tokens
+// always point back at the exact source text they came from
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"ls -la | grep foo > out.txt 2>&1",
+		"su''do ls",
+		`echo "a \"quoted\" $(date +%s)" 'single' \escaped`,
+		"cmd1 && cmd2 || cmd3; cmd4 &",
+		"echo ok\nsudo reboot",
+		"su\\\ndo ls",
+		"( cd /tmp && make ) | tee log",
+		"cat <<EOF\nbody\nEOF",
+		"echo `date` $((1+2))",
+		"diff <(ls a) <(ls b) > >(tee log)",
+		`case "$1" in start) echo s;; *) echo x;; esac`,
+		"ѕudo ls # trailing comment",
+		"echo 'unterminated",
+		"ls | # list\n  wc -l",
+		"ls |;",
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, command string) {
+		tokens, lexErr := Lex(command)
+		script, parseErr := Parse(command)
+		if lexErr != nil && parseErr == nil {
+			t.Fatalf("Parse(%q) succeeded although Lex failed: %v", command, lexErr)
+		}
+
+		for _, token := range tokens {
+			if token.Pos < 0 || token.Pos+len(token.Raw) > len(command) {
+				t.Fatalf("Lex(%q) token %+v out of bounds", command, token)
+			}
+			if command[token.Pos:token.Pos+len(token.Raw)] != token.Raw {
+				t.Fatalf("Lex(%q) token %+v does not match the source", command, token)
+			}
+			if token.Kind == Word && token.Raw == "" {
+				t.Fatalf("Lex(%q) produced an empty word", command)
+			}
+		}
+
+		if parseErr != nil {
+			return
+		}
+		for _, pipeline := range script.Pipelines {
+			if len(pipeline.Stages) == 0 {
+				t.Fatalf("Parse(%q) produced an empty pipeline", command)
+			}
+			for _, stage := range pipeline.Stages {
+				if len(stage.Args) == 0 && len(stage.Redirects) == 0 {
+					t.Fatalf("Parse(%q) produced an empty stage", command)
+				}
+			}
+			switch pipeline.Next {
+			case "", "&&", "||", ";", ";;", "&":
+			default:
+				t.Fatalf("Parse(%q) pipeline joined by %q", command, strings.TrimSpace(pipeline.Next))
+			}
+		}
+	})
+}
@@ -0,0 +1,564 @@
+// Package shell provides a small POSIX shell lexer and parser used to
+// inspect commands without executing them
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TokenKind distinguishes words from control and redirection operators
+type TokenKind int
+
+const (
+	Word TokenKind = iota
+	Operator
+	Comment
+	HereDoc // Body of a here-document, up to and including its delimiter line
+)
+
+// Token is a single lexical element of a command line
+type Token struct {
+	Kind  TokenKind
+	Value string // Word with quotes and escapes removed, or the operator itself
+	Raw   string // Exact source text
+	Pos   int    // Byte offset of Raw in the command
+}
+
+// Quoted reports whether any part of the word was quoted or escaped
+func (t Token) Quoted() bool {
+	return t.Kind == Word && t.Raw != t.Value
+}
+
+// SyntaxError describes why a command could not be lexed or parsed.
+// Unsupported marks constructs that may well be valid shell but that this
+// package does not model, so callers can treat them as unknown.
+type SyntaxError struct {
+	Pos         int
+	Message     string
+	Unsupported bool
+}
+
+func (e SyntaxError) Error() string {
+	if e.Unsupported {
+		return fmt.Sprintf("unsupported syntax at offset %d: %s", e.Pos, e.Message)
+	}
+	return fmt.Sprintf("syntax error at offset %d: %s", e.Pos, e.Message)
+}
+
+// IsUnsupported reports whether err is a SyntaxError for a construct this
+// package does not model, rather than a definite mistake
+func IsUnsupported(err error) bool {
+	var syntaxErr SyntaxError
+	return errors.As(err, &syntaxErr) && syntaxErr.Unsupported
+}
+
+// operators lists control and redirection operators, longest first so the
+// lexer always takes the longest match
+var operators = []string{
+	"&>>", "<<<", "<<-", "2>&1", "2>>",
+	"&&", "||", ";;", ">>", "<<", ">&", "<&", "&>", ">|", "<>", "2>", "|&",
+	"|", "&", ";", "<", ">", "(", ")", "\n",
+}
+
+// hereDoc is a here-document whose body has not been read yet
+type hereDoc struct {
+	delimiter string
+	stripTabs bool // <<- removes leading tabs from the body lines
+}
+
+// Lex splits a command line into tokens. Here-document bodies become a
+// single HereDoc token after the newline that ends their operator's line.
+func Lex(command string) ([]Token, error) {
+	var tokens []Token
+	var pending []hereDoc
+	i := 0
+	for i < len(command) {
+		c := command[i]
+
+		// Whitespace separates tokens (newlines are operators)
+		if c == ' ' || c == '\t' || c == '\r' {
+			i++
+			continue
+		}
+
+		// Line continuation
+		if c == '\\' && i+1 < len(command) && command[i+1] == '\n' {
+			i += 2
+			continue
+		}
+
+		// Comments run to the end of the line
+		if c == '#' {
+			end := strings.IndexByte(command[i:], '\n')
+			if end < 0 {
+				end = len(command) - i
+			}
+			tokens = append(tokens, Token{Kind: Comment, Value: strings.TrimSpace(command[i+1 : i+end]), Raw: command[i : i+end], Pos: i})
+			i += end
+			continue
+		}
+
+		// Case fall-through terminators are bash-only and not modeled
+		if isFallThrough(command[i:]) {
+			return nil, SyntaxError{Pos: i, Message: "case fall-through", Unsupported: true}
+		}
+
+		if op := matchOperator(command[i:]); op != "" && !isProcessSubstitution(command, i) {
+			tokens = append(tokens, Token{Kind: Operator, Value: op, Raw: op, Pos: i})
+			i += len(op)
+			if op == "\n" {
+				for _, doc := range pending {
+					if i >= len(command) {
+						break
+					}
+					token := readHereDoc(command, i, doc)
+					tokens = append(tokens, token)
+					i += len(token.Raw)
+				}
+				pending = nil
+			}
+			continue
+		}
+
+		word, end, err := lexWord(command, i)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == Operator && (tokens[n-1].Value == "<<" || tokens[n-1].Value == "<<-") {
+			pending = append(pending, hereDoc{delimiter: word, stripTabs: tokens[n-1].Value == "<<-"})
+		}
+		tokens = append(tokens, Token{Kind: Word, Value: word, Raw: command[i:end], Pos: i})
+		i = end
+	}
+	return tokens, nil
+}
+
+// readHereDoc reads a here-document body starting at start, up to and
+// including the line holding only its delimiter. Like the shell, a body
+// without a delimiter line runs to the end of the input.
+func readHereDoc(command string, start int, doc hereDoc) Token {
+	i := start
+	for i < len(command) {
+		lineEnd := strings.IndexByte(command[i:], '\n')
+		next := i + lineEnd + 1
+		if lineEnd < 0 {
+			lineEnd = len(command) - i
+			next = len(command)
+		}
+		line := command[i : i+lineEnd]
+		if doc.stripTabs {
+			line = strings.TrimLeft(line, "\t")
+		}
+		if line == doc.delimiter {
+			return Token{Kind: HereDoc, Value: command[start:i], Raw: command[start:next], Pos: start}
+		}
+		i = next
+	}
+	return Token{Kind: HereDoc, Value: command[start:], Raw: command[start:], Pos: start}
+}
+
+// isFallThrough reports whether s starts with a ;& or ;;& case terminator
+// (and not a separator followed by a redirect, as in "ls;&>log")
+func isFallThrough(s string) bool {
+	if strings.HasPrefix(s, ";;&") {
+		return true
+	}
+	return strings.HasPrefix(s, ";&") && !strings.HasPrefix(s, ";&>") && !strings.HasPrefix(s, ";&&")
+}
+
+// matchOperator returns the operator at the start of s, if any
+func matchOperator(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// lexWord reads a single word starting at start, returning its unquoted value
+// and the offset just past it
+func lexWord(command string, start int) (string, int, error) {
+	var b strings.Builder
+	i := start
+	for i < len(command) {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			return b.String(), i, nil
+		case c == '\\':
+			if i+1 >= len(command) {
+				return "", 0, SyntaxError{Pos: i, Message: "trailing backslash"}
+			}
+			// A backslash-newline inside a word is a line continuation
+			if command[i+1] != '\n' {
+				b.WriteByte(command[i+1])
+			}
+			i += 2
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return "", 0, SyntaxError{Pos: i, Message: "unterminated single quote"}
+			}
+			b.WriteString(command[i+1 : i+1+end])
+			i += end + 2
+		case c == '"':
+			value, end, err := lexDoubleQuoted(command, i)
+			if err != nil {
+				return "", 0, err
+			}
+			b.WriteString(value)
+			i = end
+		case c == '$' && i+1 < len(command) && command[i+1] == '(':
+			end, err := matchParen(command, i+1)
+			if err != nil {
+				return "", 0, err
+			}
+			b.WriteString(command[i:end])
+			i = end
+		case c == '$' && i+1 < len(command) && command[i+1] == '{':
+			end, err := matchBrace(command, i+1)
+			if err != nil {
+				return "", 0, err
+			}
+			b.WriteString(command[i:end])
+			i = end
+		case c == '$' && i+1 < len(command) && command[i+1] == '\'':
+			value, end, err := lexANSIQuoted(command, i)
+			if err != nil {
+				return "", 0, err
+			}
+			b.WriteString(value)
+			i = end
+		case isProcessSubstitution(command, i):
+			end, err := matchParen(command, i+1)
+			if err != nil {
+				return "", 0, err
+			}
+			b.WriteString(command[i:end])
+			i = end
+		case c == '`':
+			end := strings.IndexByte(command[i+1:], '`')
+			if end < 0 {
+				return "", 0, SyntaxError{Pos: i, Message: "unterminated backtick substitution"}
+			}
+			if end > 0 && command[i+end] == '\\' {
+				return "", 0, SyntaxError{Pos: i, Message: "nested backtick substitution", Unsupported: true}
+			}
+			b.WriteString(command[i : i+end+2])
+			i += end + 2
+		default:
+			if matchOperator(command[i:]) != "" && !isFdRedirect(command, start, i) {
+				return b.String(), i, nil
+			}
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), i, nil
+}
+
+// isProcessSubstitution reports whether <( or >( starts at i
+func isProcessSubstitution(command string, i int) bool {
+	return (command[i] == '<' || command[i] == '>') && i+1 < len(command) && command[i+1] == '('
+}
+
+// isFdRedirect keeps words like "file2>" from being split inside a word:
+// "2>" only counts as an operator at the start of a word
+func isFdRedirect(command string, start, i int) bool {
+	return i > start && command[i] == '2' && (strings.HasPrefix(command[i:], "2>"))
+}
+
+// lexDoubleQuoted reads a double-quoted string starting at the opening quote
+func lexDoubleQuoted(command string, start int) (string, int, error) {
+	var b strings.Builder
+	i := start + 1
+	for i < len(command) {
+		c := command[i]
+		switch {
+		case c == '"':
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(command) && strings.IndexByte("$`\"\\\n", command[i+1]) >= 0:
+			if command[i+1] != '\n' {
+				b.WriteByte(command[i+1])
+			}
+			i += 2
+		case c == '$' && i+1 < len(command) && command[i+1] == '(':
+			end, err := matchParen(command, i+1)
+			if err != nil {
+				return "", 0, err
+			}
+			b.WriteString(command[i:end])
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, SyntaxError{Pos: start, Message: "unterminated double quote"}
+}
+
+// lexANSIQuoted reads a bash $'...' string starting at the dollar sign,
+// where backslash escapes (including \') are honored
+func lexANSIQuoted(command string, start int) (string, int, error) {
+	escapes := map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', 'a': '\a', 'b': '\b', 'e': 0x1b, 'f': '\f', 'v': '\v'}
+	var b strings.Builder
+	i := start + 2
+	for i < len(command) {
+		c := command[i]
+		switch {
+		case c == '\'':
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(command):
+			if escaped, ok := escapes[command[i+1]]; ok {
+				b.WriteByte(escaped)
+			} else {
+				b.WriteByte(command[i+1])
+			}
+			i += 2
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, SyntaxError{Pos: start, Message: "unterminated single quote"}
+}
+
+// matchBrace finds the end of a parameter expansion starting at the opening
+// brace, honoring nested expansions, substitutions and quotes, so operators
+// in a default value (${x:-(}) stay part of the word
+func matchBrace(command string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(command); i++ {
+		switch command[i] {
+		case '\\':
+			i++
+		case '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return 0, SyntaxError{Pos: i, Message: "unterminated single quote"}
+			}
+			i += end + 1
+		case '"':
+			_, end, err := lexDoubleQuoted(command, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end - 1
+		case '$':
+			if i+1 < len(command) && command[i+1] == '(' {
+				end, err := matchParen(command, i+1)
+				if err != nil {
+					return 0, err
+				}
+				i = end - 1
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, SyntaxError{Pos: open - 1, Message: "unterminated parameter expansion"}
+}
+
+// matchParen finds the end of a parenthesized substitution starting at the
+// opening parenthesis, honoring nested parentheses and quotes
+func matchParen(command string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(command); i++ {
+		switch command[i] {
+		case '\\':
+			i++
+		case '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return 0, SyntaxError{Pos: i, Message: "unterminated single quote"}
+			}
+			i += end + 1
+		case '"':
+			_, end, err := lexDoubleQuoted(command, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, SyntaxError{Pos: open - 1, Message: "unterminated command substitution"}
+}
+
+// Stage is a single simple command within a pipeline
+type Stage struct {
+	Args      []Token // Command name followed by its arguments
+	Redirects []Redirect
+}
+
+// Name returns the command name of the stage (without any leading path)
+func (s Stage) Name() string {
+	for _, arg := range s.Args {
+		// Skip leading variable assignments (FOO=bar cmd)
+		if strings.Contains(arg.Value, "=") && !strings.HasPrefix(arg.Value, "=") && !arg.Quoted() && !strings.HasPrefix(arg.Value, "-") {
+			continue
+		}
+		name := arg.Value
+		if idx := strings.LastIndexByte(name, '/'); idx >= 0 {
+			name = name[idx+1:]
+		}
+		return name
+	}
+	return ""
+}
+
+// Redirect is an I/O redirection attached to a stage
+type Redirect struct {
+	Op     string // e.g., ">", ">>", "2>", "<"
+	Target string // File name or file descriptor
+}
+
+// Pipeline is a sequence of stages connected by pipes
+type Pipeline struct {
+	Stages []Stage
+	Next   string // Operator joining this pipeline to the next ("&&", "||", ";", "&" or "")
+}
+
+// Script is a parsed command line
+type Script struct {
+	Pipelines []Pipeline
+	Comments  []Token
+}
+
+// Stages returns every stage in the script, in order
+func (s *Script) Stages() []Stage {
+	var stages []Stage
+	for _, pipeline := range s.Pipelines {
+		stages = append(stages, pipeline.Stages...)
+	}
+	return stages
+}
+
+// redirectOps are operators that take a target word
+var redirectOps = map[string]bool{
+	">": true, ">>": true, "<": true, "2>": true, "2>>": true, "&>": true, "&>>": true,
+	">&": true, "<&": true, "<<": true, "<<-": true, "<<<": true, ">|": true, "<>": true,
+}
+
+// Parse lexes and parses a command line into pipelines and stages. Subshell
+// grouping with parentheses is flattened into the surrounding pipelines,
+// case patterns are dropped so each branch is an ordinary stage, and
+// here-document bodies are skipped (their redirect keeps the delimiter).
+func Parse(command string) (*Script, error) {
+	tokens, err := Lex(command)
+	if err != nil {
+		return nil, err
+	}
+
+	script := &Script{}
+	var pipeline Pipeline
+	var stage Stage
+	depth := 0
+	cases := 0 // Open case statements, whose patterns end with ")"
+
+	finishStage := func(pos int, op string) error {
+		if len(stage.Args) == 0 && len(stage.Redirects) == 0 {
+			return SyntaxError{Pos: pos, Message: fmt.Sprintf("unexpected %q", op)}
+		}
+		pipeline.Stages = append(pipeline.Stages, stage)
+		stage = Stage{}
+		return nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token.Kind == Comment:
+			script.Comments = append(script.Comments, token)
+		case token.Kind == HereDoc:
+			continue
+		case token.Kind == Word:
+			stage.Args = append(stage.Args, token)
+			switch {
+			case len(stage.Args) == 1 && token.Value == "esac" && cases > 0:
+				cases--
+			case len(stage.Args) == 3 && stage.Args[0].Value == "case" && token.Value == "in":
+				// End the case header so each pattern starts a new stage
+				cases++
+				pipeline.Stages = append(pipeline.Stages, stage)
+				pipeline.Next = ";"
+				script.Pipelines = append(script.Pipelines, pipeline)
+				pipeline, stage = Pipeline{}, Stage{}
+			}
+		case token.Value == ")" && depth == 0 && cases > 0:
+			// A case pattern is not a command; drop it
+			stage = Stage{}
+		case token.Value == "2>&1":
+			stage.Redirects = append(stage.Redirects, Redirect{Op: "2>", Target: "&1"})
+		case redirectOps[token.Value]:
+			if i+1 >= len(tokens) || tokens[i+1].Kind != Word {
+				return nil, SyntaxError{Pos: token.Pos, Message: fmt.Sprintf("missing target for %q", token.Value)}
+			}
+			i++
+			stage.Redirects = append(stage.Redirects, Redirect{Op: token.Value, Target: tokens[i].Value})
+		case token.Value == "|" || token.Value == "|&":
+			if err := finishStage(token.Pos, token.Value); err != nil {
+				return nil, err
+			}
+		case token.Value == "(":
+			depth++
+		case token.Value == ")":
+			depth--
+			if depth < 0 {
+				return nil, SyntaxError{Pos: token.Pos, Message: `unexpected ")"`}
+			}
+		default: // &&, ||, ;, ;;, &, newline
+			if len(stage.Args) == 0 && len(stage.Redirects) == 0 {
+				if token.Value == "\n" && len(pipeline.Stages) > 0 {
+					continue // A pipeline continues on the line after "|"
+				}
+				if len(pipeline.Stages) > 0 {
+					return nil, SyntaxError{Pos: token.Pos, Message: fmt.Sprintf("unexpected %q after pipe", strings.TrimSpace(token.Value))}
+				}
+				if token.Value == "\n" || token.Value == ";" {
+					continue // Blank lines and stray separators
+				}
+				return nil, SyntaxError{Pos: token.Pos, Message: fmt.Sprintf("unexpected %q", token.Value)}
+			}
+			pipeline.Stages = append(pipeline.Stages, stage)
+			stage = Stage{}
+			pipeline.Next = token.Value
+			if pipeline.Next == "\n" {
+				pipeline.Next = ";"
+			}
+			script.Pipelines = append(script.Pipelines, pipeline)
+			pipeline = Pipeline{}
+		}
+	}
+
+	if depth != 0 {
+		return nil, SyntaxError{Pos: len(command), Message: "unbalanced parentheses"}
+	}
+	if len(stage.Args) > 0 || len(stage.Redirects) > 0 {
+		pipeline.Stages = append(pipeline.Stages, stage)
+	} else if len(pipeline.Stages) > 0 {
+		return nil, SyntaxError{Pos: len(command), Message: "pipeline ends with an operator"}
+	}
+	if len(pipeline.Stages) > 0 {
+		script.Pipelines = append(script.Pipelines, pipeline)
+	}
+	if n := len(script.Pipelines); n > 0 && (script.Pipelines[n-1].Next == "&&" || script.Pipelines[n-1].Next == "||") {
+		return nil, SyntaxError{Pos: len(command), Message: "command ends with " + script.Pipelines[n-1].Next}
+	}
+
+	return script, nil
+}
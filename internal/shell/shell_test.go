@@ -0,0 +1,362 @@
+package shell
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// words returns the values of the word tokens in command
+func words(t *testing.T, command string) []string {
+	t.Helper()
+	tokens, err := Lex(command)
+	if err != nil {
+		t.Fatalf("Lex(%q) error = %v", command, err)
+	}
+	var values []string
+	for _, token := range tokens {
+		if token.Kind == Word {
+			values = append(values, token.Value)
+		}
+	}
+	return values
+}
+
+func TestLexHereDoc(t *testing.T) {
+	tests := []struct {
+		command string
+		body    []string
+		words   []string
+	}{
+		{"cat <<'EOF' > notes.txt\nDon't forget (milk)\nEOF", []string{"Don't forget (milk)\n"}, []string{"cat", "EOF", "notes.txt"}},
+		{"cat <<EOF\nif (x; then\nEOF\necho done", []string{"if (x; then\n"}, []string{"cat", "EOF", "echo", "done"}},
+		{"cat <<-END\n\tindented `\n\tEND", []string{"\tindented `\n"}, []string{"cat", "END"}},
+		{"cat <<A <<B\na\nA\nb\nB\n", []string{"a\n", "b\n"}, []string{"cat", "A", "B"}},
+		{"cat <<EOF\nno delimiter (", []string{"no delimiter ("}, []string{"cat", "EOF"}},
+		{"cat <<EOF", nil, []string{"cat", "EOF"}},
+		{"cat <<<'here (string)'", nil, []string{"cat", "here (string)"}},
+	}
+	for _, tt := range tests {
+		tokens, err := Lex(tt.command)
+		if err != nil {
+			t.Errorf("Lex(%q) error = %v", tt.command, err)
+			continue
+		}
+		var body []string
+		for _, token := range tokens {
+			if token.Kind == HereDoc {
+				body = append(body, token.Value)
+			}
+		}
+		if !reflect.DeepEqual(body, tt.body) {
+			t.Errorf("Lex(%q) here-documents = %q, want %q", tt.command, body, tt.body)
+		}
+		if got := words(t, tt.command); !reflect.DeepEqual(got, tt.words) {
+			t.Errorf("Lex(%q) words = %q, want %q", tt.command, got, tt.words)
+		}
+		if _, err := Parse(tt.command); err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.command, err)
+		}
+	}
+}
+
+func TestParseHereDocKeepsFollowingCommands(t *testing.T) {
+	script, err := Parse("cat <<EOF | sort\nb\na\nEOF\nrm -f tmp")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var names []string
+	for _, stage := range script.Stages() {
+		names = append(names, stage.Name())
+	}
+	if want := []string{"cat", "sort", "rm"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Stages() = %q, want %q", names, want)
+	}
+	if got := script.Stages()[0].Redirects; !reflect.DeepEqual(got, []Redirect{{Op: "<<", Target: "EOF"}}) {
+		t.Errorf("Redirects = %+v, want the here-document delimiter", got)
+	}
+}
+
+func TestLexExpansionsAndClobber(t *testing.T) {
+	tests := []struct {
+		command string
+		words   []string
+	}{
+		{"echo ${x:-(}", []string{"echo", "${x:-(}"}},
+		{"echo ${x:-${y:-)}}|wc", []string{"echo", "${x:-${y:-)}}", "wc"}},
+		{"echo ${x:-'}'}", []string{"echo", "${x:-'}'}"}},
+		{"echo ${x:-$(date)}", []string{"echo", "${x:-$(date)}"}},
+		{`echo $'it\'s\tdone'`, []string{"echo", "it's\tdone"}},
+	}
+	for _, tt := range tests {
+		if got := words(t, tt.command); !reflect.DeepEqual(got, tt.words) {
+			t.Errorf("Lex(%q) words = %q, want %q", tt.command, got, tt.words)
+		}
+	}
+
+	script, err := Parse("echo done >| status.txt")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := script.Stages()[0].Redirects; !reflect.DeepEqual(got, []Redirect{{Op: ">|", Target: "status.txt"}}) {
+		t.Errorf("Redirects = %+v, want >| status.txt", got)
+	}
+
+	if _, err := Lex("echo ${x:-("); err == nil {
+		t.Error("Lex() accepted an unterminated parameter expansion")
+	}
+}
+
+func TestUnsupportedSyntax(t *testing.T) {
+	for _, command := range []string{
+		`case $x in a) echo a;& b) echo b;; esac`,
+		`case $x in a) echo a;;& *) echo b;; esac`,
+		"echo `echo \\`date\\``",
+	} {
+		_, err := Parse(command)
+		if !IsUnsupported(err) {
+			t.Errorf("Parse(%q) error = %v, want unsupported", command, err)
+		}
+	}
+	for _, command := range []string{"echo 'open", "ls |"} {
+		if _, err := Parse(command); err == nil || IsUnsupported(err) {
+			t.Errorf("Parse(%q) error = %v, want a definite syntax error", command, err)
+		}
+	}
+	if _, err := Parse("make;&>build.log"); err != nil {
+		t.Errorf("Parse() error = %v, want a separator followed by a redirect", err)
+	}
+}
+
+func TestLexQuoting(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{`echo 'a b' "c d"`, []string{"echo", "a b", "c d"}},
+		{`echo 'it'\''s'`, []string{"echo", "it's"}},
+		{`echo "say \"hi\" \$HOME \\ \n"`, []string{"echo", `say "hi" $HOME \ \n`}},
+		{`echo "$HOME/x" '$HOME'`, []string{"echo", "$HOME/x", "$HOME"}},
+		{`echo a\ b c\;d`, []string{"echo", "a b", "c;d"}},
+		{`echo "a;b|c&d" 'e>f'`, []string{"echo", "a;b|c&d", "e>f"}},
+		{`echo pre"mid"'post'`, []string{"echo", "premidpost"}},
+		{"echo \"one\\\ntwo\"", []string{"echo", "onetwo"}},
+		{"echo one \\\n two", []string{"echo", "one", "two"}},
+		{`echo $'tab\there'`, []string{"echo", "tab\there"}},
+		{`echo ""`, []string{"echo", ""}},
+	}
+	for _, tt := range tests {
+		if got := words(t, tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lex(%q) words = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestTokenQuoted(t *testing.T) {
+	tokens, err := Lex(`rm -rf "build" dist\ x ./out`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bool{false, false, true, true, false}
+	for i, token := range tokens {
+		if token.Quoted() != want[i] {
+			t.Errorf("%q Quoted() = %v, want %v", token.Raw, token.Quoted(), want[i])
+		}
+	}
+	if tokens[3].Raw != `dist\ x` || tokens[3].Pos != 15 {
+		t.Errorf("token 3 = %q at %d, want the raw text at offset 15", tokens[3].Raw, tokens[3].Pos)
+	}
+}
+
+func TestLexOperators(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string // Operator values in order
+	}{
+		{"a && b || c; d & e", []string{"&&", "||", ";", "&"}},
+		{"a | b |& c", []string{"|", "|&"}},
+		{"a > f >> g < h 2> e 2>> e2", []string{">", ">>", "<", "2>", "2>>"}},
+		{"a &> f &>> g 2>&1 >&2 <&3", []string{"&>", "&>>", "2>&1", ">&", "<&"}},
+		{"a >| f <> g <<< s", []string{">|", "<>", "<<<"}},
+		{"(a; b)\nc", []string{"(", ";", ")", "\n"}},
+		{"a>f", []string{">"}},
+		{"cp file2> x", []string{">"}},
+	}
+	for _, tt := range tests {
+		tokens, err := Lex(tt.command)
+		if err != nil {
+			t.Fatalf("Lex(%q) error = %v", tt.command, err)
+		}
+		var got []string
+		for _, token := range tokens {
+			if token.Kind == Operator {
+				got = append(got, token.Value)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lex(%q) operators = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestLexSubstitutions(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"echo $(date +%s)", []string{"echo", "$(date +%s)"}},
+		{"echo $(ls $(pwd) | wc -l)", []string{"echo", "$(ls $(pwd) | wc -l)"}},
+		{`echo "today: $(date "+%F")"`, []string{"echo", "today: $(date \"+%F\")"}},
+		{"echo $(echo ')')", []string{"echo", "$(echo ')')"}},
+		{"echo `uname -r`", []string{"echo", "`uname -r`"}},
+		{"diff <(sort a) >(tee b)", []string{"diff", "<(sort a)", ">(tee b)"}},
+		{"echo ${HOME:-/tmp} ${#x}", []string{"echo", "${HOME:-/tmp}", "${#x}"}},
+		{"x=$(id -u); echo $x", []string{"x=$(id -u)", "echo", "$x"}},
+	}
+	for _, tt := range tests {
+		if got := words(t, tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lex(%q) words = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestLexComments(t *testing.T) {
+	tokens, err := Lex("ls # list files\necho a#b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var comments []string
+	for _, token := range tokens {
+		if token.Kind == Comment {
+			comments = append(comments, token.Value)
+		}
+	}
+	if !reflect.DeepEqual(comments, []string{"list files"}) {
+		t.Errorf("comments = %q, want only the one starting a word", comments)
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	tests := []string{
+		"echo 'open",
+		`echo "open`,
+		"echo $(date",
+		"echo ${HOME",
+		"echo `date",
+		`echo trailing\`,
+	}
+	for _, command := range tests {
+		_, err := Lex(command)
+		var syntaxErr SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Unsupported {
+			t.Errorf("Lex(%q) error = %v, want a syntax error", command, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	type stage struct {
+		name      string
+		args      int
+		redirects []Redirect
+	}
+	tests := []struct {
+		command   string
+		pipelines [][]stage
+		next      []string
+	}{
+		{"ls -la", [][]stage{{{"ls", 2, nil}}}, []string{""}},
+		{"ps aux | grep nginx | wc -l", [][]stage{{{"ps", 2, nil}, {"grep", 2, nil}, {"wc", 2, nil}}}, []string{""}},
+		{"make && ./run || echo failed; sleep 1 &", [][]stage{{{"make", 1, nil}}, {{"run", 1, nil}}, {{"echo", 2, nil}}, {{"sleep", 2, nil}}}, []string{"&&", "||", ";", "&"}},
+		{"cd src\nmake", [][]stage{{{"cd", 2, nil}}, {{"make", 1, nil}}}, []string{";", ""}},
+		{"sort < in > out 2>&1", [][]stage{{{"sort", 1, []Redirect{{"<", "in"}, {">", "out"}, {"2>", "&1"}}}}}, []string{""}},
+		{"FOO=1 BAR=2 /usr/bin/env | cat", [][]stage{{{"env", 3, nil}, {"cat", 1, nil}}}, []string{""}},
+		{"(cd /tmp && ls) | head", [][]stage{{{"cd", 2, nil}}, {{"ls", 1, nil}, {"head", 1, nil}}}, []string{"&&", ""}},
+		{"ls |\n  wc -l", [][]stage{{{"ls", 1, nil}, {"wc", 2, nil}}}, []string{""}},
+		{"case $x in a) echo a;; *) echo other;; esac", [][]stage{{{"case", 3, nil}}, {{"echo", 2, nil}}, {{"echo", 2, nil}}, {{"esac", 1, nil}}}, []string{";", ";;", ";;", ""}},
+	}
+	for _, tt := range tests {
+		script, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.command, err)
+		}
+		var got [][]stage
+		var next []string
+		for _, pipeline := range script.Pipelines {
+			var stages []stage
+			for _, s := range pipeline.Stages {
+				stages = append(stages, stage{s.Name(), len(s.Args), s.Redirects})
+			}
+			got = append(got, stages)
+			next = append(next, pipeline.Next)
+		}
+		if !reflect.DeepEqual(got, tt.pipelines) || !reflect.DeepEqual(next, tt.next) {
+			t.Errorf("Parse(%q) = %+v joined by %q, want %+v joined by %q", tt.command, got, next, tt.pipelines, tt.next)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"| grep x",
+		"ls |",
+		"ls | | wc",
+		"make &&",
+		"a || ",
+		"&& ls",
+		"(ls",
+		"ls)",
+		"echo >",
+		"cat < | wc",
+	}
+	for _, command := range tests {
+		if _, err := Parse(command); err == nil {
+			t.Errorf("Parse(%q) = nil error, want a syntax error", command)
+		}
+	}
+}
+
+func TestStages(t *testing.T) {
+	script, err := Parse("git add -A && git commit -m 'msg' | tee log; ./deploy.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, stage := range script.Stages() {
+		names = append(names, stage.Name())
+	}
+	if want := []string{"git", "git", "tee", "deploy.sh"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Stages() names = %q, want %q", names, want)
+	}
+
+	empty, err := Parse("# just a comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty.Stages()) != 0 || len(empty.Comments) != 1 {
+		t.Errorf("comment-only script: %d stages, %d comments; want 0 and 1", len(empty.Stages()), len(empty.Comments))
+	}
+}
+
+func TestStageName(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"ls", "ls"},
+		{"/usr/local/bin/rg foo", "rg"},
+		{"LANG=C sort", "sort"},
+		{"'FOO=bar' cmd", "FOO=bar"},
+		{"--x=1", "--x=1"},
+		{"A=1", ""},
+	}
+	for _, tt := range tests {
+		script, err := Parse(tt.command)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.command, err)
+		}
+		if got := script.Stages()[0].Name(); got != tt.want {
+			t.Errorf("Name() of %q = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
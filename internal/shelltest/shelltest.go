@@ -0,0 +1,177 @@
+// Package shelltest runs the shell integration scripts under real shells
+// against a fake hermes binary, so buffer placement and warnings can be
+// tested without an API key or an interactive terminal
+package shelltest
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeHermes replays the exit code and output configured through the
+// environment and logs every invocation
+const fakeHermes = `#!/bin/sh
+printf '%s\t%s\n' "${HERMES_SHELL_INTEGRATION:-0}" "$*" >> "$HERMES_FAKE_LOG"
+printf '%s' "$HERMES_FAKE_STDOUT"
+if [ -n "$HERMES_FAKE_STDERR" ]; then
+    printf '%s\n' "$HERMES_FAKE_STDERR" >&2
+fi
+exit "${HERMES_FAKE_EXIT:-0}"
+`
+
+// stubs replace the line-editor builtins, which need an interactive
+// terminal, with functions that record what would land in the buffer
+var stubs = map[string]string{
+	"bash": `read() {
+    if [ "$1" = "-e" ] && [ "$2" = "-i" ]; then
+        printf '%s' "$3" > "$HERMES_TEST_BUFFER"
+        return 0
+    fi
+    builtin read "$@"
+}
+history() {
+    if [ "$1" = "-s" ]; then
+        [ "$2" = "--" ] && shift
+        printf '%s' "$2" > "$HERMES_TEST_BUFFER"
+        return 0
+    fi
+    builtin history "$@"
+}
+`,
+	"zsh": `print() {
+    if [[ "$1" == -z || "$1" == -rz ]]; then
+        shift
+        [[ "$1" == -- ]] && shift
+        printf '%s' "$*" > "$HERMES_TEST_BUFFER"
+        return 0
+    fi
+    builtin print "$@"
+}
+`,
+	// Like the real builtin, more than one argument is an error, so
+	// word-split output shows up as a failed placement
+	"fish": `function commandline
+    if test "$argv[1]" = "--"
+        set -e argv[1]
+    end
+    if test (count $argv) -ne 1
+        echo "commandline: expected one argument, got "(count $argv) >&2
+        return 1
+    end
+    printf '%s' $argv[1] > $HERMES_TEST_BUFFER
+end
+`,
+}
+
+// driver invokes the integration function with the script's arguments and
+// propagates its exit status
+var driver = map[string]string{
+	"bash": "hermes \"$@\"\nexit $?\n",
+	"zsh":  "hermes \"$@\"\nexit $?\n",
+	"fish": "hermes $argv\nexit $status\n",
+}
+
+// Fake configures the fake hermes binary
+type Fake struct {
+	Stdout   string // Printed on stdout (the generated command)
+	Stderr   string // Printed on stderr
+	ExitCode int
+	Input    string // Typed by the user in answer to the script's prompts
+}
+
+// Call is a single invocation of the fake hermes binary
+type Call struct {
+	Args        string
+	Integration bool // HERMES_SHELL_INTEGRATION=1 was set
+}
+
+// Result captures what the integration function did
+type Result struct {
+	Buffer    string // Text placed in the command line buffer
+	BufferSet bool   // Whether anything was placed in the buffer at all
+	Stdout    string
+	Stderr    string
+	ExitCode  int
+	Calls     []Call
+}
+
+// Shells lists the shells the harness knows how to drive
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Run sources script in the named shell, calls the hermes function with
+// args and reports the outcome. The test is skipped when the shell is not
+// installed.
+func Run(t testing.TB, shell, script string, fake Fake, args ...string) Result {
+	t.Helper()
+
+	shellPath, err := exec.LookPath(shell)
+	if err != nil {
+		t.Skipf("%s not installed", shell)
+	}
+	if _, ok := stubs[shell]; !ok {
+		t.Fatalf("shelltest: unsupported shell %q", shell)
+	}
+
+	dir := t.TempDir()
+	binDir := filepath.Join(dir, "bin")
+	if err := os.Mkdir(binDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "hermes"), []byte(fakeHermes), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	driverPath := filepath.Join(dir, "driver."+shell)
+	if err := os.WriteFile(driverPath, []byte(stubs[shell]+script+"\n"+driver[shell]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bufferPath := filepath.Join(dir, "buffer")
+	logPath := filepath.Join(dir, "calls.log")
+
+	cmd := exec.Command(shellPath, append([]string{driverPath}, args...)...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(fake.Input)
+	cmd.Env = []string{
+		"PATH=" + binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TERM=dumb",
+		"HERMES_TEST_BUFFER=" + bufferPath,
+		"HERMES_FAKE_LOG=" + logPath,
+		"HERMES_FAKE_STDOUT=" + fake.Stdout,
+		"HERMES_FAKE_STDERR=" + fake.Stderr,
+		"HERMES_FAKE_EXIT=" + strconv.Itoa(fake.ExitCode),
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	result := Result{}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("shelltest: running %s: %v", shell, err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	if data, err := os.ReadFile(bufferPath); err == nil {
+		result.Buffer = string(data)
+		result.BufferSet = true
+	}
+	if data, err := os.ReadFile(logPath); err == nil {
+		for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			integration, args, _ := strings.Cut(line, "\t")
+			result.Calls = append(result.Calls, Call{Args: args, Integration: integration == "1"})
+		}
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+// Package sysfile - crontabs
+package sysfile
+
+import (
+	"strings"
+
+	"hermes/internal/cron"
+)
+
+// crontabVariables describes the variables cron itself interprets
+var crontabVariables = map[string]string{
+	"SHELL":        "shell that runs the commands (default /bin/sh)",
+	"PATH":         "search path for the commands; cron's default is minimal, so scripts often fail without it",
+	"MAILTO":       "where cron mails the commands' output (empty: discard it)",
+	"MAILFROM":     "sender address of cron's mails",
+	"HOME":         "home directory the commands run in",
+	"CRON_TZ":      "time zone the schedules are interpreted in",
+	"RANDOM_DELAY": "maximum random delay in minutes added to every job",
+}
+
+// crontab explains each entry's schedule and command
+func (e *Explanation) crontab(text string) []section {
+	e.Complete = true
+	var sections []section
+	for _, line := range contentLines(text) {
+		if schedule, command, ok := cron.Split(line); ok {
+			e.Commands = append(e.Commands, command)
+			sections = append(sections, section{
+				text:    schedule.Describe(),
+				details: []string{"runs: " + command},
+			})
+			if unescapedPercent(command) {
+				e.Warnings = append(e.Warnings, "unescaped % in a crontab command starts standard input: escape it as \\% ("+command+")")
+			}
+			continue
+		}
+
+		name, value, _ := strings.Cut(line, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		description, known := crontabVariables[name]
+		if !known {
+			description = "environment variable for the commands"
+		}
+		sections = append(sections, section{text: name + "=" + value + ": " + description})
+	}
+	return sections
+}
+
+// unescapedPercent reports whether a crontab command has a % that is not
+// escaped as \%
+func unescapedPercent(command string) bool {
+	for i := 0; i < len(command); i++ {
+		switch command[i] {
+		case '\\':
+			i++
+		case '%':
+			return true
+		}
+	}
+	return false
+}
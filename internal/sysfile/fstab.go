@@ -0,0 +1,194 @@
+// Package sysfile - fstab entries
+package sysfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fsTypes describes the common file system types
+var fsTypes = map[string]string{
+	"ext2":     "ext2 file system",
+	"ext3":     "ext3 file system",
+	"ext4":     "ext4 file system",
+	"xfs":      "XFS file system",
+	"btrfs":    "Btrfs file system",
+	"zfs":      "ZFS dataset",
+	"vfat":     "FAT file system (EFI partitions, USB sticks)",
+	"exfat":    "exFAT file system",
+	"ntfs":     "NTFS file system",
+	"ntfs-3g":  "NTFS file system through the ntfs-3g driver",
+	"iso9660":  "CD/DVD image",
+	"nfs":      "NFS network share",
+	"nfs4":     "NFSv4 network share",
+	"cifs":     "SMB/CIFS network share",
+	"smbfs":    "SMB network share",
+	"sshfs":    "remote directory over SSH (FUSE)",
+	"fuse":     "FUSE file system",
+	"swap":     "swap space",
+	"tmpfs":    "RAM-backed temporary file system",
+	"proc":     "process information pseudo file system",
+	"sysfs":    "kernel object pseudo file system",
+	"devpts":   "pseudo-terminal devices",
+	"overlay":  "overlay of several directories",
+	"none":     "no file system (bind mounts)",
+	"auto":     "file system type detected at mount time",
+	"squashfs": "read-only compressed image",
+}
+
+// networkTypes are file systems served over the network
+var networkTypes = map[string]bool{"nfs": true, "nfs4": true, "cifs": true, "smbfs": true, "sshfs": true}
+
+// fstabOptions describes the common mount options; options with a value
+// are keyed by their name followed by =
+var fstabOptions = map[string]string{
+	"defaults":                 "the default options (rw, suid, dev, exec, auto, nouser, async)",
+	"rw":                       "read-write",
+	"ro":                       "read-only",
+	"auto":                     "mounted at boot and by mount -a",
+	"noauto":                   "not mounted at boot; mount it explicitly",
+	"nofail":                   "boot continues if the device is missing",
+	"user":                     "any user may mount it (implies noexec, nosuid, nodev)",
+	"users":                    "any user may mount and unmount it",
+	"nouser":                   "only root may mount it",
+	"owner":                    "the device owner may mount it",
+	"exec":                     "programs on it may be executed",
+	"noexec":                   "programs on it cannot be executed",
+	"suid":                     "setuid bits take effect",
+	"nosuid":                   "setuid bits are ignored",
+	"dev":                      "device files on it work",
+	"nodev":                    "device files on it are ignored",
+	"sync":                     "writes go to the device immediately (slow)",
+	"async":                    "writes are buffered",
+	"atime":                    "access times are updated on every read",
+	"noatime":                  "access times are never updated (fewer writes)",
+	"nodiratime":               "directory access times are not updated",
+	"relatime":                 "access times are updated only when older than the modification time",
+	"discard":                  "TRIM is sent to the SSD on every delete",
+	"_netdev":                  "the device needs the network; mounted after networking is up",
+	"bind":                     "bind mount: the same directory tree visible at a second place",
+	"rbind":                    "recursive bind mount",
+	"sw":                       "use as swap",
+	"x-systemd.automount":      "mounted on first access instead of at boot",
+	"x-systemd.idle-timeout":   "unmounted after this much idle time",
+	"x-systemd.device-timeout": "how long systemd waits for the device",
+	"x-systemd.mount-timeout":  "how long systemd waits for the mount",
+	"x-systemd.requires":       "unit the mount requires",
+	"uid=":                     "owner of the files, for file systems without Unix permissions",
+	"gid=":                     "group of the files",
+	"umask=":                   "permissions masked out of the files",
+	"dmask=":                   "permissions masked out of directories",
+	"fmask=":                   "permissions masked out of files",
+	"errors=":                  "what to do on file system errors (remount-ro: switch to read-only)",
+	"size=":                    "maximum size",
+	"mode=":                    "permissions of the mount point",
+	"credentials=":             "file holding the share's user name and password",
+	"username=":                "user name for the share",
+	"password=":                "password for the share",
+	"vers=":                    "protocol version",
+	"subvol=":                  "Btrfs subvolume to mount",
+	"compress=":                "Btrfs compression algorithm",
+	"pri=":                     "swap priority",
+	"timeo=":                   "NFS timeout in tenths of a second",
+	"retrans=":                 "NFS retries before a major timeout",
+	"hard":                     "NFS operations retry forever when the server is unreachable",
+	"soft":                     "NFS operations fail after retries (may corrupt data)",
+	"iocharset=":               "character set for file names",
+	"lowerdir=":                "read-only lower layers of the overlay",
+	"upperdir=":                "writable upper layer of the overlay",
+	"workdir=":                 "overlay work directory",
+}
+
+// isFstab reports whether every line looks like an fstab entry: a device,
+// an absolute mount point (or none for swap) and a file system type
+func isFstab(lines []string) bool {
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || len(fields) > 6 {
+			return false
+		}
+		if !strings.HasPrefix(fields[1], "/") && fields[1] != "none" && fields[1] != "swap" {
+			return false
+		}
+		if _, known := fsTypes[fields[2]]; !known && !strings.HasPrefix(fields[2], "fuse.") {
+			return false
+		}
+	}
+	return true
+}
+
+// fstab explains each mount entry and its options
+func (e *Explanation) fstab(text string) []section {
+	e.Complete = true
+	var sections []section
+	for _, line := range contentLines(text) {
+		fields := strings.Fields(line)
+		for len(fields) < 6 {
+			fields = append(fields, "0")
+		}
+		device, mountPoint, fsType, options, dump, pass := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+
+		s := section{text: fmt.Sprintf("%s on %s: %s", describeDevice(device), mountPoint, fsTypes[fsType])}
+		if fsType == "swap" {
+			s.text = describeDevice(device) + ": " + fsTypes[fsType]
+		}
+		if fsTypes[fsType] == "" {
+			s.text += fsType + " file system"
+		}
+
+		nofail := false
+		for _, option := range strings.Split(options, ",") {
+			name, _, hasValue := strings.Cut(option, "=")
+			key := name
+			if hasValue {
+				key += "="
+			}
+			description, known := fstabOptions[key]
+			if !known {
+				description = "option not in the offline database"
+				e.Complete = false
+			}
+			s.details = append(s.details, option+": "+description)
+			switch name {
+			case "nofail", "_netdev", "noauto", "x-systemd.automount":
+				nofail = true
+			case "password":
+				e.Warnings = append(e.Warnings, mountPoint+": the password is stored in /etc/fstab, which every user can read; use credentials= with a root-only file")
+			}
+		}
+		if networkTypes[fsType] && !nofail {
+			e.Warnings = append(e.Warnings, mountPoint+": a network share without nofail or _netdev can hang the boot when the server is unreachable")
+		}
+
+		if dump != "0" {
+			s.details = append(s.details, "dump "+dump+": backed up by the legacy dump program")
+		}
+		switch pass {
+		case "0":
+			s.details = append(s.details, "pass 0: never checked by fsck at boot")
+		case "1":
+			s.details = append(s.details, "pass 1: checked by fsck first (the root file system)")
+		default:
+			s.details = append(s.details, "pass "+pass+": checked by fsck at boot after the root file system")
+		}
+		sections = append(sections, s)
+	}
+	return sections
+}
+
+// describeDevice names the device of an fstab entry
+func describeDevice(device string) string {
+	switch {
+	case strings.HasPrefix(device, "UUID="):
+		return "the partition with UUID " + strings.TrimPrefix(device, "UUID=")
+	case strings.HasPrefix(device, "PARTUUID="):
+		return "the partition with PARTUUID " + strings.TrimPrefix(device, "PARTUUID=")
+	case strings.HasPrefix(device, "LABEL="):
+		return "the file system labeled " + strings.TrimPrefix(device, "LABEL=")
+	case strings.HasPrefix(device, "//"):
+		return "the SMB share " + device
+	case strings.Contains(device, ":") && !strings.HasPrefix(device, "/"):
+		return "the network share " + device
+	}
+	return device
+}
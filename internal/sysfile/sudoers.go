@@ -0,0 +1,276 @@
+// Package sysfile - sudoers entries
+package sysfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// userSpec matches a sudoers user specification:
+	// who where = (runas) TAGS: commands
+	userSpec = regexp.MustCompile(`^(\S+)\s+(\S+)\s*=\s*(?:\(([^)]*)\)\s*)?((?:[A-Z_]+:\s*)*)(.+)$`)
+
+	// aliasDefinition matches a User_Alias, Runas_Alias, Host_Alias or Cmnd_Alias line
+	aliasDefinition = regexp.MustCompile(`^(User|Runas|Host|Cmnd|Cmd)_Alias\s+([A-Z][A-Z0-9_]*)\s*=\s*(.+)$`)
+
+	// includeDirective matches #include, #includedir, @include and @includedir
+	includeDirective = regexp.MustCompile(`^[#@](include|includedir)\s+(\S+)$`)
+)
+
+// sudoersTags describes the command tags of a user specification
+var sudoersTags = map[string]string{
+	"NOPASSWD":   "no password is asked",
+	"PASSWD":     "the password is asked",
+	"NOEXEC":     "the commands cannot start further programs",
+	"EXEC":       "the commands may start further programs",
+	"SETENV":     "the caller may keep their environment variables",
+	"NOSETENV":   "the environment is reset",
+	"LOG_INPUT":  "keyboard input is logged",
+	"LOG_OUTPUT": "output is logged",
+	"FOLLOW":     "sudoedit follows symbolic links",
+	"NOFOLLOW":   "sudoedit does not follow symbolic links",
+}
+
+// sudoersDefaults describes the common Defaults settings
+var sudoersDefaults = map[string]string{
+	"env_reset":         "run commands with a minimal environment",
+	"env_keep":          "environment variables kept despite env_reset",
+	"secure_path":       "PATH used for the commands",
+	"mail_badpass":      "mail the administrator on wrong passwords",
+	"requiretty":        "sudo only works from a real terminal",
+	"use_pty":           "run commands in their own pseudo-terminal",
+	"timestamp_timeout": "minutes before the password is asked again",
+	"passwd_tries":      "password attempts before sudo gives up",
+	"insults":           "insult users who mistype their password",
+	"lecture":           "when to show the first-use lecture",
+	"logfile":           "file sudo logs to",
+	"log_input":         "log keyboard input of every command",
+	"log_output":        "log output of every command",
+	"targetpw":          "ask for the target user's password instead of the caller's",
+	"rootpw":            "ask for root's password instead of the caller's",
+	"!authenticate":     "never ask for a password",
+	"authenticate":      "ask for a password",
+	"visiblepw":         "allow typing the password where it may be echoed",
+	"editor":            "editors visudo may use",
+	"badpass_message":   "message shown on a wrong password",
+}
+
+// isSudoers reports whether every line is a sudoers user specification,
+// Defaults line, alias definition or include directive
+func isSudoers(lines []string) bool {
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "Defaults"),
+			aliasDefinition.MatchString(line),
+			includeDirective.MatchString(line):
+		case userSpec.MatchString(line):
+			// Shell assignments like a=b also match; a user specification
+			// names a host (or ALL) before the =
+			match := userSpec.FindStringSubmatch(line)
+			if strings.ContainsAny(match[1], "=$") || strings.Contains(match[2], "=") || !sudoCommands(match[5]) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sudoCommands reports whether a command list looks like sudoers commands:
+// ALL, aliases, sudoedit and absolute paths, each possibly negated
+func sudoCommands(commands string) bool {
+	for _, command := range strings.Split(commands, ",") {
+		command = strings.TrimPrefix(strings.TrimSpace(command), "!")
+		if command != "sudoedit" && !strings.HasPrefix(command, "sudoedit ") && !strings.HasPrefix(command, "/") && !sudoAlias.MatchString(command) {
+			return false
+		}
+	}
+	return true
+}
+
+// sudoAlias matches ALL and alias names
+var sudoAlias = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// sudoers explains each rule: who may run what, as whom, on which hosts
+func (e *Explanation) sudoers(text string) []section {
+	e.Complete = true
+	var sections []section
+	for _, line := range contentLines(joinContinuations(text)) {
+		if match := includeDirective.FindStringSubmatch(line); match != nil {
+			if match[1] == "includedir" {
+				sections = append(sections, section{text: "read every file in " + match[2] + " as further rules"})
+			} else {
+				sections = append(sections, section{text: "read " + match[2] + " as further rules"})
+			}
+			continue
+		}
+		if match := aliasDefinition.FindStringSubmatch(line); match != nil {
+			kind := map[string]string{"User": "users", "Runas": "target users", "Host": "hosts", "Cmnd": "commands", "Cmd": "commands"}[match[1]]
+			sections = append(sections, section{text: match[2] + " names the " + kind + " " + strings.TrimSpace(match[3])})
+			continue
+		}
+		if strings.HasPrefix(line, "Defaults") {
+			sections = append(sections, e.sudoersDefaults(line))
+			continue
+		}
+		match := userSpec.FindStringSubmatch(line)
+		if match == nil {
+			sections = append(sections, section{text: line + ": not a sudoers rule"})
+			e.Complete = false
+			continue
+		}
+		sections = append(sections, e.userSpec(match[1], match[2], match[3], match[4], match[5]))
+	}
+	return sections
+}
+
+// sudoersDefaults explains a Defaults line
+func (e *Explanation) sudoersDefaults(line string) section {
+	scope, settings := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		scope, settings = line[:i], line[i+1:]
+	}
+	s := section{text: "default settings"}
+	switch {
+	case strings.HasPrefix(scope, "Defaults:"):
+		s.text += " for the users " + strings.TrimPrefix(scope, "Defaults:")
+	case strings.HasPrefix(scope, "Defaults@"):
+		s.text += " on the hosts " + strings.TrimPrefix(scope, "Defaults@")
+	case strings.HasPrefix(scope, "Defaults>"):
+		s.text += " when running as " + strings.TrimPrefix(scope, "Defaults>")
+	case strings.HasPrefix(scope, "Defaults!"):
+		s.text += " for the commands " + strings.TrimPrefix(scope, "Defaults!")
+	}
+	for _, setting := range splitSettings(settings) {
+		name, _, _ := strings.Cut(setting, "=")
+		name = strings.TrimRight(strings.TrimSpace(name), "+-")
+		description, known := sudoersDefaults[name]
+		if !known && strings.HasPrefix(name, "!") {
+			if description, known = sudoersDefaults[name[1:]]; known {
+				description = "turned off: " + description
+			}
+		}
+		if !known {
+			description = "setting not in the offline database"
+			e.Complete = false
+		}
+		switch name {
+		case "!env_reset":
+			e.Warnings = append(e.Warnings, "!env_reset passes the caller's environment (LD_PRELOAD, PATH) to commands run as root")
+		case "!authenticate":
+			e.Warnings = append(e.Warnings, "!authenticate lets the users run the commands without any password")
+		}
+		s.details = append(s.details, setting+": "+description)
+	}
+	return s
+}
+
+// splitSettings splits a Defaults line at the commas outside quotes
+func splitSettings(settings string) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i, c := range settings {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			parts = append(parts, strings.TrimSpace(settings[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(settings[start:]))
+}
+
+// userSpec explains a user specification
+func (e *Explanation) userSpec(who, hosts, runas, tags, commands string) section {
+	s := section{text: describeSudoUser(who) + " may run " + describeSudoCommands(commands)}
+	if hosts == "ALL" {
+		s.details = append(s.details, "on any host")
+	} else {
+		s.details = append(s.details, "on the hosts "+hosts)
+	}
+
+	switch runas {
+	case "":
+		s.details = append(s.details, "as root")
+	case "ALL", "ALL:ALL", "ALL : ALL":
+		s.details = append(s.details, "as any user and group")
+	default:
+		user, group, _ := strings.Cut(runas, ":")
+		user, group = strings.TrimSpace(user), strings.TrimSpace(group)
+		switch {
+		case user == "":
+			s.details = append(s.details, "as root with the group "+group)
+		case group == "":
+			s.details = append(s.details, "as "+user)
+		default:
+			s.details = append(s.details, "as "+user+" with the group "+group)
+		}
+	}
+
+	nopasswd := false
+	for _, tag := range strings.Split(tags, ":") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		description, known := sudoersTags[tag]
+		if !known {
+			description = "tag not in the offline database"
+			e.Complete = false
+		}
+		s.details = append(s.details, tag+": "+description)
+		nopasswd = nopasswd || tag == "NOPASSWD"
+	}
+
+	allCommands := strings.TrimSpace(commands) == "ALL"
+	switch {
+	case allCommands && nopasswd:
+		e.Warnings = append(e.Warnings, who+" gets full root access without a password")
+	case allCommands:
+		e.Warnings = append(e.Warnings, who+" gets full root access")
+	}
+	for _, command := range strings.Split(commands, ",") {
+		command = strings.TrimSpace(command)
+		if strings.HasSuffix(command, "*") || strings.Contains(command, "* ") {
+			e.Warnings = append(e.Warnings, "the wildcard in "+command+" also matches extra arguments, which may allow more than intended")
+		}
+		if program, _, _ := strings.Cut(command, " "); shellEscapes[program[strings.LastIndex(program, "/")+1:]] {
+			e.Warnings = append(e.Warnings, command+" can start a shell, which gives full access as the target user")
+		}
+	}
+	return s
+}
+
+// shellEscapes are programs that can start a shell or run arbitrary
+// commands, so allowing them is as good as allowing ALL
+var shellEscapes = map[string]bool{
+	"bash": true, "sh": true, "zsh": true, "su": true, "vi": true, "vim": true, "less": true, "more": true,
+	"find": true, "awk": true, "python": true, "python3": true, "perl": true, "env": true, "tee": true,
+}
+
+// describeSudoUser names the users of a rule
+func describeSudoUser(who string) string {
+	switch {
+	case who == "ALL":
+		return "every user"
+	case strings.HasPrefix(who, "%"):
+		return "members of the group " + strings.TrimPrefix(who, "%")
+	}
+	return who
+}
+
+// describeSudoCommands names the commands of a rule
+func describeSudoCommands(commands string) string {
+	commands = strings.TrimSpace(commands)
+	if commands == "ALL" {
+		return "any command"
+	}
+	var list []string
+	for _, command := range strings.Split(commands, ",") {
+		list = append(list, strings.TrimSpace(command))
+	}
+	return join(list)
+}
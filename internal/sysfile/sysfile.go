@@ -0,0 +1,134 @@
+// Package sysfile recognizes system configuration formats that are not
+// shell commands (systemd units, crontabs, fstab and sudoers entries) and
+// explains them offline, entry by entry
+package sysfile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"hermes/internal/cron"
+)
+
+// Format is a recognized configuration file format
+type Format string
+
+// Supported formats
+const (
+	Unit    Format = "systemd unit"
+	Crontab Format = "crontab"
+	Fstab   Format = "fstab"
+	Sudoers Format = "sudoers"
+)
+
+// Explanation is the offline explanation of a configuration file
+type Explanation struct {
+	Text     string   // One "• entry" line per entry with indented "  • detail" lines
+	Complete bool     // Every entry and setting was recognized
+	Commands []string // Commands the file runs (ExecStart=, crontab entries)
+	Warnings []string // Settings that deserve a second look
+}
+
+// section is one explained entry
+type section struct {
+	text    string
+	details []string
+}
+
+// unitSection matches a systemd section header
+var unitSection = regexp.MustCompile(`^\[(Unit|Service|Install|Timer|Socket|Mount|Automount|Path|Slice|Scope|Swap)\]$`)
+
+// Detect recognizes the format of a configuration file or line. Plain
+// shell commands are not recognized.
+func Detect(text string) (Format, bool) {
+	lines := contentLines(text)
+	if len(lines) == 0 {
+		return "", false
+	}
+	for _, line := range lines {
+		if unitSection.MatchString(line) {
+			return Unit, true
+		}
+	}
+	if isCrontab(lines) {
+		return Crontab, true
+	}
+	if isFstab(lines) {
+		return Fstab, true
+	}
+	if isSudoers(lines) {
+		return Sudoers, true
+	}
+	return "", false
+}
+
+// Explain explains a configuration file of a known format
+func Explain(format Format, text string) Explanation {
+	var e Explanation
+	var sections []section
+	switch format {
+	case Unit:
+		sections = e.unit(text)
+	case Crontab:
+		sections = e.crontab(text)
+	case Fstab:
+		sections = e.fstab(text)
+	case Sudoers:
+		sections = e.sudoers(text)
+	}
+
+	var b strings.Builder
+	for _, s := range sections {
+		fmt.Fprintf(&b, "• %s\n", s.text)
+		for _, detail := range s.details {
+			fmt.Fprintf(&b, "  • %s\n", detail)
+		}
+	}
+	e.Text = b.String()
+	return e
+}
+
+// contentLines returns the non-empty lines that are not comments. The
+// sudoers #include directives look like comments and are kept.
+func contentLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || (strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#include")) || strings.HasPrefix(line, ";") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// isCrontab reports whether every line is a crontab entry or variable
+// assignment, with at least one entry
+func isCrontab(lines []string) bool {
+	entries := 0
+	for _, line := range lines {
+		if _, _, ok := cron.Split(line); ok {
+			entries++
+			continue
+		}
+		if !envAssignment.MatchString(line) {
+			return false
+		}
+	}
+	return entries > 0
+}
+
+// envAssignment matches a crontab variable assignment
+var envAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// join lists items in a sentence
+func join(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,125 @@
+package sysfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const backupUnit = `# /etc/systemd/system/backup.service
+[Unit]
+Description=Nightly backup
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart=/usr/local/bin/backup.sh \
+    --target /mnt/backup
+ProtectSystem=strict
+
+[Install]
+WantedBy=multi-user.target
+`
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want Format
+		ok   bool
+	}{
+		{backupUnit, Unit, true},
+		{"MAILTO=ops@example.com\n30 6 * * 1-5 /opt/backup.sh\n", Crontab, true},
+		{"@reboot /usr/bin/startup", Crontab, true},
+		{"UUID=1234-abcd / ext4 defaults 0 1\n/dev/sdb1 none swap sw 0 0\n", Fstab, true},
+		{"//server/share /mnt/share cifs credentials=/root/.smb 0 0", Fstab, true},
+		{"%wheel ALL=(ALL:ALL) ALL", Sudoers, true},
+		{"Defaults env_reset\nalice ALL=(root) NOPASSWD: /usr/bin/systemctl restart nginx\n#includedir /etc/sudoers.d\n", Sudoers, true},
+		{"ls -la", "", false},
+		{"echo a = b", "", false},
+		{"FOO=bar make", "", false},
+		{"mount -t ext4 /dev/sdb1 /mnt", "", false},
+		{"# only a comment\n", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Detect(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Detect(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExplainUnit(t *testing.T) {
+	e := Explain(Unit, backupUnit)
+	for _, want := range []string{
+		"Description=Nightly backup",
+		"Type=oneshot",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(e.Text, want) {
+			t.Errorf("explanation misses %q:\n%s", want, e.Text)
+		}
+	}
+	if want := []string{"/usr/local/bin/backup.sh --target /mnt/backup"}; !reflect.DeepEqual(e.Commands, want) {
+		t.Errorf("Commands = %q, want %q", e.Commands, want)
+	}
+	if !hasWarning(e, "runs as root") {
+		t.Errorf("Warnings = %q, want the missing User= warning", e.Warnings)
+	}
+}
+
+func TestExplainCrontab(t *testing.T) {
+	e := Explain(Crontab, "PATH=/usr/bin:/bin\n0 3 * * * date +%F >> /tmp/log\n")
+	if !e.Complete {
+		t.Errorf("Complete = false, want true")
+	}
+	if want := []string{"date +%F >> /tmp/log"}; !reflect.DeepEqual(e.Commands, want) {
+		t.Errorf("Commands = %q, want %q", e.Commands, want)
+	}
+	if !strings.Contains(e.Text, "PATH=/usr/bin:/bin: search path") {
+		t.Errorf("explanation misses the PATH variable:\n%s", e.Text)
+	}
+	if !hasWarning(e, "unescaped %") {
+		t.Errorf("Warnings = %q, want the unescaped %% warning", e.Warnings)
+	}
+}
+
+func TestExplainFstab(t *testing.T) {
+	e := Explain(Fstab, "server:/export /mnt/nfs nfs defaults,noatime 0 0\n")
+	for _, want := range []string{"the network share server:/export on /mnt/nfs", "noatime:", "pass 0"} {
+		if !strings.Contains(e.Text, want) {
+			t.Errorf("explanation misses %q:\n%s", want, e.Text)
+		}
+	}
+	if !hasWarning(e, "can hang the boot") {
+		t.Errorf("Warnings = %q, want the nofail warning", e.Warnings)
+	}
+}
+
+func TestExplainSudoers(t *testing.T) {
+	e := Explain(Sudoers, "Defaults !env_reset\n%admin ALL=(ALL) NOPASSWD: ALL\nbob ALL=(www-data) /usr/bin/vim /var/www/*\n")
+	for _, want := range []string{
+		"members of the group admin may run any command",
+		"as any user and group",
+		"NOPASSWD: no password is asked",
+		"bob may run /usr/bin/vim /var/www/*",
+		"as www-data",
+	} {
+		if !strings.Contains(e.Text, want) {
+			t.Errorf("explanation misses %q:\n%s", want, e.Text)
+		}
+	}
+	for _, want := range []string{"!env_reset", "full root access without a password", "wildcard", "can start a shell"} {
+		if !hasWarning(e, want) {
+			t.Errorf("Warnings = %q, want one about %q", e.Warnings, want)
+		}
+	}
+}
+
+func hasWarning(e Explanation, substr string) bool {
+	for _, warning := range e.Warnings {
+		if strings.Contains(warning, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Package sysfile - systemd unit files
+package sysfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+// unitSections describes what each unit file section configures
+var unitSections = map[string]string{
+	"Unit":      "[Unit]: generic information and dependencies",
+	"Service":   "[Service]: how the service process runs",
+	"Install":   "[Install]: what systemctl enable hooks the unit into",
+	"Timer":     "[Timer]: when the timer activates its unit",
+	"Socket":    "[Socket]: the socket that activates the service on demand",
+	"Mount":     "[Mount]: the file system to mount",
+	"Automount": "[Automount]: the mount point that triggers a mount on access",
+	"Path":      "[Path]: the paths whose changes activate the unit",
+	"Slice":     "[Slice]: resource limits shared by the units in the slice",
+	"Scope":     "[Scope]: resource limits for externally started processes",
+	"Swap":      "[Swap]: the swap device or file to activate",
+}
+
+// unitDirectives describes the common unit file settings
+var unitDirectives = map[string]string{
+	"Description":           "human-readable name shown by systemctl status",
+	"Documentation":         "where the unit's documentation lives",
+	"After":                 "start after these units (ordering only, not a dependency)",
+	"Before":                "start before these units",
+	"Requires":              "hard dependency: start these too and stop if they stop",
+	"Requisite":             "fail unless these units are already active",
+	"Wants":                 "soft dependency: start these too but keep running if they fail",
+	"BindsTo":               "stop when these units stop",
+	"PartOf":                "restart and stop together with these units",
+	"Conflicts":             "stop these units when this one starts, and the reverse",
+	"ConditionPathExists":   "skip starting unless this path exists",
+	"StartLimitIntervalSec": "window for counting restarts against StartLimitBurst",
+	"StartLimitBurst":       "give up after this many starts within StartLimitIntervalSec",
+	"Type":                  "how systemd decides the service has started (simple, exec, forking, oneshot, notify, dbus, idle)",
+	"ExecStart":             "the command that starts the service",
+	"ExecStartPre":          "command run before ExecStart",
+	"ExecStartPost":         "command run after ExecStart",
+	"ExecStop":              "command run to stop the service (otherwise it is sent KillSignal)",
+	"ExecStopPost":          "command run after the service stopped",
+	"ExecReload":            "command run by systemctl reload",
+	"Restart":               "when systemd restarts the process after it exits (no, on-failure, on-abnormal, always, ...)",
+	"RestartSec":            "delay before a restart",
+	"User":                  "user the processes run as",
+	"Group":                 "group the processes run as",
+	"DynamicUser":           "run as a temporary user allocated when the service starts",
+	"WorkingDirectory":      "working directory of the processes",
+	"RootDirectory":         "chroot the processes into this directory",
+	"Environment":           "environment variables for the processes",
+	"EnvironmentFile":       "file of environment variables for the processes (- prefix: optional)",
+	"TimeoutStartSec":       "how long to wait for startup before failing",
+	"TimeoutStopSec":        "how long to wait for a clean stop before killing with SIGKILL",
+	"TimeoutSec":            "start and stop timeout",
+	"KillMode":              "which processes are killed on stop (control-group, mixed, process, none)",
+	"KillSignal":            "signal sent to stop the service",
+	"PIDFile":               "PID file of a forking service",
+	"RemainAfterExit":       "treat the service as active after its process exits",
+	"StandardOutput":        "where stdout goes (journal, null, file:...)",
+	"StandardError":         "where stderr goes",
+	"StandardInput":         "where stdin comes from",
+	"SyslogIdentifier":      "name used for the service's log lines",
+	"Nice":                  "CPU scheduling priority",
+	"LimitNOFILE":           "maximum number of open files",
+	"LimitNPROC":            "maximum number of processes",
+	"MemoryMax":             "hard memory limit; the service is OOM-killed above it",
+	"MemoryHigh":            "memory limit above which the service is throttled",
+	"CPUQuota":              "CPU time limit as a percentage of one CPU",
+	"TasksMax":              "maximum number of tasks (processes and threads)",
+	"ProtectSystem":         "mount /usr, /boot and /etc read-only for the service (strict: the whole file system)",
+	"ProtectHome":           "hide or protect /home, /root and /run/user",
+	"PrivateTmp":            "give the service its own /tmp and /var/tmp",
+	"PrivateDevices":        "give the service a minimal /dev",
+	"PrivateNetwork":        "give the service its own network namespace with only loopback",
+	"NoNewPrivileges":       "the processes can never gain privileges (setuid binaries have no effect)",
+	"ReadWritePaths":        "paths that stay writable under ProtectSystem",
+	"ReadOnlyPaths":         "paths made read-only",
+	"CapabilityBoundingSet": "capabilities the processes may ever have",
+	"AmbientCapabilities":   "capabilities granted to the processes, even as a non-root user",
+	"OnCalendar":            "calendar schedule, e.g. daily or Mon..Fri 06:30",
+	"OnBootSec":             "delay after boot",
+	"OnStartupSec":          "delay after systemd started",
+	"OnUnitActiveSec":       "interval since the unit was last activated",
+	"OnUnitInactiveSec":     "interval since the unit last stopped",
+	"Persistent":            "catch up on runs missed while the machine was off",
+	"RandomizedDelaySec":    "random delay to spread out runs",
+	"AccuracySec":           "how precisely the timer fires",
+	"Unit":                  "the unit to activate instead of the one with the same name",
+	"ListenStream":          "TCP port or stream socket path to listen on",
+	"ListenDatagram":        "UDP port or datagram socket path to listen on",
+	"Accept":                "start one service instance per connection",
+	"What":                  "the device or resource to mount",
+	"Where":                 "the mount point",
+	"Options":               "mount options",
+	"PathExists":            "activate when this path exists",
+	"PathChanged":           "activate when this file is closed after writing",
+	"PathModified":          "activate when this file is written to",
+	"DirectoryNotEmpty":     "activate when this directory gets files",
+	"WantedBy":              "systemctl enable makes these targets want this unit (multi-user.target: start at boot)",
+	"RequiredBy":            "systemctl enable makes these units require this one",
+	"Alias":                 "extra names for the unit",
+	"Also":                  "units enabled and disabled together with this one",
+}
+
+// unit explains a systemd unit file section by section
+func (e *Explanation) unit(text string) []section {
+	e.Complete = true
+	var sections []section
+	current := -1
+	service, hasUser := false, false
+	for _, line := range contentLines(joinContinuations(text)) {
+		if match := unitSection.FindStringSubmatch(line); match != nil {
+			sections = append(sections, section{text: unitSections[match[1]]})
+			current = len(sections) - 1
+			service = service || match[1] == "Service"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || current < 0 {
+			sections = append(sections, section{text: line + ": not a unit file setting"})
+			e.Complete = false
+			continue
+		}
+
+		description, known := unitDirectives[key]
+		if !known {
+			description = "setting not in the offline database"
+			e.Complete = false
+		}
+		if strings.HasPrefix(key, "Exec") && value != "" {
+			// Prefixes such as - (ignore failure) and + (full privileges) are not part of the command
+			e.Commands = append(e.Commands, strings.TrimLeft(value, "-+!@:"))
+			if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "!") {
+				e.Warnings = append(e.Warnings, key+" runs with full privileges despite the sandboxing settings")
+			}
+		}
+		if key == "User" || key == "DynamicUser" {
+			hasUser = true
+		}
+		sections[current].details = append(sections[current].details, key+"="+value+": "+description)
+	}
+	if service && !hasUser {
+		e.Warnings = append(e.Warnings, "no User= setting: a system service runs as root")
+	}
+	return sections
+}
+
+// continuation matches a backslash line continuation and the next line's indentation
+var continuation = regexp.MustCompile(`[ \t]*\\\n[ \t]*`)
+
+// joinContinuations joins lines ending in a backslash with the next one
+func joinContinuations(text string) string {
+	return continuation.ReplaceAllString(text, " ")
+}
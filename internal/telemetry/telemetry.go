@@ -0,0 +1,216 @@
+// Package telemetry keeps strictly opt-in, anonymous usage counters:
+// how often each subcommand runs and how providers perform. Query text,
+// commands, paths and hostnames are never recorded.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// Modes for the telemetry setting
+const (
+	ModeOff   = "off"   // Nothing is recorded or sent (default)
+	ModeLocal = "local" // Counters are kept on this machine only, for `hermes telemetry show`
+	ModeOn    = "on"    // Counters are kept and sent to the endpoint once a day
+)
+
+// SendInterval is how often counters are sent in ModeOn
+const SendInterval = 24 * time.Hour
+
+// sendTimeout bounds how long sending may delay the CLI
+const sendTimeout = 3 * time.Second
+
+// maxLatencies bounds how many latency samples are kept per provider
+const maxLatencies = 1000
+
+// Store holds the counters accumulated since they were last sent
+type Store struct {
+	path string
+
+	Since     time.Time                 `json:"since"`
+	LastSent  time.Time                 `json:"last_sent,omitempty"`
+	Commands  map[string]int            `json:"commands"`
+	Providers map[string]*providerStats `json:"providers"`
+}
+
+// providerStats is the local record of one provider's calls
+type providerStats struct {
+	Calls     int   `json:"calls"`
+	Errors    int   `json:"errors"`
+	Latencies []int `json:"latencies_ms"`
+}
+
+// Payload is exactly what is sent to the endpoint
+type Payload struct {
+	Version   string                     `json:"version"`
+	OS        string                     `json:"os"`
+	Arch      string                     `json:"arch"`
+	Since     string                     `json:"since"` // Date only
+	Commands  map[string]int             `json:"commands"`
+	Providers map[string]ProviderSummary `json:"providers"`
+}
+
+// ProviderSummary aggregates a provider's calls without individual samples
+type ProviderSummary struct {
+	Calls        int `json:"calls"`
+	Errors       int `json:"errors"`
+	LatencyP50Ms int `json:"latency_p50_ms"`
+	LatencyP90Ms int `json:"latency_p90_ms"`
+}
+
+// DefaultPath returns the counter file under the XDG state directory
+func DefaultPath() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "hermes", "telemetry.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "hermes", "telemetry.json")
+}
+
+// Load reads the counters at path; a missing file starts empty counters
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read telemetry file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("invalid telemetry file %s: %w", path, err)
+		}
+	}
+	s.init(time.Now())
+	return s, nil
+}
+
+// init fills in empty counters
+func (s *Store) init(now time.Time) {
+	if s.Since.IsZero() {
+		s.Since = now
+	}
+	if s.Commands == nil {
+		s.Commands = map[string]int{}
+	}
+	if s.Providers == nil {
+		s.Providers = map[string]*providerStats{}
+	}
+}
+
+// RecordCommand counts one run of a subcommand
+func (s *Store) RecordCommand(name string) {
+	s.Commands[name]++
+}
+
+// RecordCall counts one provider call and its latency
+func (s *Store) RecordCall(provider string, latency time.Duration, failed bool) {
+	stats := s.Providers[provider]
+	if stats == nil {
+		stats = &providerStats{}
+		s.Providers[provider] = stats
+	}
+	stats.Calls++
+	if failed {
+		stats.Errors++
+	}
+	stats.Latencies = append(stats.Latencies, int(latency.Milliseconds()))
+	if len(stats.Latencies) > maxLatencies {
+		stats.Latencies = stats.Latencies[len(stats.Latencies)-maxLatencies:]
+	}
+}
+
+// Payload builds what would be sent for the current counters
+func (s *Store) Payload(version string) Payload {
+	p := Payload{
+		Version:   version,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Since:     s.Since.Format("2006-01-02"),
+		Commands:  s.Commands,
+		Providers: map[string]ProviderSummary{},
+	}
+	for name, stats := range s.Providers {
+		p.Providers[name] = ProviderSummary{
+			Calls:        stats.Calls,
+			Errors:       stats.Errors,
+			LatencyP50Ms: percentile(stats.Latencies, 50),
+			LatencyP90Ms: percentile(stats.Latencies, 90),
+		}
+	}
+	return p
+}
+
+// Due reports whether the counters should be sent now
+func (s *Store) Due(now time.Time) bool {
+	last := s.LastSent
+	if last.IsZero() {
+		last = s.Since
+	}
+	return now.Sub(last) >= SendInterval && len(s.Commands) > 0
+}
+
+// Send posts the payload to endpoint and resets the counters on success
+func (s *Store) Send(ctx context.Context, endpoint, version string) error {
+	body, err := json.Marshal(s.Payload(version))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid telemetry endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+
+	now := time.Now()
+	*s = Store{path: s.path, LastSent: now}
+	s.init(now)
+	return nil
+}
+
+// Save writes the counters back to their file
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create telemetry directory: %w", err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save telemetry file: %w", err)
+	}
+	return nil
+}
+
+// percentile returns the p-th percentile of samples, or 0 without samples
+func percentile(samples []int, p int) int {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), samples...)
+	sort.Ints(sorted)
+	return sorted[(len(sorted)-1)*p/100]
+}
@@ -0,0 +1,75 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPayload(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "telemetry.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.RecordCommand("generate")
+	s.RecordCommand("generate")
+	s.RecordCommand("explain")
+	for _, ms := range []int{100, 200, 300, 400, 1000} {
+		s.RecordCall("gemini", time.Duration(ms)*time.Millisecond, ms == 1000)
+	}
+
+	p := s.Payload("1.2.3")
+	if p.Commands["generate"] != 2 || p.Commands["explain"] != 1 {
+		t.Errorf("Commands = %v", p.Commands)
+	}
+	want := ProviderSummary{Calls: 5, Errors: 1, LatencyP50Ms: 300, LatencyP90Ms: 400}
+	if got := p.Providers["gemini"]; got != want {
+		t.Errorf("Providers[gemini] = %+v, want %+v", got, want)
+	}
+}
+
+func TestSaveLoadAndSend(t *testing.T) {
+	var received Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "latencies") {
+			t.Errorf("raw latency samples sent: %s", body)
+		}
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "state", "telemetry.json")
+	s, _ := Load(path)
+	s.RecordCommand("generate")
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if s.Due(time.Now()) {
+		t.Error("Due() right after the first run")
+	}
+	if !s.Due(time.Now().Add(SendInterval)) {
+		t.Error("Due() false after a day")
+	}
+
+	if err := s.Send(context.Background(), server.URL, "1.2.3"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if received.Commands["generate"] != 1 || received.Version != "1.2.3" {
+		t.Errorf("received %+v", received)
+	}
+	if len(s.Commands) != 0 || s.LastSent.IsZero() {
+		t.Errorf("counters not reset after sending: %+v", s)
+	}
+}
@@ -0,0 +1,90 @@
+// Package tips rate-limits hermes's hints and offers, so each one is shown
+// at most once per Interval no matter how often hermes runs
+package tips
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Interval is the least time between two showings of the same tip
+const Interval = 7 * 24 * time.Hour
+
+// Tips hermes rate-limits
+const (
+	ShellIntegration = "shell-integration" // Enable the shell integration
+	Setup            = "setup"             // First-run setup offer
+)
+
+// Store remembers when each tip was last shown in a state file shared by
+// all hermes processes
+type Store struct {
+	path string
+	now  func() time.Time
+}
+
+// New returns a store keeping its state in the file at path
+func New(path string) *Store {
+	return &Store{path: path, now: time.Now}
+}
+
+// DefaultPath returns the tips file under the XDG state directory
+func DefaultPath() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "hermes", "tips.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "hermes", "tips.json")
+}
+
+// Take reports whether tip is due and, if so, records it as shown now.
+// An unreadable state file shows the tip rather than hiding it forever;
+// a state file that cannot be written only means it may show again.
+func (s *Store) Take(tip string) bool {
+	shown, err := s.load()
+	if err != nil {
+		shown = map[string]time.Time{}
+	}
+	now := s.now()
+	if last, ok := shown[tip]; ok && now.Sub(last) < Interval {
+		return false
+	}
+	shown[tip] = now
+	_ = s.save(shown)
+	return true
+}
+
+// load reads the state file; a missing file means no tip was shown yet
+func (s *Store) load() (map[string]time.Time, error) {
+	shown := map[string]time.Time{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return shown, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tips file: %w", err)
+	}
+	if err := json.Unmarshal(data, &shown); err != nil {
+		return nil, fmt.Errorf("invalid tips file %s: %w", s.path, err)
+	}
+	return shown, nil
+}
+
+// save writes the state file atomically
+func (s *Store) save(shown map[string]time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create tips directory: %w", err)
+	}
+	data, _ := json.Marshal(shown) // Only plain values, cannot fail
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save tips file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
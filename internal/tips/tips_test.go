@@ -0,0 +1,50 @@
+package tips
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "tips.json")
+	store := New(path)
+	store.now = func() time.Time { return now }
+
+	if !store.Take(ShellIntegration) {
+		t.Fatal("Take() = false for a tip never shown")
+	}
+	if store.Take(ShellIntegration) {
+		t.Error("Take() = true right after showing the tip")
+	}
+	if !store.Take(Setup) {
+		t.Error("Take(Setup) = false, want tips limited separately")
+	}
+
+	// Another process sees the same state
+	other := New(path)
+	other.now = func() time.Time { return now.Add(6 * 24 * time.Hour) }
+	if other.Take(ShellIntegration) {
+		t.Error("Take() = true six days later, want at most once a week")
+	}
+	other.now = func() time.Time { return now.Add(Interval) }
+	if !other.Take(ShellIntegration) {
+		t.Error("Take() = false a week later")
+	}
+}
+
+func TestTakeCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tips.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := New(path)
+	if !store.Take(ShellIntegration) {
+		t.Error("Take() = false with a corrupt state file, want the tip shown")
+	}
+	if store.Take(ShellIntegration) {
+		t.Error("Take() = true after the state file was rewritten")
+	}
+}
@@ -0,0 +1,144 @@
+// Package toolver finds the tools a query mentions and asks the installed
+// binaries for their versions, so generated flags match what is installed
+package toolver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// versionTimeout bounds each version probe
+const versionTimeout = 2 * time.Second
+
+// maxTools bounds how many tools are probed per query
+const maxTools = 3
+
+// maxVersionLength bounds the version line included in the prompt
+const maxVersionLength = 120
+
+// tools maps names users write to the binary to probe and the arguments
+// that print its version. Only tools whose flags change between versions
+// are listed; probing every word of a query would run arbitrary binaries.
+var tools = map[string]struct {
+	binary string
+	args   []string
+}{
+	"ffmpeg":      {"ffmpeg", []string{"-version"}},
+	"ffprobe":     {"ffprobe", []string{"-version"}},
+	"imagemagick": {"magick", []string{"-version"}},
+	"magick":      {"magick", []string{"-version"}},
+	"git":         {"git", []string{"--version"}},
+	"docker":      {"docker", []string{"--version"}},
+	"podman":      {"podman", []string{"--version"}},
+	"kubectl":     {"kubectl", []string{"version", "--client"}},
+	"helm":        {"helm", []string{"version", "--short"}},
+	"terraform":   {"terraform", []string{"version"}},
+	"openssl":     {"openssl", []string{"version"}},
+	"rsync":       {"rsync", []string{"--version"}},
+	"tar":         {"tar", []string{"--version"}},
+	"curl":        {"curl", []string{"--version"}},
+	"jq":          {"jq", []string{"--version"}},
+	"yq":          {"yq", []string{"--version"}},
+	"python":      {"python3", []string{"--version"}},
+	"python3":     {"python3", []string{"--version"}},
+	"pip":         {"pip", []string{"--version"}},
+	"node":        {"node", []string{"--version"}},
+	"npm":         {"npm", []string{"--version"}},
+	"go":          {"go", []string{"version"}},
+	"aws":         {"aws", []string{"--version"}},
+	"gcloud":      {"gcloud", []string{"--version"}},
+	"az":          {"az", []string{"version"}},
+	"ssh":         {"ssh", []string{"-V"}},
+	"gpg":         {"gpg", []string{"--version"}},
+	"pandoc":      {"pandoc", []string{"--version"}},
+	"systemctl":   {"systemctl", []string{"--version"}},
+	"openssh":     {"ssh", []string{"-V"}},
+	"sed":         {"sed", []string{"--version"}},
+	"awk":         {"awk", []string{"--version"}},
+	"find":        {"find", []string{"--version"}},
+}
+
+// wordPattern splits a query into candidate tool names
+var wordPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9_+.-]*`)
+
+// lookPath and run are replaced in tests
+var (
+	lookPath = exec.LookPath
+	run      = func(ctx context.Context, binary string, args ...string) ([]byte, error) {
+		var out bytes.Buffer
+		cmd := exec.CommandContext(ctx, binary, args...)
+		cmd.Stdout = &out
+		cmd.Stderr = &out // ssh and java print their version to stderr
+		err := cmd.Run()
+		return out.Bytes(), err
+	}
+)
+
+// Mentioned returns the known tools a query names, in order of appearance.
+// Words like "find" only count when used as a tool name, so they must be
+// lowercase; "Find large files" does not probe find.
+func Mentioned(query string) []string {
+	var found []string
+	seen := map[string]bool{}
+	for _, word := range wordPattern.FindAllString(query, -1) {
+		word = strings.TrimRight(word, ".-")
+		tool, ok := tools[word]
+		if !ok || seen[tool.binary] {
+			continue
+		}
+		seen[tool.binary] = true
+		found = append(found, word)
+		if len(found) == maxTools {
+			break
+		}
+	}
+	return found
+}
+
+// Version runs the tool's version command and returns its first line
+func Version(ctx context.Context, name string) (string, error) {
+	tool, ok := tools[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+	path, err := lookPath(tool.binary)
+	if err != nil {
+		return "", fmt.Errorf("%s is not installed", tool.binary)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	out, err := run(ctx, path, tool.args...)
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if len(line) > maxVersionLength {
+				line = line[:maxVersionLength]
+			}
+			return line, nil
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s version check failed: %w", tool.binary, err)
+	}
+	return "", fmt.Errorf("%s printed no version", tool.binary)
+}
+
+// Context describes the installed versions of the tools a query mentions,
+// or returns "" when none are installed
+func Context(ctx context.Context, query string) string {
+	var lines []string
+	for _, name := range Mentioned(query) {
+		if version, err := Version(ctx, name); err == nil {
+			lines = append(lines, fmt.Sprintf("%s: %s", tools[name].binary, version))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Installed tool versions (use flags and syntax these versions support):\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,50 @@
+package toolver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMentioned(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"convert video.mkv to mp4 with ffmpeg", []string{"ffmpeg"}},
+		{"resize images with ImageMagick", nil}, // Tool names are matched as typed
+		{"resize images with imagemagick then commit with git", []string{"imagemagick", "git"}},
+		{"Find large files", nil},
+		{"use python and python3", []string{"python"}}, // Same binary probed once
+		{"list files", nil},
+	}
+	for _, tt := range tests {
+		if got := Mentioned(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Mentioned(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	origLookPath, origRun := lookPath, run
+	t.Cleanup(func() { lookPath, run = origLookPath, origRun })
+	lookPath = func(binary string) (string, error) {
+		if binary == "ffmpeg" {
+			return "/usr/bin/ffmpeg", nil
+		}
+		return "", errors.New("not found")
+	}
+	run = func(ctx context.Context, binary string, args ...string) ([]byte, error) {
+		return []byte("\nffmpeg version 4.4.2-0ubuntu0.22.04.1 Copyright (c) 2000-2021\nbuilt with gcc 11\n"), nil
+	}
+
+	got := Context(context.Background(), "convert video with ffmpeg and upload with rsync")
+	if !strings.Contains(got, "ffmpeg: ffmpeg version 4.4.2-0ubuntu0.22.04.1") {
+		t.Errorf("Context() = %q, want the ffmpeg version line", got)
+	}
+	if strings.Contains(got, "rsync") {
+		t.Errorf("Context() = %q mentions rsync, which is not installed", got)
+	}
+}
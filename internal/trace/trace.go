@@ -0,0 +1,273 @@
+// Package trace provides lightweight span tracing of the hermes pipeline with
+// an optional OTLP/HTTP (JSON) exporter
+package trace
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// exportTimeout bounds how long exporting spans may delay the CLI
+const exportTimeout = 3 * time.Second
+
+// Tracer collects the spans of a single hermes invocation
+type Tracer struct {
+	mu      sync.Mutex
+	traceID string
+	spans   []*Span
+}
+
+// Span is a single timed operation
+type Span struct {
+	tracer   *Tracer
+	id       string
+	parentID string
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    map[string]string
+	err      error
+}
+
+// tracerKey and spanKey are context keys for the active tracer and span
+type (
+	tracerKey struct{}
+	spanKey   struct{}
+)
+
+// New creates a tracer with a fresh trace ID
+func New() *Tracer {
+	return &Tracer{traceID: randomID(16)}
+}
+
+// WithTracer returns a context carrying the tracer
+func WithTracer(ctx context.Context, t *Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// Start begins a span as a child of the span in ctx. Without a tracer in
+// ctx it returns a nil span, whose methods are all no-ops.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	t, _ := ctx.Value(tracerKey{}).(*Tracer)
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		id:     randomID(8),
+		name:   name,
+		start:  time.Now(),
+		attrs:  map[string]string{},
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		span.parentID = parent.id
+	}
+
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttr records a string attribute on the span
+func (s *Span) SetAttr(key, value string) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.attrs[key] = value
+}
+
+// RecordError marks the span as failed
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.err = err
+}
+
+// End finishes the span
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if s.end.IsZero() {
+		s.end = time.Now()
+	}
+}
+
+// Timing returns the duration of the first finished span with the given
+// name and whether it failed; ok is false if there is no such span
+func (t *Tracer) Timing(name string) (d time.Duration, failed, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, span := range t.spans {
+		if span.name == name && !span.end.IsZero() {
+			return span.end.Sub(span.start), span.err != nil, true
+		}
+	}
+	return 0, false, false
+}
+
+// Summary writes a human-readable timing tree of all finished spans
+func (t *Tracer) Summary(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	children := map[string][]*Span{}
+	for _, span := range t.spans {
+		children[span.parentID] = append(children[span.parentID], span)
+	}
+	for _, list := range children {
+		sort.Slice(list, func(i, j int) bool { return list[i].start.Before(list[j].start) })
+	}
+
+	var walk func(parentID string, depth int)
+	walk = func(parentID string, depth int) {
+		for _, span := range children[parentID] {
+			if span.end.IsZero() {
+				continue
+			}
+			status := ""
+			if span.err != nil {
+				status = fmt.Sprintf(" (error: %v)", span.err)
+			}
+			fmt.Fprintf(w, "%s%-*s %8.1fms%s\n", strings.Repeat("  ", depth), 32-2*depth, span.name,
+				float64(span.end.Sub(span.start).Microseconds())/1000, status)
+			walk(span.id, depth+1)
+		}
+	}
+	walk("", 0)
+}
+
+// OTLP/JSON wire types (only the fields hermes emits)
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 2 = error
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// Export sends all finished spans to an OTLP/HTTP collector endpoint
+// (e.g., http://localhost:4318) using the JSON encoding
+func (t *Tracer) Export(ctx context.Context, endpoint string) error {
+	t.mu.Lock()
+	var spans []otlpSpan
+	for _, span := range t.spans {
+		if span.end.IsZero() {
+			continue
+		}
+		out := otlpSpan{
+			TraceID:           t.traceID,
+			SpanID:            span.id,
+			ParentSpanID:      span.parentID,
+			Name:              span.name,
+			Kind:              1, // SPAN_KIND_INTERNAL
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		}
+		for key, value := range span.attrs {
+			out.Attributes = append(out.Attributes, otlpAttribute{Key: key, Value: otlpValue{StringValue: value}})
+		}
+		if span.err != nil {
+			out.Status = otlpStatus{Code: 2, Message: span.err.Error()}
+		}
+		spans = append(spans, out)
+	}
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: "hermes"}},
+		}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "hermes"}, Spans: spans}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	url := strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// randomID returns n random bytes hex-encoded
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,249 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// collector is an httptest OTLP/HTTP endpoint recording what it receives
+type collector struct {
+	server *httptest.Server
+	path   string
+	header http.Header
+	body   map[string]interface{}
+	calls  int
+}
+
+func newCollector(t *testing.T, status int) *collector {
+	t.Helper()
+	c := &collector{}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.calls++
+		c.path = r.URL.Path
+		c.header = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &c.body); err != nil {
+			t.Errorf("collector got invalid JSON %s: %v", data, err)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(c.server.Close)
+	return c
+}
+
+// field walks decoded JSON by object keys and array indexes
+func field(t *testing.T, v interface{}, path ...interface{}) interface{} {
+	t.Helper()
+	for _, step := range path {
+		switch key := step.(type) {
+		case string:
+			object, ok := v.(map[string]interface{})
+			if !ok {
+				t.Fatalf("%v: not an object at %q", path, key)
+			}
+			v = object[key]
+		case int:
+			array, ok := v.([]interface{})
+			if !ok || key >= len(array) {
+				t.Fatalf("%v: no element %d", path, key)
+			}
+			v = array[key]
+		}
+	}
+	return v
+}
+
+func TestNoTracer(t *testing.T) {
+	ctx, span := Start(context.Background(), "generate")
+	if span != nil {
+		t.Fatalf("Start() without a tracer = %v, want nil span", span)
+	}
+	// A nil span is safe to use
+	span.SetAttr("key", "value")
+	span.RecordError(errors.New("boom"))
+	span.End()
+	if ctx != context.Background() {
+		t.Error("Start() without a tracer changed the context")
+	}
+}
+
+func TestExportShape(t *testing.T) {
+	c := newCollector(t, http.StatusOK)
+	tracer := New()
+	ctx := WithTracer(context.Background(), tracer)
+
+	ctx, root := Start(ctx, "generate")
+	root.SetAttr("hermes.target", "posix")
+	_, child := Start(ctx, "ai.request")
+	child.RecordError(errors.New("rate limited"))
+	child.End()
+	root.End()
+	// Unfinished spans are not exported
+	Start(ctx, "pending")
+
+	if err := tracer.Export(context.Background(), c.server.URL+"/"); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if c.path != "/v1/traces" {
+		t.Errorf("path = %q, want /v1/traces", c.path)
+	}
+	if got := c.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	resource := field(t, c.body, "resourceSpans", 0)
+	if got := field(t, resource, "resource", "attributes", 0, "key"); got != "service.name" {
+		t.Errorf("resource attribute key = %v, want service.name", got)
+	}
+	if got := field(t, resource, "resource", "attributes", 0, "value", "stringValue"); got != "hermes" {
+		t.Errorf("service.name = %v, want hermes", got)
+	}
+	if got := field(t, resource, "scopeSpans", 0, "scope", "name"); got != "hermes" {
+		t.Errorf("scope name = %v, want hermes", got)
+	}
+
+	spans := field(t, resource, "scopeSpans", 0, "spans").([]interface{})
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2 finished spans", len(spans))
+	}
+	byName := map[string]map[string]interface{}{}
+	for _, s := range spans {
+		span := s.(map[string]interface{})
+		byName[span["name"].(string)] = span
+	}
+	rootSpan, childSpan := byName["generate"], byName["ai.request"]
+	if rootSpan == nil || childSpan == nil {
+		t.Fatalf("spans = %v, want generate and ai.request", spans)
+	}
+
+	for name, span := range byName {
+		if id, _ := span["traceId"].(string); id != tracer.traceID || len(id) != 32 {
+			t.Errorf("%s traceId = %v, want the 32-digit trace ID %s", name, span["traceId"], tracer.traceID)
+		}
+		if id, _ := span["spanId"].(string); len(id) != 16 {
+			t.Errorf("%s spanId = %v, want 16 hex digits", name, span["spanId"])
+		}
+		if span["kind"] != float64(1) {
+			t.Errorf("%s kind = %v, want 1 (internal)", name, span["kind"])
+		}
+		// OTLP/JSON encodes 64-bit integers as strings
+		start, err := strconv.ParseInt(span["startTimeUnixNano"].(string), 10, 64)
+		if err != nil {
+			t.Errorf("%s startTimeUnixNano = %v: %v", name, span["startTimeUnixNano"], err)
+		}
+		end, err := strconv.ParseInt(span["endTimeUnixNano"].(string), 10, 64)
+		if err != nil || end < start {
+			t.Errorf("%s endTimeUnixNano = %v, want a time after %d", name, span["endTimeUnixNano"], start)
+		}
+	}
+
+	if _, ok := rootSpan["parentSpanId"]; ok {
+		t.Errorf("root span has parentSpanId %v, want it omitted", rootSpan["parentSpanId"])
+	}
+	if childSpan["parentSpanId"] != rootSpan["spanId"] {
+		t.Errorf("child parentSpanId = %v, want %v", childSpan["parentSpanId"], rootSpan["spanId"])
+	}
+	if got := field(t, rootSpan, "attributes", 0); got.(map[string]interface{})["key"] != "hermes.target" ||
+		field(t, got, "value", "stringValue") != "posix" {
+		t.Errorf("root attributes = %v, want hermes.target=posix", rootSpan["attributes"])
+	}
+	if _, ok := childSpan["attributes"]; ok {
+		t.Errorf("child attributes = %v, want them omitted", childSpan["attributes"])
+	}
+	if status := rootSpan["status"].(map[string]interface{}); len(status) != 0 {
+		t.Errorf("root status = %v, want an empty (unset) status", status)
+	}
+	if got := field(t, childSpan, "status", "code"); got != float64(2) {
+		t.Errorf("child status code = %v, want 2 (error)", got)
+	}
+	if got := field(t, childSpan, "status", "message"); got != "rate limited" {
+		t.Errorf("child status message = %v, want the error", got)
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	// Nothing finished: nothing is sent
+	c := newCollector(t, http.StatusOK)
+	tracer := New()
+	Start(WithTracer(context.Background(), tracer), "pending")
+	if err := tracer.Export(context.Background(), c.server.URL); err != nil || c.calls != 0 {
+		t.Errorf("Export() with no finished spans = %v after %d calls, want nil and no request", err, c.calls)
+	}
+
+	failing := newCollector(t, http.StatusServiceUnavailable)
+	tracer = New()
+	_, span := Start(WithTracer(context.Background(), tracer), "generate")
+	span.End()
+	if err := tracer.Export(context.Background(), failing.server.URL); err == nil {
+		t.Error("Export() to a failing collector = nil, want an error")
+	}
+	if err := tracer.Export(context.Background(), "://bad"); err == nil {
+		t.Error("Export() to an invalid endpoint = nil, want an error")
+	}
+}
+
+func TestTiming(t *testing.T) {
+	tracer := New()
+	ctx := WithTracer(context.Background(), tracer)
+	_, span := Start(ctx, "ai.request")
+	_, failed := Start(ctx, "safety")
+	failed.RecordError(errors.New("blocked"))
+	failed.End()
+
+	if _, _, ok := tracer.Timing("ai.request"); ok {
+		t.Error("Timing() of an unfinished span: ok = true, want false")
+	}
+	span.End()
+	span.start = span.end.Add(-250 * time.Millisecond)
+	d, isFailed, ok := tracer.Timing("ai.request")
+	if !ok || isFailed || d != 250*time.Millisecond {
+		t.Errorf("Timing(ai.request) = %v, %v, %v; want 250ms, false, true", d, isFailed, ok)
+	}
+	if _, isFailed, ok := tracer.Timing("safety"); !ok || !isFailed {
+		t.Errorf("Timing(safety) failed = %v, ok = %v; want true, true", isFailed, ok)
+	}
+	if _, _, ok := tracer.Timing("missing"); ok {
+		t.Error("Timing(missing): ok = true, want false")
+	}
+
+	// Ending twice keeps the first end time
+	end := span.end
+	span.End()
+	if span.end != end {
+		t.Errorf("second End() moved the end time from %v to %v", end, span.end)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tracer := New()
+	ctx := WithTracer(context.Background(), tracer)
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	ctx, root := Start(ctx, "generate")
+	_, second := Start(ctx, "safety")
+	_, first := Start(ctx, "ai.request")
+	Start(ctx, "pending")
+	second.RecordError(errors.New("blocked"))
+
+	// Children are listed by start time, not creation order
+	root.start, root.end = base, base.Add(1500*time.Millisecond)
+	first.start, first.end = base.Add(time.Millisecond), base.Add(1201*time.Millisecond)
+	second.start, second.end = base.Add(1300*time.Millisecond), base.Add(1312500*time.Microsecond)
+
+	var buf bytes.Buffer
+	tracer.Summary(&buf)
+	want := "generate                           1500.0ms\n" +
+		"  ai.request                       1200.0ms\n" +
+		"  safety                             12.5ms (error: blocked)\n"
+	if buf.String() != want {
+		t.Errorf("Summary() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
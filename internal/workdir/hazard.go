@@ -0,0 +1,105 @@
+package workdir
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// networkFilesystems are mount types backed by another machine, where a
+// deletion reaches everyone sharing the files
+var networkFilesystems = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "smbfs": true, "afs": true,
+	"ceph": true, "glusterfs": true, "lustre": true, "davfs": true, "fuse.sshfs": true,
+	"fuse.glusterfs": true, "fuse.rclone": true, "fuse.s3fs": true, "fuse.cephfs": true,
+}
+
+// mountInfoPath and gitStatus are replaced in tests
+var (
+	mountInfoPath = "/proc/self/mountinfo"
+	gitStatus     = func(ctx context.Context, dir string) (string, error) {
+		out, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain").Output()
+		return string(out), err
+	}
+)
+
+// Hazard returns why clearing dir would be worse than usual: it is the
+// root directory, on a network share, or a git repository with
+// uncommitted changes. It returns "" for an ordinary directory.
+func Hazard(ctx context.Context, dir string) string {
+	dir = filepath.Clean(dir)
+	if dir == "/" {
+		return "the root directory"
+	}
+	if fstype := mountType(mountInfoPath, dir); networkFilesystems[fstype] {
+		return "on a network share (" + fstype + ")"
+	}
+	if status, err := gitStatus(ctx, dir); err == nil && strings.TrimSpace(status) != "" {
+		return "in a git repository with uncommitted changes"
+	}
+	return ""
+}
+
+// mountType returns the filesystem type of the mount holding dir,
+// according to a mountinfo file, or "" when it can't tell (e.g., not on
+// Linux)
+func mountType(path, dir string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	// The longest mount point containing dir wins
+	best, fstype := -1, ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt/parent rw,noatime master:1 - ext3 /dev/root rw
+		fields := strings.Fields(scanner.Text())
+		separator := -1
+		for i, field := range fields {
+			if field == "-" {
+				separator = i
+				break
+			}
+		}
+		if len(fields) < 5 || separator < 0 || separator+1 >= len(fields) {
+			continue
+		}
+		mountPoint := unescapeMount(fields[4])
+		if !within(dir, mountPoint) || len(mountPoint) <= best {
+			continue
+		}
+		best, fstype = len(mountPoint), fields[separator+1]
+	}
+	return fstype
+}
+
+// within reports whether dir is mountPoint or below it
+func within(dir, mountPoint string) bool {
+	return mountPoint == "/" || dir == mountPoint || strings.HasPrefix(dir, mountPoint+"/")
+}
+
+// unescapeMount decodes the octal escapes (\040 for a space) of a
+// mountinfo path
+func unescapeMount(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+4 <= len(path) {
+			if n, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package workdir
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHazard(t *testing.T) {
+	mountinfo := filepath.Join(t.TempDir(), "mountinfo")
+	lines := "22 1 8:1 / / rw,relatime - ext4 /dev/sda1 rw\n" +
+		"40 22 0:45 / /mnt/team\\040share rw,relatime - nfs4 fs:/export rw\n" +
+		"41 40 0:46 / /mnt/team\\040share/local rw - tmpfs tmpfs rw\n"
+	if err := os.WriteFile(mountinfo, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dirty := map[string]bool{"/src/hermes": true}
+	mountInfoPath = mountinfo
+	gitStatus = func(_ context.Context, dir string) (string, error) {
+		if dirty[dir] {
+			return " M main.go\n", nil
+		}
+		return "", errors.New("not a git repository")
+	}
+	t.Cleanup(func() {
+		mountInfoPath = "/proc/self/mountinfo"
+		gitStatus = defaultGitStatus
+	})
+
+	tests := map[string]string{
+		"/":                         "the root directory",
+		"/mnt/team share/docs":      "on a network share (nfs4)",
+		"/mnt/team share/local/tmp": "",
+		"/mnt/team shared":          "",
+		"/src/hermes/":              "in a git repository with uncommitted changes",
+		"/home/ana":                 "",
+	}
+	for dir, want := range tests {
+		if got := Hazard(context.Background(), dir); got != want {
+			t.Errorf("Hazard(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
+
+var defaultGitStatus = gitStatus
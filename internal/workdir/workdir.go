@@ -0,0 +1,110 @@
+// Package workdir describes the current directory as generation context and
+// remembers, per directory, whether the user agreed to share it
+package workdir
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxNames bounds how many file names are listed
+const maxNames = 100
+
+// List returns the sorted names of the visible entries in dir, with a
+// trailing slash on directories, and the total number of visible entries.
+// Hidden files are skipped and at most maxNames names are returned.
+func List(dir string) ([]string, int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	total := len(names)
+	if total > maxNames {
+		names = names[:maxNames]
+	}
+	return names, total, nil
+}
+
+// Context formats a listing for the generation prompt
+func Context(names []string, total int) string {
+	var b strings.Builder
+	b.WriteString("Files in the current directory:\n")
+	b.WriteString(strings.Join(names, "\n"))
+	if total > len(names) {
+		fmt.Fprintf(&b, "\n(and %d more)", total-len(names))
+	}
+	return b.String()
+}
+
+// Consent records, per absolute directory, whether its listing may be sent
+// to the provider
+type Consent struct {
+	path      string
+	decisions map[string]bool
+}
+
+// DefaultConsentPath returns the decision file under the XDG state directory
+func DefaultConsentPath() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return filepath.Join(state, "hermes", "dir-consent.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "hermes", "dir-consent.json")
+}
+
+// LoadConsent reads the decision file at path. A missing file means no
+// decisions have been made yet.
+func LoadConsent(path string) (*Consent, error) {
+	c := &Consent{path: path, decisions: map[string]bool{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read consent file: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.decisions); err != nil {
+		return nil, fmt.Errorf("invalid consent file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Decision returns the remembered decision for dir and whether there is one
+func (c *Consent) Decision(dir string) (allowed, known bool) {
+	allowed, known = c.decisions[dir]
+	return allowed, known
+}
+
+// Record remembers the decision for dir and saves the file
+func (c *Consent) Record(dir string, allowed bool) error {
+	c.decisions[dir] = allowed
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create consent directory: %w", err)
+	}
+	data, _ := json.MarshalIndent(c.decisions, "", "  ") // Only strings and bools, cannot fail
+	if err := os.WriteFile(c.path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to save consent file: %w", err)
+	}
+	return nil
+}
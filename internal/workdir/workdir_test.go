@@ -0,0 +1,66 @@
+package workdir
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "README.md", ".env"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "internal"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	names, total, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []string{"README.md", "internal/", "main.go"}
+	if !reflect.DeepEqual(names, want) || total != 3 {
+		t.Errorf("List() = %v, %d; want %v, 3", names, total, want)
+	}
+}
+
+func TestContextTruncation(t *testing.T) {
+	got := Context([]string{"a", "b"}, 5)
+	if !strings.Contains(got, "a\nb") || !strings.Contains(got, "(and 3 more)") {
+		t.Errorf("Context() = %q", got)
+	}
+}
+
+func TestConsentRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "dir-consent.json")
+
+	consent, err := LoadConsent(path)
+	if err != nil {
+		t.Fatalf("LoadConsent() error = %v", err)
+	}
+	if _, known := consent.Decision("/work/secret-project"); known {
+		t.Fatal("Decision() known before anything was recorded")
+	}
+	if err := consent.Record("/work/secret-project", false); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := consent.Record("/work/oss", true); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	reloaded, err := LoadConsent(path)
+	if err != nil {
+		t.Fatalf("LoadConsent() error = %v", err)
+	}
+	if allowed, known := reloaded.Decision("/work/secret-project"); !known || allowed {
+		t.Errorf("Decision(secret-project) = %v, %v; want denied", allowed, known)
+	}
+	if allowed, known := reloaded.Decision("/work/oss"); !known || !allowed {
+		t.Errorf("Decision(oss) = %v, %v; want allowed", allowed, known)
+	}
+}
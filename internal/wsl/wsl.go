@@ -0,0 +1,130 @@
+// Package wsl detects Windows Subsystem for Linux and translates paths
+// between the Linux (/mnt/c/...) and Windows (C:\...) forms
+package wsl
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"hermes/internal/shell"
+)
+
+// Detect reports whether hermes is running under WSL
+func Detect() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" || os.Getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return false
+	}
+	release := strings.ToLower(string(data))
+	return strings.Contains(release, "microsoft") || strings.Contains(release, "wsl")
+}
+
+var (
+	linuxPathPattern   = regexp.MustCompile(`^/mnt/([a-zA-Z])(/.*)?$`)
+	windowsPathPattern = regexp.MustCompile(`^([a-zA-Z]):[\\/](.*)$`)
+)
+
+// ToWindows converts /mnt/c/Users/me to C:\Users\me
+func ToWindows(path string) (string, bool) {
+	match := linuxPathPattern.FindStringSubmatch(path)
+	if match == nil {
+		return path, false
+	}
+	rest := strings.ReplaceAll(strings.TrimPrefix(match[2], "/"), "/", `\`)
+	return strings.ToUpper(match[1]) + `:\` + rest, true
+}
+
+// ToLinux converts C:\Users\me (or C:/Users/me) to /mnt/c/Users/me
+func ToLinux(path string) (string, bool) {
+	match := windowsPathPattern.FindStringSubmatch(path)
+	if match == nil {
+		return path, false
+	}
+	rest := strings.TrimSuffix(strings.ReplaceAll(match[2], `\`, "/"), "/")
+	if rest == "" {
+		return "/mnt/" + strings.ToLower(match[1]), true
+	}
+	return "/mnt/" + strings.ToLower(match[1]) + "/" + rest, true
+}
+
+// TranslateCommand rewrites paths in a POSIX command so each stage gets the
+// form it understands: Windows interop binaries (*.exe) receive C:\ paths,
+// Linux tools receive /mnt/c paths. It reports whether anything changed.
+func TranslateCommand(command string) (string, bool) {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return command, false
+	}
+
+	type edit struct {
+		pos, end    int
+		replacement string
+	}
+	var edits []edit
+	for _, stage := range script.Stages() {
+		interop := strings.HasSuffix(strings.ToLower(stage.Name()), ".exe")
+		for _, arg := range stage.Args[1:] {
+			var translated string
+			var ok bool
+			if interop {
+				translated, ok = ToWindows(arg.Value)
+			} else {
+				translated, ok = ToLinux(arg.Value)
+			}
+			if ok {
+				edits = append(edits, edit{arg.Pos, arg.Pos + len(arg.Raw), quote(translated)})
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return command, false
+	}
+
+	// Apply right to left so earlier offsets stay valid
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		command = command[:e.pos] + e.replacement + command[e.end:]
+	}
+	return command, true
+}
+
+// quote single-quotes a path when it contains characters the shell would mangle
+func quote(path string) string {
+	if strings.ContainsAny(path, " \\'\"$`*?&;|<>()") {
+		return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	}
+	return path
+}
+
+// interopKeywords suggest the user is asking about Windows itself
+var interopKeywords = []string{
+	"windows", "clipboard", "explorer", "registry", "powershell", ".exe", "notepad", "winget", `c:\`,
+}
+
+// Context returns a prompt section describing the WSL environment and which
+// tools to prefer for the given query
+func Context(query string) string {
+	distro := os.Getenv("WSL_DISTRO_NAME")
+	if distro == "" {
+		distro = "Linux"
+	}
+
+	lines := []string{
+		"The user runs " + distro + " under Windows Subsystem for Linux (WSL).",
+		"Windows drives are mounted at /mnt/<drive letter> (C:\\ is /mnt/c); Linux tools need /mnt/c paths, Windows .exe tools need C:\\ paths.",
+	}
+
+	lowered := strings.ToLower(query)
+	for _, keyword := range interopKeywords {
+		if strings.Contains(lowered, keyword) {
+			lines = append(lines, "The request concerns Windows itself, so Windows interop tools (explorer.exe, clip.exe, powershell.exe, cmd.exe /c) are appropriate.")
+			return strings.Join(lines, "\n")
+		}
+	}
+	lines = append(lines, "Prefer Linux-native tools; only use Windows interop (.exe) tools when nothing Linux-native can do the job.")
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,58 @@
+package wsl
+
+import (
+	"testing"
+)
+
+func TestPathConversion(t *testing.T) {
+	tests := []struct {
+		linux   string
+		windows string
+	}{
+		{"/mnt/c/Users/me", `C:\Users\me`},
+		{"/mnt/d/data/file.txt", `D:\data\file.txt`},
+		{"/mnt/c", `C:\`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.linux, func(t *testing.T) {
+			if got, ok := ToWindows(tt.linux); !ok || got != tt.windows {
+				t.Errorf("ToWindows(%q) = %q, %v, want %q", tt.linux, got, ok, tt.windows)
+			}
+			if got, ok := ToLinux(tt.windows); !ok || got != tt.linux {
+				t.Errorf("ToLinux(%q) = %q, %v, want %q", tt.windows, got, ok, tt.linux)
+			}
+		})
+	}
+
+	if _, ok := ToWindows("/home/me"); ok {
+		t.Errorf("ToWindows translated a Linux-only path")
+	}
+	if _, ok := ToLinux("relative/path"); ok {
+		t.Errorf("ToLinux translated a relative path")
+	}
+}
+
+func TestTranslateCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		changed bool
+	}{
+		{"linux tool with windows path", `ls 'C:\Users\me\Downloads'`, "ls /mnt/c/Users/me/Downloads", true},
+		{"interop tool with linux path", "explorer.exe /mnt/c/Users/me", `explorer.exe 'C:\Users\me'`, true},
+		{"mixed pipeline", `cat 'C:\data\a.txt' | clip.exe`, "cat /mnt/c/data/a.txt | clip.exe", true},
+		{"nothing to translate", "ls -la /home/me", "ls -la /home/me", false},
+		{"unparseable command", "ls 'C:\\unterminated", "ls 'C:\\unterminated", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := TranslateCommand(tt.command)
+			if got != tt.want || changed != tt.changed {
+				t.Errorf("TranslateCommand(%q) = %q, %v, want %q, %v", tt.command, got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}